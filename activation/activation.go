@@ -0,0 +1,113 @@
+// Package activation reads the sockets passed to the process by systemd socket activation, see sd_listen_fds(3).
+// A unit started by a .socket unit receives its sockets as file descriptors from 3 up, described by the
+// LISTEN_PID, LISTEN_FDS and LISTEN_FDNAMES environment variables.
+package activation
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Error is a custom error type for the activation package.
+type Error string
+
+const (
+	ErrInvalidListenFDs = Error("invalid LISTEN_FDS environment variable")
+	ErrInvalidListenPID = Error("invalid LISTEN_PID environment variable")
+)
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// listenFDsStart is the first file descriptor passed by the service manager, SD_LISTEN_FDS_START.
+const listenFDsStart = 3
+
+// UnknownName is the name of the sockets passed without a FileDescriptorName, as set by systemd.
+const UnknownName = "unknown"
+
+// Files returns the sockets passed to the process keyed by their FileDescriptorName, several sockets can share
+// a name. It returns nil when the process was not socket activated. The environment variables are unset so child
+// processes do not take them as their own, which also means only the first call returns the sockets.
+// It always returns nil on platforms other than unix.
+func Files() (map[string][]*os.File, error) {
+	pid, count := os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS")
+	if !supported || count == "" {
+		return nil, nil
+	}
+	names := os.Getenv("LISTEN_FDNAMES")
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	fdNames, err := parseEnv(pid, count, names, os.Getpid())
+	if err != nil || fdNames == nil {
+		return nil, err
+	}
+
+	files := make(map[string][]*os.File, len(fdNames))
+	for i, name := range fdNames {
+		fd := listenFDsStart + i
+		closeOnExec(fd)
+		files[name] = append(files[name], os.NewFile(uintptr(fd), name))
+	}
+	return files, nil
+}
+
+// parseEnv returns the name of every socket passed to the process with the given pid, nil when they were
+// passed to another process, such as the parent of this one.
+func parseEnv(pid, count, names string, self int) ([]string, error) {
+	if pid != "" {
+		listenPID, err := strconv.Atoi(pid)
+		if err != nil {
+			return nil, ErrInvalidListenPID
+		}
+		if listenPID != self {
+			return nil, nil
+		}
+	}
+
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 0 {
+		return nil, ErrInvalidListenFDs
+	}
+
+	var given []string
+	if names != "" {
+		given = strings.Split(names, ":")
+	}
+
+	fdNames := make([]string, n)
+	for i := range fdNames {
+		fdNames[i] = UnknownName
+		if i < len(given) && given[i] != "" {
+			fdNames[i] = given[i]
+		}
+	}
+	return fdNames, nil
+}
+
+// Listeners returns the stream sockets passed to the process as listeners keyed by their name, see Files.
+// Datagram sockets and other files are skipped and closed.
+func Listeners() (map[string][]net.Listener, error) {
+	files, err := Files()
+	if err != nil || files == nil {
+		return nil, err
+	}
+
+	listeners := make(map[string][]net.Listener, len(files))
+	for name, fs := range files {
+		for _, f := range fs {
+			// FileListener duplicates the descriptor, the file is closed either way.
+			listener, err := net.FileListener(f)
+			f.Close()
+			if err != nil {
+				continue
+			}
+			listeners[name] = append(listeners[name], listener)
+		}
+	}
+	return listeners, nil
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package activation
+
+// supported reports whether sockets can be passed to the process on this platform.
+const supported = false
+
+// closeOnExec does nothing, socket activation is only supported on unix platforms.
+func closeOnExec(fd int) {}
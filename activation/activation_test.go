@@ -0,0 +1,45 @@
+package activation
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		pid     string
+		count   string
+		names   string
+		want    []string
+		wantErr error
+	}{
+		{"named", "42", "2", "http:grpc", []string{"http", "grpc"}, nil},
+		{"unnamed", "42", "2", "", []string{UnknownName, UnknownName}, nil},
+		{"fewer names", "42", "3", "http:", []string{"http", UnknownName, UnknownName}, nil},
+		{"without pid", "", "1", "http", []string{"http"}, nil},
+		{"another process", "7", "1", "http", nil, nil},
+		{"invalid pid", "self", "1", "", nil, ErrInvalidListenPID},
+		{"invalid count", "42", "-1", "", nil, ErrInvalidListenFDs},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEnv(tt.pid, tt.count, tt.names, 42)
+			if err != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFiles_NotActivated(t *testing.T) {
+	t.Setenv("LISTEN_FDS", "")
+	files, err := Files()
+	if files != nil || err != nil {
+		t.Errorf("expected no sockets, got %v, %v", files, err)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build unix
+
+package activation
+
+import "syscall"
+
+// supported reports whether sockets can be passed to the process on this platform.
+const supported = true
+
+// closeOnExec keeps the sockets from leaking into the child processes of the daemon.
+func closeOnExec(fd int) {
+	syscall.CloseOnExec(fd)
+}
@@ -0,0 +1,66 @@
+// Package cloudevents exports rxd lifecycle events in the CloudEvents 1.0 format,
+// so daemon events can be routed into existing event meshes without writing adapters.
+package cloudevents
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// Stable event types emitted by the exporter.
+const (
+	TypeServiceStateChanged = "io.rxd.service.state.changed"
+	TypeDaemonStarted       = "io.rxd.daemon.started"
+	TypeDaemonStopping      = "io.rxd.daemon.stopping"
+)
+
+const specVersion = "1.0"
+
+// Event is a CloudEvent in its structured json representation.
+type Event struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+}
+
+// StateChange is the data of a TypeServiceStateChanged event.
+type StateChange struct {
+	Service string `json:"service"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+}
+
+// NewEvent creates an event with a random id and the given data encoded as json.
+func NewEvent(source, eventType, subject string, data any) (Event, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Event{}, err
+	}
+
+	e := Event{
+		SpecVersion: specVersion,
+		ID:          hex.EncodeToString(id),
+		Source:      source,
+		Type:        eventType,
+		Subject:     subject,
+		Time:        time.Now().UTC(),
+	}
+
+	if data != nil {
+		b, err := json.Marshal(data)
+		if err != nil {
+			return Event{}, err
+		}
+		e.Data = b
+		e.DataContentType = "application/json"
+	}
+
+	return e, nil
+}
@@ -0,0 +1,92 @@
+package cloudevents
+
+import (
+	"context"
+	"time"
+
+	"github.com/ambitiousfew/rxd"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+var _ rxd.ServiceRunner = (*Exporter)(nil)
+
+// Exporter is a ServiceRunner that watches the states of all daemon services and
+// emits a TypeServiceStateChanged event for every transition, along with
+// TypeDaemonStarted and TypeDaemonStopping events when it starts and stops exporting.
+type Exporter struct {
+	source      string
+	sink        Sink
+	sendTimeout time.Duration
+}
+
+// NewExporter creates an exporter emitting events with the given source (e.g. "/rxd/mydaemon") to the sink.
+func NewExporter(source string, sink Sink) *Exporter {
+	return &Exporter{
+		source:      source,
+		sink:        sink,
+		sendTimeout: 5 * time.Second,
+	}
+}
+
+func (e *Exporter) Init(sctx rxd.ServiceContext) error {
+	return nil
+}
+
+func (e *Exporter) Idle(sctx rxd.ServiceContext) error {
+	return nil
+}
+
+func (e *Exporter) Run(sctx rxd.ServiceContext) error {
+	statesC, cancel := sctx.WatchAllStates(rxd.NoFilter)
+	defer cancel()
+
+	e.emit(sctx, TypeDaemonStarted, "", nil)
+
+	previous := make(rxd.ServiceStates)
+	for {
+		select {
+		case <-sctx.Done():
+			e.emit(sctx, TypeDaemonStopping, "", nil)
+			return nil
+		case states, open := <-statesC:
+			if !open {
+				return nil
+			}
+
+			for name, state := range states {
+				prev, known := previous[name]
+				if known && prev == state {
+					continue
+				}
+
+				from := ""
+				if known {
+					from = prev.String()
+				}
+
+				e.emit(sctx, TypeServiceStateChanged, name, StateChange{Service: name, From: from, To: state.String()})
+				previous[name] = state
+			}
+		}
+	}
+}
+
+func (e *Exporter) Stop(sctx rxd.ServiceContext) error {
+	return nil
+}
+
+func (e *Exporter) emit(sctx rxd.ServiceContext, eventType, subject string, data any) {
+	event, err := NewEvent(e.source, eventType, subject, data)
+	if err != nil {
+		sctx.Log(log.LevelError, "error creating cloudevent", log.Error("error", err), log.String("type", eventType))
+		return
+	}
+
+	// events are still delivered while the daemon is stopping, but never for longer than the send timeout.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(sctx), e.sendTimeout)
+	defer cancel()
+
+	if err := e.sink.Send(ctx, event); err != nil {
+		sctx.Log(log.LevelError, "error sending cloudevent", log.Error("error", err), log.String("type", eventType))
+	}
+}
@@ -0,0 +1,60 @@
+package cloudevents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewWriterSink(&buf)
+
+	event, err := NewEvent("/rxd/test", TypeServiceStateChanged, "svc", StateChange{Service: "svc", From: "init", To: "idle"})
+	if err != nil {
+		t.Fatalf("error creating event: %s", err)
+	}
+
+	if err := sink.Send(context.Background(), event); err != nil {
+		t.Fatalf("error sending event: %s", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("error decoding event: %s", err)
+	}
+
+	if got["specversion"] != "1.0" || got["type"] != TypeServiceStateChanged || got["subject"] != "svc" {
+		t.Fatalf("unexpected event attributes: %v", got)
+	}
+
+	data, _ := got["data"].(map[string]any)
+	if data["to"] != "idle" {
+		t.Fatalf("unexpected event data: %v", got["data"])
+	}
+}
+
+func TestHTTPSink(t *testing.T) {
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	event, err := NewEvent("/rxd/test", TypeDaemonStarted, "", nil)
+	if err != nil {
+		t.Fatalf("error creating event: %s", err)
+	}
+
+	if err := (HTTPSink{URL: server.URL}).Send(context.Background(), event); err != nil {
+		t.Fatalf("error sending event: %s", err)
+	}
+
+	if contentType != "application/cloudevents+json" {
+		t.Fatalf("expected structured content mode, got %q", contentType)
+	}
+}
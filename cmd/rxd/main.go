@@ -0,0 +1,62 @@
+// Command rxd generates rxd projects.
+//
+//	rxd new myd
+//	rxd new -module example.com/myd -version v1.2.0 ./services/myd
+//
+// new writes a runnable daemon into the project directory: main.go wiring the daemon from config.json,
+// an HTTP API service, a poller checking its health, tests running the daemon with the e2e harness,
+// a systemd unit and a Dockerfile. The project is named after the last element of the directory.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ambitiousfew/rxd/pkg/scaffold"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: rxd new [-module path] [-version version] <project>")
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() < 1 || flag.Arg(0) != "new" {
+		usage()
+		os.Exit(2)
+	}
+
+	if err := newProject(flag.Args()[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "rxd:", err)
+		os.Exit(1)
+	}
+}
+
+func newProject(args []string) error {
+	fs := flag.NewFlagSet("new", flag.ExitOnError)
+	module := fs.String("module", "", "module path of the project, defaults to the project name")
+	version := fs.String("version", "", "version of rxd required by go.mod, left to go mod tidy when empty")
+	fs.Usage = func() {
+		usage()
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	dir := fs.Arg(0)
+	project := scaffold.Project{Name: filepath.Base(filepath.Clean(dir)), Module: *module, Version: *version}
+	if err := scaffold.Generate(dir, project); err != nil {
+		return err
+	}
+
+	fmt.Printf("created %s, to run it:\n\n\tcd %s\n\tgo mod tidy\n\tgo test ./...\n\tgo run . -config config.json\n", project.Name, dir)
+	return nil
+}
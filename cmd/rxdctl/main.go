@@ -0,0 +1,226 @@
+// Command rxdctl controls running rxd daemons through their control sockets, see rxd.UsingControlSocket.
+//
+//	rxdctl -socket /run/rxd/myd.sock status
+//	rxdctl -socket /run/rxd/myd.sock logs [service]
+//	rxdctl -socket /run/rxd/myd.sock restart <service>
+//	rxdctl -socket /run/rxd/myd.sock resume <service>
+//
+// With -all the command applies to every daemon with a socket in -dir, or to every daemon the daemon
+// serving -socket proxies (see rxd.UsingAdminProxy). -daemon selects a single one of them.
+//
+//	rxdctl -all status
+//	rxdctl -socket /run/rxd/admin.sock -daemon ingest restart kafka
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ambitiousfew/rxd/pkg/ctl"
+)
+
+// target is a daemon the command applies to.
+type target struct {
+	name   string
+	client *ctl.Client
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: rxdctl [-socket path | -dir path] [-all | -daemon name] [-timeout duration] status | logs [service] | restart <service> | resume <service>")
+	flag.PrintDefaults()
+}
+
+func main() {
+	socket := flag.String("socket", os.Getenv("RXD_CTL_SOCKET"), "path of the control socket of the daemon (env RXD_CTL_SOCKET)")
+	dir := flag.String("dir", ctl.DefaultSocketDir, "directory of the control sockets of the daemons, used by -all and -daemon without -socket")
+	all := flag.Bool("all", false, "apply the command to every daemon")
+	daemon := flag.String("daemon", "", "apply the command to the named daemon")
+	timeout := flag.Duration("timeout", 5*time.Second, "timeout of status, restart and resume")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() < 1 || (*socket == "" && !*all && *daemon == "") {
+		usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	targets, err := resolveTargets(ctx, *socket, *dir, *all, *daemon, *timeout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "rxdctl:", err)
+		os.Exit(1)
+	}
+
+	args := flag.Args()
+	var run func(ctx context.Context, t target) error
+	switch args[0] {
+	case "status":
+		run = func(ctx context.Context, t target) error {
+			return status(ctx, t, len(targets) > 1, *timeout)
+		}
+	case "logs":
+		var service string
+		if len(args) > 1 {
+			service = args[1]
+		}
+		run = func(ctx context.Context, t target) error {
+			return t.client.Logs(ctx, service, func(entry ctl.LogEntry) {
+				printEntry(t, len(targets) > 1, entry)
+			})
+		}
+	case "restart":
+		if len(args) < 2 {
+			usage()
+			os.Exit(2)
+		}
+		run = func(ctx context.Context, t target) error {
+			tctx, cancel := context.WithTimeout(ctx, *timeout)
+			defer cancel()
+			return t.client.Restart(tctx, args[1])
+		}
+	case "resume":
+		if len(args) < 2 {
+			usage()
+			os.Exit(2)
+		}
+		run = func(ctx context.Context, t target) error {
+			tctx, cancel := context.WithTimeout(ctx, *timeout)
+			defer cancel()
+			return t.client.Resume(tctx, args[1])
+		}
+	default:
+		usage()
+		os.Exit(2)
+	}
+
+	if err := runAll(ctx, targets, args[0] == "logs", run); err != nil {
+		fmt.Fprintln(os.Stderr, "rxdctl:", err)
+		os.Exit(1)
+	}
+}
+
+// resolveTargets returns the daemons the command applies to, through the proxy serving socket when set.
+func resolveTargets(ctx context.Context, socket, dir string, all bool, daemon string, timeout time.Duration) ([]target, error) {
+	if !all && daemon == "" {
+		return []target{{name: socket, client: ctl.NewClient(socket)}}, nil
+	}
+
+	if socket != "" {
+		proxy := ctl.NewClient(socket)
+		if daemon != "" {
+			return []target{{name: daemon, client: proxy.Via(daemon)}}, nil
+		}
+
+		tctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		daemons, err := proxy.Daemons(tctx)
+		if err != nil {
+			return nil, err
+		}
+		targets := make([]target, 0, len(daemons))
+		for _, name := range daemons {
+			targets = append(targets, target{name: name, client: proxy.Via(name)})
+		}
+		return targets, nil
+	}
+
+	if daemon != "" {
+		return []target{{name: daemon, client: ctl.NewClient(ctl.SocketPath(dir, daemon))}}, nil
+	}
+
+	daemons, err := ctl.Discover(dir)
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]target, 0, len(daemons))
+	for _, name := range daemons {
+		targets = append(targets, target{name: name, client: ctl.NewClient(ctl.SocketPath(dir, name))})
+	}
+	return targets, nil
+}
+
+// runAll runs the command against every target, in order or concurrently for streams,
+// an error of one daemon does not stop the command for the others.
+func runAll(ctx context.Context, targets []target, concurrent bool, run func(ctx context.Context, t target) error) error {
+	var mu sync.Mutex
+	var errs []error
+	record := func(t target, err error) {
+		if err == nil {
+			return
+		}
+		mu.Lock()
+		errs = append(errs, fmt.Errorf("%s: %w", t.name, err))
+		mu.Unlock()
+	}
+
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		if !concurrent {
+			record(t, run(ctx, t))
+			continue
+		}
+		wg.Add(1)
+		go func(t target) {
+			defer wg.Done()
+			record(t, run(ctx, t))
+		}(t)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func status(ctx context.Context, t target, prefixed bool, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	states, errs, err := t.client.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(states))
+	for name := range states {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		// the most recent lifecycle error follows the state, so a service stuck failing Init shows why.
+		line := fmt.Sprintf("%-8s %s", states[name], errs[name])
+		if prefixed {
+			fmt.Println(strings.TrimSpace(fmt.Sprintf("%-24s %-32s %s", t.name, name, line)))
+			continue
+		}
+		fmt.Println(strings.TrimSpace(fmt.Sprintf("%-32s %s", name, line)))
+	}
+	return nil
+}
+
+func printEntry(t target, prefixed bool, entry ctl.LogEntry) {
+	keys := make([]string, 0, len(entry.Fields))
+	for key := range entry.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	if prefixed {
+		b.WriteString(t.name + " ")
+	}
+	b.WriteString(entry.Time.Format(time.RFC3339) + " " + entry.Level + " " + entry.Message)
+	for _, key := range keys {
+		b.WriteString(" " + key + "=" + entry.Fields[key])
+	}
+	fmt.Println(b.String())
+}
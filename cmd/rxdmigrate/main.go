@@ -0,0 +1,66 @@
+// Command rxdmigrate lists the code using the ServiceResponse API of rxd that needs porting to ServiceRunner.
+// It exits with status 1 when uses are found, so it can gate CI while a codebase is being ported.
+//
+//	rxdmigrate ./...
+//	rxdmigrate ./cmd ./internal/service.go
+//
+// Services written against the old API keep running through rxd.NewLegacyService until they are ported.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ambitiousfew/rxd/pkg/migrate"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: rxdmigrate [path ...]")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	paths := flag.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	var found bool
+	for _, path := range paths {
+		findings, err := analyze(strings.TrimSuffix(path, "/..."))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "rxdmigrate:", err)
+			os.Exit(2)
+		}
+
+		for _, finding := range findings {
+			fmt.Println(finding)
+		}
+		found = found || len(findings) > 0
+	}
+
+	if found {
+		os.Exit(1)
+	}
+}
+
+func analyze(path string) ([]migrate.Finding, error) {
+	if path == "" {
+		path = "."
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return migrate.Dir(path)
+	}
+	return migrate.Source(path, nil)
+}
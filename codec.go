@@ -0,0 +1,58 @@
+package rxd
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec encodes the states and reports the daemon exports, such as the status file and the responses of the
+// admin api, see UsingCodec. JSONCodec is used by default. Other encodings such as protobuf or msgpack are
+// plugged in with NewCodec, e.g. rxd.NewCodec("application/msgpack", msgpack.Marshal, msgpack.Unmarshal).
+type Codec interface {
+	ContentType() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	// JSONCodec encodes values as json, it is the codec used by default.
+	JSONCodec Codec = NewCodec("application/json", json.Marshal, json.Unmarshal)
+	// GobCodec encodes values with encoding/gob, a compact binary encoding for go readers.
+	GobCodec Codec = NewCodec("application/x-gob", gobMarshal, gobUnmarshal)
+)
+
+// NewCodec returns a Codec with the given content type calling marshal and unmarshal.
+func NewCodec(contentType string, marshal func(v any) ([]byte, error), unmarshal func(data []byte, v any) error) Codec {
+	return funcCodec{contentType: contentType, marshal: marshal, unmarshal: unmarshal}
+}
+
+type funcCodec struct {
+	contentType string
+	marshal     func(v any) ([]byte, error)
+	unmarshal   func(data []byte, v any) error
+}
+
+func (c funcCodec) ContentType() string {
+	return c.contentType
+}
+
+func (c funcCodec) Marshal(v any) ([]byte, error) {
+	return c.marshal(v)
+}
+
+func (c funcCodec) Unmarshal(data []byte, v any) error {
+	return c.unmarshal(data, v)
+}
+
+func gobMarshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gobUnmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
@@ -0,0 +1,63 @@
+package rxd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+func TestCodec_RoundTrip(t *testing.T) {
+	report := StatusReport{Ready: true, Services: map[string]string{"api": StateRun.String()}, Errors: map[string]string{"db": "connection refused"}}
+	for _, codec := range []Codec{JSONCodec, GobCodec} {
+		data, err := codec.Marshal(report)
+		if err != nil {
+			t.Fatalf("%s: error encoding report: %s", codec.ContentType(), err)
+		}
+		var decoded StatusReport
+		if err := codec.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("%s: error decoding report: %s", codec.ContentType(), err)
+		}
+		if !reflect.DeepEqual(decoded, report) {
+			t.Errorf("%s: expected %+v, got %+v", codec.ContentType(), report, decoded)
+		}
+	}
+}
+
+func TestDaemon_UsingCodec(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.gob")
+	d := NewDaemon("test-daemon", UsingCodec(GobCodec), WithStatusFile(path)).(*daemon)
+
+	d.readiness = &readinessTracker{policy: AllOf(), statusFile: path, codec: d.codec, logger: log.NewLogger(log.LevelDebug, newTestLogger())}
+	d.readiness.observe(ServiceStates{"api": StateRun})
+	d.health.set(ServicesHealth{"api": {Status: HealthUnhealthy, Error: "connection refused", Failures: 2}})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading status file: %s", err)
+	}
+	var report StatusReport
+	if err := GobCodec.Unmarshal(data, &report); err != nil {
+		t.Fatalf("error decoding status file: %s", err)
+	}
+	if !report.Ready || report.Services["api"] != StateRun.String() {
+		t.Errorf("unexpected status report %+v", report)
+	}
+
+	rec := httptest.NewRecorder()
+	d.serveHealthz(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if got := rec.Header().Get("Content-Type"); got != GobCodec.ContentType() {
+		t.Errorf("expected content type %s, got %s", GobCodec.ContentType(), got)
+	}
+	var health ServicesHealth
+	if err := GobCodec.Unmarshal(rec.Body.Bytes(), &health); err != nil {
+		t.Fatalf("error decoding /healthz: %s", err)
+	}
+	if health["api"].Status != HealthUnhealthy || health["api"].Failures != 2 {
+		t.Errorf("unexpected health %+v", health)
+	}
+}
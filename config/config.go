@@ -0,0 +1,378 @@
+// Package config builds the options of a daemon and its services from a config file,
+// so the log level, signals, timeouts and policies of every service can change without a rebuild:
+//
+//	conf, err := config.Load("/etc/app/app.toml")
+//	if err != nil {
+//		return err
+//	}
+//	opts, err := conf.DaemonOptions()
+//	if err != nil {
+//		return err
+//	}
+//	d := rxd.NewDaemon("app", append([]rxd.DaemonOption{rxd.WithServiceLogger(logger)}, opts...)...)
+//
+//	apiOpts, err := conf.ServiceOptions("api")
+//	if err != nil {
+//		return err
+//	}
+//	err = d.AddService(rxd.NewService("api", api, apiOpts...))
+//
+// JSON and TOML files are supported out of the box, other formats such as YAML are added with RegisterFormat.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ambitiousfew/rxd"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// Run policies of a service, see Service.RunPolicy.
+const (
+	RunContinuous   = "continuous"    // rxd.RunContinuousManager, the default.
+	RunOnce         = "once"          // rxd.RunOnceManager.
+	RunUntilSuccess = "until-success" // rxd.RunUntilSuccessManager.
+)
+
+// Config is the content of a config file, every setting is optional.
+type Config struct {
+	LogLevel       string             `json:"log_level,omitempty"`       // level of the service logger e.g. "debug".
+	Signals        []string           `json:"signals,omitempty"`         // signals stopping the daemon e.g. ["SIGTERM"], see rxd.WithSignals.
+	ReportAlive    Duration           `json:"report_alive,omitempty"`    // systemd watchdog interval in whole seconds, see rxd.WithReportAlive.
+	ShutdownGrace  Duration           `json:"shutdown_grace,omitempty"`  // see rxd.WithShutdownGrace.
+	HealthInterval Duration           `json:"health_interval,omitempty"` // how often services are probed for their health, see rxd.WithHealthChecks.
+	Services       map[string]Service `json:"services,omitempty"`        // settings of the services keyed by service name.
+}
+
+// Service holds the settings of a single service.
+// Setting the run policy, delays or state timeouts replaces the manager of the service with one built
+// from these settings, the delays that are not set default to 100ms as with rxd.NewDefaultManager.
+type Service struct {
+	RunPolicy     string              `json:"run_policy,omitempty"`     // "continuous", "once" or "until-success".
+	RestartPolicy string              `json:"restart_policy,omitempty"` // "always", "on-failure", "never" or "exit-daemon".
+	StartupDelay  Duration            `json:"startup_delay,omitempty"`  // delay before the service is first initialized.
+	Delay         Duration            `json:"delay,omitempty"`          // delay between lifecycles, not used by the once run policy.
+	StateTimeouts map[string]Duration `json:"state_timeouts,omitempty"` // delay before entering a state keyed by state name, continuous only.
+	StopTimeout   Duration            `json:"stop_timeout,omitempty"`   // see rxd.WithStopTimeout.
+	DependsOn     []string            `json:"depends_on,omitempty"`     // see rxd.WithDependsOn.
+	Critical      bool                `json:"critical,omitempty"`       // see rxd.WithCritical.
+	TTL           Duration            `json:"ttl,omitempty"`            // see rxd.WithTTL.
+	MaxRuns       int                 `json:"max_runs,omitempty"`       // see rxd.WithMaxRuns.
+	Labels        map[string]string   `json:"labels,omitempty"`         // see rxd.WithLabels.
+	Settings      json.RawMessage     `json:"settings,omitempty"`       // settings of the runner, read with Settings.
+}
+
+// Duration is a time.Duration read from a string such as "1m30s" or a number of seconds.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case string:
+		duration, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*d = Duration(duration)
+	case float64:
+		*d = Duration(v * float64(time.Second))
+	default:
+		return errors.New("duration must be a string such as \"5s\" or a number of seconds")
+	}
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Decoder decodes the content of a config file into v, which is always a *map[string]any.
+// yaml.Unmarshal of gopkg.in/yaml.v3 and toml.Unmarshal of github.com/BurntSushi/toml are decoders.
+type Decoder func(data []byte, v any) error
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[string]Decoder{
+		".json": json.Unmarshal,
+		".toml": decodeTOML,
+	}
+)
+
+// RegisterFormat makes Load decode the files with the extension ext, e.g. ".yaml", with decode.
+// It replaces the decoder of the extension if there is one, so the built-in TOML support can be swapped
+// for a complete implementation.
+func RegisterFormat(ext string, decode Decoder) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats[strings.ToLower(ext)] = decode
+}
+
+// Load reads the config file at path, its format is chosen by the file extension.
+func Load(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	conf, err := Parse(data, filepath.Ext(path))
+	if err != nil {
+		return Config{}, ErrParse{Path: path, Err: err}
+	}
+	return conf, nil
+}
+
+// Parse decodes the content of a config file of the format registered for the extension ext, e.g. ".toml".
+// Unknown settings are an error so typos do not go unnoticed.
+func Parse(data []byte, ext string) (Config, error) {
+	formatsMu.RLock()
+	decode, ok := formats[strings.ToLower(ext)]
+	formatsMu.RUnlock()
+	if !ok {
+		return Config{}, ErrUnknownFormat
+	}
+
+	var raw map[string]any
+	if err := decode(data, &raw); err != nil {
+		return Config{}, err
+	}
+
+	// every format is decoded through json so the settings share a single definition.
+	normalized, err := json.Marshal(raw)
+	if err != nil {
+		return Config{}, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(normalized))
+	dec.DisallowUnknownFields()
+
+	var conf Config
+	if err := dec.Decode(&conf); err != nil {
+		return Config{}, err
+	}
+	return conf, nil
+}
+
+// DaemonOptions returns the daemon options of the config, they are given to rxd.NewDaemon after rxd.WithServiceLogger.
+// Settings that cannot be applied are all reported in the returned error.
+func (c Config) DaemonOptions() ([]rxd.DaemonOption, error) {
+	var opts []rxd.DaemonOption
+	var errs []error
+
+	if c.LogLevel != "" {
+		level, err := parseLogLevel(c.LogLevel)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			opts = append(opts, rxd.WithServiceLogLevel(level))
+		}
+	}
+
+	if len(c.Signals) > 0 {
+		signals, err := parseSignals(c.Signals)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			opts = append(opts, rxd.WithSignals(signals...))
+		}
+	}
+
+	if c.ReportAlive != 0 {
+		secs := time.Duration(c.ReportAlive) / time.Second
+		if secs < 1 {
+			errs = append(errs, ErrInvalidSetting{Key: "report_alive", Value: time.Duration(c.ReportAlive).String(), Err: ErrReportAliveTooLow})
+		} else {
+			opts = append(opts, rxd.WithReportAlive(uint64(secs)))
+		}
+	}
+
+	if c.ShutdownGrace != 0 {
+		opts = append(opts, rxd.WithShutdownGrace(time.Duration(c.ShutdownGrace)))
+	}
+
+	if c.HealthInterval != 0 {
+		opts = append(opts, rxd.WithHealthChecks(time.Duration(c.HealthInterval), 0))
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return opts, nil
+}
+
+// ServiceOptions returns the options of the service named name, none when the config has no settings for it.
+// Settings that cannot be applied are all reported in the returned error.
+func (c Config) ServiceOptions(name string) ([]rxd.ServiceOption, error) {
+	conf, ok := c.Services[name]
+	if !ok {
+		return nil, nil
+	}
+
+	key := "services." + name + "."
+	var opts []rxd.ServiceOption
+	var errs []error
+
+	manager, err := conf.manager(key)
+	if err != nil {
+		errs = append(errs, err)
+	} else if manager != nil {
+		opts = append(opts, rxd.WithManager(manager))
+	}
+
+	if conf.RestartPolicy != "" {
+		policy, ok := rxd.ParseRestartPolicy(conf.RestartPolicy)
+		if !ok {
+			errs = append(errs, ErrInvalidSetting{Key: key + "restart_policy", Value: conf.RestartPolicy, Err: ErrUnknownRestart})
+		} else {
+			opts = append(opts, rxd.WithRestartPolicy(policy))
+		}
+	}
+
+	if conf.StopTimeout != 0 {
+		opts = append(opts, rxd.WithStopTimeout(time.Duration(conf.StopTimeout)))
+	}
+
+	if len(conf.DependsOn) > 0 {
+		opts = append(opts, rxd.WithDependsOn(conf.DependsOn...))
+	}
+
+	if conf.Critical {
+		opts = append(opts, rxd.WithCritical())
+	}
+
+	if conf.TTL != 0 {
+		opts = append(opts, rxd.WithTTL(time.Duration(conf.TTL)))
+	}
+
+	if conf.MaxRuns != 0 {
+		opts = append(opts, rxd.WithMaxRuns(conf.MaxRuns))
+	}
+
+	if len(conf.Labels) > 0 {
+		opts = append(opts, rxd.WithLabels(conf.Labels))
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return opts, nil
+}
+
+// NewServices returns every service of the config, sorted by name, with its options and without a runner.
+// The daemon builds their runners with the resolver given to rxd.UsingResolver once they are added:
+//
+//	services, err := conf.NewServices()
+//	if err != nil {
+//		return err
+//	}
+//	err = d.AddServices(services...)
+func (c Config) NewServices() ([]rxd.Service, error) {
+	names := make([]string, 0, len(c.Services))
+	for name := range c.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	services := make([]rxd.Service, 0, len(names))
+	var errs []error
+	for _, name := range names {
+		opts, err := c.ServiceOptions(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		services = append(services, rxd.NewService(name, nil, opts...))
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return services, nil
+}
+
+// manager builds the manager of the service, nil when the settings leave the manager of the service untouched.
+func (s Service) manager(key string) (rxd.ServiceManager, error) {
+	if s.RunPolicy == "" && s.StartupDelay == 0 && s.Delay == 0 && len(s.StateTimeouts) == 0 {
+		return nil, nil
+	}
+
+	startupDelay, delay := 100*time.Millisecond, 100*time.Millisecond
+	if s.StartupDelay != 0 {
+		startupDelay = time.Duration(s.StartupDelay)
+	}
+	if s.Delay != 0 {
+		delay = time.Duration(s.Delay)
+	}
+
+	switch s.RunPolicy {
+	case "", RunContinuous:
+		timeouts, err := s.stateTimeouts(key)
+		if err != nil {
+			return nil, err
+		}
+		manager := rxd.NewDefaultManager(rxd.WithInitDelay(startupDelay), rxd.WithTransitionTimeouts(timeouts))
+		manager.DefaultDelay = delay
+		return manager, nil
+	case RunOnce, RunUntilSuccess:
+		if len(s.StateTimeouts) > 0 {
+			return nil, ErrInvalidSetting{Key: key + "state_timeouts", Value: s.RunPolicy, Err: ErrUnsupportedSetting}
+		}
+		if s.RunPolicy == RunOnce {
+			return rxd.NewRunOnceManager(startupDelay), nil
+		}
+		return rxd.NewRunUntilSuccessManager(delay, startupDelay), nil
+	default:
+		return nil, ErrInvalidSetting{Key: key + "run_policy", Value: s.RunPolicy, Err: ErrUnknownRunPolicy}
+	}
+}
+
+func (s Service) stateTimeouts(key string) (rxd.ManagerStateTimeouts, error) {
+	// sorted so the same state is reported first every time.
+	names := make([]string, 0, len(s.StateTimeouts))
+	for name := range s.StateTimeouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	timeouts := make(rxd.ManagerStateTimeouts, len(names))
+	for _, name := range names {
+		state, ok := rxd.ParseState(name)
+		if !ok {
+			return nil, ErrInvalidSetting{Key: key + "state_timeouts", Value: name, Err: ErrUnknownState}
+		}
+		timeouts[state] = time.Duration(s.StateTimeouts[name])
+	}
+	return timeouts, nil
+}
+
+func parseLogLevel(name string) (log.Level, error) {
+	level := log.LevelFromString(name)
+	if level.String() != strings.ToUpper(name) {
+		return level, ErrInvalidSetting{Key: "log_level", Value: name, Err: rxd.ErrUnknownLogLevel}
+	}
+	return level, nil
+}
+
+func parseSignals(names []string) ([]os.Signal, error) {
+	signals := make([]os.Signal, 0, len(names))
+	for i, name := range names {
+		signal, ok := signalsByName[strings.TrimPrefix(strings.ToUpper(name), "SIG")]
+		if !ok {
+			return nil, ErrInvalidSetting{Key: "signals." + strconv.Itoa(i), Value: name, Err: ErrUnknownSignal}
+		}
+		signals = append(signals, signal)
+	}
+	return signals, nil
+}
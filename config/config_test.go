@@ -0,0 +1,154 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd"
+)
+
+const testTOML = `
+log_level = "warning"
+signals = ["SIGTERM", "int"]
+report_alive = "10s"
+shutdown_grace = 30 # seconds
+
+[services.api]
+restart_policy = "on-failure"
+startup_delay = "1s"
+state_timeouts = { init = "5s", run = "250ms" }
+depends_on = ["db"]
+
+[services.migrate]
+run_policy = "once"
+stop_timeout = "2s"
+`
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.toml")
+	if err := os.WriteFile(path, []byte(testTOML), 0o600); err != nil {
+		t.Fatalf("error writing config file: %s", err)
+	}
+
+	conf, err := Load(path)
+	if err != nil {
+		t.Fatalf("error loading config: %s", err)
+	}
+
+	if conf.LogLevel != "warning" || len(conf.Signals) != 2 || time.Duration(conf.ShutdownGrace) != 30*time.Second {
+		t.Errorf("unexpected daemon settings %+v", conf)
+	}
+
+	opts, err := conf.DaemonOptions()
+	if err != nil {
+		t.Fatalf("error building daemon options: %s", err)
+	}
+	if len(opts) != 4 {
+		t.Errorf("expected an option for each of the 4 daemon settings, got %d", len(opts))
+	}
+
+	apiOpts, err := conf.ServiceOptions("api")
+	if err != nil {
+		t.Fatalf("error building service options: %s", err)
+	}
+	api := rxd.NewService("api", nil, apiOpts...)
+	manager, ok := api.Manager.(rxd.RunContinuousManager)
+	if !ok {
+		t.Fatalf("expected a continuous manager, got %T", api.Manager)
+	}
+	if manager.StartupDelay != time.Second || manager.StateTimeouts[rxd.StateInit] != 5*time.Second || manager.StateTimeouts[rxd.StateRun] != 250*time.Millisecond {
+		t.Errorf("unexpected manager settings %+v", manager)
+	}
+
+	migrateOpts, err := conf.ServiceOptions("migrate")
+	if err != nil {
+		t.Fatalf("error building service options: %s", err)
+	}
+	if migrate := rxd.NewService("migrate", nil, migrateOpts...); migrate.Manager != rxd.NewRunOnceManager(100*time.Millisecond) {
+		t.Errorf("expected a run once manager, got %#v", migrate.Manager)
+	}
+
+	if opts, err := conf.ServiceOptions("unknown"); opts != nil || err != nil {
+		t.Errorf("expected no options for a service without settings, got %v, %v", opts, err)
+	}
+
+	services, err := conf.NewServices()
+	if err != nil {
+		t.Fatalf("error building services: %s", err)
+	}
+	if len(services) != 2 || services[0].Name != "api" || services[1].Name != "migrate" || services[0].Runner != nil {
+		t.Errorf("expected the services sorted by name without a runner, got %+v", services)
+	}
+}
+
+func TestParse_JSON(t *testing.T) {
+	conf, err := Parse([]byte(`{"log_level": "debug", "services": {"api": {"run_policy": "until-success", "delay": 2}}}`), ".json")
+	if err != nil {
+		t.Fatalf("error parsing config: %s", err)
+	}
+
+	opts, err := conf.ServiceOptions("api")
+	if err != nil {
+		t.Fatalf("error building service options: %s", err)
+	}
+	if api := rxd.NewService("api", nil, opts...); api.Manager != rxd.NewRunUntilSuccessManager(2*time.Second, 100*time.Millisecond) {
+		t.Errorf("expected a run until success manager, got %#v", api.Manager)
+	}
+
+	if _, err := Parse([]byte(`{"log_levle": "debug"}`), ".json"); err == nil {
+		t.Errorf("expected an error for an unknown setting")
+	}
+	if _, err := Parse([]byte(`log_level: debug`), ".yaml"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected %s, got %v", ErrUnknownFormat, err)
+	}
+}
+
+func TestRegisterFormat(t *testing.T) {
+	RegisterFormat(".ini", func(data []byte, v any) error {
+		*v.(*map[string]any) = map[string]any{"log_level": string(data)}
+		return nil
+	})
+
+	conf, err := Parse([]byte("error"), ".INI")
+	if err != nil {
+		t.Fatalf("error parsing config: %s", err)
+	}
+	if conf.LogLevel != "error" {
+		t.Errorf("expected the registered decoder to be used, got %+v", conf)
+	}
+}
+
+func TestConfig_Invalid(t *testing.T) {
+	conf := Config{
+		LogLevel:    "loud",
+		Signals:     []string{"SIGTERM", "SIGKILL"},
+		ReportAlive: Duration(500 * time.Millisecond),
+		Services: map[string]Service{
+			"api":     {RestartPolicy: "sometimes", StateTimeouts: map[string]Duration{"booting": Duration(time.Second)}},
+			"migrate": {RunPolicy: "once", StateTimeouts: map[string]Duration{"init": Duration(time.Second)}},
+		},
+	}
+
+	_, err := conf.DaemonOptions()
+	for _, want := range []error{rxd.ErrUnknownLogLevel, ErrUnknownSignal, ErrReportAliveTooLow} {
+		if !errors.Is(err, want) {
+			t.Errorf("expected the daemon options to fail with %s, got %v", want, err)
+		}
+	}
+
+	_, err = conf.ServiceOptions("api")
+	var invalid ErrInvalidSetting
+	if !errors.As(err, &invalid) || invalid.Key != "services.api.state_timeouts" || invalid.Value != "booting" {
+		t.Errorf("expected the unknown state to be reported with its key, got %v", err)
+	}
+	if !errors.Is(err, ErrUnknownRestart) {
+		t.Errorf("expected %s, got %v", ErrUnknownRestart, err)
+	}
+
+	if _, err := conf.ServiceOptions("migrate"); !errors.Is(err, ErrUnsupportedSetting) {
+		t.Errorf("expected %s, got %v", ErrUnsupportedSetting, err)
+	}
+}
@@ -0,0 +1,64 @@
+package config
+
+import "strconv"
+
+// Error is a custom error type for the config package.
+type Error string
+
+const (
+	ErrUnknownFormat      = Error("unknown config file format")
+	ErrUnknownRunPolicy   = Error("unknown run policy")
+	ErrUnknownRestart     = Error("unknown restart policy")
+	ErrUnknownState       = Error("unknown state")
+	ErrUnknownSignal      = Error("unknown signal")
+	ErrUnsupportedSetting = Error("setting is not supported with this run policy")
+	ErrReportAliveTooLow  = Error("report alive interval must be at least one second")
+	ErrRequired           = Error("setting is required")
+	ErrOutOfRange         = Error("value is out of range")
+	ErrNotOneOf           = Error("value is not one of the allowed values")
+	ErrInvalidTag         = Error("invalid validate tag")
+)
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// ErrParse is returned by Load when the config file cannot be decoded.
+type ErrParse struct {
+	Path string
+	Err  error
+}
+
+func (e ErrParse) Error() string {
+	return "error parsing config file '" + e.Path + "': " + e.Err.Error()
+}
+
+func (e ErrParse) Unwrap() error {
+	return e.Err
+}
+
+// ErrInvalidSetting is returned when a setting holds a value that cannot be applied.
+// Key is the path of the setting in the config file e.g. "services.api.restart_policy".
+type ErrInvalidSetting struct {
+	Key   string
+	Value string
+	Err   error
+}
+
+func (e ErrInvalidSetting) Error() string {
+	return "invalid value '" + e.Value + "' for " + e.Key + ": " + e.Err.Error()
+}
+
+func (e ErrInvalidSetting) Unwrap() error {
+	return e.Err
+}
+
+// ErrSyntax is returned when a TOML config file is malformed.
+type ErrSyntax struct {
+	Line int
+	Msg  string
+}
+
+func (e ErrSyntax) Error() string {
+	return "line " + strconv.Itoa(e.Line) + ": " + e.Msg
+}
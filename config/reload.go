@@ -0,0 +1,162 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/ambitiousfew/rxd"
+)
+
+// Reloader returns the loader given to rxd.UsingConfigReload, it reads the config file at path again on
+// every SIGHUP. conf is the config the daemon was started with: changed settings that only apply once the
+// daemon restarts, such as signals, run policies or added services, are reported as ignored on every reload
+// until the daemon is restarted.
+//
+//	d := rxd.NewDaemon("app", append(opts, rxd.UsingConfigReload(config.Reloader("/etc/app/app.toml", conf)))...)
+func Reloader(path string, conf Config) rxd.ConfigLoader {
+	return func() (rxd.RuntimeConfig, error) {
+		next, err := Load(path)
+		if err != nil {
+			return rxd.RuntimeConfig{}, err
+		}
+
+		runtime, err := next.RuntimeConfig()
+		if err != nil {
+			return rxd.RuntimeConfig{}, ErrParse{Path: path, Err: err}
+		}
+		runtime.Ignored = restartChanges(conf, next)
+
+		// a service no longer given any manager settings loses the state timeouts it was started with.
+		for name, service := range conf.Services {
+			_, stillSet := runtime.StateTimeouts[name]
+			if _, ok := next.Services[name]; !ok || stillSet || len(service.StateTimeouts) == 0 {
+				continue
+			}
+			if runtime.StateTimeouts == nil {
+				runtime.StateTimeouts = make(map[string]rxd.ManagerStateTimeouts)
+			}
+			runtime.StateTimeouts[name] = rxd.ManagerStateTimeouts{}
+		}
+		return runtime, nil
+	}
+}
+
+// RuntimeConfig returns the settings of the config that a running daemon can change: the log level, the
+// health interval and the state timeouts of the services whose continuous manager is built from the config.
+func (c Config) RuntimeConfig() (rxd.RuntimeConfig, error) {
+	var conf rxd.RuntimeConfig
+	var errs []error
+
+	if c.LogLevel != "" {
+		level, err := parseLogLevel(c.LogLevel)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			conf.LogLevel = &level
+		}
+	}
+
+	conf.HealthInterval = time.Duration(c.HealthInterval)
+
+	for name, service := range c.Services {
+		key := "services." + name + "."
+		if manager, err := service.manager(key); err != nil {
+			errs = append(errs, err)
+			continue
+		} else if _, ok := manager.(rxd.RunContinuousManager); !ok {
+			// the manager of the service is not built from the config, or has no state timeouts.
+			continue
+		}
+
+		timeouts, err := service.stateTimeouts(key)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if conf.StateTimeouts == nil {
+			conf.StateTimeouts = make(map[string]rxd.ManagerStateTimeouts)
+		}
+		conf.StateTimeouts[name] = timeouts
+	}
+
+	if len(errs) > 0 {
+		return rxd.RuntimeConfig{}, errors.Join(errs...)
+	}
+	return conf, nil
+}
+
+// restartChanges returns the keys of the settings that differ between the configs and only apply on restart.
+func restartChanges(started, next Config) []string {
+	var keys []string
+	if !reflect.DeepEqual(started.Signals, next.Signals) {
+		keys = append(keys, "signals")
+	}
+	if started.ReportAlive != next.ReportAlive {
+		keys = append(keys, "report_alive")
+	}
+	if started.ShutdownGrace != next.ShutdownGrace {
+		keys = append(keys, "shutdown_grace")
+	}
+
+	names := make(map[string]struct{}, len(started.Services))
+	for name := range started.Services {
+		names[name] = struct{}{}
+	}
+	for name := range next.Services {
+		names[name] = struct{}{}
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		before, inStarted := started.Services[name]
+		after, inNext := next.Services[name]
+		key := "services." + name
+		if !inStarted || !inNext {
+			keys = append(keys, key)
+			continue
+		}
+
+		if before.RunPolicy != after.RunPolicy {
+			keys = append(keys, key+".run_policy")
+		}
+		if before.RestartPolicy != after.RestartPolicy {
+			keys = append(keys, key+".restart_policy")
+		}
+		if before.StartupDelay != after.StartupDelay {
+			keys = append(keys, key+".startup_delay")
+		}
+		if before.Delay != after.Delay {
+			keys = append(keys, key+".delay")
+		}
+		if before.StopTimeout != after.StopTimeout {
+			keys = append(keys, key+".stop_timeout")
+		}
+		if !reflect.DeepEqual(before.DependsOn, after.DependsOn) {
+			keys = append(keys, key+".depends_on")
+		}
+		if before.Critical != after.Critical {
+			keys = append(keys, key+".critical")
+		}
+		if before.TTL != after.TTL {
+			keys = append(keys, key+".ttl")
+		}
+		if before.MaxRuns != after.MaxRuns {
+			keys = append(keys, key+".max_runs")
+		}
+		if !reflect.DeepEqual(before.Labels, after.Labels) {
+			keys = append(keys, key+".labels")
+		}
+		if !bytes.Equal(before.Settings, after.Settings) {
+			keys = append(keys, key+".settings")
+		}
+	}
+	return keys
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+func TestReloader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.toml")
+	if err := os.WriteFile(path, []byte(testTOML), 0o600); err != nil {
+		t.Fatalf("error writing config file: %s", err)
+	}
+
+	conf, err := Load(path)
+	if err != nil {
+		t.Fatalf("error loading config: %s", err)
+	}
+	reload := Reloader(path, conf)
+
+	changed := `
+log_level = "debug"
+signals = ["SIGTERM"]
+health_interval = "30s"
+
+[services.api]
+restart_policy = "on-failure"
+startup_delay = "1s"
+state_timeouts = { init = "2s" }
+depends_on = ["db"]
+
+[services.worker]
+`
+	if err := os.WriteFile(path, []byte(changed), 0o600); err != nil {
+		t.Fatalf("error writing config file: %s", err)
+	}
+
+	runtime, err := reload()
+	if err != nil {
+		t.Fatalf("error reloading config: %s", err)
+	}
+
+	if runtime.LogLevel == nil || *runtime.LogLevel != log.LevelDebug {
+		t.Errorf("expected the debug log level, got %v", runtime.LogLevel)
+	}
+	if runtime.HealthInterval != 30*time.Second {
+		t.Errorf("expected a 30s health interval, got %s", runtime.HealthInterval)
+	}
+
+	timeouts := map[string]rxd.ManagerStateTimeouts{"api": {rxd.StateInit: 2 * time.Second}}
+	if !reflect.DeepEqual(runtime.StateTimeouts, timeouts) {
+		t.Errorf("expected the state timeouts of api only, got %v", runtime.StateTimeouts)
+	}
+
+	ignored := []string{"signals", "report_alive", "shutdown_grace", "services.migrate", "services.worker"}
+	if !reflect.DeepEqual(runtime.Ignored, ignored) {
+		t.Errorf("expected %v to be ignored, got %v", ignored, runtime.Ignored)
+	}
+
+	if err := os.WriteFile(path, []byte(`log_level = "loud"`), 0o600); err != nil {
+		t.Fatalf("error writing config file: %s", err)
+	}
+	var settingErr ErrInvalidSetting
+	if _, err := reload(); !errors.As(err, &settingErr) || settingErr.Key != "log_level" {
+		t.Errorf("expected the invalid log level to fail the reload, got %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ambitiousfew/rxd"
+)
+
+// Settings decodes the settings block of the named service into a T and checks it against the validate tags
+// of T, so a bad deploy config fails at startup with the path of every offending setting. Settings that T
+// does not define are an error. The settings are then handed to the runner with rxd.WithSettings:
+//
+//	type APISettings struct {
+//		Addr    string   `json:"addr" validate:"required"`
+//		Workers int      `json:"workers" validate:"min=1,max=64"`
+//		Mode    string   `json:"mode" validate:"oneof=strict lenient"`
+//		Timeout Duration `json:"timeout" validate:"min=1s"`
+//	}
+//
+//	settings, err := config.Settings[APISettings](conf, "api")
+//	if err != nil {
+//		return err
+//	}
+//	err = d.AddService(rxd.NewService("api", api, rxd.WithSettings(settings)))
+//
+// The runner reads them back with rxd.SettingsOf[APISettings](sctx).
+// The validate tag holds comma separated rules: required fails on the zero value, min and max bound numbers,
+// durations and the length of strings, slices and maps, oneof lists the allowed values separated by spaces.
+// Nested structs, slices and maps of structs are checked as well.
+func Settings[T any](c Config, service string) (T, error) {
+	var settings T
+	key := "services." + service + ".settings"
+
+	raw := c.Services[service].Settings
+	if len(raw) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&settings); err != nil {
+			return settings, decodeError(key, err)
+		}
+	}
+
+	if errs := validate(key, reflect.ValueOf(&settings).Elem()); len(errs) > 0 {
+		return settings, errors.Join(errs...)
+	}
+	return settings, nil
+}
+
+// WithSettings is Settings returning the service option handing the settings to the runner.
+func WithSettings[T any](c Config, service string) (rxd.ServiceOption, error) {
+	settings, err := Settings[T](c, service)
+	if err != nil {
+		return nil, err
+	}
+	return rxd.WithSettings(settings), nil
+}
+
+// decodeError reports decoding errors with the path of the setting when json tells it.
+func decodeError(key string, err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return ErrInvalidSetting{Key: key + "." + typeErr.Field, Value: typeErr.Value, Err: errors.New("expected " + typeErr.Type.String())}
+	}
+	return ErrInvalidSetting{Key: key, Value: "", Err: err}
+}
+
+var durationTypes = map[reflect.Type]bool{
+	reflect.TypeOf(time.Duration(0)): true,
+	reflect.TypeOf(Duration(0)):      true,
+}
+
+// validate checks v against the validate tags of its fields, key is the path of v in the config file.
+func validate(key string, v reflect.Value) []error {
+	var errs []error
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			errs = append(errs, validate(key, v.Elem())...)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+
+			path := key + "." + name
+			if tag := field.Tag.Get("validate"); tag != "" {
+				errs = append(errs, checkRules(path, tag, v.Field(i))...)
+			}
+			errs = append(errs, validate(path, v.Field(i))...)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			errs = append(errs, validate(key+"."+strconv.Itoa(i), v.Index(i))...)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			errs = append(errs, validate(key+"."+valueString(iter.Key()), iter.Value())...)
+		}
+	}
+	return errs
+}
+
+// checkRules checks the value of a field against the rules of its validate tag.
+func checkRules(key, tag string, v reflect.Value) []error {
+	var errs []error
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+		var err error
+		switch name {
+		case "required":
+			if v.IsZero() {
+				err = ErrRequired
+			}
+		case "min", "max":
+			err = checkBound(name, arg, v)
+		case "oneof":
+			if !v.IsZero() && !slicesContains(strings.Fields(arg), valueString(v)) {
+				err = ErrNotOneOf
+			}
+		default:
+			err = ErrInvalidTag
+		}
+
+		if err != nil {
+			errs = append(errs, ErrInvalidSetting{Key: key, Value: valueString(v), Err: err})
+		}
+	}
+	return errs
+}
+
+// checkBound compares numbers and durations by value, strings, slices and maps by length.
+func checkBound(rule, arg string, v reflect.Value) error {
+	var value, bound float64
+	var err error
+
+	switch kind := v.Kind(); {
+	case durationTypes[v.Type()]:
+		var d time.Duration
+		d, err = time.ParseDuration(arg)
+		value, bound = float64(v.Int()), float64(d)
+	case kind >= reflect.Int && kind <= reflect.Int64:
+		value = float64(v.Int())
+		bound, err = strconv.ParseFloat(arg, 64)
+	case kind >= reflect.Uint && kind <= reflect.Uintptr:
+		value = float64(v.Uint())
+		bound, err = strconv.ParseFloat(arg, 64)
+	case kind == reflect.Float32 || kind == reflect.Float64:
+		value = v.Float()
+		bound, err = strconv.ParseFloat(arg, 64)
+	case kind == reflect.String || kind == reflect.Slice || kind == reflect.Map || kind == reflect.Array:
+		value = float64(v.Len())
+		bound, err = strconv.ParseFloat(arg, 64)
+	default:
+		return ErrInvalidTag
+	}
+
+	if err != nil {
+		return ErrInvalidTag
+	}
+	if rule == "min" && value < bound || rule == "max" && value > bound {
+		return ErrOutOfRange
+	}
+	return nil
+}
+
+// valueString formats a value for error messages, durations as durations.
+func valueString(v reflect.Value) string {
+	if durationTypes[v.Type()] {
+		return time.Duration(v.Int()).String()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	default:
+		return v.Type().String()
+	}
+}
+
+func slicesContains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
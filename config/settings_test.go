@@ -0,0 +1,95 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type testSettings struct {
+	Addr     string            `json:"addr" validate:"required"`
+	Workers  int               `json:"workers" validate:"min=1,max=64"`
+	Mode     string            `json:"mode,omitempty" validate:"oneof=strict lenient"`
+	Timeout  Duration          `json:"timeout" validate:"min=1s"`
+	Backends []testBackend     `json:"backends" validate:"min=1"`
+	Labels   map[string]string `json:"labels,omitempty"`
+}
+
+type testBackend struct {
+	URL    string `json:"url" validate:"required"`
+	Weight uint   `json:"weight" validate:"max=100"`
+}
+
+func TestSettings(t *testing.T) {
+	conf, err := Parse([]byte(`
+[services.api.settings]
+addr = ":8080"
+workers = 8
+mode = "strict"
+timeout = "5s"
+backends = [{ url = "http://a", weight = 10 }]
+`), ".toml")
+	if err != nil {
+		t.Fatalf("error parsing config: %s", err)
+	}
+
+	settings, err := Settings[testSettings](conf, "api")
+	if err != nil {
+		t.Fatalf("error reading settings: %s", err)
+	}
+	if settings.Addr != ":8080" || settings.Workers != 8 || time.Duration(settings.Timeout) != 5*time.Second || settings.Backends[0].Weight != 10 {
+		t.Errorf("unexpected settings %+v", settings)
+	}
+
+	if _, err := Settings[struct{}](conf, "worker"); err != nil {
+		t.Errorf("expected a service without settings to have empty settings, got %v", err)
+	}
+}
+
+func TestSettings_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		key      string
+		err      error
+	}{
+		{"required", `{"workers": 1, "timeout": "1s", "backends": [{"url": "a"}]}`, "services.api.settings.addr", ErrRequired},
+		{"min", `{"addr": "a", "workers": 0, "timeout": "1s", "backends": [{"url": "a"}]}`, "services.api.settings.workers", ErrOutOfRange},
+		{"max", `{"addr": "a", "workers": 65, "timeout": "1s", "backends": [{"url": "a"}]}`, "services.api.settings.workers", ErrOutOfRange},
+		{"duration", `{"addr": "a", "workers": 1, "timeout": "10ms", "backends": [{"url": "a"}]}`, "services.api.settings.timeout", ErrOutOfRange},
+		{"length", `{"addr": "a", "workers": 1, "timeout": "1s", "backends": []}`, "services.api.settings.backends", ErrOutOfRange},
+		{"oneof", `{"addr": "a", "workers": 1, "mode": "loose", "timeout": "1s", "backends": [{"url": "a"}]}`, "services.api.settings.mode", ErrNotOneOf},
+		{"nested", `{"addr": "a", "workers": 1, "timeout": "1s", "backends": [{"url": "a", "weight": 101}]}`, "services.api.settings.backends.0.weight", ErrOutOfRange},
+		{"type", `{"addr": "a", "workers": "many", "timeout": "1s", "backends": [{"url": "a"}]}`, "services.api.settings.workers", nil},
+		{"unknown", `{"addr": "a", "workers": 1, "timeout": "1s", "backends": [{"url": "a"}], "extra": 1}`, "services.api.settings", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := Parse([]byte(`{"services": {"api": {"settings": `+tt.settings+`}}}`), ".json")
+			if err != nil {
+				t.Fatalf("error parsing config: %s", err)
+			}
+
+			_, err = Settings[testSettings](conf, "api")
+			var settingErr ErrInvalidSetting
+			if !errors.As(err, &settingErr) || settingErr.Key != tt.key {
+				t.Fatalf("expected an invalid setting error for %s, got %v", tt.key, err)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("expected %s, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestSettings_InvalidTag(t *testing.T) {
+	type badTag struct {
+		Enabled bool `json:"enabled" validate:"min=1"`
+	}
+
+	conf := Config{Services: map[string]Service{"api": {Settings: []byte(`{"enabled": true}`)}}}
+	if _, err := Settings[badTag](conf, "api"); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("expected %s, got %v", ErrInvalidTag, err)
+	}
+}
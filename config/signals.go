@@ -0,0 +1,13 @@
+package config
+
+import (
+	"os"
+	"syscall"
+)
+
+// signalsByName holds the signals that can stop the daemon, keyed by name without the SIG prefix.
+var signalsByName = map[string]os.Signal{
+	"INT":  syscall.SIGINT,
+	"TERM": syscall.SIGTERM,
+	"QUIT": syscall.SIGQUIT,
+}
@@ -0,0 +1,348 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// decodeTOML decodes the subset of TOML config files need into v, a *map[string]any: tables, dotted keys,
+// strings, integers, floats, booleans, arrays and inline tables. Multi-line strings, dates and arrays of
+// tables are not supported, RegisterFormat can replace it with a complete implementation.
+func decodeTOML(data []byte, v any) error {
+	out, ok := v.(*map[string]any)
+	if !ok {
+		return ErrSyntax{Line: 0, Msg: "toml can only be decoded into a *map[string]any"}
+	}
+
+	p := &tomlParser{data: string(data), line: 1}
+	root, err := p.parse()
+	if err != nil {
+		return err
+	}
+	*out = root
+	return nil
+}
+
+type tomlParser struct {
+	data string
+	pos  int
+	line int
+}
+
+func (p *tomlParser) parse() (map[string]any, error) {
+	root := make(map[string]any)
+	table := root
+
+	for {
+		p.skipBlank(true)
+		if p.eof() {
+			return root, nil
+		}
+
+		if p.peek() == '[' {
+			p.pos++
+			if !p.eof() && p.peek() == '[' {
+				return nil, p.syntaxError("arrays of tables are not supported")
+			}
+
+			keys, err := p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			p.skipBlank(false)
+			if p.eof() || p.peek() != ']' {
+				return nil, p.syntaxError("expected ']' closing the table header")
+			}
+			p.pos++
+
+			if table, err = p.table(root, keys); err != nil {
+				return nil, err
+			}
+		} else if err := p.parseKeyValue(table); err != nil {
+			return nil, err
+		}
+
+		if err := p.endOfLine(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// table returns the table at the path of keys, creating the missing tables along the way.
+func (p *tomlParser) table(root map[string]any, keys []string) (map[string]any, error) {
+	table := root
+	for _, key := range keys {
+		next, ok := table[key]
+		if !ok {
+			created := make(map[string]any)
+			table[key] = created
+			table = created
+			continue
+		}
+
+		if table, ok = next.(map[string]any); !ok {
+			return nil, p.syntaxError("key '" + key + "' is not a table")
+		}
+	}
+	return table, nil
+}
+
+func (p *tomlParser) parseKeyValue(table map[string]any) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+
+	p.skipBlank(false)
+	if p.eof() || p.peek() != '=' {
+		return p.syntaxError("expected '=' after the key")
+	}
+	p.pos++
+	p.skipBlank(false)
+
+	value, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+
+	parent, err := p.table(table, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+
+	key := keys[len(keys)-1]
+	if _, exists := parent[key]; exists {
+		return p.syntaxError("duplicate key '" + key + "'")
+	}
+	parent[key] = value
+	return nil
+}
+
+// parseKey parses a bare, quoted or dotted key.
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		p.skipBlank(false)
+		if p.eof() {
+			return nil, p.syntaxError("expected a key")
+		}
+
+		switch c := p.peek(); {
+		case c == '"' || c == '\'':
+			key, err := p.parseString()
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		case isBareKey(c):
+			start := p.pos
+			for !p.eof() && isBareKey(p.peek()) {
+				p.pos++
+			}
+			keys = append(keys, p.data[start:p.pos])
+		default:
+			return nil, p.syntaxError("expected a key")
+		}
+
+		p.skipBlank(false)
+		if p.eof() || p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+	}
+}
+
+func (p *tomlParser) parseValue() (any, error) {
+	if p.eof() {
+		return nil, p.syntaxError("expected a value")
+	}
+
+	switch c := p.peek(); {
+	case c == '"' || c == '\'':
+		if strings.HasPrefix(p.data[p.pos:], `"""`) || strings.HasPrefix(p.data[p.pos:], `'''`) {
+			return nil, p.syntaxError("multi-line strings are not supported")
+		}
+		return p.parseString()
+	case c == '[':
+		return p.parseArray()
+	case c == '{':
+		return p.parseInlineTable()
+	default:
+		return p.parseScalar()
+	}
+}
+
+// parseString parses a basic string with its escapes or a literal string.
+func (p *tomlParser) parseString() (string, error) {
+	quote := p.peek()
+	start := p.pos
+	p.pos++
+
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == '\n':
+			return "", p.syntaxError("unterminated string")
+		case c == '\\' && quote == '"':
+			p.pos += 2
+		case c == quote:
+			p.pos++
+			if quote == '\'' {
+				return p.data[start+1 : p.pos-1], nil
+			}
+			s, err := strconv.Unquote(p.data[start:p.pos])
+			if err != nil {
+				return "", p.syntaxError("invalid escape in string")
+			}
+			return s, nil
+		default:
+			p.pos++
+		}
+	}
+	return "", p.syntaxError("unterminated string")
+}
+
+func (p *tomlParser) parseArray() ([]any, error) {
+	p.pos++ // [
+	values := []any{}
+	for {
+		p.skipBlank(true)
+		if p.eof() {
+			return nil, p.syntaxError("unterminated array")
+		}
+		if p.peek() == ']' {
+			p.pos++
+			return values, nil
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		p.skipBlank(true)
+		if p.eof() {
+			return nil, p.syntaxError("unterminated array")
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, p.syntaxError("expected ',' or ']' in array")
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable() (map[string]any, error) {
+	p.pos++ // {
+	table := make(map[string]any)
+
+	p.skipBlank(false)
+	if !p.eof() && p.peek() == '}' {
+		p.pos++
+		return table, nil
+	}
+
+	for {
+		if err := p.parseKeyValue(table); err != nil {
+			return nil, err
+		}
+
+		p.skipBlank(false)
+		if p.eof() {
+			return nil, p.syntaxError("unterminated inline table")
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return table, nil
+		default:
+			return nil, p.syntaxError("expected ',' or '}' in inline table")
+		}
+	}
+}
+
+// parseScalar parses a boolean, an integer or a float.
+func (p *tomlParser) parseScalar() (any, error) {
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(" \t\r\n,]}#", rune(p.peek())) {
+		p.pos++
+	}
+	token := p.data[start:p.pos]
+
+	switch token {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "":
+		return nil, p.syntaxError("expected a value")
+	}
+
+	number := strings.ReplaceAll(token, "_", "")
+	if i, err := strconv.ParseInt(number, 0, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(number, 64); err == nil {
+		return f, nil
+	}
+	return nil, p.syntaxError("unsupported value '" + token + "'")
+}
+
+// skipBlank skips spaces and tabs, along with comments and new lines when lines is set.
+func (p *tomlParser) skipBlank(lines bool) {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\r':
+			p.pos++
+		case '\n':
+			if !lines {
+				return
+			}
+			p.line++
+			p.pos++
+		case '#':
+			if !lines {
+				return
+			}
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// endOfLine expects nothing but a comment until the end of the line.
+func (p *tomlParser) endOfLine() error {
+	p.skipBlank(false)
+	if !p.eof() && p.peek() == '#' {
+		for !p.eof() && p.peek() != '\n' {
+			p.pos++
+		}
+	}
+	if !p.eof() && p.peek() != '\n' {
+		return p.syntaxError("expected the end of the line")
+	}
+	return nil
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.data)
+}
+
+func (p *tomlParser) peek() byte {
+	return p.data[p.pos]
+}
+
+func (p *tomlParser) syntaxError(msg string) error {
+	return ErrSyntax{Line: p.line, Msg: msg}
+}
+
+func isBareKey(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeTOML(t *testing.T) {
+	data := `
+# comment
+title = "rxd \"daemon\"" # trailing comment
+path = 'C:\rxd'
+count = 1_000
+ratio = 0.5
+enabled = true
+dotted.key = "value"
+list = [
+	"a", # first
+	'b',
+]
+
+[servers."api.v2"]
+ports = [8080, 8081]
+limits = { cpu = 0.5, "memory" = "1GiB" }
+`
+	var got map[string]any
+	if err := decodeTOML([]byte(data), &got); err != nil {
+		t.Fatalf("error decoding toml: %s", err)
+	}
+
+	want := map[string]any{
+		"title":   `rxd "daemon"`,
+		"path":    `C:\rxd`,
+		"count":   int64(1000),
+		"ratio":   0.5,
+		"enabled": true,
+		"dotted":  map[string]any{"key": "value"},
+		"list":    []any{"a", "b"},
+		"servers": map[string]any{
+			"api.v2": map[string]any{
+				"ports":  []any{int64(8080), int64(8081)},
+				"limits": map[string]any{"cpu": 0.5, "memory": "1GiB"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestDecodeTOML_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		line int
+	}{
+		{"missing equals", "key \"value\"", 1},
+		{"duplicate key", "a = 1\na = 2", 2},
+		{"unterminated string", "\n\na = \"value", 3},
+		{"unterminated array", "a = [1, 2", 1},
+		{"trailing garbage", "a = 1 2", 1},
+		{"table over value", "a = 1\n[a]", 2},
+		{"array of tables", "[[a]]", 1},
+		{"multi-line string", `a = """value"""`, 1},
+		{"date", "a = 1979-05-27", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]any
+			err := decodeTOML([]byte(tt.data), &got)
+
+			var syntaxErr ErrSyntax
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("expected a syntax error, got %v", err)
+			}
+			if syntaxErr.Line != tt.line {
+				t.Errorf("expected the error on line %d, got %s", tt.line, syntaxErr)
+			}
+		})
+	}
+}
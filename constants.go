@@ -4,6 +4,11 @@ const (
 	prefix string = "_rxd"
 	// helper consts to build prefixes for internal consumer names of internal states
 	internalServiceStates  string = prefix + ".states"
+	internalServiceHealth  string = prefix + ".health"
 	internalSignals        string = prefix + ".signals"
 	internalSignalsManager string = prefix + ".signals.manager"
+	internalServiceTopics  string = prefix + ".topics." // prefixes the topics services publish to, see ServiceContext.Publish.
+
+	// envIsolatedService is set on child processes running a single isolated service.
+	envIsolatedService string = "RXD_ISOLATED_SERVICE"
 )
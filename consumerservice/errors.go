@@ -0,0 +1,26 @@
+package consumerservice
+
+// Error is a custom error type for the consumerservice package.
+type Error string
+
+const (
+	ErrNoConsumer = Error("no consumer provided")
+	ErrNoHandler  = Error("no handler provided")
+)
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// ErrHandle is returned by Run when the handler failed a message, the messages handled before it are committed on Stop.
+type ErrHandle struct {
+	Err error
+}
+
+func (e ErrHandle) Error() string {
+	return "handling message failed: " + e.Err.Error()
+}
+
+func (e ErrHandle) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,13 @@
+package consumerservice
+
+import "time"
+
+type Option[M any] func(*ConsumerRunner[M])
+
+// WithCommitInterval also commits the handled messages every interval while running,
+// bounding how many messages are redelivered if the daemon crashes. By default messages are only committed on Stop.
+func WithCommitInterval[M any](interval time.Duration) Option[M] {
+	return func(r *ConsumerRunner[M]) {
+		r.commitInterval = interval
+	}
+}
@@ -0,0 +1,175 @@
+// Package consumerservice provides a ServiceRunner running the consume loop of a message queue client,
+// such as a Kafka consumer group, through a small adapter implementing Consumer.
+package consumerservice
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ambitiousfew/rxd"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+var _ rxd.ServiceRunner = (*ConsumerRunner[any])(nil)
+
+// Consumer is implemented by an adapter around a message queue client.
+type Consumer[M any] interface {
+	// Connect connects to the brokers and joins the consumer group.
+	Connect(ctx context.Context) error
+	// Poll blocks until messages are available or ctx is done.
+	Poll(ctx context.Context) ([]M, error)
+	// Commit marks the messages as processed, they are given in the order they were handled.
+	Commit(ctx context.Context, messages []M) error
+	// Close leaves the consumer group and releases the connection.
+	Close() error
+}
+
+// Handler processes a single message.
+type Handler[M any] func(ctx context.Context, message M) error
+
+// ConsumerRunner is a ServiceRunner connecting the consumer in Init, consuming in Run and committing the
+// handled messages before closing the consumer in Stop. Run returns when polling fails or the handler
+// fails a message, the service manager decides when to reconnect.
+type ConsumerRunner[M any] struct {
+	consumer       Consumer[M]
+	handler        Handler[M]
+	commitInterval time.Duration
+
+	connected bool
+	handled   []M // handled messages that are not committed yet.
+	mu        sync.Mutex
+}
+
+// New creates a ConsumerRunner handing every message polled from consumer to handler.
+func New[M any](consumer Consumer[M], handler Handler[M], opts ...Option[M]) *ConsumerRunner[M] {
+	r := &ConsumerRunner[M]{
+		consumer: consumer,
+		handler:  handler,
+		mu:       sync.Mutex{},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *ConsumerRunner[M]) Init(sctx rxd.ServiceContext) error {
+	if r.consumer == nil {
+		return ErrNoConsumer
+	}
+	if r.handler == nil {
+		return ErrNoHandler
+	}
+
+	if err := r.consumer.Connect(sctx); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.connected = true
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *ConsumerRunner[M]) Idle(sctx rxd.ServiceContext) error {
+	return nil
+}
+
+func (r *ConsumerRunner[M]) Run(sctx rxd.ServiceContext) error {
+	var commitC <-chan time.Time
+	if r.commitInterval > 0 {
+		ticker := time.NewTicker(r.commitInterval)
+		defer ticker.Stop()
+		commitC = ticker.C
+	}
+
+	for {
+		select {
+		case <-sctx.Done():
+			return nil
+		case <-commitC:
+			if err := r.commit(sctx); err != nil {
+				return err
+			}
+			continue
+		default:
+		}
+
+		messages, err := r.consumer.Poll(sctx)
+		if err != nil {
+			if sctx.Err() != nil {
+				// polling was interrupted by the service stopping.
+				return nil
+			}
+			return err
+		}
+
+		for _, message := range messages {
+			if err := r.handler(sctx, message); err != nil {
+				// the failed message is not committed so it is redelivered once the consumer reconnects.
+				return ErrHandle{Err: err}
+			}
+
+			r.mu.Lock()
+			r.handled = append(r.handled, message)
+			r.mu.Unlock()
+		}
+	}
+}
+
+func (r *ConsumerRunner[M]) Stop(sctx rxd.ServiceContext) error {
+	r.mu.Lock()
+	connected := r.connected
+	r.connected = false
+	r.mu.Unlock()
+
+	if !connected {
+		return nil
+	}
+
+	// the service context may already be done, committing must still reach the brokers
+	// within the shutdown deadline of the daemon if it has one.
+	ctx := context.WithoutCancel(sctx)
+	if deadline, ok := sctx.ShutdownDeadline(); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	err := r.commit(ctx)
+	if err != nil {
+		sctx.Log(log.LevelError, "error committing handled messages", log.Error("error", err))
+	}
+
+	// messages that could not be committed are redelivered to the next session of the consumer.
+	r.mu.Lock()
+	r.handled = nil
+	r.mu.Unlock()
+
+	if cerr := r.consumer.Close(); cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+// commit commits the handled messages, they are kept for the next commit if it fails.
+func (r *ConsumerRunner[M]) commit(ctx context.Context) error {
+	r.mu.Lock()
+	handled := r.handled
+	r.handled = nil
+	r.mu.Unlock()
+
+	if len(handled) == 0 {
+		return nil
+	}
+
+	if err := r.consumer.Commit(ctx, handled); err != nil {
+		r.mu.Lock()
+		r.handled = append(handled, r.handled...)
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
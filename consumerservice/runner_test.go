@@ -0,0 +1,86 @@
+package consumerservice
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// fakeConsumer hands out its messages in batches of two.
+type fakeConsumer struct {
+	messages  []int
+	committed []int
+	closed    bool
+	mu        sync.Mutex
+}
+
+func (c *fakeConsumer) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (c *fakeConsumer) Poll(ctx context.Context) ([]int, error) {
+	c.mu.Lock()
+	n := min(2, len(c.messages))
+	batch := c.messages[:n]
+	c.messages = c.messages[n:]
+	c.mu.Unlock()
+
+	if n == 0 {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return batch, nil
+}
+
+func (c *fakeConsumer) Commit(ctx context.Context, messages []int) error {
+	c.mu.Lock()
+	c.committed = append(c.committed, messages...)
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *fakeConsumer) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	return nil
+}
+
+func TestConsumerRunner_CommitsHandledOnStop(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	errPoison := errors.New("poison message")
+	consumer := &fakeConsumer{messages: []int{1, 2, 3, 4, 5}}
+	runner := New[int](consumer, func(ctx context.Context, message int) error {
+		if message == 4 {
+			return errPoison
+		}
+		return nil
+	})
+
+	d := rxd.NewDaemon("test-daemon", rxd.WithServiceLogger(log.NewLogger(log.LevelError, log.NewHandler())))
+	if err := d.AddService(rxd.NewService("consumer", runner, rxd.WithManager(rxd.NewRunOnceManager(0)))); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	err := d.Start(ctx)
+	var herr ErrHandle
+	if !errors.As(err, &herr) || !errors.Is(err, errPoison) {
+		t.Fatalf("expected the handler error, got %v", err)
+	}
+
+	consumer.mu.Lock()
+	defer consumer.mu.Unlock()
+	if len(consumer.committed) != 3 || consumer.committed[2] != 3 {
+		t.Errorf("expected messages 1 to 3 to be committed, got %v", consumer.committed)
+	}
+	if !consumer.closed {
+		t.Errorf("expected the consumer to be closed on stop")
+	}
+}
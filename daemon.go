@@ -2,18 +2,19 @@ package rxd
 
 import (
 	"context"
-	"io"
+	"errors"
 	"net/http"
 	"net/rpc"
 	"os"
-	"os/signal"
 	"reflect"
+	"runtime"
 	"strconv"
 	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/ambitiousfew/rxd/activation"
 	"github.com/ambitiousfew/rxd/intracom"
 	"github.com/ambitiousfew/rxd/log"
 )
@@ -21,23 +22,108 @@ import (
 type Daemon interface {
 	AddServices(services ...Service) error
 	AddService(service Service) error
+	RemoveService(name string) error
+	Recycle(name string) error
+	RestartService(name string) error
+	ResumeService(name string) error
+	AddServiceGroup(group ServiceGroup) error
+	StartGroup(name string) error
+	StopGroup(name string) error
+	RollingRestart(name string, maxUnavailable int) error
+	GroupStates() map[string]GroupState
+	Reload()
+	SetTraceSampling(name string, sampling TraceSampling) error
+	Results() map[string]error
+	ServiceErrors() map[string]error
+	History(service string) []StateChange
+	Health() ServicesHealth
 	Start(ctx context.Context) error
+	ThrottleStats() map[string]ThrottleStats
+	Usage() map[string]ServiceUsage
+	Topics() []intracom.TopicStats
+	SetPacing(pacing Pacing)
 }
 
 type daemon struct {
-	name            string                    // name of the daemon will be used in logging
-	signals         []os.Signal               // OS signals you want your daemon to listen for
-	services        map[string]DaemonService  // map of service name to struct carrying the service runner and name.
-	managers        map[string]ServiceManager // map of service name to service handler that will run the service runner methods.
-	prestart        Pipeline                  // prestart pipeline to run before starting the daemon services
-	ic              *intracom.Intracom        // intracom registry for the daemon to communicate with services
-	reportAliveSecs uint64                    // system service manager alive report timeout in seconds aka watchdog timeout
-	logWorkerCount  int                       // number of concurrent log workers used to receive and write service logs (default: 2)
-	serviceLogger   log.Logger                // logger used by user services
-	internalLogger  log.Logger                // logger for the internal daemon, debugging
-	started         atomic.Bool               // flag to indicate if the daemon has been started
-	rpcEnabled      bool                      // flag to indicate if the daemon has rpc enabled
-	rpcConfig       RPCConfig                 // rpc configuration for the daemon
+	name             string                                  // name of the daemon will be used in logging
+	signals          []os.Signal                             // OS signals you want your daemon to listen for
+	services         map[string]DaemonService                // map of service name to struct carrying the service runner and name.
+	managers         map[string]ServiceManager               // map of service name to service handler that will run the service runner methods.
+	configs          map[string]serviceConfig                // map of service name to the options the daemon applies when running the service.
+	groups           map[string][]string                     // map of group name to the names of its member services.
+	paused           map[string]chan struct{}                // map of paused service name to the channel closed once it is resumed or removed.
+	throttles        map[string]*cpuThrottle                 // map of service name to cpu share throttle, only for services with a cpu share.
+	pacing           *atomic.Pointer[Pacing]                 // pacing policy shared with every service context, can change at runtime.
+	extractors       []FieldExtractor                        // extractors applied to every service context log call.
+	bridgeLogs       bool                                    // capture the standard library log and slog default output into the service logger.
+	niceness         Niceness                                // cooperative scheduling hints for the manager routines and states watcher.
+	shutdownGrace    time.Duration                           // time services are given to clean up once the daemon begins shutting down.
+	shutdownOrder    []string                                // services stopped one after another, before any other service, on shutdown.
+	readinessPolicy  ReadinessPolicy                         // decides when the daemon is ready from the states of its services.
+	readinessTimeout time.Duration                           // time the daemon has to become ready before it stops, 0 waits forever.
+	startupTimeout   time.Duration                           // time the services have to leave their init state, 0 waits forever.
+	startupAbort     bool                                    // stop the daemon when services have not left their init state in time.
+	statusFile       string                                  // path the readiness and states report is written to, empty disables it.
+	readiness        *readinessTracker                       // evaluates the readiness policy, set once the daemon has started.
+	startup          *startupTracker                         // services that left their init state, set once started with a startup timeout.
+	tracer           Tracer                                  // records the spans of services, nil disables tracing.
+	samplers         map[string]*traceSampler                // map of service name to its trace sampling.
+	results          *serviceResults                         // terminal errors of services that ran to completion.
+	lastErrors       *lastErrors                             // most recent lifecycle error of every service.
+	labels           *serviceLabels                          // labels of the services, read by service filters.
+	history          *stateHistory                           // last state changes of every service, see WithStateHistory.
+	watchBuffer      int                                     // state changes kept for each WatchAllStates receiver.
+	units            []string                                // external units published as virtual services, see WithExternalUnits.
+	unitSource       UnitSource                              // reports the state of the external units.
+	health           *healthProber                           // latest health of the services implementing HealthChecker.
+	healthInterval   time.Duration                           // how often services are probed for their health.
+	healthTimeout    time.Duration                           // time a single health check may take before it fails.
+	unitInterval     time.Duration                           // how often the unit source is polled.
+	prestart         Pipeline                                // prestart pipeline to run before starting the daemon services
+	preStartHooks    []Hook                                  // run in order before any service starts, a failure aborts startup.
+	postStopHooks    []Hook                                  // run in reverse order once all services have exited.
+	ic               *intracom.Intracom                      // intracom registry for the daemon to communicate with services
+	reportAliveSecs  uint64                                  // system service manager alive report timeout in seconds aka watchdog timeout
+	logWorkerCount   int                                     // number of concurrent log workers used to receive and write service logs (default: 2)
+	serviceLogger    log.Logger                              // logger used by user services
+	internalLogger   log.Logger                              // logger for the internal daemon, debugging
+	started          atomic.Bool                             // flag to indicate if the daemon has been started
+	runs             map[string]*serviceRun                  // map of service name to its running routine, once the daemon has started.
+	rt               *daemonRuntime                          // channels and context shared by service routines, set once the daemon has started.
+	mu               sync.RWMutex                            // guards the service maps and runtime since services can be added and removed at runtime.
+	rpcEnabled       bool                                    // flag to indicate if the daemon has rpc enabled
+	rpcConfig        RPCConfig                               // rpc configuration for the daemon
+	adminAddr        string                                  // address of the http admin server, empty disables it.
+	controlAddr      string                                  // address the control server listens on.
+	controlFactory   func(plane *ControlPlane) ControlServer // creates the control server, nil disables it.
+	memory           memoryTuning                            // go runtime memory settings applied while the daemon runs.
+	ctlSocket        string                                  // path of the control socket, empty disables it.
+	ctlProxyDir      string                                  // directory of the sockets of the daemons the control socket forwards to.
+	tail             logTail                                 // fans the service logs out to the control socket.
+	expectations     []Expectation                           // states the services are expected to be in, monitored while the daemon runs.
+	alerters         []Alerter                               // notified of deviations from the expectations, logs a warning when empty.
+	transitionHooks  []TransitionFunc                        // called with every state transition of every service.
+	serviceHooks     sync.Map                                // map of service name to its transition hooks, read by the states watcher without the daemon lock.
+	emergencyHooks   []EmergencyHook                         // called once a service logs at LevelCritical or above.
+	resolver         ResolveFunc                             // builds the runners of services added without one, see UsingResolver.
+	values           map[any]any                             // shared dependencies every service context holds, see UsingContextValues.
+	webhooks         []webhook                               // posted the events they subscribed to, see UsingWebhooks.
+	webhookSecret    []byte                                  // signs the events posted to the webhooks, see UsingWebhookSecret.
+	dispatcher       atomic.Pointer[webhookDispatcher]       // delivers the events to the webhooks while the daemon runs.
+	configLoader     ConfigLoader                            // loads the runtime settings on SIGHUP, see UsingConfigReload.
+	debugScheduler   *DeterministicScheduler                 // runs the lifecycles of the services one at a time, nil runs them freely.
+	resourceWatch    *ResourceWatch                          // warns of file descriptor and memory exhaustion, nil disables it.
+	codec            Codec                                   // encodes the status file and the admin api responses (default: JSONCodec).
+	rolloutTimeout   time.Duration                           // time a batch of a rolling restart has to recover before it is aborted.
+	socketActivation bool                                    // hand the sockets passed by systemd to the services, see UsingSocketActivation.
+	sockets          *activatedSockets                       // sockets passed by systemd, set once the daemon has started with socket activation.
+	pid1             bool                                    // reap orphaned processes and forward signals when running as pid 1, see UsingPID1Mode.
+	signalShutdowns  map[os.Signal]SignalShutdown            // how the daemon shuts down per stopping signal, see WithSignalShutdown.
+	stopTimeoutCap   atomic.Int64                            // caps the stop timeout of every service once set by the signal received.
+	barriers         map[string]*barrier                     // services wait at them with WaitBarrier, see WithBarrier.
+	redactor         *log.Redactor                           // redacts secrets from every log before the handlers, see UsingRedaction.
+	quarantine       quarantinePolicy                        // quarantines flapping services without a policy of their own, see UsingQuarantine.
+	emergencyMu      sync.Mutex                              // serializes the emergency hooks.
 }
 
 // NewDaemon creates and return an instance of the reactive daemon
@@ -48,16 +134,32 @@ func NewDaemon(name string, options ...DaemonOption) Daemon {
 	defaultLogger := log.NewLogger(log.LevelInfo, log.NewHandler())
 
 	d := &daemon{
-		name:     name,
-		signals:  []os.Signal{syscall.SIGINT, syscall.SIGTERM},
-		services: make(map[string]DaemonService),
-		managers: make(map[string]ServiceManager),
+		name:       name,
+		signals:    []os.Signal{syscall.SIGINT, syscall.SIGTERM},
+		services:   make(map[string]DaemonService),
+		managers:   make(map[string]ServiceManager),
+		configs:    make(map[string]serviceConfig),
+		throttles:  make(map[string]*cpuThrottle),
+		runs:       make(map[string]*serviceRun),
+		samplers:   make(map[string]*traceSampler),
+		groups:     make(map[string][]string),
+		paused:     make(map[string]chan struct{}),
+		results:    newServiceResults(),
+		lastErrors: newLastErrors(),
+		labels:     newServiceLabels(),
+		history:    newStateHistory(defaultHistorySize),
+		health:     &healthProber{},
+		pacing:     &atomic.Pointer[Pacing]{},
 		prestart: &prestartPipeline{
 			RestartOnError: true,
 			RestartDelay:   5 * time.Second,
 			Stages:         []Stage{},
 		},
 		ic:              intracom.New("rxd-intracom"),
+		codec:           JSONCodec,
+		rolloutTimeout:  time.Minute,
+		healthInterval:  10 * time.Second,
+		healthTimeout:   5 * time.Second,
 		reportAliveSecs: 0,
 		logWorkerCount:  2,
 		serviceLogger:   defaultLogger,
@@ -84,38 +186,7 @@ func NewDaemon(name string, options ...DaemonOption) Daemon {
 // NOTE: this can also be set by passing the WithServiceLogger option in NewDaemon
 // This is to support the old pattern of creating a daemon with a custom service logger.
 func NewDaemonWithLogger(name string, logger log.Logger, options ...DaemonOption) Daemon {
-	d := &daemon{
-		name:     name,
-		signals:  []os.Signal{syscall.SIGINT, syscall.SIGTERM},
-		services: make(map[string]DaemonService),
-		managers: make(map[string]ServiceManager),
-		prestart: &prestartPipeline{
-			RestartOnError: true,
-			RestartDelay:   5 * time.Second,
-			Stages:         []Stage{},
-		},
-		ic:              intracom.New("rxd-intracom"),
-		reportAliveSecs: 0,
-		logWorkerCount:  2,
-		serviceLogger:   logger,
-		// by default the internal daemon logger is disabled.
-		internalLogger: log.NewLogger(log.LevelDebug, &daemonLogHandler{
-			filepath: "rxd.log",        // relative to the executable, if enabled
-			enabled:  false,            // disabled by default
-			total:    0,                // total bytes written to the log file
-			limit:    10 * 1024 * 1024, // 10MB (if enabled)
-			file:     nil,
-			mu:       sync.RWMutex{},
-		}),
-		started: atomic.Bool{},
-	}
-
-	for _, option := range options {
-		option(d)
-	}
-
-	return d
-
+	return NewDaemon(name, append([]DaemonOption{WithServiceLogger(logger)}, options...)...)
 }
 
 func (d *daemon) Start(parent context.Context) error {
@@ -124,28 +195,121 @@ func (d *daemon) Start(parent context.Context) error {
 		return ErrDaemonStarted
 	}
 
+	// every log of the daemon, its services and the bridged log packages goes through these loggers.
+	if d.redactor != nil {
+		d.serviceLogger = redactingLogger{Logger: d.serviceLogger, redactor: d.redactor}
+		d.internalLogger = redactingLogger{Logger: d.internalLogger, redactor: d.redactor}
+		d.tail.redactor = d.redactor
+	}
+
 	if len(d.services) == 0 {
 		return ErrNoServices
 	}
 
+	// the loggers are flushed and closed last, post-stop hooks still log through them.
+	defer d.closeLoggers()
+
+	if name := os.Getenv(envIsolatedService); name != "" {
+		// this process is a child of a daemon running one of its services in isolation.
+		return d.runIsolated(parent, name)
+	}
+
+	if err := d.checkDependencies(); err != nil {
+		return err
+	}
+
+	d.mu.RLock()
+	err := d.checkShutdownOrderLocked()
+	d.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	// post-stop hooks run even when a pre-start hook failed, to release what the earlier hooks acquired.
+	if err := d.runPreStartHooks(parent); err != nil {
+		return errors.Join(err, d.runPostStopHooks(parent))
+	}
+
+	err = d.serve(parent)
+	return errors.Join(err, d.runPostStopHooks(parent))
+}
+
+// serve runs the services of the daemon until they have all exited and the daemon has cleaned up.
+func (d *daemon) serve(parent context.Context) error {
 	nameField := log.String("rxd", d.name)
 
 	// daemon child context from parent
 	dctx, dcancel := context.WithCancel(parent)
 	defer dcancel()
 
+	// --- Go Runtime Memory ---
+	// the previous settings are restored once the daemon stops, since they are process wide.
+	defer d.applyMemoryTuning()()
+	d.internalLogger.Log(log.LevelInfo, "starting daemon", append(runtimeFields(), nameField)...)
+	d.logGCStats(dctx)
+	d.watchResources(dctx)
+
+	// every service context derives from the daemon context and carries the shutdown deadline.
+	clock := &shutdownClock{grace: d.shutdownGrace, doneC: dctx.Done()}
+	dctx = context.WithValue(dctx, shutdownDeadlineKey{}, clock)
+
+	// --- Init Mode ---
+	// as pid 1 of a container the daemon reaps orphaned processes and forwards signals until it returns.
+	if d.pid1 {
+		defer d.runInit(nameField)()
+	}
+
+	// --- Socket Activation ---
+	// the sockets passed by systemd are kept open until the daemon stops, services get duplicates of them.
+	if d.socketActivation {
+		files, err := activation.Files()
+		if err != nil {
+			d.internalLogger.Log(log.LevelError, "error reading the activated sockets", log.Error("error", err), nameField)
+			return err
+		}
+		d.sockets = &activatedSockets{files: files}
+		defer d.sockets.close()
+		d.internalLogger.Log(log.LevelInfo, "inherited activated sockets", log.String("names", d.sockets.names()), nameField)
+	}
+
 	// --- Service Manager Notifier ---
-	// TODO:: Future work here will be to support multiple platform service managers
-	// such as windows service manager, systemd, etc.
-	//
-	// This will require manager selection to be selected dynamically at runtime.
-	// notifier := GetSystemNotifier(ctx) --- probably...
-	// For now, we are only supporting linux - systemd.
-	notifier, err := NewSystemdNotifier(os.Getenv("NOTIFY_SOCKET"), d.reportAliveSecs)
+	// the notifier of the platform service manager: systemd on linux, the service control manager on windows.
+	// a service manager requesting the daemon to stop cancels the daemon context.
+	notifier, err := newSystemNotifier(d.name, d.reportAliveSecs, dcancel)
 	if err != nil {
-		d.internalLogger.Log(log.LevelError, "error creating systemd notifier", log.Error("error", err), nameField)
+		d.internalLogger.Log(log.LevelError, "error creating system notifier", log.Error("error", err), nameField)
 		return err
 	}
+	defer func() {
+		if err := notifier.Notify(NotifyStateStopped); err != nil {
+			d.internalLogger.Log(log.LevelError, "error sending 'stopped' notification", log.Error("error", err), nameField)
+		}
+	}()
+
+	// --- Readiness ---
+	// readiness is evaluated by the states watcher, READY is notified the first time the policy is satisfied.
+	policy := d.readinessPolicy
+	if policy == nil {
+		policy = AllOf()
+	}
+	d.readiness = &readinessTracker{
+		policy:     policy,
+		notifier:   notifier,
+		statusFile: d.statusFile,
+		codec:      d.codec,
+		logger:     d.internalLogger,
+		readyC:     make(chan struct{}),
+		errors:     d.lastErrors,
+	}
+	if d.startupTimeout > 0 {
+		d.startup = &startupTracker{started: make(map[string]struct{})}
+	}
+
+	// the watchdog keep-alive is withheld while a critical service is wedged or unhealthy,
+	// so the service manager restarts the daemon.
+	if gate, ok := notifier.(watchdogGate); ok {
+		gate.gateWatchdog(d.watchdogAlive)
+	}
 
 	d.internalLogger.Log(log.LevelDebug, "starting system notifier", nameField)
 	// Start the notifier, this will start the watchdog portion.
@@ -156,27 +320,70 @@ func (d *daemon) Start(parent context.Context) error {
 		return err
 	}
 
+	// --- Standard Library Log Bridge ---
+	// routes log and slog default output into the service logger until the daemon stops.
+	if d.bridgeLogs {
+		bridge := newLogBridge(d.serviceLogger)
+		bridge.install()
+		defer bridge.uninstall()
+	}
+
 	logC := make(chan DaemonLog, 50)
 	// --- Start the Daemon Service Log Watcher ---
 	// listens for logs from services via channel and logs them to the daemon logger.
 	loggerDoneC := d.serviceLogWatcher(logC)
 
+	// --- Webhooks ---
+	// posts the events of the daemon and its services, the queued events are delivered once the daemon has stopped.
+	stopWebhooks := d.startWebhooks(nameField)
+	defer stopWebhooks()
+	// the daemon stops once signaled or once every service has exited on its own, whichever comes first.
+	var stoppingOnce sync.Once
+	stopping := func() {
+		stoppingOnce.Do(func() { d.sendWebhook(WebhookPayload{Event: EventDaemonStopping}) })
+	}
+
 	// --- Daemon Signal Watcher ---
 	// listens for signals to stop the daemon such as OS signals or context done.
+	// SIGHUP reloads the services implementing Reloader instead.
 	go func() {
-		signalC := make(chan os.Signal, 1)
-		signal.Notify(signalC, syscall.SIGINT, syscall.SIGTERM)
-		defer signal.Stop(signalC)
-
-		select {
-		case <-dctx.Done():
-			d.internalLogger.Log(log.LevelDebug, "signal watcher received context done from parent context", nameField)
-		case sig := <-signalC:
-			d.internalLogger.Log(log.LevelNotice, "signal watcher received an os signal", log.String("signal", sig.String()), nameField)
-			// if we received a signal to stop, cancel the context
-			dcancel()
+		// a panic, e.g. in the Reload of a service, shuts the daemon down rather than leaving it deaf to signals.
+		defer d.recoverPanic("signal watcher", func(PanicError) { dcancel() })
+		signalC, reloadC, stopSignals := watchSignals(d.stopSignals())
+		defer stopSignals()
+
+	watch:
+		for {
+			select {
+			case <-dctx.Done():
+				d.internalLogger.Log(log.LevelDebug, "signal watcher received context done from parent context", nameField)
+				break watch
+			case <-reloadC:
+				d.internalLogger.Log(log.LevelNotice, "signal watcher received a reload signal", nameField)
+				// reopen log files first, they may have just been rotated.
+				d.reopenLoggers(nameField)
+				if err := notifier.Notify(NotifyStateReloading); err != nil {
+					d.internalLogger.Log(log.LevelError, "error sending 'reloading' notification", nameField)
+				}
+				d.readiness.reloading()
+				d.reloadConfig(nameField)
+				d.Reload()
+			case sig := <-signalC:
+				d.internalLogger.Log(log.LevelNotice, "signal watcher received an os signal", log.String("signal", sig.String()), nameField)
+				// if we received a signal to stop, cancel the context
+				d.applySignalShutdown(sig, clock, notifier, nameField)
+				dcancel()
+				break watch
+			}
+		}
+
+		// pin the shutdown deadline to when the shutdown began rather than when a service first asks for it.
+		if deadline, ok := clock.Deadline(); ok {
+			d.internalLogger.Log(log.LevelDebug, "shutdown deadline set", log.String("deadline", deadline.Format(time.RFC3339Nano)), nameField)
 		}
 
+		stopping()
+
 		// inform systemd that we are stopping/cleaning up
 		// TODO: Test if this notify should happen before or after cancel()
 		// since the watchdog notify continues to until the context is cancelled.
@@ -198,6 +405,8 @@ func (d *daemon) Start(parent context.Context) error {
 		Name: internalServiceStates,
 		// Buffer:      1,
 		ErrIfExists: true,
+		// watchers subscribing once the states settled start from the current ones.
+		ReplayLast: true,
 	})
 
 	if err != nil {
@@ -205,54 +414,78 @@ func (d *daemon) Start(parent context.Context) error {
 		return err
 	}
 
+	d.mu.RLock()
 	stateUpdateC := make(chan StateUpdate, len(d.services)*4)
+	d.mu.RUnlock()
 
 	// --- Service States Watcher ---
 	// states watcher routine needs to be closed once all services have exited.
 	d.internalLogger.Log(log.LevelInfo, "starting service states watcher", nameField)
 	statesDoneC := d.statesWatcher(statesTopic, stateUpdateC)
 
-	d.internalLogger.Log(log.LevelInfo, "starting "+strconv.Itoa(len(d.services))+" services", nameField)
-	var dwg sync.WaitGroup // daemon wait group
-
-	// --- Launch Daemon Service(s) ---
-	// launch all services in their own routine.
-	for _, service := range d.services {
-		manager, ok := d.managers[service.Name]
-		if !ok {
-			// TODO: Should we be doing pre-flight checks?
-			// is it better to log the error and still try to start the daemon with the services that dont error
-			// or is it better to fail fast and exit the daemon with an error?
-			d.internalLogger.Log(log.LevelError, "error getting manager for service", log.String("service_name", service.Name), nameField)
-			continue
-		}
-
-		dwg.Add(1)
-		// each service is handled in its own routine.
-		go func(ctx context.Context, wg *sync.WaitGroup, ds DaemonService, manager ServiceManager, stateC chan<- StateUpdate) {
-			sctx, scancel := newServiceContextWithCancel(ctx, ds.Name, logC, d.ic)
-
-			defer func() {
-				// recover from any panics in the service runner
-				// no service should be able to crash the daemon.
-				if r := recover(); r != nil {
-					d.serviceLogger.Log(log.LevelError, "recovered from panic", log.String("service", ds.Name), log.Any("error", r))
-					d.internalLogger.Log(log.LevelError, "recovered from panic", log.String("service_name", ds.Name), log.Any("error", r), nameField)
-					stateC <- StateUpdate{Name: ds.Name, State: StateExit}
-				}
-				scancel()
-				wg.Done()
-				d.internalLogger.Log(log.LevelInfo, "service has stopped", log.String("service_name", ds.Name), nameField)
-			}()
+	// --- External Units Watcher ---
+	// publishes the state of host units as virtual services until the daemon begins shutting down.
+	watchersCtx, watchersCancel := context.WithCancel(dctx)
+	defer watchersCancel()
+	unitsDoneC := d.watchUnits(watchersCtx, stateUpdateC)
 
-			d.internalLogger.Log(log.LevelInfo, "starting service", log.String("service_name", ds.Name), nameField)
-			// run the service according to the manager policy
-			manager.Manage(sctx, ds, stateC)
-			// scancel()
-			// wg.Done()
+	// --- Health Prober ---
+	// probes the services implementing HealthChecker until the daemon begins shutting down.
+	healthTopic, err := intracom.CreateTopic[ServicesHealth](d.ic, intracom.TopicConfig{
+		Name:        internalServiceHealth,
+		ErrIfExists: true,
+		ReplayLast:  true,
+	})
+	if err != nil {
+		d.internalLogger.Log(log.LevelError, "error creating intracom topic", log.Error("error", err), nameField)
+		return err
+	}
+	healthC := make(chan time.Duration, 1)
+	healthDoneC := d.probeHealth(watchersCtx, statesTopic, healthTopic, healthC)
+	baselineDoneC := d.monitorBaseline(watchersCtx, statesTopic)
+
+	// --- Deterministic Scheduler ---
+	// hands out the turns of the services until they have all exited.
+	var sched *scheduler
+	schedCtx, schedCancel := context.WithCancel(context.Background())
+	defer schedCancel()
+	if d.debugScheduler != nil {
+		d.internalLogger.Log(log.LevelWarning, "services run one lifecycle at a time on the deterministic scheduler", log.String("seed", strconv.FormatInt(d.debugScheduler.Seed, 10)), nameField)
+		sched = newScheduler(*d.debugScheduler, d.internalLogger, nameField)
+		go sched.run(schedCtx)
+	}
 
-		}(dctx, &dwg, service, manager, stateUpdateC)
+	// --- Launch Daemon Service(s) ---
+	// launch all services in their own routine, services added from here on are launched as they are added.
+	d.mu.Lock()
+	d.rt = &daemonRuntime{
+		ctx:       dctx,
+		logC:      logC,
+		stateC:    stateUpdateC,
+		nameField: nameField,
+		idleC:     make(chan struct{}),
+		cancel:    dcancel,
+		healthC:   healthC,
+		sched:     sched,
 	}
+	d.internalLogger.Log(log.LevelInfo, "starting "+strconv.Itoa(len(d.services))+" services", nameField)
+	if sched != nil {
+		sched.hold()
+	}
+	for name := range d.services {
+		d.startLocked(name)
+	}
+	if sched != nil {
+		sched.resume()
+	}
+	idleC := d.rt.idleC
+	d.mu.Unlock()
+
+	// --- Readiness Barrier ---
+	// startup fails when the services do not satisfy the readiness policy within the readiness timeout,
+	// or when they have not left their init state within the startup timeout and it aborts.
+	readinessErr := d.awaitReadiness(dctx, dcancel, nameField)
+	startupErr := d.awaitStartup(dctx, dcancel, nameField)
 
 	// --- Daemon RPC Server ---
 	var server *http.Server
@@ -264,6 +497,7 @@ func (d *daemon) Start(parent context.Context) error {
 		cmdHandler := CommandHandler{
 			sLogger: d.serviceLogger,
 			iLogger: d.internalLogger,
+			daemon:  d,
 		}
 
 		err := rpcServer.Register(cmdHandler)
@@ -274,6 +508,7 @@ func (d *daemon) Start(parent context.Context) error {
 			// rpc handlers registered successfully, try to start the rpc server
 			addr := d.rpcConfig.Addr + ":" + strconv.Itoa(int(d.rpcConfig.Port))
 			mux.Handle("/rpc", rpcServer)
+			mux.Handle("/readyz", d.readiness)
 			server = &http.Server{
 				Addr:    addr,
 				Handler: mux,
@@ -290,13 +525,15 @@ func (d *daemon) Start(parent context.Context) error {
 		}
 	}
 
-	err = notifier.Notify(NotifyStateReady)
-	if err != nil {
-		d.internalLogger.Log(log.LevelError, "error sending 'ready' notification", log.Error("error", err), nameField)
-	}
+	// --- Daemon HTTP Admin Server ---
+	admin := d.startAdmin(nameField)
+	// --- Daemon Control Plane ---
+	stopControl := d.startControl(nameField)
+	closeControlSocket := d.startControlSocket(nameField)
 
 	// block until all services have exited their lifecycles
-	dwg.Wait()
+	<-idleC
+	schedCancel()
 	// -- ALL SERVICES HAVE EXITED THEIR LIFECYCLES --
 	//         CLEANUP AND SHUTDOWN
 
@@ -309,10 +546,26 @@ func (d *daemon) Start(parent context.Context) error {
 		}
 	}
 
+	stopControl()
+	closeControlSocket()
+
+	if admin != nil {
+		timedctx, timedcancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer timedcancel()
+		if err := admin.Shutdown(timedctx); err != nil {
+			d.internalLogger.Log(log.LevelError, "error shutting down http admin server", log.Error("error", err), nameField)
+		}
+	}
+
 	d.internalLogger.Log(log.LevelDebug, "closing states watcher", nameField)
+	watchersCancel()
+	<-unitsDoneC // the units watcher must not publish once the states update channel is closed.
 	// since all services have exited their lifecycles, we can close the states update channel.
 	close(stateUpdateC)
 	<-statesDoneC // wait for states watcher to finish
+	<-healthDoneC // the health prober must not publish once intracom is closed.
+	<-baselineDoneC
+	stopping() // after the last transitions of the services, when they all exited on their own.
 	d.internalLogger.Log(log.LevelDebug, "states watcher closed", nameField)
 
 	d.internalLogger.Log(log.LevelDebug, "closing intracom", nameField)
@@ -331,17 +584,17 @@ func (d *daemon) Start(parent context.Context) error {
 
 	d.internalLogger.Log(log.LevelDebug, "services log channel closed", nameField)
 
-	// if the internal logger is an io.Closer, close it.
-	if internalLogger, ok := d.internalLogger.(io.Closer); ok {
-		internalLogger.Close()
+	// services that ran to completion and failed surface their terminal errors, see RunOnceManager.
+	if err := errors.Join(readinessErr(), startupErr()); err != nil {
+		return errors.Join(err, d.results.failed())
 	}
-	return nil
+	return d.results.failed()
 }
 
 // AddServices adds a list of services to the daemon.
-// if any service fails to be added, the error is logged and the next service is attempted.
-// any services that fail likely are failing due to name overlap and will be skipped
-// if daemon is already started, no new services can be added.
+// if any service fails to be added, the error is returned and the remaining services are not added.
+// any services that fail likely are failing due to name overlap.
+// if daemon is already started, the services are launched as they are added.
 func (d *daemon) AddServices(services ...Service) error {
 	for _, service := range services {
 		err := d.addService(service)
@@ -354,20 +607,26 @@ func (d *daemon) AddServices(services ...Service) error {
 
 // AddService adds a service to the daemon.
 // if the service fails to be added, the error will be returned.
+// if the daemon is already started, the service is launched right away.
 func (d *daemon) AddService(service Service) error {
 	return d.addService(service)
 }
 
 // addService is a helper function to add a service to the daemon.
+// if the daemon is already running, the service is launched right away.
 func (d *daemon) addService(service Service) error {
-	if d.started.Load() {
-		return ErrAddingServiceOnceStarted
-	}
-
 	if service.Name == "" {
 		return ErrNoServiceName
 	}
 
+	if service.Runner == nil {
+		runner, err := d.resolve(service.Name)
+		if err != nil {
+			return err
+		}
+		service.Runner = runner
+	}
+
 	if service.Manager == nil {
 		service.Manager = NewDefaultManager()
 	}
@@ -384,6 +643,26 @@ func (d *daemon) addService(service Service) error {
 		return err
 	}
 
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, exists := d.services[service.Name]; exists || d.isUnitLocked(service.Name) {
+		return ErrDuplicateServiceName
+	}
+
+	if d.rt != nil && d.rt.closing {
+		return ErrDaemonStopping
+	}
+
+	if d.rt != nil {
+		// once running, dependencies must already be registered, which also rules out cycles.
+		for _, dep := range service.config.dependsOn {
+			if _, ok := d.services[dep]; !ok && !d.isUnitLocked(dep) {
+				return ErrUnknownDependency
+			}
+		}
+	}
+
 	// add the service to the daemon services
 	d.services[service.Name] = DaemonService{
 		Name:   service.Name,
@@ -392,10 +671,63 @@ func (d *daemon) addService(service Service) error {
 
 	// add the handler to a similar map of service name to handlers
 	d.managers[service.Name] = service.Manager
+	service.config.stateTimeouts = &atomic.Pointer[ManagerStateTimeouts]{}
+	service.config.resources = &resourceAccount{}
+	service.config.usage = &usageMeter{}
+	d.configs[service.Name] = service.config
+	d.labels.set(service.Name, service.config.labels)
+	if len(service.config.transitionHooks) > 0 {
+		d.serviceHooks.Store(service.Name, service.config.transitionHooks)
+	}
+
+	if service.config.cpuShare > 0 {
+		d.throttles[service.Name] = newCPUThrottle(service.config.cpuShare, service.config.cpuBurst)
+	}
+	d.samplers[service.Name] = newTraceSampler(d.tracer, service.config.traceSampling)
+
+	if d.rt != nil {
+		if err := d.checkShutdownOrderLocked(); err != nil {
+			delete(d.services, service.Name)
+			delete(d.managers, service.Name)
+			delete(d.configs, service.Name)
+			delete(d.throttles, service.Name)
+			delete(d.samplers, service.Name)
+			d.labels.remove(service.Name)
+			return err
+		}
+
+		// the daemon is already running, launch the service right away.
+		d.startLocked(service.Name)
+	}
 
 	return nil
 }
 
+// ThrottleStats returns the throttling statistics of every service that was given a cpu share.
+func (d *daemon) ThrottleStats() map[string]ThrottleStats {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	stats := make(map[string]ThrottleStats, len(d.throttles))
+	for name, throttle := range d.throttles {
+		stats[name] = throttle.stats()
+	}
+	return stats
+}
+
+// Topics returns the stats of the topics of the daemon sorted by name, such as the states topic and the topics
+// services publish to, see ServiceContext.Publish. Their consumer groups show which watcher or subscriber lags behind.
+// Topics are closed once the daemon stopped, none are returned then.
+func (d *daemon) Topics() []intracom.TopicStats {
+	return intracom.Stats(d.ic)
+}
+
+// SetPacing replaces the pacing policy applied to services calling sctx.Yield() or sctx.Throttle(rate).
+// It is safe to call while the daemon is running, services pick up the new policy on their next call.
+func (d *daemon) SetPacing(pacing Pacing) {
+	d.pacing.Store(&pacing)
+}
+
 func (d *daemon) serviceLogWatcher(logC <-chan DaemonLog) <-chan struct{} {
 	doneC := make(chan struct{})
 
@@ -403,12 +735,19 @@ func (d *daemon) serviceLogWatcher(logC <-chan DaemonLog) <-chan struct{} {
 		// semaphore to limit the number of concurrent log writes to the daemon logger.
 		sema := make(chan struct{}, d.logWorkerCount)
 		for entry := range logC {
+			d.tail.publish(entry)
 			sema <- struct{}{}
 			go func() {
+				defer func() { <-sema }()
+				// a panicking log handler drops the entry, the services keep logging.
+				defer d.recoverPanic("log pump", nil)
 				d.serviceLogger.Log(entry.Level, entry.Message, entry.Fields...)
-				<-sema
 			}()
 		}
+		// wait for the writes in flight, the loggers are closed once the daemon stopped.
+		for i := 0; i < cap(sema); i++ {
+			sema <- struct{}{}
+		}
 		close(doneC)
 	}()
 
@@ -418,15 +757,33 @@ func (d *daemon) statesWatcher(statesTopic intracom.Topic[ServiceStates], stateU
 	doneC := make(chan struct{})
 
 	go func() {
-		// retrieve the publisher channel for the states topic
-		d.internalLogger.Log(log.LevelDebug, "states topic publish channel", log.String("topic", internalServiceStates))
-		statesC := statesTopic.PublishChannel()
+		d.internalLogger.Log(log.LevelDebug, "publishing states", log.String("topic", internalServiceStates))
 
+		d.mu.RLock()
 		states := make(ServiceStates, len(d.services))
 		for name := range d.services {
 			states[name] = StateExit
 		}
+		d.mu.RUnlock()
+
+		// apply records an update, the transition hooks run before the new state is published.
+		// It reports whether the states changed, updates repeating the current state are not published again.
+		apply := func(update StateUpdate) bool {
+			if update.removed {
+				_, ok := states[update.Name]
+				delete(states, update.Name)
+				d.history.remove(update.Name)
+				return ok
+			}
+			// services added at runtime have no state yet, they start from exited.
+			current, ok := states[update.Name]
+			d.transition(update.Name, current, update.State)
+			states[update.Name] = update.State
+			return !ok || current != update.State
+		}
 
+		// the first update is always published, so watchers see every service even if nothing changed.
+		var published bool
 		// states watcher routine should be closed after all services have exited.
 		for state := range stateUpdatesC {
 			d.internalLogger.Log(log.LevelDebug, "states transition update", log.String("service_name", state.Name), log.String("state", state.State.String()))
@@ -436,10 +793,38 @@ func (d *daemon) statesWatcher(statesTopic intracom.Topic[ServiceStates], stateU
 			// d.logger.Log(log.LevelDebug, "service state update", log.String("service_name", state.Name), log.String("state", state.State.String()))
 			// }
 			// update the state of the service only if it changed.
-			states[state.Name] = state.State
+			changed := apply(state)
+
+			// fold any pending updates into the same publish, bounded by the batch size.
+		batch:
+			for n := 1; n < d.niceness.StateBatchSize; n++ {
+				select {
+				case next, open := <-stateUpdatesC:
+					if !open {
+						break batch
+					}
+					changed = apply(next) || changed
+				default:
+					break batch
+				}
+			}
+
+			if !changed && published {
+				continue
+			}
+
+			// send the updated states to the intracom bus, the snapshot is shared by every subscriber
+			// and the readiness tracker so it is never modified once published.
+			snapshot := states.copy()
+			if err := statesTopic.Publish(context.Background(), snapshot); err != nil {
+				d.internalLogger.Log(log.LevelError, "error publishing states", log.Error("error", err), log.String("topic", internalServiceStates))
+			}
+			d.readiness.observe(snapshot)
+			published = true
 
-			// send the updated states to the intracom bus
-			statesC <- states.copy()
+			if d.niceness.YieldOnTransition {
+				runtime.Gosched()
+			}
 		}
 		d.internalLogger.Log(log.LevelDebug, "states watcher completed")
 		// signal done after states watcher has finished.
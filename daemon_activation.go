@@ -0,0 +1,39 @@
+package rxd
+
+import (
+	"net"
+	"os"
+	"sort"
+	"strings"
+)
+
+// activatedSockets holds the sockets passed to the daemon by socket activation, see UsingSocketActivation.
+type activatedSockets struct {
+	files map[string][]*os.File
+}
+
+// listener returns a listener on the first socket named name. Every call duplicates the socket, so closing the
+// listener, e.g. when an http server shuts down, leaves the socket open for the next lifecycle of the service.
+func (s *activatedSockets) listener(name string) (net.Listener, error) {
+	if s == nil || len(s.files[name]) == 0 {
+		return nil, ErrListenerNotFound
+	}
+	return net.FileListener(s.files[name][0])
+}
+
+func (s *activatedSockets) names() string {
+	names := make([]string, 0, len(s.files))
+	for name := range s.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func (s *activatedSockets) close() {
+	for _, files := range s.files {
+		for _, f := range files {
+			f.Close()
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package rxd
+
+import (
+	"net"
+	"os"
+	"testing"
+)
+
+func TestServiceContext_Listener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %s", err)
+	}
+	f, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatalf("error getting the socket file: %s", err)
+	}
+	ln.Close()
+
+	sockets := &activatedSockets{files: map[string][]*os.File{"http": {f}}}
+	defer sockets.close()
+	sctx := &serviceContext{sockets: sockets}
+
+	// every lifecycle gets a listener of its own, closing it leaves the socket open.
+	for i := 0; i < 2; i++ {
+		listener, err := sctx.Listener("http")
+		if err != nil {
+			t.Fatalf("error getting listener: %s", err)
+		}
+
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("error dialing the activated socket: %s", err)
+		}
+		accepted, err := listener.Accept()
+		if err != nil {
+			t.Fatalf("error accepting: %s", err)
+		}
+		accepted.Close()
+		conn.Close()
+		listener.Close()
+	}
+
+	if _, err := sctx.Listener("grpc"); err != ErrListenerNotFound {
+		t.Errorf("expected %s, got %v", ErrListenerNotFound, err)
+	}
+	if _, err := (&serviceContext{}).Listener("http"); err != ErrListenerNotFound {
+		t.Errorf("expected %s without socket activation, got %v", ErrListenerNotFound, err)
+	}
+}
@@ -0,0 +1,260 @@
+package rxd
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// leveler is implemented by loggers that report their current level, such as the loggers of the log package.
+type leveler interface {
+	Level() log.Level
+}
+
+// startAdmin starts the http admin server when enabled, the listener is opened before returning
+// so the endpoints are reachable once Start is running. It returns nil if the server did not start.
+func (d *daemon) startAdmin(nameField log.Field) *http.Server {
+	if d.adminAddr == "" {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", d.adminAddr)
+	if err != nil {
+		d.internalLogger.Log(log.LevelError, "error starting http admin server", log.Error("error", err), nameField)
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", d.serveHealthz)
+	mux.Handle("/readyz", d.readiness)
+	mux.HandleFunc("/services", d.serveServices)
+	mux.HandleFunc("/errors", d.serveErrors)
+	mux.HandleFunc("/resume", d.serveResume)
+	mux.HandleFunc("/loglevel", d.serveLogLevel)
+	mux.HandleFunc("/pacing", d.servePacing)
+	mux.HandleFunc("/usage", d.serveUsage)
+	mux.HandleFunc("/history", d.serveHistory)
+	mux.HandleFunc("/topics", d.serveTopics)
+
+	server := &http.Server{Handler: mux}
+	go func() {
+		d.internalLogger.Log(log.LevelInfo, "starting http admin server at "+listener.Addr().String(), nameField)
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			d.internalLogger.Log(log.LevelError, "error running http admin server", log.Error("error", err), nameField)
+			return
+		}
+		d.internalLogger.Log(log.LevelInfo, "stopped running http admin server and exited successfully", nameField)
+	}()
+
+	return server
+}
+
+// serveHealthz answers 200 unless a service is unhealthy and 503 otherwise.
+func (d *daemon) serveHealthz(w http.ResponseWriter, req *http.Request) {
+	health := d.Health()
+	status := http.StatusOK
+	if !health.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+	d.writeEncoded(w, status, health)
+}
+
+func (d *daemon) serveServices(w http.ResponseWriter, req *http.Request) {
+	states := d.readiness.current()
+	services := make(map[string]string, len(states))
+	for name, state := range states {
+		services[name] = state.String()
+	}
+	d.writeEncoded(w, http.StatusOK, services)
+}
+
+// serveErrors reports the most recent lifecycle error of every service that failed one.
+func (d *daemon) serveErrors(w http.ResponseWriter, req *http.Request) {
+	type lifecycleError struct {
+		State string    `json:"state"`
+		Error string    `json:"error"`
+		Time  time.Time `json:"time"`
+	}
+
+	snapshot := d.lastErrors.snapshot()
+	errs := make(map[string]lifecycleError, len(snapshot))
+	for name, err := range snapshot {
+		errs[name] = lifecycleError{State: err.State.String(), Error: err.Err.Error(), Time: err.Time}
+	}
+	d.writeEncoded(w, http.StatusOK, errs)
+}
+
+// serveHistory reports the last state changes of the service named by the service query parameter,
+// or those of every service without it.
+func (d *daemon) serveHistory(w http.ResponseWriter, req *http.Request) {
+	type stateChange struct {
+		State string    `json:"state"`
+		Time  time.Time `json:"time"`
+		Error string    `json:"error,omitempty"`
+	}
+	encode := func(changes []StateChange) []stateChange {
+		encoded := make([]stateChange, 0, len(changes))
+		for _, change := range changes {
+			c := stateChange{State: change.State.String(), Time: change.Time}
+			if change.Err != nil {
+				c.Error = change.Err.Error()
+			}
+			encoded = append(encoded, c)
+		}
+		return encoded
+	}
+
+	if name := req.URL.Query().Get("service"); name != "" {
+		changes := d.History(name)
+		if changes == nil {
+			http.Error(w, ErrServiceNotFound.Error()+": "+name, http.StatusNotFound)
+			return
+		}
+		d.writeEncoded(w, http.StatusOK, encode(changes))
+		return
+	}
+
+	histories := make(map[string][]stateChange)
+	for _, name := range d.history.services() {
+		histories[name] = encode(d.History(name))
+	}
+	d.writeEncoded(w, http.StatusOK, histories)
+}
+
+// serveUsage reports the approximate resource usage of every service.
+func (d *daemon) serveUsage(w http.ResponseWriter, req *http.Request) {
+	d.writeEncoded(w, http.StatusOK, d.Usage())
+}
+
+// serveTopics reports the traffic of every topic and the state of its consumer groups.
+func (d *daemon) serveTopics(w http.ResponseWriter, req *http.Request) {
+	d.writeEncoded(w, http.StatusOK, d.Topics())
+}
+
+// serveResume starts the paused service named by the service query parameter on POST.
+func (d *daemon) serveResume(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := req.URL.Query().Get("service")
+	if err := d.ResumeService(name); err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	d.writeEncoded(w, http.StatusOK, map[string]string{"service": name, "state": StateInit.String()})
+}
+
+// errorStatus maps the errors of the daemon to the status of the admin endpoints.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrServiceNotFound), errors.Is(err, ErrGroupNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrDaemonNotStarted), errors.Is(err, ErrDaemonStopping):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusConflict
+	}
+}
+
+// serveLogLevel reports the level of the service logger on GET, PUT and POST set the level
+// of both the service and internal loggers from the level query parameter or the request body.
+func (d *daemon) serveLogLevel(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		l, ok := unwrapLogger(d.serviceLogger).(leveler)
+		if !ok {
+			http.Error(w, "log level is not available", http.StatusNotImplemented)
+			return
+		}
+		d.writeEncoded(w, http.StatusOK, map[string]string{"level": l.Level().String()})
+	case http.MethodPut, http.MethodPost:
+		value := req.URL.Query().Get("level")
+		if value == "" {
+			body, err := io.ReadAll(io.LimitReader(req.Body, 64))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			value = strings.TrimSpace(string(body))
+		}
+
+		level, err := parseLevel(value)
+		if err != nil {
+			http.Error(w, err.Error()+": "+value, http.StatusBadRequest)
+			return
+		}
+		d.serviceLogger.SetLevel(level)
+		d.internalLogger.SetLevel(level)
+		d.internalLogger.Log(log.LevelInfo, "log level changed to "+level.String(), log.String("rxd", d.name))
+		d.writeEncoded(w, http.StatusOK, map[string]string{"level": level.String()})
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// servePacing reports the pacing policy on GET, PUT and POST change it from the yield_delay and rate_factor
+// query parameters, those not given are kept, see SetPacing.
+func (d *daemon) servePacing(w http.ResponseWriter, req *http.Request) {
+	type pacing struct {
+		YieldDelay string  `json:"yield_delay"`
+		RateFactor float64 `json:"rate_factor"`
+	}
+
+	current := Pacing{}
+	if p := d.pacing.Load(); p != nil {
+		current = *p
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		d.writeEncoded(w, http.StatusOK, pacing{YieldDelay: current.YieldDelay.String(), RateFactor: current.RateFactor})
+	case http.MethodPut, http.MethodPost:
+		query := req.URL.Query()
+		if value := query.Get("yield_delay"); value != "" {
+			delay, err := time.ParseDuration(value)
+			if err != nil || delay < 0 {
+				http.Error(w, "invalid yield delay: "+value, http.StatusBadRequest)
+				return
+			}
+			current.YieldDelay = delay
+		}
+		if value := query.Get("rate_factor"); value != "" {
+			factor, err := strconv.ParseFloat(value, 64)
+			if err != nil || factor < 0 || factor > 1 {
+				http.Error(w, "invalid rate factor: "+value, http.StatusBadRequest)
+				return
+			}
+			current.RateFactor = factor
+		}
+
+		d.SetPacing(current)
+		d.internalLogger.Log(log.LevelInfo, "pacing changed", log.String("yield_delay", current.YieldDelay.String()),
+			log.Float("rate_factor", current.RateFactor), log.String("rxd", d.name))
+		d.writeEncoded(w, http.StatusOK, pacing{YieldDelay: current.YieldDelay.String(), RateFactor: current.RateFactor})
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// writeEncoded writes v encoded with the codec of the daemon, see UsingCodec.
+func (d *daemon) writeEncoded(w http.ResponseWriter, status int, v any) {
+	data, err := d.codec.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", d.codec.ContentType())
+	w.WriteHeader(status)
+	w.Write(data)
+}
@@ -0,0 +1,144 @@
+package rxd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/intracom"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+func TestDaemon_AdminEndpoints(t *testing.T) {
+	serviceLogger := log.NewLogger(log.LevelInfo, newTestLogger())
+	d := NewDaemon("test-daemon",
+		UsingHTTPAdmin("127.0.0.1:0"),
+		WithInternalLogger(log.NewLogger(log.LevelInfo, newTestLogger())),
+		WithServiceLogger(serviceLogger),
+	).(*daemon)
+
+	d.readiness = &readinessTracker{policy: AllOf(), logger: d.internalLogger}
+	d.readiness.observe(ServiceStates{"api": StateRun, "worker": StateInit})
+	d.health.set(ServicesHealth{"api": {Status: HealthUnhealthy, Error: "connection refused", Failures: 2}})
+
+	rec := httptest.NewRecorder()
+	d.serveHealthz(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected /healthz status %d, got %d", http.StatusServiceUnavailable, rec.Code)
+	}
+	var health map[string]struct {
+		Status   string `json:"status"`
+		Failures int    `json:"failures"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&health); err != nil {
+		t.Fatalf("error decoding /healthz: %s", err)
+	}
+	if health["api"].Status != "unhealthy" || health["api"].Failures != 2 {
+		t.Errorf("unexpected health %+v", health)
+	}
+
+	rec = httptest.NewRecorder()
+	d.serveServices(rec, httptest.NewRequest(http.MethodGet, "/services", nil))
+	var services map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&services); err != nil {
+		t.Fatalf("error decoding /services: %s", err)
+	}
+	if services["api"] != StateRun.String() || services["worker"] != StateInit.String() {
+		t.Errorf("unexpected services %v", services)
+	}
+
+	rec = httptest.NewRecorder()
+	d.serveLogLevel(rec, httptest.NewRequest(http.MethodPut, "/loglevel?level=debug", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected /loglevel status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	d.serveLogLevel(rec, httptest.NewRequest(http.MethodGet, "/loglevel", nil))
+	if !strings.Contains(rec.Body.String(), `"DEBUG"`) {
+		t.Errorf("expected level DEBUG, got %s", rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	d.serveLogLevel(rec, httptest.NewRequest(http.MethodPost, "/loglevel", strings.NewReader("verbose")))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an unknown level, got %d", http.StatusBadRequest, rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	d.serveResume(rec, httptest.NewRequest(http.MethodPost, "/resume?service=unknown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status %d resuming an unknown service, got %d", http.StatusNotFound, rec.Code)
+	}
+
+	if _, err := intracom.CreateTopic[ServiceStates](d.ic, intracom.TopicConfig{Name: internalServiceStates}); err != nil {
+		t.Fatalf("error creating states topic: %s", err)
+	}
+	rec = httptest.NewRecorder()
+	d.serveTopics(rec, httptest.NewRequest(http.MethodGet, "/topics", nil))
+	var topics []intracom.TopicStats
+	if err := json.NewDecoder(rec.Body).Decode(&topics); err != nil {
+		t.Fatalf("error decoding /topics: %s", err)
+	}
+	if len(topics) != 1 || topics[0].Name != internalServiceStates {
+		t.Errorf("expected the states topic, got %+v", topics)
+	}
+
+	server := d.startAdmin(log.String("rxd", d.name))
+	if server == nil {
+		t.Fatal("expected the admin server to start")
+	}
+	server.Close()
+}
+
+func TestDaemon_AdminPacing(t *testing.T) {
+	d := NewDaemon("test-daemon",
+		WithPacing(Pacing{RateFactor: 0.5}),
+		WithInternalLogger(log.NewLogger(log.LevelInfo, newTestLogger())),
+	).(*daemon)
+	p := newPacer(d.pacing)
+
+	rec := httptest.NewRecorder()
+	d.servePacing(rec, httptest.NewRequest(http.MethodPut, "/pacing?yield_delay=20ms", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected /pacing status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+
+	// the services pick up the new policy on their next call, the rate factor not given is kept.
+	if got := p.current(); got.YieldDelay != 20*time.Millisecond || got.RateFactor != 0.5 {
+		t.Errorf("expected a yield delay of 20ms and a rate factor of 0.5, got %+v", got)
+	}
+	start := time.Now()
+	p.yield(context.Background())
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("expected yield to pause for the new yield delay, took %s", elapsed)
+	}
+
+	rec = httptest.NewRecorder()
+	d.servePacing(rec, httptest.NewRequest(http.MethodGet, "/pacing", nil))
+	var pacing struct {
+		YieldDelay string  `json:"yield_delay"`
+		RateFactor float64 `json:"rate_factor"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&pacing); err != nil {
+		t.Fatalf("error decoding /pacing: %s", err)
+	}
+	if pacing.YieldDelay != "20ms" || pacing.RateFactor != 0.5 {
+		t.Errorf("unexpected pacing %+v", pacing)
+	}
+
+	for _, query := range []string{"yield_delay=-1s", "yield_delay=soon", "rate_factor=2"} {
+		rec = httptest.NewRecorder()
+		d.servePacing(rec, httptest.NewRequest(http.MethodPost, "/pacing?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d for %s, got %d", http.StatusBadRequest, query, rec.Code)
+		}
+	}
+	if got := p.current(); got.YieldDelay != 20*time.Millisecond {
+		t.Errorf("expected an invalid change to keep the pacing, got %+v", got)
+	}
+}
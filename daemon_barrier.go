@@ -0,0 +1,117 @@
+package rxd
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// barrier releases its participants once every one of them is waiting at it, see WithBarrier.
+// It is reused once released, the participants waiting again make up the next round.
+type barrier struct {
+	name         string
+	participants map[string]struct{}
+	timeout      time.Duration
+
+	mu    sync.Mutex
+	round *barrierRound
+}
+
+// barrierRound is a round of a barrier, released once all the participants arrived or the timeout elapsed.
+type barrierRound struct {
+	arrived map[string]struct{}
+	doneC   chan struct{} // closed once the round is released.
+	err     error         // set before doneC is closed when the round timed out.
+	timer   *time.Timer   // started by the first participant to arrive, nil without a timeout.
+}
+
+func newBarrier(name string, timeout time.Duration, participants ...string) *barrier {
+	b := &barrier{
+		name:         name,
+		participants: make(map[string]struct{}, len(participants)),
+		timeout:      timeout,
+	}
+	for _, participant := range participants {
+		b.participants[participant] = struct{}{}
+	}
+	b.round = newBarrierRound()
+	return b
+}
+
+func newBarrierRound() *barrierRound {
+	return &barrierRound{arrived: make(map[string]struct{}), doneC: make(chan struct{})}
+}
+
+// wait marks participant as arrived and blocks until the round is released or ctx is done.
+// A participant leaving because ctx is done no longer counts as arrived.
+func (b *barrier) wait(ctx context.Context, participant string) error {
+	if _, ok := b.participants[participant]; !ok {
+		return ErrNotBarrierParticipant
+	}
+
+	b.mu.Lock()
+	round := b.round
+	round.arrived[participant] = struct{}{}
+	if len(round.arrived) == len(b.participants) {
+		b.releaseLocked(round, nil)
+		b.mu.Unlock()
+		return nil
+	}
+	if b.timeout > 0 && round.timer == nil {
+		round.timer = time.AfterFunc(b.timeout, func() {
+			b.expire(round)
+		})
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-round.doneC:
+		return round.err
+	case <-ctx.Done():
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	select {
+	case <-round.doneC:
+		// the round was released while the participant was leaving.
+		return round.err
+	default:
+	}
+	delete(round.arrived, participant)
+	if len(round.arrived) == 0 && round.timer != nil {
+		// the timeout starts over with the next participant to arrive.
+		round.timer.Stop()
+		round.timer = nil
+	}
+	return ctx.Err()
+}
+
+// expire releases round with an ErrBarrierTimeout naming the participants that did not arrive.
+func (b *barrier) expire(round *barrierRound) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.round != round {
+		return
+	}
+
+	var missing []string
+	for participant := range b.participants {
+		if _, ok := round.arrived[participant]; !ok {
+			missing = append(missing, participant)
+		}
+	}
+	slices.Sort(missing)
+	b.releaseLocked(round, ErrBarrierTimeout{Barrier: b.name, Timeout: b.timeout, Missing: missing})
+}
+
+// releaseLocked releases round and starts the next one, it must be called with the barrier lock held.
+func (b *barrier) releaseLocked(round *barrierRound, err error) {
+	if round.timer != nil {
+		round.timer.Stop()
+	}
+	round.err = err
+	close(round.doneC)
+	b.round = newBarrierRound()
+}
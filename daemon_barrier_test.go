@@ -0,0 +1,125 @@
+package rxd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+func TestBarrier_Wait(t *testing.T) {
+	b := newBarrier("cutover", 0, "old", "new")
+	ctx := context.Background()
+
+	if err := b.wait(ctx, "other"); !errors.Is(err, ErrNotBarrierParticipant) {
+		t.Fatalf("expected %s, got %v", ErrNotBarrierParticipant, err)
+	}
+
+	releasedC := make(chan error, 1)
+	go func() {
+		releasedC <- b.wait(ctx, "old")
+	}()
+
+	select {
+	case err := <-releasedC:
+		t.Fatalf("expected old to wait for new, got %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if err := b.wait(ctx, "new"); err != nil {
+		t.Fatalf("expected the last participant to release the barrier, got %s", err)
+	}
+	if err := <-releasedC; err != nil {
+		t.Fatalf("expected old to be released, got %s", err)
+	}
+
+	// a participant leaving no longer counts as arrived in the next round.
+	leaving, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := b.wait(leaving, "old"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the participant to leave with its context, got %v", err)
+	}
+	go func() {
+		releasedC <- b.wait(ctx, "new")
+	}()
+	select {
+	case err := <-releasedC:
+		t.Fatalf("expected new to wait for old to arrive again, got %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	if err := b.wait(ctx, "old"); err != nil || <-releasedC != nil {
+		t.Fatalf("expected the second round to be released")
+	}
+}
+
+func TestBarrier_Timeout(t *testing.T) {
+	b := newBarrier("cutover", 30*time.Millisecond, "old", "new", "proxy")
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, participant := range []string{"old", "new"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = b.wait(context.Background(), participant)
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		var timeout ErrBarrierTimeout
+		if !errors.As(err, &timeout) || len(timeout.Missing) != 1 || timeout.Missing[0] != "proxy" {
+			t.Errorf("expected the barrier to time out waiting for proxy, got %v", err)
+		}
+	}
+}
+
+// barrierService waits at the cutover barrier once it runs.
+type barrierService struct {
+	recordingService
+}
+
+func (b *barrierService) Run(sctx ServiceContext) error {
+	if err := sctx.WaitBarrier("cutover"); err != nil {
+		return err
+	}
+	b.journal.record(b.name + ":released")
+	return b.recordingService.Run(sctx)
+}
+
+func TestDaemon_WaitBarrier(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	journal := &recordingJournal{}
+	d := NewDaemon("test-daemon",
+		WithServiceLogger(log.NewLogger(log.LevelError, newTestLogger())),
+		WithBarrier("cutover", 0, "old", "new"),
+	)
+	for _, name := range []string{"old", "new"} {
+		if err := d.AddService(NewService(name, &barrierService{recordingService{name: name, journal: journal}})); err != nil {
+			t.Fatalf("error adding service: %s", err)
+		}
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for journal.index("old:released") < 0 || journal.index("new:released") < 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected both services to be released from the barrier")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+}
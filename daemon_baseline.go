@@ -0,0 +1,263 @@
+package rxd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ambitiousfew/rxd/intracom"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// Expectation declares the state services are expected to be in, e.g. running during business hours.
+// A service deviating from it for longer than the grace period raises an alert, and another once it is resolved.
+type Expectation struct {
+	Services []string
+	State    State         // expected state of the services, usually StateRun.
+	Window   Window        // when the expectation applies, nil for always.
+	Grace    time.Duration // how long a service may deviate before an alert is raised.
+}
+
+// Deviation is passed to the alerters when a service has deviated from its expected state for longer than
+// the grace period, and again with Resolved set once it is back in the expected state or out of the window.
+type Deviation struct {
+	Service  string
+	Expected State
+	Actual   State
+	Since    time.Time
+	Resolved bool
+}
+
+// MarshalJSON encodes the states by name, as posted by the WebhookAlerter.
+func (d Deviation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Service  string    `json:"service"`
+		Expected string    `json:"expected"`
+		Actual   string    `json:"actual"`
+		Since    time.Time `json:"since"`
+		Resolved bool      `json:"resolved"`
+	}{d.Service, d.Expected.String(), d.Actual.String(), d.Since, d.Resolved})
+}
+
+// Alerter is notified of the deviations from the expectations of the daemon.
+type Alerter interface {
+	Alert(ctx context.Context, deviation Deviation) error
+}
+
+// AlerterFunc is a function that implements Alerter.
+type AlerterFunc func(ctx context.Context, deviation Deviation) error
+
+func (f AlerterFunc) Alert(ctx context.Context, deviation Deviation) error {
+	return f(ctx, deviation)
+}
+
+// LogAlerter logs deviations at level, and their resolution at info level.
+func LogAlerter(logger log.Logger, level log.Level) Alerter {
+	return AlerterFunc(func(ctx context.Context, deviation Deviation) error {
+		fields := []log.Field{
+			log.String("service", deviation.Service),
+			log.String("expected", deviation.Expected.String()),
+			log.String("actual", deviation.Actual.String()),
+			log.String("since", deviation.Since.Format(time.RFC3339)),
+		}
+		if deviation.Resolved {
+			logger.Log(log.LevelInfo, "service is back in its expected state", fields...)
+			return nil
+		}
+		logger.Log(level, "service deviates from its expected state", fields...)
+		return nil
+	})
+}
+
+// WebhookAlerter posts every deviation as json to url.
+func WebhookAlerter(url string) Alerter {
+	return AlerterFunc(func(ctx context.Context, deviation Deviation) error {
+		body, err := json.Marshal(deviation)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return ErrAlertRejected{StatusCode: resp.StatusCode}
+		}
+		return nil
+	})
+}
+
+// DeviationCounter is an Alerter counting deviations, for export as metrics.
+type DeviationCounter struct {
+	mu     sync.Mutex
+	active map[string]bool
+	total  map[string]uint64
+}
+
+func (c *DeviationCounter) Alert(ctx context.Context, deviation Deviation) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.active == nil {
+		c.active = make(map[string]bool)
+		c.total = make(map[string]uint64)
+	}
+
+	if deviation.Resolved {
+		delete(c.active, deviation.Service)
+		return nil
+	}
+	c.active[deviation.Service] = true
+	c.total[deviation.Service]++
+	return nil
+}
+
+// Active returns the number of services currently deviating.
+func (c *DeviationCounter) Active() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.active)
+}
+
+// Total returns how many times the service has deviated.
+func (c *DeviationCounter) Total(service string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.total[service]
+}
+
+// baselineMonitor tracks the deviations of the services from the expectations of the daemon.
+type baselineMonitor struct {
+	expectations []Expectation
+	alerters     []Alerter
+	since        map[string]time.Time // when each deviating service started deviating.
+	alerted      map[string]Deviation // deviations that were alerted and not yet resolved.
+}
+
+// evaluate returns the alerts to raise at now given the current states.
+func (m *baselineMonitor) evaluate(now time.Time, states ServiceStates) []Deviation {
+	var alerts []Deviation
+	for _, exp := range m.expectations {
+		active := exp.Window == nil || exp.Window.Active(now)
+		for _, name := range exp.Services {
+			actual, known := states[name]
+			deviating := active && known && actual != exp.State
+
+			if !deviating {
+				delete(m.since, name)
+				if alerted, ok := m.alerted[name]; ok {
+					delete(m.alerted, name)
+					alerted.Actual, alerted.Resolved = actual, true
+					alerts = append(alerts, alerted)
+				}
+				continue
+			}
+
+			since, ok := m.since[name]
+			if !ok {
+				since = now
+				m.since[name] = now
+			}
+			if _, ok := m.alerted[name]; ok || now.Sub(since) < exp.Grace {
+				continue
+			}
+
+			deviation := Deviation{Service: name, Expected: exp.State, Actual: actual, Since: since}
+			m.alerted[name] = deviation
+			alerts = append(alerts, deviation)
+		}
+	}
+	return alerts
+}
+
+// interval returns how often the expectations are evaluated, often enough to honor the shortest grace period.
+func (m *baselineMonitor) interval() time.Duration {
+	interval := time.Second
+	for _, exp := range m.expectations {
+		if half := exp.Grace / 2; half < interval {
+			interval = half
+		}
+	}
+	return max(interval, 10*time.Millisecond)
+}
+
+// monitorBaseline evaluates the expectations on every states update and interval until ctx is done.
+func (d *daemon) monitorBaseline(ctx context.Context, statesTopic intracom.Topic[ServiceStates]) <-chan struct{} {
+	doneC := make(chan struct{})
+	if len(d.expectations) == 0 {
+		close(doneC)
+		return doneC
+	}
+
+	alerters := d.alerters
+	if len(alerters) == 0 {
+		alerters = []Alerter{LogAlerter(d.serviceLogger, log.LevelWarning)}
+	}
+	monitor := &baselineMonitor{
+		expectations: d.expectations,
+		alerters:     alerters,
+		since:        make(map[string]time.Time),
+		alerted:      make(map[string]Deviation),
+	}
+
+	go func() {
+		defer close(doneC)
+
+		const consumer = prefix + ".baseline"
+		statesC, err := statesTopic.Subscribe(ctx, intracom.SubscriberConfig[ServiceStates]{
+			ConsumerGroup: consumer,
+			BufferSize:    1,
+			BufferPolicy:  intracom.BufferPolicyDropOldest[ServiceStates]{},
+		})
+		if err != nil {
+			d.internalLogger.Log(log.LevelError, "error subscribing the baseline monitor to states", log.Error("error", err), log.String("rxd", d.name))
+			return
+		}
+		defer statesTopic.Unsubscribe(consumer, statesC)
+
+		ticker := time.NewTicker(monitor.interval())
+		defer ticker.Stop()
+
+		var states ServiceStates
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case latest, open := <-statesC:
+				if !open {
+					return
+				}
+				states = latest
+			case <-ticker.C:
+			}
+
+			for _, deviation := range monitor.evaluate(time.Now(), states) {
+				d.alert(ctx, monitor.alerters, deviation)
+			}
+		}
+	}()
+
+	return doneC
+}
+
+// alert notifies every alerter, each within a timeout so a slow webhook cannot stall the monitor for long.
+func (d *daemon) alert(ctx context.Context, alerters []Alerter, deviation Deviation) {
+	for i, alerter := range alerters {
+		actx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		if err := alerter.Alert(actx, deviation); err != nil {
+			d.internalLogger.Log(log.LevelError, "error sending deviation alert", log.String("service_name", deviation.Service), log.String("alerter", strconv.Itoa(i)), log.Error("error", err), log.String("rxd", d.name))
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,102 @@
+package rxd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+func TestBaselineMonitor_Evaluate(t *testing.T) {
+	businessHours, err := ParseWindow("* 9-17 * * 1-5")
+	if err != nil {
+		t.Fatalf("error parsing window: %s", err)
+	}
+
+	monitor := &baselineMonitor{
+		expectations: []Expectation{{Services: []string{"api"}, State: StateRun, Window: businessHours, Grace: time.Minute}},
+		since:        make(map[string]time.Time),
+		alerted:      make(map[string]Deviation),
+	}
+
+	// monday 10:00.
+	monday := time.Date(2024, time.March, 4, 10, 0, 0, 0, time.Local)
+	down := ServiceStates{"api": StateExit}
+
+	if alerts := monitor.evaluate(monday, down); len(alerts) != 0 {
+		t.Fatalf("expected no alert within the grace period, got %v", alerts)
+	}
+	alerts := monitor.evaluate(monday.Add(time.Minute), down)
+	if len(alerts) != 1 || alerts[0].Resolved || alerts[0].Actual != StateExit || !alerts[0].Since.Equal(monday) {
+		t.Fatalf("expected a deviation since %s, got %v", monday, alerts)
+	}
+	if alerts := monitor.evaluate(monday.Add(2*time.Minute), down); len(alerts) != 0 {
+		t.Fatalf("expected a deviation to be alerted once, got %v", alerts)
+	}
+
+	alerts = monitor.evaluate(monday.Add(3*time.Minute), ServiceStates{"api": StateRun})
+	if len(alerts) != 1 || !alerts[0].Resolved {
+		t.Fatalf("expected the deviation to be resolved, got %v", alerts)
+	}
+
+	// outside of business hours the service may be down.
+	sunday := time.Date(2024, time.March, 3, 10, 0, 0, 0, time.Local)
+	for _, now := range []time.Time{sunday, sunday.Add(time.Hour)} {
+		if alerts := monitor.evaluate(now, down); len(alerts) != 0 {
+			t.Fatalf("expected no alert outside of the window, got %v", alerts)
+		}
+	}
+}
+
+func TestDaemon_Expectation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	posted := make(chan map[string]any, 10)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		posted <- body
+	}))
+	defer webhook.Close()
+
+	counter := &DeviationCounter{}
+	d := NewDaemon("test-daemon",
+		UsingExpectation(Expectation{Services: []string{"api"}, State: StateRun, Grace: 50 * time.Millisecond}, counter, WebhookAlerter(webhook.URL)),
+		WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+		WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+	)
+	// api exits right away while keeper keeps the daemon running, so api deviates from the expectation.
+	if err := d.AddService(NewService("api", &failingService{recordingService{name: "api", journal: &recordingJournal{}}, nil}, WithManager(NewRunOnceManager(0)))); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+	if err := d.AddService(NewService("keeper", &recordingService{name: "keeper", journal: &recordingJournal{}})); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	select {
+	case body := <-posted:
+		if body["service"] != "api" || body["expected"] != StateRun.String() || body["resolved"] != false {
+			t.Errorf("unexpected alert %v", body)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the deviation alert")
+	}
+	if counter.Active() != 1 || counter.Total("api") != 1 {
+		t.Errorf("expected a single active deviation, got %d active and %d total", counter.Active(), counter.Total("api"))
+	}
+
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+}
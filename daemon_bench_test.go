@@ -0,0 +1,167 @@
+package rxd
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/intracom"
+)
+
+func BenchmarkDaemon_StatesWatcher(b *testing.B) {
+	for _, niceness := range []Niceness{
+		{},
+		{StateBatchSize: 16},
+		{StateBatchSize: 16, YieldOnTransition: true},
+	} {
+		name := "batch=" + strconv.Itoa(niceness.StateBatchSize) + "/yield=" + strconv.FormatBool(niceness.YieldOnTransition)
+		b.Run(name, func(b *testing.B) {
+			benchmarkStatesWatcher(b, 200, niceness)
+		})
+	}
+}
+
+// benchmarkStatesWatcher measures the cost of a state update reaching a watcher of all states.
+func benchmarkStatesWatcher(b *testing.B, services int, niceness Niceness) {
+	d := NewDaemon("bench-daemon", WithNiceness(niceness)).(*daemon)
+	for i := 0; i < services; i++ {
+		err := d.AddService(NewService("service-"+strconv.Itoa(i), newMockService(time.Millisecond)))
+		if err != nil {
+			b.Fatalf("error adding service: %s", err)
+		}
+	}
+
+	topic, err := intracom.CreateTopic[ServiceStates](d.ic, intracom.TopicConfig{Name: internalServiceStates})
+	if err != nil {
+		b.Fatalf("error creating topic: %s", err)
+	}
+	defer intracom.Close(d.ic)
+
+	sub, err := topic.Subscribe(context.Background(), intracom.SubscriberConfig[ServiceStates]{
+		ConsumerGroup: b.Name(),
+		BufferSize:    1,
+		BufferPolicy:  intracom.BufferPolicyDropOldest[ServiceStates]{},
+	})
+	if err != nil {
+		b.Fatalf("error subscribing: %s", err)
+	}
+
+	go func() {
+		for range sub {
+		}
+	}()
+
+	updateC := make(chan StateUpdate, services*4)
+	doneC := d.statesWatcher(topic, updateC)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		updateC <- StateUpdate{Name: "service-" + strconv.Itoa(i%services), State: State(i%4 + 1)}
+	}
+	close(updateC)
+	<-doneC
+}
+
+// fanoutSizes are the daemon sizes and subscriber counts the state fan-out is measured at.
+var fanoutSizes = []struct{ services, subscribers int }{
+	{10, 1}, {10, 10}, {10, 100},
+	{100, 1}, {100, 10}, {100, 100},
+	{1000, 1}, {1000, 10}, {1000, 100},
+}
+
+func BenchmarkDaemon_StatesFanout(b *testing.B) {
+	for _, size := range fanoutSizes {
+		name := "services=" + strconv.Itoa(size.services) + "/subscribers=" + strconv.Itoa(size.subscribers)
+		b.Run(name, func(b *testing.B) {
+			fanout := newStatesFanout(b, size.services, size.subscribers)
+			defer fanout.close()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				fanout.roundTrip(i)
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*size.subscribers), "ns/delivery")
+		})
+	}
+}
+
+// statesFanout drives the states watcher of a daemon with the given number of services,
+// each round trip publishes a single state update and waits for every subscriber to receive it.
+type statesFanout struct {
+	updateC chan<- StateUpdate
+	ackC    chan struct{}
+	doneC   <-chan struct{}
+	ic      *intracom.Intracom
+
+	subscribers int
+}
+
+func newStatesFanout(tb testing.TB, services, subscribers int) *statesFanout {
+	d := NewDaemon("bench-daemon").(*daemon)
+	for i := 0; i < services; i++ {
+		if err := d.AddService(NewService("service-"+strconv.Itoa(i), newMockService(time.Millisecond))); err != nil {
+			tb.Fatalf("error adding service: %s", err)
+		}
+	}
+
+	// the readiness policy is evaluated on every publish, as it is once the daemon has started.
+	d.readiness = &readinessTracker{policy: AllOf(), logger: d.internalLogger}
+
+	topic, err := intracom.CreateTopic[ServiceStates](d.ic, intracom.TopicConfig{Name: internalServiceStates})
+	if err != nil {
+		tb.Fatalf("error creating topic: %s", err)
+	}
+
+	ackC := make(chan struct{}, subscribers)
+	for i := 0; i < subscribers; i++ {
+		sub, err := topic.Subscribe(context.Background(), intracom.SubscriberConfig[ServiceStates]{
+			ConsumerGroup: "fanout-" + strconv.Itoa(i),
+			BufferSize:    1,
+			BufferPolicy:  intracom.BufferPolicyDropOldest[ServiceStates]{},
+		})
+		if err != nil {
+			tb.Fatalf("error subscribing: %s", err)
+		}
+
+		go func() {
+			// acknowledge every change of service-0, only one update is in flight at a time.
+			last := StateExit
+			for states := range sub {
+				if states["service-0"] != last {
+					last = states["service-0"]
+					ackC <- struct{}{}
+				}
+			}
+		}()
+	}
+
+	updateC := make(chan StateUpdate, services*4)
+	return &statesFanout{
+		updateC:     updateC,
+		ackC:        ackC,
+		doneC:       d.statesWatcher(topic, updateC),
+		ic:          d.ic,
+		subscribers: subscribers,
+	}
+}
+
+func (f *statesFanout) roundTrip(i int) {
+	state := StateRun
+	if i%2 == 1 {
+		state = StateIdle
+	}
+
+	f.updateC <- StateUpdate{Name: "service-0", State: state}
+	for n := 0; n < f.subscribers; n++ {
+		<-f.ackC
+	}
+}
+
+func (f *statesFanout) close() {
+	close(f.updateC)
+	<-f.doneC
+	intracom.Close(f.ic)
+}
@@ -0,0 +1,61 @@
+package rxd
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// fanoutBudgets is the performance budget of a state update reaching 10 subscribers, see the README.
+// Allocations are always checked, the latency only with RXD_PERF_BUDGET=1 on a quiet machine.
+var fanoutBudgets = []struct {
+	services int
+	latency  time.Duration
+	bytes    uint64
+	allocs   uint64
+}{
+	{services: 10, latency: 25 * time.Microsecond, bytes: 1 << 10, allocs: 10},
+	{services: 100, latency: 50 * time.Microsecond, bytes: 8 << 10, allocs: 10},
+	{services: 1000, latency: 250 * time.Microsecond, bytes: 96 << 10, allocs: 12},
+}
+
+func TestDaemon_StatesFanoutBudget(t *testing.T) {
+	const subscribers, rounds = 10, 1000
+
+	for _, budget := range fanoutBudgets {
+		t.Run("services="+strconv.Itoa(budget.services), func(t *testing.T) {
+			fanout := newStatesFanout(t, budget.services, subscribers)
+			defer fanout.close()
+
+			// warm up the watcher, the subscribers and the snapshot sizes before measuring.
+			for i := 0; i < 10; i++ {
+				fanout.roundTrip(i)
+			}
+
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			start := time.Now()
+			for i := 0; i < rounds; i++ {
+				fanout.roundTrip(i)
+			}
+			latency := time.Since(start) / rounds
+			runtime.ReadMemStats(&after)
+
+			bytes := (after.TotalAlloc - before.TotalAlloc) / rounds
+			allocs := (after.Mallocs - before.Mallocs) / rounds
+			t.Logf("%s/op %d B/op %d allocs/op", latency, bytes, allocs)
+
+			if bytes > budget.bytes {
+				t.Errorf("expected at most %d B/op, got %d", budget.bytes, bytes)
+			}
+			if allocs > budget.allocs {
+				t.Errorf("expected at most %d allocs/op, got %d", budget.allocs, allocs)
+			}
+			if os.Getenv("RXD_PERF_BUDGET") == "1" && latency > budget.latency {
+				t.Errorf("expected at most %s/op, got %s", budget.latency, latency)
+			}
+		})
+	}
+}
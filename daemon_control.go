@@ -0,0 +1,217 @@
+package rxd
+
+import (
+	"context"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/ambitiousfew/rxd/intracom"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// ControlServer is the server the control plane is exposed with, it is implemented by *grpc.Server.
+// The rxd module does not depend on grpc, see proto/control.proto for the service definition.
+type ControlServer interface {
+	Serve(lis net.Listener) error
+	GracefulStop()
+	Stop()
+}
+
+// ControlPlane lets remote tooling inspect and drive the services of a running daemon. It is handed to
+// the factory given to UsingControlPlane, which registers a server implementation backed by it.
+type ControlPlane struct {
+	d     *daemon
+	doneC chan struct{} // closed once the daemon stops the control server, ending every WatchStates stream.
+}
+
+// ListServices returns the current state of every service, including the external units watched by the daemon.
+func (p *ControlPlane) ListServices() ServiceStates {
+	return p.d.readiness.current()
+}
+
+// GetState returns the current state of a single service.
+func (p *ControlPlane) GetState(name string) (State, error) {
+	state, ok := p.d.readiness.current()[name]
+	if !ok {
+		return StateExit, ErrServiceNotFound
+	}
+	return state, nil
+}
+
+// StartService starts a registered service that is not running, e.g. after StopService.
+func (p *ControlPlane) StartService(name string) error {
+	return p.d.startService(name)
+}
+
+// StopService stops a running service and waits until it exits its lifecycles or ctx is done.
+// The service stays registered and can be started again with StartService.
+// NOTE: once the last running service exits the daemon shuts down, just as when all services exit on their own.
+func (p *ControlPlane) StopService(ctx context.Context, name string) error {
+	return p.d.stopService(ctx, name)
+}
+
+// RestartService takes a running service through Stop and back to Init, see Recycle.
+func (p *ControlPlane) RestartService(name string) error {
+	return p.d.Recycle(name)
+}
+
+// SetLogLevel sets the level of both the service and internal loggers.
+func (p *ControlPlane) SetLogLevel(level log.Level) {
+	p.d.serviceLogger.SetLevel(level)
+	p.d.internalLogger.SetLevel(level)
+	p.d.internalLogger.Log(log.LevelInfo, "log level changed to "+level.String(), log.String("rxd", p.d.name))
+}
+
+// WatchStates returns a channel receiving the states of every service each time they change, starting with
+// the current states. Only the latest states are kept if the receiver falls behind. The channel is closed
+// once ctx is done or the daemon stops.
+func (p *ControlPlane) WatchStates(ctx context.Context) (<-chan ServiceStates, error) {
+	consumer := intracom.ConsumerID(p.d.ic, prefix+".control")
+	sub, err := intracom.CreateSubscription[ServiceStates](ctx, p.d.ic, internalServiceStates, -1, intracom.SubscriberConfig[ServiceStates]{
+		ConsumerGroup: consumer,
+		ErrIfExists:   true,
+		BufferSize:    1,
+		BufferPolicy:  intracom.BufferPolicyDropOldest[ServiceStates]{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan ServiceStates, 1)
+	go func() {
+		defer close(ch)
+		defer intracom.RemoveSubscription[ServiceStates](p.d.ic, internalServiceStates, consumer, sub)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-p.doneC:
+				return
+			case states, open := <-sub:
+				if !open {
+					return
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-p.doneC:
+					return
+				case ch <- states:
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// startService launches a registered service whose routine is not running.
+func (d *daemon) startService(name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.services[name]; !ok {
+		return ErrServiceNotFound
+	}
+	if d.rt == nil {
+		return ErrDaemonNotStarted
+	}
+	if d.rt.closing || d.rt.ctx.Err() != nil {
+		return ErrDaemonStopping
+	}
+	if run, ok := d.runs[name]; ok && !run.exited() {
+		return ErrServiceRunning
+	}
+
+	d.internalLogger.Log(log.LevelInfo, "starting service on request", log.String("service_name", name), log.String("rxd", d.name))
+	d.launchLocked(name)
+	return nil
+}
+
+// stopService stops the routine of a running service without deregistering it.
+func (d *daemon) stopService(ctx context.Context, name string) error {
+	d.mu.RLock()
+	if _, ok := d.services[name]; !ok {
+		d.mu.RUnlock()
+		return ErrServiceNotFound
+	}
+	run, ok := d.runs[name]
+	if !ok || run.exited() {
+		d.mu.RUnlock()
+		return ErrServiceNotRunning
+	}
+	for other, conf := range d.configs {
+		if r, ok := d.runs[other]; ok && !r.exited() && slices.Contains(conf.dependsOn, name) {
+			d.mu.RUnlock()
+			return ErrServiceHasDependents
+		}
+	}
+	d.mu.RUnlock()
+
+	d.internalLogger.Log(log.LevelInfo, "stopping service on request", log.String("service_name", name), log.String("rxd", d.name))
+	run.cancel()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-run.doneC:
+		return nil
+	}
+}
+
+// exited reports whether the service routine has returned.
+func (r *serviceRun) exited() bool {
+	select {
+	case <-r.doneC:
+		return true
+	default:
+		return false
+	}
+}
+
+// startControl starts the control server when enabled, it returns a func stopping it gracefully.
+func (d *daemon) startControl(nameField log.Field) func() {
+	if d.controlFactory == nil {
+		return func() {}
+	}
+
+	listener, err := net.Listen("tcp", d.controlAddr)
+	if err != nil {
+		d.internalLogger.Log(log.LevelError, "error starting control server", log.Error("error", err), nameField)
+		return func() {}
+	}
+
+	plane := &ControlPlane{d: d, doneC: make(chan struct{})}
+	server := d.controlFactory(plane)
+	doneC := make(chan struct{})
+	go func() {
+		defer close(doneC)
+		d.internalLogger.Log(log.LevelInfo, "starting control server at "+listener.Addr().String(), nameField)
+		if err := server.Serve(listener); err != nil {
+			d.internalLogger.Log(log.LevelError, "error running control server", log.Error("error", err), nameField)
+			return
+		}
+		d.internalLogger.Log(log.LevelInfo, "stopped running control server and exited successfully", nameField)
+	}()
+
+	return func() {
+		close(plane.doneC)
+
+		stoppedC := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(stoppedC)
+		}()
+
+		select {
+		case <-stoppedC:
+		case <-time.After(5 * time.Second):
+			// pending calls did not finish in time.
+			server.Stop()
+			<-stoppedC
+		}
+		<-doneC
+	}
+}
@@ -0,0 +1,144 @@
+package rxd
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// fakeControlServer accepts connections until it is stopped, standing in for a *grpc.Server.
+type fakeControlServer struct {
+	mu      sync.Mutex
+	lis     net.Listener
+	stopped bool
+}
+
+func (s *fakeControlServer) Serve(lis net.Listener) error {
+	s.mu.Lock()
+	s.lis = lis
+	if s.stopped {
+		lis.Close()
+	}
+	s.mu.Unlock()
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			return nil
+		}
+		conn.Close()
+	}
+}
+
+func (s *fakeControlServer) GracefulStop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+	if s.lis != nil {
+		s.lis.Close()
+	}
+}
+
+func (s *fakeControlServer) Stop() {
+	s.GracefulStop()
+}
+
+func awaitState(t *testing.T, statesC <-chan ServiceStates, name string, want State) {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case states := <-statesC:
+			if states[name] == want {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s to reach %s", name, want)
+		}
+	}
+}
+
+func TestDaemon_ControlPlane(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	planeC := make(chan *ControlPlane, 1)
+	serviceLogger := log.NewLogger(log.LevelInfo, newTestLogger())
+	journal := &recordingJournal{}
+	d := NewDaemon("test-daemon",
+		UsingControlPlane("127.0.0.1:0", func(plane *ControlPlane) ControlServer {
+			planeC <- plane
+			return &fakeControlServer{}
+		}),
+		WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+		WithServiceLogger(serviceLogger),
+	)
+	for _, name := range []string{"api", "worker"} {
+		if err := d.AddService(NewService(name, &recordingService{name: name, journal: journal})); err != nil {
+			t.Fatalf("error adding service: %s", err)
+		}
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	var plane *ControlPlane
+	select {
+	case plane = <-planeC:
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the control server")
+	}
+
+	statesC, err := plane.WatchStates(ctx)
+	if err != nil {
+		t.Fatalf("error watching states: %s", err)
+	}
+	awaitState(t, statesC, "worker", StateRun)
+
+	if err := plane.StartService("worker"); err != ErrServiceRunning {
+		t.Errorf("expected %s, got %v", ErrServiceRunning, err)
+	}
+	if err := plane.StopService(ctx, "worker"); err != nil {
+		t.Fatalf("error stopping service: %s", err)
+	}
+	awaitState(t, statesC, "worker", StateExit)
+	if err := plane.StopService(ctx, "worker"); err != ErrServiceNotRunning {
+		t.Errorf("expected %s, got %v", ErrServiceNotRunning, err)
+	}
+	if state, err := plane.GetState("api"); err != nil || state != StateRun {
+		t.Errorf("expected api to run, got %s, %v", state, err)
+	}
+
+	if err := plane.StartService("worker"); err != nil {
+		t.Fatalf("error starting service: %s", err)
+	}
+	awaitState(t, statesC, "worker", StateRun)
+	if journal.count("worker:init") != 2 {
+		t.Errorf("expected worker to be initialized twice, got %v", journal.entries)
+	}
+
+	if _, err := plane.GetState("unknown"); err != ErrServiceNotFound {
+		t.Errorf("expected %s, got %v", ErrServiceNotFound, err)
+	}
+	if len(plane.ListServices()) != 2 {
+		t.Errorf("expected 2 services, got %v", plane.ListServices())
+	}
+
+	plane.SetLogLevel(log.LevelDebug)
+	if l, ok := serviceLogger.(leveler); !ok || l.Level() != log.LevelDebug {
+		t.Errorf("expected the service logger level to be debug")
+	}
+
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+	// the stream ends once the daemon stops.
+	for range statesC {
+	}
+}
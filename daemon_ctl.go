@@ -0,0 +1,229 @@
+package rxd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+	"github.com/ambitiousfew/rxd/pkg/ctl"
+)
+
+// logTail fans the service logs out to the streams of the control socket.
+type logTail struct {
+	mu       sync.RWMutex
+	subs     map[chan ctl.LogEntry]struct{}
+	redactor *log.Redactor // redacts the entries streamed, see UsingRedaction.
+}
+
+func (t *logTail) subscribe() chan ctl.LogEntry {
+	ch := make(chan ctl.LogEntry, 100)
+	t.mu.Lock()
+	if t.subs == nil {
+		t.subs = make(map[chan ctl.LogEntry]struct{})
+	}
+	t.subs[ch] = struct{}{}
+	t.mu.Unlock()
+	return ch
+}
+
+func (t *logTail) unsubscribe(ch chan ctl.LogEntry) {
+	t.mu.Lock()
+	delete(t.subs, ch)
+	t.mu.Unlock()
+}
+
+// publish never blocks the service logs, entries are dropped for streams that fall behind.
+func (t *logTail) publish(entry DaemonLog) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if len(t.subs) == 0 {
+		return
+	}
+	if t.redactor != nil {
+		entry.Message = t.redactor.RedactMessage(entry.Message)
+		entry.Fields = t.redactor.RedactFields(entry.Fields)
+	}
+
+	tailed := ctl.LogEntry{
+		Time:    time.Now(),
+		Level:   entry.Level.String(),
+		Message: entry.Message,
+		Fields:  make(map[string]string, len(entry.Fields)),
+	}
+	for _, f := range entry.Fields {
+		tailed.Fields[f.Key] = f.Value
+	}
+
+	for ch := range t.subs {
+		select {
+		case ch <- tailed:
+		default:
+		}
+	}
+}
+
+// startControlSocket serves the control socket when enabled, it returns a func closing the socket
+// and every open connection.
+func (d *daemon) startControlSocket(nameField log.Field) func() {
+	if d.ctlSocket == "" {
+		return func() {}
+	}
+
+	// a socket left behind by a daemon that did not exit cleanly would fail the listen.
+	if err := os.Remove(d.ctlSocket); err != nil && !os.IsNotExist(err) {
+		d.internalLogger.Log(log.LevelError, "error removing stale control socket", log.Error("error", err), nameField)
+	}
+
+	listener, err := net.Listen("unix", d.ctlSocket)
+	if err != nil {
+		d.internalLogger.Log(log.LevelError, "error starting control socket", log.Error("error", err), nameField)
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.internalLogger.Log(log.LevelInfo, "serving control socket at "+d.ctlSocket, nameField)
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d.serveControlConn(ctx, conn)
+			}()
+		}
+	}()
+
+	return func() {
+		cancel()
+		listener.Close()
+		wg.Wait()
+		d.internalLogger.Log(log.LevelInfo, "closed control socket", nameField)
+	}
+}
+
+// serveControlConn answers the single request of a connection.
+func (d *daemon) serveControlConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	// a blocked read or write is released once the socket is closed.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return
+	}
+
+	enc := json.NewEncoder(conn)
+	var req ctl.Request
+	if err := json.Unmarshal(line, &req); err != nil {
+		enc.Encode(ctl.Response{Err: err.Error()})
+		return
+	}
+
+	if req.Daemon != "" && req.Daemon != d.name {
+		d.forwardControl(ctx, conn, reader, req)
+		return
+	}
+
+	switch req.Command {
+	case ctl.Daemons:
+		if d.ctlProxyDir == "" {
+			enc.Encode(ctl.Response{Err: ErrNotAdminProxy.Error()})
+			return
+		}
+		daemons, err := ctl.Discover(d.ctlProxyDir)
+		if err != nil {
+			enc.Encode(ctl.Response{Err: err.Error()})
+			return
+		}
+		enc.Encode(ctl.Response{Daemons: daemons})
+	case ctl.States:
+		states := d.readiness.current()
+		resp := ctl.Response{States: make(map[string]string, len(states)), Errors: d.lastErrors.messages()}
+		for name, state := range states {
+			resp.States[name] = state.String()
+		}
+		enc.Encode(resp)
+	case ctl.Restart:
+		if err := d.Recycle(req.Service); err != nil {
+			enc.Encode(ctl.Response{Err: err.Error()})
+			return
+		}
+		enc.Encode(ctl.Response{})
+	case ctl.Resume:
+		if err := d.ResumeService(req.Service); err != nil {
+			enc.Encode(ctl.Response{Err: err.Error()})
+			return
+		}
+		enc.Encode(ctl.Response{})
+	case ctl.Logs:
+		entries := d.tail.subscribe()
+		defer d.tail.unsubscribe(entries)
+
+		closedC := closedByClient(reader)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-closedC:
+				return
+			case entry := <-entries:
+				if req.Service != "" && entry.Fields["service"] != req.Service {
+					continue
+				}
+				if err := enc.Encode(ctl.Response{Log: &entry}); err != nil {
+					return
+				}
+			}
+		}
+	default:
+		enc.Encode(ctl.Response{Err: ErrInvalidOperation.Error()})
+	}
+}
+
+// forwardControl relays a request to another daemon discovered in the proxy directory.
+func (d *daemon) forwardControl(ctx context.Context, conn net.Conn, reader *bufio.Reader, req ctl.Request) {
+	if d.ctlProxyDir == "" {
+		json.NewEncoder(conn).Encode(ctl.Response{Err: ErrNotAdminProxy.Error()})
+		return
+	}
+
+	fctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	closedC := closedByClient(reader)
+	go func() {
+		select {
+		case <-closedC:
+			cancel()
+		case <-fctx.Done():
+		}
+	}()
+
+	if err := ctl.Forward(fctx, d.ctlProxyDir, req, conn); err != nil && fctx.Err() == nil {
+		json.NewEncoder(conn).Encode(ctl.Response{Err: err.Error()})
+	}
+}
+
+// closedByClient returns a channel closed once the client closes the connection, which ends streams.
+// Clients send nothing after their request, so any read returning means the connection is done.
+func closedByClient(reader *bufio.Reader) <-chan struct{} {
+	closedC := make(chan struct{})
+	go func() {
+		reader.ReadByte()
+		close(closedC)
+	}()
+	return closedC
+}
@@ -0,0 +1,199 @@
+package rxd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+	"github.com/ambitiousfew/rxd/pkg/ctl"
+)
+
+// chattyService logs every few milliseconds while it runs.
+type chattyService struct {
+	recordingService
+}
+
+func (s *chattyService) Run(sctx ServiceContext) error {
+	s.journal.record(s.name + ":run")
+	ticker := time.NewTicker(5 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sctx.Done():
+			return nil
+		case <-ticker.C:
+			sctx.Log(log.LevelInfo, "tick")
+		}
+	}
+}
+
+func TestDaemon_ControlSocket(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// unix socket paths are limited in length, the test temp dir may be too long.
+	dir, err := os.MkdirTemp("", "rxd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "ctl.sock")
+
+	journal := &recordingJournal{}
+	d := NewDaemon("test-daemon",
+		UsingControlSocket(socket),
+		WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+		WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+	)
+	for _, name := range []string{"api", "worker"} {
+		if err := d.AddService(NewService(name, &chattyService{recordingService{name: name, journal: journal}})); err != nil {
+			t.Fatalf("error adding service: %s", err)
+		}
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	client := ctl.NewClient(socket)
+	for journal.count("worker:run") < 1 || journal.count("api:run") < 1 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var states map[string]string
+	for states["worker"] != StateRun.String() {
+		if states, err = client.States(ctx); err != nil {
+			t.Fatalf("error querying states: %s", err)
+		}
+	}
+
+	logsCtx, logsCancel := context.WithCancel(ctx)
+	var entries []ctl.LogEntry
+	err = client.Logs(logsCtx, "worker", func(entry ctl.LogEntry) {
+		entries = append(entries, entry)
+		if len(entries) == 3 {
+			logsCancel()
+		}
+	})
+	if err != nil {
+		t.Fatalf("error tailing logs: %s", err)
+	}
+	for _, entry := range entries {
+		if entry.Message != "tick" || entry.Fields["service"] != "worker" {
+			t.Errorf("unexpected log entry %+v", entry)
+		}
+	}
+
+	if err := client.Restart(ctx, "worker"); err != nil {
+		t.Fatalf("error restarting service: %s", err)
+	}
+	for journal.count("worker:run") < 2 {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for the service to run again, got %v", journal.entries)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	if err := client.Restart(ctx, "unknown"); !errors.Is(err, ErrServiceNotFound) {
+		t.Errorf("expected %s, got %v", ErrServiceNotFound, err)
+	}
+
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+	if _, err := os.Stat(socket); !os.IsNotExist(err) {
+		t.Errorf("expected the control socket to be removed, got %v", err)
+	}
+}
+
+func TestDaemon_AdminProxy(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	dir, err := os.MkdirTemp("", "rxd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// files that are not sockets are not daemons.
+	if err := os.WriteFile(filepath.Join(dir, "stale.sock"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	journal := &recordingJournal{}
+	start := func(name string, opts ...DaemonOption) <-chan error {
+		opts = append(opts,
+			UsingControlSocket(ctl.SocketPath(dir, name)),
+			WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+			WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+		)
+		d := NewDaemon(name, opts...)
+		if err := d.AddService(NewService(name+"-svc", &recordingService{name: name + "-svc", journal: journal})); err != nil {
+			t.Fatalf("error adding service: %s", err)
+		}
+		errC := make(chan error, 1)
+		go func() {
+			errC <- d.Start(ctx)
+		}()
+		return errC
+	}
+
+	ingestErrC := start("ingest")
+	adminErrC := start("admin", UsingAdminProxy(dir))
+
+	for journal.count("ingest-svc:run") < 1 || journal.count("admin-svc:run") < 1 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	proxy := ctl.NewClient(ctl.SocketPath(dir, "admin"))
+	daemons, err := proxy.Daemons(ctx)
+	if err != nil {
+		t.Fatalf("error listing daemons: %s", err)
+	}
+	if strings.Join(daemons, ",") != "admin,ingest" {
+		t.Errorf("expected daemons admin and ingest, got %v", daemons)
+	}
+
+	var states map[string]string
+	for states["ingest-svc"] != StateRun.String() {
+		if states, err = proxy.Via("ingest").States(ctx); err != nil {
+			t.Fatalf("error querying states through the proxy: %s", err)
+		}
+	}
+	if _, ok := states["admin-svc"]; ok {
+		t.Errorf("expected only the states of ingest, got %v", states)
+	}
+
+	if err := proxy.Via("ingest").Restart(ctx, "ingest-svc"); err != nil {
+		t.Fatalf("error restarting through the proxy: %s", err)
+	}
+	for journal.count("ingest-svc:run") < 2 {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for the service to run again, got %v", journal.entries)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	if _, err := proxy.Via("unknown").States(ctx); err == nil {
+		t.Errorf("expected an error for an unknown daemon")
+	}
+	if _, err := ctl.NewClient(ctl.SocketPath(dir, "ingest")).Daemons(ctx); !errors.Is(err, ErrNotAdminProxy) {
+		t.Errorf("expected %s, got %v", ErrNotAdminProxy, err)
+	}
+
+	cancel()
+	for _, errC := range []<-chan error{ingestErrC, adminErrC} {
+		if err := <-errC; err != nil {
+			t.Fatalf("error starting daemon: %s", err)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package rxd
+
+import (
+	"context"
+	"slices"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// checkDependencies ensures every dependency of every service is registered
+// and that the dependencies do not form a cycle, which would block startup forever.
+func (d *daemon) checkDependencies() error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	marks := make(map[string]int, len(d.configs))
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch marks[name] {
+		case visiting:
+			return ErrDependencyCycle
+		case visited:
+			return nil
+		}
+
+		marks[name] = visiting
+		for _, dep := range d.configs[name].dependsOn {
+			if d.isUnitLocked(dep) {
+				// external units have no dependencies of their own.
+				continue
+			}
+			if _, ok := d.services[dep]; !ok {
+				return ErrUnknownDependency
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		marks[name] = visited
+		return nil
+	}
+
+	for name := range d.services {
+		if d.isUnitLocked(name) {
+			return ErrDuplicateServiceName
+		}
+		if err := visit(name); err != nil {
+			d.internalLogger.Log(log.LevelError, "invalid service dependencies", log.String("service_name", name), log.Error("error", err), log.String("rxd", d.name))
+			return err
+		}
+	}
+	return nil
+}
+
+// dependentsLocked returns the running services that depend on the given service, the caller must hold the daemon lock.
+func (d *daemon) dependentsLocked(name string) []string {
+	var dependents []string
+	for other, conf := range d.configs {
+		if _, running := d.runs[other]; running && slices.Contains(conf.dependsOn, name) {
+			dependents = append(dependents, other)
+		}
+	}
+	return dependents
+}
+
+// awaitDependencies blocks until every dependency of the service has reached StateRun.
+// false is returned when the service context is done first.
+func (d *daemon) awaitDependencies(sctx ServiceContext, dependsOn []string) bool {
+	sctx.Log(log.LevelDebug, "waiting for dependencies to run", log.Any("depends_on", dependsOn))
+
+	ch, cancel := sctx.WatchAllServices(Entered, StateRun, dependsOn...)
+	defer cancel()
+
+	select {
+	case <-sctx.Done():
+		return false
+	case _, open := <-ch:
+		return open
+	}
+}
+
+// stopBeforeLocked returns the registered services that must have stopped before the given service is stopped,
+// its dependents and the services ahead of it in the shutdown order. The caller must hold the daemon lock.
+func (d *daemon) stopBeforeLocked(name string) []string {
+	var before []string
+	for other, conf := range d.configs {
+		if slices.Contains(conf.dependsOn, name) {
+			before = append(before, other)
+		}
+	}
+
+	if len(d.shutdownOrder) > 0 {
+		pos := slices.Index(d.shutdownOrder, name)
+		if pos < 0 {
+			// services without a position stop after all the ordered ones.
+			pos = len(d.shutdownOrder)
+		}
+		for _, other := range d.shutdownOrder[:pos] {
+			if _, ok := d.services[other]; ok && other != name && !slices.Contains(before, other) {
+				before = append(before, other)
+			}
+		}
+	}
+	return before
+}
+
+// checkShutdownOrderLocked ensures the shutdown order does not contradict the service dependencies,
+// which would leave services waiting on each other forever. The caller must hold the daemon lock.
+func (d *daemon) checkShutdownOrderLocked() error {
+	if len(d.shutdownOrder) == 0 {
+		// dependencies alone are already checked for cycles.
+		return nil
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	marks := make(map[string]int, len(d.services))
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch marks[name] {
+		case visiting:
+			return ErrShutdownOrderConflict
+		case visited:
+			return nil
+		}
+
+		marks[name] = visiting
+		for _, before := range d.stopBeforeLocked(name) {
+			if err := visit(before); err != nil {
+				return err
+			}
+		}
+		marks[name] = visited
+		return nil
+	}
+
+	for name := range d.services {
+		if d.isUnitLocked(name) {
+			return ErrDuplicateServiceName
+		}
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stopInOrder cancels the service once the daemon is stopping, but only after the services that must stop
+// before it have exited, so a service never loses a dependency while it is still running.
+func (d *daemon) stopInOrder(ctx context.Context, name string, run *serviceRun) {
+	select {
+	case <-run.doneC:
+		return
+	case <-ctx.Done():
+	}
+
+	d.mu.RLock()
+	var waitC []chan struct{}
+	for _, before := range d.stopBeforeLocked(name) {
+		if r, running := d.runs[before]; running {
+			waitC = append(waitC, r.doneC)
+		}
+	}
+	d.mu.RUnlock()
+
+	for _, doneC := range waitC {
+		<-doneC
+	}
+	run.cancel()
+}
@@ -0,0 +1,125 @@
+package rxd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// emergencyLevel is the least severe level logged through the emergency path: LevelEmergency, LevelAlert and LevelCritical.
+const emergencyLevel log.Level = log.LevelCritical
+
+// Emergency is a message a service logged at LevelCritical or above, handed to the emergency hooks.
+type Emergency struct {
+	Service string
+	Log     DaemonLog
+	States  ServiceStates // the states of every service when the message was logged.
+}
+
+// MarshalJSON writes the level and states by name, so snapshots stay readable.
+func (e Emergency) MarshalJSON() ([]byte, error) {
+	fields := make(map[string]string, len(e.Log.Fields))
+	for _, f := range e.Log.Fields {
+		fields[f.Key] = f.Value
+	}
+
+	states := make(map[string]string, len(e.States))
+	for name, state := range e.States {
+		states[name] = state.String()
+	}
+
+	return json.Marshal(struct {
+		Service string            `json:"service"`
+		Level   string            `json:"level"`
+		Message string            `json:"message"`
+		Fields  map[string]string `json:"fields"`
+		States  map[string]string `json:"states"`
+	}{e.Service, e.Log.Level.String(), e.Log.Message, fields, states})
+}
+
+// EmergencyHook is called after an emergency message has been written and flushed, e.g. to capture a snapshot
+// of the process. Hooks run synchronously in the routine of the service that logged the message, one emergency at a time.
+type EmergencyHook func(e Emergency)
+
+// SnapshotStates writes the emergency along with the states of every service as json to path, replacing the previous snapshot.
+func SnapshotStates(path string) EmergencyHook {
+	return func(e Emergency) {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return
+		}
+		writeFileAtomic(path, data)
+	}
+}
+
+// DumpGoroutines writes the stacks of every goroutine to path, replacing the previous dump.
+// The daemon keeps running, the dump stands in for a core dump without aborting the process.
+func DumpGoroutines(path string) EmergencyHook {
+	return func(e Emergency) {
+		var b bytes.Buffer
+		if err := pprof.Lookup("goroutine").WriteTo(&b, 2); err != nil {
+			return
+		}
+		writeFileAtomic(path, b.Bytes())
+	}
+}
+
+// logEmergency writes an emergency message of a service through the service logger synchronously, bypassing
+// the log channel and its workers so the message is not lost if the process dies. The logger is flushed
+// before the emergency hooks run.
+func (d *daemon) logEmergency(service string, entry DaemonLog) {
+	d.tail.publish(entry)
+	d.serviceLogger.Log(entry.Level, entry.Message, entry.Fields...)
+	if err := log.Flush(d.serviceLogger); err != nil {
+		d.internalLogger.Log(log.LevelError, "error flushing service logger", log.Error("error", err), log.String("rxd", d.name))
+	}
+
+	if len(d.emergencyHooks) == 0 {
+		return
+	}
+
+	e := Emergency{
+		Service: service,
+		Log:     entry,
+		States:  d.readiness.current().copy(),
+	}
+
+	d.emergencyMu.Lock()
+	defer d.emergencyMu.Unlock()
+	for _, hook := range d.emergencyHooks {
+		d.callEmergencyHook(hook, e)
+	}
+}
+
+// callEmergencyHook recovers from a panicking hook, which must not take down the service that logged the emergency.
+func (d *daemon) callEmergencyHook(hook EmergencyHook, e Emergency) {
+	defer func() {
+		if r := recover(); r != nil {
+			perr := newPanicError("emergency hook", r)
+			d.internalLogger.Log(log.LevelError, "recovered from panic in emergency hook", append(perr.fields(), log.String("service_name", e.Service), log.String("rxd", d.name))...)
+		}
+	}()
+	hook(e)
+}
+
+// writeFileAtomic replaces path with data so readers never observe a partial file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
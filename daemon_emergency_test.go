@@ -0,0 +1,113 @@
+package rxd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// flushingLogger records whether messages were flushed.
+type flushingLogger struct {
+	*testServiceLogger
+	flushes atomic.Int32
+}
+
+func (l *flushingLogger) Flush() error {
+	l.flushes.Add(1)
+	return nil
+}
+
+// criticalService logs a critical message once running and checks it was written before Log returned.
+type criticalService struct {
+	recordingService
+	handler *flushingLogger
+	written atomic.Bool
+	started func() bool
+}
+
+func (s *criticalService) Run(sctx ServiceContext) error {
+	for !s.started() {
+		time.Sleep(time.Millisecond)
+	}
+	sctx.Log(log.LevelCritical, "disk corrupted")
+	s.written.Store(strings.Contains(s.handler.Output(), "disk corrupted") && s.handler.flushes.Load() > 0)
+	return nil
+}
+
+func TestDaemon_EmergencyLog(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	dir := t.TempDir()
+	snapshot := filepath.Join(dir, "snapshot.json")
+	dump := filepath.Join(dir, "goroutines.txt")
+
+	var hooked []Emergency
+	handler := &flushingLogger{testServiceLogger: newTestLogger()}
+	var d *daemon
+	d = NewDaemon("test-daemon",
+		UsingEmergencyHook(func(e Emergency) {
+			hooked = append(hooked, e)
+		}),
+		UsingEmergencyHook(func(e Emergency) {
+			panic("hooks must not take down the service")
+		}),
+		UsingEmergencyHook(SnapshotStates(snapshot)),
+		UsingEmergencyHook(DumpGoroutines(dump)),
+		WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+		WithServiceLogger(log.NewLogger(log.LevelDebug, handler)),
+	).(*daemon)
+
+	runner := &criticalService{recordingService: recordingService{name: "critical", journal: &recordingJournal{}}, handler: handler}
+	runner.started = func() bool { return d.readiness.current()["critical"] == StateRun }
+	if err := d.AddService(NewService("critical", runner, WithManager(NewRunOnceManager(0)))); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	if err := d.Start(ctx); err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+
+	if !runner.written.Load() {
+		t.Errorf("expected the critical message to be written and flushed before Log returned")
+	}
+
+	if len(hooked) != 1 || hooked[0].Service != "critical" || hooked[0].Log.Message != "disk corrupted" {
+		t.Fatalf("expected one emergency of the critical service, got %+v", hooked)
+	}
+	if hooked[0].States["critical"] != StateRun {
+		t.Errorf("expected the emergency states to have the service running, got %v", hooked[0].States)
+	}
+
+	data, err := os.ReadFile(snapshot)
+	if err != nil {
+		t.Fatalf("error reading states snapshot: %s", err)
+	}
+
+	var report struct {
+		Service string            `json:"service"`
+		Level   string            `json:"level"`
+		States  map[string]string `json:"states"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("error decoding states snapshot: %s", err)
+	}
+	if report.Service != "critical" || report.Level != log.Level(log.LevelCritical).String() || report.States["critical"] != StateRun.String() {
+		t.Errorf("unexpected states snapshot: %s", data)
+	}
+
+	stacks, err := os.ReadFile(dump)
+	if err != nil {
+		t.Fatalf("error reading goroutine dump: %s", err)
+	}
+	if !strings.Contains(string(stacks), "criticalService") {
+		t.Errorf("expected the goroutine dump to have the stack of the service")
+	}
+}
@@ -0,0 +1,142 @@
+package rxd
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// Environment variables read by FromEnv.
+const (
+	EnvLogLevel         = "RXD_LOG_LEVEL"          // level of the service logger e.g. "debug".
+	EnvShutdownTimeout  = "RXD_SHUTDOWN_TIMEOUT"   // shutdown grace period e.g. "30s", see WithShutdownGrace.
+	EnvDebugAddr        = "RXD_DEBUG_ADDR"         // address of the rpc server e.g. "127.0.0.1:1337", see WithRPC.
+	EnvReportAliveSecs  = "RXD_REPORT_ALIVE_SECS"  // systemd watchdog interval in seconds, see WithReportAlive.
+	EnvLogWorkers       = "RXD_LOG_WORKERS"        // number of service log workers, see WithLogWorkerCount.
+	EnvStatusFile       = "RXD_STATUS_FILE"        // path of the status file, see WithStatusFile.
+	EnvHealthInterval   = "RXD_HEALTH_INTERVAL"    // health check interval e.g. "10s", see WithHealthChecks.
+	EnvHealthTimeout    = "RXD_HEALTH_TIMEOUT"     // health check timeout e.g. "5s", see WithHealthChecks.
+	EnvInternalLogFile  = "RXD_INTERNAL_LOG_FILE"  // enables internal logging to the file, see WithInternalLogging.
+	EnvInternalLogLevel = "RXD_INTERNAL_LOG_LEVEL" // level of the internal log file (default: debug).
+)
+
+// FromEnv reads the daemon configuration from the RXD_* environment variables so containers can tune
+// the daemon without code changes. Variables that are not set leave the configuration untouched,
+// values that cannot be parsed are all reported in the returned error.
+// Options given after FromEnv override the environment, RXD_LOG_LEVEL applies to the service logger
+// set before FromEnv, so WithServiceLogger should be given first:
+//
+//	env, err := rxd.FromEnv()
+//	if err != nil {
+//		return err
+//	}
+//	d := rxd.NewDaemon("app", rxd.WithServiceLogger(logger), env)
+func FromEnv() (DaemonOption, error) {
+	return fromEnv(os.LookupEnv)
+}
+
+func fromEnv(lookup func(key string) (string, bool)) (DaemonOption, error) {
+	var opts []DaemonOption
+	var errs []error
+
+	env := func(key string, parse func(value string) (DaemonOption, error)) {
+		value, ok := lookup(key)
+		if !ok || value == "" {
+			return
+		}
+
+		opt, err := parse(value)
+		if err != nil {
+			errs = append(errs, ErrInvalidEnv{Key: key, Value: value, Err: err})
+			return
+		}
+		if opt != nil {
+			opts = append(opts, opt)
+		}
+	}
+
+	env(EnvLogLevel, func(value string) (DaemonOption, error) {
+		level, err := parseLevel(value)
+		if err != nil {
+			return nil, err
+		}
+		return WithServiceLogLevel(level), nil
+	})
+
+	env(EnvShutdownTimeout, func(value string) (DaemonOption, error) {
+		grace, err := time.ParseDuration(value)
+		return WithShutdownGrace(grace), err
+	})
+
+	env(EnvDebugAddr, func(value string) (DaemonOption, error) {
+		host, portStr, err := net.SplitHostPort(value)
+		if err != nil {
+			return nil, err
+		}
+		port, err := strconv.ParseUint(portStr, 10, 16)
+		return WithRPC(RPCConfig{Addr: host, Port: uint16(port)}), err
+	})
+
+	env(EnvReportAliveSecs, func(value string) (DaemonOption, error) {
+		secs, err := strconv.ParseUint(value, 10, 64)
+		return WithReportAlive(secs), err
+	})
+
+	env(EnvLogWorkers, func(value string) (DaemonOption, error) {
+		count, err := strconv.Atoi(value)
+		if err == nil && count < 1 {
+			err = strconv.ErrRange
+		}
+		return WithLogWorkerCount(count), err
+	})
+
+	env(EnvStatusFile, func(value string) (DaemonOption, error) {
+		return WithStatusFile(value), nil
+	})
+
+	env(EnvHealthInterval, func(value string) (DaemonOption, error) {
+		interval, err := time.ParseDuration(value)
+		return WithHealthChecks(interval, 0), err
+	})
+
+	env(EnvHealthTimeout, func(value string) (DaemonOption, error) {
+		timeout, err := time.ParseDuration(value)
+		return WithHealthChecks(0, timeout), err
+	})
+
+	var internalLevel log.Level = log.LevelDebug
+	env(EnvInternalLogLevel, func(value string) (DaemonOption, error) {
+		level, err := parseLevel(value)
+		internalLevel = level
+		// only used along with the internal log file.
+		return nil, err
+	})
+
+	env(EnvInternalLogFile, func(value string) (DaemonOption, error) {
+		return WithInternalLogging(value, internalLevel), nil
+	})
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return func(d *daemon) {
+		for _, opt := range opts {
+			opt(d)
+		}
+	}, nil
+}
+
+// parseLevel parses a log level name, unlike log.LevelFromString unknown names are an error.
+func parseLevel(value string) (log.Level, error) {
+	level := log.LevelFromString(value)
+	if level.String() != strings.ToUpper(value) {
+		return level, ErrUnknownLogLevel
+	}
+	return level, nil
+}
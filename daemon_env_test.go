@@ -0,0 +1,76 @@
+package rxd
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+func lookupFrom(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	logger := newTestLogger()
+	opt, err := fromEnv(lookupFrom(map[string]string{
+		EnvLogLevel:        "warning",
+		EnvShutdownTimeout: "30s",
+		EnvDebugAddr:       "0.0.0.0:9000",
+		EnvReportAliveSecs: "15",
+		EnvLogWorkers:      "4",
+		EnvHealthInterval:  "1m",
+		EnvStatusFile:      "",
+	}))
+	if err != nil {
+		t.Fatalf("error reading env: %s", err)
+	}
+
+	d := NewDaemon("test-daemon", WithServiceLogger(log.NewLogger(log.LevelDebug, logger)), opt).(*daemon)
+
+	if d.shutdownGrace != 30*time.Second {
+		t.Errorf("expected a 30s shutdown grace, got %s", d.shutdownGrace)
+	}
+	if !d.rpcEnabled || d.rpcConfig.Addr != "0.0.0.0" || d.rpcConfig.Port != 9000 {
+		t.Errorf("expected rpc on 0.0.0.0:9000, got %+v", d.rpcConfig)
+	}
+	if d.reportAliveSecs != 15 || d.logWorkerCount != 4 {
+		t.Errorf("expected report alive 15 and 4 log workers, got %d and %d", d.reportAliveSecs, d.logWorkerCount)
+	}
+	if d.healthInterval != time.Minute || d.healthTimeout != 5*time.Second {
+		t.Errorf("expected the health interval to change only, got %s and %s", d.healthInterval, d.healthTimeout)
+	}
+	if d.statusFile != "" {
+		t.Errorf("expected empty variables to be ignored, got status file %q", d.statusFile)
+	}
+
+	d.serviceLogger.Log(log.LevelInfo, "filtered")
+	d.serviceLogger.Log(log.LevelWarning, "kept")
+	if out := logger.Output(); out != "kept \n" {
+		t.Errorf("expected the service logger level to be warning, got %q", out)
+	}
+}
+
+func TestFromEnv_Invalid(t *testing.T) {
+	_, err := fromEnv(lookupFrom(map[string]string{
+		EnvLogLevel:        "loud",
+		EnvShutdownTimeout: "30",
+		EnvDebugAddr:       "localhost",
+		EnvLogWorkers:      "0",
+	}))
+
+	var invalid ErrInvalidEnv
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected an invalid env error, got %v", err)
+	}
+	if !errors.Is(err, ErrUnknownLogLevel) {
+		t.Errorf("expected the unknown log level to be reported, got %v", err)
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); !ok || len(joined.Unwrap()) != 4 {
+		t.Errorf("expected every invalid variable to be reported, got %v", err)
+	}
+}
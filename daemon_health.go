@@ -0,0 +1,257 @@
+package rxd
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ambitiousfew/rxd/intracom"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// HealthChecker can be implemented by a service runner to report whether it is healthy while it runs.
+// Health is probed by the daemon on an interval and is distinct from the lifecycle state of the service,
+// a running service can be unhealthy e.g. when it lost its upstream connection.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// HealthStatus is the outcome of the latest health check of a service.
+type HealthStatus uint8
+
+const (
+	// HealthUnknown is reported for services that are not running or have not been checked yet.
+	HealthUnknown HealthStatus = iota
+	HealthHealthy
+	HealthUnhealthy
+)
+
+func (s HealthStatus) String() string {
+	switch s {
+	case HealthHealthy:
+		return "healthy"
+	case HealthUnhealthy:
+		return "unhealthy"
+	default:
+		return "unknown"
+	}
+}
+
+func (s HealthStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText parses the health written by MarshalText, so health decodes with any codec, see UsingCodec.
+func (s *HealthStatus) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "healthy":
+		*s = HealthHealthy
+	case "unhealthy":
+		*s = HealthUnhealthy
+	default:
+		*s = HealthUnknown
+	}
+	return nil
+}
+
+// ServiceHealth is the health of a single service as of its latest check.
+type ServiceHealth struct {
+	Status    HealthStatus `json:"status"`
+	Error     string       `json:"error,omitempty"` // error of the latest check, empty unless unhealthy.
+	CheckedAt time.Time    `json:"checked_at"`      // when the latest check completed, zero if the service was never checked.
+	Failures  int          `json:"failures"`        // number of consecutive failed checks.
+}
+
+// ServicesHealth is the health of every service implementing HealthChecker by service name.
+type ServicesHealth map[string]ServiceHealth
+
+// Healthy reports whether no service is unhealthy, services with an unknown health do not count against it.
+func (h ServicesHealth) Healthy() bool {
+	for _, health := range h {
+		if health.Status == HealthUnhealthy {
+			return false
+		}
+	}
+	return true
+}
+
+func (h ServicesHealth) copy() ServicesHealth {
+	c := make(ServicesHealth, len(h))
+	for name, health := range h {
+		c[name] = health
+	}
+	return c
+}
+
+// healthProber holds the latest health of the services, it is updated by the probe routine of the daemon.
+type healthProber struct {
+	mu     sync.RWMutex
+	latest ServicesHealth
+}
+
+func (p *healthProber) get() ServicesHealth {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.latest.copy()
+}
+
+func (p *healthProber) set(health ServicesHealth) {
+	p.mu.Lock()
+	p.latest = health
+	p.mu.Unlock()
+}
+
+// Health returns the health of every service implementing HealthChecker as of its latest check.
+func (d *daemon) Health() ServicesHealth {
+	return d.health.get()
+}
+
+// probeHealth checks every running service implementing HealthChecker on the health interval until ctx is done,
+// publishing the results on the health topic. Services are checked concurrently, each within the health timeout.
+// The interval changes to those received on intervalC, sent by config reloads.
+func (d *daemon) probeHealth(ctx context.Context, statesTopic intracom.Topic[ServiceStates], healthTopic intracom.Topic[ServicesHealth], intervalC <-chan time.Duration) <-chan struct{} {
+	doneC := make(chan struct{})
+
+	go func() {
+		defer close(doneC)
+
+		const consumer = prefix + ".health.prober"
+		statesC, err := statesTopic.Subscribe(ctx, intracom.SubscriberConfig[ServiceStates]{
+			ConsumerGroup: consumer,
+			BufferSize:    1,
+			BufferPolicy:  intracom.BufferPolicyDropOldest[ServiceStates]{},
+		})
+		if err != nil {
+			d.internalLogger.Log(log.LevelError, "error subscribing the health prober to states", log.Error("error", err), log.String("rxd", d.name))
+			return
+		}
+		defer statesTopic.Unsubscribe(consumer, statesC)
+
+		d.mu.RLock()
+		ticker := time.NewTicker(d.healthInterval)
+		d.mu.RUnlock()
+		defer ticker.Stop()
+
+		var states ServiceStates
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case latest, open := <-statesC:
+				if !open {
+					return
+				}
+				states = latest
+				continue
+			case interval := <-intervalC:
+				ticker.Reset(interval)
+				continue
+			case <-ticker.C:
+			}
+
+			health := d.checkHealth(ctx, states, d.health.get())
+			d.health.set(health)
+
+			if err := healthTopic.Publish(ctx, health.copy()); err != nil {
+				return
+			}
+		}
+	}()
+
+	return doneC
+}
+
+// checkHealth runs one round of health checks, previous carries the consecutive failures over.
+func (d *daemon) checkHealth(ctx context.Context, states ServiceStates, previous ServicesHealth) ServicesHealth {
+	checkers := make(map[string]HealthChecker)
+	d.mu.RLock()
+	for name, ds := range d.services {
+		if d.configs[name].isolated {
+			// the runner of an isolated service runs in a child process.
+			continue
+		}
+		if checker, ok := ds.Runner.(HealthChecker); ok {
+			checkers[name] = checker
+		}
+	}
+	d.mu.RUnlock()
+
+	health := make(ServicesHealth, len(checkers))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, checker := range checkers {
+		if states[name] != StateRun {
+			health[name] = ServiceHealth{Status: HealthUnknown}
+			continue
+		}
+
+		wg.Add(1)
+		go func(name string, checker HealthChecker) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, d.healthTimeout)
+			defer cancel()
+
+			result := ServiceHealth{Status: HealthHealthy}
+			if err := checker.HealthCheck(checkCtx); err != nil {
+				result = ServiceHealth{Status: HealthUnhealthy, Error: err.Error(), Failures: previous[name].Failures + 1}
+				d.internalLogger.Log(log.LevelWarning, "service health check failed", log.String("service_name", name), log.Error("error", err), log.Int("failures", result.Failures))
+			}
+			result.CheckedAt = time.Now()
+
+			mu.Lock()
+			health[name] = result
+			mu.Unlock()
+		}(name, checker)
+	}
+	wg.Wait()
+
+	return health
+}
+
+// WatchHealth returns a channel receiving the health of the services after every round of health checks,
+// only the latest round is kept if the receiver falls behind. The channel is closed once the context is done.
+func WatchHealth(sctx ServiceContext) (<-chan ServicesHealth, context.CancelFunc) {
+	ch := make(chan ServicesHealth, 1)
+	sc, ok := sctx.(*serviceContext)
+	if !ok {
+		close(ch)
+		return ch, func() {}
+	}
+
+	watchCtx, cancel := context.WithCancel(sc)
+	go func(ctx context.Context) {
+		defer close(ch)
+
+		consumer := intracom.ConsumerID(sc.ic, prefix+".health."+sc.fqcn)
+		sub, err := intracom.CreateSubscription[ServicesHealth](ctx, sc.ic, internalServiceHealth, -1, intracom.SubscriberConfig[ServicesHealth]{
+			ConsumerGroup: consumer,
+			ErrIfExists:   true,
+			BufferSize:    1,
+			BufferPolicy:  intracom.BufferPolicyDropOldest[ServicesHealth]{},
+		})
+		if err != nil {
+			sc.Log(log.LevelError, "failed to subscribe to services health: "+err.Error())
+			return
+		}
+		defer intracom.RemoveSubscription[ServicesHealth](sc.ic, internalServiceHealth, consumer, sub)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case health, open := <-sub:
+				if !open {
+					return
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case ch <- health:
+				}
+			}
+		}
+	}(watchCtx)
+
+	return ch, cancel
+}
@@ -0,0 +1,96 @@
+package rxd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// checkedService reports the given error from its health checks.
+type checkedService struct {
+	recordingService
+	err error
+}
+
+func (c *checkedService) HealthCheck(ctx context.Context) error {
+	return c.err
+}
+
+func TestDaemon_Health(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	journal := &recordingJournal{}
+	d := NewDaemon("test-daemon",
+		WithHealthChecks(20*time.Millisecond, 100*time.Millisecond),
+		WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+		WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+	)
+
+	healthC := make(chan ServicesHealth, 1)
+	watcher := &sleepyService{recordingService{name: "watcher", journal: journal}, time.Hour}
+	err := d.AddServices(
+		NewService("api", &checkedService{recordingService{name: "api", journal: journal}, nil}),
+		NewService("cache", &checkedService{recordingService{name: "cache", journal: journal}, errors.New("upstream unreachable")}),
+		NewService("watcher", healthWatcher{watcher, healthC}),
+	)
+	if err != nil {
+		t.Fatalf("error adding services: %s", err)
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	var health ServicesHealth
+	select {
+	case health = <-healthC:
+	case <-ctx.Done():
+		t.Fatalf("timed out waiting for the health of the services")
+	}
+
+	if health["api"].Status != HealthHealthy {
+		t.Errorf("expected api to be healthy, got %+v", health["api"])
+	}
+	if health["cache"].Status != HealthUnhealthy || health["cache"].Error != "upstream unreachable" || health["cache"].Failures < 1 {
+		t.Errorf("expected cache to be unhealthy, got %+v", health["cache"])
+	}
+	if _, ok := health["watcher"]; ok {
+		t.Errorf("expected only services implementing HealthChecker, got %v", health)
+	}
+	if health.Healthy() {
+		t.Errorf("expected the services not to be healthy")
+	}
+	if d.Health()["cache"].Status != HealthUnhealthy {
+		t.Errorf("expected the daemon to report cache as unhealthy, got %v", d.Health())
+	}
+
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+}
+
+// healthWatcher forwards the first round of health checks in which every checked service was probed.
+type healthWatcher struct {
+	*sleepyService
+	healthC chan<- ServicesHealth
+}
+
+func (w healthWatcher) Run(sctx ServiceContext) error {
+	ch, cancel := WatchHealth(sctx)
+	defer cancel()
+
+	for health := range ch {
+		if health["api"].Status != HealthUnknown && health["cache"].Status != HealthUnknown {
+			w.healthC <- health
+			break
+		}
+	}
+	<-sctx.Done()
+	return nil
+}
@@ -0,0 +1,37 @@
+package rxd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// Hook is run by the daemon outside of any service, see UsingPreStartHook and UsingPostStopHook.
+type Hook func(ctx context.Context) error
+
+// runPreStartHooks runs the pre-start hooks in order, stopping at the first error.
+func (d *daemon) runPreStartHooks(ctx context.Context) error {
+	for i, hook := range d.preStartHooks {
+		if err := hook(ctx); err != nil {
+			d.internalLogger.Log(log.LevelError, "pre-start hook failed, aborting startup", log.Int("hook", i), log.Error("error", err), log.String("rxd", d.name))
+			return err
+		}
+	}
+	return nil
+}
+
+// runPostStopHooks runs every post-stop hook in the reverse order they were added, joining their errors.
+// The hooks are given a context that is not cancelled along with the parent, since the daemon has already stopped.
+func (d *daemon) runPostStopHooks(parent context.Context) error {
+	ctx := context.WithoutCancel(parent)
+
+	var errs []error
+	for i := len(d.postStopHooks) - 1; i >= 0; i-- {
+		if err := d.postStopHooks[i](ctx); err != nil {
+			d.internalLogger.Log(log.LevelError, "post-stop hook failed", log.Int("hook", i), log.Error("error", err), log.String("rxd", d.name))
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
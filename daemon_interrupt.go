@@ -0,0 +1,137 @@
+package rxd
+
+import (
+	"sync"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// Interrupt is the reason the Run lifecycle of a service was interrupted by the daemon, see RunInterruptible.
+type Interrupt uint8
+
+const (
+	// InterruptNone is reported when Run returned on its own.
+	InterruptNone Interrupt = iota
+	// InterruptReload moves the service through Reload and back into Run.
+	InterruptReload
+	// InterruptIdle moves the service back into Idle, skipping Stop and Init, see WatchDependency.
+	InterruptIdle
+	// InterruptRecycle moves the service through Stop and Init on the same runner instance.
+	InterruptRecycle
+)
+
+// interrupter lets the daemon interrupt the Run lifecycle of a service so its manager
+// can reload or recycle it without tearing down the manager routine.
+type interrupter struct {
+	mu      sync.Mutex
+	cancel  func()    // cancels the current Run lifecycle, nil outside of Run.
+	pending Interrupt // the strongest interrupt requested and not yet honored.
+}
+
+// request interrupts the current Run lifecycle, or the next one if the service is not running yet.
+// A recycle takes precedence over a reload requested at the same time.
+func (i *interrupter) request(reason Interrupt) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.pending = max(i.pending, reason)
+	if i.cancel != nil {
+		i.cancel()
+	}
+}
+
+// requestRunning interrupts the current Run lifecycle, nothing is left pending when the service is not running.
+func (i *interrupter) requestRunning(reason Interrupt) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.cancel == nil {
+		return
+	}
+	i.pending = max(i.pending, reason)
+	i.cancel()
+}
+
+func (i *interrupter) arm(cancel func()) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.cancel = cancel
+	if i.pending != InterruptNone {
+		cancel()
+	}
+}
+
+func (i *interrupter) disarm() Interrupt {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	reason := i.pending
+	i.pending = InterruptNone
+	i.cancel = nil
+	return reason
+}
+
+// runScope returns the context a manager should hand to the Run lifecycle, which is cancelled
+// when the daemon interrupts the service. The returned done func must be called once Run returns
+// and reports why Run was interrupted, if it was.
+func runScope(sctx ServiceContext) (ServiceContext, func() Interrupt) {
+	sc, ok := sctx.(*serviceContext)
+	if !ok || sc.interrupter == nil {
+		return sctx, func() Interrupt { return InterruptNone }
+	}
+
+	runCtx, cancel := sc.WithParent(sc.Context)
+	sc.interrupter.arm(cancel)
+	return runCtx, func() Interrupt {
+		cancel()
+		return sc.interrupter.disarm()
+	}
+}
+
+// Recycle soft restarts a running service: its Run lifecycle is interrupted and the manager runs
+// Stop then Init, Idle and Run again on the same runner instance, keeping the manager routine alive.
+// Runners holding expensive caches can keep them across recycles, unlike a full restart.
+// Only the built-in managers honor recycles and isolated services cannot be recycled.
+func (d *daemon) Recycle(name string) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	run, ok := d.runs[name]
+	if !ok {
+		return ErrServiceNotFound
+	}
+
+	if d.configs[name].isolated {
+		return ErrRecycleIsolated
+	}
+
+	d.internalLogger.Log(log.LevelInfo, "recycling service", log.String("service_name", name), log.String("rxd", d.name))
+	run.interrupter.request(InterruptRecycle)
+	return nil
+}
+
+// Reload drives every running service implementing Reloader through StateReload, its Run lifecycle
+// is interrupted, Reload is called and the service goes back to Run without a full stop and start.
+// The daemon calls Reload when it receives SIGHUP. Only the built-in managers honor reloads.
+func (d *daemon) Reload() {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	for name, run := range d.runs {
+		if _, ok := d.services[name].Runner.(Reloader); !ok || d.configs[name].isolated {
+			continue
+		}
+
+		d.internalLogger.Log(log.LevelInfo, "reloading service", log.String("service_name", name), log.String("rxd", d.name))
+		run.interrupter.request(InterruptReload)
+	}
+}
+
+// reloadRunner calls Reload of the runner, runners that cannot reload are left as they are.
+func reloadRunner(sctx ServiceContext, runner ServiceRunner) error {
+	if r, ok := runner.(Reloader); ok {
+		return r.Reload(sctx)
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+//go:build unix && !rxdminimal
+
+package rxd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ambitiousfew/rxd/intracom"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// Isolated services are run by re-executing the daemon binary with the envIsolatedService
+// environment variable set to the name of the service. The child process runs only that service
+// and speaks a small line delimited json protocol back to the parent daemon:
+//
+//   - child -> parent over file descriptor 3: state updates and service logs.
+//   - parent -> child over stdin: "stop" to stop the service, closing stdin has the same effect.
+//
+// Limitations: the child only sees its own state when watching other services,
+// and the child must build the daemon with the same services as the parent for the service to be found.
+
+const (
+	isolationKindState = "state"
+	isolationKindLog   = "log"
+	isolationStop      = "stop"
+
+	isolationRestartDelay = 5 * time.Second
+	isolationStopTimeout  = 10 * time.Second
+)
+
+type isolationMessage struct {
+	Kind    string      `json:"kind"`
+	State   State       `json:"state,omitempty"`
+	Level   log.Level   `json:"level,omitempty"`
+	Message string      `json:"message,omitempty"`
+	Fields  []log.Field `json:"fields,omitempty"`
+}
+
+// superviseIsolated runs the service in a child process until the child exits cleanly or the context is
+// cancelled. A child that crashes is restarted as the restart policy of the service allows.
+func (d *daemon) superviseIsolated(sctx ServiceContext, ds DaemonService, stateC chan<- StateUpdate, logC chan<- DaemonLog) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-sctx.Done():
+			stateC <- StateUpdate{Name: ds.Name, State: StateExit}
+			return
+		case <-timer.C:
+		}
+
+		err := d.runIsolatedProcess(sctx, ds, stateC, logC)
+		// the child always ends in exit, whether it exited cleanly or crashed.
+		stateC <- StateUpdate{Name: ds.Name, State: StateExit}
+		if sctx.Err() != nil || err == nil {
+			// the manager of the child applied the policy of the service, it is done.
+			return
+		}
+
+		fields := []log.Field{log.String("service", ds.Name), log.Error("error", err)}
+		if !restartAfterRun(sctx, err) {
+			logC <- DaemonLog{Level: log.LevelError, Message: "isolated service process exited", Fields: fields}
+			return
+		}
+		logC <- DaemonLog{Level: log.LevelError, Message: "isolated service process exited, restarting", Fields: fields}
+		timer.Reset(isolationRestartDelay)
+	}
+}
+
+// runIsolatedProcess starts a single child process for the service and relays its messages until it exits.
+func (d *daemon) runIsolatedProcess(ctx context.Context, ds DaemonService, stateC chan<- StateUpdate, logC chan<- DaemonLog) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Env = append(os.Environ(), envIsolatedService+"="+ds.Name)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = []*os.File{w}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		w.Close()
+		return err
+	}
+
+	err = cmd.Start()
+	// the child holds its own copy of the write end, the parent no longer needs it.
+	w.Close()
+	if err != nil {
+		return err
+	}
+
+	d.internalLogger.Log(log.LevelInfo, "started isolated service process", log.String("service_name", ds.Name), log.Int("pid", cmd.Process.Pid))
+
+	relayDoneC := make(chan struct{})
+	go func() {
+		defer close(relayDoneC)
+		decoder := json.NewDecoder(r)
+		for {
+			var msg isolationMessage
+			if err := decoder.Decode(&msg); err != nil {
+				// EOF once the child has exited.
+				return
+			}
+
+			switch msg.Kind {
+			case isolationKindState:
+				stateC <- StateUpdate{Name: ds.Name, State: msg.State}
+			case isolationKindLog:
+				entry := DaemonLog{Level: msg.Level, Message: msg.Message, Fields: msg.Fields}
+				if entry.Level <= emergencyLevel {
+					d.logEmergency(ds.Name, entry)
+					continue
+				}
+				logC <- entry
+			}
+		}
+	}()
+
+	waitC := make(chan error, 1)
+	go func() {
+		waitC <- cmd.Wait()
+	}()
+
+	select {
+	case err = <-waitC:
+	case <-ctx.Done():
+		io.WriteString(stdin, isolationStop+"\n")
+		stdin.Close()
+
+		timeout := time.NewTimer(isolationStopTimeout)
+		select {
+		case err = <-waitC:
+		case <-timeout.C:
+			d.internalLogger.Log(log.LevelWarning, "isolated service did not stop in time, killing", log.String("service_name", ds.Name))
+			cmd.Process.Kill()
+			err = <-waitC
+		}
+		timeout.Stop()
+	}
+
+	<-relayDoneC
+	return err
+}
+
+// runIsolated is the entrypoint of a child process running a single isolated service.
+func (d *daemon) runIsolated(parent context.Context, name string) error {
+	ds, ok := d.services[name]
+	if !ok {
+		return ErrIsolatedServiceNotFound
+	}
+	manager := d.managers[name]
+
+	// a process started by other means has nothing or something else open as fd 3, it must not write to it.
+	var stat syscall.Stat_t
+	if err := syscall.Fstat(3, &stat); err != nil || stat.Mode&syscall.S_IFMT != syscall.S_IFIFO {
+		return ErrIsolationPipe
+	}
+	out := os.NewFile(3, "rxd-isolation")
+	defer out.Close()
+
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	ctx = context.WithValue(ctx, shutdownDeadlineKey{}, &shutdownClock{grace: d.shutdownGrace, doneC: ctx.Done()})
+
+	// the parent controls the lifetime of the child, signals sent to the process group are left to the parent.
+	signal.Ignore(syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if scanner.Text() == isolationStop {
+				break
+			}
+		}
+		// a stop request or a closed stdin (parent died) stops the service.
+		cancel()
+	}()
+
+	var encMu sync.Mutex
+	encoder := json.NewEncoder(out)
+	send := func(msg isolationMessage) {
+		encMu.Lock()
+		encoder.Encode(msg)
+		encMu.Unlock()
+	}
+
+	statesTopic, err := intracom.CreateTopic[ServiceStates](d.ic, intracom.TopicConfig{
+		Name:        internalServiceStates,
+		ErrIfExists: true,
+		ReplayLast:  true,
+	})
+	if err != nil {
+		return err
+	}
+	defer intracom.Close(d.ic)
+
+	logC := make(chan DaemonLog, 50)
+	stateC := make(chan StateUpdate, 4)
+
+	var relays sync.WaitGroup
+	relays.Add(2)
+	go func() {
+		defer relays.Done()
+		for entry := range logC {
+			send(isolationMessage{Kind: isolationKindLog, Level: entry.Level, Message: entry.Message, Fields: entry.Fields})
+		}
+	}()
+
+	go func() {
+		defer relays.Done()
+		for update := range stateC {
+			send(isolationMessage{Kind: isolationKindState, State: update.State})
+			// the states topic is closed with the intracom once the relays are done.
+			statesTopic.Publish(context.Background(), ServiceStates{update.Name: update.State})
+		}
+	}()
+
+	func() {
+		generation := &serviceGeneration{}
+		ds.Runner = generationRunner{runner: ds.Runner, generation: generation}
+		sctx, scancel := newServiceContextWithCancel(ctx, name, logC, d.ic, contextConfig{
+			throttle:      d.throttles[name],
+			pacer:         newPacer(d.pacing),
+			extractors:    d.extractors,
+			niceness:      d.niceness,
+			preconditions: d.configs[name].preconditions,
+			generation:    generation,
+			values:        d.values,
+			watchBuffer:   d.watchBuffer,
+			labels:        d.labels,
+			// emergency messages are relayed to the parent right away, it writes them synchronously.
+			emergency: func(service string, entry DaemonLog) {
+				send(isolationMessage{Kind: isolationKindLog, Level: entry.Level, Message: entry.Message, Fields: entry.Fields})
+			},
+		})
+		defer scancel()
+
+		defer func() {
+			if r := recover(); r != nil {
+				perr := newPanicError("service "+name, r)
+				logC <- DaemonLog{Level: log.LevelError, Message: "recovered from panic", Fields: append(perr.fields(), log.String("service", name))}
+				stateC <- StateUpdate{Name: name, State: StateExit}
+			}
+		}()
+
+		manager.Manage(sctx, ds, stateC)
+	}()
+
+	close(stateC)
+	close(logC)
+	relays.Wait()
+	return nil
+}
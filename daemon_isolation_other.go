@@ -0,0 +1,20 @@
+//go:build !unix || rxdminimal
+
+package rxd
+
+import (
+	"context"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// superviseIsolated cannot start child processes in minimal builds, nor on platforms where the child cannot
+// inherit the pipe to the daemon, the service exits right away.
+func (d *daemon) superviseIsolated(sctx ServiceContext, ds DaemonService, stateC chan<- StateUpdate, logC chan<- DaemonLog) {
+	d.internalLogger.Log(log.LevelError, "isolated service cannot run", log.String("service_name", ds.Name), log.Error("error", ErrIsolationUnsupported), log.String("rxd", d.name))
+	stateC <- StateUpdate{Name: ds.Name, State: StateExit}
+}
+
+func (d *daemon) runIsolated(parent context.Context, name string) error {
+	return ErrIsolationUnsupported
+}
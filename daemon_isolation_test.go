@@ -0,0 +1,160 @@
+//go:build !rxdminimal && unix
+
+package rxd
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// envHelperProcess is set by the isolation test, the daemon passes it on to the test binary it re-executes
+// to run the isolated service, which then runs the helper daemon instead of the tests.
+const envHelperProcess = "GO_WANT_HELPER_PROCESS"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(envHelperProcess) == "1" {
+		os.Exit(runIsolationHelper())
+	}
+	os.Exit(m.Run())
+}
+
+// runIsolationHelper runs the daemon of the isolation test as the child process of its isolated service.
+func runIsolationHelper() int {
+	d := newIsolationDaemon(os.Getenv(envIsolatedService), log.NewLogger(log.LevelDebug, newTestLogger()), nil)
+	if err := d.Start(context.Background()); err != nil {
+		return 1
+	}
+	return 0
+}
+
+// newIsolationDaemon builds the same daemon in the test and in the child process, so the child finds the service.
+func newIsolationDaemon(service string, logger log.Logger, hook TransitionFunc) Daemon {
+	options := []DaemonOption{WithServiceLogger(logger)}
+	if hook != nil {
+		options = append(options, UsingTransitionHook(hook))
+	}
+	d := NewDaemon("test-daemon", options...)
+	switch service {
+	case "isolated":
+		d.AddService(NewService(service, &isolatedService{}, WithIsolation(), WithManager(NewRunOnceManager(0))))
+	case "crashing":
+		d.AddService(NewService(service, &exitingService{}, WithIsolation(), WithRestartPolicy(RestartNever)))
+	}
+	return d
+}
+
+// isolatedService logs every lifecycle it goes through along with the process running it.
+type isolatedService struct{}
+
+func (s *isolatedService) Init(sctx ServiceContext) error {
+	sctx.Log(log.LevelInfo, "isolated init", log.Int("pid", os.Getpid()))
+	return nil
+}
+
+func (s *isolatedService) Idle(sctx ServiceContext) error { return nil }
+
+func (s *isolatedService) Run(sctx ServiceContext) error {
+	sctx.Log(log.LevelInfo, "isolated run", log.Int("pid", os.Getpid()))
+	return nil
+}
+
+func (s *isolatedService) Stop(sctx ServiceContext) error {
+	sctx.Log(log.LevelInfo, "isolated stop", log.Int("pid", os.Getpid()))
+	return nil
+}
+
+// exitingService takes down the process running it once it runs.
+type exitingService struct{}
+
+func (s *exitingService) Init(sctx ServiceContext) error { return nil }
+func (s *exitingService) Idle(sctx ServiceContext) error { return nil }
+func (s *exitingService) Run(sctx ServiceContext) error  { os.Exit(3); return nil }
+func (s *exitingService) Stop(sctx ServiceContext) error { return nil }
+
+// runIsolationTest runs the daemon with the isolated service until it stopped on its own,
+// it returns the states the service went through and what the daemon logged.
+func runIsolationTest(t *testing.T, service string) ([]State, string) {
+	t.Helper()
+	t.Setenv(envHelperProcess, "1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	var states []State
+	hook := func(name string, from, to State) {
+		mu.Lock()
+		defer mu.Unlock()
+		states = append(states, to)
+	}
+
+	logger := newTestLogger()
+	d := newIsolationDaemon(service, log.NewLogger(log.LevelDebug, logger), hook)
+	// the child is not restarted, so the daemon stops once its only service exited.
+	if err := d.Start(ctx); err != nil {
+		t.Fatalf("error running daemon: %s", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("expected the daemon to stop once the isolated service exited: %s", logger.Output())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return states, logger.Output()
+}
+
+func TestDaemon_Isolation(t *testing.T) {
+	states, output := runIsolationTest(t, "isolated")
+
+	// the states reported by the child went through Init, Run and Stop in order.
+	want := []State{StateInit, StateRun, StateStop, StateExit}
+	next := 0
+	for _, state := range states {
+		if next < len(want) && state == want[next] {
+			next++
+		}
+	}
+	if next != len(want) {
+		t.Errorf("expected the states %v in order, got %v", want, states)
+	}
+
+	// the logs of the child reached the service logger of the daemon, written from another process.
+	for _, message := range []string{"isolated init", "isolated run", "isolated stop"} {
+		line := ""
+		for _, l := range strings.Split(output, "\n") {
+			if strings.HasPrefix(l, message) {
+				line = l
+				break
+			}
+		}
+		if line == "" {
+			t.Errorf("expected %q to be logged by the child, got %q", message, output)
+			continue
+		}
+		if strings.Contains(line, "pid="+strconv.Itoa(os.Getpid())+" ") {
+			t.Errorf("expected %q to be logged by the child process, got %q", message, line)
+		}
+	}
+	if strings.Contains(output, "isolated service process exited") {
+		t.Errorf("expected a clean exit of the child not to be reported, got %q", output)
+	}
+}
+
+func TestDaemon_IsolationCrash(t *testing.T) {
+	_, output := runIsolationTest(t, "crashing")
+
+	// the restart policy never restarts the service, so the crashed child is reported but not restarted.
+	if !strings.Contains(output, "isolated service process exited") || !strings.Contains(output, "exit status 3") {
+		t.Errorf("expected the crash of the child to be reported, got %q", output)
+	}
+	if strings.Contains(output, "restarting") {
+		t.Errorf("expected the child not to be restarted, got %q", output)
+	}
+}
@@ -2,7 +2,10 @@ package rxd
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"os"
+	"reflect"
 	"sync"
 	"time"
 
@@ -91,3 +94,84 @@ func (h *daemonLogHandler) Close() error {
 	}
 	return nil
 }
+
+// Flush syncs the log file to disk.
+func (h *daemonLogHandler) Flush() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file != nil {
+		return h.file.Sync()
+	}
+	return nil
+}
+
+// Reopen closes the log file, the next message opens the file at the same path again.
+func (h *daemonLogHandler) Reopen() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file == nil {
+		return nil
+	}
+	err := h.file.Close()
+	h.file, h.total = nil, 0
+	return err
+}
+
+// loggers returns the service logger and the internal logger, once when both are the same logger.
+func (d *daemon) loggers() []log.Logger {
+	service, internal := unwrapLogger(d.serviceLogger), unwrapLogger(d.internalLogger)
+	// comparing loggers of a type that is not comparable would panic.
+	if t := reflect.TypeOf(service); t != nil && t.Comparable() && service == internal {
+		return []log.Logger{d.serviceLogger}
+	}
+	return []log.Logger{d.serviceLogger, d.internalLogger}
+}
+
+// reopenLoggers reopens the loggers implementing log.Reopener, such as those writing to a log.FileWriter.
+func (d *daemon) reopenLoggers(nameField log.Field) {
+	for _, logger := range d.loggers() {
+		if err := log.Reopen(logger); err != nil {
+			d.internalLogger.Log(log.LevelError, "error reopening logger", log.Error("error", err), nameField)
+		}
+	}
+}
+
+// closeLoggers flushes then closes the loggers once the daemon stopped, so buffered messages are not lost on exit.
+// Errors are written to stderr, the loggers cannot report them anymore.
+func (d *daemon) closeLoggers() {
+	for _, logger := range d.loggers() {
+		if err := errors.Join(log.Flush(logger), log.Close(logger)); err != nil {
+			fmt.Fprintf(os.Stderr, "rxd: error closing logger of %s: %s\n", d.name, err)
+		}
+	}
+}
+
+// redactingLogger redacts the logs written to the service and internal loggers, see UsingRedaction.
+type redactingLogger struct {
+	log.Logger
+	redactor *log.Redactor
+}
+
+func (l redactingLogger) Log(level log.Level, message string, fields ...log.Field) {
+	l.Logger.Log(level, l.redactor.RedactMessage(message), l.redactor.RedactFields(fields)...)
+}
+
+func (l redactingLogger) Flush() error {
+	return log.Flush(l.Logger)
+}
+
+func (l redactingLogger) Close() error {
+	return log.Close(l.Logger)
+}
+
+func (l redactingLogger) Reopen() error {
+	return log.Reopen(l.Logger)
+}
+
+// unwrapLogger returns the logger wrapped for redaction, the logger itself otherwise.
+func unwrapLogger(logger log.Logger) log.Logger {
+	if l, ok := logger.(redactingLogger); ok {
+		return l.Logger
+	}
+	return logger
+}
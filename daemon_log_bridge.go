@@ -0,0 +1,168 @@
+package rxd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	stdlog "log"
+	"log/slog"
+	"runtime/pprof"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// labelService is the goroutine (pprof) label the daemon sets on every service routine.
+// It is used by the log bridge to tag slog records with the service they originate from.
+const labelService = "rxd_service"
+
+// logBridge captures the output of the standard library log package and the slog default logger
+// and routes it into the daemon service logger, so third-party library logs do not bypass the daemon handlers.
+type logBridge struct {
+	logger log.Logger
+
+	// previous state of the global loggers, restored once the daemon stops.
+	prevSlog   *slog.Logger
+	prevWriter io.Writer
+	prevFlags  int
+	prevPrefix string
+}
+
+func newLogBridge(logger log.Logger) *logBridge {
+	return &logBridge{logger: logger}
+}
+
+// install redirects the global log and slog output to the bridge.
+func (b *logBridge) install() {
+	b.prevSlog = slog.Default()
+	b.prevWriter = stdlog.Writer()
+	b.prevFlags = stdlog.Flags()
+	b.prevPrefix = stdlog.Prefix()
+
+	// NOTE: slog.SetDefault also redirects the log package, so log output must be set afterwards.
+	slog.SetDefault(slog.New(&slogBridgeHandler{logger: b.logger}))
+	stdlog.SetOutput(&stdlogBridgeWriter{logger: b.logger})
+	// the daemon handlers already add a timestamp.
+	stdlog.SetFlags(0)
+}
+
+// uninstall restores the global log and slog output to what it was before install.
+func (b *logBridge) uninstall() {
+	slog.SetDefault(b.prevSlog)
+	stdlog.SetOutput(b.prevWriter)
+	stdlog.SetFlags(b.prevFlags)
+	stdlog.SetPrefix(b.prevPrefix)
+}
+
+// stdlogBridgeWriter receives one write per entry from the log package.
+// The log package carries no context, so entries cannot be tagged with the originating service.
+type stdlogBridgeWriter struct {
+	logger log.Logger
+}
+
+func (w *stdlogBridgeWriter) Write(p []byte) (int, error) {
+	msg := string(bytes.TrimRight(p, "\n"))
+	w.logger.Log(log.LevelInfo, msg, log.String("source", "log"))
+	return len(p), nil
+}
+
+type slogBridgeHandler struct {
+	logger log.Logger
+	attrs  []log.Field
+	group  string
+}
+
+func (h *slogBridgeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	// the daemon logger decides what is filtered by level.
+	return true
+}
+
+func (h *slogBridgeHandler) Handle(ctx context.Context, record slog.Record) error {
+	fields := make([]log.Field, 0, len(h.attrs)+record.NumAttrs()+2)
+	fields = append(fields, h.attrs...)
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = appendSlogAttr(fields, h.group, attr)
+		return true
+	})
+
+	fields = append(fields, log.String("source", "slog"))
+	if ctx != nil {
+		if name := serviceNameFromContext(ctx); name != "" {
+			fields = append(fields, log.String("service", name))
+		}
+	}
+
+	h.logger.Log(levelFromSlog(record.Level), record.Message, fields...)
+	return nil
+}
+
+func (h *slogBridgeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]log.Field, len(h.attrs), len(h.attrs)+len(attrs))
+	copy(fields, h.attrs)
+	for _, attr := range attrs {
+		fields = appendSlogAttr(fields, h.group, attr)
+	}
+	return &slogBridgeHandler{logger: h.logger, attrs: fields, group: h.group}
+}
+
+func (h *slogBridgeHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	group := name
+	if h.group != "" {
+		group = h.group + "." + name
+	}
+	return &slogBridgeHandler{logger: h.logger, attrs: h.attrs, group: group}
+}
+
+func appendSlogAttr(fields []log.Field, group string, attr slog.Attr) []log.Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	key := attr.Key
+	if group != "" && key != "" {
+		key = group + "." + key
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key == "" {
+			// inline groups are flattened into the current group.
+			key = group
+		}
+		for _, nested := range attr.Value.Group() {
+			fields = appendSlogAttr(fields, key, nested)
+		}
+		return fields
+	}
+
+	return append(fields, log.String(key, attr.Value.String()))
+}
+
+func levelFromSlog(level slog.Level) log.Level {
+	switch {
+	case level < slog.LevelInfo:
+		return log.LevelDebug
+	case level < slog.LevelWarn:
+		return log.LevelInfo
+	case level < slog.LevelError:
+		return log.LevelWarning
+	case level == slog.LevelError:
+		return log.LevelError
+	default:
+		return log.LevelCritical
+	}
+}
+
+// serviceNameFromContext identifies the service a context belongs to, either because the context
+// is a ServiceContext or because it derives from a service routine context carrying the service label.
+func serviceNameFromContext(ctx context.Context) string {
+	if sctx, ok := ctx.(ServiceContext); ok {
+		return sctx.Name()
+	}
+
+	name, _ := pprof.Label(ctx, labelService)
+	return name
+}
@@ -0,0 +1,40 @@
+package rxd
+
+import (
+	"context"
+	stdlog "log"
+	"log/slog"
+	"runtime/pprof"
+	"strings"
+	"testing"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+func TestLogBridge_CapturesGlobalLoggers(t *testing.T) {
+	testLogger := newTestLogger()
+	bridge := newLogBridge(log.NewLogger(log.LevelDebug, testLogger))
+	bridge.install()
+
+	ctx := pprof.WithLabels(context.Background(), pprof.Labels(labelService, "test-service"))
+
+	stdlog.Println("from the log package")
+	slog.InfoContext(ctx, "from slog", slog.String("tenant", "acme"))
+	bridge.uninstall()
+
+	// once uninstalled, nothing else should reach the daemon logger.
+	slog.Info("after uninstall")
+
+	output := testLogger.Output()
+	if !strings.Contains(output, "from the log package source=log") {
+		t.Fatalf("expected log package output to be captured, got %q", output)
+	}
+
+	if !strings.Contains(output, "from slog tenant=acme source=slog service=test-service") {
+		t.Fatalf("expected slog output tagged with the service, got %q", output)
+	}
+
+	if strings.Contains(output, "after uninstall") {
+		t.Fatalf("expected no output after uninstall, got %q", output)
+	}
+}
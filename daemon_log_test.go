@@ -0,0 +1,185 @@
+package rxd
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// bufferedLogService logs a message once it runs.
+type bufferedLogService struct {
+	recordingService
+}
+
+func (c *bufferedLogService) Run(sctx ServiceContext) error {
+	sctx.Log(log.LevelInfo, "buffered message")
+	return c.recordingService.Run(sctx)
+}
+
+func TestDaemon_CloseLoggers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "services.log")
+	file, err := log.OpenFile(path)
+	if err != nil {
+		t.Fatalf("error opening log file: %s", err)
+	}
+
+	journal := &recordingJournal{}
+	d := NewDaemon("test-daemon", WithServiceLogger(log.NewLogger(log.LevelInfo, log.NewHandler(log.WithWriter(file)))))
+	if err := d.AddService(NewService("chatty", &bufferedLogService{recordingService{name: "chatty", journal: journal}})); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	for journal.index("chatty:run") < 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading log file: %s", err)
+	}
+	if !strings.Contains(string(contents), "buffered message") {
+		t.Errorf("expected the buffered messages to be written out once the daemon stopped, got %q", contents)
+	}
+	if _, err := file.Write([]byte("late\n")); err != os.ErrClosed {
+		t.Errorf("expected the log file to be closed, got %v", err)
+	}
+}
+
+func TestDaemon_ReopenLoggers(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "services.log")
+	file, err := log.OpenFile(path)
+	if err != nil {
+		t.Fatalf("error opening log file: %s", err)
+	}
+
+	logger := log.NewLogger(log.LevelInfo, log.NewHandler(log.WithWriter(file)))
+	d := NewDaemon("test-daemon", WithServiceLogger(logger)).(*daemon)
+	logger.Log(log.LevelInfo, "before rotation")
+
+	// logrotate moves the file away, the daemon is then sent SIGHUP.
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatalf("error rotating log file: %s", err)
+	}
+	d.reopenLoggers(log.String("rxd", d.name))
+	logger.Log(log.LevelInfo, "after rotation")
+	d.closeLoggers()
+
+	rotated, _ := os.ReadFile(path + ".1")
+	current, _ := os.ReadFile(path)
+	if !strings.Contains(string(rotated), "before rotation") || strings.Contains(string(rotated), "after rotation") {
+		t.Errorf("expected the rotated file to end at the rotation, got %q", rotated)
+	}
+	if !strings.Contains(string(current), "after rotation") {
+		t.Errorf("expected the reopened file to get the messages after the rotation, got %q", current)
+	}
+}
+
+// leakyLogService logs secrets once it runs.
+type leakyLogService struct {
+	recordingService
+}
+
+func (l *leakyLogService) Run(sctx ServiceContext) error {
+	sctx.Log(log.LevelInfo, "connecting with Authorization: Bearer abc.def.ghi",
+		log.String("db_password", "hunter2"),
+		log.String("upstream_secret", "s3cr3t"),
+		log.Secret("session", "deadbeef"),
+		log.String("user", "alice"),
+	)
+	return l.recordingService.Run(sctx)
+}
+
+func TestDaemon_Redaction(t *testing.T) {
+	var out strings.Builder
+	journal := &recordingJournal{}
+	redactor := log.NewRedactor([]string{"*password", "*_secret"}, regexp.MustCompile(`Bearer [\w.]+`))
+	d := NewDaemon("test-daemon", UsingRedaction(redactor), WithServiceLogger(log.NewLogger(log.LevelInfo, log.NewHandler(log.WithWriter(&out)))))
+	if err := d.AddService(NewService("leaky", &leakyLogService{recordingService{name: "leaky", journal: journal}})); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+	for journal.index("leaky:run") < 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+
+	logged := out.String()
+	for _, secret := range []string{"hunter2", "s3cr3t", "deadbeef", "abc.def.ghi"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("expected %q to be redacted, got %q", secret, logged)
+		}
+	}
+	if !strings.Contains(logged, "db_password="+log.Redacted) || !strings.Contains(logged, "user=alice") {
+		t.Errorf("expected only the secrets to be redacted, got %q", logged)
+	}
+}
+
+// bridgedLogService logs a secret through the slog default logger, routed to the service logger by the log bridge.
+type bridgedLogService struct {
+	recordingService
+}
+
+func (l *bridgedLogService) Run(sctx ServiceContext) error {
+	slog.InfoContext(sctx, "connecting to the database", slog.String("password", "hunter2"), slog.String("user", "alice"))
+	return l.recordingService.Run(sctx)
+}
+
+func TestDaemon_RedactionOfBridgedLogs(t *testing.T) {
+	var out strings.Builder
+	journal := &recordingJournal{}
+	redactor := log.NewRedactor([]string{"password"})
+	d := NewDaemon("test-daemon", UsingRedaction(redactor), WithLogBridge(), WithServiceLogger(log.NewLogger(log.LevelInfo, log.NewHandler(log.WithWriter(&out)))))
+	if err := d.AddService(NewService("bridged", &bridgedLogService{recordingService{name: "bridged", journal: journal}})); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+	for journal.index("bridged:run") < 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+
+	logged := out.String()
+	if strings.Contains(logged, "hunter2") {
+		t.Errorf("expected the password of the bridged record to be redacted, got %q", logged)
+	}
+	if !strings.Contains(logged, "password="+log.Redacted) || !strings.Contains(logged, "user=alice") {
+		t.Errorf("expected only the password to be redacted, got %q", logged)
+	}
+}
@@ -0,0 +1,105 @@
+package rxd
+
+import (
+	"context"
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// memoryTuning holds the go runtime memory settings applied while the daemon runs.
+type memoryTuning struct {
+	gcPercent      int
+	setGCPercent   bool
+	memoryLimit    int64
+	setMemoryLimit bool
+	statsInterval  time.Duration // how often gc stats are logged, 0 disables it.
+}
+
+// applyMemoryTuning applies the memory settings of the daemon, the returned func restores the previous settings.
+func (d *daemon) applyMemoryTuning() func() {
+	prevGCPercent, prevMemoryLimit := -1, int64(-1)
+	if d.memory.setGCPercent {
+		prevGCPercent = debug.SetGCPercent(d.memory.gcPercent)
+	}
+	if d.memory.setMemoryLimit {
+		prevMemoryLimit = debug.SetMemoryLimit(d.memory.memoryLimit)
+	}
+
+	return func() {
+		if d.memory.setGCPercent {
+			debug.SetGCPercent(prevGCPercent)
+		}
+		if d.memory.setMemoryLimit {
+			debug.SetMemoryLimit(prevMemoryLimit)
+		}
+	}
+}
+
+// runtimeFields describes the go runtime the daemon runs with, as recorded in the startup banner.
+func runtimeFields() []log.Field {
+	samples := []metrics.Sample{
+		{Name: "/gc/gogc:percent"},
+		{Name: "/gc/gomemlimit:bytes"},
+	}
+	metrics.Read(samples)
+
+	fields := []log.Field{
+		log.String("go_version", runtime.Version()),
+		log.Int("gomaxprocs", runtime.GOMAXPROCS(0)),
+	}
+	if samples[0].Value.Kind() == metrics.KindUint64 {
+		fields = append(fields, log.Int("gc_percent", samples[0].Value.Uint64()))
+	}
+	if samples[1].Value.Kind() == metrics.KindUint64 {
+		fields = append(fields, log.Int("memory_limit", samples[1].Value.Uint64()))
+	}
+	return fields
+}
+
+// logGCStats logs the gc stats every stats interval until ctx is done.
+func (d *daemon) logGCStats(ctx context.Context) {
+	if d.memory.statsInterval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(d.memory.statsInterval)
+		defer ticker.Stop()
+
+		samples := []metrics.Sample{
+			{Name: "/memory/classes/heap/objects:bytes"},
+			{Name: "/gc/heap/goal:bytes"},
+		}
+		var stats debug.GCStats
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			debug.ReadGCStats(&stats)
+			metrics.Read(samples)
+
+			fields := []log.Field{
+				log.Int("num_gc", stats.NumGC),
+				log.String("pause_total", stats.PauseTotal.String()),
+				log.String("rxd", d.name),
+			}
+			if len(stats.Pause) > 0 {
+				fields = append(fields, log.String("last_pause", stats.Pause[0].String()))
+			}
+			if samples[0].Value.Kind() == metrics.KindUint64 {
+				fields = append(fields, log.Int("heap_bytes", samples[0].Value.Uint64()))
+			}
+			if samples[1].Value.Kind() == metrics.KindUint64 {
+				fields = append(fields, log.Int("heap_goal", samples[1].Value.Uint64()))
+			}
+			d.internalLogger.Log(log.LevelInfo, "gc stats", fields...)
+		}
+	}()
+}
@@ -0,0 +1,60 @@
+package rxd
+
+import (
+	"context"
+	"runtime/debug"
+	"runtime/metrics"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// gcSettingsService records the gc settings of the runtime while it runs.
+type gcSettingsService struct {
+	recordingService
+	gcPercent   uint64
+	memoryLimit uint64
+}
+
+func (s *gcSettingsService) Run(sctx ServiceContext) error {
+	samples := []metrics.Sample{{Name: "/gc/gogc:percent"}, {Name: "/gc/gomemlimit:bytes"}}
+	metrics.Read(samples)
+	s.gcPercent, s.memoryLimit = samples[0].Value.Uint64(), samples[1].Value.Uint64()
+	return nil
+}
+
+func TestDaemon_MemoryTuning(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	prevGCPercent := debug.SetGCPercent(100)
+	defer debug.SetGCPercent(prevGCPercent)
+
+	internal := newTestLogger()
+	service := &gcSettingsService{recordingService: recordingService{name: "gc", journal: &recordingJournal{}}}
+	d := NewDaemon("test-daemon",
+		UsingGCPercent(50),
+		UsingMemoryLimit(1<<30),
+		WithInternalLogger(log.NewLogger(log.LevelDebug, internal)),
+		WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+	)
+	if err := d.AddService(NewService("gc", service, WithManager(NewRunOnceManager(0)))); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	if err := d.Start(ctx); err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+
+	if service.gcPercent != 50 || service.memoryLimit != 1<<30 {
+		t.Errorf("expected gc percent 50 and memory limit %d while running, got %d and %d", 1<<30, service.gcPercent, service.memoryLimit)
+	}
+	if got := debug.SetGCPercent(100); got != 100 {
+		t.Errorf("expected gc percent 100 to be restored, got %d", got)
+	}
+	if !strings.Contains(internal.Output(), "gc_percent=50") {
+		t.Errorf("expected the startup banner to record the gc percent, got %s", internal.Output())
+	}
+}
@@ -0,0 +1,22 @@
+package rxd
+
+import "runtime"
+
+// Niceness holds cooperative scheduling hints for daemons running hundreds of services,
+// where the manager routines and the states watcher compete for the same processors.
+type Niceness struct {
+	// YieldOnTransition inserts a runtime.Gosched between lifecycle transitions of the built-in managers
+	// and after every states publish, so a busy service cannot starve the others between transitions.
+	YieldOnTransition bool
+	// StateBatchSize bounds how many pending state updates the states watcher folds into a single publish.
+	// Batching reduces the fan-out work to watchers, but watchers may miss states that were only
+	// held briefly within a batch. Values below 2 publish every update (default).
+	StateBatchSize int
+}
+
+// yieldTransition is called by the built-in managers between lifecycle transitions.
+func yieldTransition(sctx ServiceContext) {
+	if sc, ok := sctx.(*serviceContext); ok && sc.niceness.YieldOnTransition {
+		runtime.Gosched()
+	}
+}
@@ -3,6 +3,7 @@ package rxd
 import (
 	"os"
 	"sync"
+	"time"
 
 	"github.com/ambitiousfew/rxd/log"
 )
@@ -35,6 +36,8 @@ func WithServiceLogger(logger log.Logger) DaemonOption {
 
 // WithReportAlive sets the interval in seconds for when the daemon should report that it is still alive
 // to the service manager. If the value is set to 0, the daemon will not interact with the service manager.
+// Once the daemon is ready, reports are withheld while a critical service is wedged or unhealthy, see WithCritical,
+// the interval should be at most half of the watchdog timeout so a single withheld report is tolerated.
 func WithReportAlive(timeoutSecs uint64) DaemonOption {
 	return func(d *daemon) {
 		d.reportAliveSecs = timeoutSecs
@@ -43,12 +46,20 @@ func WithReportAlive(timeoutSecs uint64) DaemonOption {
 
 // WithSignals sets the OS signals that the daemon should listen for. If no signals are provided, the daemon
 // will listen for SIGINT and SIGTERM by default.
+// SIGHUP always reloads the services instead.
 func WithSignals(signals ...os.Signal) DaemonOption {
 	return func(d *daemon) {
 		d.signals = signals
 	}
 }
 
+// WithServiceLogLevel sets the level of the service logger, given after WithServiceLogger.
+func WithServiceLogLevel(level log.Level) DaemonOption {
+	return func(d *daemon) {
+		d.serviceLogger.SetLevel(level)
+	}
+}
+
 // WithInternalLogger sets a custom logger for the daemon to use for internal logging.
 // by default, the daemon will use a noop logger since this logger is used for rxd internals.
 func WithInternalLogger(logger log.Logger) DaemonOption {
@@ -96,3 +107,425 @@ func WithRPC(cfg RPCConfig) DaemonOption {
 		}
 	}
 }
+
+// WithPacing sets the initial pacing policy for services that cooperate by calling
+// sctx.Yield() or sctx.Throttle(rate). The policy can be changed at runtime via SetPacing.
+func WithPacing(pacing Pacing) DaemonOption {
+	return func(d *daemon) {
+		d.pacing.Store(&pacing)
+	}
+}
+
+// WithFieldExtractors sets extractors that are applied to every service context Log call.
+// Each extractor is given the context chain of the service so values such as
+// request IDs or tenants are automatically appended as log fields.
+func WithFieldExtractors(extractors ...FieldExtractor) DaemonOption {
+	return func(d *daemon) {
+		d.extractors = append(d.extractors, extractors...)
+	}
+}
+
+// WithLogBridge captures the output of the standard library log package and the slog default logger
+// while the daemon is running and routes it into the service logger.
+// slog records logged with a ServiceContext (or a context derived from one) are tagged with the service name,
+// log package entries carry no context and are only tagged with their source.
+// The previous global loggers are restored once the daemon stops.
+func WithLogBridge() DaemonOption {
+	return func(d *daemon) {
+		d.bridgeLogs = true
+	}
+}
+
+// WithDeterministicScheduler runs the lifecycles of the services one at a time in a reproducible order,
+// to reproduce and step through races between services without changing their code.
+// It is a debugging aid, see DeterministicScheduler for how the order is decided and its limits.
+func WithDeterministicScheduler(conf DeterministicScheduler) DaemonOption {
+	return func(d *daemon) {
+		d.debugScheduler = &conf
+	}
+}
+
+// WithNiceness sets cooperative scheduling hints for the manager routines and the states watcher.
+// This is mostly useful for daemons running hundreds of services, see Niceness for the tradeoffs.
+func WithNiceness(niceness Niceness) DaemonOption {
+	return func(d *daemon) {
+		d.niceness = niceness
+	}
+}
+
+// WithReadinessPolicy sets how the daemon decides it is ready from the states of its services.
+// The policy drives the systemd READY notification, the /readyz endpoint of the rpc server and the status file.
+// By default the daemon is ready once all of its services are running, see AllOf.
+func WithReadinessPolicy(policy ReadinessPolicy) DaemonOption {
+	return func(d *daemon) {
+		d.readinessPolicy = policy
+	}
+}
+
+// WithReadinessTimeout stops the daemon when the readiness policy is not satisfied within timeout of starting,
+// Start then returns ErrReadinessTimeout. systemd is only notified READY once the policy is satisfied,
+// the timeout keeps a daemon whose services never come up from running unready. 0 waits forever, the default.
+func WithReadinessTimeout(timeout time.Duration) DaemonOption {
+	return func(d *daemon) {
+		d.readinessTimeout = timeout
+	}
+}
+
+// UsingStartupTimeout logs the services that have not made it through their init state within timeout of the daemon starting,
+// e.g. blocked on a dependency that never comes up. With abort the daemon is stopped as well and Start returns an ErrStartupTimeout
+// listing those services, so orchestrators see a hung startup fail. Paused services are not waited for.
+func UsingStartupTimeout(timeout time.Duration, abort bool) DaemonOption {
+	return func(d *daemon) {
+		d.startupTimeout = timeout
+		d.startupAbort = abort
+	}
+}
+
+// UsingPID1Mode makes the daemon act as the init process of a container when it runs as pid 1, in place of tini
+// or dumb-init: orphaned processes are reaped once they have not been waited for within a second, and SIGTERM,
+// SIGINT and SIGQUIT are forwarded to every other process, such as the child processes started by services.
+// It is only supported on linux, and does nothing when the daemon is not pid 1.
+func UsingPID1Mode() DaemonOption {
+	return func(d *daemon) {
+		d.pid1 = true
+	}
+}
+
+// UsingSocketActivation hands the sockets passed by systemd socket activation to the services, which get a
+// listener on them with sctx.Listener, named after the FileDescriptorName of the socket ("unknown" without).
+func UsingSocketActivation() DaemonOption {
+	return func(d *daemon) {
+		d.socketActivation = true
+	}
+}
+
+// WithRolloutTimeout sets the time every batch of a RollingRestart has to be running and healthy again
+// before the restart is aborted (default: 1m).
+func WithRolloutTimeout(timeout time.Duration) DaemonOption {
+	return func(d *daemon) {
+		if timeout > 0 {
+			d.rolloutTimeout = timeout
+		}
+	}
+}
+
+// WithBarrier adds a barrier the participants, named after their services, wait at with sctx.WaitBarrier(name).
+// They are all released once every one of them is waiting, e.g. to cut over from an old to a new backend at once.
+// With a timeout, the participants waiting are released with an ErrBarrierTimeout once it elapsed since the first
+// of them arrived, 0 waits for all of them forever. The barrier is reused once released.
+func WithBarrier(name string, timeout time.Duration, participants ...string) DaemonOption {
+	return func(d *daemon) {
+		if d.barriers == nil {
+			d.barriers = make(map[string]*barrier)
+		}
+		d.barriers[name] = newBarrier(name, timeout, participants...)
+	}
+}
+
+// UsingQuarantine quarantines every service without a policy of its own once it cycled from Init to Stop more than
+// cycles times within window, holding it in StateQuarantined for the cooldown, see WithQuarantine.
+func UsingQuarantine(cycles int, window, cooldown time.Duration) DaemonOption {
+	return func(d *daemon) {
+		d.quarantine = quarantinePolicy{cycles: cycles, window: window, cooldown: cooldown}
+	}
+}
+
+// WithStateHistory keeps the last size state changes of every service, see Daemon.History (default: 32).
+// A size of 0 or less disables the history.
+func WithStateHistory(size int) DaemonOption {
+	return func(d *daemon) {
+		d.history = newStateHistory(size)
+	}
+}
+
+// WithWatchBuffer keeps up to size state changes for each receiver of WatchAllStates that falls behind,
+// past it the oldest are dropped (default: 1, only the latest states).
+func WithWatchBuffer(size int) DaemonOption {
+	return func(d *daemon) {
+		d.watchBuffer = size
+	}
+}
+
+// UsingRedaction redacts the messages and fields of every log with redactor before the loggers and the log tail
+// receive them, whichever service logged them, e.g. log.NewRedactor(nil) redacts passwords, tokens and secrets.
+// Fields created with log.Secret are always redacted.
+func UsingRedaction(redactor *log.Redactor) DaemonOption {
+	return func(d *daemon) {
+		d.redactor = redactor
+	}
+}
+
+// UsingCodec encodes the status file and the responses of the admin api with codec instead of json, e.g. GobCodec
+// or a compact binary encoding plugged in with NewCodec for high-frequency state export.
+func UsingCodec(codec Codec) DaemonOption {
+	return func(d *daemon) {
+		if codec != nil {
+			d.codec = codec
+		}
+	}
+}
+
+// WithStatusFile writes a StatusReport of the readiness and the states of every service to path each time
+// the states change, for tooling that cannot reach the daemon otherwise. It is json unless UsingCodec is given.
+func WithStatusFile(path string) DaemonOption {
+	return func(d *daemon) {
+		d.statusFile = path
+	}
+}
+
+// WithTracer records spans of the lifecycles of every service and of the work units started with StartSpan,
+// sampled per service with WithTraceSampling. Tracing is disabled without a tracer.
+func WithTracer(tracer Tracer) DaemonOption {
+	return func(d *daemon) {
+		d.tracer = tracer
+	}
+}
+
+// WithHealthChecks sets how often the services implementing HealthChecker are probed while they run
+// and how long a single check may take before it counts as failed (default: every 10s with a 5s timeout).
+func WithHealthChecks(interval, timeout time.Duration) DaemonOption {
+	return func(d *daemon) {
+		if interval > 0 {
+			d.healthInterval = interval
+		}
+		if timeout > 0 {
+			d.healthTimeout = timeout
+		}
+	}
+}
+
+// UsingHTTPAdmin serves an http admin endpoint at addr e.g. "127.0.0.1:8081" while the daemon runs with:
+//   - /healthz: 200 unless a service is unhealthy, with the health of every service as json.
+//   - /readyz: 200 once the readiness policy is satisfied.
+//   - /services: the current state of every service as json.
+//   - /loglevel: the current level on GET, PUT or POST with ?level=debug sets the level of the service and internal loggers.
+//   - /pacing: the pacing policy on GET, PUT or POST with ?yield_delay=5ms&rate_factor=0.5 changes it, see SetPacing.
+//
+// Responses are json unless UsingCodec is given.
+func UsingHTTPAdmin(addr string) DaemonOption {
+	return func(d *daemon) {
+		d.adminAddr = addr
+	}
+}
+
+// UsingControlPlane serves the control plane of the daemon at addr e.g. "127.0.0.1:50051" while the daemon runs,
+// for remote tooling to list, start, stop and restart services, change the log level and stream state changes.
+// factory creates the server, typically a *grpc.Server with the service of proto/control.proto registered
+// with an implementation backed by the given ControlPlane.
+func UsingControlPlane(addr string, factory func(plane *ControlPlane) ControlServer) DaemonOption {
+	return func(d *daemon) {
+		d.controlAddr = addr
+		d.controlFactory = factory
+	}
+}
+
+// UsingControlSocket serves the control socket of the daemon at path e.g. "/run/myd/rxd.sock" while the daemon runs,
+// for the rxdctl command to query the states of the services, tail their logs and restart them, see the pkg/ctl package.
+func UsingControlSocket(path string) DaemonOption {
+	return func(d *daemon) {
+		d.ctlSocket = path
+	}
+}
+
+// UsingAdminProxy makes the control socket of the daemon a single control point for the host: requests naming
+// another daemon are forwarded to its socket in dir, where daemons place their sockets named after themselves
+// e.g. UsingControlSocket(ctl.SocketPath(ctl.DefaultSocketDir, "myd")). Requires UsingControlSocket.
+func UsingAdminProxy(dir string) DaemonOption {
+	return func(d *daemon) {
+		d.ctlProxyDir = dir
+	}
+}
+
+// UsingExpectation monitors that the services are in the expected state, e.g. running during business hours,
+// and notifies the alerters of deviations lasting longer than the grace period. Alerters are shared by
+// every expectation, without any a warning is logged with the service logger.
+func UsingExpectation(expectation Expectation, alerters ...Alerter) DaemonOption {
+	return func(d *daemon) {
+		d.expectations = append(d.expectations, expectation)
+		d.alerters = append(d.alerters, alerters...)
+	}
+}
+
+// UsingGCPercent sets the garbage collection target percentage while the daemon runs, see debug.SetGCPercent.
+// A negative percentage disables the garbage collector unless a memory limit is set.
+func UsingGCPercent(percent int) DaemonOption {
+	return func(d *daemon) {
+		d.memory.gcPercent = percent
+		d.memory.setGCPercent = true
+	}
+}
+
+// UsingMemoryLimit sets the soft memory limit of the go runtime in bytes while the daemon runs, see debug.SetMemoryLimit.
+func UsingMemoryLimit(bytes int64) DaemonOption {
+	return func(d *daemon) {
+		d.memory.memoryLimit = bytes
+		d.memory.setMemoryLimit = true
+	}
+}
+
+// WithGCStats logs the garbage collection stats and heap size of the process every interval (default: disabled).
+func WithGCStats(interval time.Duration) DaemonOption {
+	return func(d *daemon) {
+		d.memory.statsInterval = interval
+	}
+}
+
+// WithResourceWatch samples the file descriptors and memory used by the process against their limits every
+// interval, and warns once either crosses its threshold with the services accounting the most of it, see
+// AccountResources. With Recycle set the worst offender is recycled, before the process dies at the limit.
+func WithResourceWatch(watch ResourceWatch) DaemonOption {
+	return func(d *daemon) {
+		d.resourceWatch = &watch
+	}
+}
+
+// UsingTransitionHook calls hook with every state transition of every service, synchronously before the
+// new state is published, e.g. to emit audit events or metrics without subscribing to the states.
+func UsingTransitionHook(hook TransitionFunc) DaemonOption {
+	return func(d *daemon) {
+		d.transitionHooks = append(d.transitionHooks, hook)
+	}
+}
+
+// UsingEmergencyHook calls hook once a service logs at LevelCritical or above, e.g. to snapshot the states with
+// SnapshotStates or the goroutines with DumpGoroutines. Such messages always bypass the log channel and are written
+// and flushed synchronously by the service logging them, the hooks run once the message is flushed.
+func UsingEmergencyHook(hook EmergencyHook) DaemonOption {
+	return func(d *daemon) {
+		d.emergencyHooks = append(d.emergencyHooks, hook)
+	}
+}
+
+// UsingResolver builds the runner of every service added without one with resolve, e.g. from a
+// dependency injection container, so services declared in code, in a config file or by plugins
+// get their shared dependencies the same way. Services added with a runner are left untouched.
+func UsingResolver(resolve ResolveFunc) DaemonOption {
+	return func(d *daemon) {
+		d.resolver = resolve
+	}
+}
+
+// UsingContextValues injects shared dependencies such as database pools, feature flags or clients once for
+// every service, the runners get them back with sctx.Value(key) instead of each carrying its own copy.
+// The values are held by the service context itself, so every child of it created with WithFields, WithName
+// or WithParent returns them as well. Values of the context chain take precedence, a key is only looked up
+// in the injected values when no context holds it. Keys must be comparable, as with context.WithValue,
+// the values are shared by every service and must be safe for concurrent use.
+// Values given by several options are merged, the last value given for a key wins.
+func UsingContextValues(values map[any]any) DaemonOption {
+	return func(d *daemon) {
+		if d.values == nil {
+			d.values = make(map[any]any, len(values))
+		}
+		for key, value := range values {
+			d.values[key] = value
+		}
+	}
+}
+
+// UsingWebhooks posts the given events of the daemon and its services as json to url, every event when none
+// are given, e.g. to relay failures to a chat channel or incident tooling without a metrics stack.
+// Events are posted from a routine of their own in the order they happened, retried with a growing delay
+// while the webhook is unreachable or answers with a server error, and dropped when too many are pending.
+// The events still pending when the daemon stops are delivered within its shutdown grace, 5 seconds at least.
+// Each call adds a webhook, see UsingWebhookSecret to sign the events.
+func UsingWebhooks(url string, events ...WebhookEvent) DaemonOption {
+	return func(d *daemon) {
+		hook := webhook{url: url, events: make(map[WebhookEvent]bool, len(events))}
+		for _, event := range events {
+			hook.events[event] = true
+		}
+		d.webhooks = append(d.webhooks, hook)
+	}
+}
+
+// UsingWebhookSecret signs the events posted to every webhook with an HMAC-SHA256 of their body keyed by secret,
+// sent in the WebhookSignatureHeader, which receivers check with VerifyWebhook.
+func UsingWebhookSecret(secret []byte) DaemonOption {
+	return func(d *daemon) {
+		d.webhookSecret = secret
+	}
+}
+
+// UsingConfigReload makes the daemon load its runtime settings with load on every SIGHUP, before the services
+// implementing Reloader are reloaded. The log level, health interval and state timeouts change in place and
+// every change is logged, the current settings are kept when they cannot be loaded.
+// config.Reloader loads them from the config file of the daemon.
+func UsingConfigReload(load ConfigLoader) DaemonOption {
+	return func(d *daemon) {
+		d.configLoader = load
+	}
+}
+
+// UsingPreStartHook adds a hook run before any service starts, e.g. to acquire a pid lock or open privileged sockets.
+// Hooks run in the order they were added, the first hook returning an error aborts startup and Start returns its error.
+func UsingPreStartHook(hook Hook) DaemonOption {
+	return func(d *daemon) {
+		d.preStartHooks = append(d.preStartHooks, hook)
+	}
+}
+
+// UsingPostStopHook adds a hook run once all services have exited, e.g. to release what a pre-start hook acquired.
+// Hooks run in the reverse order they were added, all of them run and their errors are returned by Start.
+// They also run when a pre-start hook aborted startup.
+func UsingPostStopHook(hook Hook) DaemonOption {
+	return func(d *daemon) {
+		d.postStopHooks = append(d.postStopHooks, hook)
+	}
+}
+
+// UsingShutdownOrder stops the named services one after another in the given order when the daemon shuts down,
+// each service is only signalled once the services before it have exited. Services that are not named stop
+// after the named ones. Dependencies set with WithDependsOn are always honored, an order that contradicts them
+// is rejected when the daemon starts.
+func UsingShutdownOrder(services ...string) DaemonOption {
+	return func(d *daemon) {
+		d.shutdownOrder = services
+	}
+}
+
+// WithSignalShutdown sets how the daemon shuts down once it receives sig, which also stops the daemon if it is not
+// among the signals given to WithSignals, e.g. a fast shutdown on SIGINT and a full drain on SIGTERM:
+//
+//	rxd.WithSignalShutdown(syscall.SIGINT, rxd.SignalShutdown{StopTimeout: 2 * time.Second})
+//	rxd.WithSignalShutdown(syscall.SIGTERM, rxd.SignalShutdown{Grace: 60 * time.Second})
+func WithSignalShutdown(sig os.Signal, shutdown SignalShutdown) DaemonOption {
+	return func(d *daemon) {
+		if d.signalShutdowns == nil {
+			d.signalShutdowns = make(map[os.Signal]SignalShutdown)
+		}
+		d.signalShutdowns[sig] = shutdown
+	}
+}
+
+// WithShutdownGrace sets the time services are given to clean up once the daemon begins shutting down.
+// Services can read the resulting deadline via sctx.ShutdownDeadline() to budget their cleanup work.
+func WithShutdownGrace(grace time.Duration) DaemonOption {
+	return func(d *daemon) {
+		d.shutdownGrace = grace
+	}
+}
+
+// WithExternalUnits publishes the state of units managed by the host, e.g. "postgresql.service", as virtual
+// services polled every interval, so services can gate on them with WithDependsOn or the Watch APIs
+// of the service context. An active unit is published as StateRun, an inactive or failed unit as StateExit.
+// On linux the units are read from systemd unless a source is set with WithUnitSource.
+func WithExternalUnits(interval time.Duration, units ...string) DaemonOption {
+	return func(d *daemon) {
+		if interval <= 0 {
+			interval = 5 * time.Second
+		}
+		d.units = append(d.units, units...)
+		d.unitInterval = interval
+		if d.unitSource == nil {
+			d.unitSource = defaultUnitSource()
+		}
+	}
+}
+
+// WithUnitSource sets where the state of the units given to WithExternalUnits is read from.
+func WithUnitSource(source UnitSource) DaemonOption {
+	return func(d *daemon) {
+		d.unitSource = source
+	}
+}
@@ -0,0 +1,39 @@
+package rxd
+
+import (
+	"runtime/debug"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// newPanicError captures the stack of the routine that panicked with r, so it must be called by the deferred
+// function that recovered it. A PanicError raised again, e.g. by the stop guard, keeps the stack it was created with.
+func newPanicError(component string, r any) PanicError {
+	if perr, ok := r.(PanicError); ok {
+		if perr.Component == "" {
+			perr.Component = component
+		}
+		return perr
+	}
+	return PanicError{Component: component, Value: r, Stack: debug.Stack()}
+}
+
+// fields returns the log fields of the panic, its stack included.
+func (e PanicError) fields() []log.Field {
+	return []log.Field{log.Any("error", e.Value), log.String("component", e.Component), log.String("stack", string(e.Stack))}
+}
+
+// recoverPanic is deferred by the routines of the daemon so a panic does not crash the whole process.
+// It logs the panic along with its stack and hands it to onPanic, which may be nil.
+func (d *daemon) recoverPanic(component string, onPanic func(perr PanicError)) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	perr := newPanicError(component, r)
+	d.internalLogger.Log(log.LevelError, "recovered from panic in "+component, append(perr.fields(), log.String("rxd", d.name))...)
+	if onPanic != nil {
+		onPanic(perr)
+	}
+}
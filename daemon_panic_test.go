@@ -0,0 +1,127 @@
+package rxd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+var errPanicked = errors.New("panicked on purpose")
+
+// panickingService panics with errPanicked once it runs.
+type panickingService struct {
+	recordingService
+}
+
+func (p *panickingService) Run(sctx ServiceContext) error {
+	panic(errPanicked)
+}
+
+func TestDaemon_PanicError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	d := NewDaemon("test-daemon", WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())), WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())))
+	if err := d.AddService(NewService("boom", &panickingService{recordingService{name: "boom", journal: &recordingJournal{}}}, WithManager(NewRunOnceManager(0)))); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	err := d.Start(ctx)
+	var perr PanicError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected the panic to be returned as a PanicError, got %v", err)
+	}
+	if perr.Component != "service boom" || !errors.Is(err, errPanicked) {
+		t.Errorf("expected the panic of boom to wrap its value, got %+v", perr)
+	}
+	if !strings.Contains(string(perr.Stack), "(*panickingService).Run") {
+		t.Errorf("expected the stack of the panicking routine, got %s", perr.Stack)
+	}
+}
+
+// escapingManager runs the runner once without recovering from its panics.
+type escapingManager struct{}
+
+func (escapingManager) Manage(sctx ServiceContext, ds DaemonService, updateC chan<- StateUpdate) {
+	ds.Runner.Run(sctx)
+}
+
+func TestDaemon_PanicStopGuard(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// the panic escapes the manager and is raised again by the stop guard, the stack of the runner is kept.
+	internal := newTestLogger()
+	d := NewDaemon("test-daemon", WithInternalLogger(log.NewLogger(log.LevelDebug, internal)), WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())))
+	if err := d.AddService(NewService("boom", &panickingService{recordingService{name: "boom", journal: &recordingJournal{}}}, WithManager(escapingManager{}), WithStopTimeout(time.Second))); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	if err := d.Start(ctx); err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+	if logged := internal.Output(); !strings.Contains(logged, "component=service boom") || !strings.Contains(logged, "(*panickingService).Run") {
+		t.Errorf("expected the panic to be logged with the stack of the runner, got %q", logged)
+	}
+}
+
+// panickingHandler panics on the messages containing "explode".
+type panickingHandler struct {
+	*testServiceLogger
+}
+
+func (h panickingHandler) Handle(level log.Level, message string, fields []log.Field) {
+	if strings.Contains(message, "explode") {
+		panic("handler exploded")
+	}
+	h.testServiceLogger.Handle(level, message, fields)
+}
+
+// explodingLogService logs a message its log handler panics on, then a regular one.
+type explodingLogService struct {
+	recordingService
+}
+
+func (e *explodingLogService) Run(sctx ServiceContext) error {
+	sctx.Log(log.LevelInfo, "explode")
+	sctx.Log(log.LevelInfo, "still logging")
+	return e.recordingService.Run(sctx)
+}
+
+func TestDaemon_PanicLogPump(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	journal := &recordingJournal{}
+	internal, services := newTestLogger(), newTestLogger()
+	d := NewDaemon("test-daemon",
+		WithInternalLogger(log.NewLogger(log.LevelDebug, internal)),
+		WithServiceLogger(log.NewLogger(log.LevelDebug, panickingHandler{services})),
+	)
+	if err := d.AddService(NewService("chatty", &explodingLogService{recordingService{name: "chatty", journal: journal}})); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+	for journal.index("chatty:run") < 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+
+	if !strings.Contains(services.Output(), "still logging") {
+		t.Errorf("expected the services to keep logging, got %q", services.Output())
+	}
+	if !strings.Contains(internal.Output(), "recovered from panic in log pump") {
+		t.Errorf("expected the panic of the handler to be logged, got %q", internal.Output())
+	}
+}
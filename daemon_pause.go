@@ -0,0 +1,132 @@
+package rxd
+
+import (
+	"context"
+
+	"github.com/ambitiousfew/rxd/intracom"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// startLocked launches a registered service, or pauses it when it starts paused, the caller must hold the daemon lock.
+func (d *daemon) startLocked(name string) {
+	if conf := d.configs[name]; conf.startPaused {
+		d.pauseLocked(name, conf.resumeWhenRunning)
+		return
+	}
+	d.launchLocked(name)
+}
+
+// pauseLocked reports the service as paused until it is resumed, the caller must hold the daemon lock.
+// The paused service holds a routine of the daemon, so the daemon keeps running while it waits.
+func (d *daemon) pauseLocked(name string, resumeWhenRunning []string) {
+	rt := d.rt
+	resumeC := make(chan struct{})
+	d.paused[name] = resumeC
+	rt.active++
+
+	// the states update channel is only closed once closing is set, so this is safe while holding the lock.
+	rt.stateC <- StateUpdate{Name: name, State: StatePaused}
+	d.internalLogger.Log(log.LevelInfo, "service paused until resumed", log.String("service_name", name), rt.nameField)
+
+	go d.awaitResume(rt, name, resumeC, resumeWhenRunning)
+}
+
+// awaitResume resumes the service once the services it waits for are running, and gives up its routine of
+// the daemon once the service is resumed, removed or the daemon stops.
+func (d *daemon) awaitResume(rt *daemonRuntime, name string, resumeC <-chan struct{}, resumeWhenRunning []string) {
+	ctx, cancel := context.WithCancel(rt.ctx)
+	defer cancel()
+
+	var runningC <-chan struct{}
+	if len(resumeWhenRunning) > 0 {
+		runningC = d.awaitRunning(ctx, name, resumeWhenRunning)
+	}
+
+	select {
+	case <-resumeC:
+	case <-runningC:
+		d.internalLogger.Log(log.LevelInfo, "resuming service, the services it waits for are running", log.String("service_name", name), rt.nameField)
+		if err := d.ResumeService(name); err != nil {
+			d.internalLogger.Log(log.LevelError, "error resuming service", log.String("service_name", name), log.Error("error", err), rt.nameField)
+		}
+	case <-ctx.Done():
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, paused := d.paused[name]; paused {
+		// the daemon stopped while the service was paused, it never ran.
+		delete(d.paused, name)
+		rt.stateC <- StateUpdate{Name: name, State: StateExit}
+	}
+	d.releaseLocked()
+}
+
+// awaitRunning returns a channel closed once all of the given services have reached StateRun.
+func (d *daemon) awaitRunning(ctx context.Context, name string, services []string) <-chan struct{} {
+	runningC := make(chan struct{})
+
+	go func() {
+		consumer := internalAllStatesConsumer(d.ic, prefix+".paused."+name)
+		sub, err := intracom.CreateSubscription[ServiceStates](ctx, d.ic, internalServiceStates, -1, intracom.SubscriberConfig[ServiceStates]{
+			ConsumerGroup: consumer,
+			BufferSize:    1,
+			BufferPolicy:  intracom.BufferPolicyDropOldest[ServiceStates]{},
+		})
+		if err != nil {
+			if ctx.Err() == nil {
+				d.internalLogger.Log(log.LevelError, "error subscribing the paused service to states", log.String("service_name", name), log.Error("error", err), log.String("rxd", d.name))
+			}
+			return
+		}
+		defer intracom.RemoveSubscription[ServiceStates](d.ic, internalServiceStates, consumer, sub)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case states, open := <-sub:
+				if !open {
+					return
+				}
+				if countRunning(states, services) == len(services) {
+					close(runningC)
+					return
+				}
+			}
+		}
+	}()
+
+	return runningC
+}
+
+// ResumeService starts a service that was registered with WithStartPaused and is still paused.
+func (d *daemon) ResumeService(name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.services[name]; !ok {
+		return ErrServiceNotFound
+	}
+	if d.rt == nil {
+		return ErrDaemonNotStarted
+	}
+	if d.rt.closing || d.rt.ctx.Err() != nil {
+		return ErrDaemonStopping
+	}
+	if _, ok := d.paused[name]; !ok {
+		return ErrServiceNotPaused
+	}
+
+	d.internalLogger.Log(log.LevelInfo, "resuming service", log.String("service_name", name), log.String("rxd", d.name))
+	d.launchLocked(name)
+	return nil
+}
+
+// unpauseLocked lets the awaiting routine of a paused service go, the caller must hold the daemon lock.
+func (d *daemon) unpauseLocked(name string) {
+	if resumeC, ok := d.paused[name]; ok {
+		delete(d.paused, name)
+		close(resumeC)
+	}
+}
@@ -0,0 +1,108 @@
+package rxd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+func TestDaemon_StartPaused(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	journal := &recordingJournal{}
+	d := NewDaemon("test-daemon", WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())), WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())))
+	err := d.AddServices(
+		NewService("worker", &recordingService{name: "worker", journal: journal}),
+		NewService("migrate", &failingService{recordingService{name: "migrate", journal: journal}, nil}, WithManager(NewRunOnceManager(0)), WithStartPaused()),
+		NewService("backfill", &recordingService{name: "backfill", journal: journal}, WithStartPaused("worker")),
+	)
+	if err != nil {
+		t.Fatalf("error adding services: %s", err)
+	}
+
+	if err := d.ResumeService("migrate"); err != ErrDaemonNotStarted {
+		t.Errorf("expected %s before starting, got %v", ErrDaemonNotStarted, err)
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	dd := d.(*daemon)
+	await := func(name string, want State) {
+		t.Helper()
+		for dd.readiness.current()[name] != want {
+			select {
+			case <-ctx.Done():
+				t.Fatalf("timed out waiting for %s to be %s, got %v", name, want, dd.readiness.current())
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}
+
+	await("migrate", StatePaused)
+	// backfill resumes on its own once worker runs.
+	await("backfill", StateRun)
+
+	if !dd.readiness.ready.Load() {
+		t.Errorf("expected the paused service not to hold back readiness")
+	}
+	if journal.count("migrate:init") != 0 {
+		t.Fatalf("expected the paused service not to start")
+	}
+	if err := d.ResumeService("worker"); err != ErrServiceNotPaused {
+		t.Errorf("expected %s, got %v", ErrServiceNotPaused, err)
+	}
+
+	if err := d.ResumeService("migrate"); err != nil {
+		t.Fatalf("error resuming service: %s", err)
+	}
+	await("migrate", StateExit)
+	if journal.count("migrate:run") != 1 {
+		t.Errorf("expected the resumed service to run once")
+	}
+
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+}
+
+func TestDaemon_StartPausedStop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	journal := &recordingJournal{}
+	d := NewDaemon("test-daemon", WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())), WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+		UsingTransitionHook(func(service string, from, to State) {
+			if to == StatePaused {
+				cancel()
+			}
+		}),
+	)
+	if err := d.AddService(NewService("migrate", &recordingService{name: "migrate", journal: journal}, WithStartPaused())); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	select {
+	case err := <-errC:
+		if err != nil {
+			t.Fatalf("error starting daemon: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a daemon with only paused services to stop")
+	}
+
+	if journal.count("migrate:init") != 0 {
+		t.Errorf("expected the paused service never to start")
+	}
+}
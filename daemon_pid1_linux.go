@@ -0,0 +1,141 @@
+//go:build linux && !rxdminimal
+
+package rxd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// reapGrace is how long a zombie child is left to the code that started it, such as exec.Cmd.Wait, before the
+// init mode reaps it, so the exit status of the processes started by services is never taken from them.
+const reapGrace = time.Second
+
+// runInit reaps orphaned processes and forwards SIGTERM, SIGINT and SIGQUIT to every other process of the container
+// when the daemon runs as pid 1, until the returned func is called. See UsingPID1Mode.
+func (d *daemon) runInit(nameField log.Field) func() {
+	if os.Getpid() != 1 {
+		d.internalLogger.Log(log.LevelDebug, "not running as pid 1, init mode is disabled", nameField)
+		return func() {}
+	}
+
+	childC := make(chan os.Signal, 1)
+	signal.Notify(childC, syscall.SIGCHLD)
+	forwardC := make(chan os.Signal, 1)
+	signal.Notify(forwardC, syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	doneC := make(chan struct{})
+	go func() {
+		defer close(doneC)
+		defer signal.Stop(childC)
+		defer signal.Stop(forwardC)
+
+		ticker := time.NewTicker(reapGrace)
+		defer ticker.Stop()
+
+		zombies := make(map[int]time.Time)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-forwardC:
+				// kill -1 signals every process but pid 1 itself, which is every other process of the container.
+				if err := syscall.Kill(-1, sig.(syscall.Signal)); err != nil && err != syscall.ESRCH {
+					d.internalLogger.Log(log.LevelError, "error forwarding signal", log.String("signal", sig.String()), log.Error("error", err), nameField)
+				} else {
+					d.internalLogger.Log(log.LevelDebug, "forwarded signal to the processes of the container", log.String("signal", sig.String()), nameField)
+				}
+			case <-childC:
+			case <-ticker.C:
+			}
+			d.reapZombies(zombies, nameField)
+		}
+	}()
+
+	d.internalLogger.Log(log.LevelInfo, "running as pid 1, reaping orphaned processes and forwarding signals", nameField)
+	return func() {
+		cancel()
+		<-doneC
+	}
+}
+
+// reapZombies reaps the zombie children that have not been waited for within the reap grace,
+// zombies holds when each zombie was first seen.
+func (d *daemon) reapZombies(zombies map[int]time.Time, nameField log.Field) {
+	now := time.Now()
+	seen := make(map[int]struct{}, len(zombies))
+	for _, pid := range zombieChildren(os.Getpid()) {
+		seen[pid] = struct{}{}
+		first, ok := zombies[pid]
+		if !ok {
+			zombies[pid] = now
+			continue
+		}
+		if now.Sub(first) < reapGrace {
+			continue
+		}
+
+		var status syscall.WaitStatus
+		if reaped, err := syscall.Wait4(pid, &status, syscall.WNOHANG, nil); err == nil && reaped == pid {
+			d.internalLogger.Log(log.LevelDebug, "reaped orphaned process", log.Int("pid", pid), log.Int("exit_status", status.ExitStatus()), nameField)
+		}
+		delete(zombies, pid)
+	}
+
+	for pid := range zombies {
+		if _, ok := seen[pid]; !ok {
+			// waited for by the code that started it.
+			delete(zombies, pid)
+		}
+	}
+}
+
+// zombieChildren returns the children of parent that exited and were not waited for yet, read from /proc.
+func zombieChildren(parent int) []int {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+
+	var pids []int
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := os.ReadFile("/proc/" + entry.Name() + "/stat")
+		if err != nil {
+			continue
+		}
+		if state, ppid, ok := parseStat(stat); ok && state == 'Z' && ppid == parent {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// parseStat returns the state and parent pid of a process from /proc/<pid>/stat, "pid (comm) state ppid ...".
+// The command name may contain spaces and parentheses, so the fields are read after its last parenthesis.
+func parseStat(stat []byte) (state byte, ppid int, ok bool) {
+	end := bytes.LastIndexByte(stat, ')')
+	if end < 0 {
+		return 0, 0, false
+	}
+	fields := bytes.Fields(stat[end+1:])
+	if len(fields) < 2 || len(fields[0]) != 1 {
+		return 0, 0, false
+	}
+	ppid, err := strconv.Atoi(string(fields[1]))
+	if err != nil {
+		return 0, 0, false
+	}
+	return fields[0][0], ppid, true
+}
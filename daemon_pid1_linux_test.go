@@ -0,0 +1,55 @@
+//go:build linux && !rxdminimal
+
+package rxd
+
+import (
+	"os"
+	"os/exec"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+func TestParseStat(t *testing.T) {
+	state, ppid, ok := parseStat([]byte("42 (my (odd) cmd) Z 1 42 42 0 -1"))
+	if !ok || state != 'Z' || ppid != 1 {
+		t.Errorf("expected a zombie child of pid 1, got %c %d %v", state, ppid, ok)
+	}
+	if _, _, ok := parseStat([]byte("42 no command")); ok {
+		t.Errorf("expected a malformed stat to be rejected")
+	}
+}
+
+func TestDaemon_ReapZombies(t *testing.T) {
+	cmd := exec.Command("true")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start a child process: %s", err)
+	}
+	pid := cmd.Process.Pid
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !slices.Contains(zombieChildren(os.Getpid()), pid) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected child %d to become a zombie", pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	d := NewDaemon("test-daemon", WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger()))).(*daemon)
+	zombies := map[int]time.Time{}
+	d.reapZombies(zombies, log.String("rxd", d.name))
+	if !slices.Contains(zombieChildren(os.Getpid()), pid) {
+		t.Fatalf("expected the zombie to be left to its parent within the reap grace")
+	}
+
+	zombies[pid] = time.Now().Add(-2 * reapGrace)
+	d.reapZombies(zombies, log.String("rxd", d.name))
+	if slices.Contains(zombieChildren(os.Getpid()), pid) {
+		t.Errorf("expected the zombie to be reaped once the reap grace passed")
+	}
+	if len(zombies) != 0 {
+		t.Errorf("expected reaped zombies to be forgotten, got %v", zombies)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !linux || rxdminimal
+
+package rxd
+
+import "github.com/ambitiousfew/rxd/log"
+
+// runInit does nothing, the init mode is only supported by linux builds without the rxdminimal tag.
+func (d *daemon) runInit(nameField log.Field) func() {
+	d.internalLogger.Log(log.LevelWarning, "init mode is not supported by this build", nameField)
+	return func() {}
+}
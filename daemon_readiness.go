@@ -0,0 +1,247 @@
+package rxd
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// ReadinessPolicy decides whether the daemon is ready from the current states of its services.
+// The same policy drives the systemd READY notification, the /readyz endpoint and the status file.
+type ReadinessPolicy interface {
+	Ready(states ServiceStates) bool
+}
+
+// ReadinessFunc adapts a plain function to a ReadinessPolicy.
+type ReadinessFunc func(states ServiceStates) bool
+
+func (f ReadinessFunc) Ready(states ServiceStates) bool {
+	return f(states)
+}
+
+// AllOf is ready once all of the named services are running.
+// Without any names every service of the daemon that is not paused must be running, which is the default policy.
+func AllOf(services ...string) ReadinessPolicy {
+	return ReadinessFunc(func(states ServiceStates) bool {
+		// evaluated on every publish of the states, it returns as soon as a service is found not running.
+		if len(services) > 0 {
+			for _, name := range services {
+				if state, ok := states[name]; !ok || state != StateRun {
+					return false
+				}
+			}
+			return true
+		}
+
+		for _, state := range states {
+			// paused services wait on an operator, they do not hold back the readiness of the daemon.
+			if state != StateRun && state != StatePaused {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// AnyOf is ready once any of the named services, or any service when none are named, is running.
+func AnyOf(services ...string) ReadinessPolicy {
+	return ReadinessFunc(func(states ServiceStates) bool {
+		return countRunning(states, services) > 0
+	})
+}
+
+// Quorum is ready once at least k of the named services, or of all services when none are named, are running.
+func Quorum(k int, services ...string) ReadinessPolicy {
+	return ReadinessFunc(func(states ServiceStates) bool {
+		return countRunning(states, services) >= k
+	})
+}
+
+func countRunning(states ServiceStates, services []string) int {
+	var running int
+	if len(services) == 0 {
+		for _, state := range states {
+			if state == StateRun {
+				running++
+			}
+		}
+		return running
+	}
+
+	for _, name := range services {
+		if state, ok := states[name]; ok && state == StateRun {
+			running++
+		}
+	}
+	return running
+}
+
+// readinessTracker evaluates the readiness policy on every states update of the states watcher.
+type readinessTracker struct {
+	policy     ReadinessPolicy
+	notifier   SystemNotifier
+	statusFile string
+	codec      Codec // encodes the status file.
+	logger     log.Logger
+	ready      atomic.Bool
+	notified   atomic.Bool // READY is only sent to the notifier the first time the daemon becomes ready, or after a reload.
+	states     atomic.Pointer[ServiceStates]
+	readyC     chan struct{} // closed the first time the policy is satisfied, nil when nobody waits for it.
+	readyOnce  sync.Once
+	errors     *lastErrors          // reported in the status file along with the states, may be nil.
+	status     string               // latest summary sent with NotifyStatus.
+	ranAt      map[string]time.Time // when every service was last seen running, to tell retries from past errors.
+}
+
+// StatusReport is the content of the status file, decoded with the codec of the daemon, see UsingCodec.
+type StatusReport struct {
+	Ready    bool              `json:"ready"`
+	Services map[string]string `json:"services"`
+	Errors   map[string]string `json:"errors,omitempty"` // the most recent lifecycle error of the services that failed one.
+}
+
+// observe is only called by the states watcher routine with a snapshot that is never modified after.
+func (r *readinessTracker) observe(states ServiceStates) {
+	if r == nil {
+		return
+	}
+
+	ready := r.policy.Ready(states)
+	r.ready.Store(ready)
+	r.states.Store(&states)
+
+	if ready && r.readyC != nil {
+		r.readyOnce.Do(func() { close(r.readyC) })
+	}
+
+	if ready && r.notifier != nil && !r.notified.Swap(true) {
+		if err := r.notifier.Notify(NotifyStateReady); err != nil {
+			r.logger.Log(log.LevelError, "error sending 'ready' notification", log.Error("error", err))
+		}
+	}
+
+	if r.notifier != nil {
+		r.notifyStatus(states)
+	}
+
+	if r.statusFile != "" {
+		if err := r.writeStatus(ready, states); err != nil {
+			r.logger.Log(log.LevelError, "error writing status file", log.String("path", r.statusFile), log.Error("error", err))
+		}
+	}
+}
+
+// notifyStatus sends the summary of the states to the notifier whenever it changes.
+func (r *readinessTracker) notifyStatus(states ServiceStates) {
+	if r.ranAt == nil {
+		r.ranAt = make(map[string]time.Time)
+	}
+	now := time.Now()
+	for name, state := range states {
+		if state == StateRun || state == StateIdle {
+			r.ranAt[name] = now
+		}
+	}
+
+	var errs map[string]LifecycleError
+	if r.errors != nil {
+		errs = r.errors.snapshot()
+	}
+	status := statusSummary(states, errs, r.ranAt)
+	if status == r.status {
+		return
+	}
+	r.status = status
+	if err := r.notifier.NotifyStatus(status); err != nil {
+		r.logger.Log(log.LevelError, "error sending status notification", log.Error("error", err))
+	}
+}
+
+// current returns the latest states observed, empty until the daemon has started.
+func (r *readinessTracker) current() ServiceStates {
+	if r == nil {
+		return ServiceStates{}
+	}
+	if states := r.states.Load(); states != nil {
+		return *states
+	}
+	return ServiceStates{}
+}
+
+// reloading makes the tracker notify READY again once the policy is satisfied after a reload.
+func (r *readinessTracker) reloading() {
+	if r != nil {
+		r.notified.Store(false)
+	}
+}
+
+// writeStatus replaces the status file atomically so readers never observe a partial report.
+func (r *readinessTracker) writeStatus(ready bool, states ServiceStates) error {
+	report := StatusReport{Ready: ready, Services: make(map[string]string, len(states)), Errors: r.errors.messages()}
+	for name, state := range states {
+		report.Services[name] = state.String()
+	}
+
+	data, err := r.codec.Marshal(report)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(r.statusFile, data)
+}
+
+// awaitReadiness stops the daemon when its readiness policy is not satisfied within the readiness timeout,
+// so a daemon whose services never come up fails to start instead of running without ever notifying READY.
+// The returned func reports the failure once the daemon has stopped.
+func (d *daemon) awaitReadiness(ctx context.Context, cancel context.CancelFunc, nameField log.Field) func() error {
+	if d.readinessTimeout <= 0 {
+		return func() error { return nil }
+	}
+
+	var timedOut atomic.Bool
+	go func() {
+		timer := time.NewTimer(d.readinessTimeout)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+		case <-d.readiness.readyC:
+		case <-timer.C:
+			var pending []string
+			for name, state := range d.readiness.current() {
+				if state != StateRun {
+					pending = append(pending, name)
+				}
+			}
+			sort.Strings(pending)
+
+			timedOut.Store(true)
+			d.internalLogger.Log(log.LevelError, "daemon did not become ready in time, stopping",
+				log.String("timeout", d.readinessTimeout.String()), log.String("not_running", strings.Join(pending, ",")), nameField)
+			cancel()
+		}
+	}()
+
+	return func() error {
+		if timedOut.Load() {
+			return ErrReadinessTimeout
+		}
+		return nil
+	}
+}
+
+// ServeHTTP answers /readyz with 200 when the policy is satisfied and 503 otherwise.
+func (r *readinessTracker) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if r.ready.Load() {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ready"))
+		return
+	}
+	w.WriteHeader(http.StatusServiceUnavailable)
+	w.Write([]byte("not ready"))
+}
@@ -0,0 +1,150 @@
+package rxd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+func TestReadinessPolicies(t *testing.T) {
+	states := ServiceStates{"a": StateRun, "b": StateRun, "c": StateInit}
+
+	tests := []struct {
+		name   string
+		policy ReadinessPolicy
+		want   bool
+	}{
+		{"all of every service", AllOf(), false},
+		{"all of named", AllOf("a", "b"), true},
+		{"all of unknown", AllOf("a", "d"), false},
+		{"any of named", AnyOf("c", "b"), true},
+		{"any of none running", AnyOf("c"), false},
+		{"quorum met", Quorum(2), true},
+		{"quorum not met", Quorum(2, "a", "c"), false},
+		{"custom func", ReadinessFunc(func(s ServiceStates) bool { return s["c"] == StateInit }), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Ready(states); got != tt.want {
+				t.Errorf("expected %t, got %t", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestReadinessTracker_StatusAndReadyz(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.json")
+	tracker := &readinessTracker{
+		policy:     AllOf(),
+		statusFile: path,
+		codec:      JSONCodec,
+		logger:     log.NewLogger(log.LevelDebug, newTestLogger()),
+	}
+
+	readyz := func() int {
+		rec := httptest.NewRecorder()
+		tracker.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return rec.Code
+	}
+
+	tracker.observe(ServiceStates{"a": StateRun, "b": StateIdle})
+	if code := readyz(); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected %d before all services run, got %d", http.StatusServiceUnavailable, code)
+	}
+
+	tracker.observe(ServiceStates{"a": StateRun, "b": StateRun})
+	if code := readyz(); code != http.StatusOK {
+		t.Fatalf("expected %d once all services run, got %d", http.StatusOK, code)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading status file: %s", err)
+	}
+
+	var report StatusReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("error decoding status file: %s", err)
+	}
+
+	if !report.Ready || report.Services["b"] != StateRun.String() {
+		t.Errorf("unexpected status report: %s", data)
+	}
+}
+
+// idlingService never leaves Idle until it is stopped.
+type idlingService struct {
+	recordingService
+}
+
+func (s *idlingService) Idle(sctx ServiceContext) error {
+	<-sctx.Done()
+	return nil
+}
+
+func TestDaemon_ReadinessTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  ReadinessPolicy
+		wantErr bool
+	}{
+		{"all services", AllOf(), true},
+		{"running subset", AllOf("running"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			journal := &recordingJournal{}
+			d := NewDaemon("test-daemon",
+				WithReadinessPolicy(tt.policy),
+				WithReadinessTimeout(200*time.Millisecond),
+				WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+				WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+			)
+
+			err := d.AddServices(
+				NewService("running", &recordingService{name: "running", journal: journal}),
+				NewService("idling", &idlingService{recordingService{name: "idling", journal: journal}}),
+			)
+			if err != nil {
+				t.Fatalf("error adding services: %s", err)
+			}
+
+			if !tt.wantErr {
+				// the daemon is ready, it keeps running past the readiness timeout until stopped.
+				time.AfterFunc(500*time.Millisecond, cancel)
+			}
+
+			start := time.Now()
+			err = d.Start(ctx)
+			if tt.wantErr {
+				if !errors.Is(err, ErrReadinessTimeout) {
+					t.Fatalf("expected %s, got %v", ErrReadinessTimeout, err)
+				}
+				if elapsed := time.Since(start); elapsed > 2*time.Second {
+					t.Errorf("expected the daemon to stop after the readiness timeout, took %s", elapsed)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected the ready daemon to stop cleanly, got %s", err)
+			}
+			if elapsed := time.Since(start); elapsed < 500*time.Millisecond {
+				t.Errorf("expected the ready daemon to run until stopped, stopped after %s", elapsed)
+			}
+		})
+	}
+}
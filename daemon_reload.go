@@ -0,0 +1,151 @@
+package rxd
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// RuntimeConfig holds the settings of a daemon that can change while it runs, see UsingConfigReload.
+// Settings left at their zero value are not changed.
+type RuntimeConfig struct {
+	LogLevel       *log.Level                      // level of the service logger.
+	HealthInterval time.Duration                   // how often services are probed for their health, see WithHealthChecks.
+	StateTimeouts  map[string]ManagerStateTimeouts // transition timeouts of continuous managers keyed by service name, an empty map clears them.
+	Ignored        []string                        // settings that changed but only apply once the daemon restarts.
+}
+
+// ConfigLoader loads the runtime settings of the daemon, e.g. from its config file.
+type ConfigLoader func() (RuntimeConfig, error)
+
+// configChange is a setting changed by a reload.
+type configChange struct {
+	setting  string
+	from, to string
+}
+
+// reloadConfig loads the runtime settings with the config loader and applies them, logging every change.
+// The current settings are kept when they cannot be loaded.
+func (d *daemon) reloadConfig(nameField log.Field) {
+	if d.configLoader == nil {
+		return
+	}
+
+	conf, err := d.configLoader()
+	if err != nil {
+		d.serviceLogger.Log(log.LevelError, "error reloading the configuration, keeping the current one", log.Error("error", err), log.String("rxd", d.name))
+		d.internalLogger.Log(log.LevelError, "error reloading the configuration, keeping the current one", log.Error("error", err), nameField)
+		return
+	}
+
+	changes, ignored := d.applyConfig(conf)
+	for _, change := range changes {
+		fields := []log.Field{log.String("setting", change.setting), log.String("from", change.from), log.String("to", change.to)}
+		d.serviceLogger.Log(log.LevelNotice, "configuration changed", append(fields, log.String("rxd", d.name))...)
+		d.internalLogger.Log(log.LevelNotice, "configuration changed", append(fields, nameField)...)
+	}
+	for _, setting := range ignored {
+		d.serviceLogger.Log(log.LevelWarning, "configuration change ignored until the daemon restarts", log.String("setting", setting), log.String("rxd", d.name))
+		d.internalLogger.Log(log.LevelWarning, "configuration change ignored until the daemon restarts", log.String("setting", setting), nameField)
+	}
+	if len(changes) == 0 && len(ignored) == 0 {
+		d.internalLogger.Log(log.LevelInfo, "configuration reloaded without changes", nameField)
+	}
+}
+
+// applyConfig applies the runtime settings, it returns the settings that changed and those that could not be applied.
+func (d *daemon) applyConfig(conf RuntimeConfig) ([]configChange, []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var changes []configChange
+	ignored := append([]string(nil), conf.Ignored...)
+
+	if conf.LogLevel != nil {
+		from := "unknown"
+		if l, ok := unwrapLogger(d.serviceLogger).(interface{ Level() log.Level }); ok {
+			from = l.Level().String()
+		}
+		if to := conf.LogLevel.String(); from != to {
+			d.serviceLogger.SetLevel(*conf.LogLevel)
+			changes = append(changes, configChange{setting: "log_level", from: from, to: to})
+		}
+	}
+
+	if conf.HealthInterval > 0 && conf.HealthInterval != d.healthInterval {
+		changes = append(changes, configChange{setting: "health_interval", from: d.healthInterval.String(), to: conf.HealthInterval.String()})
+		d.healthInterval = conf.HealthInterval
+		if d.rt != nil {
+			// only the latest interval matters to the prober, replace one it has not picked up yet.
+			select {
+			case <-d.rt.healthC:
+			default:
+			}
+			d.rt.healthC <- conf.HealthInterval
+		}
+	}
+
+	// sorted so the changes are logged in the same order every time.
+	names := make([]string, 0, len(conf.StateTimeouts))
+	for name := range conf.StateTimeouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		setting := "services." + name + ".state_timeouts"
+		manager, ok := d.managers[name].(RunContinuousManager)
+		if !ok {
+			// unknown services and other managers have no state timeouts to replace.
+			ignored = append(ignored, setting)
+			continue
+		}
+
+		current := manager.StateTimeouts
+		override := d.configs[name].stateTimeouts
+		if reloaded := override.Load(); reloaded != nil {
+			current = *reloaded
+		}
+
+		timeouts := make(ManagerStateTimeouts, len(conf.StateTimeouts[name]))
+		for state, timeout := range conf.StateTimeouts[name] {
+			timeouts[state] = timeout
+		}
+		if from, to := formatTimeouts(current), formatTimeouts(timeouts); from != to {
+			override.Store(&timeouts)
+			changes = append(changes, configChange{setting: setting, from: from, to: to})
+		}
+	}
+
+	return changes, ignored
+}
+
+// stateTimeout returns the transition timeout of the state, timeouts reloaded by the daemon take precedence.
+func (m RunContinuousManager) stateTimeout(sctx ServiceContext, state State) (time.Duration, bool) {
+	timeouts := m.StateTimeouts
+	if sc, ok := sctx.(*serviceContext); ok && sc.stateTimeouts != nil {
+		if reloaded := sc.stateTimeouts.Load(); reloaded != nil {
+			timeouts = *reloaded
+		}
+	}
+
+	timeout, ok := timeouts[state]
+	return timeout, ok
+}
+
+// formatTimeouts formats state timeouts in the order of the states, e.g. "init=5s run=250ms".
+func formatTimeouts(timeouts ManagerStateTimeouts) string {
+	var parts []string
+	for _, state := range states {
+		if timeout, ok := timeouts[state]; ok {
+			parts = append(parts, state.String()+"="+timeout.String())
+		}
+	}
+
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, " ")
+}
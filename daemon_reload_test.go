@@ -0,0 +1,112 @@
+package rxd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+func TestDaemon_ReloadConfig(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	debug := log.Level(log.LevelDebug)
+	errLoad := errors.New("config file not found")
+	loads := []RuntimeConfig{{
+		LogLevel:       &debug,
+		HealthInterval: 20 * time.Millisecond,
+		StateTimeouts: map[string]ManagerStateTimeouts{
+			"api":     {StateInit: time.Second, StateRun: 250 * time.Millisecond},
+			"migrate": {StateInit: time.Second},
+		},
+		Ignored: []string{"signals"},
+	}}
+	var loadErr error
+	loader := func() (RuntimeConfig, error) {
+		if loadErr != nil {
+			return RuntimeConfig{}, loadErr
+		}
+		return loads[0], nil
+	}
+
+	output := newTestLogger()
+	journal := &recordingJournal{}
+	d := NewDaemon("test-daemon",
+		WithHealthChecks(time.Hour, 100*time.Millisecond),
+		WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+		WithServiceLogger(log.NewLogger(log.LevelInfo, output)),
+		UsingConfigReload(loader),
+	)
+	err := d.AddServices(
+		NewService("api", &checkedService{recordingService{name: "api", journal: journal}, nil}),
+		NewService("migrate", &sleepyService{recordingService{name: "migrate", journal: journal}, time.Hour}, WithManager(NewRunOnceManager(0))),
+	)
+	if err != nil {
+		t.Fatalf("error adding services: %s", err)
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	dd := d.(*daemon)
+	for dd.readiness == nil || dd.readiness.current()["api"] != StateRun {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for api to run")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	nameField := log.String("rxd", "test-daemon")
+	dd.reloadConfig(nameField)
+
+	for _, want := range []string{
+		"configuration changed setting=log_level from=INFO to=DEBUG",
+		"configuration changed setting=health_interval from=1h0m0s to=20ms",
+		"configuration changed setting=services.api.state_timeouts from=init=5s to=init=1s run=250ms",
+		"configuration change ignored until the daemon restarts setting=signals",
+		"configuration change ignored until the daemon restarts setting=services.migrate.state_timeouts",
+	} {
+		if !strings.Contains(output.Output(), want) {
+			t.Errorf("expected the reload to log %q, got:\n%s", want, output.Output())
+		}
+	}
+
+	// the prober picks up the shorter interval instead of waiting an hour.
+	for d.Health()["api"].Status != HealthHealthy {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for api to be probed with the reloaded interval")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	sctx, scancel := newServiceContextWithCancel(ctx, "api", make(chan DaemonLog, 1), nil, contextConfig{stateTimeouts: dd.configs["api"].stateTimeouts})
+	defer scancel()
+	if timeout, ok := dd.managers["api"].(RunContinuousManager).stateTimeout(sctx, StateRun); !ok || timeout != 250*time.Millisecond {
+		t.Errorf("expected the manager to use the reloaded state timeouts, got %s", timeout)
+	}
+
+	// the same settings again change nothing, a failing load keeps them.
+	changed := strings.Count(output.Output(), "configuration changed")
+	dd.reloadConfig(nameField)
+	loadErr = errLoad
+	dd.reloadConfig(nameField)
+	if got := strings.Count(output.Output(), "configuration changed"); got != changed {
+		t.Errorf("expected no further changes, got %d instead of %d", got, changed)
+	}
+	if !strings.Contains(output.Output(), "error reloading the configuration") {
+		t.Errorf("expected the failing load to be logged, got:\n%s", output.Output())
+	}
+
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+}
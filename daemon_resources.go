@@ -0,0 +1,221 @@
+package rxd
+
+import (
+	"context"
+	"math"
+	"runtime/debug"
+	"runtime/metrics"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// ResourceWatch configures the early warning of file descriptor and memory exhaustion, see WithResourceWatch.
+type ResourceWatch struct {
+	Interval        time.Duration // how often the usage of the process is sampled (default: 30s).
+	FDThreshold     float64       // fraction of the file descriptor limit raising a warning (default: 0.8).
+	MemoryThreshold float64       // fraction of the memory limit raising a warning (default: 0.8).
+	// MemoryLimit is the number of bytes the memory usage is measured against, 0 uses the smaller of
+	// RLIMIT_AS and the go memory limit. Memory is not watched when neither is set.
+	MemoryLimit int64
+	// Recycle recycles the service accounting the most of a resource once it crosses its threshold.
+	Recycle bool
+}
+
+// resourceAccount holds the resources a service accounted with AccountResources.
+// It is created once per service, so the usage is kept across restarts of the service routine.
+type resourceAccount struct {
+	fds   atomic.Int64
+	bytes atomic.Int64
+}
+
+// AccountResources records the file descriptors and bytes of memory acquired by the service, or released when
+// negative, so the resource watch of the daemon can attribute the usage of the process to its services.
+func AccountResources(sctx ServiceContext, fds int64, bytes int64) {
+	sc, ok := sctx.(*serviceContext)
+	if !ok || sc.resources == nil {
+		return
+	}
+	sc.resources.fds.Add(fds)
+	sc.resources.bytes.Add(bytes)
+}
+
+// resourceKind is a resource of the process watched for exhaustion.
+type resourceKind struct {
+	name    string // used in the logs, e.g. "file descriptors".
+	field   string // key of the usage logged per service.
+	account func(a *resourceAccount) int64
+}
+
+var (
+	resourceFDs    = resourceKind{name: "file descriptors", field: "fds", account: func(a *resourceAccount) int64 { return a.fds.Load() }}
+	resourceMemory = resourceKind{name: "memory", field: "bytes", account: func(a *resourceAccount) int64 { return a.bytes.Load() }}
+)
+
+// resourceAlarm tracks a resource crossing its threshold, so it is reported once per crossing.
+type resourceAlarm struct {
+	kind      resourceKind
+	threshold float64
+	raised    bool
+	previous  map[string]int64 // usage of every service at the previous sample, to report its growth.
+}
+
+// watchResources samples the usage of the process every interval until ctx is done, warning once a
+// resource crosses its threshold and again once it is back below it.
+func (d *daemon) watchResources(ctx context.Context) {
+	if d.resourceWatch == nil {
+		return
+	}
+
+	watch := *d.resourceWatch
+	if watch.Interval <= 0 {
+		watch.Interval = 30 * time.Second
+	}
+	if watch.FDThreshold <= 0 {
+		watch.FDThreshold = 0.8
+	}
+	if watch.MemoryThreshold <= 0 {
+		watch.MemoryThreshold = 0.8
+	}
+
+	memoryLimit := uint64(watch.MemoryLimit)
+	if memoryLimit == 0 {
+		memoryLimit = processMemoryLimit()
+	}
+
+	go func() {
+		ticker := time.NewTicker(watch.Interval)
+		defer ticker.Stop()
+
+		fdAlarm := &resourceAlarm{kind: resourceFDs, threshold: watch.FDThreshold}
+		memAlarm := &resourceAlarm{kind: resourceMemory, threshold: watch.MemoryThreshold}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if open, limit, ok := fdUsage(); ok {
+				d.checkResource(fdAlarm, open, limit, watch.Recycle)
+			}
+			if memoryLimit > 0 {
+				d.checkResource(memAlarm, memoryUsage(), memoryLimit, watch.Recycle)
+			}
+		}
+	}()
+}
+
+// checkResource compares the usage of a resource against its limit, logging the services accounting
+// the most of it and recycling the worst offender when it crosses the threshold.
+func (d *daemon) checkResource(alarm *resourceAlarm, used, limit uint64, recycle bool) {
+	if limit == 0 {
+		return
+	}
+
+	usage := d.resourceUsage(alarm.kind)
+	defer func() { alarm.previous = usage }()
+
+	ratio := float64(used) / float64(limit)
+	fields := []log.Field{
+		log.String("resource", alarm.kind.name),
+		log.Int("used", used),
+		log.Int("limit", limit),
+		log.String("percent", strconv.FormatFloat(ratio*100, 'f', 1, 64)),
+		log.String("rxd", d.name),
+	}
+
+	if ratio < alarm.threshold {
+		if alarm.raised {
+			alarm.raised = false
+			d.serviceLogger.Log(log.LevelInfo, "resource usage back below its threshold", fields...)
+		}
+		return
+	}
+	if alarm.raised {
+		return
+	}
+	alarm.raised = true
+
+	offenders := rankOffenders(usage)
+	if len(offenders) > 0 {
+		parts := make([]string, 0, len(offenders))
+		for _, name := range offenders {
+			growth := usage[name] - alarm.previous[name]
+			parts = append(parts, name+"="+strconv.FormatInt(usage[name], 10)+"("+signed(growth)+")")
+		}
+		fields = append(fields, log.String(alarm.kind.field+"_by_service", strings.Join(parts, ",")))
+	}
+	d.serviceLogger.Log(log.LevelWarning, "resource usage nearing its limit", fields...)
+
+	if recycle && len(offenders) > 0 {
+		if err := d.Recycle(offenders[0]); err != nil {
+			d.serviceLogger.Log(log.LevelError, "error recycling the service exhausting a resource",
+				log.String("resource", alarm.kind.name), log.String("service_name", offenders[0]), log.Error("error", err), log.String("rxd", d.name))
+		}
+	}
+}
+
+// resourceUsage returns the usage of a resource accounted by every service.
+func (d *daemon) resourceUsage(kind resourceKind) map[string]int64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	usage := make(map[string]int64, len(d.configs))
+	for name, conf := range d.configs {
+		if conf.resources != nil {
+			usage[name] = kind.account(conf.resources)
+		}
+	}
+	return usage
+}
+
+// rankOffenders returns the services accounting some of a resource, the largest first.
+func rankOffenders(usage map[string]int64) []string {
+	names := make([]string, 0, len(usage))
+	for name, used := range usage {
+		if used > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if usage[names[i]] != usage[names[j]] {
+			return usage[names[i]] > usage[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+func signed(n int64) string {
+	if n < 0 {
+		return strconv.FormatInt(n, 10)
+	}
+	return "+" + strconv.FormatInt(n, 10)
+}
+
+// memoryUsage returns the memory mapped by the go runtime and not yet released to the operating system.
+func memoryUsage() uint64 {
+	samples := []metrics.Sample{
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/memory/classes/heap/released:bytes"},
+	}
+	metrics.Read(samples)
+	if samples[0].Value.Kind() != metrics.KindUint64 || samples[1].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return samples[0].Value.Uint64() - samples[1].Value.Uint64()
+}
+
+// processMemoryLimit returns the smaller of the address space rlimit and the go memory limit, 0 when neither is set.
+func processMemoryLimit() uint64 {
+	limit := addressSpaceLimit()
+	if soft := debug.SetMemoryLimit(-1); soft > 0 && soft != math.MaxInt64 && (limit == 0 || uint64(soft) < limit) {
+		limit = uint64(soft)
+	}
+	return limit
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package rxd
+
+// fdUsage is not supported on this platform, only the memory usage is watched.
+func fdUsage() (open, limit uint64, ok bool) {
+	return 0, 0, false
+}
+
+// addressSpaceLimit is not supported on this platform, the go memory limit is used instead.
+func addressSpaceLimit() uint64 {
+	return 0
+}
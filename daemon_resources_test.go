@@ -0,0 +1,100 @@
+package rxd
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// leakyService accounts file descriptors on every Init without ever releasing them.
+type leakyService struct {
+	inits atomic.Int32
+}
+
+func (s *leakyService) Init(sctx ServiceContext) error {
+	s.inits.Add(1)
+	AccountResources(sctx, 5, 0)
+	return nil
+}
+
+func (s *leakyService) Idle(sctx ServiceContext) error { return nil }
+
+func (s *leakyService) Run(sctx ServiceContext) error {
+	<-sctx.Done()
+	return nil
+}
+
+func (s *leakyService) Stop(sctx ServiceContext) error { return nil }
+
+func TestDaemon_CheckResource(t *testing.T) {
+	logger := newTestLogger()
+	d := NewDaemon("test-daemon", WithServiceLogger(log.NewLogger(log.LevelDebug, logger))).(*daemon)
+	for _, name := range []string{"leaky", "tidy", "idle"} {
+		if err := d.AddService(NewService(name, &leakyService{})); err != nil {
+			t.Fatalf("error adding service: %s", err)
+		}
+	}
+
+	leaky := &serviceContext{resources: d.configs["leaky"].resources}
+	AccountResources(leaky, 12, 0)
+	AccountResources(&serviceContext{resources: d.configs["tidy"].resources}, 3, 0)
+
+	alarm := &resourceAlarm{kind: resourceFDs, threshold: 0.8}
+	d.checkResource(alarm, 50, 100, false)
+	if strings.Contains(logger.Output(), "nearing its limit") {
+		t.Fatalf("expected no warning below the threshold, got %s", logger.Output())
+	}
+
+	AccountResources(leaky, 4, 0)
+	d.checkResource(alarm, 90, 100, false)
+	d.checkResource(alarm, 95, 100, false)
+	output := logger.Output()
+	if got := strings.Count(output, "resource usage nearing its limit"); got != 1 {
+		t.Fatalf("expected a single warning per crossing, got %d in %s", got, output)
+	}
+	if !strings.Contains(output, "fds_by_service=leaky=16(+4),tidy=3(+0)") {
+		t.Errorf("expected the usage to be attributed to the services, got %s", output)
+	}
+
+	d.checkResource(alarm, 10, 100, false)
+	if !strings.Contains(logger.Output(), "resource usage back below its threshold") {
+		t.Errorf("expected the resolution to be logged, got %s", logger.Output())
+	}
+}
+
+func TestDaemon_ResourceWatchRecycle(t *testing.T) {
+	if _, _, ok := fdUsage(); !ok {
+		t.Skip("file descriptors are not watched on this platform")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	service := &leakyService{}
+	d := NewDaemon("test-daemon",
+		WithResourceWatch(ResourceWatch{Interval: 50 * time.Millisecond, FDThreshold: 1e-9, Recycle: true}),
+		WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+	)
+	if err := d.AddService(NewService("leaky", service)); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	errC := make(chan error, 1)
+	go func() { errC <- d.Start(ctx) }()
+
+	for service.inits.Load() < 2 {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("expected the leaky service to be recycled, got %d inits", service.inits.Load())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error running daemon: %s", err)
+	}
+}
@@ -0,0 +1,38 @@
+//go:build linux || darwin
+
+package rxd
+
+import (
+	"math"
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// fdUsage returns the number of file descriptors open in the process and its RLIMIT_NOFILE soft limit.
+func fdUsage() (open, limit uint64, ok bool) {
+	var rlim syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlim); err != nil || rlim.Cur == 0 {
+		return 0, 0, false
+	}
+
+	dir := "/proc/self/fd"
+	if runtime.GOOS == "darwin" {
+		dir = "/dev/fd"
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) == 0 {
+		return 0, 0, false
+	}
+	// reading the directory takes a descriptor of its own.
+	return uint64(len(entries) - 1), uint64(rlim.Cur), true
+}
+
+// addressSpaceLimit returns the RLIMIT_AS soft limit of the process, 0 when unlimited (RLIM_INFINITY).
+func addressSpaceLimit() uint64 {
+	var rlim syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_AS, &rlim); err != nil || rlim.Cur >= math.MaxInt64 {
+		return 0
+	}
+	return uint64(rlim.Cur)
+}
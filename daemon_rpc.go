@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/ambitiousfew/rxd/log"
+	rxrpc "github.com/ambitiousfew/rxd/pkg/rpc"
 )
 
 type RPCConfig struct {
@@ -50,6 +51,7 @@ func NewRPCHandler(cfg RPCConfig) (*RPCServer, error) {
 type CommandHandler struct {
 	sLogger log.Logger // service logger
 	iLogger log.Logger // internal logger
+	daemon  *daemon    // daemon the service commands apply to
 }
 
 func (h CommandHandler) ChangeLogLevel(level log.Level, resp *error) error {
@@ -58,6 +60,118 @@ func (h CommandHandler) ChangeLogLevel(level log.Level, resp *error) error {
 	return nil
 }
 
+// Batch applies the operations of the request in order. Every operation is validated first and
+// nothing is applied if any is invalid, so a mistyped service name cannot leave a half applied batch.
+// A dry run only reports the plan. If applying an operation fails, the remaining operations are skipped.
+func (h CommandHandler) Batch(req rxrpc.BatchRequest, resp *rxrpc.BatchResponse) error {
+	resp.Results = make([]rxrpc.OperationResult, len(req.Operations))
+
+	valid := true
+	for i, op := range req.Operations {
+		plan, err := h.plan(op)
+		resp.Results[i] = rxrpc.OperationResult{Operation: op, Plan: plan}
+		if err != nil {
+			resp.Results[i].Err = err.Error()
+			valid = false
+		}
+	}
+
+	if !valid || req.DryRun {
+		return nil
+	}
+
+	for i, op := range req.Operations {
+		if err := h.apply(op); err != nil {
+			resp.Results[i].Err = err.Error()
+			h.iLogger.Log(log.LevelError, "batch operation failed, skipping the remaining operations", log.String("plan", resp.Results[i].Plan), log.Error("error", err))
+			return nil
+		}
+		resp.Results[i].Applied = true
+		h.iLogger.Log(log.LevelInfo, "batch operation applied", log.String("plan", resp.Results[i].Plan))
+	}
+
+	resp.Applied = true
+	return nil
+}
+
+// plan validates an operation against the current daemon and describes its effect.
+func (h CommandHandler) plan(op rxrpc.Operation) (string, error) {
+	switch op.Command {
+	case rxrpc.SetLevel:
+		if op.Level > log.LevelDebug {
+			return "", ErrInvalidOperation
+		}
+		return "set log level to " + op.Level.String(), nil
+	case rxrpc.RemoveService, rxrpc.RecycleService:
+		h.daemon.mu.RLock()
+		_, exists := h.daemon.services[op.Service]
+		isolated := h.daemon.configs[op.Service].isolated
+		h.daemon.mu.RUnlock()
+		if !exists {
+			return "", ErrServiceNotFound
+		}
+		if op.Command == rxrpc.RecycleService {
+			if isolated {
+				return "", ErrRecycleIsolated
+			}
+			return "recycle service " + op.Service, nil
+		}
+		return "stop and remove service " + op.Service, nil
+	case rxrpc.Reload:
+		return "reload services", nil
+	case rxrpc.SetTraceSampling:
+		h.daemon.mu.RLock()
+		_, exists := h.daemon.samplers[op.Service]
+		h.daemon.mu.RUnlock()
+		if !exists {
+			return "", ErrServiceNotFound
+		}
+		if op.Rate < 0 || op.Rate > 1 {
+			return "", ErrInvalidOperation
+		}
+		if _, err := traceSamplingFromOperation(op); err != nil {
+			return "", err
+		}
+		return "set trace sampling of service " + op.Service + " to " + strconv.FormatFloat(op.Rate, 'f', -1, 64), nil
+	default:
+		return "", ErrInvalidOperation
+	}
+}
+
+func (h CommandHandler) apply(op rxrpc.Operation) error {
+	switch op.Command {
+	case rxrpc.SetLevel:
+		return h.ChangeLogLevel(op.Level, nil)
+	case rxrpc.RemoveService:
+		return h.daemon.RemoveService(op.Service)
+	case rxrpc.RecycleService:
+		return h.daemon.Recycle(op.Service)
+	case rxrpc.Reload:
+		h.daemon.Reload()
+		return nil
+	case rxrpc.SetTraceSampling:
+		sampling, err := traceSamplingFromOperation(op)
+		if err != nil {
+			return err
+		}
+		return h.daemon.SetTraceSampling(op.Service, sampling)
+	default:
+		return ErrInvalidOperation
+	}
+}
+
+func traceSamplingFromOperation(op rxrpc.Operation) (TraceSampling, error) {
+	sampling := TraceSampling{Rate: op.Rate}
+	for _, name := range op.States {
+		state, ok := ParseState(name)
+		if !ok {
+			return TraceSampling{}, ErrInvalidOperation
+		}
+		sampling.States = append(sampling.States, state)
+	}
+	return sampling, nil
+}
+
 // func (h CommandHandler) Send(payload rxrpc.CommandPayload, reply *rxrpc.CommandResponse) error {
 // 	// retrieve the service's state channel it uses to listen for rxd-specific state transitions.
 // 	// current := s.sw.Current()
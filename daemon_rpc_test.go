@@ -0,0 +1,75 @@
+package rxd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+	rxrpc "github.com/ambitiousfew/rxd/pkg/rpc"
+)
+
+func TestCommandHandler_Batch(t *testing.T) {
+	logger := log.NewLogger(log.LevelInfo, newTestLogger())
+	d := NewDaemon("test-daemon", WithInternalLogger(logger), WithServiceLogger(logger)).(*daemon)
+
+	err := d.AddServices(
+		NewService("a", newMockService(100*time.Millisecond)),
+		NewService("b", newMockService(100*time.Millisecond)),
+	)
+	if err != nil {
+		t.Fatalf("error adding services: %s", err)
+	}
+
+	h := CommandHandler{sLogger: logger, iLogger: logger, daemon: d}
+	hasService := func(name string) bool {
+		d.mu.RLock()
+		defer d.mu.RUnlock()
+		_, ok := d.services[name]
+		return ok
+	}
+
+	// dry run only reports the plan.
+	var resp rxrpc.BatchResponse
+	err = h.Batch(rxrpc.BatchRequest{
+		DryRun: true,
+		Operations: []rxrpc.Operation{
+			{Command: rxrpc.RemoveService, Service: "b"},
+			{Command: rxrpc.SetLevel, Level: log.LevelDebug},
+		},
+	}, &resp)
+	if err != nil {
+		t.Fatalf("error running batch: %s", err)
+	}
+	if resp.Applied || resp.Results[0].Plan != "stop and remove service b" || !hasService("b") {
+		t.Fatalf("expected an unapplied plan, got %+v", resp)
+	}
+
+	// an invalid operation prevents the whole batch from being applied.
+	resp = rxrpc.BatchResponse{}
+	err = h.Batch(rxrpc.BatchRequest{
+		Operations: []rxrpc.Operation{
+			{Command: rxrpc.RemoveService, Service: "b"},
+			{Command: rxrpc.RemoveService, Service: "missing"},
+		},
+	}, &resp)
+	if err != nil {
+		t.Fatalf("error running batch: %s", err)
+	}
+	if resp.Applied || resp.Results[1].Err != ErrServiceNotFound.Error() || !hasService("b") {
+		t.Fatalf("expected the batch to be rejected, got %+v", resp)
+	}
+
+	resp = rxrpc.BatchResponse{}
+	err = h.Batch(rxrpc.BatchRequest{
+		Operations: []rxrpc.Operation{
+			{Command: rxrpc.SetLevel, Level: log.LevelDebug},
+			{Command: rxrpc.RemoveService, Service: "b"},
+		},
+	}, &resp)
+	if err != nil {
+		t.Fatalf("error running batch: %s", err)
+	}
+	if !resp.Applied || hasService("b") {
+		t.Fatalf("expected the batch to be applied, got %+v", resp)
+	}
+}
@@ -0,0 +1,298 @@
+package rxd
+
+import (
+	"context"
+	"runtime"
+	"runtime/pprof"
+	"sync/atomic"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// daemonRuntime holds what service routines share once the daemon has started.
+// It is guarded by the daemon mutex.
+type daemonRuntime struct {
+	ctx       context.Context
+	logC      chan<- DaemonLog
+	stateC    chan<- StateUpdate
+	nameField log.Field
+	active    int                // number of running service routines.
+	closing   bool               // set once no service routines remain, no services can be launched after.
+	idleC     chan struct{}      // closed once no service routines remain.
+	cancel    context.CancelFunc // shuts down the daemon.
+	healthC   chan time.Duration // hands health intervals changed by config reloads to the prober.
+	sched     *scheduler         // hands out the turns of the services, nil without a DeterministicScheduler.
+}
+
+// serviceRun tracks a running service routine so it can be stopped on its own.
+type serviceRun struct {
+	cancel      context.CancelFunc
+	doneC       chan struct{}
+	interrupter *interrupter
+	generation  *serviceGeneration // advanced every time the service enters Init, see RollingRestart.
+}
+
+// launchLocked starts the routine of a registered service, the caller must hold the daemon lock.
+func (d *daemon) launchLocked(name string) {
+	rt := d.rt
+	ds := d.services[name]
+	manager := d.managers[name]
+
+	// the service is not cancelled along with the daemon context directly,
+	// it is cancelled once the services that must stop before it have exited.
+	ctx, cancel := context.WithCancel(context.WithoutCancel(rt.ctx))
+	run := &serviceRun{cancel: cancel, doneC: make(chan struct{}), interrupter: &interrupter{}, generation: &serviceGeneration{}}
+	d.runs[name] = run
+	rt.active++
+	d.unpauseLocked(name)
+
+	go d.stopInOrder(rt.ctx, name, run)
+	if rt.sched != nil && len(d.configs[name].dependsOn) == 0 {
+		// registered before the routine starts, so the order of the turns does not depend on when it does.
+		rt.sched.register(name)
+	}
+	go d.runService(ctx, run, ds, manager, d.configs[name], d.throttles[name], d.samplers[name])
+}
+
+// runService runs a single service according to its manager policy until it exits.
+func (d *daemon) runService(ctx context.Context, run *serviceRun, ds DaemonService, manager ServiceManager, conf serviceConfig, throttle *cpuThrottle, sampler *traceSampler) {
+	rt := d.rt
+	nameField := rt.nameField
+	stateC := rt.stateC
+
+	defer d.serviceExited(run)
+
+	if conf.lockOSThread {
+		// the manager runs the runner lifecycles in this routine, so the service keeps this thread.
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+	}
+
+	// label the service routine so profiles and bridged slog records can identify the service.
+	ctx = pprof.WithLabels(ctx, pprof.Labels(labelService, ds.Name))
+	pprof.SetGoroutineLabels(ctx)
+
+	// with a stop timeout the service reports through a guard, so it can be abandoned if it does not stop in time.
+	svcLogC, svcStateC := rt.logC, rt.stateC
+	var guard *stopGuard
+	if conf.stopTimeout > 0 || d.capsStopTimeout() {
+		guard = newStopGuard(conf.stopTimeout, &d.stopTimeoutCap)
+		svcLogC, svcStateC = guard.logC, guard.stateC
+	}
+
+	var yieldTurn func()
+	if rt.sched != nil {
+		yieldTurn = func() { rt.sched.yield(ds.Name) }
+	}
+
+	generation := run.generation
+	store := &serviceStore{}
+	sctx, scancel := newServiceContextWithCancel(ctx, ds.Name, svcLogC, d.ic, contextConfig{
+		throttle:      throttle,
+		pacer:         newPacer(d.pacing),
+		extractors:    d.extractors,
+		niceness:      d.niceness,
+		preconditions: conf.preconditions,
+		interrupter:   run.interrupter,
+		sampler:       sampler,
+		results:       d.results,
+		restartPolicy: conf.restartPolicy,
+		settings:      conf.settings,
+		stateTimeouts: conf.stateTimeouts,
+		yieldTurn:     yieldTurn,
+		resources:     conf.resources,
+		sockets:       d.sockets,
+		barriers:      d.barriers,
+		quarantine:    d.newQuarantine(ds.Name, conf, svcStateC),
+		values:        d.values,
+		store:         store,
+		watchBuffer:   d.watchBuffer,
+		labels:        d.labels,
+		generation:    generation,
+		emergency:     d.logEmergency,
+	})
+
+	if len(conf.dependencies) > 0 && !conf.isolated {
+		// the breakers hold the service in Idle while a dependency is down, whichever manager runs it.
+		var waitBreakers func()
+		ds.Runner, waitBreakers = watchDependencies(sctx, ds.Runner, run, conf.dependencies)
+		defer waitBreakers()
+	}
+	if d.tracer != nil && !conf.isolated {
+		// lifecycles are traced by wrapping the runner, so every manager records them the same way.
+		ds.Runner = tracedRunner{runner: ds.Runner, sampler: sampler}
+	}
+	if !conf.isolated {
+		// the generation is advanced on every Init, which resets the guards created with OnceValue.
+		ds.Runner = generationRunner{runner: ds.Runner, generation: generation}
+		ds.Runner = errorsRunner{runner: ds.Runner, service: ds.Name, errs: d.lastErrors}
+		ds.Runner = meteredRunner{runner: ds.Runner, meter: conf.usage}
+	}
+	if conf.maxRuns > 0 && !conf.isolated {
+		ds.Runner = expiringRunner{runner: ds.Runner, maxRuns: conf.maxRuns, runs: &atomic.Int64{}, expire: func(reason string) {
+			d.expireService(ds.Name, run, reason)
+		}}
+	}
+	if conf.ttl > 0 {
+		defer d.expireAfter(ds.Name, run, conf.ttl)()
+	}
+	if rt.sched != nil && !conf.isolated {
+		// every lifecycle waits for its turn, whichever manager runs the service.
+		ds.Runner = scheduledRunner{runner: ds.Runner, service: ds.Name, sched: rt.sched}
+	}
+
+	defer func() {
+		// recover from any panics in the service runner
+		// no service should be able to crash the daemon.
+		if r := recover(); r != nil {
+			perr := newPanicError("service "+ds.Name, r)
+			d.serviceLogger.Log(log.LevelError, "recovered from panic", log.String("service", ds.Name), log.Any("error", perr.Value), log.String("stack", string(perr.Stack)))
+			d.internalLogger.Log(log.LevelError, "recovered from panic", append(perr.fields(), log.String("service_name", ds.Name), nameField)...)
+			stateC <- StateUpdate{Name: ds.Name, State: StateExit}
+		}
+		scancel()
+		store.clear()
+		d.internalLogger.Log(log.LevelInfo, "service has stopped", log.String("service_name", ds.Name), nameField)
+	}()
+
+	manage := func() {
+		if len(conf.dependsOn) > 0 && !d.awaitDependencies(sctx, conf.dependsOn) {
+			// the service was stopped before its dependencies were running.
+			svcStateC <- StateUpdate{Name: ds.Name, State: StateExit}
+			return
+		}
+
+		d.internalLogger.Log(log.LevelInfo, "starting service", log.String("service_name", ds.Name), nameField)
+		if rt.sched != nil {
+			// services with dependencies are registered once they run, the scheduler would otherwise await them.
+			rt.sched.register(ds.Name)
+			defer rt.sched.unregister(ds.Name)
+		}
+		if conf.isolated {
+			// the manager policy is applied by the child process.
+			d.superviseIsolated(sctx, ds, svcStateC, svcLogC)
+		} else {
+			// run the service according to the manager policy
+			manager.Manage(sctx, ds, svcStateC)
+		}
+
+		if conf.restartPolicy == RestartExitDaemon && ctx.Err() == nil {
+			// the service exited on its own rather than being stopped.
+			d.internalLogger.Log(log.LevelNotice, "service exited, shutting down the daemon per its restart policy", log.String("service_name", ds.Name), nameField)
+			rt.cancel()
+		}
+	}
+
+	if guard == nil {
+		manage()
+		return
+	}
+
+	if guard.run(sctx.Done(), stateC, rt.logC, manage) {
+		d.internalLogger.Log(log.LevelError, "service did not stop within its stop timeout, abandoning it", log.String("service_name", ds.Name), log.String("timeout", guard.stopTimeout().String()), nameField)
+		d.results.add(ds.Name, ErrShutdownTimeout)
+		stateC <- StateUpdate{Name: ds.Name, State: StateExit}
+	}
+}
+
+// serviceExited marks a service routine as done, once no routines remain the daemon begins its shutdown.
+func (d *daemon) serviceExited(run *serviceRun) {
+	run.cancel()
+	close(run.doneC)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.releaseLocked()
+}
+
+// releaseLocked drops one of the routines keeping the daemon running, the caller must hold the daemon lock.
+func (d *daemon) releaseLocked() {
+	d.rt.active--
+	if d.rt.active == 0 && !d.rt.closing {
+		d.rt.closing = true
+		close(d.rt.idleC)
+	}
+}
+
+// RemoveService gracefully stops a service, waiting for it to exit its lifecycles, then deregisters it.
+// If the daemon has not started yet the service is only deregistered.
+// NOTE: once the last running service exits the daemon shuts down, just as when all services exit on their own.
+func (d *daemon) RemoveService(name string) error {
+	d.mu.Lock()
+	if _, ok := d.services[name]; !ok {
+		d.mu.Unlock()
+		return ErrServiceNotFound
+	}
+	if len(d.dependentsLocked(name)) > 0 {
+		d.mu.Unlock()
+		return ErrServiceHasDependents
+	}
+	run := d.runs[name]
+	d.mu.Unlock()
+
+	if run != nil {
+		run.cancel()
+		<-run.doneC
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.services, name)
+	delete(d.managers, name)
+	delete(d.configs, name)
+	d.serviceHooks.Delete(name)
+	d.lastErrors.remove(name)
+	d.labels.remove(name)
+	delete(d.throttles, name)
+	delete(d.samplers, name)
+	delete(d.runs, name)
+	d.unpauseLocked(name)
+
+	if d.rt != nil && !d.rt.closing {
+		// the states update channel is only closed once closing is set, so this is safe while holding the lock.
+		d.rt.stateC <- StateUpdate{Name: name, removed: true}
+	}
+
+	d.internalLogger.Log(log.LevelInfo, "service removed", log.String("service_name", name), log.String("rxd", d.name))
+	return nil
+}
+
+// RestartService stops a single service, waits for it to exit its lifecycles and launches it again,
+// without touching any other service. A service that has exited is started again.
+// Unlike Recycle the manager of the service is started over, resetting e.g. its backoff.
+// NOTE: services depending on the restarted service keep running while it restarts.
+func (d *daemon) RestartService(name string) error {
+	d.mu.Lock()
+	if _, ok := d.services[name]; !ok {
+		d.mu.Unlock()
+		return ErrServiceNotFound
+	}
+	if d.rt == nil {
+		d.mu.Unlock()
+		return ErrDaemonNotStarted
+	}
+	if d.rt.closing {
+		d.mu.Unlock()
+		return ErrDaemonStopping
+	}
+	// the restart holds the daemon running, it would shut down if the service was the last one running.
+	d.rt.active++
+	run := d.runs[name]
+	d.mu.Unlock()
+
+	defer func() {
+		d.mu.Lock()
+		d.releaseLocked()
+		d.mu.Unlock()
+	}()
+
+	d.internalLogger.Log(log.LevelInfo, "restarting service", log.String("service_name", name), log.String("rxd", d.name))
+	if run != nil {
+		run.cancel()
+		<-run.doneC
+	}
+
+	return d.startService(name)
+}
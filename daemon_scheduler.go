@@ -0,0 +1,314 @@
+package rxd
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// DeterministicScheduler multiplexes the lifecycles of all services onto a single scheduler handing out turns,
+// so races between services can be reproduced and stepped through. Only the service holding the turn runs its
+// lifecycle, the turn is handed back once the lifecycle returns or the service calls Yield, Checkpoint or Throttle.
+// The next turn is given once every other service is waiting for one, in an order drawn from the seed, so the
+// same seed replays the same interleaving of Init, Idle, Run, Stop and Reload calls whatever the timing.
+//
+// Services still run on their own routines, a lifecycle blocking for longer than the step timeout, such as a Run
+// waiting for the daemon to stop, is parked and the other services go on without it. Manager delays longer than
+// the step timeout are only awaited for that long, so keep them short to keep the order deterministic.
+// It is meant for debugging and slows the daemon down, it must not be used in production.
+type DeterministicScheduler struct {
+	Seed        int64                                          // orders the services waiting for a turn.
+	StepTimeout time.Duration                                  // time a turn is held or a service is awaited before the others go on (default: 500ms).
+	Step        <-chan struct{}                                // when set, every turn is only given once a value is received.
+	OnStep      func(step uint64, service string, state State) // called with every turn given, e.g. to record the order.
+}
+
+// turn states of a service routine.
+const (
+	turnBusy    = iota // running between lifecycles, e.g. waiting on a manager delay.
+	turnPending        // waiting for its turn.
+	turnActive         // holding the turn.
+	turnParked         // held the turn for longer than the step timeout.
+)
+
+// scheduler hands out the turns of a DeterministicScheduler from a single routine.
+type scheduler struct {
+	conf     DeterministicScheduler
+	rng      *rand.Rand
+	mu       sync.Mutex
+	routines map[string]*turnState
+	active   bool
+	held     bool // no turns are handed out while the services are launched.
+	step     uint64
+	wakeC    chan struct{}
+	doneC    chan struct{} // closed once turns are no longer handed out.
+	logger   log.Logger
+	field    log.Field
+}
+
+type turnState struct {
+	status int
+	state  State         // lifecycle the service waits to run or runs.
+	since  time.Time     // when the service became busy.
+	grantC chan struct{} // receives the turn.
+	step   uint64        // turn held, to tell a parked turn from a later one.
+}
+
+func newScheduler(conf DeterministicScheduler, logger log.Logger, field log.Field) *scheduler {
+	if conf.StepTimeout <= 0 {
+		conf.StepTimeout = 500 * time.Millisecond
+	}
+
+	return &scheduler{
+		conf:     conf,
+		rng:      rand.New(rand.NewSource(conf.Seed)),
+		routines: make(map[string]*turnState),
+		wakeC:    make(chan struct{}, 1),
+		doneC:    make(chan struct{}),
+		logger:   logger,
+		field:    field,
+	}
+}
+
+// run hands out turns until ctx is done, it must outlive every service routine.
+func (s *scheduler) run(ctx context.Context) {
+	defer close(s.doneC)
+	timer := time.NewTimer(s.conf.StepTimeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wakeC:
+		case <-timer.C:
+		}
+
+		retry := s.dispatch(ctx)
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(retry)
+	}
+}
+
+// dispatch gives the next turn if no service holds it and every service is waiting for one,
+// it returns when it should be tried again at the latest.
+func (s *scheduler) dispatch(ctx context.Context) time.Duration {
+	s.mu.Lock()
+	pending, retry := s.pendingLocked()
+	s.mu.Unlock()
+	if len(pending) == 0 {
+		return retry
+	}
+
+	if s.conf.Step != nil {
+		select {
+		case <-ctx.Done():
+			return retry
+		case <-s.conf.Step:
+		}
+		// services may have come and gone while waiting for the step.
+		s.mu.Lock()
+		pending, retry = s.pendingLocked()
+		s.mu.Unlock()
+		if len(pending) == 0 {
+			return retry
+		}
+	}
+
+	s.mu.Lock()
+	name := pending[s.rng.Intn(len(pending))]
+	t := s.routines[name]
+	s.step++
+	step := s.step
+	t.status, t.step = turnActive, step
+	s.active = true
+	s.mu.Unlock()
+
+	s.logger.Log(log.LevelDebug, "scheduler turn", log.String("step", strconv.FormatUint(step, 10)), log.String("service_name", name), log.String("state", t.state.String()), s.field)
+	if s.conf.OnStep != nil {
+		s.conf.OnStep(step, name, t.state)
+	}
+
+	time.AfterFunc(s.conf.StepTimeout, func() { s.park(name, step) })
+	t.grantC <- struct{}{}
+	return s.conf.StepTimeout
+}
+
+// pendingLocked returns the services waiting for a turn, sorted by name, once none holds the turn and every
+// service busy for less than the step timeout is waiting too. The caller must hold the scheduler lock.
+func (s *scheduler) pendingLocked() ([]string, time.Duration) {
+	retry := s.conf.StepTimeout
+	if s.active || s.held {
+		return nil, retry
+	}
+
+	var pending []string
+	for name, t := range s.routines {
+		switch t.status {
+		case turnPending:
+			pending = append(pending, name)
+		case turnBusy:
+			if waited := time.Since(t.since); waited < s.conf.StepTimeout {
+				// await the service, it may be about to ask for a turn.
+				return nil, s.conf.StepTimeout - waited
+			}
+		}
+	}
+
+	sort.Strings(pending)
+	return pending, retry
+}
+
+// park lets the others go on when the service still holds the turn of step.
+func (s *scheduler) park(name string, step uint64) {
+	s.mu.Lock()
+	t, ok := s.routines[name]
+	parked := ok && t.status == turnActive && t.step == step
+	if parked {
+		t.status = turnParked
+		s.active = false
+	}
+	s.mu.Unlock()
+
+	if parked {
+		s.logger.Log(log.LevelDebug, "scheduler parked a service holding its turn", log.String("service_name", name), log.String("state", t.state.String()), s.field)
+		s.wake()
+	}
+}
+
+func (s *scheduler) wake() {
+	select {
+	case s.wakeC <- struct{}{}:
+	default:
+	}
+}
+
+// hold stops handing out turns until resume is called, so services launched together all take part in the first turn.
+func (s *scheduler) hold() {
+	s.mu.Lock()
+	s.held = true
+	s.mu.Unlock()
+}
+
+func (s *scheduler) resume() {
+	s.mu.Lock()
+	s.held = false
+	s.mu.Unlock()
+	s.wake()
+}
+
+// register adds the routine of a service, it is awaited from now on.
+func (s *scheduler) register(name string) {
+	s.mu.Lock()
+	if _, ok := s.routines[name]; !ok {
+		s.routines[name] = &turnState{status: turnBusy, since: time.Now(), grantC: make(chan struct{}, 1)}
+	}
+	s.mu.Unlock()
+	s.wake()
+}
+
+// unregister removes the routine of a service once it exited.
+func (s *scheduler) unregister(name string) {
+	s.mu.Lock()
+	if t, ok := s.routines[name]; ok && t.status == turnActive {
+		s.active = false
+	}
+	delete(s.routines, name)
+	s.mu.Unlock()
+	s.wake()
+}
+
+// acquire blocks until the service is given the turn to run the lifecycle state.
+func (s *scheduler) acquire(name string, state State) {
+	s.mu.Lock()
+	t, ok := s.routines[name]
+	if !ok || t.status == turnActive || t.status == turnParked {
+		// not scheduled, or the service already holds the turn e.g. when called from Yield within a lifecycle.
+		s.mu.Unlock()
+		return
+	}
+	t.status, t.state = turnPending, state
+	s.mu.Unlock()
+	s.wake()
+
+	select {
+	case <-t.grantC:
+	case <-s.doneC:
+	}
+}
+
+// release hands the turn back, the service is busy until it asks for the next one.
+func (s *scheduler) release(name string) {
+	s.mu.Lock()
+	t, ok := s.routines[name]
+	if ok {
+		if t.status == turnActive {
+			s.active = false
+		}
+		t.status, t.since = turnBusy, time.Now()
+	}
+	s.mu.Unlock()
+	s.wake()
+}
+
+// yield hands the turn back and waits for the next one, within a lifecycle.
+func (s *scheduler) yield(name string) {
+	s.mu.Lock()
+	t, ok := s.routines[name]
+	holding := ok && t.status == turnActive
+	state := StateExit
+	if ok {
+		state = t.state
+	}
+	s.mu.Unlock()
+
+	if holding {
+		s.release(name)
+		s.acquire(name, state)
+	}
+}
+
+// scheduledRunner runs every lifecycle of the runner within a turn of the scheduler.
+type scheduledRunner struct {
+	runner  ServiceRunner
+	service string
+	sched   *scheduler
+}
+
+func (r scheduledRunner) Init(sctx ServiceContext) error {
+	return r.turn(sctx, StateInit, r.runner.Init)
+}
+
+func (r scheduledRunner) Idle(sctx ServiceContext) error {
+	return r.turn(sctx, StateIdle, r.runner.Idle)
+}
+
+func (r scheduledRunner) Run(sctx ServiceContext) error {
+	return r.turn(sctx, StateRun, r.runner.Run)
+}
+
+func (r scheduledRunner) Stop(sctx ServiceContext) error {
+	return r.turn(sctx, StateStop, r.runner.Stop)
+}
+
+func (r scheduledRunner) Reload(sctx ServiceContext) error {
+	return r.turn(sctx, StateReload, func(sctx ServiceContext) error {
+		return reloadRunner(sctx, r.runner)
+	})
+}
+
+func (r scheduledRunner) turn(sctx ServiceContext, state State, lifecycle func(ServiceContext) error) error {
+	r.sched.acquire(r.service, state)
+	defer r.sched.release(r.service)
+	return lifecycle(sctx)
+}
@@ -0,0 +1,150 @@
+package rxd
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// yieldingService yields a few times within Run and counts the lifecycles running at once.
+type yieldingService struct {
+	recordingService
+	running *atomic.Int32
+	overlap *atomic.Bool
+}
+
+func (s *yieldingService) enter() func() {
+	if s.running.Add(1) > 1 {
+		s.overlap.Store(true)
+	}
+	return func() { s.running.Add(-1) }
+}
+
+func (s *yieldingService) Init(sctx ServiceContext) error {
+	defer s.enter()()
+	return nil
+}
+
+func (s *yieldingService) Run(sctx ServiceContext) error {
+	for i := 0; i < 3; i++ {
+		done := s.enter()
+		s.journal.record(s.name + ":tick" + strconv.Itoa(i))
+		done()
+		sctx.Yield()
+	}
+	return nil
+}
+
+func (s *yieldingService) Stop(sctx ServiceContext) error {
+	defer s.enter()()
+	return nil
+}
+
+func TestDaemon_DeterministicScheduler(t *testing.T) {
+	run := func(seed int64) ([]string, []string, bool) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var mu sync.Mutex
+		var steps []string
+		d := NewDaemon("test-daemon",
+			WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+			WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+			WithDeterministicScheduler(DeterministicScheduler{
+				Seed:        seed,
+				StepTimeout: time.Second,
+				OnStep: func(step uint64, service string, state State) {
+					mu.Lock()
+					steps = append(steps, service+":"+state.String())
+					mu.Unlock()
+				},
+			}),
+		)
+
+		journal := &recordingJournal{}
+		running, overlap := &atomic.Int32{}, &atomic.Bool{}
+		for _, name := range []string{"a", "b", "c", "d"} {
+			service := &yieldingService{recordingService{name: name, journal: journal}, running, overlap}
+			if err := d.AddService(NewService(name, service, WithManager(NewRunOnceManager(0)))); err != nil {
+				t.Fatalf("error adding service: %s", err)
+			}
+		}
+
+		if err := d.Start(ctx); err != nil {
+			t.Fatalf("error starting daemon: %s", err)
+		}
+		return steps, journal.entries, overlap.Load()
+	}
+
+	steps, entries, overlap := run(42)
+	if overlap {
+		t.Errorf("expected a single lifecycle to run at a time")
+	}
+	// init, idle, run, 3 yields and stop of 4 services.
+	if len(steps) != 4*7 {
+		t.Errorf("expected 28 turns, got %d: %v", len(steps), steps)
+	}
+
+	replayedSteps, replayedEntries, _ := run(42)
+	if !reflect.DeepEqual(steps, replayedSteps) || !reflect.DeepEqual(entries, replayedEntries) {
+		t.Errorf("expected the same seed to replay the same order, got\n%v\n%v", steps, replayedSteps)
+	}
+
+	var reordered bool
+	for seed := int64(1); seed <= 5 && !reordered; seed++ {
+		other, _, _ := run(seed)
+		reordered = !reflect.DeepEqual(steps, other)
+	}
+	if !reordered {
+		t.Errorf("expected other seeds to interleave the services differently")
+	}
+}
+
+func TestDaemon_DeterministicSchedulerStep(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stepC := make(chan struct{})
+	var turns atomic.Int32
+	d := NewDaemon("test-daemon",
+		WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+		WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+		WithDeterministicScheduler(DeterministicScheduler{
+			Step: stepC,
+			OnStep: func(step uint64, service string, state State) {
+				turns.Add(1)
+			},
+		}),
+	)
+
+	journal := &recordingJournal{}
+	service := &yieldingService{recordingService{name: "a", journal: journal}, &atomic.Int32{}, &atomic.Bool{}}
+	if err := d.AddService(NewService("a", service, WithManager(NewRunOnceManager(0)))); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	for i := 0; i < 2; i++ {
+		stepC <- struct{}{}
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := turns.Load(); got != 2 {
+		t.Errorf("expected a turn for every step, got %d turns after 2 steps", got)
+	}
+
+	// a closed step channel lets the remaining turns go.
+	close(stepC)
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+}
@@ -0,0 +1,125 @@
+package rxd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// shutdownDeadlineKey is the context key the daemon stores its shutdown clock under.
+type shutdownDeadlineKey struct{}
+
+// shutdownClock pins the shutdown deadline the first time the daemon context is seen done.
+// The grace may be replaced by the signal watcher until it cancels the daemon context, see WithSignalShutdown.
+type shutdownClock struct {
+	grace    time.Duration
+	doneC    <-chan struct{}
+	deadline time.Time
+	once     sync.Once
+}
+
+func (c *shutdownClock) Deadline() (time.Time, bool) {
+	if c == nil {
+		return time.Time{}, false
+	}
+
+	select {
+	case <-c.doneC:
+	default:
+		// the daemon is not shutting down.
+		return time.Time{}, false
+	}
+
+	if c.grace <= 0 {
+		return time.Time{}, false
+	}
+
+	c.once.Do(func() {
+		c.deadline = time.Now().Add(c.grace)
+	})
+	return c.deadline, true
+}
+
+// SignalShutdown is how the daemon shuts down once it receives a given signal, see WithSignalShutdown.
+// e.g. a short stop timeout for SIGINT sent interactively, and a long grace to drain on SIGTERM sent by an orchestrator.
+type SignalShutdown struct {
+	// Grace is the shutdown grace reported by ShutdownDeadline, 0 keeps the one set with WithShutdownGrace.
+	// The service manager is asked to wait that long for the daemon to stop, e.g. with EXTEND_TIMEOUT_USEC for systemd.
+	Grace time.Duration
+	// StopTimeout caps the time every service has to exit once stopped before it is abandoned,
+	// 0 keeps the stop timeout of each service, see WithStopTimeout.
+	StopTimeout time.Duration
+}
+
+// timeoutExtender is implemented by notifiers that can ask the service manager to wait longer for the daemon to stop.
+type timeoutExtender interface {
+	extendTimeout(timeout time.Duration) error
+}
+
+// stopSignals returns the signals stopping the daemon, including those given a SignalShutdown.
+func (d *daemon) stopSignals() []os.Signal {
+	signals := append([]os.Signal(nil), d.signals...)
+	for sig := range d.signalShutdowns {
+		watched := false
+		for _, s := range signals {
+			watched = watched || s == sig
+		}
+		if !watched {
+			signals = append(signals, sig)
+		}
+	}
+	return signals
+}
+
+// capsStopTimeout reports whether a signal may cap the stop timeout of the services.
+func (d *daemon) capsStopTimeout() bool {
+	for _, shutdown := range d.signalShutdowns {
+		if shutdown.StopTimeout > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// applySignalShutdown applies the shutdown set for sig with WithSignalShutdown, if any.
+// It must be called before the daemon context is cancelled, the clock is not read until then.
+func (d *daemon) applySignalShutdown(sig os.Signal, clock *shutdownClock, notifier SystemNotifier, nameField log.Field) {
+	shutdown, ok := d.signalShutdowns[sig]
+	if !ok {
+		return
+	}
+
+	if shutdown.Grace > 0 {
+		clock.grace = shutdown.Grace
+	}
+	if shutdown.StopTimeout > 0 {
+		d.stopTimeoutCap.Store(int64(shutdown.StopTimeout))
+	}
+	d.internalLogger.Log(log.LevelNotice, "shutting down on signal", log.String("signal", sig.String()), log.String("grace", clock.grace.String()), log.String("stop_timeout", shutdown.StopTimeout.String()), nameField)
+
+	status := fmt.Sprintf("stopping on %s", sig)
+	if clock.grace > 0 {
+		status = fmt.Sprintf("draining on %s for up to %s", sig, clock.grace)
+		if extender, ok := notifier.(timeoutExtender); ok {
+			if err := extender.extendTimeout(clock.grace); err != nil {
+				d.internalLogger.Log(log.LevelError, "error extending the stop timeout of the service manager", log.Error("error", err), nameField)
+			}
+		}
+	}
+	if err := notifier.NotifyStatus(status); err != nil {
+		d.internalLogger.Log(log.LevelError, "error sending status notification", log.Error("error", err), nameField)
+	}
+}
+
+// ShutdownDeadline returns the time by which services should have finished their cleanup
+// once the daemon has begun shutting down. ok is false while the daemon is running,
+// or when the daemon was not given a shutdown grace period via WithShutdownGrace.
+// Any context derived from a ServiceContext carries the deadline.
+func ShutdownDeadline(ctx context.Context) (deadline time.Time, ok bool) {
+	clock, _ := ctx.Value(shutdownDeadlineKey{}).(*shutdownClock)
+	return clock.Deadline()
+}
@@ -0,0 +1,62 @@
+package rxd
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+func TestDaemon_SignalShutdown(t *testing.T) {
+	d := NewDaemon("test-daemon",
+		WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+		WithShutdownGrace(5*time.Second),
+		WithSignalShutdown(syscall.SIGINT, SignalShutdown{StopTimeout: 100 * time.Millisecond}),
+		WithSignalShutdown(syscall.SIGTERM, SignalShutdown{Grace: time.Minute}),
+		WithSignalShutdown(syscall.SIGQUIT, SignalShutdown{}),
+	).(*daemon)
+
+	if got := len(d.stopSignals()); got != 3 {
+		t.Errorf("expected SIGQUIT to be watched along with SIGINT and SIGTERM, got %d signals", got)
+	}
+	if !d.capsStopTimeout() {
+		t.Errorf("expected SIGINT to cap the stop timeout of the services")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	notifier := &statusNotifier{}
+	clock := &shutdownClock{grace: d.shutdownGrace, doneC: ctx.Done()}
+	d.applySignalShutdown(syscall.SIGTERM, clock, notifier, log.String("rxd", d.name))
+	cancel()
+
+	deadline, ok := clock.Deadline()
+	if !ok || time.Until(deadline) < 55*time.Second {
+		t.Errorf("expected SIGTERM to drain for a minute, got a deadline in %s", time.Until(deadline))
+	}
+	if len(notifier.statuses) != 1 || notifier.statuses[0] != "draining on terminated for up to 1m0s" {
+		t.Errorf("expected a draining status, got %q", notifier.statuses)
+	}
+	if capped := d.stopTimeoutCap.Load(); capped != 0 {
+		t.Errorf("expected SIGTERM to keep the stop timeouts, got %s", time.Duration(capped))
+	}
+
+	d.applySignalShutdown(syscall.SIGINT, &shutdownClock{doneC: ctx.Done()}, notifier, log.String("rxd", d.name))
+	if notifier.statuses[1] != "stopping on interrupt" {
+		t.Errorf("expected a stopping status, got %q", notifier.statuses[1])
+	}
+
+	guard := newStopGuard(0, &d.stopTimeoutCap)
+	if got := guard.stopTimeout(); got != 100*time.Millisecond {
+		t.Errorf("expected SIGINT to cap a service without a stop timeout, got %s", got)
+	}
+	guard = newStopGuard(time.Minute, &d.stopTimeoutCap)
+	if got := guard.stopTimeout(); got != 100*time.Millisecond {
+		t.Errorf("expected SIGINT to cap a longer stop timeout, got %s", got)
+	}
+	guard = newStopGuard(10*time.Millisecond, &d.stopTimeoutCap)
+	if got := guard.stopTimeout(); got != 10*time.Millisecond {
+		t.Errorf("expected a shorter stop timeout to be kept, got %s", got)
+	}
+}
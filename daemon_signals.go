@@ -0,0 +1,28 @@
+//go:build !rxdminimal && !js
+
+package rxd
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchSignals relays the signals stopping (SIGINT, SIGTERM unless others are given) and reloading (SIGHUP)
+// the daemon until stop is called.
+func watchSignals(signals []os.Signal) (stopC, reloadC <-chan os.Signal, stop func()) {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	}
+
+	signalC := make(chan os.Signal, 1)
+	signal.Notify(signalC, signals...)
+
+	hupC := make(chan os.Signal, 1)
+	signal.Notify(hupC, syscall.SIGHUP)
+
+	return signalC, hupC, func() {
+		signal.Stop(signalC)
+		signal.Stop(hupC)
+	}
+}
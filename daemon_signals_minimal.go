@@ -0,0 +1,10 @@
+//go:build rxdminimal || js
+
+package rxd
+
+import "os"
+
+// watchSignals never relays any signal, the daemon is only stopped by cancelling the context given to Start.
+func watchSignals(signals []os.Signal) (stopC, reloadC <-chan os.Signal, stop func()) {
+	return nil, nil, func() {}
+}
@@ -0,0 +1,87 @@
+package rxd
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// startupTracker records the services that made it through their init state since the daemon started.
+type startupTracker struct {
+	mu      sync.Mutex
+	started map[string]struct{}
+}
+
+// transition is only called by the states watcher routine.
+func (t *startupTracker) transition(service string, to State) {
+	if t == nil || (to != StateIdle && to != StateRun) {
+		return
+	}
+	t.mu.Lock()
+	t.started[service] = struct{}{}
+	t.mu.Unlock()
+}
+
+// stuck returns the services of states that have not made it through their init state, sorted by name.
+// Paused services wait on an operator and exited services failed rather than hung, neither is stuck.
+func (t *startupTracker) stuck(states ServiceStates) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var stuck []string
+	for name, state := range states {
+		if _, ok := t.started[name]; ok || state == StatePaused || state == StateExit {
+			continue
+		}
+		stuck = append(stuck, name)
+	}
+	sort.Strings(stuck)
+	return stuck
+}
+
+// awaitStartup logs the services that have not left their init state within the startup timeout
+// and stops the daemon when the timeout aborts startup. The returned func reports the abort once the daemon has stopped.
+func (d *daemon) awaitStartup(ctx context.Context, cancel context.CancelFunc, nameField log.Field) func() error {
+	if d.startupTimeout <= 0 {
+		return func() error { return nil }
+	}
+
+	var timeoutErr atomic.Pointer[ErrStartupTimeout]
+	go func() {
+		timer := time.NewTimer(d.startupTimeout)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		stuck := d.startup.stuck(d.readiness.current())
+		if len(stuck) == 0 {
+			return
+		}
+
+		fields := []log.Field{log.String("timeout", d.startupTimeout.String()), log.String("stuck", strings.Join(stuck, ",")), nameField}
+		if !d.startupAbort {
+			d.internalLogger.Log(log.LevelWarning, "services did not start in time", fields...)
+			return
+		}
+
+		timeoutErr.Store(&ErrStartupTimeout{Timeout: d.startupTimeout, Services: stuck})
+		d.internalLogger.Log(log.LevelError, "services did not start in time, stopping", fields...)
+		cancel()
+	}()
+
+	return func() error {
+		if err := timeoutErr.Load(); err != nil {
+			return *err
+		}
+		return nil
+	}
+}
@@ -0,0 +1,81 @@
+package rxd
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// hangingService never leaves Init until it is stopped.
+type hangingService struct {
+	recordingService
+}
+
+func (s *hangingService) Init(sctx ServiceContext) error {
+	<-sctx.Done()
+	return nil
+}
+
+func TestDaemon_StartupTimeout(t *testing.T) {
+	for _, abort := range []bool{true, false} {
+		name := "log only"
+		if abort {
+			name = "abort"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			journal := &recordingJournal{}
+			d := NewDaemon("test-daemon",
+				UsingStartupTimeout(200*time.Millisecond, abort),
+				WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+				WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+			)
+
+			err := d.AddServices(
+				NewService("running", &recordingService{name: "running", journal: journal}),
+				NewService("hanging", &hangingService{recordingService{name: "hanging", journal: journal}}),
+				NewService("paused", &recordingService{name: "paused", journal: journal}, WithStartPaused()),
+			)
+			if err != nil {
+				t.Fatalf("error adding services: %s", err)
+			}
+
+			if !abort {
+				// without abort the timeout is only logged, the daemon keeps running until stopped.
+				time.AfterFunc(500*time.Millisecond, cancel)
+			}
+
+			start := time.Now()
+			err = d.Start(ctx)
+			elapsed := time.Since(start)
+
+			if !abort {
+				if err != nil {
+					t.Fatalf("expected the daemon to stop cleanly, got %s", err)
+				}
+				if elapsed < 500*time.Millisecond {
+					t.Errorf("expected the daemon to run until stopped, stopped after %s", elapsed)
+				}
+				return
+			}
+
+			var timeoutErr ErrStartupTimeout
+			if !errors.As(err, &timeoutErr) {
+				t.Fatalf("expected %T, got %v", timeoutErr, err)
+			}
+			if !slices.Equal(timeoutErr.Services, []string{"hanging"}) || timeoutErr.Timeout != 200*time.Millisecond {
+				t.Errorf("expected only the hanging service to be reported, got %+v", timeoutErr)
+			}
+			if elapsed > 2*time.Second {
+				t.Errorf("expected the daemon to stop after the startup timeout, took %s", elapsed)
+			}
+		})
+	}
+}
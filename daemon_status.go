@@ -0,0 +1,46 @@
+package rxd
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxStatusServices is the number of services not running named in the status summary, the others are counted.
+const maxStatusServices = 5
+
+// statusSummary describes the states of the services for NotifyStatus, e.g. "7/8 services running; ingest: init (retrying)".
+// Services failing since they last ran, according to ranAt, are marked as retrying. Services that exited without
+// an error are counted as neither running nor named.
+func statusSummary(states ServiceStates, errs map[string]LifecycleError, ranAt map[string]time.Time) string {
+	running := 0
+	var pending []string
+	for name, state := range states {
+		switch state {
+		case StateRun, StateIdle:
+			running++
+			continue
+		case StateExit:
+			if _, failed := errs[name]; !failed {
+				continue
+			}
+		}
+		pending = append(pending, name)
+	}
+	sort.Strings(pending)
+
+	var b strings.Builder
+	b.WriteString(strconv.Itoa(running) + "/" + strconv.Itoa(len(states)) + " services running")
+	for i, name := range pending {
+		if i == maxStatusServices {
+			b.WriteString("; +" + strconv.Itoa(len(pending)-i) + " more")
+			break
+		}
+		b.WriteString("; " + name + ": " + states[name].String())
+		if err, failed := errs[name]; failed && err.Time.After(ranAt[name]) {
+			b.WriteString(" (retrying)")
+		}
+	}
+	return b.String()
+}
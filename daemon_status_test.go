@@ -0,0 +1,73 @@
+package rxd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// statusNotifier records the status summaries it is sent.
+type statusNotifier struct {
+	statuses []string
+}
+
+func (n *statusNotifier) Start(ctx context.Context, logger log.Logger) error { return nil }
+func (n *statusNotifier) Notify(state NotifyState) error                     { return nil }
+
+func (n *statusNotifier) NotifyStatus(msg string) error {
+	n.statuses = append(n.statuses, msg)
+	return nil
+}
+
+func TestStatusSummary(t *testing.T) {
+	now := time.Now()
+	states := ServiceStates{"api": StateRun, "cache": StateIdle, "ingest": StateInit, "migrate": StateExit, "report": StatePaused}
+	errs := map[string]LifecycleError{"ingest": {Service: "ingest", State: StateInit, Time: now, Err: errors.New("connection refused")}}
+
+	got := statusSummary(states, errs, map[string]time.Time{"ingest": now.Add(-time.Minute)})
+	if want := "2/5 services running; ingest: init (retrying); report: paused"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	got = statusSummary(states, errs, map[string]time.Time{"ingest": now.Add(time.Minute)})
+	if want := "2/5 services running; ingest: init; report: paused"; got != want {
+		t.Errorf("expected errors from before the service last ran to be ignored, got %q", got)
+	}
+
+	many := ServiceStates{}
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		many[name] = StateInit
+	}
+	if want := "0/7 services running; a: init; b: init; c: init; d: init; e: init; +2 more"; statusSummary(many, nil, nil) != want {
+		t.Errorf("expected %q, got %q", want, statusSummary(many, nil, nil))
+	}
+}
+
+func TestReadinessTracker_NotifyStatus(t *testing.T) {
+	notifier := &statusNotifier{}
+	errs := newLastErrors()
+	tracker := &readinessTracker{policy: AllOf(), notifier: notifier, errors: errs, logger: log.NewLogger(log.LevelDebug, newTestLogger())}
+
+	tracker.observe(ServiceStates{"api": StateInit, "ingest": StateInit})
+	tracker.observe(ServiceStates{"api": StateRun, "ingest": StateRun})
+	tracker.observe(ServiceStates{"api": StateRun, "ingest": StateRun})
+	errs.record("ingest", StateRun, errors.New("connection refused"))
+	tracker.observe(ServiceStates{"api": StateRun, "ingest": StateInit})
+
+	want := []string{
+		"0/2 services running; api: init; ingest: init",
+		"2/2 services running",
+		"1/2 services running; ingest: init (retrying)",
+	}
+	if len(notifier.statuses) != len(want) {
+		t.Fatalf("expected a status for every change %q, got %q", want, notifier.statuses)
+	}
+	for i := range want {
+		if notifier.statuses[i] != want[i] {
+			t.Errorf("expected status %q, got %q", want[i], notifier.statuses[i])
+		}
+	}
+}
@@ -2,7 +2,9 @@ package rxd
 
 import (
 	"context"
+	"errors"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -58,6 +60,262 @@ func TestDaemon_AddServices(t *testing.T) {
 	}
 }
 
+func TestNewDaemonWithLogger(t *testing.T) {
+	logger := log.NewLogger(log.LevelInfo, newTestLogger())
+	// the options given act on the logger, they must not write past the slice of the caller either.
+	options := make([]DaemonOption, 1, 2)
+	options[0] = WithServiceLogLevel(log.LevelDebug)
+	spare := options[:2]
+
+	d := NewDaemonWithLogger("test-daemon", logger, options...).(*daemon)
+	if d.serviceLogger != logger {
+		t.Fatalf("expected the given logger to be the service logger")
+	}
+	if level := logger.(leveler).Level(); level != log.LevelDebug {
+		t.Errorf("expected the service log level option to set the given logger to debug, got %s", level)
+	}
+	if spare[1] != nil {
+		t.Errorf("expected the options of the caller to be left untouched")
+	}
+}
+
+func TestDaemon_AddRemoveServiceAtRuntime(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	d := NewDaemon("test-daemon", WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())), WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())))
+
+	err := d.AddService(NewService("test-service-1", newMockService(100*time.Millisecond)))
+	if err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	// wait for the daemon to launch its services.
+	for {
+		dm := d.(*daemon)
+		dm.mu.RLock()
+		running := dm.rt != nil
+		dm.mu.RUnlock()
+		if running {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	err = d.AddService(NewService("test-service-2", newMockService(100*time.Millisecond)))
+	if err != nil {
+		t.Fatalf("error adding service at runtime: %s", err)
+	}
+
+	err = d.AddService(NewService("test-service-2", newMockService(100*time.Millisecond)))
+	if err != ErrDuplicateServiceName {
+		t.Fatalf("expected %s, got %v", ErrDuplicateServiceName, err)
+	}
+
+	if err := d.RemoveService("test-service-2"); err != nil {
+		t.Fatalf("error removing service: %s", err)
+	}
+
+	if err := d.RemoveService("test-service-2"); err != ErrServiceNotFound {
+		t.Fatalf("expected %s, got %v", ErrServiceNotFound, err)
+	}
+
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+}
+
+func TestDaemon_DependencyOrder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	journal := &recordingJournal{}
+	d := NewDaemon("test-daemon", WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())), WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())))
+
+	err := d.AddServices(
+		NewService("frontend", &recordingService{name: "frontend", journal: journal}, WithDependsOn("database")),
+		NewService("database", &recordingService{name: "database", journal: journal}),
+	)
+	if err != nil {
+		t.Fatalf("error adding services: %s", err)
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	for journal.index("frontend:run") < 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+
+	if journal.index("database:init") > journal.index("frontend:init") {
+		t.Errorf("expected database to start before frontend, got %v", journal.entries)
+	}
+
+	if journal.index("frontend:stop") > journal.index("database:stop") {
+		t.Errorf("expected frontend to stop before database, got %v", journal.entries)
+	}
+}
+
+func TestDaemon_DependencyCycle(t *testing.T) {
+	d := NewDaemon("test-daemon", WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())))
+
+	err := d.AddServices(
+		NewService("a", newMockService(100*time.Millisecond), WithDependsOn("b")),
+		NewService("b", newMockService(100*time.Millisecond), WithDependsOn("a")),
+	)
+	if err != nil {
+		t.Fatalf("error adding services: %s", err)
+	}
+
+	if err := d.Start(context.Background()); err != ErrDependencyCycle {
+		t.Fatalf("expected %s, got %v", ErrDependencyCycle, err)
+	}
+}
+
+func TestDaemon_ShutdownOrder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	journal := &recordingJournal{}
+	d := NewDaemon("test-daemon", UsingShutdownOrder("first", "second"), WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())), WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())))
+
+	err := d.AddServices(
+		NewService("last", &recordingService{name: "last", journal: journal}),
+		NewService("second", &recordingService{name: "second", journal: journal}),
+		NewService("first", &recordingService{name: "first", journal: journal}),
+	)
+	if err != nil {
+		t.Fatalf("error adding services: %s", err)
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	for journal.index("first:run") < 0 || journal.index("second:run") < 0 || journal.index("last:run") < 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+
+	if !(journal.index("first:stop") < journal.index("second:stop") && journal.index("second:stop") < journal.index("last:stop")) {
+		t.Errorf("expected services to stop in order first, second, last, got %v", journal.entries)
+	}
+}
+
+func TestDaemon_ShutdownOrderConflict(t *testing.T) {
+	d := NewDaemon("test-daemon", UsingShutdownOrder("database", "frontend"), WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())))
+
+	err := d.AddServices(
+		NewService("frontend", newMockService(100*time.Millisecond), WithDependsOn("database")),
+		NewService("database", newMockService(100*time.Millisecond)),
+	)
+	if err != nil {
+		t.Fatalf("error adding services: %s", err)
+	}
+
+	if err := d.Start(context.Background()); err != ErrShutdownOrderConflict {
+		t.Fatalf("expected %s, got %v", ErrShutdownOrderConflict, err)
+	}
+}
+
+func TestDaemon_Recycle(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	journal := &recordingJournal{}
+	runner := &recordingService{name: "cache", journal: journal}
+	d := NewDaemon("test-daemon", WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())), WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())))
+
+	if err := d.AddService(NewService("cache", runner)); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	for journal.count("cache:run") < 1 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := d.Recycle("cache"); err != nil {
+		t.Fatalf("error recycling service: %s", err)
+	}
+
+	for journal.count("cache:run") < 2 {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for the service to run again, got %v", journal.entries)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	if journal.count("cache:stop") != 1 || journal.count("cache:init") != 2 {
+		t.Errorf("expected a single stop and init cycle, got %v", journal.entries)
+	}
+
+	if err := d.Recycle("unknown"); err != ErrServiceNotFound {
+		t.Errorf("expected %s, got %v", ErrServiceNotFound, err)
+	}
+
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+}
+
+func TestDaemon_StopTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	journal := &recordingJournal{}
+	d := NewDaemon("test-daemon", WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())), WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())))
+
+	wedged := &wedgedService{recordingService{name: "wedged", journal: journal}}
+	if err := d.AddService(NewService("wedged", wedged, WithStopTimeout(100*time.Millisecond))); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	for journal.index("wedged:run") < 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case err := <-errC:
+		var serviceErr ServiceError
+		if !errors.As(err, &serviceErr) || serviceErr.Service != "wedged" || !errors.Is(err, ErrShutdownTimeout) {
+			t.Fatalf("expected the wedged service to fail with %s, got %v", ErrShutdownTimeout, err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("daemon did not finish with a wedged service stop")
+	}
+}
+
 func TestDaemon_PanicService(t *testing.T) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
@@ -91,3 +349,318 @@ func TestDaemon_PanicService(t *testing.T) {
 	}
 
 }
+
+// failingService fails its Run with the given error.
+type failingService struct {
+	recordingService
+	err error
+}
+
+func (f *failingService) Run(sctx ServiceContext) error {
+	f.journal.record(f.name + ":run")
+	return f.err
+}
+
+func TestDaemon_RunOnceResults(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	journal := &recordingJournal{}
+	errMigrate := errors.New("migration failed")
+	d := NewDaemon("test-daemon", WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())), WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())))
+
+	err := d.AddServices(
+		NewService("seed", &failingService{recordingService{name: "seed", journal: journal}, nil}, WithManager(NewRunOnceManager(0))),
+		NewService("migrate", &failingService{recordingService{name: "migrate", journal: journal}, errMigrate}, WithManager(NewRunOnceManager(0))),
+	)
+	if err != nil {
+		t.Fatalf("error adding services: %s", err)
+	}
+
+	// the daemon exits on its own once every service ran once.
+	err = d.Start(ctx)
+	if !errors.Is(err, errMigrate) {
+		t.Fatalf("expected %s, got %v", errMigrate, err)
+	}
+
+	var serr ServiceError
+	if !errors.As(err, &serr) || serr.Service != "migrate" {
+		t.Errorf("expected a service error for migrate, got %v", err)
+	}
+
+	results := d.Results()
+	if len(results) != 2 || results["seed"] != nil || !errors.Is(results["migrate"], errMigrate) {
+		t.Errorf("unexpected results %v", results)
+	}
+
+	for _, name := range []string{"seed", "migrate"} {
+		if journal.count(name+":run") != 1 || journal.count(name+":stop") != 1 {
+			t.Errorf("expected %s to run and stop once, got %v", name, journal.entries)
+		}
+	}
+}
+
+func TestDaemon_ExternalUnits(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var active atomic.Bool
+	source := UnitSourceFunc(func(ctx context.Context, units []string) (map[string]string, error) {
+		states := make(map[string]string, len(units))
+		for _, unit := range units {
+			states[unit] = "inactive"
+			if active.Load() {
+				states[unit] = "active"
+			}
+		}
+		return states, nil
+	})
+
+	journal := &recordingJournal{}
+	d := NewDaemon("test-daemon",
+		WithExternalUnits(10*time.Millisecond, "postgresql.service"),
+		WithUnitSource(source),
+		WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+		WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+	)
+
+	err := d.AddService(NewService("api", &recordingService{name: "api", journal: journal}, WithDependsOn("postgresql.service")))
+	if err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	if err := d.AddService(NewService("postgresql.service", &recordingService{name: "db", journal: journal})); err != ErrDuplicateServiceName {
+		t.Errorf("expected %s, got %v", ErrDuplicateServiceName, err)
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	if journal.count("api:init") != 0 {
+		t.Fatalf("expected api to wait for the unit, got %v", journal.entries)
+	}
+
+	active.Store(true)
+	for journal.count("api:run") < 1 {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for api to run once the unit is active")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+}
+
+// sleepyService returns from Run on its own after the given duration.
+type sleepyService struct {
+	recordingService
+	d time.Duration
+}
+
+func (s *sleepyService) Run(sctx ServiceContext) error {
+	s.journal.record(s.name + ":run")
+	select {
+	case <-sctx.Done():
+	case <-time.After(s.d):
+	}
+	return nil
+}
+
+func TestDaemon_RestartPolicy(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	journal := &recordingJournal{}
+	d := NewDaemon("test-daemon", WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())), WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())))
+
+	err := d.AddServices(
+		NewService("oneshot", &failingService{recordingService{name: "oneshot", journal: journal}, nil},
+			WithManager(NewDefaultManager()), WithRestartPolicy(RestartOnFailure)),
+		NewService("flaky", &failingService{recordingService{name: "flaky", journal: journal}, errors.New("flaky")},
+			WithManager(NewDefaultManager()), WithRestartPolicy(RestartOnFailure)),
+		NewService("leader", &sleepyService{recordingService{name: "leader", journal: journal}, 500 * time.Millisecond},
+			WithManager(NewDefaultManager()), WithRestartPolicy(RestartExitDaemon)),
+	)
+	if err != nil {
+		t.Fatalf("error adding services: %s", err)
+	}
+
+	// the daemon shuts down on its own once the leader returns from run.
+	if err := d.Start(ctx); err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("expected the leader to shut down the daemon")
+	}
+
+	if journal.count("oneshot:run") != 1 || journal.count("oneshot:stop") != 1 {
+		t.Errorf("expected oneshot to run and stop once, got %v", journal.entries)
+	}
+	if journal.count("flaky:run") < 2 {
+		t.Errorf("expected flaky to be restarted, got %v", journal.entries)
+	}
+	if journal.count("leader:run") != 1 {
+		t.Errorf("expected leader to run once, got %v", journal.entries)
+	}
+}
+
+func TestDaemon_Hooks(t *testing.T) {
+	journal := &recordingJournal{}
+	hook := func(entry string, err error) Hook {
+		return func(ctx context.Context) error {
+			journal.record(entry)
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	d := NewDaemon("test-daemon",
+		UsingPreStartHook(hook("pidlock", nil)),
+		UsingPreStartHook(hook("socket", nil)),
+		UsingPostStopHook(hook("unlock", nil)),
+		UsingPostStopHook(hook("close-socket", nil)),
+		WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+		WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+	)
+	if err := d.AddService(NewService("once", &failingService{recordingService{name: "once", journal: journal}, nil}, WithManager(NewRunOnceManager(0)))); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	if err := d.Start(ctx); err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+
+	want := []string{"pidlock", "socket", "once:init", "once:run", "once:stop", "close-socket", "unlock"}
+	if strings.Join(journal.entries, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, journal.entries)
+	}
+
+	// a failing pre-start hook aborts startup, the post-stop hooks still run.
+	journal = &recordingJournal{}
+	errLocked := errors.New("pid file is locked")
+	d = NewDaemon("test-daemon",
+		UsingPreStartHook(hook("pidlock", errLocked)),
+		UsingPreStartHook(hook("socket", nil)),
+		UsingPostStopHook(hook("unlock", nil)),
+		WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+		WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+	)
+	if err := d.AddService(NewService("once", &failingService{recordingService{name: "once", journal: journal}, nil}, WithManager(NewRunOnceManager(0)))); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	if err := d.Start(ctx); !errors.Is(err, errLocked) {
+		t.Fatalf("expected %s, got %v", errLocked, err)
+	}
+
+	want = []string{"pidlock", "unlock"}
+	if strings.Join(journal.entries, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, journal.entries)
+	}
+}
+
+func TestDaemon_RestartService(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	journal := &recordingJournal{}
+	d := NewDaemon("test-daemon", WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())), WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())))
+
+	if err := d.RestartService("cache"); err != ErrServiceNotFound {
+		t.Errorf("expected %s, got %v", ErrServiceNotFound, err)
+	}
+	// the only service of the daemon, restarting it must not shut the daemon down.
+	if err := d.AddService(NewService("cache", &recordingService{name: "cache", journal: journal})); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+	if err := d.RestartService("cache"); err != ErrDaemonNotStarted {
+		t.Errorf("expected %s, got %v", ErrDaemonNotStarted, err)
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	for journal.count("cache:run") < 1 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := d.RestartService("cache"); err != nil {
+		t.Fatalf("error restarting service: %s", err)
+	}
+
+	for journal.count("cache:run") < 2 {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for the service to run again, got %v", journal.entries)
+		case <-errC:
+			t.Fatalf("daemon stopped while restarting its only service")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	if journal.count("cache:stop") != 1 || journal.count("cache:init") != 2 {
+		t.Errorf("expected a single stop and init cycle, got %v", journal.entries)
+	}
+
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+}
+
+func TestDaemon_TransitionHooks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var daemonTransitions, serviceTransitions []string
+	var published atomic.Bool
+	var d *daemon
+	d = NewDaemon("test-daemon",
+		UsingTransitionHook(func(service string, from, to State) {
+			daemonTransitions = append(daemonTransitions, service+":"+from.String()+">"+to.String())
+			// hooks run before the new state is published.
+			if d.readiness.current()[service] == to && from != to {
+				published.Store(true)
+			}
+		}),
+		UsingTransitionHook(func(service string, from, to State) {
+			panic("hooks must not stop the states watcher")
+		}),
+		WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+		WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+	).(*daemon)
+
+	runner := &failingService{recordingService{name: "once", journal: &recordingJournal{}}, nil}
+	err := d.AddService(NewService("once", runner, WithManager(NewRunOnceManager(0)), WithTransitionHook(func(service string, from, to State) {
+		serviceTransitions = append(serviceTransitions, from.String()+">"+to.String())
+	})))
+	if err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	if err := d.Start(ctx); err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+
+	want := []string{"exit>init", "init>idle", "idle>run", "run>stop", "stop>exit"}
+	if strings.Join(serviceTransitions, ",") != strings.Join(want, ",") {
+		t.Errorf("expected service transitions %v, got %v", want, serviceTransitions)
+	}
+	if len(daemonTransitions) != len(want) || daemonTransitions[0] != "once:exit>init" {
+		t.Errorf("expected daemon transitions of once, got %v", daemonTransitions)
+	}
+	if published.Load() {
+		t.Errorf("expected hooks to run before the states are published")
+	}
+}
@@ -0,0 +1,148 @@
+package rxd
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"sync/atomic"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// Tracer is implemented by tracing integrations, e.g. an OpenTelemetry adapter, to record the spans of services.
+type Tracer interface {
+	StartSpan(ctx context.Context, name string, fields ...log.Field) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	End(err error)
+}
+
+// noopTracer is handed to services by sctx.Tracer() when the daemon has no tracer, its spans record nothing.
+type noopTracer struct{}
+
+func (noopTracer) StartSpan(ctx context.Context, name string, fields ...log.Field) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) End(err error) {}
+
+// TraceSampling controls which spans of a service are recorded once the daemon has a tracer.
+type TraceSampling struct {
+	// Rate is the fraction (0-1) of spans started with StartSpan that are recorded, e.g. per Run iteration.
+	Rate float64
+	// States lists the lifecycle states whose spans are recorded, nil records every state.
+	States []State
+}
+
+// defaultTraceSampling records every lifecycle and every helper span.
+var defaultTraceSampling = TraceSampling{Rate: 1}
+
+// traceSampler applies the sampling of a single service, the sampling can be swapped at runtime.
+type traceSampler struct {
+	tracer   Tracer
+	sampling atomic.Pointer[TraceSampling]
+}
+
+func newTraceSampler(tracer Tracer, sampling *TraceSampling) *traceSampler {
+	s := &traceSampler{tracer: tracer}
+	if sampling == nil {
+		sampling = &defaultTraceSampling
+	}
+	s.sampling.Store(sampling)
+	return s
+}
+
+func (s *traceSampler) traces(state State) bool {
+	if s == nil || s.tracer == nil {
+		return false
+	}
+	states := s.sampling.Load().States
+	return states == nil || slices.Contains(states, state)
+}
+
+func (s *traceSampler) sampled() bool {
+	if s == nil || s.tracer == nil {
+		return false
+	}
+	rate := s.sampling.Load().Rate
+	return rate >= 1 || (rate > 0 && rand.Float64() < rate)
+}
+
+// StartSpan starts a span for a unit of work of the service, e.g. an iteration of its Run loop.
+// Spans are sampled at the rate of the service sampling, when the span is not sampled or the daemon
+// has no tracer the given context is returned as is. The returned func ends the span with the result of the work.
+func StartSpan(sctx ServiceContext, name string, fields ...log.Field) (ServiceContext, func(err error)) {
+	sc, ok := sctx.(*serviceContext)
+	if !ok || !sc.sampler.sampled() {
+		return sctx, func(error) {}
+	}
+
+	spanCtx, span := sc.sampler.tracer.StartSpan(sc.Context, name, append(fields, log.String("service", sc.name))...)
+	child, cancel := sc.WithParent(spanCtx)
+	return child, func(err error) {
+		span.End(err)
+		cancel()
+	}
+}
+
+// tracedRunner records a span around every lifecycle of the runner whose state is sampled.
+type tracedRunner struct {
+	runner  ServiceRunner
+	sampler *traceSampler
+}
+
+func (r tracedRunner) trace(sctx ServiceContext, state State, lifecycle func(ServiceContext) error) error {
+	sc, ok := sctx.(*serviceContext)
+	if !ok || !r.sampler.traces(state) {
+		return lifecycle(sctx)
+	}
+
+	spanCtx, span := r.sampler.tracer.StartSpan(sc.Context, "rxd."+state.String(), log.String("service", sc.name), log.String("state", state.String()))
+	child, cancel := sc.WithParent(spanCtx)
+	defer cancel()
+
+	err := lifecycle(child)
+	span.End(err)
+	return err
+}
+
+func (r tracedRunner) Init(sctx ServiceContext) error {
+	return r.trace(sctx, StateInit, r.runner.Init)
+}
+
+func (r tracedRunner) Idle(sctx ServiceContext) error {
+	return r.trace(sctx, StateIdle, r.runner.Idle)
+}
+
+func (r tracedRunner) Run(sctx ServiceContext) error {
+	return r.trace(sctx, StateRun, r.runner.Run)
+}
+
+func (r tracedRunner) Stop(sctx ServiceContext) error {
+	return r.trace(sctx, StateStop, r.runner.Stop)
+}
+
+func (r tracedRunner) Reload(sctx ServiceContext) error {
+	return r.trace(sctx, StateReload, func(sctx ServiceContext) error {
+		return reloadRunner(sctx, r.runner)
+	})
+}
+
+// SetTraceSampling changes the trace sampling of a service at runtime.
+func (d *daemon) SetTraceSampling(name string, sampling TraceSampling) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	sampler, ok := d.samplers[name]
+	if !ok {
+		return ErrServiceNotFound
+	}
+
+	sampler.sampling.Store(&sampling)
+	d.internalLogger.Log(log.LevelInfo, "trace sampling changed", log.String("service_name", name), log.Float("rate", sampling.Rate), log.String("rxd", d.name))
+	return nil
+}
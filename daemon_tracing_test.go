@@ -0,0 +1,136 @@
+package rxd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+type testTracer struct {
+	mu     sync.Mutex
+	spans  []string
+	fields [][]log.Field
+	errs   []error
+}
+
+type testSpan struct {
+	tracer *testTracer
+}
+
+func (s testSpan) End(err error) {
+	s.tracer.mu.Lock()
+	s.tracer.errs = append(s.tracer.errs, err)
+	s.tracer.mu.Unlock()
+}
+
+func (t *testTracer) StartSpan(ctx context.Context, name string, fields ...log.Field) (context.Context, Span) {
+	t.mu.Lock()
+	t.spans = append(t.spans, name)
+	t.fields = append(t.fields, fields)
+	t.mu.Unlock()
+	return ctx, testSpan{tracer: t}
+}
+
+func (t *testTracer) count(name string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var n int
+	for _, span := range t.spans {
+		if span == name {
+			n++
+		}
+	}
+	return n
+}
+
+func TestTraceSampling(t *testing.T) {
+	tracer := &testTracer{}
+	d := NewDaemon("test-daemon", WithTracer(tracer), WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger()))).(*daemon)
+
+	err := d.AddService(NewService("traced", newMockService(time.Millisecond), WithTraceSampling(TraceSampling{
+		Rate:   0,
+		States: []State{StateInit, StateStop},
+	})))
+	if err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	sampler := d.samplers["traced"]
+	sctx, cancel := newServiceContextWithCancel(context.Background(), "traced", make(chan DaemonLog, 100), nil, contextConfig{sampler: sampler})
+	defer cancel()
+
+	runner := tracedRunner{runner: &recordingService{name: "traced", journal: &recordingJournal{}}, sampler: sampler}
+	runner.Init(sctx)
+	runner.Stop(sctx)
+	if tracer.count("rxd.init") != 1 || tracer.count("rxd.stop") != 1 || tracer.count("rxd.idle") != 0 {
+		t.Fatalf("expected only init and stop spans, got %v", tracer.spans)
+	}
+
+	for i := 0; i < 10; i++ {
+		_, end := StartSpan(sctx, "iteration")
+		end(nil)
+	}
+	if n := tracer.count("iteration"); n != 0 {
+		t.Fatalf("expected no sampled iterations at rate 0, got %d", n)
+	}
+
+	if err := d.SetTraceSampling("traced", TraceSampling{Rate: 1}); err != nil {
+		t.Fatalf("error setting trace sampling: %s", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		_, end := StartSpan(sctx, "iteration")
+		end(nil)
+	}
+	runner.Idle(sctx)
+	if tracer.count("iteration") != 10 || tracer.count("rxd.idle") != 1 {
+		t.Fatalf("expected every span to be recorded after the change, got %v", tracer.spans)
+	}
+
+	if err := d.SetTraceSampling("missing", TraceSampling{}); err != ErrServiceNotFound {
+		t.Fatalf("expected %s, got %v", ErrServiceNotFound, err)
+	}
+}
+
+// failingInit fails its init.
+type failingInit struct {
+	recordingService
+	err error
+}
+
+func (f *failingInit) Init(sctx ServiceContext) error {
+	return f.err
+}
+
+func TestServiceContext_Tracer(t *testing.T) {
+	sctx, cancel := newServiceContextWithCancel(context.Background(), "untraced", make(chan DaemonLog, 100), nil, contextConfig{})
+	defer cancel()
+	_, span := sctx.Tracer().StartSpan(sctx, "query")
+	span.End(nil)
+
+	tracer := &testTracer{}
+	sampler := newTraceSampler(tracer, nil)
+	sctx, cancel = newServiceContextWithCancel(context.Background(), "traced", make(chan DaemonLog, 100), nil, contextConfig{sampler: sampler})
+	defer cancel()
+	if sctx.Tracer() != Tracer(tracer) {
+		t.Fatalf("expected the tracer of the daemon")
+	}
+
+	errInit := errors.New("connection refused")
+	runner := tracedRunner{runner: &failingInit{err: errInit}, sampler: sampler}
+	runner.Init(sctx)
+	if len(tracer.spans) != 1 || tracer.spans[0] != "rxd.init" || tracer.errs[0] != errInit {
+		t.Fatalf("expected an init span ending with the error of init, got %v %v", tracer.spans, tracer.errs)
+	}
+	fields := map[string]string{}
+	for _, field := range tracer.fields[0] {
+		fields[field.Key] = field.Value
+	}
+	if fields["service"] != "traced" || fields["state"] != "init" {
+		t.Errorf("expected the span to carry the service and its state, got %v", tracer.fields[0])
+	}
+}
@@ -0,0 +1,40 @@
+package rxd
+
+import "github.com/ambitiousfew/rxd/log"
+
+// TransitionFunc is called with every state transition of a service, e.g. to emit audit events or metrics.
+// It runs synchronously in the states watcher before the new state is published, so it must return quickly.
+type TransitionFunc func(service string, from, to State)
+
+// transition calls the daemon level hooks and those of the service when its state changes.
+// Only called by the states watcher routine.
+func (d *daemon) transition(service string, from, to State) {
+	if from == to {
+		return
+	}
+	d.startup.transition(service, to)
+	err := d.recordTransition(service, from, to)
+	d.webhookTransition(service, from, to, err)
+
+	for _, hook := range d.transitionHooks {
+		d.callTransitionHook(hook, service, from, to)
+	}
+
+	// the daemon lock is not taken, services are removed while holding it and sending their removal to the watcher.
+	if hooks, ok := d.serviceHooks.Load(service); ok {
+		for _, hook := range hooks.([]TransitionFunc) {
+			d.callTransitionHook(hook, service, from, to)
+		}
+	}
+}
+
+// callTransitionHook recovers from a panicking hook, which must not stop the states watcher.
+func (d *daemon) callTransitionHook(hook TransitionFunc, service string, from, to State) {
+	defer func() {
+		if r := recover(); r != nil {
+			perr := newPanicError("transition hook", r)
+			d.internalLogger.Log(log.LevelError, "recovered from panic in transition hook", append(perr.fields(), log.String("service_name", service), log.String("rxd", d.name))...)
+		}
+	}()
+	hook(service, from, to)
+}
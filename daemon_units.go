@@ -0,0 +1,87 @@
+package rxd
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// UnitSource reports the state of units managed by the host, such as systemd units, which are
+// not services of the daemon. States are the systemd ActiveState values e.g. "active" or "failed".
+type UnitSource interface {
+	ActiveStates(ctx context.Context, units []string) (map[string]string, error)
+}
+
+// UnitSourceFunc is a function that implements UnitSource.
+type UnitSourceFunc func(ctx context.Context, units []string) (map[string]string, error)
+
+func (f UnitSourceFunc) ActiveStates(ctx context.Context, units []string) (map[string]string, error) {
+	return f(ctx, units)
+}
+
+// unitState maps the ActiveState of a unit to the state its virtual service is published with.
+func unitState(activeState string) State {
+	switch activeState {
+	case "active":
+		return StateRun
+	case "reloading":
+		return StateReload
+	case "activating":
+		return StateInit
+	case "deactivating":
+		return StateStop
+	default:
+		// inactive, failed, maintenance or a unit the host does not know.
+		return StateExit
+	}
+}
+
+// isUnitLocked reports whether name is an external unit watched by the daemon, the caller must hold the daemon lock.
+func (d *daemon) isUnitLocked(name string) bool {
+	return slices.Contains(d.units, name)
+}
+
+// watchUnits polls the unit source every interval until ctx is done,
+// publishing the state of every external unit as a virtual service whenever it changes.
+func (d *daemon) watchUnits(ctx context.Context, stateC chan<- StateUpdate) <-chan struct{} {
+	doneC := make(chan struct{})
+	if len(d.units) == 0 {
+		close(doneC)
+		return doneC
+	}
+
+	go func() {
+		defer close(doneC)
+
+		ticker := time.NewTicker(d.unitInterval)
+		defer ticker.Stop()
+
+		known := make(map[string]State, len(d.units))
+		for {
+			states, err := d.unitSource.ActiveStates(ctx, d.units)
+			if err != nil {
+				// the last known states are kept until the source recovers.
+				d.internalLogger.Log(log.LevelError, "error reading the state of external units", log.Error("error", err), log.String("rxd", d.name))
+			} else {
+				for _, unit := range d.units {
+					state := unitState(states[unit])
+					if current, ok := known[unit]; ok && current == state {
+						continue
+					}
+					known[unit] = state
+					stateC <- StateUpdate{Name: unit, State: state}
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return doneC
+}
@@ -0,0 +1,16 @@
+//go:build !linux || rxdminimal
+
+package rxd
+
+import (
+	"context"
+	"errors"
+)
+
+// defaultUnitSource reports every poll as unsupported, the units are published as exited.
+// Set a source with WithUnitSource to watch external units on other platforms.
+func defaultUnitSource() UnitSource {
+	return UnitSourceFunc(func(ctx context.Context, units []string) (map[string]string, error) {
+		return nil, errors.ErrUnsupported
+	})
+}
@@ -0,0 +1,45 @@
+//go:build linux && !rxdminimal
+
+package rxd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+)
+
+// SystemdUnitSource reads the ActiveState of systemd units with systemctl.
+// It is the default source of the units watched with WithExternalUnits on linux.
+type SystemdUnitSource struct {
+	// User queries the user service manager instead of the system one.
+	User bool
+}
+
+func (s SystemdUnitSource) ActiveStates(ctx context.Context, units []string) (map[string]string, error) {
+	states := make(map[string]string, len(units))
+	for _, unit := range units {
+		args := []string{"show", "--property=ActiveState", unit}
+		if s.User {
+			args = append([]string{"--user"}, args...)
+		}
+
+		out, err := exec.CommandContext(ctx, "systemctl", args...).Output()
+		if err != nil {
+			return nil, err
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(out))
+		for scanner.Scan() {
+			if value, ok := strings.CutPrefix(scanner.Text(), "ActiveState="); ok {
+				states[unit] = value
+			}
+		}
+	}
+	return states, nil
+}
+
+func defaultUnitSource() UnitSource {
+	return SystemdUnitSource{}
+}
@@ -0,0 +1,50 @@
+package rxd
+
+import (
+	"sort"
+	"time"
+)
+
+// watchdogAlive reports whether the watchdog keep-alive should be sent, an error names the critical service
+// that is wedged or unhealthy. Keep-alives are always sent until the daemon is ready, the service manager
+// bounds the startup on its own.
+func (d *daemon) watchdogAlive() error {
+	select {
+	case <-d.readiness.readyC:
+	default:
+		return nil
+	}
+
+	states := d.readiness.current()
+	health := d.health.get()
+
+	d.mu.RLock()
+	critical := make([]string, 0, len(d.configs))
+	for name, conf := range d.configs {
+		if conf.critical {
+			critical = append(critical, name)
+		}
+	}
+	// a healthy check older than this means the prober is stuck on the service.
+	stale := 2*d.healthInterval + d.healthTimeout
+	d.mu.RUnlock()
+	sort.Strings(critical)
+
+	for _, name := range critical {
+		if state := states[name]; state != StateRun && state != StateIdle {
+			return ErrWatchdogWithheld{Service: name, Reason: "is in state " + state.String()}
+		}
+
+		checked, ok := health[name]
+		if !ok {
+			continue
+		}
+		switch {
+		case checked.Status == HealthUnhealthy:
+			return ErrWatchdogWithheld{Service: name, Reason: "failed its health check: " + checked.Error}
+		case checked.Status == HealthHealthy && time.Since(checked.CheckedAt) > stale:
+			return ErrWatchdogWithheld{Service: name, Reason: "has not passed a health check since " + checked.CheckedAt.Format(time.RFC3339)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package rxd
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDaemon_WatchdogAlive(t *testing.T) {
+	d := NewDaemon("test-daemon").(*daemon)
+	if err := d.AddService(NewService("db", &leakyService{}, WithCritical())); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+	if err := d.AddService(NewService("cache", &leakyService{})); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	d.readiness = &readinessTracker{policy: AllOf(), readyC: make(chan struct{})}
+	d.readiness.observe(ServiceStates{"db": StateInit, "cache": StateInit})
+	if err := d.watchdogAlive(); err != nil {
+		t.Fatalf("expected keep-alives until the daemon is ready, got %s", err)
+	}
+
+	d.readiness.observe(ServiceStates{"db": StateRun, "cache": StateRun})
+	if err := d.watchdogAlive(); err != nil {
+		t.Fatalf("expected keep-alives while the critical services run, got %s", err)
+	}
+
+	d.readiness.observe(ServiceStates{"db": StateRun, "cache": StateStop})
+	if err := d.watchdogAlive(); err != nil {
+		t.Errorf("expected services that are not critical to be ignored, got %s", err)
+	}
+
+	d.readiness.observe(ServiceStates{"db": StateStop, "cache": StateRun})
+	var withheld ErrWatchdogWithheld
+	if err := d.watchdogAlive(); !errors.As(err, &withheld) || withheld.Service != "db" {
+		t.Errorf("expected the keep-alive to be withheld for db, got %v", err)
+	}
+
+	d.readiness.observe(ServiceStates{"db": StateRun, "cache": StateRun})
+	d.health.set(ServicesHealth{"db": {Status: HealthUnhealthy, Error: "connection refused", CheckedAt: time.Now()}})
+	if err := d.watchdogAlive(); err == nil || err.Error() != "critical service 'db' failed its health check: connection refused" {
+		t.Errorf("expected the keep-alive to be withheld while db is unhealthy, got %v", err)
+	}
+
+	d.health.set(ServicesHealth{"db": {Status: HealthHealthy, CheckedAt: time.Now().Add(-time.Hour)}})
+	if err := d.watchdogAlive(); err == nil {
+		t.Errorf("expected the keep-alive to be withheld while the health of db is stale")
+	}
+
+	d.health.set(ServicesHealth{"db": {Status: HealthHealthy, CheckedAt: time.Now()}})
+	if err := d.watchdogAlive(); err != nil {
+		t.Errorf("expected keep-alives once db is healthy again, got %s", err)
+	}
+}
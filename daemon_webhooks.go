@@ -0,0 +1,263 @@
+package rxd
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+const (
+	// webhookQueueSize bounds the events waiting to be posted, events are dropped once it is full.
+	webhookQueueSize = 64
+	// webhookAttempts is how many times an event is posted before it is given up on.
+	webhookAttempts = 4
+	// webhookRetryDelay is the delay before the first retry, it doubles with every retry.
+	webhookRetryDelay = 500 * time.Millisecond
+	// webhookDrainTimeout bounds the delivery of the queued events once the daemon has stopped,
+	// unless a longer shutdown grace is set.
+	webhookDrainTimeout = 5 * time.Second
+	// WebhookSignatureHeader holds "sha256=" followed by the hex encoded HMAC-SHA256 of the body,
+	// when a secret is set with UsingWebhookSecret.
+	WebhookSignatureHeader = "X-Rxd-Signature"
+	// WebhookEventHeader holds the event of the body, so receivers can route it without decoding it.
+	WebhookEventHeader = "X-Rxd-Event"
+)
+
+// WebhookEvent is an event of the daemon or of its services posted to webhooks, see UsingWebhooks.
+type WebhookEvent string
+
+const (
+	EventServiceFailed      WebhookEvent = "service.failed"      // a lifecycle of the service returned an error.
+	EventServiceQuarantined WebhookEvent = "service.quarantined" // the service was quarantined, see WithQuarantine.
+	EventServiceExited      WebhookEvent = "service.exited"      // the service exited and will not run again.
+	EventDaemonStopping     WebhookEvent = "daemon.stopping"     // the daemon has begun shutting down.
+)
+
+// WebhookPayload is posted as json to the webhooks subscribed to its event.
+type WebhookPayload struct {
+	Event   WebhookEvent
+	Daemon  string
+	Service string // empty for the events of the daemon.
+	State   State  // state the service left for service.failed, else the state it entered.
+	Error   string // error of the lifecycle for service.failed.
+	Time    time.Time
+}
+
+// MarshalJSON encodes the state by name.
+func (p WebhookPayload) MarshalJSON() ([]byte, error) {
+	payload := struct {
+		Event   WebhookEvent `json:"event"`
+		Daemon  string       `json:"daemon"`
+		Service string       `json:"service,omitempty"`
+		State   string       `json:"state,omitempty"`
+		Error   string       `json:"error,omitempty"`
+		Time    time.Time    `json:"time"`
+	}{Event: p.Event, Daemon: p.Daemon, Service: p.Service, Error: p.Error, Time: p.Time}
+	if p.Service != "" {
+		payload.State = p.State.String()
+	}
+	return json.Marshal(payload)
+}
+
+// SignWebhook returns the value of the WebhookSignatureHeader of body signed with secret.
+func SignWebhook(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook reports whether signature, the value of the WebhookSignatureHeader, is that of body signed with secret.
+// Receivers check it before trusting a payload.
+func VerifyWebhook(secret, body []byte, signature string) bool {
+	return hmac.Equal([]byte(signature), []byte(SignWebhook(secret, body)))
+}
+
+// webhook is a url subscribed to events, all of them when events is empty.
+type webhook struct {
+	url    string
+	events map[WebhookEvent]bool
+}
+
+func (w webhook) subscribed(event WebhookEvent) bool {
+	return len(w.events) == 0 || w.events[event]
+}
+
+// webhookDispatcher posts the events to the webhooks from a routine of its own,
+// so a slow or unreachable webhook never holds up the states watcher.
+type webhookDispatcher struct {
+	hooks  []webhook
+	secret []byte
+	client *http.Client
+	logger log.Logger
+	field  log.Field
+	ctx    context.Context
+	doneC  chan struct{}
+
+	mu     sync.Mutex // guards the queue against events sent while it is closed.
+	closed bool
+	queueC chan WebhookPayload
+}
+
+// startWebhooks starts delivering the events to the webhooks, the returned func stops once the queued events
+// have been delivered or given up on within the shutdown grace, or webhookDrainTimeout if longer.
+func (d *daemon) startWebhooks(nameField log.Field) func() {
+	if len(d.webhooks) == 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &webhookDispatcher{
+		hooks:  d.webhooks,
+		secret: d.webhookSecret,
+		client: &http.Client{Timeout: 5 * time.Second},
+		logger: d.internalLogger,
+		field:  nameField,
+		ctx:    ctx,
+		doneC:  make(chan struct{}),
+		queueC: make(chan WebhookPayload, webhookQueueSize),
+	}
+	go w.run()
+	d.dispatcher.Store(w)
+
+	return func() {
+		w.mu.Lock()
+		w.closed = true
+		close(w.queueC)
+		w.mu.Unlock()
+
+		timer := time.NewTimer(max(d.shutdownGrace, webhookDrainTimeout))
+		defer timer.Stop()
+		select {
+		case <-w.doneC:
+		case <-timer.C:
+			d.internalLogger.Log(log.LevelWarning, "gave up delivering the queued webhook events", nameField)
+		}
+		cancel()
+		<-w.doneC
+	}
+}
+
+// sendWebhook queues the event for the webhooks subscribed to it, it never blocks.
+// The daemon lock is not taken, the states watcher sends events while services are removed holding it.
+func (d *daemon) sendWebhook(payload WebhookPayload) {
+	w := d.dispatcher.Load()
+	if w == nil {
+		return
+	}
+
+	payload.Daemon = d.name
+	if payload.Time.IsZero() {
+		payload.Time = time.Now()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	select {
+	case w.queueC <- payload:
+	default:
+		d.internalLogger.Log(log.LevelWarning, "webhook queue is full, dropping event", log.String("event", string(payload.Event)), log.String("service_name", payload.Service), w.field)
+	}
+}
+
+// webhookTransition queues the events of a state transition of a service, err is the error of the lifecycle it left.
+// Only called by the states watcher routine.
+func (d *daemon) webhookTransition(service string, from, to State, err error) {
+	if len(d.webhooks) == 0 {
+		return
+	}
+
+	if err != nil {
+		d.sendWebhook(WebhookPayload{Event: EventServiceFailed, Service: service, State: from, Error: err.Error()})
+	}
+	switch to {
+	case StateQuarantined:
+		d.sendWebhook(WebhookPayload{Event: EventServiceQuarantined, Service: service, State: to})
+	case StateExit:
+		d.sendWebhook(WebhookPayload{Event: EventServiceExited, Service: service, State: to})
+	}
+}
+
+func (w *webhookDispatcher) run() {
+	defer close(w.doneC)
+	for payload := range w.queueC {
+		body, err := json.Marshal(payload)
+		if err != nil {
+			w.logger.Log(log.LevelError, "error encoding webhook event", log.Error("error", err), w.field)
+			continue
+		}
+
+		for _, hook := range w.hooks {
+			if !hook.subscribed(payload.Event) {
+				continue
+			}
+			if err := w.post(hook.url, payload.Event, body); err != nil {
+				w.logger.Log(log.LevelError, "error posting webhook event", log.String("event", string(payload.Event)), log.String("url", hook.url), log.Error("error", err), w.field)
+			}
+		}
+	}
+}
+
+// post posts the body to url, retrying with a growing delay while the webhook is unreachable or failing.
+// Requests rejected by the webhook, other than for a timeout or rate limit, are not retried.
+func (w *webhookDispatcher) post(url string, event WebhookEvent, body []byte) error {
+	delay := webhookRetryDelay
+	var err error
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		var retry bool
+		if retry, err = w.postOnce(url, event, body); err == nil || !retry {
+			return err
+		}
+		if attempt == webhookAttempts {
+			break
+		}
+
+		w.logger.Log(log.LevelDebug, "retrying webhook event", log.String("event", string(event)), log.Int("attempt", attempt), log.Error("error", err), w.field)
+		timer := time.NewTimer(delay)
+		select {
+		case <-w.ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+	return err
+}
+
+func (w *webhookDispatcher) postOnce(url string, event WebhookEvent, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(w.ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, string(event))
+	if len(w.secret) > 0 {
+		req.Header.Set(WebhookSignatureHeader, SignWebhook(w.secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return w.ctx.Err() == nil, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests:
+		return true, ErrWebhookRejected{StatusCode: resp.StatusCode}
+	default:
+		return false, ErrWebhookRejected{StatusCode: resp.StatusCode}
+	}
+}
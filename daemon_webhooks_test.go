@@ -0,0 +1,111 @@
+package rxd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// webhookRecorder records the events posted to it, answering the first request with 503 when flaky.
+type webhookRecorder struct {
+	mu       sync.Mutex
+	flaky    bool
+	requests int
+	events   []WebhookEvent
+	payloads []map[string]any
+	unsigned int
+}
+
+func (r *webhookRecorder) handler(secret []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.requests++
+		if r.flaky && r.requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if secret != nil && !VerifyWebhook(secret, body, req.Header.Get(WebhookSignatureHeader)) {
+			r.unsigned++
+		}
+
+		var payload map[string]any
+		json.Unmarshal(body, &payload)
+		r.events = append(r.events, WebhookEvent(req.Header.Get(WebhookEventHeader)))
+		r.payloads = append(r.payloads, payload)
+	})
+}
+
+func TestDaemon_Webhooks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	secret := []byte("s3cr3t")
+	all := &webhookRecorder{flaky: true}
+	allServer := httptest.NewServer(all.handler(secret))
+	defer allServer.Close()
+	stopping := &webhookRecorder{}
+	stoppingServer := httptest.NewServer(stopping.handler(nil))
+	defer stoppingServer.Close()
+
+	errMigrate := errors.New("migration failed")
+	d := NewDaemon("test-daemon",
+		UsingWebhooks(allServer.URL),
+		UsingWebhooks(stoppingServer.URL, EventDaemonStopping),
+		UsingWebhookSecret(secret),
+		WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+		WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+	)
+	if err := d.AddService(NewService("migrate", &failingService{recordingService{name: "migrate", journal: &recordingJournal{}}, errMigrate}, WithManager(NewRunOnceManager(0)))); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	// the queued events are delivered before Start returns.
+	if err := d.Start(ctx); !errors.Is(err, errMigrate) {
+		t.Fatalf("expected the daemon to stop with %s, got %v", errMigrate, err)
+	}
+
+	all.mu.Lock()
+	defer all.mu.Unlock()
+	want := []WebhookEvent{EventServiceFailed, EventServiceExited, EventDaemonStopping}
+	if !slices.Equal(all.events, want) {
+		t.Fatalf("expected the events %v, got %v", want, all.events)
+	}
+	if all.requests != len(want)+1 {
+		t.Errorf("expected the rejected event to be retried once, got %d requests", all.requests)
+	}
+	if all.unsigned > 0 {
+		t.Errorf("expected every event to be signed, got %d unsigned", all.unsigned)
+	}
+	if failed := all.payloads[0]; failed["service"] != "migrate" || failed["state"] != StateRun.String() || failed["error"] != errMigrate.Error() || failed["daemon"] != "test-daemon" {
+		t.Errorf("expected the failure of the run of migrate, got %v", failed)
+	}
+
+	stopping.mu.Lock()
+	defer stopping.mu.Unlock()
+	if !slices.Equal(stopping.events, []WebhookEvent{EventDaemonStopping}) {
+		t.Errorf("expected only the subscribed event, got %v", stopping.events)
+	}
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	body := []byte(`{"event":"daemon.stopping"}`)
+	signature := SignWebhook([]byte("key"), body)
+	if !VerifyWebhook([]byte("key"), body, signature) {
+		t.Errorf("expected the signature to verify")
+	}
+	if VerifyWebhook([]byte("other"), body, signature) || VerifyWebhook([]byte("key"), []byte(`{}`), signature) {
+		t.Errorf("expected a signature of another key or body to be rejected")
+	}
+}
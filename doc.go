@@ -0,0 +1,13 @@
+// Package rxd runs services through their lifecycles within a single daemon process.
+//
+// # Build tags
+//
+// The rxdminimal build tag excludes the subsystems that depend on the host operating system,
+// so the daemon, intracom and the managers build for wasm and other restricted environments:
+//
+//   - signal handling: the daemon is only stopped by cancelling the context given to Start.
+//   - systemd notifications and the systemctl unit source: no-ops, as on every platform but linux.
+//   - isolated services: child processes cannot be started, see ErrIsolationUnsupported.
+//
+// Builds for js/wasm never handle signals, with or without the tag.
+package rxd
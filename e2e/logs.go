@@ -0,0 +1,51 @@
+package e2e
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+var _ log.LogHandler = (*LogRecorder)(nil)
+
+// Entry is a single log record captured by a LogRecorder.
+type Entry struct {
+	Level   log.Level
+	Message string
+	Fields  map[string]string
+}
+
+// LogRecorder is a log handler keeping every record in memory for assertions.
+type LogRecorder struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+func (r *LogRecorder) Handle(level log.Level, message string, fields []log.Field) {
+	entry := Entry{Level: level, Message: message, Fields: make(map[string]string, len(fields))}
+	for _, field := range fields {
+		entry.Fields[field.Key] = field.Value
+	}
+
+	r.mu.Lock()
+	r.entries = append(r.entries, entry)
+	r.mu.Unlock()
+}
+
+// Entries returns the records captured so far, in order.
+func (r *LogRecorder) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Entry(nil), r.entries...)
+}
+
+// Contains reports whether any record message contains substr.
+func (r *LogRecorder) Contains(substr string) bool {
+	for _, entry := range r.Entries() {
+		if strings.Contains(entry.Message, substr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package e2e
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ambitiousfew/rxd"
+)
+
+var (
+	_ rxd.ServiceRunner = (*MockRunner)(nil)
+	_ rxd.Reloader      = (*MockRunner)(nil)
+)
+
+// MockRunner is a scriptable ServiceRunner that records every lifecycle it enters,
+// so scenarios can assert the exact sequence of transitions the daemon drove it through.
+// By default every lifecycle succeeds and Run blocks until the service is stopped.
+type MockRunner struct {
+	mu       sync.Mutex
+	calls    []rxd.State
+	failures map[rxd.State][]error
+	runFor   time.Duration
+	changedC chan struct{} // closed and replaced on every recorded call.
+}
+
+// NewMockRunner creates a runner whose lifecycles all succeed.
+func NewMockRunner() *MockRunner {
+	return &MockRunner{
+		failures: make(map[rxd.State][]error),
+		changedC: make(chan struct{}),
+	}
+}
+
+// FailNext makes the next call of the lifecycle for state return err, calls queue up in order.
+func (m *MockRunner) FailNext(state rxd.State, err error) *MockRunner {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failures[state] = append(m.failures[state], err)
+	return m
+}
+
+// RunFor makes Run return after d instead of blocking until the service is stopped.
+func (m *MockRunner) RunFor(d time.Duration) *MockRunner {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runFor = d
+	return m
+}
+
+// Calls returns the lifecycles entered so far, in order.
+func (m *MockRunner) Calls() []rxd.State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]rxd.State(nil), m.calls...)
+}
+
+// Count returns how many times the lifecycle for state was entered.
+func (m *MockRunner) Count(state rxd.State) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int
+	for _, call := range m.calls {
+		if call == state {
+			n++
+		}
+	}
+	return n
+}
+
+// changed returns a channel closed on the next recorded call.
+func (m *MockRunner) changed() <-chan struct{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.changedC
+}
+
+func (m *MockRunner) record(state rxd.State) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, state)
+	close(m.changedC)
+	m.changedC = make(chan struct{})
+
+	if queued := m.failures[state]; len(queued) > 0 {
+		m.failures[state] = queued[1:]
+		return queued[0]
+	}
+	return nil
+}
+
+func (m *MockRunner) Init(sctx rxd.ServiceContext) error {
+	return m.record(rxd.StateInit)
+}
+
+func (m *MockRunner) Idle(sctx rxd.ServiceContext) error {
+	return m.record(rxd.StateIdle)
+}
+
+func (m *MockRunner) Run(sctx rxd.ServiceContext) error {
+	if err := m.record(rxd.StateRun); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	runFor := m.runFor
+	m.mu.Unlock()
+
+	if runFor <= 0 {
+		<-sctx.Done()
+		return nil
+	}
+
+	timer := time.NewTimer(runFor)
+	defer timer.Stop()
+	select {
+	case <-sctx.Done():
+	case <-timer.C:
+	}
+	return nil
+}
+
+func (m *MockRunner) Stop(sctx rxd.ServiceContext) error {
+	return m.record(rxd.StateStop)
+}
+
+func (m *MockRunner) Reload(sctx rxd.ServiceContext) error {
+	return m.record(rxd.StateReload)
+}
@@ -0,0 +1,183 @@
+// Package e2e runs whole rxd daemons inside tests. A Scenario declares the services of a daemon,
+// mostly scriptable MockRunners, and its Harness starts the real daemon so tests can inject failures
+// and assert the lifecycle transitions and log output it produces, instead of only unit testing mocks.
+package e2e
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// Scenario declares a daemon to run in a test.
+type Scenario struct {
+	t        testing.TB
+	name     string
+	services []rxd.Service
+	runners  map[string]*MockRunner
+	options  []rxd.DaemonOption
+	timeout  time.Duration
+}
+
+// NewScenario creates an empty scenario for a daemon with the given name.
+// Waits and the daemon itself are bounded by a 10 second timeout unless changed with WithTimeout.
+func NewScenario(t testing.TB, name string) *Scenario {
+	return &Scenario{
+		t:       t,
+		name:    name,
+		runners: make(map[string]*MockRunner),
+		timeout: 10 * time.Second,
+	}
+}
+
+// WithMockServices adds n mock services named "service-1" to "service-n".
+func (s *Scenario) WithMockServices(n int, opts ...rxd.ServiceOption) *Scenario {
+	for i := 1; i <= n; i++ {
+		s.WithMockService("service-"+strconv.Itoa(i), NewMockRunner(), opts...)
+	}
+	return s
+}
+
+// WithMockService adds a service backed by the given mock runner, which stays reachable through Harness.Runner.
+func (s *Scenario) WithMockService(name string, runner *MockRunner, opts ...rxd.ServiceOption) *Scenario {
+	s.runners[name] = runner
+	return s.WithService(rxd.NewService(name, runner, opts...))
+}
+
+// WithService adds any service, e.g. a real runner of the daemon under test.
+func (s *Scenario) WithService(service rxd.Service) *Scenario {
+	s.services = append(s.services, service)
+	return s
+}
+
+// WithOptions adds daemon options. Loggers set here are replaced by the harness recorders.
+func (s *Scenario) WithOptions(opts ...rxd.DaemonOption) *Scenario {
+	s.options = append(s.options, opts...)
+	return s
+}
+
+// WithTimeout bounds the daemon run and every wait of the harness.
+func (s *Scenario) WithTimeout(timeout time.Duration) *Scenario {
+	s.timeout = timeout
+	return s
+}
+
+// Start starts the daemon in the background and returns a harness to drive it.
+// The daemon is stopped when the test ends if Stop was not called.
+func (s *Scenario) Start() *Harness {
+	s.t.Helper()
+
+	h := &Harness{
+		t:        s.t,
+		runners:  s.runners,
+		timeout:  s.timeout,
+		Logs:     &LogRecorder{},
+		Internal: &LogRecorder{},
+		errC:     make(chan error, 1),
+	}
+
+	opts := append(slices.Clone(s.options),
+		rxd.WithServiceLogger(log.NewLogger(log.LevelDebug, h.Logs)),
+		rxd.WithInternalLogger(log.NewLogger(log.LevelDebug, h.Internal)),
+	)
+
+	h.Daemon = rxd.NewDaemon(s.name, opts...)
+	if err := h.Daemon.AddServices(s.services...); err != nil {
+		s.t.Fatalf("e2e: error adding services: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	h.cancel = cancel
+	go func() {
+		h.errC <- h.Daemon.Start(ctx)
+	}()
+
+	s.t.Cleanup(func() {
+		if !h.stopped {
+			h.Stop()
+		}
+	})
+	return h
+}
+
+// Harness drives a running scenario daemon.
+type Harness struct {
+	Daemon   rxd.Daemon
+	Logs     *LogRecorder // logs of the services.
+	Internal *LogRecorder // internal logs of the daemon.
+
+	t       testing.TB
+	runners map[string]*MockRunner
+	timeout time.Duration
+	cancel  context.CancelFunc
+	errC    chan error
+	stopped bool
+}
+
+// Runner returns the mock runner of a service added with WithMockService or WithMockServices.
+func (h *Harness) Runner(name string) *MockRunner {
+	h.t.Helper()
+	runner, ok := h.runners[name]
+	if !ok {
+		h.t.Fatalf("e2e: no mock runner for service %q", name)
+	}
+	return runner
+}
+
+// WaitFor blocks until the service has entered the lifecycle for state at least count times.
+func (h *Harness) WaitFor(name string, state rxd.State, count int) {
+	h.t.Helper()
+	runner := h.Runner(name)
+
+	timer := time.NewTimer(h.timeout)
+	defer timer.Stop()
+
+	for {
+		changedC := runner.changed()
+		if runner.Count(state) >= count {
+			return
+		}
+
+		select {
+		case <-changedC:
+		case <-timer.C:
+			h.t.Fatalf("e2e: timed out waiting for %s to enter %s %d time(s), got %v", name, state, count, runner.Calls())
+		}
+	}
+}
+
+// AssertTransitions fails the test unless the service entered exactly the given lifecycles, in order.
+func (h *Harness) AssertTransitions(name string, want ...rxd.State) {
+	h.t.Helper()
+	if got := h.Runner(name).Calls(); !slices.Equal(got, want) {
+		h.t.Errorf("e2e: expected %s to transition %v, got %v", name, want, got)
+	}
+}
+
+// AssertLogContains fails the test unless a service log message contains substr.
+func (h *Harness) AssertLogContains(substr string) {
+	h.t.Helper()
+	if !h.Logs.Contains(substr) {
+		h.t.Errorf("e2e: expected a service log containing %q", substr)
+	}
+}
+
+// Stop stops the daemon and returns the error of Start.
+func (h *Harness) Stop() error {
+	h.t.Helper()
+	h.stopped = true
+	h.cancel()
+
+	select {
+	case err := <-h.errC:
+		return err
+	case <-time.After(h.timeout):
+		h.t.Fatalf("e2e: timed out waiting for the daemon to stop")
+		return nil
+	}
+}
@@ -0,0 +1,54 @@
+package e2e
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd"
+)
+
+func TestScenario_MockServices(t *testing.T) {
+	h := NewScenario(t, "e2e-daemon").WithMockServices(3).Start()
+
+	for _, name := range []string{"service-1", "service-2", "service-3"} {
+		h.WaitFor(name, rxd.StateRun, 1)
+	}
+
+	if err := h.Stop(); err != nil {
+		t.Fatalf("error stopping daemon: %s", err)
+	}
+
+	h.AssertTransitions("service-2", rxd.StateInit, rxd.StateIdle, rxd.StateRun, rxd.StateStop)
+}
+
+func TestScenario_InjectedFailure(t *testing.T) {
+	runner := NewMockRunner().FailNext(rxd.StateInit, errors.New("database unreachable"))
+	manager := rxd.NewDefaultManager(rxd.WithTransitionTimeouts(rxd.ManagerStateTimeouts{rxd.StateInit: 10 * time.Millisecond}))
+
+	h := NewScenario(t, "e2e-daemon").
+		WithMockService("flaky", runner, rxd.WithManager(manager)).
+		Start()
+
+	h.WaitFor("flaky", rxd.StateRun, 1)
+	if err := h.Stop(); err != nil {
+		t.Fatalf("error stopping daemon: %s", err)
+	}
+
+	h.AssertTransitions("flaky", rxd.StateInit, rxd.StateStop, rxd.StateInit, rxd.StateIdle, rxd.StateRun, rxd.StateStop)
+	h.AssertLogContains("database unreachable")
+}
+
+func TestScenario_Reload(t *testing.T) {
+	h := NewScenario(t, "e2e-daemon").WithMockServices(1).Start()
+
+	h.WaitFor("service-1", rxd.StateRun, 1)
+	h.Daemon.Reload()
+	h.WaitFor("service-1", rxd.StateRun, 2)
+
+	if err := h.Stop(); err != nil {
+		t.Fatalf("error stopping daemon: %s", err)
+	}
+
+	h.AssertTransitions("service-1", rxd.StateInit, rxd.StateIdle, rxd.StateRun, rxd.StateReload, rxd.StateRun, rxd.StateStop)
+}
@@ -1,13 +1,61 @@
 package rxd
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The errors returned by the daemon are compared with errors.Is, they may be wrapped with the service
+// they are about, e.g. in a ServiceError, or joined with other errors as Start does.
 const (
-	ErrDaemonStarted            Error = Error("daemon has already been started")
-	ErrDuplicateServiceName     Error = Error("duplicate service name found")
-	ErrNoServices               Error = Error("no services to run")
-	ErrNoServiceName            Error = Error("no service name provided")
-	ErrNilService               Error = Error("nil service provided")
-	ErrDuplicateServicePolicy   Error = Error("duplicate service policy found")
+	ErrDaemonStarted          Error = Error("daemon has already been started")
+	ErrDuplicateServiceName   Error = Error("duplicate service name found")
+	ErrDuplicateService       Error = ErrDuplicateServiceName // same error as ErrDuplicateServiceName.
+	ErrNoServices             Error = Error("no services to run")
+	ErrNoServiceName          Error = Error("no service name provided")
+	ErrNilService             Error = Error("nil service provided")
+	ErrDuplicateServicePolicy Error = Error("duplicate service policy found")
+	// Deprecated: services can be added once the daemon is started.
 	ErrAddingServiceOnceStarted Error = Error("cannot add a service once the daemon is started")
+	ErrDaemonStopping           Error = Error("daemon is stopping")
+	ErrServiceNotFound          Error = Error("service not found")
+	ErrUnknownDependency        Error = Error("service depends on an unknown service")
+	ErrDependencyCycle          Error = Error("service dependencies form a cycle")
+	ErrServiceHasDependents     Error = Error("service has running dependent services")
+	ErrInvalidCronExpression    Error = Error("invalid cron expression")
+	ErrInvalidOperation         Error = Error("invalid or unsupported admin operation")
+	ErrRecycleIsolated          Error = Error("isolated services cannot be recycled")
+	ErrShutdownOrderConflict    Error = Error("shutdown order conflicts with service dependencies")
+	ErrPreconditionNotMet       Error = Error("service preconditions were not met")
+	ErrIsolatedServiceNotFound  Error = Error("isolated service not found in the child daemon")
+	ErrIsolationPipe            Error = Error("isolation pipe to the parent daemon is not available")
+	ErrIsolationUnsupported     Error = Error("isolated services are not supported by this build")
+	ErrStdioCaptured            Error = Error("stdout and stderr are already captured by another service")
+	ErrUnknownLogLevel          Error = Error("unknown log level")
+	ErrDaemonNotStarted         Error = Error("daemon has not been started")
+	ErrServiceRunning           Error = Error("service is already running")
+	ErrServiceNotRunning        Error = Error("service is not running")
+	ErrServiceNotPaused         Error = Error("service is not paused")
+	ErrNotAdminProxy            Error = Error("control socket does not proxy other daemons")
+	ErrNoGroupName              Error = Error("no service group name provided")
+	ErrDuplicateGroupName       Error = Error("duplicate service group name found")
+	ErrGroupNotFound            Error = Error("service group not found")
+	ErrReadinessTimeout         Error = Error("daemon did not become ready within the readiness timeout")
+	ErrShutdownTimeout          Error = Error("service did not stop within its stop timeout")
+	ErrNotifySocket             Error = Error("notify socket is not a unix socket")
+	ErrEmptyPipeline            Error = Error("pipeline has no stages")
+	ErrPipelineSource           Error = Error("first stage of a pipeline must be a source")
+	ErrPipelineSink             Error = Error("last stage of a pipeline must be a sink")
+	ErrPipelineLink             Error = Error("stage does not accept the output of the previous stage")
+	ErrLaunchdUnsupported       Error = Error("launchd sockets are only supported by darwin builds with cgo")
+	ErrRolloutTimeout           Error = Error("services did not recover within the rollout timeout")
+	ErrListenerNotFound         Error = Error("no socket with this name was passed to the daemon")
+	ErrPollTimeout              Error = Error("condition was not met within the poll timeout")
+	ErrBarrierNotFound          Error = Error("barrier not found")
+	ErrNotBarrierParticipant    Error = Error("service is not a participant of the barrier")
+	ErrNoTopicName              Error = Error("no topic name provided")
 )
 
 type Error string
@@ -16,6 +64,135 @@ func (e Error) Error() string {
 	return string(e)
 }
 
+// ErrAlertRejected is returned by the WebhookAlerter when the webhook does not accept the alert.
+type ErrAlertRejected struct {
+	StatusCode int
+}
+
+func (e ErrAlertRejected) Error() string {
+	return "alert rejected by webhook with status " + strconv.Itoa(e.StatusCode)
+}
+
+// ErrWebhookRejected is logged when a webhook does not accept an event, see UsingWebhooks.
+type ErrWebhookRejected struct {
+	StatusCode int
+}
+
+func (e ErrWebhookRejected) Error() string {
+	return "event rejected by webhook with status " + strconv.Itoa(e.StatusCode)
+}
+
+// PanicError is a panic recovered by the daemon, in a service or in a routine of the daemon itself, which is logged
+// along with its stack. Start returns it within a ServiceError for services run to completion, see RunOnceManager.
+type PanicError struct {
+	Component string // where the panic was recovered, e.g. "service api" or "signal watcher".
+	Value     any    // the value given to panic.
+	Stack     []byte // stack trace of the routine that panicked.
+}
+
+func (e PanicError) Error() string {
+	return "panic in " + e.Component + ": " + fmt.Sprint(e.Value)
+}
+
+// Unwrap returns the value given to panic when it is an error.
+func (e PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// ErrStartupTimeout is returned by Start when services had not left their init state within the startup timeout,
+// see UsingStartupTimeout.
+type ErrStartupTimeout struct {
+	Timeout  time.Duration
+	Services []string // the services stuck in their init state, sorted by name.
+}
+
+func (e ErrStartupTimeout) Error() string {
+	return "services did not start within " + e.Timeout.String() + ": " + strings.Join(e.Services, ", ")
+}
+
+// ErrResolve is returned when the resolver fails to build the runner of a service, see UsingResolver.
+type ErrResolve struct {
+	Service string
+	Err     error
+}
+
+func (e ErrResolve) Error() string {
+	return "error resolving the runner of service '" + e.Service + "': " + e.Err.Error()
+}
+
+func (e ErrResolve) Unwrap() error {
+	return e.Err
+}
+
+// ErrPipelineStage is returned by NewPipeline when a stage does not fit where it is in the pipeline.
+type ErrPipelineStage struct {
+	Stage string
+	Err   error
+}
+
+func (e ErrPipelineStage) Error() string {
+	return "pipeline stage '" + e.Stage + "': " + e.Err.Error()
+}
+
+func (e ErrPipelineStage) Unwrap() error {
+	return e.Err
+}
+
+// ErrLaunchdSocket is returned by LaunchdListeners when the sockets of the launchd job cannot be activated,
+// Err is syscall.ENOENT when the job has no socket of that name and syscall.ESRCH when launchd does not run the process.
+type ErrLaunchdSocket struct {
+	Name string
+	Err  error
+}
+
+func (e ErrLaunchdSocket) Error() string {
+	return "error activating launchd socket '" + e.Name + "': " + e.Err.Error()
+}
+
+func (e ErrLaunchdSocket) Unwrap() error {
+	return e.Err
+}
+
+// ErrRollingRestart is returned by RollingRestart once it is aborted, Services did not recover or could not be recycled.
+type ErrRollingRestart struct {
+	Group    string
+	Services []string
+	Err      error
+}
+
+func (e ErrRollingRestart) Error() string {
+	return "rolling restart of group '" + e.Group + "' aborted at " + strings.Join(e.Services, ", ") + ": " + e.Err.Error()
+}
+
+func (e ErrRollingRestart) Unwrap() error {
+	return e.Err
+}
+
+// ErrWatchdogWithheld is returned by the watchdog gate of the daemon while a critical service is wedged
+// or unhealthy, see WithCritical.
+type ErrWatchdogWithheld struct {
+	Service string
+	Reason  string // e.g. "is in state init".
+}
+
+func (e ErrWatchdogWithheld) Error() string {
+	return "critical service '" + e.Service + "' " + e.Reason
+}
+
+// ErrUnsupportedNotifyState is returned by a SystemNotifier asked to notify a state it does not support.
+type ErrUnsupportedNotifyState struct {
+	State NotifyState
+}
+
+func (e ErrUnsupportedNotifyState) Error() string {
+	name := e.State.String()
+	if name == "" {
+		name = strconv.Itoa(int(e.State))
+	}
+	return "'" + name + "' unsupported state for the system notifier"
+}
+
 type ErrUninitialized struct {
 	StructName string
 	Method     string
@@ -24,3 +201,30 @@ type ErrUninitialized struct {
 func (e ErrUninitialized) Error() string {
 	return e.StructName + " is nil, but uses a value receiver for '" + e.Method + "' method."
 }
+
+// ErrInvalidEnv is returned by FromEnv when an environment variable holds a value that cannot be parsed.
+type ErrInvalidEnv struct {
+	Key   string
+	Value string
+	Err   error
+}
+
+func (e ErrInvalidEnv) Error() string {
+	return "invalid value '" + e.Value + "' for " + e.Key + ": " + e.Err.Error()
+}
+
+func (e ErrInvalidEnv) Unwrap() error {
+	return e.Err
+}
+
+// ErrBarrierTimeout is returned by WaitBarrier to every participant waiting at a barrier once its timeout elapsed
+// before all of them arrived, see WithBarrier.
+type ErrBarrierTimeout struct {
+	Barrier string
+	Timeout time.Duration
+	Missing []string // the participants that did not arrive, sorted by name.
+}
+
+func (e ErrBarrierTimeout) Error() string {
+	return "barrier '" + e.Barrier + "' timed out after " + e.Timeout.String() + " waiting for: " + strings.Join(e.Missing, ", ")
+}
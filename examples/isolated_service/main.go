@@ -0,0 +1,96 @@
+// For this example we will run a service in its own child process of the daemon.
+// The crashing service simulates a fatal crash (such as a segfault in cgo code) by exiting its process
+// during Run. Since the service is isolated, only its child process dies and the daemon restarts it,
+// while the heartbeat service keeps running untouched in the daemon process.
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/ambitiousfew/rxd"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+const DaemonName = "isolated-service"
+
+func main() {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	logger := log.NewLogger(log.LevelInfo, log.NewHandler())
+
+	// NOTE: the child process runs this same main, so services must be added the same way on every run.
+	crashing := rxd.NewService("crashing", &CrashingService{}, rxd.WithIsolation())
+	heartbeat := rxd.NewService("heartbeat", &HeartbeatService{})
+
+	daemon := rxd.NewDaemon(DaemonName, rxd.WithServiceLogger(logger))
+	err := daemon.AddServices(crashing, heartbeat)
+	if err != nil {
+		logger.Log(log.LevelError, err.Error())
+		os.Exit(1)
+	}
+
+	err = daemon.Start(ctx)
+	if err != nil {
+		logger.Log(log.LevelError, err.Error())
+		os.Exit(1)
+	}
+}
+
+// CrashingService exits its whole process shortly after entering Run.
+type CrashingService struct{}
+
+func (s *CrashingService) Init(sctx rxd.ServiceContext) error {
+	sctx.Log(log.LevelInfo, "init in process", log.Int("pid", os.Getpid()))
+	return nil
+}
+
+func (s *CrashingService) Idle(sctx rxd.ServiceContext) error {
+	return nil
+}
+
+func (s *CrashingService) Run(sctx rxd.ServiceContext) error {
+	sctx.Log(log.LevelInfo, "running, crashing in 3 seconds")
+	select {
+	case <-sctx.Done():
+		return nil
+	case <-time.After(3 * time.Second):
+		os.Exit(2)
+	}
+	return nil
+}
+
+func (s *CrashingService) Stop(sctx rxd.ServiceContext) error {
+	sctx.Log(log.LevelInfo, "stopping")
+	return nil
+}
+
+// HeartbeatService logs every second to show the daemon is unaffected by the crashes.
+type HeartbeatService struct{}
+
+func (s *HeartbeatService) Init(sctx rxd.ServiceContext) error {
+	return nil
+}
+
+func (s *HeartbeatService) Idle(sctx rxd.ServiceContext) error {
+	return nil
+}
+
+func (s *HeartbeatService) Run(sctx rxd.ServiceContext) error {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sctx.Done():
+			return nil
+		case <-ticker.C:
+			sctx.Log(log.LevelInfo, "heartbeat", log.Int("pid", os.Getpid()))
+		}
+	}
+}
+
+func (s *HeartbeatService) Stop(sctx rxd.ServiceContext) error {
+	return nil
+}
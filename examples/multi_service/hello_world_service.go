@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"time"
 
@@ -63,9 +64,19 @@ func (s *HelloWorldAPIService) Run(ctx rxd.ServiceContext) error {
 		}
 	}()
 
-	ctx.Log(log.LevelInfo, fmt.Sprintf("server starting at %s", s.server.Addr))
-	// ListenAndServe will block forever serving requests/responses
-	err := s.server.ListenAndServe()
+	// with systemd socket activation the daemon holds the socket named "http" by the .socket unit,
+	// otherwise the server binds its address itself.
+	listener, err := ctx.Listener("http")
+	if err != nil {
+		listener, err = net.Listen("tcp", s.server.Addr)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx.Log(log.LevelInfo, fmt.Sprintf("server starting at %s", listener.Addr()))
+	// Serve will block forever serving requests/responses
+	err = s.server.Serve(listener)
 
 	if err != nil && err != http.ErrServerClosed {
 		// Stop running, move back to an Idle retry state
@@ -99,8 +110,6 @@ func (s *HelloWorldAPIService) Init(ctx rxd.ServiceContext) error {
 
 // Stop handles anything you might need to do to clean up before ending your service.
 func (s *HelloWorldAPIService) Stop(ctx rxd.ServiceContext) error {
-	// We must return a NewResponse, we use NoopState because it exits with no operation.
-	// using StopState would try to recall Stop again.
 	ctx.Log(log.LevelInfo, "stopping")
 	s.server = nil
 	return nil
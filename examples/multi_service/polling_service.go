@@ -118,8 +118,6 @@ func (s *APIPollingService) Run(ctx rxd.ServiceContext) error {
 
 // Stop handles anything you might need to do to clean up before ending your service.
 func (s *APIPollingService) Stop(ctx rxd.ServiceContext) error {
-	// We must return a NewResponse, we use NoopState because it exits with no operation.
-	// using StopState would try to recall Stop again.
 	ctx.Log(log.LevelInfo, "stopping")
 	return nil
 }
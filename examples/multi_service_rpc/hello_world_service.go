@@ -102,8 +102,6 @@ func (s *HelloWorldAPIService) Init(sctx rxd.ServiceContext) error {
 
 // Stop handles anything you might need to do to clean up before ending your service.
 func (s *HelloWorldAPIService) Stop(sctx rxd.ServiceContext) error {
-	// We must return a NewResponse, we use NoopState because it exits with no operation.
-	// using StopState would try to recall Stop again.
 	sctx.Log(log.LevelDebug, "entered init state")
 	s.server = nil
 
@@ -53,7 +53,7 @@ func main() {
 	// will be reported down to poll client when API Server reaches that stage.
 	// apiSvc.AddDependentService(pollRxdSvc, rxd.RunState, rxd.StopState)
 	// We are interested in when API Server reaches a RunState and when its reached a StopState
-	// NOTE: Make sure you watch for <service context>.ChangeState() in your polling stage that cares.
+	// NOTE: Make sure you watch the states with <service context>.WatchAllServices() in your polling stage that cares.
 
 	// Pass N services for daemon to manage and start
 
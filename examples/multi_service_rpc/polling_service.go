@@ -123,8 +123,6 @@ func (s *APIPollingService) Run(sctx rxd.ServiceContext) error {
 
 // Stop handles anything you might need to do to clean up before ending your service.
 func (s *APIPollingService) Stop(sctx rxd.ServiceContext) error {
-	// We must return a NewResponse, we use NoopState because it exits with no operation.
-	// using StopState would try to recall Stop again.
 	sctx.Log(log.LevelDebug, "entered stop state")
 	select {
 	case <-sctx.Done():
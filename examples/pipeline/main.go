@@ -0,0 +1,124 @@
+// For this example we will wire three services into a pipeline with rxd.NewPipeline.
+// The ticker service produces a number every second, the squarer service squares the numbers it receives
+// and the printer service logs the results.
+//
+// Each stage only starts once the stage before it reached Run and receives its input on a typed pipe,
+// a stage that restarts keeps receiving where it left off since the pipes outlive the stages.
+package main
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ambitiousfew/rxd"
+	"github.com/ambitiousfew/rxd/intracom"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+const DaemonName = "pipeline"
+
+func main() {
+	// NOTE: Intentional cancellation timeout after 10 seconds to show startup/shutdown.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	logger := log.NewLogger(log.LevelInfo, log.NewHandler())
+
+	services, err := rxd.NewPipeline(
+		rxd.PipelineSource("ticker", func(out *intracom.Pipe[int]) rxd.ServiceRunner {
+			return &Ticker{out: out}
+		}),
+		rxd.PipelineStage("squarer", func(in *intracom.Pipe[int], out *intracom.Pipe[int]) rxd.ServiceRunner {
+			return &Squarer{in: in, out: out}
+		}),
+		rxd.PipelineSink("printer", func(in *intracom.Pipe[int]) rxd.ServiceRunner {
+			return &Printer{in: in}
+		}),
+	)
+	if err != nil {
+		logger.Log(log.LevelError, err.Error())
+		os.Exit(1)
+	}
+
+	daemon := rxd.NewDaemon(DaemonName, rxd.WithServiceLogger(logger))
+	if err := daemon.AddServices(services...); err != nil {
+		logger.Log(log.LevelError, err.Error())
+		os.Exit(1)
+	}
+
+	// Start the daemon, this will block until the daemon is stopped via ctx cancel or OS signal.
+	if err := daemon.Start(ctx); err != nil {
+		logger.Log(log.LevelError, err.Error())
+		os.Exit(1)
+	}
+	logger.Log(log.LevelInfo, "successfully stopped daemon")
+}
+
+// Ticker sends the next number every second.
+type Ticker struct {
+	out  *intracom.Pipe[int]
+	next int
+}
+
+func (t *Ticker) Init(sctx rxd.ServiceContext) error { return nil }
+func (t *Ticker) Idle(sctx rxd.ServiceContext) error { return nil }
+func (t *Ticker) Stop(sctx rxd.ServiceContext) error { return nil }
+
+func (t *Ticker) Run(sctx rxd.ServiceContext) error {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-sctx.Done():
+			return nil
+		case <-ticker.C:
+			t.next++
+			if err := t.out.Send(sctx, t.next); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// Squarer sends the square of every number it receives.
+type Squarer struct {
+	in, out *intracom.Pipe[int]
+}
+
+func (s *Squarer) Init(sctx rxd.ServiceContext) error { return nil }
+func (s *Squarer) Idle(sctx rxd.ServiceContext) error { return nil }
+func (s *Squarer) Stop(sctx rxd.ServiceContext) error { return nil }
+
+func (s *Squarer) Run(sctx rxd.ServiceContext) error {
+	for {
+		n, err := s.in.Receive(sctx)
+		if err != nil {
+			return nil
+		}
+		if err := s.out.Send(sctx, n*n); err != nil {
+			return nil
+		}
+	}
+}
+
+// Printer logs every number it receives.
+type Printer struct {
+	in *intracom.Pipe[int]
+}
+
+func (p *Printer) Init(sctx rxd.ServiceContext) error { return nil }
+func (p *Printer) Idle(sctx rxd.ServiceContext) error { return nil }
+func (p *Printer) Stop(sctx rxd.ServiceContext) error { return nil }
+
+func (p *Printer) Run(sctx rxd.ServiceContext) error {
+	for {
+		n, err := p.in.Receive(sctx)
+		if err != nil {
+			return nil
+		}
+		sctx.Log(log.LevelInfo, "received "+strconv.Itoa(n))
+	}
+}
@@ -38,18 +38,14 @@ func main() {
 	}
 
 	go func(ctx context.Context, topic intracom.Topic[int]) {
-		publishC := topic.PublishChannel()
+		defer topic.Close()
 
 		// publish 100 messages to the topic
 		for i := 0; i < 100; i++ {
-			select {
-			case <-ctx.Done():
-				topic.Close()
+			if err := topic.Publish(ctx, i); err != nil {
 				return
-			case publishC <- i:
 			}
 		}
-		topic.Close()
 	}(parent, topic)
 
 	// consume the messages over subscriber channel.
@@ -4,7 +4,7 @@ import (
 	"time"
 
 	"github.com/ambitiousfew/rxd"
-	"github.com/ambitiousfew/rxd/log"
+	"github.com/ambitiousfew/rxd/managerkit"
 )
 
 var _ rxd.ServiceManager = (*CustomManager)(nil)
@@ -19,35 +19,32 @@ type CustomManager struct{}
 // The Stop state will always transition back to the Init state if there is no error.
 // The manager always checks for context cancellation and will exit if the context is cancelled between state transitions.
 func (m CustomManager) Manage(sctx rxd.ServiceContext, ds rxd.DaemonService, updateC chan<- rxd.StateUpdate) {
+	// the lifecycle publishes the states, logs lifecycle errors and honors the preconditions, reloads and
+	// recycles the daemon applies to the service.
+	lc := managerkit.New(sctx, ds, updateC)
+	// Exit recovers from a panicking runner, runs Stop if the service did not stop yet and sends the final
+	// Exit state to the states watcher.
+	defer lc.Exit()
+
 	// Set an initial state to init
 	state := rxd.StateInit
 
-	// Causing an intentional arbitrary delay of 1 second between state transitions
-	timeout := time.NewTimer(1 * time.Second)
-	defer timeout.Stop()
-
 	// Loop until the state is set to exit
 	for state != rxd.StateExit {
-
 		// calling this function sends the current state to RxD's states watcher.
 		// this is useful when wanting to monitor the state of a service or
 		// have other services subscribe to the state of any other service.
-		updateC <- rxd.StateUpdate{Name: ds.Name, State: state}
+		lc.Enter(state)
 
-		select {
-		case <-sctx.Done():
-			// if context has been cancelled we need to exit.
-			state = rxd.StateExit
-		case <-timeout.C:
-			// if the timeout has been reached, we can transition to the next state.
+		// Causing an intentional arbitrary delay of 1 second between state transitions,
+		// if context has been cancelled we need to exit.
+		if !lc.Wait(1 * time.Second) {
+			return
 		}
 
-		var err error
 		switch state {
 		case rxd.StateInit:
-			err = ds.Runner.Init(sctx)
-			if err != nil {
-				sctx.Log(log.LevelError, err.Error())
+			if err := lc.Init(); err != nil {
 				// if there is an error during init, this manager will immediately exit.
 				state = rxd.StateExit
 			} else {
@@ -55,35 +52,24 @@ func (m CustomManager) Manage(sctx rxd.ServiceContext, ds rxd.DaemonService, upd
 				state = rxd.StateIdle
 			}
 		case rxd.StateIdle:
-			err = ds.Runner.Idle(sctx)
-			if err != nil {
-				sctx.Log(log.LevelError, err.Error())
+			if err := lc.Idle(); err != nil {
 				state = rxd.StateStop
 			} else {
 				// if there is no error, we can transition to the next state Idle --to--> Run
 				state = rxd.StateRun
 			}
 		case rxd.StateRun:
-			err = ds.Runner.Run(sctx)
-			if err != nil {
-				sctx.Log(log.LevelError, err.Error())
-			}
 			// regardless of error or not, we will transition to the next state Run --to--> Stop
+			lc.Run()
 			state = rxd.StateStop
 		case rxd.StateStop:
-			if err = ds.Runner.Stop(sctx); err != nil {
-				sctx.Log(log.LevelError, err.Error())
-				state = rxd.StateExit
+			if err := lc.Stop(); err != nil {
 				// if there is an error during stop, this manager will immediately exit.
+				state = rxd.StateExit
 			} else {
 				// if there is no error, we will push back around to the first state Stop --to--> Init
 				state = rxd.StateInit
 			}
-
 		}
-		timeout.Reset(1 * time.Second)
 	}
-
-	// Because ExitState is a terminal state, we should send the final state to the states watcher before exiting.
-	updateC <- rxd.StateUpdate{Name: ds.Name, State: rxd.StateExit}
 }
@@ -0,0 +1,29 @@
+package flushservice
+
+import "strconv"
+
+// Error is a custom error type for the flushservice package.
+type Error string
+
+const (
+	ErrNoTopic   = Error("no topic provided")
+	ErrNoFlusher = Error("no flusher provided")
+)
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// ErrFlush is returned by Stop when the final flush failed, the items are counted as failed in the Stats.
+type ErrFlush struct {
+	Items int
+	Err   error
+}
+
+func (e ErrFlush) Error() string {
+	return "flushing " + strconv.Itoa(e.Items) + " items failed: " + e.Err.Error()
+}
+
+func (e ErrFlush) Unwrap() error {
+	return e.Err
+}
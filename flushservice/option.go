@@ -0,0 +1,36 @@
+package flushservice
+
+import "time"
+
+type Option func(*FlushRunner)
+
+// WithBatchSize flushes the queued items once size of them are queued (default: 100).
+func WithBatchSize(size int) Option {
+	return func(r *FlushRunner) {
+		r.batchSize = size
+	}
+}
+
+// WithFlushInterval flushes the queued items at least every interval, however few (default: 1s).
+func WithFlushInterval(interval time.Duration) Option {
+	return func(r *FlushRunner) {
+		r.interval = interval
+	}
+}
+
+// WithMaxQueue bounds the items waiting to be flushed, once it is reached the oldest items are dropped
+// rather than holding up the publishers (default: 10000).
+func WithMaxQueue(size int) Option {
+	return func(r *FlushRunner) {
+		r.maxQueue = size
+	}
+}
+
+// WithRetry flushes a batch up to attempts times, waiting delay before the first retry and twice as long
+// before every other one (default: 3 attempts, 100ms).
+func WithRetry(attempts int, delay time.Duration) Option {
+	return func(r *FlushRunner) {
+		r.attempts = attempts
+		r.retryDelay = delay
+	}
+}
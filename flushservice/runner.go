@@ -0,0 +1,270 @@
+// Package flushservice provides a ServiceRunner shipping the items services publish to a topic in batches,
+// such as the log lines or events a daemon forwards to a collector, through a Flusher.
+package flushservice
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ambitiousfew/rxd"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+var _ rxd.ServiceRunner = (*FlushRunner)(nil)
+
+// Flusher writes a batch of items in the order they were published, e.g. to a log collector or an object store.
+type Flusher func(ctx context.Context, batch [][]byte) error
+
+// Stats accounts for the items received by a FlushRunner since it was created.
+type Stats struct {
+	Queued  int    // items waiting to be flushed.
+	Flushed uint64 // items flushed.
+	Dropped uint64 // items dropped because the queue was full.
+	Failed  uint64 // items given up on once every attempt to flush them failed.
+}
+
+// FlushRunner is a ServiceRunner receiving the items published to its topic with ServiceContext.Publish while
+// it runs, and flushing them in batches once enough are queued or the flush interval elapsed. A failed flush is
+// retried, items are only lost once the queue is full or every attempt failed, both are counted in the Stats.
+// Stop flushes the items left in the queue within the shutdown deadline of the daemon if it has one.
+type FlushRunner struct {
+	topic      string
+	flusher    Flusher
+	batchSize  int
+	interval   time.Duration
+	maxQueue   int
+	attempts   int
+	retryDelay time.Duration
+
+	flushMu sync.Mutex // held while flushing, so batches are flushed one at a time and in order.
+	mu      sync.Mutex
+	queue   [][]byte
+	flushed uint64
+	dropped uint64
+	failed  uint64
+}
+
+// New creates a FlushRunner flushing the items published to topic with flusher.
+func New(topic string, flusher Flusher, opts ...Option) *FlushRunner {
+	r := &FlushRunner{
+		topic:      topic,
+		flusher:    flusher,
+		batchSize:  100,
+		interval:   time.Second,
+		maxQueue:   10000,
+		attempts:   3,
+		retryDelay: 100 * time.Millisecond,
+		mu:         sync.Mutex{},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.batchSize = max(r.batchSize, 1)
+	r.maxQueue = max(r.maxQueue, r.batchSize)
+	r.attempts = max(r.attempts, 1)
+	return r
+}
+
+// Stats returns the accounting of the items received so far, it is safe to call from any routine.
+func (r *FlushRunner) Stats() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Stats{Queued: len(r.queue), Flushed: r.flushed, Dropped: r.dropped, Failed: r.failed}
+}
+
+func (r *FlushRunner) Init(sctx rxd.ServiceContext) error {
+	if r.topic == "" {
+		return ErrNoTopic
+	}
+	if r.flusher == nil {
+		return ErrNoFlusher
+	}
+	return nil
+}
+
+func (r *FlushRunner) Idle(sctx rxd.ServiceContext) error {
+	return nil
+}
+
+func (r *FlushRunner) Run(sctx rxd.ServiceContext) error {
+	// the subscription ends along with Run, items published while the service does not run are not received.
+	subCtx, unsubscribe := sctx.WithParent(sctx)
+	defer unsubscribe()
+	itemsC, err := subCtx.Subscribe(r.topic, rxd.SubscribeConfig{BufferSize: r.batchSize})
+	if err != nil {
+		return err
+	}
+
+	flushC := make(chan struct{}, 1)
+	stopC := make(chan struct{})
+	doneC := make(chan struct{})
+	go func() {
+		defer close(doneC)
+		r.flushLoop(sctx, flushC, stopC)
+	}()
+	defer func() {
+		close(stopC)
+		<-doneC
+	}()
+
+	for {
+		select {
+		case <-sctx.Done():
+			// the items received but not read yet are queued for the final flush in Stop.
+			unsubscribe()
+			for drained := false; !drained; {
+				select {
+				case item, open := <-itemsC:
+					if !open {
+						drained = true
+						continue
+					}
+					r.enqueue(item)
+				default:
+					drained = true
+				}
+			}
+			return nil
+
+		case item, open := <-itemsC:
+			if !open {
+				return nil
+			}
+			if r.enqueue(item) >= r.batchSize {
+				select {
+				case flushC <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}
+}
+
+func (r *FlushRunner) Stop(sctx rxd.ServiceContext) error {
+	// the service context may already be done, the final flush must still reach the destination
+	// within the shutdown deadline of the daemon if it has one.
+	ctx := context.WithoutCancel(sctx)
+	if deadline, ok := sctx.ShutdownDeadline(); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	before := r.Stats()
+	err := r.flushQueued(ctx, true)
+
+	r.mu.Lock()
+	// the items put back into the queue once the deadline passed are given up on as well.
+	r.failed += uint64(len(r.queue))
+	r.queue = nil
+	stats := Stats{Flushed: r.flushed, Dropped: r.dropped, Failed: r.failed}
+	r.mu.Unlock()
+
+	sctx.Log(log.LevelInfo, "flushed queued items", log.Int("flushed", stats.Flushed), log.Int("dropped", stats.Dropped), log.Int("failed", stats.Failed))
+	if err != nil {
+		return ErrFlush{Items: int(stats.Failed - before.Failed), Err: err}
+	}
+	return nil
+}
+
+// flushLoop flushes the queued items every interval, or once a batch is full, until Run returns.
+func (r *FlushRunner) flushLoop(sctx rxd.ServiceContext, flushC, stopC <-chan struct{}) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		var partial bool
+		select {
+		case <-stopC:
+			return
+		case <-ticker.C:
+			partial = true
+		case <-flushC:
+		}
+
+		// a full batch is flushed right away, a partial batch waits for the interval.
+		if err := r.flushQueued(sctx, partial); err != nil && sctx.Err() == nil {
+			sctx.Log(log.LevelError, "error flushing items", log.Error("error", err))
+		}
+	}
+}
+
+// enqueue queues the item, dropping the oldest item once the queue is full. It returns the length of the queue.
+func (r *FlushRunner) enqueue(item []byte) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.queue) >= r.maxQueue {
+		r.queue = r.queue[1:]
+		r.dropped++
+	}
+	r.queue = append(r.queue, item)
+	return len(r.queue)
+}
+
+// flushQueued flushes the queued items in batches, along with the last partial batch if partial is set.
+// The batch that failed is given up on unless ctx is done, then it is put back in front of the queue for the next flush.
+func (r *FlushRunner) flushQueued(ctx context.Context, partial bool) error {
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+
+	for {
+		r.mu.Lock()
+		n := min(len(r.queue), r.batchSize)
+		if n < r.batchSize && !partial {
+			n = 0
+		}
+		batch := r.queue[:n:n]
+		r.queue = r.queue[n:]
+		r.mu.Unlock()
+
+		if n == 0 {
+			return nil
+		}
+
+		err := r.flushBatch(ctx, batch)
+
+		r.mu.Lock()
+		switch {
+		case err == nil:
+			r.flushed += uint64(n)
+		case ctx.Err() != nil:
+			queue := append(batch, r.queue...)
+			if over := len(queue) - r.maxQueue; over > 0 {
+				queue = queue[over:]
+				r.dropped += uint64(over)
+			}
+			r.queue = queue
+		default:
+			r.failed += uint64(n)
+		}
+		r.mu.Unlock()
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// flushBatch flushes the batch, retrying with a growing delay until an attempt succeeds or ctx is done.
+func (r *FlushRunner) flushBatch(ctx context.Context, batch [][]byte) error {
+	delay := r.retryDelay
+	var err error
+	for attempt := 1; attempt <= r.attempts; attempt++ {
+		if err = r.flusher(ctx, batch); err == nil || attempt == r.attempts {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+	return err
+}
@@ -0,0 +1,141 @@
+package flushservice
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// recorder keeps the batches it is given.
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]string
+	items   int
+}
+
+func (r *recorder) flush(ctx context.Context, batch [][]byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	items := make([]string, 0, len(batch))
+	for _, item := range batch {
+		items = append(items, string(item))
+	}
+	r.batches = append(r.batches, items)
+	r.items += len(batch)
+	return nil
+}
+
+func (r *recorder) flushed() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.items
+}
+
+// publisher publishes its items to the topic once the flusher runs.
+type publisher struct {
+	topic string
+	items int
+}
+
+func (p *publisher) Init(sctx rxd.ServiceContext) error { return nil }
+func (p *publisher) Idle(sctx rxd.ServiceContext) error { return nil }
+func (p *publisher) Stop(sctx rxd.ServiceContext) error { return nil }
+
+func (p *publisher) Run(sctx rxd.ServiceContext) error {
+	runningC, cancel := sctx.WatchAllServices(rxd.Entered, rxd.StateRun, "flusher")
+	defer cancel()
+	select {
+	case <-sctx.Done():
+		return nil
+	case <-runningC:
+	}
+	// the flusher subscribes as it enters its run state.
+	time.Sleep(50 * time.Millisecond)
+
+	for i := 1; i <= p.items; i++ {
+		if err := sctx.Publish(p.topic, []byte(strconv.Itoa(i))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestFlushRunner_BatchesAndFinalFlush(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rec := &recorder{}
+	runner := New("events", rec.flush, WithBatchSize(3), WithFlushInterval(time.Hour))
+
+	d := rxd.NewDaemon("test-daemon", rxd.WithServiceLogger(log.NewLogger(log.LevelError, log.NewHandler())))
+	err := d.AddServices(
+		rxd.NewService("flusher", runner),
+		rxd.NewService("publisher", &publisher{topic: "events", items: 7}, rxd.WithManager(rxd.NewRunOnceManager(0))),
+	)
+	if err != nil {
+		t.Fatalf("error adding services: %s", err)
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	// two full batches are flushed while running, the last item waits for the interval.
+	for rec.flushed() < 6 || runner.Stats().Queued < 1 {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for the full batches, flushed %d", rec.flushed())
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	if stats := runner.Stats(); stats != (Stats{Queued: 1, Flushed: 6}) {
+		t.Errorf("expected the last item to be queued, got %+v", stats)
+	}
+
+	cancel()
+	<-errC
+
+	want := [][]string{{"1", "2", "3"}, {"4", "5", "6"}, {"7"}}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if !slices.EqualFunc(rec.batches, want, slices.Equal) {
+		t.Errorf("expected the batches %v, got %v", want, rec.batches)
+	}
+	if stats := runner.Stats(); stats != (Stats{Flushed: 7}) {
+		t.Errorf("expected every item to be flushed, got %+v", stats)
+	}
+}
+
+func TestFlushRunner_LossAccounting(t *testing.T) {
+	errDown := errors.New("collector is down")
+	var attempts int
+	runner := New("events", func(ctx context.Context, batch [][]byte) error {
+		attempts++
+		return errDown
+	}, WithBatchSize(2), WithMaxQueue(2), WithRetry(2, time.Millisecond))
+
+	for _, item := range []string{"1", "2", "3"} {
+		runner.enqueue([]byte(item))
+	}
+	if stats := runner.Stats(); stats.Queued != 2 || stats.Dropped != 1 {
+		t.Fatalf("expected the oldest item to be dropped from the full queue, got %+v", stats)
+	}
+
+	if err := runner.flushQueued(context.Background(), true); !errors.Is(err, errDown) {
+		t.Fatalf("expected the error of the flusher, got %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("expected the batch to be retried once, got %d attempts", attempts)
+	}
+	if stats := runner.Stats(); stats != (Stats{Dropped: 1, Failed: 2}) {
+		t.Errorf("expected the batch to be given up on, got %+v", stats)
+	}
+}
@@ -0,0 +1,13 @@
+package grpcservice
+
+// Error is a custom error type for the grpcservice package.
+type Error string
+
+const (
+	ErrNoServer   = Error("no server factory provided")
+	ErrNotServing = Error("server is not serving")
+)
+
+func (e Error) Error() string {
+	return string(e)
+}
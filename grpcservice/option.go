@@ -0,0 +1,31 @@
+package grpcservice
+
+import "time"
+
+type Option func(*ServerRunner)
+
+// WithGracePeriod bounds the time GracefulStop may wait for pending rpcs before the server is stopped forcefully (default: 10s).
+func WithGracePeriod(grace time.Duration) Option {
+	return func(r *ServerRunner) {
+		r.grace = grace
+	}
+}
+
+// WithHealthReporter reports the serving status of the server, e.g. to a grpc health server:
+//
+//	hs := health.NewServer()
+//	grpcservice.WithHealthReporter(func(serving bool) {
+//		status := healthpb.HealthCheckResponse_NOT_SERVING
+//		if serving {
+//			status = healthpb.HealthCheckResponse_SERVING
+//		}
+//		hs.SetServingStatus("", status)
+//	})
+//
+// The server reports serving only while it is running and every service of the daemon checked by
+// the rxd health checks is healthy, so the grpc health service follows the rxd health.
+func WithHealthReporter(report func(serving bool)) Option {
+	return func(r *ServerRunner) {
+		r.report = report
+	}
+}
@@ -0,0 +1,173 @@
+// Package grpcservice provides a ServiceRunner serving a grpc server.
+// It depends on the subset of *grpc.Server it uses rather than on grpc itself.
+package grpcservice
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ambitiousfew/rxd"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+var _ rxd.ServiceRunner = (*ServerRunner)(nil)
+var _ rxd.HealthChecker = (*ServerRunner)(nil)
+
+// Server is implemented by *grpc.Server.
+type Server interface {
+	Serve(lis net.Listener) error
+	GracefulStop()
+	Stop()
+}
+
+// ServerRunner is a ServiceRunner listening in Init, serving in Run and gracefully stopping the server in Stop.
+// A grpc server cannot serve again once stopped, so a new server is created by the factory on every Init,
+// which is where the services of the server should be registered.
+type ServerRunner struct {
+	addr    string
+	factory func() Server
+	grace   time.Duration
+	report  func(serving bool)
+
+	server  Server
+	lis     net.Listener
+	serving bool
+	mu      sync.Mutex
+}
+
+// New creates a ServerRunner serving the servers created by factory on addr, e.g. ":50051".
+func New(addr string, factory func() Server, opts ...Option) *ServerRunner {
+	r := &ServerRunner{
+		addr:    addr,
+		factory: factory,
+		grace:   10 * time.Second,
+		mu:      sync.Mutex{},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *ServerRunner) Init(sctx rxd.ServiceContext) error {
+	if r.factory == nil {
+		return ErrNoServer
+	}
+
+	lis, err := net.Listen("tcp", r.addr)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.server = r.factory()
+	r.lis = lis
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *ServerRunner) Idle(sctx rxd.ServiceContext) error {
+	return nil
+}
+
+func (r *ServerRunner) Run(sctx rxd.ServiceContext) error {
+	r.mu.Lock()
+	server, lis := r.server, r.lis
+	r.mu.Unlock()
+
+	if server == nil {
+		return ErrNotServing
+	}
+
+	sctx.Log(log.LevelInfo, "grpc server listening", log.String("addr", lis.Addr().String()))
+
+	serveC := make(chan error, 1)
+	go func() {
+		serveC <- server.Serve(lis)
+	}()
+
+	healthC, cancel := rxd.WatchHealth(sctx)
+	defer cancel()
+	r.setServing(true)
+
+	for {
+		select {
+		case err := <-serveC:
+			r.setServing(false)
+			return err
+		case health, open := <-healthC:
+			if open {
+				r.setServing(health.Healthy())
+				continue
+			}
+			// the service context is done, the server is stopped in Stop.
+			healthC = nil
+		case <-sctx.Done():
+			r.setServing(false)
+			r.stop(sctx)
+			return <-serveC
+		}
+	}
+}
+
+func (r *ServerRunner) Stop(sctx rxd.ServiceContext) error {
+	r.setServing(false)
+	r.stop(sctx)
+	return nil
+}
+
+// HealthCheck reports ErrNotServing unless the server is serving.
+func (r *ServerRunner) HealthCheck(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.serving {
+		return ErrNotServing
+	}
+	return nil
+}
+
+func (r *ServerRunner) setServing(serving bool) {
+	r.mu.Lock()
+	if r.server == nil {
+		serving = false
+	}
+	r.serving = serving
+	r.mu.Unlock()
+
+	if r.report != nil {
+		r.report(serving)
+	}
+}
+
+// stop gracefully stops the server, forcing it to stop once the grace period has elapsed.
+// The server is released, a new one is created by the next Init.
+func (r *ServerRunner) stop(sctx rxd.ServiceContext) {
+	r.mu.Lock()
+	server, lis := r.server, r.lis
+	r.server, r.lis = nil, nil
+	r.mu.Unlock()
+
+	if server == nil {
+		return
+	}
+
+	doneC := make(chan struct{})
+	go func() {
+		defer close(doneC)
+		server.GracefulStop()
+	}()
+
+	select {
+	case <-doneC:
+	case <-time.After(r.grace):
+		sctx.Log(log.LevelWarning, "grpc server did not stop within its grace period, stopping it", log.String("grace", r.grace.String()))
+		server.Stop()
+		<-doneC
+	}
+
+	// the server closes the listener it served, this covers a server that never served it.
+	lis.Close()
+}
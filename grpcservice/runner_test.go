@@ -0,0 +1,100 @@
+package grpcservice
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// fakeServer accepts connections until it is stopped, like a grpc server.
+type fakeServer struct {
+	lis      net.Listener
+	graceful bool
+	mu       sync.Mutex
+}
+
+func (s *fakeServer) Serve(lis net.Listener) error {
+	s.mu.Lock()
+	s.lis = lis
+	s.mu.Unlock()
+
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			return nil
+		}
+		conn.Close()
+	}
+}
+
+func (s *fakeServer) GracefulStop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.graceful = true
+	if s.lis != nil {
+		s.lis.Close()
+	}
+}
+
+func (s *fakeServer) Stop() {
+	s.GracefulStop()
+}
+
+func TestServerRunner(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	server := &fakeServer{}
+	var mu sync.Mutex
+	var reports []bool
+	runner := New("127.0.0.1:0", func() Server { return server }, WithHealthReporter(func(serving bool) {
+		mu.Lock()
+		reports = append(reports, serving)
+		mu.Unlock()
+	}))
+
+	d := rxd.NewDaemon("test-daemon", rxd.WithServiceLogger(log.NewLogger(log.LevelError, log.NewHandler())))
+	if err := d.AddService(rxd.NewService("grpc", runner)); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	for runner.HealthCheck(ctx) != nil {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for the server to serve")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+
+	if runner.HealthCheck(context.Background()) != ErrNotServing {
+		t.Errorf("expected the server not to serve once stopped")
+	}
+
+	server.mu.Lock()
+	graceful := server.graceful
+	server.mu.Unlock()
+	if !graceful {
+		t.Errorf("expected the server to be stopped gracefully")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reports) < 2 || !reports[0] || reports[len(reports)-1] {
+		t.Errorf("expected serving to be reported then not serving, got %v", reports)
+	}
+}
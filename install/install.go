@@ -0,0 +1,238 @@
+// Package install generates and installs OS service definitions for a daemon binary,
+// a systemd unit on linux, a launchd plist on macOS or a Windows service registration.
+//
+// A daemon can expose installation as a subcommand of its own binary:
+//
+//	if len(os.Args) > 1 && os.Args[1] == "install" {
+//		err := install.Main(install.Config{Name: "myd", ReportAliveSecs: 10}, os.Args[2:])
+//		...
+//	}
+package install
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// Config describes how the service manager should run the daemon binary.
+type Config struct {
+	Name        string            // unique name of the service, used as unit name / label / service name.
+	Description string            // human readable description of the service.
+	ExecPath    string            // absolute path of the binary, defaults to the current executable.
+	Args        []string          // arguments passed to the binary.
+	WorkingDir  string            // working directory of the daemon.
+	User        string            // user to run the daemon as, empty runs as the service manager default.
+	Env         map[string]string // extra environment variables.
+	// ReportAliveSecs must match the value passed to rxd.WithReportAlive.
+	// rxd only notifies the service manager when it is set, so the generated definitions
+	// only enable notify/watchdog supervision when it is greater than 0.
+	ReportAliveSecs uint64
+	RestartDelay    time.Duration // delay before the service manager restarts a failed daemon (default: 5s).
+}
+
+// Target is the service manager a definition is generated for.
+type Target string
+
+const (
+	TargetSystemd Target = "systemd"
+	TargetLaunchd Target = "launchd"
+	TargetWindows Target = "windows"
+)
+
+var ErrUnsupportedTarget = errors.New("unsupported install target")
+
+// DefaultTarget returns the service manager of the running platform.
+func DefaultTarget() (Target, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return TargetSystemd, nil
+	case "darwin":
+		return TargetLaunchd, nil
+	case "windows":
+		return TargetWindows, nil
+	default:
+		return "", ErrUnsupportedTarget
+	}
+}
+
+func (c Config) withDefaults() (Config, error) {
+	if c.Name == "" {
+		return c, errors.New("install config requires a service name")
+	}
+
+	if c.ExecPath == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return c, err
+		}
+		c.ExecPath = exe
+	}
+
+	abs, err := filepath.Abs(c.ExecPath)
+	if err != nil {
+		return c, err
+	}
+	c.ExecPath = abs
+
+	if c.Description == "" {
+		c.Description = c.Name
+	}
+
+	if c.RestartDelay <= 0 {
+		c.RestartDelay = 5 * time.Second
+	}
+	return c, nil
+}
+
+// Generate returns the service definition of the daemon for the given target.
+// For windows the definition is the sc.exe command line used to register the service.
+func Generate(target Target, conf Config) ([]byte, error) {
+	conf, err := conf.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	switch target {
+	case TargetSystemd:
+		return systemdUnit(conf)
+	case TargetLaunchd:
+		return launchdPlist(conf)
+	case TargetWindows:
+		return windowsCommand(conf)
+	default:
+		return nil, ErrUnsupportedTarget
+	}
+}
+
+// Path returns where the definition of the daemon is installed for the given target.
+func Path(target Target, conf Config) (string, error) {
+	switch target {
+	case TargetSystemd:
+		return filepath.Join("/etc/systemd/system", conf.Name+".service"), nil
+	case TargetLaunchd:
+		return filepath.Join("/Library/LaunchDaemons", conf.Name+".plist"), nil
+	case TargetWindows:
+		// windows services live in the registry, there is no file to install.
+		return "", nil
+	default:
+		return "", ErrUnsupportedTarget
+	}
+}
+
+// Install writes the service definition for the target and registers it with the service manager.
+func Install(target Target, conf Config) error {
+	conf, err := conf.withDefaults()
+	if err != nil {
+		return err
+	}
+
+	def, err := Generate(target, conf)
+	if err != nil {
+		return err
+	}
+
+	path, err := Path(target, conf)
+	if err != nil {
+		return err
+	}
+
+	switch target {
+	case TargetSystemd:
+		if err := os.WriteFile(path, def, 0644); err != nil {
+			return err
+		}
+		if err := run("systemctl", "daemon-reload"); err != nil {
+			return err
+		}
+		return run("systemctl", "enable", conf.Name+".service")
+	case TargetLaunchd:
+		if err := os.WriteFile(path, def, 0644); err != nil {
+			return err
+		}
+		return run("launchctl", "load", "-w", path)
+	case TargetWindows:
+		return run("sc.exe", windowsArgs(conf)...)
+	default:
+		return ErrUnsupportedTarget
+	}
+}
+
+// Uninstall unregisters the service from the service manager and removes its definition.
+func Uninstall(target Target, conf Config) error {
+	path, err := Path(target, conf)
+	if err != nil {
+		return err
+	}
+
+	switch target {
+	case TargetSystemd:
+		if err := run("systemctl", "disable", conf.Name+".service"); err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		return run("systemctl", "daemon-reload")
+	case TargetLaunchd:
+		if err := run("launchctl", "unload", "-w", path); err != nil {
+			return err
+		}
+		return os.Remove(path)
+	case TargetWindows:
+		return run("sc.exe", "delete", conf.Name)
+	default:
+		return ErrUnsupportedTarget
+	}
+}
+
+// Main implements an install subcommand for a daemon binary.
+// Supported flags: -target (systemd, launchd, windows), -print (write the definition to stdout) and -uninstall.
+func Main(conf Config, args []string) error {
+	return mainWithOutput(conf, args, os.Stdout)
+}
+
+func mainWithOutput(conf Config, args []string, out io.Writer) error {
+	target, err := DefaultTarget()
+	if err != nil {
+		target = TargetSystemd
+	}
+
+	fs := flag.NewFlagSet("install", flag.ContinueOnError)
+	fs.SetOutput(out)
+	targetFlag := fs.String("target", string(target), "service manager to install for: systemd, launchd or windows")
+	printOnly := fs.Bool("print", false, "print the service definition instead of installing it")
+	uninstall := fs.Bool("uninstall", false, "uninstall the service instead of installing it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	target = Target(*targetFlag)
+	switch {
+	case *printOnly:
+		def, err := Generate(target, conf)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(def)
+		return err
+	case *uninstall:
+		return Uninstall(target, conf)
+	default:
+		return Install(target, conf)
+	}
+}
+
+func run(name string, args ...string) error {
+	output, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %v: %w: %s", name, args, err, output)
+	}
+	return nil
+}
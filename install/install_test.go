@@ -0,0 +1,56 @@
+package install
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerate_SystemdNotify(t *testing.T) {
+	unit, err := Generate(TargetSystemd, Config{
+		Name:            "myd",
+		ExecPath:        "/usr/local/bin/myd",
+		Args:            []string{"--config", "/etc/my d.conf"},
+		ReportAliveSecs: 10,
+		Env:             map[string]string{"LEVEL": "debug"},
+	})
+	if err != nil {
+		t.Fatalf("error generating unit: %s", err)
+	}
+
+	for _, want := range []string{
+		"Type=notify",
+		"WatchdogSec=20",
+		"NotifyAccess=main",
+		`ExecStart=/usr/local/bin/myd --config "/etc/my d.conf"`,
+		"Environment=LEVEL=debug",
+		"RestartSec=5",
+	} {
+		if !strings.Contains(string(unit), want) {
+			t.Fatalf("expected unit to contain %q, got:\n%s", want, unit)
+		}
+	}
+}
+
+func TestGenerate_SystemdSimple(t *testing.T) {
+	unit, err := Generate(TargetSystemd, Config{Name: "myd", ExecPath: "/usr/local/bin/myd"})
+	if err != nil {
+		t.Fatalf("error generating unit: %s", err)
+	}
+
+	if !strings.Contains(string(unit), "Type=simple") || strings.Contains(string(unit), "WatchdogSec") {
+		t.Fatalf("expected a simple unit without watchdog, got:\n%s", unit)
+	}
+}
+
+func TestGenerate_Launchd(t *testing.T) {
+	plist, err := Generate(TargetLaunchd, Config{Name: "io.rxd.myd", ExecPath: "/usr/local/bin/myd", Args: []string{"a&b"}})
+	if err != nil {
+		t.Fatalf("error generating plist: %s", err)
+	}
+
+	for _, want := range []string{"<string>io.rxd.myd</string>", "<string>a&amp;b</string>", "<key>KeepAlive</key>"} {
+		if !strings.Contains(string(plist), want) {
+			t.Fatalf("expected plist to contain %q, got:\n%s", want, plist)
+		}
+	}
+}
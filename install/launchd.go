@@ -0,0 +1,62 @@
+package install
+
+import (
+	"bytes"
+	"encoding/xml"
+	"sort"
+	"strconv"
+)
+
+func launchdPlist(conf Config) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	b.WriteString(`<plist version="1.0">` + "\n<dict>\n")
+
+	writeKey(&b, "Label", conf.Name)
+
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range append([]string{conf.ExecPath}, conf.Args...) {
+		b.WriteString("\t\t<string>" + escape(arg) + "</string>\n")
+	}
+	b.WriteString("\t</array>\n")
+
+	if conf.WorkingDir != "" {
+		writeKey(&b, "WorkingDirectory", conf.WorkingDir)
+	}
+
+	if conf.User != "" {
+		writeKey(&b, "UserName", conf.User)
+	}
+
+	if len(conf.Env) > 0 {
+		keys := make([]string, 0, len(conf.Env))
+		for k := range conf.Env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		b.WriteString("\t<key>EnvironmentVariables</key>\n\t<dict>\n")
+		for _, k := range keys {
+			b.WriteString("\t\t<key>" + escape(k) + "</key>\n\t\t<string>" + escape(conf.Env[k]) + "</string>\n")
+		}
+		b.WriteString("\t</dict>\n")
+	}
+
+	b.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	// launchd has no watchdog, restart the daemon whenever it exits unsuccessfully.
+	b.WriteString("\t<key>KeepAlive</key>\n\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>\n")
+	b.WriteString("\t<key>ThrottleInterval</key>\n\t<integer>" + strconv.Itoa(int(conf.RestartDelay.Seconds())) + "</integer>\n")
+	b.WriteString("</dict>\n</plist>\n")
+	return b.Bytes(), nil
+}
+
+func writeKey(b *bytes.Buffer, key, value string) {
+	b.WriteString("\t<key>" + key + "</key>\n\t<string>" + escape(value) + "</string>\n")
+}
+
+func escape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
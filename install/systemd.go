@@ -0,0 +1,95 @@
+package install
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+var systemdTemplate = template.Must(template.New("systemd").Parse(`[Unit]
+Description={{ .Description }}
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type={{ .Type }}
+ExecStart={{ .ExecStart }}
+{{- if .WorkingDir }}
+WorkingDirectory={{ .WorkingDir }}
+{{- end }}
+{{- if .User }}
+User={{ .User }}
+{{- end }}
+{{- range .Env }}
+Environment={{ . }}
+{{- end }}
+{{- if .Notify }}
+NotifyAccess=main
+WatchdogSec={{ .WatchdogSec }}
+{{- end }}
+Restart=on-failure
+RestartSec={{ .RestartSec }}
+KillSignal=SIGTERM
+
+[Install]
+WantedBy=multi-user.target
+`))
+
+func systemdUnit(conf Config) ([]byte, error) {
+	notify := conf.ReportAliveSecs > 0
+
+	data := struct {
+		Config
+		Type        string
+		ExecStart   string
+		Notify      bool
+		WatchdogSec uint64
+		RestartSec  string
+		Env         []string
+	}{
+		Config:     conf,
+		Type:       "simple",
+		ExecStart:  systemdQuote(append([]string{conf.ExecPath}, conf.Args...)),
+		Notify:     notify,
+		RestartSec: strconv.FormatFloat(conf.RestartDelay.Seconds(), 'f', -1, 64),
+		Env:        sortedEnv(conf.Env, systemdQuoteArg),
+	}
+
+	if notify {
+		data.Type = "notify"
+		// rxd reports alive every ReportAliveSecs, give systemd room for one missed report.
+		data.WatchdogSec = conf.ReportAliveSecs * 2
+	}
+
+	var b bytes.Buffer
+	if err := systemdTemplate.Execute(&b, data); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func systemdQuote(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = systemdQuoteArg(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func systemdQuoteArg(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\") {
+		return arg
+	}
+	return strconv.Quote(arg)
+}
+
+func sortedEnv(env map[string]string, quote func(string) string) []string {
+	out := make([]string, 0, len(env))
+	for k, v := range env {
+		out = append(out, quote(k+"="+v))
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,46 @@
+package install
+
+import (
+	"strconv"
+	"strings"
+)
+
+// windowsArgs returns the sc.exe arguments registering the daemon as a Windows service.
+func windowsArgs(conf Config) []string {
+	binPath := make([]string, 0, len(conf.Args)+1)
+	for _, arg := range append([]string{conf.ExecPath}, conf.Args...) {
+		binPath = append(binPath, windowsQuote(arg))
+	}
+
+	args := []string{
+		"create", conf.Name,
+		"binPath=", strings.Join(binPath, " "),
+		"start=", "auto",
+		"DisplayName=", conf.Description,
+	}
+
+	if conf.User != "" {
+		args = append(args, "obj=", conf.User)
+	}
+	return args
+}
+
+// windowsCommand renders the sc.exe command lines registering the service and its restart policy.
+func windowsCommand(conf Config) ([]byte, error) {
+	create := append([]string{"sc.exe"}, windowsArgs(conf)...)
+	for i, arg := range create {
+		create[i] = windowsQuote(arg)
+	}
+
+	restartMs := strconv.FormatInt(conf.RestartDelay.Milliseconds(), 10)
+	failure := []string{"sc.exe", "failure", windowsQuote(conf.Name), "reset=", "86400", "actions=", "restart/" + restartMs}
+	return []byte(strings.Join(create, " ") + "\r\n" + strings.Join(failure, " ") + "\r\n"), nil
+}
+
+// windowsQuote quotes an argument for the windows command line when it contains spaces or quotes.
+func windowsQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"") {
+		return arg
+	}
+	return `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+}
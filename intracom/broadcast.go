@@ -1,5 +1,9 @@
 package intracom
 
+// Broadcaster receives published messages and fans them out to all subscribers of a topic.
+// The topic sends SubscribeRequest, UnsubscribeRequest and CloseRequest values over requests,
+// each of which must be answered on its response channel.
+// The intracom/broadcastertest package can be used to verify custom implementations.
 type Broadcaster[T any] interface {
 	Broadcast(requests <-chan any, messages chan T)
 }
@@ -0,0 +1,385 @@
+package intracom
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultBufferedCapacity is the ring size of the consumer groups subscribing without a BufferSize.
+const defaultBufferedCapacity = 64
+
+// BufferedBroadcaster fans messages out to every consumer group through a ring buffer of its own, each delivered
+// by a routine of its own, so a slow consumer group only holds up the publishers when its buffer policy waits.
+// The ring of a consumer group holds its SubscriberConfig.BufferSize messages, or Capacity without one,
+// and its buffer policy decides what happens to a message once the ring is full:
+//
+//   - BufferPolicyDropOldest drops the oldest message of the ring, BufferPolicyDropNewest the new message.
+//   - BufferPolicyDropOldestAfterTimeout and BufferPolicyDropNewestAfterTimeout wait up to their drop timeout
+//     for room before dropping.
+//   - BufferPolicyDropNone, or any other policy, waits for room and holds up the publishers meanwhile.
+//
+// A new consumer group first receives the last message published before it subscribed. Messages still in the
+// ring of a consumer group when it unsubscribes or the topic closes are dropped. The deliver and drop hooks of
+// the topic are called from the routines of the consumer groups.
+type BufferedBroadcaster[T any] struct {
+	Capacity        int  // ring size of the consumer groups subscribing without a BufferSize (default: 64).
+	SubscriberAware bool // if true, broadcaster wont broadcast if there are no subscribers.
+}
+
+// retention is what a consumer group of a BufferedBroadcaster does with a message once its ring is full.
+type retention uint8
+
+const (
+	retainBlock retention = iota
+	retainDropOldest
+	retainDropNewest
+)
+
+// bufferedState is owned by the routine of a BufferedBroadcaster.
+type bufferedState[T any] struct {
+	b            BufferedBroadcaster[T]
+	recv         <-chan T
+	broadcast    chan T
+	broadcasting bool
+	consumers    map[string]*bufferedConsumer[T]
+	lastMessage  T
+	hasLast      bool
+}
+
+func (b BufferedBroadcaster[T]) Broadcast(requests <-chan any, broadcast chan T) {
+	s := &bufferedState[T]{
+		b:         b,
+		broadcast: broadcast,
+		consumers: make(map[string]*bufferedConsumer[T]),
+	}
+	if !b.SubscriberAware {
+		s.recv = broadcast
+		s.broadcasting = true
+	}
+
+	for {
+		select {
+		case msg, ok := <-s.recv:
+			if !ok {
+				s.stopAll()
+				return
+			}
+
+			// consumer groups subscribing while a full ring holds up the fan-out receive msg as their last message,
+			// so they are left out of it.
+			targets := make([]*bufferedConsumer[T], 0, len(s.consumers))
+			for _, c := range s.consumers {
+				targets = append(targets, c)
+			}
+			s.lastMessage = msg
+			s.hasLast = true
+
+			at := time.Now()
+			for _, c := range targets {
+				if c.filter != nil && !c.filter(msg) {
+					continue
+				}
+				if !s.offer(c, bufferedItem[T]{msg: msg, at: at}, requests) {
+					return
+				}
+			}
+
+		case request, open := <-requests:
+			if !open {
+				s.stopAll()
+				return
+			}
+			s.handle(request)
+		}
+	}
+}
+
+// offer hands the item to the consumer group, waiting for room in its ring when its policy says so.
+// Requests are still answered while waiting, it reports false once the requests channel is closed.
+func (s *bufferedState[T]) offer(c *bufferedConsumer[T], item bufferedItem[T], requests <-chan any) bool {
+	if c.push(item) {
+		return true
+	}
+
+	var timeoutC <-chan time.Time
+	if c.timeout > 0 {
+		timer := time.NewTimer(c.timeout)
+		defer timer.Stop()
+		timeoutC = timer.C
+	}
+
+	for {
+		select {
+		case <-c.roomC:
+			if c.push(item) {
+				return true
+			}
+		case <-timeoutC:
+			c.evict(item)
+			return true
+		case <-c.stopC:
+			// the consumer group unsubscribed or the topic closed meanwhile.
+			return true
+		case request, open := <-requests:
+			if !open {
+				s.stopAll()
+				return false
+			}
+			s.handle(request)
+		}
+	}
+}
+
+func (s *bufferedState[T]) handle(request any) {
+	switch r := request.(type) {
+	case SubscribeRequest[T]:
+		c, exists := s.consumers[r.Conf.ConsumerGroup]
+		if r.Snapshot && !s.hasLast {
+			r.ResponseC <- SubscribeResponse[T]{Err: ErrNoSnapshot}
+			return
+		}
+
+		if exists && r.Conf.ErrIfExists {
+			r.ResponseC <- SubscribeResponse[T]{Ch: c.out, Err: ErrConsumerAlreadyExists}
+			return
+		}
+
+		if !exists {
+			c = newBufferedConsumer(r.Conf, s.b.Capacity)
+			s.consumers[r.Conf.ConsumerGroup] = c
+			// the messages wait in the ring rather than in the unbuffered subscriber channel.
+			r.Conf.hooks.track(r.Conf.ConsumerGroup).setDepth(c.depth)
+			// the ring of a new consumer group always has room for the last message.
+			// snapshot subscribers receive it in the response instead.
+			if s.hasLast && !r.Snapshot && (c.filter == nil || c.filter(s.lastMessage)) {
+				c.push(bufferedItem[T]{msg: s.lastMessage, at: time.Now()})
+			}
+			go c.deliver()
+		}
+		r.ResponseC <- SubscribeResponse[T]{Ch: c.out, Last: s.lastMessage}
+
+		if s.b.SubscriberAware && !s.broadcasting && len(s.consumers) > 0 {
+			// enable broadcasting if we have subscribers
+			s.recv = s.broadcast
+			s.broadcasting = true
+		}
+
+	case UnsubscribeRequest[T]:
+		if c, exists := s.consumers[r.Consumer]; exists {
+			if (<-chan T)(c.out) != r.Ch {
+				r.ResponseC <- UnsubscribeResponse{Err: ErrConsumerMismatch}
+				return
+			}
+			delete(s.consumers, r.Consumer)
+			c.stop()
+		}
+		r.ResponseC <- UnsubscribeResponse{}
+
+		if s.b.SubscriberAware && s.broadcasting && len(s.consumers) < 1 {
+			// disable broadcasting if we have no subscribers
+			s.recv = nil
+			s.broadcasting = false
+		}
+
+	case CloseRequest:
+		s.recv = nil // disable anymore publishing.
+		s.broadcasting = false
+		s.stopAll()
+		r.ResponseC <- CloseResponse{}
+
+	default:
+		// unknown request, do nothing.
+	}
+}
+
+func (s *bufferedState[T]) stopAll() {
+	for name, c := range s.consumers {
+		delete(s.consumers, name)
+		c.stop()
+	}
+}
+
+type bufferedItem[T any] struct {
+	msg T
+	at  time.Time // when the message entered the ring, the deliver hook reports the time it spent there.
+}
+
+// bufferedConsumer is a consumer group of a BufferedBroadcaster, its routine hands the messages
+// of its ring over to the subscriber channel.
+type bufferedConsumer[T any] struct {
+	group   string
+	policy  retention
+	timeout time.Duration // time waited for room before dropping, 0 waits for as long as it takes.
+	hooks   *topicHooks[T]
+	filter  func(msg T) bool
+	out     chan T
+	readyC  chan struct{} // wakes the routine once a message entered the empty ring.
+	roomC   chan struct{} // wakes the broadcaster waiting for room once a message left the ring.
+	stopC   chan struct{}
+	doneC   chan struct{}
+
+	mu    sync.Mutex
+	ring  []bufferedItem[T]
+	head  int
+	count int
+}
+
+func newBufferedConsumer[T any](conf SubscriberConfig[T], capacity int) *bufferedConsumer[T] {
+	size := conf.BufferSize
+	if size <= 0 {
+		size = capacity
+	}
+	if size <= 0 {
+		size = defaultBufferedCapacity
+	}
+
+	c := &bufferedConsumer[T]{
+		group:  conf.ConsumerGroup,
+		policy: retainBlock,
+		hooks:  conf.hooks,
+		filter: conf.Filter,
+		out:    make(chan T),
+		readyC: make(chan struct{}, 1),
+		roomC:  make(chan struct{}, 1),
+		stopC:  make(chan struct{}),
+		doneC:  make(chan struct{}),
+		ring:   make([]bufferedItem[T], size),
+	}
+
+	switch bp := conf.BufferPolicy.(type) {
+	case BufferPolicyDropOldest[T]:
+		c.policy = retainDropOldest
+	case BufferPolicyDropNewest[T]:
+		c.policy = retainDropNewest
+	case BufferPolicyDropOldestAfterTimeout[T]:
+		c.policy, c.timeout = retainDropOldest, bp.DropTimeout
+		if c.timeout == 0 {
+			c.timeout = conf.DropTimeout
+		}
+	case BufferPolicyDropNewestAfterTimeout[T]:
+		c.policy, c.timeout = retainDropNewest, bp.DropTimout
+		if c.timeout == 0 {
+			c.timeout = conf.DropTimeout
+		}
+	}
+	return c
+}
+
+// push adds the item to the ring, or applies the policy when the ring is full.
+// It reports false when the ring is full and the item must wait for room.
+func (c *bufferedConsumer[T]) push(item bufferedItem[T]) bool {
+	c.mu.Lock()
+	if c.count == len(c.ring) {
+		if c.policy == retainBlock || c.timeout > 0 {
+			c.mu.Unlock()
+			return false
+		}
+		c.mu.Unlock()
+		c.evict(item)
+		return true
+	}
+	c.ring[(c.head+c.count)%len(c.ring)] = item
+	c.count++
+	c.mu.Unlock()
+
+	select {
+	case c.readyC <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// evict makes room for the item according to the policy once waiting for it is over,
+// dropping either the oldest message of the ring or the item itself.
+func (c *bufferedConsumer[T]) evict(item bufferedItem[T]) {
+	if c.policy != retainDropOldest {
+		c.hooks.dropped(c.group, item.msg, ErrMessageDropped)
+		return
+	}
+
+	c.mu.Lock()
+	var oldest bufferedItem[T]
+	full := c.count == len(c.ring)
+	if full {
+		oldest = c.ring[c.head]
+		c.ring[c.head] = item
+		c.head = (c.head + 1) % len(c.ring)
+	} else {
+		c.ring[(c.head+c.count)%len(c.ring)] = item
+		c.count++
+	}
+	c.mu.Unlock()
+
+	if full {
+		c.hooks.dropped(c.group, oldest.msg, ErrMessageDropped)
+	}
+	select {
+	case c.readyC <- struct{}{}:
+	default:
+	}
+}
+
+func (c *bufferedConsumer[T]) pop() (bufferedItem[T], bool) {
+	c.mu.Lock()
+	if c.count == 0 {
+		c.mu.Unlock()
+		return bufferedItem[T]{}, false
+	}
+	item := c.ring[c.head]
+	c.ring[c.head] = bufferedItem[T]{}
+	c.head = (c.head + 1) % len(c.ring)
+	c.count--
+	c.mu.Unlock()
+
+	select {
+	case c.roomC <- struct{}{}:
+	default:
+	}
+	return item, true
+}
+
+// depth returns the messages in the ring and its size.
+func (c *bufferedConsumer[T]) depth() (int, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.count, len(c.ring)
+}
+
+func (c *bufferedConsumer[T]) deliver() {
+	defer close(c.doneC)
+	defer close(c.out)
+
+	for {
+		item, ok := c.pop()
+		if !ok {
+			select {
+			case <-c.stopC:
+				return
+			case <-c.readyC:
+			}
+			continue
+		}
+
+		select {
+		case <-c.stopC:
+			c.hooks.dropped(c.group, item.msg, ErrSubscriberClosed)
+			return
+		case c.out <- item.msg:
+			c.hooks.delivered(c.group, item.msg, time.Since(item.at))
+		}
+	}
+}
+
+// stop ends the routine of the consumer group, closing its channel. The messages left in the ring are dropped.
+func (c *bufferedConsumer[T]) stop() {
+	close(c.stopC)
+	<-c.doneC
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for ; c.count > 0; c.count-- {
+		c.hooks.dropped(c.group, c.ring[c.head].msg, ErrSubscriberClosed)
+		c.ring[c.head] = bufferedItem[T]{}
+		c.head = (c.head + 1) % len(c.ring)
+	}
+}
@@ -0,0 +1,96 @@
+package intracom
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBufferedBroadcaster_Policies(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	testTopic := NewTopic[int](TopicConfig{Name: t.Name()}, WithBroadcaster[int](BufferedBroadcaster[int]{}))
+	defer testTopic.Close()
+
+	oldest, err := testTopic.Subscribe(ctx, SubscriberConfig[int]{ConsumerGroup: "oldest", BufferSize: 3, BufferPolicy: BufferPolicyDropOldest[int]{}})
+	if err != nil {
+		t.Fatalf("error subscribing: %v", err)
+	}
+	newest, err := testTopic.Subscribe(ctx, SubscriberConfig[int]{ConsumerGroup: "newest", BufferSize: 3, BufferPolicy: BufferPolicyDropNewest[int]{}})
+	if err != nil {
+		t.Fatalf("error subscribing: %v", err)
+	}
+
+	// neither consumer group receives, their rings fill up without holding up the publisher.
+	for i := 1; i <= 10; i++ {
+		if err := testTopic.Publish(ctx, i); err != nil {
+			t.Fatalf("error publishing %d: %v", i, err)
+		}
+	}
+
+	// drain receives until nothing more arrives.
+	drain := func(ch <-chan int) []int {
+		var got []int
+		for {
+			select {
+			case msg := <-ch:
+				got = append(got, msg)
+			case <-time.After(50 * time.Millisecond):
+				return got
+			}
+		}
+	}
+
+	// besides its ring, the routine of a group may hold the message it is handing over.
+	got := drain(oldest)
+	if len(got) < 3 || len(got) > 4 || !slices.IsSorted(got) || !slices.Equal(got[len(got)-3:], []int{8, 9, 10}) {
+		t.Errorf("expected the oldest messages to be dropped, got %v", got)
+	}
+	got = drain(newest)
+	if len(got) < 3 || len(got) > 4 || !slices.IsSorted(got) || !slices.Equal(got[:3], []int{1, 2, 3}) {
+		t.Errorf("expected the newest messages to be dropped, got %v", got)
+	}
+}
+
+func TestBufferedBroadcaster_Block(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	testTopic := NewTopic[int](TopicConfig{Name: t.Name()}, WithBroadcaster[int](BufferedBroadcaster[int]{}))
+	defer testTopic.Close()
+
+	blocking, err := testTopic.Subscribe(ctx, SubscriberConfig[int]{ConsumerGroup: "blocking", BufferSize: 2})
+	if err != nil {
+		t.Fatalf("error subscribing: %v", err)
+	}
+
+	// the ring holds 2 messages and the routine 1, the fourth holds up the broadcaster.
+	for i := 1; i <= 4; i++ {
+		if err := testTopic.Publish(ctx, i); err != nil {
+			t.Fatalf("error publishing %d: %v", i, err)
+		}
+	}
+	publishCtx, publishCancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer publishCancel()
+	if err := testTopic.Publish(publishCtx, 5); err != context.DeadlineExceeded {
+		t.Fatalf("expected the full ring to hold up the publisher, got: %v", err)
+	}
+
+	// requests are still answered while the broadcaster waits for room.
+	other, err := testTopic.Subscribe(ctx, SubscriberConfig[int]{ConsumerGroup: "other", BufferSize: 1})
+	if err != nil {
+		t.Fatalf("error subscribing while held up: %v", err)
+	}
+
+	for want := 1; want <= 4; want++ {
+		if got := <-blocking; got != want {
+			t.Fatalf("expected %d, got %d", want, got)
+		}
+	}
+	// the message held up is the last one the new group subscribed after.
+	if got := <-other; got != 4 {
+		t.Fatalf("expected the last message 4 to be replayed, got %d", got)
+	}
+}
@@ -1,11 +1,8 @@
 package intracom
 
-import (
-	"errors"
-)
-
 type SyncBroadcaster[T any] struct {
 	SubscriberAware bool // if true, broadcaster wont broadcast if there are no subscribers.
+	ReplayLast      bool // if true, new subscribers always receive the last message, unbuffered ones get room for it.
 }
 
 func (b SyncBroadcaster[T]) Broadcast(requests <-chan any, broadcast chan T) {
@@ -22,6 +19,7 @@ func (b SyncBroadcaster[T]) Broadcast(requests <-chan any, broadcast chan T) {
 	}
 
 	var lastMessage T
+	var hasLast bool // only replay the last message once something has been broadcasted.
 	for {
 		select {
 		case msg, ok := <-recv:
@@ -40,6 +38,7 @@ func (b SyncBroadcaster[T]) Broadcast(requests <-chan any, broadcast chan T) {
 
 			// store the previous broadcasted message.
 			lastMessage = msg
+			hasLast = true
 
 		case request, open := <-requests:
 			if !open {
@@ -48,26 +47,39 @@ func (b SyncBroadcaster[T]) Broadcast(requests <-chan any, broadcast chan T) {
 			}
 
 			switch r := request.(type) {
-			case subscribeRequest[T]:
+			case SubscribeRequest[T]:
 				// handle subscribe request
-				sub, exists := subscribers[r.conf.ConsumerGroup]
-				if exists && r.conf.ErrIfExists {
-					r.responseC <- subscribeResponse[T]{ch: sub.Chan(), err: errors.New("consumer group '" + r.conf.ConsumerGroup + "' already exists")}
+				sub, exists := subscribers[r.Conf.ConsumerGroup]
+				if r.Snapshot && !hasLast {
+					r.ResponseC <- SubscribeResponse[T]{Err: ErrNoSnapshot}
+					continue
+				}
+
+				if exists && r.Conf.ErrIfExists {
+					r.ResponseC <- SubscribeResponse[T]{Ch: sub.Chan(), Err: ErrConsumerAlreadyExists}
 					continue
 				}
 
 				if !exists {
-					newSub := newSubscriber[T](r.conf)
-					subscribers[r.conf.ConsumerGroup] = newSub
+					conf := r.Conf
+					if b.ReplayLast && hasLast && !r.Snapshot && conf.BufferSize < 1 {
+						// the replayed message must not be dropped for lack of room.
+						conf.BufferSize = 1
+					}
+					newSub := newSubscriber[T](conf)
+					subscribers[r.Conf.ConsumerGroup] = newSub
 					// if you are a new subscriber, then we try to send the last message of topic.
-					select {
-					case newSub.ch <- lastMessage:
-					default:
-						// if the channel is full or unbuffered, then we dont send last message.
+					// snapshot subscribers receive it in the response instead.
+					if hasLast && !r.Snapshot && newSub.accepts(lastMessage) {
+						select {
+						case newSub.ch <- lastMessage:
+						default:
+							// if the channel is full or unbuffered, then we dont send last message.
+						}
 					}
-					r.responseC <- subscribeResponse[T]{ch: newSub.ch, err: nil}
+					r.ResponseC <- SubscribeResponse[T]{Ch: newSub.ch, Last: lastMessage, Err: nil}
 				} else {
-					r.responseC <- subscribeResponse[T]{ch: sub.Chan(), err: nil}
+					r.ResponseC <- SubscribeResponse[T]{Ch: sub.Chan(), Last: lastMessage, Err: nil}
 				}
 
 				if b.SubscriberAware && !broadcasting && len(subscribers) > 0 {
@@ -76,26 +88,26 @@ func (b SyncBroadcaster[T]) Broadcast(requests <-chan any, broadcast chan T) {
 					broadcasting = true
 				}
 
-			case unsubscribeRequest[T]:
+			case UnsubscribeRequest[T]:
 				// handle unsubscribe request
-				sub, exists := subscribers[r.consumer]
+				sub, exists := subscribers[r.Consumer]
 				if exists {
-					if sub.Chan() != r.ch {
+					if sub.Chan() != r.Ch {
 						// if the channel is not the same, then we cannot unsubscribe
-						r.responseC <- unsubscribeResponse{err: errors.New("consumer group channel'" + r.consumer + "' does not match")}
+						r.ResponseC <- UnsubscribeResponse{Err: ErrConsumerMismatch}
 						continue
 					}
 
-					delete(subscribers, r.consumer)
+					delete(subscribers, r.Consumer)
 					err := sub.Close()
 					if err != nil {
-						r.responseC <- unsubscribeResponse{err: err}
+						r.ResponseC <- UnsubscribeResponse{Err: err}
 						continue
 					}
 				}
 
 				// TODO: we could capture sub close errors now and pass it in the unsubscribe response.
-				r.responseC <- unsubscribeResponse{err: nil}
+				r.ResponseC <- UnsubscribeResponse{Err: nil}
 
 				if b.SubscriberAware && broadcasting && len(subscribers) < 1 {
 					// disable broadcasting if we have no subscribers
@@ -103,7 +115,7 @@ func (b SyncBroadcaster[T]) Broadcast(requests <-chan any, broadcast chan T) {
 					broadcasting = false
 				}
 
-			case closeRequest:
+			case CloseRequest:
 				recv = nil // disable anymore publishing.
 				broadcasting = false
 
@@ -116,7 +128,7 @@ func (b SyncBroadcaster[T]) Broadcast(requests <-chan any, broadcast chan T) {
 					}
 				}
 				// signal back that we are done
-				r.responseC <- closeResponse{}
+				r.ResponseC <- CloseResponse{}
 			default:
 				// unknown request, do nothing.
 			}
@@ -0,0 +1,281 @@
+// Package broadcastertest provides a conformance and benchmark suite for implementations of intracom.Broadcaster.
+// Custom broadcasters (sharded, lock-free, etc.) can run the suite from their own tests:
+//
+//	func TestMyBroadcaster(t *testing.T) {
+//		broadcastertest.Run(t, func() intracom.Broadcaster[int] { return &MyBroadcaster[int]{} }, broadcastertest.Options{})
+//	}
+package broadcastertest
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/intracom"
+)
+
+// Factory returns a new, unused broadcaster for every call.
+type Factory func() intracom.Broadcaster[int]
+
+// Options describes the guarantees the broadcaster under test claims to provide.
+type Options struct {
+	// SubscriberAware is true when the broadcaster does not accept published messages until it has subscribers.
+	SubscriberAware bool
+	// Messages is the number of messages published by the ordering and message loss checks (default: 1000).
+	Messages int
+	// Timeout is the max time any single check may wait on the broadcaster (default: 2s).
+	Timeout time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.Messages <= 0 {
+		o.Messages = 1000
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 2 * time.Second
+	}
+	return o
+}
+
+// Run runs the conformance suite against broadcasters created by the factory.
+func Run(t *testing.T, factory Factory, opts Options) {
+	opts = opts.withDefaults()
+
+	t.Run("OrderingWithoutLoss", func(t *testing.T) { testOrdering(t, factory, opts) })
+	t.Run("MultipleSubscribers", func(t *testing.T) { testMultipleSubscribers(t, factory, opts) })
+	t.Run("DuplicateConsumerGroup", func(t *testing.T) { testDuplicateConsumerGroup(t, factory, opts) })
+	t.Run("Unsubscribe", func(t *testing.T) { testUnsubscribe(t, factory, opts) })
+	t.Run("Close", func(t *testing.T) { testClose(t, factory, opts) })
+	t.Run("SubscriberAware", func(t *testing.T) { testSubscriberAware(t, factory, opts) })
+}
+
+// Benchmark measures the cost of publishing a message to the given number of draining subscribers.
+func Benchmark(b *testing.B, factory Factory, subscribers int) {
+	topic := intracom.NewTopic[int](intracom.TopicConfig{Name: b.Name()}, intracom.WithBroadcaster(factory()))
+	defer topic.Close()
+
+	ctx := context.Background()
+	for i := 0; i < subscribers; i++ {
+		sub, err := topic.Subscribe(ctx, subscriberConfig("bench-"+strconv.Itoa(i), 64))
+		if err != nil {
+			b.Fatalf("error subscribing: %s", err)
+		}
+
+		go func() {
+			for range sub {
+			}
+		}()
+	}
+
+	publishC := topic.PublishChannel()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		publishC <- i
+	}
+}
+
+func newTopic(t *testing.T, factory Factory) intracom.Topic[int] {
+	t.Helper()
+	return intracom.NewTopic[int](intracom.TopicConfig{Name: t.Name()}, intracom.WithBroadcaster(factory()))
+}
+
+func subscriberConfig(consumer string, size int) intracom.SubscriberConfig[int] {
+	return intracom.SubscriberConfig[int]{
+		ConsumerGroup: consumer,
+		ErrIfExists:   true,
+		BufferSize:    size,
+		BufferPolicy:  intracom.BufferPolicyDropNone[int]{},
+	}
+}
+
+func subscribe(t *testing.T, topic intracom.Topic[int], consumer string, opts Options) <-chan int {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	sub, err := topic.Subscribe(ctx, subscriberConfig(consumer, 16))
+	if err != nil {
+		t.Fatalf("error subscribing consumer '%s': %s", consumer, err)
+	}
+	return sub
+}
+
+func publish(topic intracom.Topic[int], count int) <-chan struct{} {
+	doneC := make(chan struct{})
+	go func() {
+		defer close(doneC)
+		publishC := topic.PublishChannel()
+		for i := 1; i <= count; i++ {
+			publishC <- i
+		}
+	}()
+	return doneC
+}
+
+// receive expects messages 1..count in order on the subscriber channel.
+func receive(t *testing.T, sub <-chan int, count int, opts Options) {
+	t.Helper()
+	timeout := time.NewTimer(opts.Timeout)
+	defer timeout.Stop()
+
+	for want := 1; want <= count; want++ {
+		select {
+		case <-timeout.C:
+			t.Fatalf("timed out waiting for message %d of %d", want, count)
+		case got, open := <-sub:
+			if !open {
+				t.Fatalf("subscriber closed after %d of %d messages", want-1, count)
+			}
+			if got != want {
+				t.Fatalf("expected message %d, got %d", want, got)
+			}
+		}
+	}
+}
+
+func closeTopic(t *testing.T, topic intracom.Topic[int], opts Options) {
+	t.Helper()
+	errC := make(chan error, 1)
+	go func() {
+		errC <- topic.Close()
+	}()
+
+	select {
+	case err := <-errC:
+		if err != nil {
+			t.Fatalf("error closing topic: %s", err)
+		}
+	case <-time.After(opts.Timeout):
+		t.Fatalf("timed out closing topic, broadcaster did not answer the close request")
+	}
+}
+
+func testOrdering(t *testing.T, factory Factory, opts Options) {
+	topic := newTopic(t, factory)
+	sub := subscribe(t, topic, "ordering", opts)
+
+	doneC := publish(topic, opts.Messages)
+	receive(t, sub, opts.Messages, opts)
+	<-doneC
+	closeTopic(t, topic, opts)
+}
+
+func testMultipleSubscribers(t *testing.T, factory Factory, opts Options) {
+	topic := newTopic(t, factory)
+	sub1 := subscribe(t, topic, "first", opts)
+	sub2 := subscribe(t, topic, "second", opts)
+
+	doneC := publish(topic, opts.Messages)
+	receivedC := make(chan struct{})
+	go func() {
+		defer close(receivedC)
+		receive(t, sub2, opts.Messages, opts)
+	}()
+	receive(t, sub1, opts.Messages, opts)
+	<-receivedC
+	<-doneC
+	closeTopic(t, topic, opts)
+}
+
+func testDuplicateConsumerGroup(t *testing.T, factory Factory, opts Options) {
+	topic := newTopic(t, factory)
+	defer closeTopic(t, topic, opts)
+
+	sub := subscribe(t, topic, "duplicate", opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+	dup, err := topic.Subscribe(ctx, subscriberConfig("duplicate", 16))
+	if err == nil {
+		t.Fatalf("expected error subscribing a duplicate consumer group with ErrIfExists")
+	}
+
+	if dup != sub {
+		t.Fatalf("expected the existing subscriber channel to be returned with the error")
+	}
+
+	conf := subscriberConfig("duplicate", 16)
+	conf.ErrIfExists = false
+	same, err := topic.Subscribe(ctx, conf)
+	if err != nil {
+		t.Fatalf("expected no error subscribing an existing consumer group without ErrIfExists: %s", err)
+	}
+
+	if same != sub {
+		t.Fatalf("expected the existing subscriber channel to be returned")
+	}
+}
+
+func testUnsubscribe(t *testing.T, factory Factory, opts Options) {
+	topic := newTopic(t, factory)
+	defer closeTopic(t, topic, opts)
+
+	sub := subscribe(t, topic, "unsubscribe", opts)
+	other := subscribe(t, topic, "other", opts)
+
+	if err := topic.Unsubscribe("unsubscribe", other); err == nil {
+		t.Fatalf("expected error unsubscribing with a channel that does not belong to the consumer group")
+	}
+
+	if err := topic.Unsubscribe("unsubscribe", sub); err != nil {
+		t.Fatalf("error unsubscribing: %s", err)
+	}
+
+	select {
+	case _, open := <-sub:
+		if open {
+			t.Fatalf("expected no messages on an unsubscribed channel")
+		}
+	case <-time.After(opts.Timeout):
+		t.Fatalf("expected subscriber channel to be closed after unsubscribe")
+	}
+}
+
+func testClose(t *testing.T, factory Factory, opts Options) {
+	topic := newTopic(t, factory)
+	sub := subscribe(t, topic, "close", opts)
+
+	closeTopic(t, topic, opts)
+
+	select {
+	case _, open := <-sub:
+		if open {
+			t.Fatalf("expected no messages after close")
+		}
+	case <-time.After(opts.Timeout):
+		t.Fatalf("expected subscriber channel to be closed after topic close")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+	if _, err := topic.Subscribe(ctx, subscriberConfig("after-close", 1)); err == nil {
+		t.Fatalf("expected error subscribing to a closed topic")
+	}
+}
+
+func testSubscriberAware(t *testing.T, factory Factory, opts Options) {
+	topic := newTopic(t, factory)
+	defer closeTopic(t, topic, opts)
+
+	publishC := topic.PublishChannel()
+	select {
+	case publishC <- 1:
+		if opts.SubscriberAware {
+			t.Fatalf("expected a subscriber aware broadcaster not to accept messages without subscribers")
+		}
+	case <-time.After(50 * time.Millisecond):
+		if !opts.SubscriberAware {
+			t.Fatalf("expected broadcaster to accept messages without subscribers")
+		}
+	}
+
+	if !opts.SubscriberAware {
+		return
+	}
+
+	sub := subscribe(t, topic, "aware", opts)
+	doneC := publish(topic, 1)
+	receive(t, sub, 1, opts)
+	<-doneC
+}
@@ -0,0 +1,43 @@
+package broadcastertest
+
+import (
+	"testing"
+
+	"github.com/ambitiousfew/rxd/intracom"
+)
+
+func TestSyncBroadcaster(t *testing.T) {
+	Run(t, func() intracom.Broadcaster[int] {
+		return intracom.SyncBroadcaster[int]{}
+	}, Options{})
+}
+
+func TestSyncBroadcaster_SubscriberAware(t *testing.T) {
+	Run(t, func() intracom.Broadcaster[int] {
+		return intracom.SyncBroadcaster[int]{SubscriberAware: true}
+	}, Options{SubscriberAware: true})
+}
+
+func BenchmarkSyncBroadcaster(b *testing.B) {
+	Benchmark(b, func() intracom.Broadcaster[int] {
+		return intracom.SyncBroadcaster[int]{}
+	}, 4)
+}
+
+func TestBufferedBroadcaster(t *testing.T) {
+	Run(t, func() intracom.Broadcaster[int] {
+		return intracom.BufferedBroadcaster[int]{}
+	}, Options{})
+}
+
+func TestBufferedBroadcaster_SubscriberAware(t *testing.T) {
+	Run(t, func() intracom.Broadcaster[int] {
+		return intracom.BufferedBroadcaster[int]{SubscriberAware: true}
+	}, Options{SubscriberAware: true})
+}
+
+func BenchmarkBufferedBroadcaster(b *testing.B) {
+	Benchmark(b, func() intracom.Broadcaster[int] {
+		return intracom.BufferedBroadcaster[int]{}
+	}, 4)
+}
@@ -1,9 +1,6 @@
 package intracom
 
-import (
-	"errors"
-	"time"
-)
+import "time"
 
 type BufferPolicyHandler[T any] interface {
 	Handle(ch chan T, message T, stopC <-chan struct{}) error
@@ -14,7 +11,7 @@ type BufferPolicyDropNone[T any] struct{}
 func (d BufferPolicyDropNone[T]) Handle(ch chan T, message T, stopC <-chan struct{}) error {
 	select {
 	case <-stopC:
-		return errors.New("subscriber stopped")
+		return ErrSubscriberStopped
 	case ch <- message:
 		return nil
 	}
@@ -26,7 +23,7 @@ func (d BufferPolicyDropOldest[T]) Handle(ch chan T, message T, stopC <-chan str
 	select {
 	case <-stopC:
 		// subscriber stopped dont try to send the message
-		return errors.New("subscriber stopped")
+		return ErrSubscriberStopped
 	case ch <- message:
 		// we succeeded at pushing the message
 		return nil
@@ -34,22 +31,31 @@ func (d BufferPolicyDropOldest[T]) Handle(ch chan T, message T, stopC <-chan str
 		// we failed to push the message buffer is full
 	}
 
+	return dropOldest(ch, message, stopC)
+}
+
+// dropOldest makes room for the message by dropping the oldest buffered message.
+// The pop never blocks: the consumer may have drained the buffer meanwhile and
+// an unbuffered channel has nothing to pop, in which case the message is dropped instead.
+func dropOldest[T any](ch chan T, message T, stopC <-chan struct{}) error {
 	select {
 	case <-stopC:
 		// subscriber stopped dont try to send the message
-		return errors.New("subscriber stopped")
+		return ErrSubscriberStopped
 	case <-ch:
 		// dropped the oldest message
-		select {
-		case <-stopC:
-			return errors.New("subscriber stopped")
-		case ch <- message:
-			// we succeeded at pushing the new message
-			return nil
-		default:
-			// we failed to push the message buffer is still full
-			return errors.New("failed to push message")
-		}
+	default:
+	}
+
+	select {
+	case <-stopC:
+		return ErrSubscriberStopped
+	case ch <- message:
+		// we succeeded at pushing the new message
+		return nil
+	default:
+		// we failed to push the message buffer is still full
+		return ErrMessageDropped
 	}
 }
 
@@ -62,17 +68,17 @@ func (d BufferPolicyDropOldestAfterTimeout[T]) Handle(ch chan T, message T, stop
 	select {
 	case <-stopC:
 		// subscriber stopped dont try to send the message
-		return errors.New("subscriber stopped")
+		return ErrSubscriberStopped
 	case ch <- message:
 		// attempt to send to the publish channel
 		return nil
 	default:
 		// if the channel is full, wait on timeout trying to send the message
-		d.Timer.Reset(d.DropTimeout)
+		resetTimer(d.Timer, d.DropTimeout)
 		select {
 		case <-stopC:
 			// subscriber stopped dont try to send the message
-			return errors.New("subscriber stopped")
+			return ErrSubscriberStopped
 		case ch <- message:
 			// we succeeded at pushing the message
 			return nil
@@ -82,24 +88,7 @@ func (d BufferPolicyDropOldestAfterTimeout[T]) Handle(ch chan T, message T, stop
 	}
 
 	// last attempt to send the message or we error.
-	select {
-	case <-stopC:
-		// subscriber stopped dont try to send the message
-		return errors.New("subscriber stopped")
-	case <-ch: // try to pop one
-		// we popped one, now try to push the message
-		select {
-		case <-stopC:
-			// subscriber stopped dont try to send the message
-			return errors.New("subscriber stopped")
-		case ch <- message:
-			// we succeeded at pushing the message
-			return nil
-		default:
-			// we failed to push the message
-			return errors.New("timeout exceeded, failed to push message")
-		}
-	}
+	return dropOldest(ch, message, stopC)
 }
 
 type BufferPolicyDropNewest[T any] struct{}
@@ -107,14 +96,14 @@ type BufferPolicyDropNewest[T any] struct{}
 func (d BufferPolicyDropNewest[T]) Handle(ch chan T, message T, stopC <-chan struct{}) error {
 	select {
 	case <-stopC:
-		return errors.New("subscriber stopped")
+		return ErrSubscriberStopped
 	case ch <- message:
 		// we succeeded at pushing the message
 		return nil
 	default:
 		// we failed to push the message buffer is full
 		// so just drop the current message
-		return nil
+		return ErrMessageDropped
 	}
 }
 
@@ -127,23 +116,35 @@ func (d BufferPolicyDropNewestAfterTimeout[T]) Handle(ch chan T, message T, stop
 	select {
 	case <-stopC:
 		// subscriber stopped dont try to send the message
-		return errors.New("subscriber stopped")
+		return ErrSubscriberStopped
 	case ch <- message:
 		// attempt to send to the publish channel
 		return nil
 	default:
 		// if the channel is full, wait on timeout trying to send the message
-		d.Timer.Reset(d.DropTimout)
+		resetTimer(d.Timer, d.DropTimout)
 		select {
 		case <-stopC:
 			// subscriber stopped dont try to send the message
-			return errors.New("subscriber stopped")
+			return ErrSubscriberStopped
 		case ch <- message:
 			// we succeeded at pushing the message
 			return nil
 		case <-d.Timer.C:
 			// timer elapsed continue... just drop the current message
-			return nil
+			return ErrMessageDropped
+		}
+	}
+}
+
+// resetTimer stops the timer and drains an expiry left over from an earlier send before resetting it,
+// otherwise the stale expiry would drop the message without waiting for the timeout.
+func resetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
 		}
 	}
+	t.Reset(d)
 }
@@ -0,0 +1,70 @@
+// Package intracom is an in-process pub/sub registry of typed topics.
+//
+// # Delivery guarantees
+//
+// The guarantees below hold for topics using the default SyncBroadcaster,
+// custom broadcasters can verify them with the intracom/broadcastertest package.
+//
+//   - Per-publisher FIFO per consumer group: messages sent by a single publisher (one routine sending on
+//     the publish channel) are delivered to every consumer group in the order they were sent.
+//   - Single order: messages of concurrent publishers are interleaved in one order, every consumer group
+//     observes the same order.
+//   - No duplicates: a message is delivered at most once to a subscription. A new subscription may first
+//     receive the last message published before it subscribed, it is never delivered again afterwards.
+//   - Replay: a new subscription with room in its buffer first receives the last message published before
+//     it subscribed. With TopicConfig.ReplayLast every new subscription receives it, also without a buffer.
+//   - Completeness: with BufferPolicyDropNone (the default when no policy is set) every message published
+//     after Subscribe returned and before Unsubscribe or Close was called is delivered. The other buffer
+//     policies may drop messages, the messages they do deliver keep their order.
+//   - Close: pending Subscribe and Unsubscribe calls complete before a topic closes, calls made afterwards
+//     return an error. Publish and TryPublish report a closed topic, also to publishers waiting when it closes.
+//     Publishers sending on the publish channel must stop before the topic is closed, sending on it then panics.
+//
+// # Registry
+//
+// An Intracom is the Registry owning the topics by name, from CreateTopic until RemoveTopic or Close, which
+// closes every topic left. GetTopic looks a topic up without creating it, with an ErrTopicType when it was
+// created with another message type, and ListTopics names the topics registered, e.g. for debugging.
+//
+// # Buffered broadcaster
+//
+// The SyncBroadcaster hands every message to all consumer groups from a single routine, a consumer group
+// whose buffer policy waits holds up every other one. The BufferedBroadcaster gives each consumer group a ring
+// buffer and a routine of its own, so only the consumer groups with a waiting policy hold up the publishers:
+//
+//	topic, err := intracom.CreateTopic[Event](ic, intracom.TopicConfig{Name: "events"},
+//		intracom.WithBroadcaster[Event](intracom.BufferedBroadcaster[Event]{Capacity: 1024}))
+//
+// # Consumer groups
+//
+// A consumer group names a single subscription of a topic. Subscribing again with the same group returns
+// the same channel, unless ErrIfExists is set, and unsubscribing closes it for every holder. Subscribers
+// created repeatedly with the same name, such as watchers, derive their group with ConsumerID, which
+// appends an id unique within the Intracom. Consumers lists the groups subscribed to a topic for debugging.
+// A SubscriberConfig.Filter selects the messages delivered to a consumer group within the broadcaster, the
+// messages it rejects neither wake the subscriber nor take room in its buffer.
+//
+// # Stats
+//
+// Topic.Stats reports the messages published to a topic and their rate, and for every consumer group the messages
+// delivered, dropped and still buffered. Stats returns them for every topic of an Intracom. They are read without
+// the broadcaster, so a consumer group holding it up can still be told apart by its depth.
+//
+// # Pattern subscriptions
+//
+// SubscribePattern observes every topic whose name matches a pattern of dot separated segments, where * matches
+// one segment and ** any number of them, including topics created later. Messages arrive on a single channel
+// as a TopicMessage naming their topic, e.g. for audit or metrics consumers that do not enumerate the topics:
+//
+//	events, err := intracom.SubscribePattern[any](ctx, ic, "service.*.events",
+//		intracom.SubscriberConfig[intracom.TopicMessage[any]]{ConsumerGroup: "audit", BufferSize: 64})
+//
+// # Request and reply
+//
+// Request publishes an Envelope to a request topic and waits for a single typed reply, received on a hidden
+// topic created for that request and removed once it returns. Respond serves a request topic with a handler,
+// subscribers of their own answer an Envelope with Reply:
+//
+//	go intracom.Respond(ctx, ic, "config", intracom.SubscriberConfig[intracom.Envelope[string]]{BufferSize: 1}, lookup)
+//	value, err := intracom.Request[string, string](ctx, ic, "config", "log_level", time.Second)
+package intracom
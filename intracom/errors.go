@@ -12,7 +12,16 @@ const (
 	ErrInvalidTopicType      = Error("topic exists but with a different type")
 	ErrTopicClosed           = Error("topic is closed")
 	ErrConsumerAlreadyExists = Error("consumer already exists")
+	ErrConsumerMismatch      = Error("consumer group is subscribed with a different channel")
 	ErrMaxTimeoutReached     = Error("max timeout reached")
+	ErrSubscribeTimeout      = Error("subscribe request timed out")
+	ErrUnsubscribeTimeout    = Error("unsubscribe request timed out")
+	ErrSubscriberClosed      = Error("subscriber is closed")
+	ErrSubscriberStopped     = Error("subscriber stopped")
+	ErrMessageDropped        = Error("message dropped by buffer policy")
+	ErrNoSnapshot            = Error("topic has no retained message to snapshot")
+	ErrRequestTimeout        = Error("request timed out waiting for a reply")
+	ErrInvalidPattern        = Error("invalid topic pattern, segments cannot be empty")
 )
 
 // Action is the action that was attempted when an error occurred.
@@ -23,8 +32,11 @@ const (
 	ActionClosingTopic         = Action("closing topic")
 	ActionRemovingTopic        = Action("removing topic")
 	ActionCreatingTopic        = Action("creating topic")
+	ActionGettingTopic         = Action("getting topic")
 	ActionRemovingSubscription = Action("removing subscription")
 	ActionCreatingSubscription = Action("creating subscription")
+	ActionRequesting           = Action("requesting")
+	ActionReplying             = Action("replying")
 )
 
 func (e Error) Error() string {
@@ -40,6 +52,10 @@ func (e ErrIntracom) Error() string {
 	return "error " + string(e.Action) + " reason: " + e.Err.Error()
 }
 
+func (e ErrIntracom) Unwrap() error {
+	return e.Err
+}
+
 type ErrSubscribe struct {
 	Topic    string
 	Consumer string
@@ -51,6 +67,10 @@ func (e ErrSubscribe) Error() string {
 	return "error " + string(e.Action) + " to topic '" + e.Topic + "' with consumer '" + e.Consumer + "' reason: " + e.Err.Error()
 }
 
+func (e ErrSubscribe) Unwrap() error {
+	return e.Err
+}
+
 type ErrTopic struct {
 	Topic  string
 	Action Action
@@ -58,6 +78,29 @@ type ErrTopic struct {
 }
 
 func (e ErrTopic) Error() string {
-	return "error " + string(e.Action) + " removing topic '" + e.Topic + "'" + " reason: " + e.Err.Error()
+	return "error " + string(e.Action) + " '" + e.Topic + "' reason: " + e.Err.Error()
+}
+
+func (e ErrTopic) Unwrap() error {
+	return e.Err
+}
+
+// ErrTopicType is returned when a topic is looked up with another message type than it was created with.
+// It matches ErrInvalidTopicType with errors.Is.
+type ErrTopicType struct {
+	Topic string
+	Want  string // message type the topic was looked up with.
+	Got   string // message type the topic was created with, empty for topics of custom implementations.
+}
+
+func (e ErrTopicType) Error() string {
+	got := e.Got
+	if got == "" {
+		got = "another type"
+	}
+	return "topic '" + e.Topic + "' carries " + got + ", not " + e.Want
+}
 
+func (e ErrTopicType) Unwrap() error {
+	return ErrInvalidTopicType
 }
@@ -2,6 +2,9 @@ package intracom
 
 import (
 	"context"
+	"reflect"
+	"sort"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -21,14 +24,25 @@ type Intracom struct {
 	mu     sync.RWMutex
 	logger log.Logger
 	closed atomic.Bool
+	// consumers subscribed per topic through CreateSubscription, see Consumers.
+	consumers   map[string]map[string]struct{}
+	consumerIDs atomic.Uint64
+	// pattern subscriptions by consumer group, attached to the matching topics as they are created.
+	patterns map[string]patternSubscriber
 }
 
+// Registry is the role of an Intracom: it owns the topics by name, from CreateTopic until RemoveTopic or Close.
+// A daemon closes its registry once all its services exited, closing the topics they created.
+type Registry = Intracom
+
 // New creates a new instance of Intracom with the given name and logger and starts the broker routine.
 func New(name string, opts ...Option) *Intracom {
 
 	ic := &Intracom{
-		name:   name,
-		topics: make(map[string]any),
+		name:      name,
+		topics:    make(map[string]any),
+		consumers: make(map[string]map[string]struct{}),
+		patterns:  make(map[string]patternSubscriber),
 
 		logger: noopLogger{},
 		closed: atomic.Bool{},
@@ -44,30 +58,36 @@ func New(name string, opts ...Option) *Intracom {
 
 // CreateTopic creates a new topic with the given configuration.
 // Topic names must be unique, if the topic already exists, an error is returned.
-func CreateTopic[T any](ic *Intracom, conf TopicConfig) (Topic[T], error) {
+// Topic options such as broadcasters or instrumentation hooks only apply when the topic is created.
+func CreateTopic[T any](ic *Intracom, conf TopicConfig, opts ...TopicOption[T]) (Topic[T], error) {
 	if ic == nil {
 		return nil, ErrTopic{Topic: conf.Name, Action: ActionCreatingTopic, Err: ErrInvalidIntracomNil}
 	}
 
+	// the lookup and the registration happen under the same lock so concurrent creators share a single topic.
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
 	if ic.closed.Load() {
 		return nil, ErrTopic{Topic: conf.Name, Action: ActionCreatingTopic, Err: ErrIntracomClosed}
 	}
 
-	ic.mu.RLock()
 	topicAny, ok := ic.topics[conf.Name]
-	ic.mu.RUnlock()
 	if !ok {
-		topic := NewTopic[T](conf)
-
-		ic.mu.Lock()
+		topic := NewTopic[T](conf, opts...)
 		ic.topics[conf.Name] = topic
-		ic.mu.Unlock()
+		// the broadcaster of the new topic answers right away, it is safe to subscribe while holding the lock.
+		for _, p := range ic.patterns {
+			if p.matches(conf.Name) {
+				p.attach(context.Background(), topic)
+			}
+		}
 		return topic, nil
 	}
 
-	topic, ok := topicAny.(Topic[T])
-	if !ok {
-		return nil, ErrTopic{Topic: conf.Name, Action: ActionCreatingTopic, Err: ErrInvalidTopicType}
+	topic, err := typedTopic[T](conf.Name, topicAny)
+	if err != nil {
+		return nil, ErrTopic{Topic: conf.Name, Action: ActionCreatingTopic, Err: err}
 	}
 
 	if conf.ErrIfExists {
@@ -81,43 +101,134 @@ func RemoveTopic[T any](ic *Intracom, name string) error {
 	if ic == nil {
 		return ErrTopic{Topic: name, Action: ActionRemovingTopic, Err: ErrInvalidIntracomNil}
 	}
-	ic.mu.RLock()
+	ic.mu.Lock()
 	topicAny, ok := ic.topics[name]
-	ic.mu.RUnlock()
 	if !ok {
+		ic.mu.Unlock()
 		return ErrTopic{Topic: name, Action: ActionRemovingTopic, Err: ErrTopicDoesNotExist}
 	}
 
-	topic, ok := topicAny.(Topic[T])
-	if !ok {
-		return ErrTopic{Topic: name, Action: ActionRemovingTopic, Err: ErrInvalidTopicType}
-	}
-
-	err := topic.Close()
+	topic, err := typedTopic[T](name, topicAny)
 	if err != nil {
+		ic.mu.Unlock()
 		return ErrTopic{Topic: name, Action: ActionRemovingTopic, Err: err}
 	}
 
-	ic.mu.Lock()
+	// unregister before closing so the topic cannot be handed out to new subscribers once closed.
 	delete(ic.topics, name)
+	delete(ic.consumers, name)
 	ic.mu.Unlock()
+
+	if err := topic.Close(); err != nil {
+		return ErrTopic{Topic: name, Action: ActionRemovingTopic, Err: err}
+	}
 	return nil
 }
 
+// GetTopic returns the topic registered under name, without creating it. ErrTopicDoesNotExist is returned
+// for unknown topics, ErrTopicType for topics created with another message type than T.
+func GetTopic[T any](ic *Intracom, name string) (Topic[T], error) {
+	if ic == nil {
+		return nil, ErrTopic{Topic: name, Action: ActionGettingTopic, Err: ErrInvalidIntracomNil}
+	}
+
+	ic.mu.RLock()
+	topicAny, ok := ic.topics[name]
+	ic.mu.RUnlock()
+	if !ok {
+		if ic.closed.Load() {
+			return nil, ErrTopic{Topic: name, Action: ActionGettingTopic, Err: ErrIntracomClosed}
+		}
+		return nil, ErrTopic{Topic: name, Action: ActionGettingTopic, Err: ErrTopicDoesNotExist}
+	}
+
+	topic, err := typedTopic[T](name, topicAny)
+	if err != nil {
+		return nil, ErrTopic{Topic: name, Action: ActionGettingTopic, Err: err}
+	}
+	return topic, nil
+}
+
+// ListTopics returns the names of the topics registered in the intracom, sorted, e.g. for debugging.
+// Hidden topics, such as the reply topics of requests, are listed as well.
+func ListTopics(ic *Intracom) []string {
+	if ic == nil {
+		return nil
+	}
+
+	ic.mu.RLock()
+	defer ic.mu.RUnlock()
+
+	names := make([]string, 0, len(ic.topics))
+	for name := range ic.topics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// typedTopic asserts the registered topic carries messages of type T.
+func typedTopic[T any](name string, topicAny any) (Topic[T], error) {
+	if topic, ok := topicAny.(Topic[T]); ok {
+		return topic, nil
+	}
+
+	err := ErrTopicType{Topic: name, Want: reflect.TypeFor[T]().String()}
+	if typed, ok := topicAny.(interface{ messageType() reflect.Type }); ok {
+		err.Got = typed.messageType().String()
+	}
+	return nil, err
+}
+
 // CreateSubscription will (if set) wait a max timeout for a topic to exist and the proceed to subscribe to that topic.
 // If the topic does not exist within the maxWait duration, an error is returned.
 // If maxWait is 0, the function will wait indefinitely for the topic to exist.
 // If the intracom is closed, an error is returned.
 // If the context is canceled, a context error is returned.
 func CreateSubscription[T any](ctx context.Context, ic *Intracom, topic string, maxWait time.Duration, conf SubscriberConfig[T]) (<-chan T, error) {
+	t, err := waitForTopic[T](ctx, ic, topic, maxWait, conf.ConsumerGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	ch, err := t.Subscribe(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+	ic.addConsumer(topic, conf.ConsumerGroup)
+	return ch, nil
+}
+
+// SubscribeWithSnapshot waits for the topic like CreateSubscription and subscribes to it, also returning
+// the current (last published) value of the topic so callers can initialize their view without waiting
+// for the next publish. The current value is not replayed on the returned channel.
+// If nothing has been published to the topic yet, ErrNoSnapshot is returned and no subscription is created,
+// callers can fall back to CreateSubscription.
+func SubscribeWithSnapshot[T any](ctx context.Context, ic *Intracom, topic string, maxWait time.Duration, conf SubscriberConfig[T]) (T, <-chan T, error) {
+	t, err := waitForTopic[T](ctx, ic, topic, maxWait, conf.ConsumerGroup)
+	if err != nil {
+		var zero T
+		return zero, nil, err
+	}
+
+	last, ch, err := t.SubscribeWithSnapshot(ctx, conf)
+	if err != nil {
+		return last, nil, err
+	}
+	ic.addConsumer(topic, conf.ConsumerGroup)
+	return last, ch, nil
+}
+
+// waitForTopic polls for the topic to exist for up to maxWait, or indefinitely if maxWait is 0.
+func waitForTopic[T any](ctx context.Context, ic *Intracom, topic string, maxWait time.Duration, consumer string) (Topic[T], error) {
 	if ic == nil {
 		// return nil, ErrCreatingSubscription{Topic: topic, Err: ErrInvalidIntracomNil}
-		return nil, ErrSubscribe{Action: ActionCreatingSubscription, Topic: topic, Consumer: conf.ConsumerGroup, Err: ErrInvalidIntracomNil}
+		return nil, ErrSubscribe{Action: ActionCreatingSubscription, Topic: topic, Consumer: consumer, Err: ErrInvalidIntracomNil}
 	}
 
 	if ic.closed.Load() {
 		// return nil, ErrCreatingSubscription{Topic: topic, Err: ErrIntracomClosed}
-		return nil, ErrSubscribe{Action: ActionCreatingSubscription, Topic: topic, Consumer: conf.ConsumerGroup, Err: ErrIntracomClosed}
+		return nil, ErrSubscribe{Action: ActionCreatingSubscription, Topic: topic, Consumer: consumer, Err: ErrIntracomClosed}
 	}
 
 	retryTimeout := time.NewTimer(1 * time.Nanosecond)
@@ -136,19 +247,18 @@ func CreateSubscription[T any](ctx context.Context, ic *Intracom, topic string,
 	var exists bool
 
 	var topicAny any
-	var t Topic[T]
 
 	for !exists {
 		select {
 		case <-ctx.Done():
 			// the caller has canceled the context, exit with error.
-			return nil, ErrSubscribe{Action: ActionCreatingSubscription, Topic: topic, Consumer: conf.ConsumerGroup, Err: ctx.Err()}
+			return nil, ErrSubscribe{Action: ActionCreatingSubscription, Topic: topic, Consumer: consumer, Err: ctx.Err()}
 		case <-maxTimeout:
 			// exceeded the callers set max timeout, exit with error.
-			return nil, ErrMaxTimeoutReached
+			return nil, ErrSubscribe{Action: ActionCreatingSubscription, Topic: topic, Consumer: consumer, Err: ErrMaxTimeoutReached}
 		case <-retryTimeout.C:
 			if ic.closed.Load() {
-				return nil, ErrSubscribe{Action: ActionCreatingSubscription, Topic: topic, Consumer: conf.ConsumerGroup, Err: ErrIntracomClosed}
+				return nil, ErrSubscribe{Action: ActionCreatingSubscription, Topic: topic, Consumer: consumer, Err: ErrIntracomClosed}
 			}
 
 			// check if the topic exists yet.
@@ -160,12 +270,12 @@ func CreateSubscription[T any](ctx context.Context, ic *Intracom, topic string,
 		}
 	}
 
-	t, exists = topicAny.(Topic[T])
-	if !exists {
-		return nil, ErrSubscribe{Action: ActionCreatingSubscription, Topic: topic, Consumer: conf.ConsumerGroup, Err: ErrInvalidTopicType}
+	t, err := typedTopic[T](topic, topicAny)
+	if err != nil {
+		return nil, ErrSubscribe{Action: ActionCreatingSubscription, Topic: topic, Consumer: consumer, Err: err}
 	}
 
-	return t.Subscribe(ctx, conf)
+	return t, nil
 }
 
 // RemoveSubscription removes a subscription from a topic consumer name and consumer channel are required to remove the subscription.
@@ -183,12 +293,68 @@ func RemoveSubscription[T any](ic *Intracom, topic string, consumer string, ch <
 		return ErrSubscribe{Action: ActionRemovingSubscription, Topic: topic, Consumer: consumer, Err: ErrTopicNotFound}
 	}
 
-	t, ok := topicAny.(Topic[T])
-	if !ok {
-		return ErrSubscribe{Action: ActionRemovingSubscription, Topic: topic, Consumer: consumer, Err: ErrInvalidTopicType}
+	t, err := typedTopic[T](topic, topicAny)
+	if err != nil {
+		return ErrSubscribe{Action: ActionRemovingSubscription, Topic: topic, Consumer: consumer, Err: err}
 	}
 
-	return t.Unsubscribe(consumer, ch)
+	if err := t.Unsubscribe(consumer, ch); err != nil {
+		return err
+	}
+	ic.removeConsumer(topic, consumer)
+	return nil
+}
+
+// ConsumerID returns a consumer group named after base that is unique within the intracom, e.g. base#12.
+// Subscribers created repeatedly with the same base, such as watchers of the same condition, use it so they
+// neither share a subscription nor close each other's channel when unsubscribing.
+func ConsumerID(ic *Intracom, base string) string {
+	if ic == nil {
+		return base
+	}
+	return base + "#" + strconv.FormatUint(ic.consumerIDs.Add(1), 10)
+}
+
+// Consumers returns the consumer groups subscribed to the topic through CreateSubscription or
+// SubscribeWithSnapshot and not removed yet with RemoveSubscription, sorted, e.g. for debugging.
+func Consumers(ic *Intracom, topic string) []string {
+	if ic == nil {
+		return nil
+	}
+
+	ic.mu.RLock()
+	defer ic.mu.RUnlock()
+
+	consumers := make([]string, 0, len(ic.consumers[topic]))
+	for consumer := range ic.consumers[topic] {
+		consumers = append(consumers, consumer)
+	}
+	sort.Strings(consumers)
+	return consumers
+}
+
+func (ic *Intracom) addConsumer(topic, consumer string) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	// the topic may have been removed while subscribing.
+	if _, ok := ic.topics[topic]; !ok {
+		return
+	}
+	if ic.consumers[topic] == nil {
+		ic.consumers[topic] = make(map[string]struct{})
+	}
+	ic.consumers[topic][consumer] = struct{}{}
+}
+
+func (ic *Intracom) removeConsumer(topic, consumer string) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	delete(ic.consumers[topic], consumer)
+	if len(ic.consumers[topic]) == 0 {
+		delete(ic.consumers, topic)
+	}
 }
 
 // Close interacts with the Intracom registry and closes all topics.
@@ -202,8 +368,15 @@ func Close(ic *Intracom) error {
 	}
 
 	ic.mu.Lock()
+	// the relays of pattern subscriptions no longer read from their channel, they must not hold up the topics.
+	patterns := ic.patterns
+	for _, p := range patterns {
+		p.stop()
+	}
+
 	for name, topicAny := range ic.topics {
-		topic, ok := topicAny.(Topic[any])
+		// topics are generic, so they are closed through the non generic part of their interface.
+		topic, ok := topicAny.(interface{ Close() error })
 		if !ok {
 			continue
 		}
@@ -215,6 +388,12 @@ func Close(ic *Intracom) error {
 	}
 
 	ic.topics = make(map[string]any)
+	ic.consumers = make(map[string]map[string]struct{})
+	ic.patterns = make(map[string]patternSubscriber)
 	ic.mu.Unlock()
+
+	for _, p := range patterns {
+		p.close()
+	}
 	return nil
 }
@@ -2,6 +2,7 @@ package intracom
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"testing"
 	"time"
@@ -106,10 +107,9 @@ func TestIntracom_CreateTopicDuplicate(t *testing.T) {
 		ErrIfExists: true,
 	})
 
-	if err == nil {
-		t.Fatalf("error creating duplicate topic should not be nil")
+	if !errors.Is(err, ErrTopicAlreadyExists) {
+		t.Fatalf("expected creating a duplicate topic to fail with %s, got %v", ErrTopicAlreadyExists, err)
 	}
-
 }
 
 func TestIntracom_RemoveTopic(t *testing.T) {
@@ -247,6 +247,98 @@ func TestIntracom_RemoveSubscriptionFromTopic(t *testing.T) {
 
 }
 
+func TestIntracom_ConsumerID(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	ic := New("test-consumer-id")
+	defer Close(ic)
+	if _, err := CreateTopic[string](ic, TopicConfig{Name: t.Name()}); err != nil {
+		t.Fatalf("error creating topic: %v", err)
+	}
+
+	first, second := ConsumerID(ic, "watcher"), ConsumerID(ic, "watcher")
+	if first == second {
+		t.Fatalf("expected unique consumer ids, got %s twice", first)
+	}
+
+	subs := make(map[string]<-chan string)
+	for _, consumer := range []string{first, second} {
+		sub, err := CreateSubscription[string](ctx, ic, t.Name(), 0, SubscriberConfig[string]{
+			ConsumerGroup: consumer,
+			ErrIfExists:   true,
+			BufferSize:    1,
+			BufferPolicy:  BufferPolicyDropNone[string]{},
+		})
+		if err != nil {
+			t.Fatalf("error creating subscription %s: %v", consumer, err)
+		}
+		subs[consumer] = sub
+	}
+
+	if consumers := Consumers(ic, t.Name()); len(consumers) != 2 || consumers[0] != first || consumers[1] != second {
+		t.Fatalf("expected both consumers to be subscribed, got %v", consumers)
+	}
+
+	if err := RemoveSubscription(ic, t.Name(), first, subs[first]); err != nil {
+		t.Fatalf("error removing subscription: %v", err)
+	}
+	if consumers := Consumers(ic, t.Name()); len(consumers) != 1 || consumers[0] != second {
+		t.Errorf("expected only %s to remain subscribed, got %v", second, consumers)
+	}
+	select {
+	case _, open := <-subs[second]:
+		if !open {
+			t.Errorf("expected %s to stay subscribed once %s unsubscribed", second, first)
+		}
+	default:
+	}
+}
+
+func TestIntracom_GetTopic(t *testing.T) {
+	ic := New("test-get-topic")
+
+	if _, err := GetTopic[int](ic, "counts"); !errors.Is(err, ErrTopicDoesNotExist) {
+		t.Fatalf("expected %v before the topic is created, got %v", ErrTopicDoesNotExist, err)
+	}
+
+	created, err := CreateTopic[int](ic, TopicConfig{Name: "counts"})
+	if err != nil {
+		t.Fatalf("error creating topic: %v", err)
+	}
+	if _, err := CreateTopic[string](ic, TopicConfig{Name: "names"}); err != nil {
+		t.Fatalf("error creating topic: %v", err)
+	}
+
+	topic, err := GetTopic[int](ic, "counts")
+	if err != nil || topic != created {
+		t.Fatalf("expected the created topic, got %v with error %v", topic, err)
+	}
+
+	_, err = GetTopic[string](ic, "counts")
+	var typeErr ErrTopicType
+	if !errors.Is(err, ErrInvalidTopicType) || !errors.As(err, &typeErr) {
+		t.Fatalf("expected %v looking up the topic with another type, got %v", ErrInvalidTopicType, err)
+	}
+	if typeErr.Want != "string" || typeErr.Got != "int" {
+		t.Errorf("expected the error to name both types, got %+v", typeErr)
+	}
+
+	if names := ListTopics(ic); len(names) != 2 || names[0] != "counts" || names[1] != "names" {
+		t.Errorf("expected both topics to be listed, got %v", names)
+	}
+
+	if err := Close(ic); err != nil {
+		t.Fatalf("error closing intracom: %v", err)
+	}
+	if names := ListTopics(ic); len(names) != 0 {
+		t.Errorf("expected no topics once closed, got %v", names)
+	}
+	if _, err := GetTopic[int](ic, "counts"); !errors.Is(err, ErrIntracomClosed) {
+		t.Errorf("expected %v once closed, got %v", ErrIntracomClosed, err)
+	}
+}
+
 func TestIntracom_Close(t *testing.T) {
 	ic := New("test-intracom")
 
@@ -0,0 +1,265 @@
+package intracom
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+// collector drains a subscription channel until it is closed.
+type collector struct {
+	got   []int
+	want  []int
+	doneC chan struct{}
+}
+
+func collect(ch <-chan int) *collector {
+	c := &collector{doneC: make(chan struct{})}
+	go func() {
+		defer close(c.doneC)
+		for msg := range ch {
+			c.got = append(c.got, msg)
+		}
+	}()
+	return c
+}
+
+func (c *collector) check(t *testing.T, consumer string) {
+	t.Helper()
+	select {
+	case <-c.doneC:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("subscription of %s was not closed", consumer)
+	}
+
+	if len(c.got) != len(c.want) {
+		t.Fatalf("%s expected %v, got %v", consumer, c.want, c.got)
+	}
+	for i := range c.want {
+		if c.got[i] != c.want[i] {
+			t.Fatalf("%s expected %v, got %v", consumer, c.want, c.got)
+		}
+	}
+}
+
+// FuzzTopicInterleavings runs scripts of publish, subscribe, unsubscribe and close operations
+// against a topic, every subscription must receive exactly the messages published while it was
+// subscribed, in order, preceded by the last message published before it subscribed.
+func FuzzTopicInterleavings(f *testing.F) {
+	f.Add([]byte{0, 1, 0, 0, 2, 0})
+	f.Add([]byte{1, 5, 9, 0, 0, 6, 0, 1, 3})
+	f.Add([]byte{0, 0, 1, 0, 2, 1, 0, 3, 1, 2})
+	f.Add([]byte{1, 2, 1, 2, 0, 1, 0, 0, 0, 10, 0})
+
+	f.Fuzz(func(t *testing.T, script []byte) {
+		topic := NewTopic[int](TopicConfig{Name: "fuzz"})
+		publishC := topic.PublishChannel()
+		ctx := context.Background()
+
+		subs := make(map[string]<-chan int)
+		active := make(map[string]*collector)
+
+		var published int
+		var closed bool
+		for _, op := range script {
+			consumer := "group-" + strconv.Itoa(int(op/4)%3)
+			switch op % 4 {
+			case 0:
+				published++
+				publishC <- published
+				for _, c := range active {
+					c.want = append(c.want, published)
+				}
+			case 1:
+				if _, ok := subs[consumer]; ok {
+					continue
+				}
+				ch, err := topic.Subscribe(ctx, SubscriberConfig[int]{ConsumerGroup: consumer, BufferSize: 1 + int(op/12)%3})
+				if err != nil {
+					t.Fatalf("error subscribing %s: %s", consumer, err)
+				}
+				c := collect(ch)
+				if published > 0 {
+					// the last message published before subscribing is replayed first.
+					c.want = append(c.want, published)
+				}
+				subs[consumer] = ch
+				active[consumer] = c
+			case 2:
+				ch, ok := subs[consumer]
+				if !ok {
+					continue
+				}
+				if err := topic.Unsubscribe(consumer, ch); err != nil {
+					t.Fatalf("error unsubscribing %s: %s", consumer, err)
+				}
+				active[consumer].check(t, consumer)
+				delete(subs, consumer)
+				delete(active, consumer)
+			case 3:
+				if err := topic.Close(); err != nil {
+					t.Fatalf("error closing topic: %s", err)
+				}
+				closed = true
+			}
+
+			if closed {
+				break
+			}
+		}
+
+		if !closed {
+			if err := topic.Close(); err != nil {
+				t.Fatalf("error closing topic: %s", err)
+			}
+		}
+
+		for consumer, c := range active {
+			c.check(t, consumer)
+		}
+
+		if _, err := topic.Subscribe(ctx, SubscriberConfig[int]{ConsumerGroup: "after-close"}); err == nil {
+			t.Fatalf("expected error subscribing to a closed topic")
+		}
+		for consumer, ch := range subs {
+			if err := topic.Unsubscribe(consumer, ch); err == nil {
+				t.Fatalf("expected error unsubscribing from a closed topic")
+			}
+		}
+	})
+}
+
+type sequenced struct {
+	publisher int
+	seq       int
+}
+
+// TestTopic_OrderingProperties checks that with lossy buffer policies and concurrent publishers,
+// each consumer group receives the messages of every publisher in order and that all consumer
+// groups observe the same order for the messages they both received.
+func TestTopic_OrderingProperties(t *testing.T) {
+	property := func(publishers, messages, buffer uint8, dropOldest bool) bool {
+		numPublishers := 1 + int(publishers)%4
+		numMessages := 1 + int(messages)
+		var policy BufferPolicyHandler[sequenced] = BufferPolicyDropNewest[sequenced]{}
+		if dropOldest {
+			policy = BufferPolicyDropOldest[sequenced]{}
+		}
+
+		topic := NewTopic[sequenced](TopicConfig{Name: "ordering"})
+
+		groups := make([][]sequenced, 3)
+		var wg sync.WaitGroup
+		for i := range groups {
+			ch, err := topic.Subscribe(context.Background(), SubscriberConfig[sequenced]{
+				ConsumerGroup: "group-" + strconv.Itoa(i),
+				BufferSize:    int(buffer) % 8,
+				BufferPolicy:  policy,
+			})
+			if err != nil {
+				t.Errorf("error subscribing: %s", err)
+				return false
+			}
+
+			wg.Add(1)
+			go func(i int, ch <-chan sequenced) {
+				defer wg.Done()
+				for msg := range ch {
+					groups[i] = append(groups[i], msg)
+				}
+			}(i, ch)
+		}
+
+		var publishing sync.WaitGroup
+		for p := 0; p < numPublishers; p++ {
+			publishing.Add(1)
+			go func(p int) {
+				defer publishing.Done()
+				for seq := 0; seq < numMessages; seq++ {
+					topic.PublishChannel() <- sequenced{publisher: p, seq: seq}
+				}
+			}(p)
+		}
+		publishing.Wait()
+
+		if err := topic.Close(); err != nil {
+			t.Errorf("error closing topic: %s", err)
+			return false
+		}
+		wg.Wait()
+
+		return perPublisherFIFO(groups) && sameOrder(groups)
+	}
+
+	if err := quick.Check(property, &quick.Config{MaxCount: 50}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func perPublisherFIFO(groups [][]sequenced) bool {
+	for _, received := range groups {
+		last := make(map[int]int)
+		for _, msg := range received {
+			if prev, ok := last[msg.publisher]; ok && msg.seq <= prev {
+				return false
+			}
+			last[msg.publisher] = msg.seq
+		}
+	}
+	return true
+}
+
+func sameOrder(groups [][]sequenced) bool {
+	positions := make(map[sequenced]int, len(groups[0]))
+	for i, msg := range groups[0] {
+		positions[msg] = i
+	}
+
+	for _, received := range groups[1:] {
+		last := -1
+		for _, msg := range received {
+			pos, ok := positions[msg]
+			if !ok {
+				continue
+			}
+			if pos <= last {
+				return false
+			}
+			last = pos
+		}
+	}
+	return true
+}
+
+// TestTopic_ConcurrentClose closes a topic while subscribers come and go, which must never panic.
+func TestTopic_ConcurrentClose(t *testing.T) {
+	for run := 0; run < 20; run++ {
+		topic := NewTopic[int](TopicConfig{Name: "concurrent-close"})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				consumer := "group-" + strconv.Itoa(i)
+				for j := 0; j < 10; j++ {
+					ch, err := topic.Subscribe(context.Background(), SubscriberConfig[int]{ConsumerGroup: consumer, BufferSize: 1})
+					if err != nil {
+						// the topic was closed.
+						return
+					}
+					_ = topic.Unsubscribe(consumer, ch)
+				}
+			}(i)
+		}
+
+		time.Sleep(time.Duration(run) * 50 * time.Microsecond)
+		if err := topic.Close(); err != nil {
+			t.Fatalf("error closing topic: %s", err)
+		}
+		wg.Wait()
+	}
+}
@@ -0,0 +1,229 @@
+package intracom
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// TopicMessage is a message received through a pattern subscription, along with the name of its topic.
+type TopicMessage[T any] struct {
+	Topic   string
+	Message T
+}
+
+// SubscribePattern subscribes the consumer group to every topic whose name matches pattern, both the topics
+// registered now and those created later, delivering their messages on a single channel along with the name of
+// their topic. Patterns are made of dot separated segments like the topic names they match: a * segment matches
+// any one segment and a ** segment any number of segments, e.g. service.*.events or metrics.**.
+//
+// Only topics whose messages are assignable to T are subscribed to, T any subscribes to all of them.
+// The hidden reply topics of requests are never matched. Each topic is subscribed to with the consumer group,
+// topics on which the group is already subscribed are skipped. The config applies to the returned channel,
+// its buffer policy decides whether a full channel holds up the publishers of every matching topic.
+func SubscribePattern[T any](ctx context.Context, ic *Intracom, pattern string, conf SubscriberConfig[TopicMessage[T]]) (<-chan TopicMessage[T], error) {
+	if ic == nil {
+		return nil, ErrSubscribe{Action: ActionCreatingSubscription, Topic: pattern, Consumer: conf.ConsumerGroup, Err: ErrInvalidIntracomNil}
+	}
+
+	segments, err := parsePattern(pattern)
+	if err != nil {
+		return nil, ErrSubscribe{Action: ActionCreatingSubscription, Topic: pattern, Consumer: conf.ConsumerGroup, Err: err}
+	}
+
+	// the subscription is registered along with the list of the topics it starts from, under the same lock,
+	// so the topics created afterwards are attached by CreateTopic and none are missed or attached twice.
+	ic.mu.Lock()
+	if ic.closed.Load() {
+		ic.mu.Unlock()
+		return nil, ErrSubscribe{Action: ActionCreatingSubscription, Topic: pattern, Consumer: conf.ConsumerGroup, Err: ErrIntracomClosed}
+	}
+
+	if existing, exists := ic.patterns[conf.ConsumerGroup]; exists {
+		ic.mu.Unlock()
+		p, ok := existing.(*patternSubscription[T])
+		if !ok || p.pattern != pattern || conf.ErrIfExists {
+			return nil, ErrSubscribe{Action: ActionCreatingSubscription, Topic: pattern, Consumer: conf.ConsumerGroup, Err: ErrConsumerAlreadyExists}
+		}
+		return p.out.ch, nil
+	}
+
+	p := &patternSubscription[T]{
+		pattern:  pattern,
+		segments: segments,
+		consumer: conf.ConsumerGroup,
+		out:      newSubscriber(conf),
+		stopC:    make(chan struct{}),
+	}
+	ic.patterns[conf.ConsumerGroup] = p
+
+	topics := make([]any, 0)
+	for name, topicAny := range ic.topics {
+		if p.matches(name) {
+			topics = append(topics, topicAny)
+		}
+	}
+	ic.mu.Unlock()
+
+	for _, topicAny := range topics {
+		p.attach(ctx, topicAny)
+	}
+	return p.out.ch, nil
+}
+
+// RemovePatternSubscription stops the pattern subscription of the consumer group, unsubscribing it from every
+// matching topic, and closes its channel.
+func RemovePatternSubscription[T any](ic *Intracom, pattern string, consumer string, ch <-chan TopicMessage[T]) error {
+	if ic == nil {
+		return ErrSubscribe{Action: ActionRemovingSubscription, Topic: pattern, Consumer: consumer, Err: ErrInvalidIntracomNil}
+	}
+
+	ic.mu.Lock()
+	existing, exists := ic.patterns[consumer]
+	if !exists {
+		ic.mu.Unlock()
+		return nil
+	}
+
+	p, ok := existing.(*patternSubscription[T])
+	if !ok || p.pattern != pattern || (<-chan TopicMessage[T])(p.out.ch) != ch {
+		ic.mu.Unlock()
+		return ErrSubscribe{Action: ActionRemovingSubscription, Topic: pattern, Consumer: consumer, Err: ErrConsumerMismatch}
+	}
+	delete(ic.patterns, consumer)
+	ic.mu.Unlock()
+
+	p.stop()
+	p.close()
+	return nil
+}
+
+// patternSubscriber is the non generic part of a pattern subscription, the registry holds them by consumer group.
+type patternSubscriber interface {
+	matches(topic string) bool
+	attach(ctx context.Context, topicAny any)
+	stop()
+	close()
+}
+
+// relayTopic is implemented by the topics of the registry, pattern subscriptions receive their messages through it.
+type relayTopic interface {
+	Name() string
+	messageType() reflect.Type
+	relay(ctx context.Context, consumer string, stopC <-chan struct{}, deliver func(msg any)) (func(), error)
+}
+
+type patternSubscription[T any] struct {
+	pattern  string
+	segments []string
+	consumer string
+	out      subscriber[TopicMessage[T]]
+	stopC    chan struct{} // closed once the subscription is removed, the relays stop delivering.
+	sendMu   sync.Mutex    // the filter and buffer policy of the subscription see one message at a time.
+
+	mu      sync.Mutex
+	stopped bool
+	relays  sync.WaitGroup
+}
+
+func (p *patternSubscription[T]) matches(topic string) bool {
+	if strings.HasPrefix(topic, replyTopicPrefix) {
+		return false
+	}
+	return matchSegments(p.segments, strings.Split(topic, "."))
+}
+
+// attach relays the messages of the topic to the subscription if they are assignable to T.
+func (p *patternSubscription[T]) attach(ctx context.Context, topicAny any) {
+	t, ok := topicAny.(relayTopic)
+	if !ok || !t.messageType().AssignableTo(reflect.TypeFor[T]()) {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped {
+		return
+	}
+
+	name := t.Name()
+	run, err := t.relay(ctx, p.consumer, p.stopC, func(msg any) {
+		p.deliver(name, msg)
+	})
+	if err != nil {
+		// the topic closed meanwhile, or the consumer group is subscribed to it on its own.
+		return
+	}
+
+	p.relays.Add(1)
+	go func() {
+		defer p.relays.Done()
+		run()
+	}()
+}
+
+func (p *patternSubscription[T]) deliver(topic string, msg any) {
+	value, _ := msg.(T) // nil interface values are delivered as the zero value of T.
+	message := TopicMessage[T]{Topic: topic, Message: value}
+
+	p.sendMu.Lock()
+	defer p.sendMu.Unlock()
+	if !p.out.accepts(message) {
+		return
+	}
+	p.out.bufferPolicy.Handle(p.out.ch, message, p.stopC)
+}
+
+// stop releases the relays waiting to deliver and keeps new topics from being attached.
+func (p *patternSubscription[T]) stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.stopped {
+		p.stopped = true
+		close(p.stopC)
+	}
+}
+
+// close waits for the relays to return once stopped, then closes the channel of the subscription.
+func (p *patternSubscription[T]) close() {
+	p.relays.Wait()
+	p.out.Close()
+}
+
+// parsePattern splits the pattern into its segments, none of them may be empty.
+func parsePattern(pattern string) ([]string, error) {
+	segments := strings.Split(pattern, ".")
+	for _, segment := range segments {
+		if segment == "" {
+			return nil, ErrInvalidPattern
+		}
+	}
+	return segments, nil
+}
+
+// matchSegments reports whether the segments of a topic name match those of a pattern.
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case "**":
+			// ** takes as many segments of the name as the rest of the pattern leaves.
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		case "*":
+			if len(name) == 0 {
+				return false
+			}
+		default:
+			if len(name) == 0 || name[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
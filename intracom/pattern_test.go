@@ -0,0 +1,151 @@
+package intracom
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIntracom_MatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		topic   string
+		want    bool
+	}{
+		{"service.*.events", "service.db.events", true},
+		{"service.*.events", "service.db.metrics", false},
+		{"service.*.events", "service.events", false},
+		{"service.*.events", "service.db.cache.events", false},
+		{"service.**", "service", true},
+		{"service.**", "service.db.events", true},
+		{"**.events", "service.db.events", true},
+		{"**.events", "events", true},
+		{"**", "anything.at.all", true},
+		{"service.**.events", "service.events", true},
+		{"service.**.events", "service.db.events.metrics", false},
+	}
+
+	for _, tt := range tests {
+		segments, err := parsePattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("error parsing pattern %s: %v", tt.pattern, err)
+		}
+		if got := matchSegments(segments, strings.Split(tt.topic, ".")); got != tt.want {
+			t.Errorf("expected %s matching %s to be %t", tt.pattern, tt.topic, tt.want)
+		}
+	}
+
+	if _, err := parsePattern("service..events"); !errors.Is(err, ErrInvalidPattern) {
+		t.Errorf("expected %v for an empty segment, got %v", ErrInvalidPattern, err)
+	}
+}
+
+func TestIntracom_SubscribePattern(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	ic := New(t.Name())
+
+	dbEvents, err := CreateTopic[string](ic, TopicConfig{Name: "service.db.events"})
+	if err != nil {
+		t.Fatalf("error creating topic: %v", err)
+	}
+	dbMetrics, err := CreateTopic[string](ic, TopicConfig{Name: "service.db.metrics"})
+	if err != nil {
+		t.Fatalf("error creating topic: %v", err)
+	}
+	counts, err := CreateTopic[int](ic, TopicConfig{Name: "service.api.events"})
+	if err != nil {
+		t.Fatalf("error creating topic: %v", err)
+	}
+
+	events, err := SubscribePattern[string](ctx, ic, "service.*.events", SubscriberConfig[TopicMessage[string]]{ConsumerGroup: "audit", BufferSize: 10})
+	if err != nil {
+		t.Fatalf("error subscribing to pattern: %v", err)
+	}
+	everything, err := SubscribePattern[any](ctx, ic, "**", SubscriberConfig[TopicMessage[any]]{ConsumerGroup: "metrics", BufferSize: 10})
+	if err != nil {
+		t.Fatalf("error subscribing to pattern: %v", err)
+	}
+
+	// topics created after subscribing are matched as well.
+	cacheEvents, err := CreateTopic[string](ic, TopicConfig{Name: "service.cache.events"})
+	if err != nil {
+		t.Fatalf("error creating topic: %v", err)
+	}
+
+	for _, publish := range []func() error{
+		func() error { return dbEvents.Publish(ctx, "db started") },
+		func() error { return dbMetrics.Publish(ctx, "db latency") },
+		func() error { return counts.Publish(ctx, 42) },
+		func() error { return cacheEvents.Publish(ctx, "cache started") },
+	} {
+		if err := publish(); err != nil {
+			t.Fatalf("error publishing: %v", err)
+		}
+	}
+
+	receive := func(ch <-chan TopicMessage[string]) TopicMessage[string] {
+		t.Helper()
+		select {
+		case msg := <-ch:
+			return msg
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for a message")
+			return TopicMessage[string]{}
+		}
+	}
+
+	// messages of different topics are not ordered among each other.
+	got := map[string]string{}
+	for range 2 {
+		msg := receive(events)
+		got[msg.Topic] = msg.Message
+	}
+	if len(got) != 2 || got["service.db.events"] != "db started" || got["service.cache.events"] != "cache started" {
+		t.Fatalf("expected the string events of db and cache, got %v", got)
+	}
+
+	all := map[string]any{}
+	for range 4 {
+		select {
+		case msg := <-everything:
+			all[msg.Topic] = msg.Message
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for every message, got %v", all)
+		}
+	}
+	if all["service.api.events"] != 42 || all["service.db.metrics"] != "db latency" {
+		t.Fatalf("expected the messages of every topic, got %v", all)
+	}
+
+	if err := RemovePatternSubscription(ic, "service.*.events", "audit", events); err != nil {
+		t.Fatalf("error removing pattern subscription: %v", err)
+	}
+	if _, open := <-events; open {
+		t.Fatalf("expected the channel to be closed once removed")
+	}
+	if consumers := Consumers(ic, "service.db.events"); len(consumers) != 0 {
+		t.Errorf("expected pattern subscriptions not to be listed as consumers, got %v", consumers)
+	}
+
+	// closing the registry closes the pattern subscriptions left, even those holding up publishers.
+	stuck, err := SubscribePattern[string](ctx, ic, "service.db.*", SubscriberConfig[TopicMessage[string]]{ConsumerGroup: "stuck"})
+	if err != nil {
+		t.Fatalf("error subscribing to pattern: %v", err)
+	}
+	for _, msg := range []string{"db stopping", "db stopped"} {
+		if err := dbEvents.Publish(ctx, msg); err != nil {
+			t.Fatalf("error publishing: %v", err)
+		}
+	}
+	if err := Close(ic); err != nil {
+		t.Fatalf("error closing intracom: %v", err)
+	}
+	for range everything {
+	}
+	for range stuck {
+	}
+}
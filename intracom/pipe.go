@@ -0,0 +1,50 @@
+package intracom
+
+import "context"
+
+// Pipe is a typed channel between a single producer and a single consumer, such as two stages of an
+// rxd pipeline. Unlike a topic it does not fan out and never drops messages, Send blocks while the
+// buffer is full. A pipe outlives the routines using it, so a restarted producer or consumer picks up
+// where the previous one left off.
+type Pipe[T any] struct {
+	c chan T
+}
+
+// NewPipe creates a pipe buffering up to size messages, a pipe of size 0 hands every message over directly.
+func NewPipe[T any](size int) *Pipe[T] {
+	if size < 0 {
+		size = 0
+	}
+	return &Pipe[T]{c: make(chan T, size)}
+}
+
+// Send sends v to the consumer, it returns the error of ctx if ctx is done before v could be sent.
+func (p *Pipe[T]) Send(ctx context.Context, v T) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case p.c <- v:
+		return nil
+	}
+}
+
+// Receive returns the next message, it returns the error of ctx if ctx is done before one is sent.
+func (p *Pipe[T]) Receive(ctx context.Context) (T, error) {
+	select {
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	case v := <-p.c:
+		return v, nil
+	}
+}
+
+// C returns the channel messages are received on, to select on along with other channels.
+func (p *Pipe[T]) C() <-chan T {
+	return p.c
+}
+
+// Len returns the number of messages waiting in the buffer.
+func (p *Pipe[T]) Len() int {
+	return len(p.c)
+}
@@ -0,0 +1,36 @@
+package intracom
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPipe(t *testing.T) {
+	pipe := NewPipe[int](2)
+	ctx := context.Background()
+
+	for i := 1; i <= 2; i++ {
+		if err := pipe.Send(ctx, i); err != nil {
+			t.Fatalf("error sending %d: %s", i, err)
+		}
+	}
+	if pipe.Len() != 2 {
+		t.Errorf("expected 2 buffered messages, got %d", pipe.Len())
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := pipe.Send(cancelled, 3); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a full pipe to block until the context is done, got %v", err)
+	}
+
+	for want := 1; want <= 2; want++ {
+		if got, err := pipe.Receive(ctx); err != nil || got != want {
+			t.Errorf("expected %d, got %d (%v)", want, got, err)
+		}
+	}
+	if _, err := pipe.Receive(cancelled); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected an empty pipe to block until the context is done, got %v", err)
+	}
+}
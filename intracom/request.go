@@ -1,27 +1,37 @@
 package intracom
 
-type subscribeRequest[T any] struct {
-	conf      SubscriberConfig[T]
-	responseC chan<- subscribeResponse[T]
+// The request types below are sent by a Topic over the requests channel of its Broadcaster.
+// A Broadcaster must answer every request on its response channel.
+
+// SubscribeRequest asks the broadcaster to add (or return the existing) consumer group.
+// When Snapshot is set the broadcaster returns its retained last message in the response
+// instead of replaying it on the channel, or ErrNoSnapshot without subscribing when it has none.
+type SubscribeRequest[T any] struct {
+	Conf      SubscriberConfig[T]
+	Snapshot  bool
+	ResponseC chan<- SubscribeResponse[T]
 }
 
-type subscribeResponse[T any] struct {
-	ch  <-chan T
-	err error
+type SubscribeResponse[T any] struct {
+	Ch   <-chan T
+	Last T // the retained last message, only set for snapshot requests.
+	Err  error
 }
 
-type unsubscribeRequest[T any] struct {
-	consumer  string
-	ch        <-chan T
-	responseC chan<- unsubscribeResponse
+// UnsubscribeRequest asks the broadcaster to remove a consumer group and close its channel.
+type UnsubscribeRequest[T any] struct {
+	Consumer  string
+	Ch        <-chan T
+	ResponseC chan<- UnsubscribeResponse
 }
 
-type unsubscribeResponse struct {
-	err error
+type UnsubscribeResponse struct {
+	Err error
 }
 
-type closeRequest struct {
-	responseC chan<- closeResponse
+// CloseRequest asks the broadcaster to stop broadcasting and close all subscriber channels.
+type CloseRequest struct {
+	ResponseC chan<- CloseResponse
 }
 
-type closeResponse struct{}
+type CloseResponse struct{}
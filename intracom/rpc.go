@@ -0,0 +1,156 @@
+package intracom
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// replyTopicPrefix prefixes the hidden topics a single reply is published to, see Request.
+const replyTopicPrefix = "_intracom.reply."
+
+// Envelope is a request published by Request to a request topic, a Topic[Envelope[T]].
+// Responders answer it with Reply, or serve the topic with Respond.
+type Envelope[T any] struct {
+	Payload T
+	ReplyTo string // hidden topic the reply is published to.
+}
+
+// reply is published to the reply topic of a request.
+type reply[R any] struct {
+	value R
+	err   error
+}
+
+// Request publishes payload to the request topic and waits for the first reply, for up to timeout or until ctx
+// is done, e.g. to ask a service for its current config. The reply is received on a hidden topic created for
+// this request only and removed once it returns. The request topic is awaited for up to timeout.
+// A timeout of 0 waits until ctx is done. ErrRequestTimeout is returned once the timeout elapsed, and the error
+// of the responder, given to Reply, is returned as is.
+func Request[T, R any](ctx context.Context, ic *Intracom, topic string, payload T, timeout time.Duration) (R, error) {
+	var zero R
+	if ic == nil {
+		return zero, ErrTopic{Topic: topic, Action: ActionRequesting, Err: ErrInvalidIntracomNil}
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// the reply topic is subscribed to before the request is published, so the reply cannot be missed.
+	// further replies are dropped rather than holding up the responders.
+	replyTo := replyTopicPrefix + topic + "." + strconv.FormatUint(ic.consumerIDs.Add(1), 10)
+	replies, err := CreateTopic[reply[R]](ic, TopicConfig{Name: replyTo, ErrIfExists: true})
+	if err != nil {
+		return zero, err
+	}
+	defer RemoveTopic[reply[R]](ic, replyTo)
+
+	replyC, err := replies.Subscribe(ctx, SubscriberConfig[reply[R]]{
+		ConsumerGroup: replyTo,
+		BufferSize:    1,
+		BufferPolicy:  BufferPolicyDropNewest[reply[R]]{},
+	})
+	if err != nil {
+		return zero, requestError(ctx, topic, err)
+	}
+
+	requests, err := waitForTopic[Envelope[T]](ctx, ic, topic, 0, replyTo)
+	if err != nil {
+		return zero, requestError(ctx, topic, err)
+	}
+
+	if err := requests.Publish(ctx, Envelope[T]{Payload: payload, ReplyTo: replyTo}); err != nil {
+		return zero, requestError(ctx, topic, err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return zero, requestError(ctx, topic, ctx.Err())
+	case r, ok := <-replyC:
+		if !ok {
+			return zero, ErrTopic{Topic: topic, Action: ActionRequesting, Err: ErrIntracomClosed}
+		}
+		return r.value, r.err
+	}
+}
+
+// Reply publishes the reply to a request to the topic it is expected on, env.ReplyTo, err is returned by Request.
+// Only the first reply to a request is received, ErrTopicDoesNotExist or ErrTopicClosed is returned once the request
+// has returned.
+func Reply[T, R any](ic *Intracom, env Envelope[T], value R, err error) error {
+	if ic == nil {
+		return ErrTopic{Topic: env.ReplyTo, Action: ActionReplying, Err: ErrInvalidIntracomNil}
+	}
+
+	ic.mu.RLock()
+	topicAny, ok := ic.topics[env.ReplyTo]
+	ic.mu.RUnlock()
+	if !ok {
+		return ErrTopic{Topic: env.ReplyTo, Action: ActionReplying, Err: ErrTopicDoesNotExist}
+	}
+	replies, typeErr := typedTopic[reply[R]](env.ReplyTo, topicAny)
+	if typeErr != nil {
+		return ErrTopic{Topic: env.ReplyTo, Action: ActionReplying, Err: typeErr}
+	}
+
+	// the reply topic drops the replies it has no room for, publishing returns right away.
+	if err := replies.Publish(context.Background(), reply[R]{value: value, err: err}); err != nil {
+		return ErrTopic{Topic: env.ReplyTo, Action: ActionReplying, Err: err}
+	}
+	return nil
+}
+
+// Respond answers the requests published to topic with handle until ctx is done, creating the topic if needed.
+// Requests are handled one at a time, in the order they were published. It returns the error of the context,
+// or nil once the topic is closed.
+func Respond[T, R any](ctx context.Context, ic *Intracom, topic string, conf SubscriberConfig[Envelope[T]], handle func(ctx context.Context, payload T) (R, error)) error {
+	if _, err := CreateTopic[Envelope[T]](ic, TopicConfig{Name: topic}); err != nil {
+		return err
+	}
+
+	if conf.ConsumerGroup == "" {
+		conf.ConsumerGroup = ConsumerID(ic, topic+".responder")
+	}
+	requests, err := CreateSubscription[Envelope[T]](ctx, ic, topic, 0, conf)
+	if err != nil {
+		return err
+	}
+	defer RemoveSubscription[Envelope[T]](ic, topic, conf.ConsumerGroup, requests)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case env, ok := <-requests:
+			if !ok {
+				return nil
+			}
+			if !pending(ic, env.ReplyTo) {
+				// the request was replayed to this new subscriber but its requester has returned already.
+				continue
+			}
+			value, err := handle(ctx, env.Payload)
+			// the requester may have given up already, there is no one left to tell.
+			_ = Reply(ic, env, value, err)
+		}
+	}
+}
+
+// pending reports whether the requester still waits on the reply topic.
+func pending(ic *Intracom, replyTo string) bool {
+	ic.mu.RLock()
+	defer ic.mu.RUnlock()
+	_, ok := ic.topics[replyTo]
+	return ok
+}
+
+// requestError reports the timeout of a request as ErrRequestTimeout.
+func requestError(ctx context.Context, topic string, err error) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		err = ErrRequestTimeout
+	}
+	return ErrTopic{Topic: topic, Action: ActionRequesting, Err: err}
+}
@@ -0,0 +1,88 @@
+package intracom
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIntracom_Request(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	ic := New(t.Name())
+	defer Close(ic)
+
+	errNegative := errors.New("negative number")
+	respondCtx, stopResponding := context.WithCancel(ctx)
+	doneC := make(chan error, 1)
+	go func() {
+		doneC <- Respond(respondCtx, ic, "double", SubscriberConfig[Envelope[int]]{BufferSize: 1}, func(ctx context.Context, n int) (int, error) {
+			if n < 0 {
+				return 0, errNegative
+			}
+			return n * 2, nil
+		})
+	}()
+
+	for len(Consumers(ic, "double")) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	got, err := Request[int, int](ctx, ic, "double", 21, time.Second)
+	if err != nil || got != 42 {
+		t.Fatalf("expected 42, got %d with error %v", got, err)
+	}
+
+	if _, err := Request[int, int](ctx, ic, "double", -1, time.Second); err != errNegative {
+		t.Fatalf("expected the error of the responder, got %v", err)
+	}
+
+	stopResponding()
+	if err := <-doneC; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the responder to stop with the context, got %v", err)
+	}
+
+	ic.mu.RLock()
+	defer ic.mu.RUnlock()
+	for name := range ic.topics {
+		if strings.HasPrefix(name, replyTopicPrefix) {
+			t.Errorf("expected the reply topics to be removed, found %s", name)
+		}
+	}
+}
+
+func TestIntracom_RequestTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	ic := New(t.Name())
+	defer Close(ic)
+
+	// the request topic never exists.
+	if _, err := Request[string, string](ctx, ic, "missing", "ping", 50*time.Millisecond); !errors.Is(err, ErrRequestTimeout) {
+		t.Fatalf("expected %v without a request topic, got %v", ErrRequestTimeout, err)
+	}
+
+	// the request is received but never replied to.
+	requests, err := CreateTopic[Envelope[string]](ic, TopicConfig{Name: "silent"})
+	if err != nil {
+		t.Fatalf("error creating topic: %v", err)
+	}
+	sub, err := requests.Subscribe(ctx, SubscriberConfig[Envelope[string]]{ConsumerGroup: t.Name(), BufferSize: 1})
+	if err != nil {
+		t.Fatalf("error subscribing: %v", err)
+	}
+
+	if _, err := Request[string, string](ctx, ic, "silent", "ping", 50*time.Millisecond); !errors.Is(err, ErrRequestTimeout) {
+		t.Fatalf("expected %v without a reply, got %v", ErrRequestTimeout, err)
+	}
+
+	// replying once the requester returned is reported.
+	env := <-sub
+	if err := Reply(ic, env, "pong", nil); !errors.Is(err, ErrTopicDoesNotExist) {
+		t.Fatalf("expected %v replying late, got %v", ErrTopicDoesNotExist, err)
+	}
+}
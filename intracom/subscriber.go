@@ -1,7 +1,6 @@
 package intracom
 
 import (
-	"errors"
 	"sync/atomic"
 	"time"
 )
@@ -14,6 +13,8 @@ type subscriber[T any] struct {
 	ch            chan T
 	stopC         chan struct{}
 	closed        *atomic.Bool
+	hooks         *topicHooks[T]
+	filter        func(msg T) bool
 }
 
 func newSubscriber[T any](conf SubscriberConfig[T]) subscriber[T] {
@@ -24,13 +25,23 @@ func newSubscriber[T any](conf SubscriberConfig[T]) subscriber[T] {
 		if bp.Timer == nil {
 			bp.Timer = time.NewTimer(conf.DropTimeout)
 		}
+		if bp.DropTimeout == 0 {
+			bp.DropTimeout = conf.DropTimeout
+		}
 		bp.Timer.Stop()
+		bufferPolicy = bp
 	case BufferPolicyDropNewestAfterTimeout[T]:
 		if bp.Timer == nil {
 			bp.Timer = time.NewTimer(conf.DropTimeout)
 		}
+		if bp.DropTimout == 0 {
+			bp.DropTimout = conf.DropTimeout
+		}
 		bp.Timer.Stop()
 		bufferPolicy = bp
+	case nil:
+		// without a policy the subscriber applies back pressure rather than dropping messages.
+		bufferPolicy = BufferPolicyDropNone[T]{}
 	default:
 		bufferPolicy = bp
 	}
@@ -43,9 +54,17 @@ func newSubscriber[T any](conf SubscriberConfig[T]) subscriber[T] {
 		ch:            make(chan T, conf.BufferSize),
 		stopC:         make(chan struct{}),
 		closed:        &atomic.Bool{},
+		hooks:         conf.hooks,
+		filter:        conf.Filter,
 	}
 }
 
+// NewSubscriber creates a subscriber channel for use by custom Broadcasters.
+// Send applies the filter and the buffer policy of the config, Close closes the underlying channel.
+func NewSubscriber[T any](conf SubscriberConfig[T]) Channel[T] {
+	return newSubscriber[T](conf)
+}
+
 func (s subscriber[T]) Chan() <-chan T {
 	return s.ch
 }
@@ -55,15 +74,33 @@ func (s subscriber[T]) Chan() <-chan T {
 // how to handle the message.
 func (s subscriber[T]) Send(message T) error {
 	if s.closed.Load() {
-		return errors.New("subscriber already closed")
+		s.hooks.dropped(s.consumerGroup, message, ErrSubscriberClosed)
+		return ErrSubscriberClosed
+	}
+
+	if !s.accepts(message) {
+		return nil
+	}
+
+	start := time.Now()
+	err := s.bufferPolicy.Handle(s.ch, message, s.stopC)
+	if err != nil {
+		s.hooks.dropped(s.consumerGroup, message, err)
+		return err
 	}
 
-	return s.bufferPolicy.Handle(s.ch, message, s.stopC)
+	s.hooks.delivered(s.consumerGroup, message, time.Since(start))
+	return nil
+}
+
+// accepts reports whether the filter of the subscriber, if any, lets the message through.
+func (s subscriber[T]) accepts(message T) bool {
+	return s.filter == nil || s.filter(message)
 }
 
 func (s subscriber[T]) Close() error {
 	if s.closed.Swap(true) {
-		return errors.New("subscriber already closed")
+		return ErrSubscriberClosed
 	}
 
 	//signal to stop
@@ -8,4 +8,10 @@ type SubscriberConfig[T any] struct {
 	BufferSize    int
 	BufferPolicy  BufferPolicyHandler[T]
 	DropTimeout   time.Duration
+	// Filter, if set, is asked by the broadcaster whether to deliver each message, including the replayed last
+	// message, to the consumer group. It is called from the broadcaster routine in the order messages are
+	// published, so it may keep state between calls, but it holds up the topic and must not block.
+	Filter func(msg T) bool
+
+	hooks *topicHooks[T] // set by the topic when subscribing.
 }
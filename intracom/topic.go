@@ -2,7 +2,7 @@ package intracom
 
 import (
 	"context"
-	"errors"
+	"reflect"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -12,8 +12,17 @@ type Topic[T any] interface {
 	Name() string                                                              // Name returns the unique name of the topic.
 	PublishChannel() chan<- T                                                  // PublishChannel returns the channel publishers use to send messages to the topic.
 	Subscribe(ctx context.Context, conf SubscriberConfig[T]) (<-chan T, error) // Subscribe will attemp to add a consumer group to the topic.
-	Unsubscribe(consumer string, ch <-chan T) error                            // Unsubscribe will remove the consumer group from the topic and close the subscriber channel.
-	Close() error                                                              // Close will remove all consumer groups from the topic and close all channels.
+	// Publish sends msg to the topic, waiting until it is taken or ctx is done. Unlike sending on the PublishChannel,
+	// publishing to a closed topic returns ErrTopicClosed instead of panicking, also when it closes meanwhile.
+	Publish(ctx context.Context, msg T) error
+	// TryPublish sends msg to the topic only if it is taken right away, it reports false otherwise or once closed.
+	TryPublish(msg T) bool
+	// SubscribeWithSnapshot subscribes like Subscribe but also returns the retained last message of the topic,
+	// which is not replayed on the channel. ErrNoSnapshot is returned, without subscribing, if nothing was published yet.
+	SubscribeWithSnapshot(ctx context.Context, conf SubscriberConfig[T]) (T, <-chan T, error)
+	Unsubscribe(consumer string, ch <-chan T) error // Unsubscribe will remove the consumer group from the topic and close the subscriber channel.
+	Close() error                                   // Close will remove all consumer groups from the topic and close all channels.
+	Stats() TopicStats                              // Stats returns the traffic of the topic and the state of its consumer groups.
 }
 
 type TopicOption[T any] func(*topic[T])
@@ -35,6 +44,10 @@ type TopicConfig struct {
 	Name            string // unique name for the topic
 	ErrIfExists     bool   // return error if topic already exists
 	SubscriberAware bool   // if true, topic broadcaster wont broadcast if there are no subscribers.
+	// ReplayLast guarantees new subscribers first receive the last message published to the topic, the
+	// latest state for topics of states, even those subscribing without a buffer. Without it the last message
+	// is only replayed to subscribers with room in their buffer. Custom broadcasters are configured on their own.
+	ReplayLast bool
 }
 
 type topic[T any] struct {
@@ -42,7 +55,10 @@ type topic[T any] struct {
 	publishC chan T
 	requestC chan any
 	bc       Broadcaster[T]
+	hooks    *topicHooks[T]
 	closed   atomic.Bool
+	closingC chan struct{} // closed once Close is called, releases the publishers waiting in Publish.
+	meter    *rateMeter    // counts the messages published with Publish and TryPublish.
 	mu       sync.RWMutex
 }
 
@@ -55,18 +71,29 @@ func NewTopic[T any](conf TopicConfig, opts ...TopicOption[T]) Topic[T] {
 		publishC: publishC,
 		requestC: requestC,
 		closed:   atomic.Bool{},
+		closingC: make(chan struct{}),
+		meter:    newRateMeter(),
 		bc: SyncBroadcaster[T]{
 			SubscriberAware: conf.SubscriberAware,
+			ReplayLast:      conf.ReplayLast,
 		},
-		mu: sync.RWMutex{},
+		hooks: &topicHooks[T]{topic: conf.Name},
+		mu:    sync.RWMutex{},
 	}
 
 	for _, opt := range opts {
 		opt(t)
 	}
 
+	broadcastC := publishC
+	if t.hooks.onPublish != nil {
+		// interpose a forwarder between publishers and the broadcaster to observe every published message.
+		broadcastC = make(chan T)
+		go t.forward(publishC, broadcastC)
+	}
+
 	// start a broadcaster for this topic
-	go t.bc.Broadcast(requestC, publishC)
+	go t.bc.Broadcast(requestC, broadcastC)
 
 	return t
 }
@@ -75,66 +102,197 @@ func (t *topic[T]) Name() string {
 	return t.name
 }
 
+// messageType is the type of the messages of the topic, for lookups and pattern subscriptions of other types.
+func (t *topic[T]) messageType() reflect.Type {
+	return reflect.TypeFor[T]()
+}
+
+// relay subscribes the consumer group to the topic for a pattern subscription. The returned run hands every
+// message to deliver until stopC is closed, then unsubscribes, or until the topic closes.
+func (t *topic[T]) relay(ctx context.Context, consumer string, stopC <-chan struct{}, deliver func(msg any)) (run func(), err error) {
+	ch, err := t.Subscribe(ctx, SubscriberConfig[T]{ConsumerGroup: consumer, ErrIfExists: true, BufferSize: 1})
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		for {
+			select {
+			case <-stopC:
+				// the broadcaster may be waiting to deliver to the relay, it is drained while unsubscribing.
+				go func() {
+					for range ch {
+					}
+				}()
+				t.Unsubscribe(consumer, ch)
+				return
+			case msg, open := <-ch:
+				if !open {
+					return
+				}
+				deliver(msg)
+			}
+		}
+	}, nil
+}
+
 func (t *topic[T]) PublishChannel() chan<- T {
 	return t.publishC
 }
 
+func (t *topic[T]) Publish(ctx context.Context, msg T) error {
+	// the lock is held while sending so Close cannot close the publish channel meanwhile.
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.closed.Load() {
+		return ErrTopicClosed
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.closingC:
+		return ErrTopicClosed
+	case t.publishC <- msg:
+		t.meter.add(time.Now())
+		return nil
+	}
+}
+
+func (t *topic[T]) TryPublish(msg T) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.closed.Load() {
+		return false
+	}
+
+	select {
+	case t.publishC <- msg:
+		t.meter.add(time.Now())
+		return true
+	default:
+		return false
+	}
+}
+
 func (t *topic[T]) Subscribe(ctx context.Context, conf SubscriberConfig[T]) (<-chan T, error) {
+	res := t.subscribe(ctx, conf, false)
+	return res.Ch, res.Err
+}
+
+func (t *topic[T]) SubscribeWithSnapshot(ctx context.Context, conf SubscriberConfig[T]) (T, <-chan T, error) {
+	res := t.subscribe(ctx, conf, true)
+	return res.Last, res.Ch, res.Err
+}
+
+func (t *topic[T]) subscribe(ctx context.Context, conf SubscriberConfig[T], snapshot bool) SubscribeResponse[T] {
+	// hold off Close until the request is answered, Close closes the request channel.
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
 	if t.closed.Load() {
-		return nil, errors.New("cannot subscribe, topic already closed")
+		return SubscribeResponse[T]{Err: ErrTopicClosed}
 	}
 
-	responseC := make(chan subscribeResponse[T], 1)
+	// subscribers report deliveries and drops through the hooks of the topic.
+	conf.hooks = t.hooks
+
+	responseC := make(chan SubscribeResponse[T], 1)
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("subscribe request timed out 1")
-	case t.requestC <- subscribeRequest[T]{conf: conf, responseC: responseC}:
+		return SubscribeResponse[T]{Err: ErrSubscribeTimeout}
+	case t.requestC <- SubscribeRequest[T]{Conf: conf, Snapshot: snapshot, ResponseC: responseC}:
 	}
 
 	select {
 	case <-ctx.Done():
-		return nil, errors.New("subscribe response timed out 2")
+		return SubscribeResponse[T]{Err: ErrSubscribeTimeout}
 	case res := <-responseC:
-		return res.ch, res.err
+		if res.Err == nil {
+			// broadcasters buffering outside the subscriber channel report its depth on their own.
+			ch := res.Ch
+			depth := func() (int, int) { return len(ch), cap(ch) }
+			t.hooks.track(conf.ConsumerGroup).depth.CompareAndSwap(nil, &depth)
+		}
+		return res
 	}
 }
 
 // Unsubscribe will remove the consumer group from the topic.
 func (t *topic[T]) Unsubscribe(consumer string, ch <-chan T) error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
 	if t.closed.Load() {
-		return errors.New("cannot unsubscribe, topic already closed")
+		return ErrTopicClosed
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	responseC := make(chan unsubscribeResponse, 1)
+	responseC := make(chan UnsubscribeResponse, 1)
 	select {
 	case <-ctx.Done():
-		return errors.New("unsubscribe request timed out")
-	case t.requestC <- unsubscribeRequest[T]{consumer: consumer, ch: ch, responseC: responseC}:
+		return ErrUnsubscribeTimeout
+	case t.requestC <- UnsubscribeRequest[T]{Consumer: consumer, Ch: ch, ResponseC: responseC}:
 	}
 
 	select {
 	case <-ctx.Done():
-		return errors.New("unsubscribe response timed out")
+		return ErrUnsubscribeTimeout
 	case resp := <-responseC:
-		return resp.err
+		if resp.Err == nil {
+			t.hooks.untrack(consumer)
+		}
+		return resp.Err
 	}
 
 }
 
+// Close waits for pending subscribe and unsubscribe requests before closing the topic.
+// Publishers using Publish are released, those sending on the publish channel must have stopped,
+// sending on the publish channel of a closed topic panics.
 func (t *topic[T]) Close() error {
 	if t.closed.Swap(true) {
-		return errors.New("topic already closed")
+		return ErrTopicClosed
 	}
+	// publishers waiting in Publish hold the lock, they give up before Close takes it.
+	close(t.closingC)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
 
-	responseC := make(chan closeResponse, 1)
-	t.requestC <- closeRequest{responseC: responseC}
+	responseC := make(chan CloseResponse, 1)
+	t.requestC <- CloseRequest{ResponseC: responseC}
 	<-responseC
+	t.hooks.untrackAll()
 
 	// now we can close the request channel
 	close(t.requestC)
 	close(t.publishC)
 	return nil
 }
+
+// Stats returns the traffic of the topic and the state of its consumer groups, see TopicStats.
+func (t *topic[T]) Stats() TopicStats {
+	published, rate := t.meter.read(time.Now())
+	consumers := t.hooks.consumerStats()
+	return TopicStats{
+		Name:        t.name,
+		Subscribers: len(consumers),
+		Published:   published,
+		PublishRate: rate,
+		Consumers:   consumers,
+	}
+}
+
+// forward relays published messages to the broadcaster, calling the publish hook for each message.
+func (t *topic[T]) forward(publishC <-chan T, broadcastC chan<- T) {
+	defer close(broadcastC)
+	for msg := range publishC {
+		t.hooks.onPublish(t.name, msg)
+		broadcastC <- msg
+	}
+}
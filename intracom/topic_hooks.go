@@ -0,0 +1,65 @@
+package intracom
+
+import (
+	"sync"
+	"time"
+)
+
+// topicHooks holds the instrumentation callbacks of a topic.
+// Hooks are called from the broadcaster routine, so they must be fast and must not block.
+type topicHooks[T any] struct {
+	topic     string
+	onPublish func(topic string, message T)
+	onDeliver func(topic, consumer string, message T, took time.Duration)
+	onDrop    func(topic, consumer string, message T, err error)
+	consumers sync.Map // consumer group to its *consumerCounters, see Topic.Stats.
+}
+
+// WithOnPublish calls fn for every message published to the topic before it is broadcasted.
+func WithOnPublish[T any](fn func(topic string, message T)) TopicOption[T] {
+	return func(t *topic[T]) {
+		t.hooks.onPublish = fn
+	}
+}
+
+// WithOnDeliver calls fn for every message delivered to a consumer group of the topic,
+// took is the time spent handing the message to the subscriber including any buffer policy waits.
+func WithOnDeliver[T any](fn func(topic, consumer string, message T, took time.Duration)) TopicOption[T] {
+	return func(t *topic[T]) {
+		t.hooks.onDeliver = fn
+	}
+}
+
+// WithOnDrop calls fn for every message that was not delivered to a consumer group of the topic,
+// err describes why the message was dropped.
+func WithOnDrop[T any](fn func(topic, consumer string, message T, err error)) TopicOption[T] {
+	return func(t *topic[T]) {
+		t.hooks.onDrop = fn
+	}
+}
+
+func (h *topicHooks[T]) delivered(consumer string, message T, took time.Duration) {
+	if h == nil {
+		return
+	}
+	if counters := h.counters(consumer); counters != nil {
+		counters.delivered.Add(1)
+	}
+	if h.onDeliver == nil {
+		return
+	}
+	h.onDeliver(h.topic, consumer, message, took)
+}
+
+func (h *topicHooks[T]) dropped(consumer string, message T, err error) {
+	if h == nil {
+		return
+	}
+	if counters := h.counters(consumer); counters != nil {
+		counters.dropped.Add(1)
+	}
+	if h.onDrop == nil {
+		return
+	}
+	h.onDrop(h.topic, consumer, message, err)
+}
@@ -0,0 +1,171 @@
+package intracom
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// rateWindow is the number of seconds the publish rate of a topic is averaged over.
+const rateWindow = 10
+
+// TopicStats describes the traffic of a topic and the state of its consumer groups, see Topic.Stats.
+// They are read without involving the broadcaster, so they are available while a slow consumer holds it up.
+type TopicStats struct {
+	Name        string          `json:"name"`
+	Subscribers int             `json:"subscribers"`  // consumer groups subscribed.
+	Published   uint64          `json:"published"`    // messages published with Publish or TryPublish, not on the PublishChannel.
+	PublishRate float64         `json:"publish_rate"` // messages published per second over the last 10 seconds.
+	Consumers   []ConsumerStats `json:"consumers"`    // sorted by consumer group.
+}
+
+// ConsumerStats describes a consumer group of a topic since it subscribed.
+type ConsumerStats struct {
+	ConsumerGroup string `json:"consumer_group"`
+	Delivered     uint64 `json:"delivered"` // messages handed to the subscriber.
+	Dropped       uint64 `json:"dropped"`   // messages dropped by its buffer policy or once it was closed.
+	Depth         int    `json:"depth"`     // messages buffered, waiting for the subscriber to receive them.
+	Capacity      int    `json:"capacity"`  // messages the buffer holds.
+}
+
+// Stats returns the stats of every topic of the intracom, sorted by topic name.
+func Stats(ic *Intracom) []TopicStats {
+	if ic == nil {
+		return nil
+	}
+
+	ic.mu.RLock()
+	topics := make([]interface{ Stats() TopicStats }, 0, len(ic.topics))
+	for _, topicAny := range ic.topics {
+		if topic, ok := topicAny.(interface{ Stats() TopicStats }); ok {
+			topics = append(topics, topic)
+		}
+	}
+	ic.mu.RUnlock()
+
+	stats := make([]TopicStats, 0, len(topics))
+	for _, topic := range topics {
+		stats = append(stats, topic.Stats())
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
+
+// consumerCounters are kept by the hooks of a topic for each of its consumer groups.
+type consumerCounters struct {
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+	// depth reports the messages buffered for the consumer group and the size of its buffer,
+	// set by broadcasters buffering outside the subscriber channel, or else by the topic.
+	depth atomic.Pointer[func() (depth, capacity int)]
+}
+
+func (c *consumerCounters) setDepth(fn func() (depth, capacity int)) {
+	if c != nil {
+		c.depth.Store(&fn)
+	}
+}
+
+// track returns the counters of the consumer group, creating them once it subscribed.
+func (h *topicHooks[T]) track(consumer string) *consumerCounters {
+	if h == nil {
+		return nil
+	}
+	counters, _ := h.consumers.LoadOrStore(consumer, &consumerCounters{})
+	return counters.(*consumerCounters)
+}
+
+// counters returns the counters of the consumer group, nil once it unsubscribed.
+func (h *topicHooks[T]) counters(consumer string) *consumerCounters {
+	counters, ok := h.consumers.Load(consumer)
+	if !ok {
+		return nil
+	}
+	return counters.(*consumerCounters)
+}
+
+func (h *topicHooks[T]) untrack(consumer string) {
+	h.consumers.Delete(consumer)
+}
+
+func (h *topicHooks[T]) untrackAll() {
+	h.consumers.Range(func(key, _ any) bool {
+		h.consumers.Delete(key)
+		return true
+	})
+}
+
+func (h *topicHooks[T]) consumerStats() []ConsumerStats {
+	stats := make([]ConsumerStats, 0)
+	h.consumers.Range(func(key, value any) bool {
+		counters := value.(*consumerCounters)
+		s := ConsumerStats{
+			ConsumerGroup: key.(string),
+			Delivered:     counters.delivered.Load(),
+			Dropped:       counters.dropped.Load(),
+		}
+		if depth := counters.depth.Load(); depth != nil {
+			s.Depth, s.Capacity = (*depth)()
+		}
+		stats = append(stats, s)
+		return true
+	})
+	sort.Slice(stats, func(i, j int) bool { return stats[i].ConsumerGroup < stats[j].ConsumerGroup })
+	return stats
+}
+
+// rateMeter counts events in one second buckets, reporting their rate over the last rateWindow seconds.
+type rateMeter struct {
+	mu      sync.Mutex
+	start   time.Time
+	total   uint64
+	second  int64 // seconds since start of the latest bucket.
+	buckets [rateWindow]uint64
+}
+
+func newRateMeter() *rateMeter {
+	return &rateMeter{start: time.Now()}
+}
+
+func (m *rateMeter) add(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.advance(now)
+	m.total++
+	m.buckets[m.second%rateWindow]++
+}
+
+// advance clears the buckets of the seconds elapsed since the latest one.
+func (m *rateMeter) advance(now time.Time) {
+	second := int64(now.Sub(m.start) / time.Second)
+	if second-m.second >= rateWindow {
+		m.buckets = [rateWindow]uint64{}
+		m.second = second
+		return
+	}
+	for m.second < second {
+		m.second++
+		m.buckets[m.second%rateWindow] = 0
+	}
+}
+
+// read returns the events counted in total and their rate per second over the window.
+func (m *rateMeter) read(now time.Time) (uint64, float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.advance(now)
+
+	var sum uint64
+	for _, count := range m.buckets {
+		sum += count
+	}
+
+	// the window spans the full seconds of the older buckets and the part of the latest second elapsed.
+	windowStart := m.start.Add(time.Duration(max(m.second-(rateWindow-1), 0)) * time.Second)
+	elapsed := now.Sub(windowStart).Seconds()
+	if elapsed <= 0 {
+		return m.total, 0
+	}
+	return m.total, float64(sum) / elapsed
+}
@@ -2,6 +2,8 @@ package intracom
 
 import (
 	"context"
+	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -107,11 +109,349 @@ func TestIntracom_TopicDuplicateSubscribers(t *testing.T) {
 		BufferPolicy:  BufferPolicyDropNone[string]{},
 	})
 
-	if err == nil {
-		t.Fatalf("expected error subscribing to topic, got nil")
+	if !errors.Is(err, ErrConsumerAlreadyExists) {
+		t.Fatalf("expected %v subscribing to topic, got %v", ErrConsumerAlreadyExists, err)
 	}
 
 	if sub1 != sub2 {
 		t.Fatalf("expected same subscribers, got different")
 	}
+
+	if err := testTopic.Unsubscribe(t.Name(), make(chan string)); !errors.Is(err, ErrConsumerMismatch) {
+		t.Fatalf("expected %v unsubscribing with another channel, got %v", ErrConsumerMismatch, err)
+	}
+
+	if err := testTopic.Close(); err != nil {
+		t.Fatalf("error closing topic: %v", err)
+	}
+
+	if _, err := testTopic.Subscribe(ctx, SubscriberConfig[string]{ConsumerGroup: "late"}); !errors.Is(err, ErrTopicClosed) {
+		t.Fatalf("expected %v subscribing to a closed topic, got %v", ErrTopicClosed, err)
+	}
+
+	if err := testTopic.Close(); !errors.Is(err, ErrTopicClosed) {
+		t.Fatalf("expected %v closing the topic twice, got %v", ErrTopicClosed, err)
+	}
+}
+
+func TestIntracom_TopicHooks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var published, delivered, dropped atomic.Int32
+	testTopic := NewTopic[string](TopicConfig{Name: t.Name()},
+		WithOnPublish[string](func(topic string, message string) { published.Add(1) }),
+		WithOnDeliver[string](func(topic, consumer string, message string, took time.Duration) { delivered.Add(1) }),
+		WithOnDrop[string](func(topic, consumer string, message string, err error) { dropped.Add(1) }),
+	)
+	defer testTopic.Close()
+
+	sub, err := testTopic.Subscribe(ctx, SubscriberConfig[string]{
+		ConsumerGroup: t.Name(),
+		BufferSize:    1,
+		BufferPolicy:  BufferPolicyDropNewest[string]{},
+	})
+	if err != nil {
+		t.Fatalf("error subscribing to topic: %v", err)
+	}
+
+	// nothing reads the subscriber, so only the first message fits in its buffer.
+	publishC := testTopic.PublishChannel()
+	publishC <- "first"
+	publishC <- "second"
+	publishC <- "third"
+
+	deadline := time.Now().Add(time.Second)
+	for delivered.Load()+dropped.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if len(sub) != 1 {
+		t.Fatalf("expected 1 buffered message, got %d", len(sub))
+	}
+
+	if published.Load() != 3 {
+		t.Fatalf("expected 3 published messages, got %d", published.Load())
+	}
+
+	if delivered.Load() != 1 {
+		t.Fatalf("expected 1 delivered message, got %d", delivered.Load())
+	}
+
+	if dropped.Load() != 2 {
+		t.Fatalf("expected 2 dropped messages, got %d", dropped.Load())
+	}
+}
+
+func TestIntracom_SubscribeWithSnapshot(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	testTopic, err := CreateTopic[string](sharedIC, TopicConfig{
+		Name:        t.Name(),
+		ErrIfExists: true,
+	})
+	if err != nil {
+		t.Fatalf("error creating topic: %v", err)
+	}
+
+	conf := SubscriberConfig[string]{
+		ConsumerGroup: t.Name(),
+		BufferSize:    1,
+		BufferPolicy:  BufferPolicyDropNone[string]{},
+	}
+
+	_, _, err = SubscribeWithSnapshot[string](ctx, sharedIC, t.Name(), time.Second, conf)
+	if err != ErrNoSnapshot {
+		t.Fatalf("expected %v before publishing, got: %v", ErrNoSnapshot, err)
+	}
+
+	testTopic.PublishChannel() <- "first"
+
+	current, sub, err := SubscribeWithSnapshot[string](ctx, sharedIC, t.Name(), time.Second, conf)
+	if err != nil {
+		t.Fatalf("error subscribing with snapshot: %v", err)
+	}
+
+	if current != "first" {
+		t.Fatalf("expected snapshot 'first', got '%s'", current)
+	}
+
+	select {
+	case msg := <-sub:
+		t.Fatalf("expected snapshot not to be replayed on the channel, got '%s'", msg)
+	default:
+	}
+
+	testTopic.PublishChannel() <- "second"
+
+	select {
+	case msg := <-sub:
+		if msg != "second" {
+			t.Fatalf("expected 'second', got '%s'", msg)
+		}
+	case <-ctx.Done():
+		t.Fatalf("timed out waiting for the next publish")
+	}
+}
+
+func TestIntracom_TopicPublish(t *testing.T) {
+	// a subscriber aware topic without subscribers takes no message, publishers wait.
+	testTopic := NewTopic[string](TopicConfig{Name: t.Name(), SubscriberAware: true})
+
+	if testTopic.TryPublish("dropped") {
+		t.Fatalf("expected TryPublish to fail while no message is taken")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := testTopic.Publish(ctx, "late"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the publish to stop with the context, got: %v", err)
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- testTopic.Publish(context.Background(), "waiting")
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// closing releases the waiting publisher instead of panicking it.
+	if err := testTopic.Close(); err != nil {
+		t.Fatalf("error closing topic: %v", err)
+	}
+	select {
+	case err := <-errC:
+		if err != ErrTopicClosed {
+			t.Fatalf("expected %v for the waiting publisher, got: %v", ErrTopicClosed, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected the waiting publisher to be released by Close")
+	}
+
+	if err := testTopic.Publish(context.Background(), "closed"); err != ErrTopicClosed {
+		t.Fatalf("expected %v once closed, got: %v", ErrTopicClosed, err)
+	}
+	if testTopic.TryPublish("closed") {
+		t.Fatalf("expected TryPublish to fail once closed")
+	}
+}
+
+func TestIntracom_TopicReplayLast(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	testTopic := NewTopic[string](TopicConfig{Name: t.Name(), ReplayLast: true})
+	defer testTopic.Close()
+
+	if err := testTopic.Publish(ctx, "first"); err != nil {
+		t.Fatalf("error publishing: %v", err)
+	}
+	if err := testTopic.Publish(ctx, "latest"); err != nil {
+		t.Fatalf("error publishing: %v", err)
+	}
+
+	// even a subscriber without a buffer receives the last message first.
+	sub, err := testTopic.Subscribe(ctx, SubscriberConfig[string]{ConsumerGroup: t.Name()})
+	if err != nil {
+		t.Fatalf("error subscribing to topic: %v", err)
+	}
+
+	select {
+	case msg := <-sub:
+		if msg != "latest" {
+			t.Fatalf("expected the last message 'latest' to be replayed, got '%s'", msg)
+		}
+	case <-ctx.Done():
+		t.Fatalf("timed out waiting for the replayed message")
+	}
+
+	go testTopic.Publish(ctx, "next")
+	select {
+	case msg := <-sub:
+		if msg != "next" {
+			t.Fatalf("expected 'next' after the replayed message, got '%s'", msg)
+		}
+	case <-ctx.Done():
+		t.Fatalf("timed out waiting for the next publish")
+	}
+}
+
+func TestIntracom_TopicSubscriberFilter(t *testing.T) {
+	broadcasters := map[string]Broadcaster[int]{
+		"sync":     SyncBroadcaster[int]{},
+		"buffered": BufferedBroadcaster[int]{},
+	}
+
+	for name, bc := range broadcasters {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+
+			testTopic := NewTopic[int](TopicConfig{Name: t.Name()}, WithBroadcaster[int](bc))
+			defer testTopic.Close()
+
+			// the odd last message is not replayed to the subscriber of even messages.
+			if err := testTopic.Publish(ctx, 1); err != nil {
+				t.Fatalf("error publishing: %v", err)
+			}
+
+			sub, err := testTopic.Subscribe(ctx, SubscriberConfig[int]{
+				ConsumerGroup: t.Name(),
+				BufferSize:    10,
+				Filter:        func(n int) bool { return n%2 == 0 },
+			})
+			if err != nil {
+				t.Fatalf("error subscribing to topic: %v", err)
+			}
+
+			for n := 2; n <= 6; n++ {
+				if err := testTopic.Publish(ctx, n); err != nil {
+					t.Fatalf("error publishing: %v", err)
+				}
+			}
+
+			for _, want := range []int{2, 4, 6} {
+				select {
+				case got := <-sub:
+					if got != want {
+						t.Fatalf("expected %d, got %d", want, got)
+					}
+				case <-ctx.Done():
+					t.Fatalf("timed out waiting for %d", want)
+				}
+			}
+		})
+	}
+}
+
+func TestIntracom_TopicStats(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	ic := New(t.Name())
+	defer Close(ic)
+
+	testTopic, err := CreateTopic[int](ic, TopicConfig{Name: "counts"})
+	if err != nil {
+		t.Fatalf("error creating topic: %v", err)
+	}
+	buffered, err := CreateTopic[int](ic, TopicConfig{Name: "buffered"}, WithBroadcaster[int](BufferedBroadcaster[int]{}))
+	if err != nil {
+		t.Fatalf("error creating topic: %v", err)
+	}
+
+	slow, err := testTopic.Subscribe(ctx, SubscriberConfig[int]{
+		ConsumerGroup: "slow",
+		BufferSize:    4,
+		BufferPolicy:  BufferPolicyDropNewest[int]{},
+	})
+	if err != nil {
+		t.Fatalf("error subscribing to topic: %v", err)
+	}
+	if _, err := buffered.Subscribe(ctx, SubscriberConfig[int]{ConsumerGroup: "ring", BufferSize: 8}); err != nil {
+		t.Fatalf("error subscribing to topic: %v", err)
+	}
+
+	// the slow consumer group never receives, its buffer fills up and the rest is dropped.
+	for n := range 6 {
+		if err := testTopic.Publish(ctx, n); err != nil {
+			t.Fatalf("error publishing: %v", err)
+		}
+	}
+
+	// the broadcaster may still be handing the last message over once Publish returned.
+	stats := testTopic.Stats()
+	for len(stats.Consumers) == 1 && stats.Consumers[0].Dropped < 2 && ctx.Err() == nil {
+		time.Sleep(time.Millisecond)
+		stats = testTopic.Stats()
+	}
+	if stats.Name != "counts" || stats.Subscribers != 1 || stats.Published != 6 || stats.PublishRate <= 0 {
+		t.Fatalf("expected 6 messages published to a single subscriber, got %+v", stats)
+	}
+	want := ConsumerStats{ConsumerGroup: "slow", Delivered: 4, Dropped: 2, Depth: 4, Capacity: 4}
+	if len(stats.Consumers) != 1 || stats.Consumers[0] != want {
+		t.Fatalf("expected the stats of the slow consumer group %+v, got %+v", want, stats.Consumers)
+	}
+
+	// the buffered broadcaster reports the ring of the consumer group.
+	if ring := buffered.Stats().Consumers; len(ring) != 1 || ring[0].Capacity != 8 {
+		t.Fatalf("expected the ring of 8 messages to be reported, got %+v", ring)
+	}
+
+	all := Stats(ic)
+	if len(all) != 2 || all[0].Name != "buffered" || all[1].Name != "counts" {
+		t.Fatalf("expected the stats of both topics sorted by name, got %+v", all)
+	}
+
+	if err := testTopic.Unsubscribe("slow", slow); err != nil {
+		t.Fatalf("error unsubscribing: %v", err)
+	}
+	if stats := testTopic.Stats(); stats.Subscribers != 0 || len(stats.Consumers) != 0 {
+		t.Fatalf("expected no consumer groups once unsubscribed, got %+v", stats)
+	}
+}
+
+func TestIntracom_RateMeter(t *testing.T) {
+	start := time.Now()
+	m := &rateMeter{start: start}
+
+	// 10 events a second for 20 seconds, only the last 10 seconds are averaged.
+	for second := range 20 {
+		for i := range 10 {
+			m.add(start.Add(time.Duration(second)*time.Second + time.Duration(i)*100*time.Millisecond))
+		}
+	}
+
+	total, rate := m.read(start.Add(20 * time.Second))
+	if total != 200 {
+		t.Errorf("expected 200 events in total, got %d", total)
+	}
+	if rate < 9 || rate > 11 {
+		t.Errorf("expected about 10 events a second, got %f", rate)
+	}
+
+	// a minute later nothing was published within the window.
+	if _, rate := m.read(start.Add(80 * time.Second)); rate != 0 {
+		t.Errorf("expected no events within the window, got a rate of %f", rate)
+	}
 }
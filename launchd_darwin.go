@@ -0,0 +1,63 @@
+//go:build darwin && cgo && !rxdminimal
+
+package rxd
+
+/*
+#include <launch.h>
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"net"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// LaunchdListeners returns the listeners of the socket named name in the Sockets dictionary of the launchd job
+// running the daemon, e.g. to serve on a privileged port launchd opened or to start the daemon on demand.
+// The daemon must be built with cgo, ErrLaunchdUnsupported is returned otherwise.
+func LaunchdListeners(name string) ([]net.Listener, error) {
+	fds, err := launchdSockets(name)
+	if err != nil {
+		return nil, ErrLaunchdSocket{Name: name, Err: err}
+	}
+
+	listeners := make([]net.Listener, 0, len(fds))
+	for i, fd := range fds {
+		f := os.NewFile(uintptr(fd), name)
+		listener, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			for _, fd := range fds[i+1:] {
+				syscall.Close(fd)
+			}
+			return nil, ErrLaunchdSocket{Name: name, Err: err}
+		}
+		listeners = append(listeners, listener)
+	}
+	return listeners, nil
+}
+
+// launchdSockets returns the file descriptors launch_activate_socket hands over.
+func launchdSockets(name string) ([]int, error) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	var fds *C.int
+	var count C.size_t
+	if rc := C.launch_activate_socket(cname, &fds, &count); rc != 0 {
+		return nil, syscall.Errno(rc)
+	}
+	defer C.free(unsafe.Pointer(fds))
+
+	sockets := make([]int, 0, int(count))
+	for _, fd := range unsafe.Slice(fds, int(count)) {
+		sockets = append(sockets, int(fd))
+	}
+	return sockets, nil
+}
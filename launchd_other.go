@@ -0,0 +1,11 @@
+//go:build !darwin || !cgo || rxdminimal
+
+package rxd
+
+import "net"
+
+// LaunchdListeners returns the listeners of a socket of the launchd job running the daemon,
+// it is only supported by darwin builds with cgo.
+func LaunchdListeners(name string) ([]net.Listener, error) {
+	return nil, ErrLaunchdUnsupported
+}
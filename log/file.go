@@ -0,0 +1,87 @@
+package log
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"sync"
+)
+
+// fileBufferSize is the size of the buffer a FileWriter writes through.
+const fileBufferSize = 32 * 1024
+
+// FileWriter is a buffered writer appending to a log file, to hand to a handler with WithWriter.
+// Buffered writes are lost unless the writer is flushed or closed, which the daemon does once it stopped.
+// Reopen starts a new file at the same path, e.g. once logrotate moved the previous one.
+type FileWriter struct {
+	path string
+	mu   sync.Mutex
+	file *os.File
+	buf  *bufio.Writer
+}
+
+// OpenFile opens the log file at path for appending, creating it if needed.
+func OpenFile(path string) (*FileWriter, error) {
+	w := &FileWriter{path: path}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *FileWriter) open() error {
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	w.file = f
+	w.buf = bufio.NewWriterSize(f, fileBufferSize)
+	return nil
+}
+
+// Write writes p to the buffer of the file, it fails with os.ErrClosed once the writer is closed.
+func (w *FileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+	return w.buf.Write(p)
+}
+
+// Flush writes out the buffer and syncs the file to disk.
+func (w *FileWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	return errors.Join(w.buf.Flush(), w.file.Sync())
+}
+
+// Reopen flushes and closes the file, then opens the file at the same path again.
+func (w *FileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	// open the file again even when closing failed, the writer would be closed for good otherwise.
+	return errors.Join(w.close(), w.open())
+}
+
+// Close flushes and closes the file, closing it again is a no-op.
+func (w *FileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	return w.close()
+}
+
+func (w *FileWriter) close() error {
+	err := errors.Join(w.buf.Flush(), w.file.Close())
+	w.file, w.buf = nil, nil
+	return err
+}
@@ -1,6 +1,7 @@
 package log
 
 import (
+	"errors"
 	"io"
 	"os"
 	"strings"
@@ -9,8 +10,9 @@ import (
 )
 
 type defaultHandler struct {
-	out io.Writer
-	mu  sync.RWMutex
+	out    io.Writer
+	mu     sync.RWMutex
+	closed bool
 
 	disabled bool
 	msgfmt   string
@@ -57,3 +59,33 @@ func (h *defaultHandler) Handle(level Level, message string, fields []Field) {
 	h.out.Write([]byte(out + "\n"))
 	h.mu.Unlock()
 }
+
+// Flush flushes or syncs the writer of the handler, see FlushWriter.
+func (h *defaultHandler) Flush() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return FlushWriter(h.out)
+}
+
+// Close flushes the writer of the handler and closes it when it is an io.Closer other than stdout or stderr.
+func (h *defaultHandler) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil
+	}
+	h.closed = true
+
+	err := FlushWriter(h.out)
+	if c, ok := h.out.(io.Closer); ok && h.out != os.Stdout && h.out != os.Stderr {
+		err = errors.Join(err, c.Close())
+	}
+	return err
+}
+
+// Reopen reopens the writer of the handler when it implements Reopener, such as a FileWriter.
+func (h *defaultHandler) Reopen() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return Reopen(h.out)
+}
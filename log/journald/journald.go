@@ -1,6 +1,7 @@
 package journald
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -75,3 +76,16 @@ func (h *journaldHandler) logErr(message string) {
 	fmt.Fprintf(h.stderr, "%s\n", message)
 	h.errMu.Unlock()
 }
+
+// Flush flushes or syncs the stdout and stderr writers of the handler, see log.FlushWriter.
+func (h *journaldHandler) Flush() error {
+	h.outMu.Lock()
+	errOut := log.FlushWriter(h.stdout)
+	h.outMu.Unlock()
+
+	h.errMu.Lock()
+	errErr := log.FlushWriter(h.stderr)
+	h.errMu.Unlock()
+
+	return errors.Join(errOut, errErr)
+}
@@ -1,9 +1,11 @@
 package log
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"syscall"
 )
 
 type LogHandler interface {
@@ -15,6 +17,64 @@ type Logger interface {
 	SetLevel(level Level)
 }
 
+// Flusher is implemented by loggers and handlers that can write out anything they have buffered.
+type Flusher interface {
+	Flush() error
+}
+
+// Flush flushes v when it implements Flusher, it is a no-op otherwise.
+func Flush(v any) error {
+	if f, ok := v.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Closer is implemented by loggers and handlers holding resources such as files, the daemon closes its loggers
+// once it stopped, after flushing them. Nothing should be logged through a closed logger.
+type Closer interface {
+	Close() error
+}
+
+// Close closes v when it implements Closer, it is a no-op otherwise.
+func Close(v any) error {
+	if c, ok := v.(Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Reopener is implemented by loggers and handlers writing to files, they reopen them so a log file moved by
+// logrotate is written to again. The daemon reopens its loggers on SIGHUP.
+type Reopener interface {
+	Reopen() error
+}
+
+// Reopen reopens v when it implements Reopener, it is a no-op otherwise.
+func Reopen(v any) error {
+	if r, ok := v.(Reopener); ok {
+		return r.Reopen()
+	}
+	return nil
+}
+
+// FlushWriter flushes or syncs w, e.g. a *bufio.Writer or an *os.File.
+// Errors syncing a file that does not support it, such as a terminal or a pipe, are ignored.
+func FlushWriter(w any) error {
+	var err error
+	switch w := w.(type) {
+	case Flusher:
+		err = w.Flush()
+	case interface{ Sync() error }:
+		err = w.Sync()
+	}
+
+	if errors.Is(err, syscall.EINVAL) || errors.Is(err, errors.ErrUnsupported) {
+		return nil
+	}
+	return err
+}
+
 const (
 	// LevelEmergency (0) Rarely used by user applications but import for critical services
 	// examples include: when the system is unusable, system-wide outaged, situations that require immediate attention and human intervention
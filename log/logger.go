@@ -49,3 +49,25 @@ func (l *logger) SetLevel(level Level) {
 	l.level = &lvl
 	l.mu.Unlock()
 }
+
+// Level returns the current level of the logger.
+func (l *logger) Level() Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return *l.level
+}
+
+// Flush flushes the handler of the logger when it implements Flusher.
+func (l *logger) Flush() error {
+	return Flush(l.handler)
+}
+
+// Close closes the handler of the logger when it implements Closer.
+func (l *logger) Close() error {
+	return Close(l.handler)
+}
+
+// Reopen reopens the handler of the logger when it implements Reopener.
+func (l *logger) Reopen() error {
+	return Reopen(l.handler)
+}
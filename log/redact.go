@@ -0,0 +1,108 @@
+package log
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the values of redacted fields and the parts of values matched by a Redactor.
+const Redacted = "[REDACTED]"
+
+// DefaultRedactedKeys are the key patterns a Redactor created without any redacts.
+var DefaultRedactedKeys = []string{"password", "passwd", "secret", "*_secret", "token", "*_token", "api_key", "apikey", "authorization", "cookie"}
+
+// Secret returns a field whose value is redacted as it is created, so it never reaches a handler whichever logger
+// it is given to. The value only documents what the field holds at the call site.
+func Secret(key string, value string) Field {
+	return Field{Key: key, Value: Redacted}
+}
+
+// Redactor redacts the values of fields whose key matches one of its key patterns, and the parts of messages and
+// values matched by its value patterns, e.g. bearer tokens or connection strings with credentials.
+type Redactor struct {
+	keys   []string
+	values []*regexp.Regexp
+}
+
+// NewRedactor creates a Redactor matching keys, case insensitively, against the given patterns in the syntax of
+// path.Match, e.g. "*_secret", and redacting the parts of values matching values. Without key patterns
+// DefaultRedactedKeys are used.
+func NewRedactor(keys []string, values ...*regexp.Regexp) *Redactor {
+	if len(keys) == 0 {
+		keys = DefaultRedactedKeys
+	}
+
+	r := &Redactor{keys: make([]string, 0, len(keys)), values: values}
+	for _, key := range keys {
+		r.keys = append(r.keys, strings.ToLower(key))
+	}
+	return r
+}
+
+// redactsKey reports whether the value of the fields with this key is redacted.
+func (r *Redactor) redactsKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range r.keys {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactMessage returns message with the parts matched by the value patterns redacted.
+func (r *Redactor) RedactMessage(message string) string {
+	if r == nil {
+		return message
+	}
+	for _, value := range r.values {
+		message = value.ReplaceAllString(message, Redacted)
+	}
+	return message
+}
+
+// RedactFields returns the fields with their values redacted, fields is not modified.
+func (r *Redactor) RedactFields(fields []Field) []Field {
+	if r == nil || len(fields) == 0 {
+		return fields
+	}
+
+	redacted := make([]Field, len(fields))
+	for i, field := range fields {
+		if r.redactsKey(field.Key) {
+			field.Value = Redacted
+		} else {
+			field.Value = r.RedactMessage(field.Value)
+		}
+		redacted[i] = field
+	}
+	return redacted
+}
+
+type redactingHandler struct {
+	handler  LogHandler
+	redactor *Redactor
+}
+
+// NewRedactingHandler returns a handler redacting the messages and fields before handler receives them.
+// Flush, Close and Reopen are passed on to handler.
+func NewRedactingHandler(handler LogHandler, redactor *Redactor) LogHandler {
+	return redactingHandler{handler: handler, redactor: redactor}
+}
+
+func (h redactingHandler) Handle(level Level, message string, fields []Field) {
+	h.handler.Handle(level, h.redactor.RedactMessage(message), h.redactor.RedactFields(fields))
+}
+
+func (h redactingHandler) Flush() error {
+	return Flush(h.handler)
+}
+
+func (h redactingHandler) Close() error {
+	return Close(h.handler)
+}
+
+func (h redactingHandler) Reopen() error {
+	return Reopen(h.handler)
+}
@@ -0,0 +1,7 @@
+package managerkit
+
+import "github.com/ambitiousfew/rxd"
+
+// Backoff computes exponentially growing delays between the restarts of a failing service,
+// the same delays the ExponentialBackoffManager of rxd waits.
+type Backoff = rxd.Backoff
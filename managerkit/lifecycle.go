@@ -0,0 +1,219 @@
+// Package managerkit is a toolkit for writing custom ServiceManagers. A Lifecycle publishes the states of the
+// service, calls its lifecycles the way the built-in managers do, honoring the preconditions, reloads, recycles,
+// circuit breakers and restart policy the daemon applies, recovers from panicking runners and guarantees Stop runs
+// before the service exits. Backoff computes the delays between restarts.
+//
+//	func (m MyManager) Manage(sctx rxd.ServiceContext, ds rxd.DaemonService, updateC chan<- rxd.StateUpdate) {
+//		lc := managerkit.New(sctx, ds, updateC)
+//		defer lc.Exit()
+//
+//		state := rxd.StateInit
+//		for state != rxd.StateExit {
+//			lc.Enter(state)
+//			if !lc.Wait(m.Delay) {
+//				return
+//			}
+//			state = lc.Next()
+//		}
+//	}
+package managerkit
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/ambitiousfew/rxd"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// Lifecycle drives a single service on behalf of its manager, it must only be used by the manager routine.
+type Lifecycle struct {
+	sctx    rxd.ServiceContext
+	ds      rxd.DaemonService
+	updateC chan<- rxd.StateUpdate
+	state   rxd.State
+	entered bool // set once a state was published.
+	pending bool // set once a lifecycle ran since the last Stop, Exit runs Stop then.
+	exited  bool
+	failed  bool // set when the last lifecycle returned an error or Run returned on its own.
+	exiting bool // set once the restart policy does not restart the service, it exits once stopped.
+}
+
+// New returns the Lifecycle of the service, Manage calls it with its arguments.
+func New(sctx rxd.ServiceContext, ds rxd.DaemonService, updateC chan<- rxd.StateUpdate) *Lifecycle {
+	return &Lifecycle{sctx: sctx, ds: ds, updateC: updateC, state: rxd.StateExit}
+}
+
+// State returns the state the service last entered.
+func (l *Lifecycle) State() rxd.State {
+	return l.state
+}
+
+// Failed reports whether the last lifecycle before Stop returned an error, or Run returned on its own while
+// the service was not stopping, e.g. to back off before the next Init.
+func (l *Lifecycle) Failed() bool {
+	return l.failed
+}
+
+// Enter publishes the state the service is about to enter to the states watcher of the daemon.
+// Entering the state the service is already in is not published again.
+func (l *Lifecycle) Enter(state rxd.State) {
+	if l.entered && state == l.state {
+		return
+	}
+	l.entered = true
+	l.state = state
+	l.updateC <- rxd.StateUpdate{Name: l.ds.Name, State: state}
+}
+
+// Wait waits for delay before the next lifecycle, yielding the processor first when the daemon asks for it.
+// It returns false once the service context is done, the manager should exit then.
+func (l *Lifecycle) Wait(delay time.Duration) bool {
+	rxd.YieldTransition(l.sctx)
+	if delay <= 0 {
+		return l.sctx.Err() == nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-l.sctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// Init awaits the preconditions of the service, then calls Init of its runner.
+// It returns the error of Init, or the error of the service context once it is done before the preconditions passed.
+func (l *Lifecycle) Init() error {
+	if err := rxd.AwaitPreconditions(l.sctx); err != nil {
+		l.failed = false
+		return l.sctx.Err()
+	}
+	return l.call(func() error { return l.ds.Runner.Init(l.sctx) })
+}
+
+// Idle calls Idle of the runner.
+func (l *Lifecycle) Idle() error {
+	return l.call(func() error { return l.ds.Runner.Idle(l.sctx) })
+}
+
+// Run calls Run of the runner and returns why the daemon interrupted it along with its error,
+// rxd.InterruptNone when Run returned on its own.
+func (l *Lifecycle) Run() (rxd.Interrupt, error) {
+	var interrupt rxd.Interrupt
+	err := l.call(func() error {
+		var err error
+		interrupt, err = rxd.RunInterruptible(l.sctx, l.ds.Runner)
+		return err
+	})
+	if interrupt == rxd.InterruptNone && l.sctx.Err() == nil {
+		// run is expected to last until the service is stopped.
+		l.failed = true
+	}
+	return interrupt, err
+}
+
+// Reload calls Reload of runners implementing rxd.Reloader.
+func (l *Lifecycle) Reload() error {
+	return l.call(func() error { return rxd.ReloadRunner(l.sctx, l.ds.Runner) })
+}
+
+// Stop calls Stop of the runner, Exit then no longer does. Failed keeps reporting the lifecycle before it.
+func (l *Lifecycle) Stop() error {
+	failed := l.failed
+	err := l.call(func() error { return l.ds.Runner.Stop(l.sctx) })
+	l.pending = false
+	l.failed = failed
+	return err
+}
+
+// Next calls the lifecycle of the state the service is in and returns the state it moves to next,
+// following the policy of the built-in continuous manager: errors move the service to StateStop, Stop moves it
+// back to StateInit and Run to StateStop, StateReload or StateIdle according to the daemon.
+// StateExit is returned once the service context is done, or after Stop once the restart policy exits the service.
+func (l *Lifecycle) Next() rxd.State {
+	if l.sctx.Err() != nil {
+		return rxd.StateExit
+	}
+
+	switch l.state {
+	case rxd.StateInit:
+		if err := l.Init(); err != nil {
+			return rxd.StateStop
+		}
+		return rxd.StateIdle
+	case rxd.StateIdle:
+		if err := l.Idle(); err != nil {
+			return rxd.StateStop
+		}
+		return rxd.StateRun
+	case rxd.StateRun:
+		interrupt, err := l.Run()
+		switch {
+		case l.sctx.Err() != nil:
+			return rxd.StateStop
+		case interrupt == rxd.InterruptReload:
+			return rxd.StateReload
+		case interrupt == rxd.InterruptIdle:
+			return rxd.StateIdle
+		case interrupt == rxd.InterruptNone && !rxd.RestartAfterRun(l.sctx, err):
+			l.exiting = true
+			return rxd.StateStop
+		default:
+			return rxd.StateStop
+		}
+	case rxd.StateReload:
+		if err := l.Reload(); err != nil {
+			return rxd.StateStop
+		}
+		return rxd.StateRun
+	case rxd.StateStop:
+		l.Stop()
+		if l.exiting {
+			return rxd.StateExit
+		}
+		return rxd.StateInit
+	default:
+		return rxd.StateExit
+	}
+}
+
+// Exit runs Stop if a lifecycle ran since the service last stopped and publishes StateExit, once.
+// Deferred by Manage, it also recovers from a panicking runner and logs it, so the daemon keeps running.
+func (l *Lifecycle) Exit() {
+	if r := recover(); r != nil {
+		l.sctx.Log(log.LevelError, fmt.Sprintf("recovered from a panic: %v", r), log.String("stack", string(debug.Stack())))
+	}
+	if l.exited {
+		return
+	}
+	l.exited = true
+
+	if l.pending {
+		func() {
+			// a runner that panicked once may panic again in Stop.
+			defer func() {
+				if r := recover(); r != nil {
+					l.sctx.Log(log.LevelError, fmt.Sprintf("recovered from a panic in stop: %v", r))
+				}
+			}()
+			l.Stop()
+		}()
+	}
+	l.Enter(rxd.StateExit)
+}
+
+// call runs a lifecycle of the runner, logging and recording its error.
+func (l *Lifecycle) call(lifecycle func() error) error {
+	l.pending = true
+	err := lifecycle()
+	l.failed = err != nil
+	if err != nil && !errors.Is(err, l.sctx.Err()) {
+		l.sctx.Log(log.LevelError, err.Error())
+	}
+	return err
+}
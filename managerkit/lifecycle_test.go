@@ -0,0 +1,124 @@
+package managerkit
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// kitManager is a custom manager written with the toolkit, as in the package documentation.
+type kitManager struct {
+	delay time.Duration
+}
+
+func (m kitManager) Manage(sctx rxd.ServiceContext, ds rxd.DaemonService, updateC chan<- rxd.StateUpdate) {
+	lc := New(sctx, ds, updateC)
+	defer lc.Exit()
+
+	state := rxd.StateInit
+	for state != rxd.StateExit {
+		lc.Enter(state)
+		if !lc.Wait(m.delay) {
+			return
+		}
+		state = lc.Next()
+	}
+}
+
+// journalRunner records its lifecycles, Run returns right away or panics.
+type journalRunner struct {
+	mu      sync.Mutex
+	calls   []string
+	panicky bool
+}
+
+func (r *journalRunner) record(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func (r *journalRunner) journal() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return strings.Join(r.calls, ",")
+}
+
+func (r *journalRunner) Init(sctx rxd.ServiceContext) error { r.record("init"); return nil }
+func (r *journalRunner) Idle(sctx rxd.ServiceContext) error { r.record("idle"); return nil }
+func (r *journalRunner) Stop(sctx rxd.ServiceContext) error { r.record("stop"); return nil }
+
+func (r *journalRunner) Run(sctx rxd.ServiceContext) error {
+	r.record("run")
+	if r.panicky {
+		panic("boom")
+	}
+	return nil
+}
+
+func startService(t *testing.T, runner rxd.ServiceRunner, opts ...rxd.ServiceOption) string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var out strings.Builder
+	var mu sync.Mutex
+	logger := log.NewLogger(log.LevelError, log.NewHandler(log.WithWriter(writerFunc(func(p []byte) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return out.Write(p)
+	}))))
+
+	d := rxd.NewDaemon("test-daemon", rxd.WithServiceLogger(logger))
+	opts = append(opts, rxd.WithManager(kitManager{delay: time.Millisecond}))
+	if err := d.AddService(rxd.NewService("kit", runner, opts...)); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+	if err := d.Start(ctx); err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return out.String()
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+func TestLifecycle_RestartPolicy(t *testing.T) {
+	runner := &journalRunner{}
+	var checks int
+	precondition := rxd.Precondition{Name: "counted", Check: func(ctx context.Context) error {
+		checks++
+		return nil
+	}}
+
+	startService(t, runner, rxd.WithRestartPolicy(rxd.RestartNever), rxd.WithPreconditions(precondition))
+	if journal := runner.journal(); journal != "init,idle,run,stop" {
+		t.Errorf("expected the service to run once and stop, got %s", journal)
+	}
+	if checks != 1 {
+		t.Errorf("expected the preconditions to be awaited before init, got %d checks", checks)
+	}
+}
+
+func TestLifecycle_RecoversPanics(t *testing.T) {
+	runner := &journalRunner{panicky: true}
+	logged := startService(t, runner)
+
+	if journal := runner.journal(); journal != "init,idle,run,stop" {
+		t.Errorf("expected the service to be stopped once run panicked, got %s", journal)
+	}
+	if !strings.Contains(logged, "recovered from a panic: boom") {
+		t.Errorf("expected the panic to be logged, got %q", logged)
+	}
+}
@@ -6,24 +6,21 @@ import (
 	"github.com/ambitiousfew/rxd/log"
 )
 
-// TODO: This is a basic implementation for interacting with a service manager.
-// Actual interactions with a service manager are a little more involved.
-// This implementation really only provides a way to notify watchdog if its enabled.
-// This is not cross-platform and is only for linux systems that use systemd.
-//
-// Ideally there should be a subpackage that provides an interface for running
-// as a system daemon on different platforms. This subpackage would need to
-// provide a factory that can hand back a given struct that meets the interface
-// based on the runtime value(s).
-//
-// Basically if we build with linux tags, we get the systemd implementation.
-// if we build with windows tags, we get the windows service implementation.
-// Because I want rxd to be cross-platform, this is already a consideration
-// for the future. Currently its a big lift and current needs are only for linux.
-
+// SystemNotifier reports the state of the daemon to the service manager of the platform.
+// The daemon picks the notifier of the platform it is built for: systemd on linux, the service control
+// manager on windows when it was started by it, and a notifier doing nothing otherwise or with rxdminimal.
 type SystemNotifier interface {
 	Start(ctx context.Context, logger log.Logger) error
 	Notify(state NotifyState) error
+	// NotifyStatus reports a human-readable status of the daemon, e.g. "7/8 services running; ingest: init (retrying)",
+	// it is sent every time the states of the services change. Service managers without a status text ignore it.
+	NotifyStatus(msg string) error
+}
+
+// watchdogGate is implemented by the notifiers pinging a watchdog, alive is called before every keep-alive
+// and the keep-alive is withheld while it returns an error.
+type watchdogGate interface {
+	gateWatchdog(alive func() error)
 }
 
 const (
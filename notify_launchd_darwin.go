@@ -0,0 +1,64 @@
+//go:build darwin && !rxdminimal
+
+package rxd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// launchdNotifier integrates a daemon run as a launchd job. launchd has no readiness or watchdog protocol,
+// it stops jobs with SIGTERM and kills them once their ExitTimeOut passed, so the notifier makes sure SIGTERM
+// always stops the daemon cleanly, whichever signals were given with WithSignals.
+type launchdNotifier struct {
+	label string
+	stop  context.CancelFunc
+}
+
+// newSystemNotifier returns the launchd notifier when the daemon runs as a launchd job, one doing nothing otherwise.
+func newSystemNotifier(name string, reportAliveSecs uint64, stop context.CancelFunc) (SystemNotifier, error) {
+	label, ok := launchdLabel()
+	if !ok {
+		return noopNotifier{}, nil
+	}
+	return launchdNotifier{label: label, stop: stop}, nil
+}
+
+// launchdLabel returns the label of the launchd job running the process, launchd sets XPC_SERVICE_NAME for its
+// jobs while processes started from a terminal inherit "0" or nothing.
+func launchdLabel() (string, bool) {
+	label := os.Getenv("XPC_SERVICE_NAME")
+	if label == "" || label == "0" || os.Getppid() != 1 {
+		return "", false
+	}
+	return label, true
+}
+
+func (n launchdNotifier) Start(ctx context.Context, logger log.Logger) error {
+	termC := make(chan os.Signal, 1)
+	signal.Notify(termC, syscall.SIGTERM)
+
+	go func() {
+		defer signal.Stop(termC)
+		select {
+		case <-ctx.Done():
+		case <-termC:
+			logger.Log(log.LevelNotice, "launchd requested the daemon to stop", log.String("label", n.label))
+			n.stop()
+		}
+	}()
+	return nil
+}
+
+func (n launchdNotifier) Notify(state NotifyState) error {
+	// launchd only learns about the daemon from its process, there is nothing to report.
+	return nil
+}
+
+func (n launchdNotifier) NotifyStatus(msg string) error {
+	return nil
+}
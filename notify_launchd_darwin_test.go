@@ -0,0 +1,33 @@
+//go:build darwin && !rxdminimal
+
+package rxd
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+func TestLaunchdNotifier_SIGTERM(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stopped := make(chan struct{})
+	notifier := launchdNotifier{label: "com.example.test", stop: func() { close(stopped) }}
+	if err := notifier.Start(ctx, log.NewLogger(log.LevelDebug, newTestLogger())); err != nil {
+		t.Fatalf("error starting notifier: %s", err)
+	}
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatalf("error sending SIGTERM: %s", err)
+	}
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected SIGTERM to stop the daemon")
+	}
+}
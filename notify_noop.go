@@ -0,0 +1,30 @@
+//go:build !linux || rxdminimal
+
+package rxd
+
+import (
+	"context"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// noopNotifier stands in for the systemd notifier where systemd is not available.
+type noopNotifier struct{}
+
+// NewSystemdNotifier returns a notifier that does nothing, systemd is only supported on linux builds
+// without the rxdminimal tag.
+func NewSystemdNotifier(socketName string, durationSecs uint64) (SystemNotifier, error) {
+	return noopNotifier{}, nil
+}
+
+func (noopNotifier) Start(ctx context.Context, logger log.Logger) error {
+	return nil
+}
+
+func (noopNotifier) Notify(state NotifyState) error {
+	return nil
+}
+
+func (noopNotifier) NotifyStatus(msg string) error {
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build (!linux && !windows && !darwin) || rxdminimal
+
+package rxd
+
+import "context"
+
+// newSystemNotifier returns a notifier that does nothing, there is no supported service manager on this platform.
+func newSystemNotifier(name string, reportAliveSecs uint64, stop context.CancelFunc) (SystemNotifier, error) {
+	return noopNotifier{}, nil
+}
@@ -1,11 +1,13 @@
-//go:build linux
+//go:build linux && !rxdminimal
 
 package rxd
 
 import (
 	"context"
-	"errors"
 	"net"
+	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,6 +18,12 @@ type systemdNotifier struct {
 	watchdog uint64
 	conn     *net.UnixConn
 	mu       *sync.RWMutex
+	alive    func() error // withholds the keep-alive while it returns an error, nil always sends it.
+}
+
+// newSystemNotifier returns the notifier of the systemd unit the daemon runs in, if any.
+func newSystemNotifier(name string, reportAliveSecs uint64, stop context.CancelFunc) (SystemNotifier, error) {
+	return NewSystemdNotifier(os.Getenv("NOTIFY_SOCKET"), reportAliveSecs)
 }
 
 func NewSystemdNotifier(socketName string, durationSecs uint64) (SystemNotifier, error) {
@@ -31,7 +39,7 @@ func NewSystemdNotifier(socketName string, durationSecs uint64) (SystemNotifier,
 
 	unixConn, ok := conn.(*net.UnixConn)
 	if !ok {
-		return nil, errors.New("connection is not a unix connection type")
+		return nil, ErrNotifySocket
 	}
 
 	return &systemdNotifier{
@@ -57,10 +65,46 @@ func (n systemdNotifier) Notify(state NotifyState) error {
 		payload = []byte("RELOADING=1")
 	case NotifyStateAlive:
 		payload = []byte("WATCHDOG=1")
+	case NotifyStateStopped:
+		// systemd learns the daemon stopped once the process exits.
+		return nil
 	default:
-		return errors.New("'" + string(state) + "' unsupported state for systemd notifier")
+		return ErrUnsupportedNotifyState{State: state}
+	}
+
+	n.mu.Lock()
+	_, err := n.conn.Write(payload)
+	n.mu.Unlock()
+	return err
+}
+
+// gateWatchdog withholds the keep-alive while alive returns an error, it must be called before Start.
+func (n *systemdNotifier) gateWatchdog(alive func() error) {
+	n.alive = alive
+}
+
+// extendTimeout sends EXTEND_TIMEOUT_USEC so systemd waits up to timeout for the daemon to stop.
+func (n systemdNotifier) extendTimeout(timeout time.Duration) error {
+	if n.watchdog == 0 {
+		// do nothing if watchdog is not set
+		return nil
+	}
+
+	payload := []byte("EXTEND_TIMEOUT_USEC=" + strconv.FormatInt(timeout.Microseconds(), 10))
+	n.mu.Lock()
+	_, err := n.conn.Write(payload)
+	n.mu.Unlock()
+	return err
+}
+
+// NotifyStatus sends STATUS=msg, shown by systemctl status, on a single line.
+func (n systemdNotifier) NotifyStatus(msg string) error {
+	if n.watchdog == 0 {
+		// do nothing if watchdog is not set
+		return nil
 	}
 
+	payload := []byte("STATUS=" + strings.ReplaceAll(msg, "\n", " "))
 	n.mu.Lock()
 	_, err := n.conn.Write(payload)
 	n.mu.Unlock()
@@ -81,6 +125,12 @@ func (n systemdNotifier) Start(ctx context.Context, logger log.Logger) error {
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
+				if n.alive != nil {
+					if err := n.alive(); err != nil {
+						logger.Log(log.LevelWarning, "internal:systemd-notifier withholding watchdog keep-alive", log.Error("error", err))
+						continue
+					}
+				}
 				err := n.Notify(NotifyStateAlive)
 				if err != nil {
 					logger.Log(log.LevelError, "internal:systemd-notifier", log.Error("error", err))
@@ -0,0 +1,113 @@
+//go:build linux && !rxdminimal
+
+package rxd
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+func TestSystemdNotifier_GatedWatchdog(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("error listening on the notify socket: %s", err)
+	}
+	defer conn.Close()
+
+	notifier, err := NewSystemdNotifier(socket, 1)
+	if err != nil {
+		t.Fatalf("error creating notifier: %s", err)
+	}
+
+	var wedged atomic.Bool
+	wedged.Store(true)
+	notifier.(watchdogGate).gateWatchdog(func() error {
+		if wedged.Load() {
+			return ErrWatchdogWithheld{Service: "db", Reason: "is in state init"}
+		}
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := notifier.Start(ctx, log.NewLogger(log.LevelDebug, newTestLogger())); err != nil {
+		t.Fatalf("error starting notifier: %s", err)
+	}
+
+	buf := make([]byte, 64)
+	conn.SetReadDeadline(time.Now().Add(1500 * time.Millisecond))
+	if n, err := conn.Read(buf); err == nil {
+		t.Fatalf("expected the keep-alive to be withheld, got %q", buf[:n])
+	}
+
+	wedged.Store(false)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("expected a keep-alive once the service recovered: %s", err)
+	}
+	if got := string(buf[:n]); got != "WATCHDOG=1" {
+		t.Errorf("expected WATCHDOG=1, got %q", got)
+	}
+}
+
+func TestSystemdNotifier_NotifyStatus(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("error listening on the notify socket: %s", err)
+	}
+	defer conn.Close()
+
+	notifier, err := NewSystemdNotifier(socket, 1)
+	if err != nil {
+		t.Fatalf("error creating notifier: %s", err)
+	}
+	if err := notifier.NotifyStatus("1/2 services running;\ningest: init (retrying)"); err != nil {
+		t.Fatalf("error sending status: %s", err)
+	}
+
+	buf := make([]byte, 128)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("error reading status: %s", err)
+	}
+	if got, want := string(buf[:n]), "STATUS=1/2 services running; ingest: init (retrying)"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestSystemdNotifier_ExtendTimeout(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("error listening on the notify socket: %s", err)
+	}
+	defer conn.Close()
+
+	notifier, err := NewSystemdNotifier(socket, 1)
+	if err != nil {
+		t.Fatalf("error creating notifier: %s", err)
+	}
+	if err := notifier.(timeoutExtender).extendTimeout(90 * time.Second); err != nil {
+		t.Fatalf("error extending the stop timeout: %s", err)
+	}
+
+	buf := make([]byte, 64)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("error reading notification: %s", err)
+	}
+	if got, want := string(buf[:n]), "EXTEND_TIMEOUT_USEC=90000000"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
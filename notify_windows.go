@@ -0,0 +1,270 @@
+//go:build windows && !rxdminimal
+
+package rxd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+var (
+	advapi32                          = syscall.NewLazyDLL("advapi32.dll")
+	procStartServiceCtrlDispatcherW   = advapi32.NewProc("StartServiceCtrlDispatcherW")
+	procRegisterServiceCtrlHandlerExW = advapi32.NewProc("RegisterServiceCtrlHandlerExW")
+	procSetServiceStatus              = advapi32.NewProc("SetServiceStatus")
+)
+
+// service states, controls and errors of the service control manager (winsvc.h).
+const (
+	serviceWin32OwnProcess = 0x10
+
+	serviceStopped      = 1
+	serviceStartPending = 2
+	serviceStopPending  = 3
+	serviceRunning      = 4
+
+	serviceAcceptStop     = 0x1
+	serviceAcceptShutdown = 0x4
+
+	serviceControlStop        = 1
+	serviceControlInterrogate = 4
+	serviceControlShutdown    = 5
+
+	errorCallNotImplemented             = 120
+	errorFailedServiceControllerConnect = 1063
+
+	// pendingWaitHint is how long the SCM waits for the next update of a pending state, in milliseconds.
+	pendingWaitHint = 30000
+)
+
+// serviceStatus is SERVICE_STATUS.
+type serviceStatus struct {
+	ServiceType             uint32
+	CurrentState            uint32
+	ControlsAccepted        uint32
+	Win32ExitCode           uint32
+	ServiceSpecificExitCode uint32
+	CheckPoint              uint32
+	WaitHint                uint32
+}
+
+// serviceTableEntry is SERVICE_TABLE_ENTRYW.
+type serviceTableEntry struct {
+	ServiceName *uint16
+	ServiceProc uintptr
+}
+
+var (
+	// the service control manager calls back into the process, callbacks are a limited resource so they are created once.
+	callbacksOnce   sync.Once
+	serviceMainCb   uintptr
+	ctlHandlerCb    uintptr
+	currentNotifier atomic.Pointer[windowsNotifier]
+	// the process connects to the SCM at most once, a daemon started again does not report to it.
+	dispatched atomic.Bool
+)
+
+// windowsNotifier reports the state of the daemon to the Windows service control manager,
+// Stop and Shutdown requests of the SCM stop the daemon.
+type windowsNotifier struct {
+	name    *uint16
+	stop    context.CancelFunc
+	handle  uintptr
+	startC  chan struct{} // closed once the SCM called the service main.
+	stopped chan struct{} // closed once the daemon reported it stopped, releasing the service main.
+	once    sync.Once
+	logger  atomic.Pointer[log.Logger]
+	mu      sync.Mutex
+	status  serviceStatus
+}
+
+// newSystemNotifier registers the daemon with the Windows service control manager when the process was started
+// by it, stop is called on Stop and Shutdown requests. Daemons run from a console get a notifier doing nothing.
+func newSystemNotifier(name string, reportAliveSecs uint64, stop context.CancelFunc) (SystemNotifier, error) {
+	serviceName, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &windowsNotifier{
+		name:    serviceName,
+		stop:    stop,
+		startC:  make(chan struct{}),
+		stopped: make(chan struct{}),
+		status:  serviceStatus{ServiceType: serviceWin32OwnProcess, CurrentState: serviceStartPending, WaitHint: pendingWaitHint},
+	}
+	if !dispatched.CompareAndSwap(false, true) {
+		return noopNotifier{}, nil
+	}
+	currentNotifier.Store(n)
+
+	callbacksOnce.Do(func() {
+		serviceMainCb = syscall.NewCallback(serviceMain)
+		ctlHandlerCb = syscall.NewCallback(ctlHandler)
+	})
+
+	errC := make(chan error, 1)
+	go func() {
+		// the dispatcher holds its thread until the service stops.
+		table := []serviceTableEntry{{ServiceName: serviceName, ServiceProc: serviceMainCb}, {}}
+		r, _, err := procStartServiceCtrlDispatcherW.Call(uintptr(unsafe.Pointer(&table[0])))
+		if r == 0 {
+			errC <- err
+			return
+		}
+		errC <- nil
+	}()
+
+	select {
+	case <-n.startC:
+		return n, nil
+	case err := <-errC:
+		currentNotifier.Store(nil)
+		var errno syscall.Errno
+		if errors.As(err, &errno) && errno == errorFailedServiceControllerConnect {
+			// not started by the service control manager, which a later start will not be either.
+			dispatched.Store(false)
+			return noopNotifier{}, nil
+		}
+		return nil, err
+	}
+}
+
+// serviceMain is the ServiceMain of the daemon, it runs on a thread of the SCM until the daemon stopped.
+func serviceMain(argc, argv uintptr) uintptr {
+	n := currentNotifier.Load()
+	if n == nil {
+		return 0
+	}
+
+	handle, _, _ := procRegisterServiceCtrlHandlerExW.Call(uintptr(unsafe.Pointer(n.name)), ctlHandlerCb, 0)
+	n.mu.Lock()
+	n.handle = handle
+	n.setStatusLocked()
+	n.mu.Unlock()
+
+	close(n.startC)
+	<-n.stopped
+	return 0
+}
+
+// ctlHandler is the HandlerEx of the daemon, called by the SCM with control requests.
+func ctlHandler(ctl, eventType, eventData, handlerContext uintptr) uintptr {
+	n := currentNotifier.Load()
+	if n == nil {
+		return errorCallNotImplemented
+	}
+
+	switch ctl {
+	case serviceControlStop, serviceControlShutdown:
+		n.mu.Lock()
+		n.status.CurrentState = serviceStopPending
+		n.status.ControlsAccepted = 0
+		n.status.WaitHint = pendingWaitHint
+		n.setStatusLocked()
+		n.mu.Unlock()
+
+		n.log(log.LevelNotice, "service control manager requested the daemon to stop")
+		n.stop()
+		return 0
+	case serviceControlInterrogate:
+		n.mu.Lock()
+		n.setStatusLocked()
+		n.mu.Unlock()
+		return 0
+	default:
+		return errorCallNotImplemented
+	}
+}
+
+// windowsState maps a state of the daemon to the state reported to the SCM, ok is false for states that
+// only report progress of the current state.
+func windowsState(state NotifyState) (current uint32, ok bool) {
+	switch state {
+	case NotifyStateReady, NotifyStateReloading:
+		// the SCM has no reloading state, the daemon keeps running while it reloads.
+		return serviceRunning, true
+	case NotifyStateRestarting:
+		return serviceStartPending, true
+	case NotifyStateStopping:
+		return serviceStopPending, true
+	case NotifyStateStopped:
+		return serviceStopped, true
+	default:
+		return 0, false
+	}
+}
+
+func (n *windowsNotifier) Start(ctx context.Context, logger log.Logger) error {
+	n.logger.Store(&logger)
+	return nil
+}
+
+// NotifyStatus does nothing, the service control manager has no status text.
+func (n *windowsNotifier) NotifyStatus(msg string) error {
+	return nil
+}
+
+func (n *windowsNotifier) Notify(state NotifyState) error {
+	current, ok := windowsState(state)
+	if !ok && state != NotifyStateAlive {
+		return ErrUnsupportedNotifyState{State: state}
+	}
+
+	n.mu.Lock()
+	if !ok {
+		// alive only moves the checkpoint of pending states, so the SCM knows the daemon makes progress.
+		if n.status.CurrentState == serviceStartPending || n.status.CurrentState == serviceStopPending {
+			n.status.CheckPoint++
+		}
+	} else {
+		if current != n.status.CurrentState {
+			n.status.CheckPoint = 0
+		}
+		n.status.CurrentState = current
+		n.status.WaitHint = 0
+		n.status.ControlsAccepted = 0
+		switch current {
+		case serviceRunning:
+			n.status.ControlsAccepted = serviceAcceptStop | serviceAcceptShutdown
+		case serviceStartPending, serviceStopPending:
+			n.status.WaitHint = pendingWaitHint
+		}
+	}
+	err := n.setStatusLocked()
+	n.mu.Unlock()
+
+	if current == serviceStopped {
+		// the service main returns once stopped, ending the dispatcher.
+		n.once.Do(func() {
+			close(n.stopped)
+			currentNotifier.CompareAndSwap(n, nil)
+		})
+	}
+	return err
+}
+
+// setStatusLocked reports the status to the SCM, the caller must hold the notifier lock.
+func (n *windowsNotifier) setStatusLocked() error {
+	if n.handle == 0 {
+		return nil
+	}
+
+	status := n.status
+	if r, _, err := procSetServiceStatus.Call(n.handle, uintptr(unsafe.Pointer(&status))); r == 0 {
+		return err
+	}
+	return nil
+}
+
+func (n *windowsNotifier) log(level log.Level, message string) {
+	if logger := n.logger.Load(); logger != nil {
+		(*logger).Log(level, message, log.String("notifier", "windows-scm"))
+	}
+}
@@ -0,0 +1,43 @@
+//go:build windows && !rxdminimal
+
+package rxd
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWindowsState(t *testing.T) {
+	tests := []struct {
+		state   NotifyState
+		current uint32
+		ok      bool
+	}{
+		{NotifyStateReady, serviceRunning, true},
+		{NotifyStateReloading, serviceRunning, true},
+		{NotifyStateRestarting, serviceStartPending, true},
+		{NotifyStateStopping, serviceStopPending, true},
+		{NotifyStateStopped, serviceStopped, true},
+		{NotifyStateAlive, 0, false},
+	}
+
+	for _, tt := range tests {
+		if current, ok := windowsState(tt.state); current != tt.current || ok != tt.ok {
+			t.Errorf("expected %s to map to %d (%t), got %d (%t)", tt.state, tt.current, tt.ok, current, ok)
+		}
+	}
+}
+
+func TestNewSystemNotifier_Console(t *testing.T) {
+	// tests are not started by the service control manager.
+	notifier, err := newSystemNotifier("test-daemon", 0, func() {})
+	if err != nil {
+		t.Fatalf("error creating notifier: %s", err)
+	}
+	if _, ok := notifier.(noopNotifier); !ok {
+		t.Errorf("expected a notifier doing nothing outside of the SCM, got %T", notifier)
+	}
+	if err := notifier.Start(context.Background(), nil); err != nil {
+		t.Errorf("error starting notifier: %s", err)
+	}
+}
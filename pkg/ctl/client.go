@@ -0,0 +1,121 @@
+package ctl
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+)
+
+// Client sends requests to the control socket of a daemon, every request uses its own connection.
+type Client struct {
+	path   string
+	daemon string // daemon the requests are forwarded to by a proxy, empty for the daemon serving the socket.
+}
+
+// NewClient creates a client of the control socket at path.
+func NewClient(path string) *Client {
+	return &Client{path: path}
+}
+
+// Via returns a client of the named daemon whose requests are forwarded by the proxy serving the socket of c.
+func (c *Client) Via(daemon string) *Client {
+	return &Client{path: c.path, daemon: daemon}
+}
+
+// Daemons returns the names of the daemons the proxy serving the socket forwards to.
+func (c *Client) Daemons(ctx context.Context) ([]string, error) {
+	var resp Response
+	err := c.do(ctx, Request{Command: Daemons}, func(r Response) bool {
+		resp = r
+		return false
+	})
+	return resp.Daemons, err
+}
+
+// States returns the current state of every service of the daemon.
+func (c *Client) States(ctx context.Context) (map[string]string, error) {
+	states, _, err := c.Status(ctx)
+	return states, err
+}
+
+// Status returns the current state of every service of the daemon, along with the most recent
+// lifecycle error of the services that failed one.
+func (c *Client) Status(ctx context.Context) (states map[string]string, errs map[string]string, err error) {
+	var resp Response
+	err = c.do(ctx, Request{Command: States}, func(r Response) bool {
+		resp = r
+		return false
+	})
+	return resp.States, resp.Errors, err
+}
+
+// Restart takes a service through Stop and back to Init.
+func (c *Client) Restart(ctx context.Context, service string) error {
+	return c.do(ctx, Request{Command: Restart, Service: service}, func(Response) bool {
+		return false
+	})
+}
+
+// Resume starts a service that was registered paused.
+func (c *Client) Resume(ctx context.Context, service string) error {
+	return c.do(ctx, Request{Command: Resume, Service: service}, func(Response) bool {
+		return false
+	})
+}
+
+// Logs calls fn with every service log of the daemon, of service only when it is not empty,
+// until ctx is done or the daemon stops. The error is nil once ctx is done.
+func (c *Client) Logs(ctx context.Context, service string, fn func(entry LogEntry)) error {
+	err := c.do(ctx, Request{Command: Logs, Service: service}, func(r Response) bool {
+		if r.Log != nil {
+			fn(*r.Log)
+		}
+		return true
+	})
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// do sends the request and passes every response to fn until fn returns false or the connection is closed.
+func (c *Client) do(ctx context.Context, req Request, fn func(resp Response) bool) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", c.path)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// unblocks reading from the connection once the context is done.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	req.Daemon = c.daemon
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return err
+	}
+
+	var received bool
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		received = true
+		var resp Response
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			return err
+		}
+		if resp.Err != "" {
+			return Error(resp.Err)
+		}
+		if !fn(resp) {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil || received {
+		return err
+	}
+	// the daemon closed the connection without answering.
+	return io.ErrUnexpectedEOF
+}
@@ -0,0 +1,52 @@
+// Package ctl implements the control socket protocol of a daemon, see rxd.UsingControlSocket.
+// Requests and responses are line-delimited json. Every connection carries a single request, answered
+// by a single response, or by a stream of responses for Logs until either side closes the connection.
+package ctl
+
+import "time"
+
+// Commands of a request.
+const (
+	States  = "states"  // returns the current state and the most recent lifecycle error of every service.
+	Logs    = "logs"    // streams the service logs, of Service only when set.
+	Restart = "restart" // takes Service through Stop and back to Init.
+	Resume  = "resume"  // starts Service when it is paused.
+	Daemons = "daemons" // returns the names of the daemons a proxy forwards to.
+)
+
+// Request is sent to the daemon serving the socket, or forwarded by it when Daemon names
+// another daemon and the socket is a proxy, see rxd.UsingAdminProxy.
+type Request struct {
+	Command string `json:"command"`
+	Service string `json:"service,omitempty"`
+	Daemon  string `json:"daemon,omitempty"`
+}
+
+type Response struct {
+	Daemons []string          `json:"daemons,omitempty"`
+	States  map[string]string `json:"states,omitempty"`
+	Errors  map[string]string `json:"errors,omitempty"`
+	Log     *LogEntry         `json:"log,omitempty"`
+	Err     string            `json:"error,omitempty"`
+}
+
+// LogEntry is a single service log streamed by Logs.
+type LogEntry struct {
+	Time    time.Time         `json:"time"`
+	Level   string            `json:"level"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// Error is an error reported by the daemon.
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// Is reports whether the daemon reported target, so errors.Is matches the errors of the daemon
+// across the socket, e.g. rxd.ErrServiceNotFound.
+func (e Error) Is(target error) bool {
+	return target != nil && target.Error() == string(e)
+}
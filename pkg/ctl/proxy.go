@@ -0,0 +1,69 @@
+package ctl
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultSocketDir is where daemons place their control sockets by convention, named after the daemon.
+const DefaultSocketDir = "/run/rxd"
+
+const socketExt = ".sock"
+
+// SocketPath returns the path of the control socket of the named daemon in dir.
+func SocketPath(dir, daemon string) string {
+	return filepath.Join(dir, daemon+socketExt)
+}
+
+// Discover returns the sorted names of the daemons with a control socket in dir.
+func Discover(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var daemons []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), socketExt)
+		if !ok || entry.Type()&os.ModeSocket == 0 {
+			continue
+		}
+		daemons = append(daemons, name)
+	}
+	sort.Strings(daemons)
+	return daemons, nil
+}
+
+// Forward sends req to the control socket of the daemon named by req.Daemon in dir and copies
+// its responses to w until either side closes the connection or ctx is done.
+func Forward(ctx context.Context, dir string, req Request, w io.Writer) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", SocketPath(dir, req.Daemon))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	req.Daemon = ""
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		if _, err := w.Write(append(scanner.Bytes(), '\n')); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
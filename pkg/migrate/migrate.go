@@ -0,0 +1,166 @@
+// Package migrate finds the code using the ServiceResponse API of rxd that needs porting to ServiceRunner.
+//
+// The analysis is syntactic, ChangeState and ShutdownSignal calls are flagged on any receiver.
+package migrate
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ImportPath is the import path of the rxd package.
+const ImportPath = "github.com/ambitiousfew/rxd"
+
+// Finding is a use of the old API at a position of a source file.
+type Finding struct {
+	Pos  token.Position
+	Old  string // the identifier of the old API in use.
+	Hint string // how to port it.
+}
+
+func (f Finding) String() string {
+	return f.Pos.String() + ": " + f.Old + ": " + f.Hint
+}
+
+// identifiers are the identifiers of the rxd package that are deprecated with their hints.
+var identifiers = map[string]string{
+	"ServiceResponse":     "lifecycles of a ServiceRunner return an error only",
+	"NewResponse":         "return the error, the manager of the service picks the next state",
+	"LegacyServiceRunner": "implement rxd.ServiceRunner",
+	"LegacyContext":       "take an rxd.ServiceContext",
+	"NewLegacyService":    "use rxd.NewService with a ServiceRunner",
+	"InitState":           "use rxd.StateInit",
+	"IdleState":           "use rxd.StateIdle",
+	"RunState":            "use rxd.StateRun",
+	"StopState":           "use rxd.StateStop",
+	"ExitState":           "use rxd.StateExit",
+	"NoopState":           "return nil, the manager moves the service to its next state",
+	"Entering":            "use rxd.Entered",
+	"Exiting":             "use rxd.Exited",
+	"Changing":            "use rxd.Changed",
+}
+
+// methods are the methods of the old service context with their hints.
+var methods = map[string]string{
+	"ChangeState":    "watch the states with WatchAllStates, WatchAnyServices or WatchAllServices",
+	"ShutdownSignal": "use Done of the rxd.ServiceContext",
+}
+
+// File returns the uses of the old API in the parsed file f.
+func File(fset *token.FileSet, f *ast.File) []Finding {
+	name := importName(f)
+	if name == "" {
+		return nil
+	}
+
+	var findings []Finding
+	report := func(pos token.Pos, old, hint string) {
+		findings = append(findings, Finding{Pos: fset.Position(pos), Old: old, Hint: hint})
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.StarExpr:
+			if isRxd(n.X, name, "ServiceContext") {
+				report(n.Pos(), "*"+name+".ServiceContext", "rxd.ServiceContext is an interface, take it by value")
+				return false
+			}
+		case *ast.SelectorExpr:
+			if x, ok := n.X.(*ast.Ident); ok && x.Name == name {
+				if hint, ok := identifiers[n.Sel.Name]; ok {
+					report(n.Pos(), name+"."+n.Sel.Name, hint)
+				}
+			}
+		case *ast.CallExpr:
+			if sel, ok := n.Fun.(*ast.SelectorExpr); ok && len(n.Args) == 0 {
+				if hint, ok := methods[sel.Sel.Name]; ok {
+					report(sel.Sel.Pos(), sel.Sel.Name+"()", hint)
+				}
+			}
+		}
+		return true
+	})
+
+	return findings
+}
+
+// Source parses the go source src and returns the uses of the old API, filename is used for the positions.
+func Source(filename string, src any) ([]Finding, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	return File(fset, f), nil
+}
+
+// Dir walks root and returns the uses of the old API in every go file, skipping vendor and hidden directories.
+func Dir(root string) ([]Finding, error) {
+	var findings []Finding
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path != root && (d.Name() == "vendor" || d.Name() == "testdata" || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		found, err := Source(path, nil)
+		if err != nil {
+			return err
+		}
+		findings = append(findings, found...)
+		return nil
+	})
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Pos.Filename != findings[j].Pos.Filename {
+			return findings[i].Pos.Filename < findings[j].Pos.Filename
+		}
+		return findings[i].Pos.Offset < findings[j].Pos.Offset
+	})
+	return findings, err
+}
+
+// importName returns the name rxd is imported as in f, or an empty string if f does not import it.
+func importName(f *ast.File) string {
+	for _, spec := range f.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil || path != ImportPath {
+			continue
+		}
+
+		if spec.Name == nil {
+			return "rxd"
+		}
+		if spec.Name.Name == "_" || spec.Name.Name == "." {
+			// dot imports cannot be told apart from local identifiers without type checking.
+			return ""
+		}
+		return spec.Name.Name
+	}
+	return ""
+}
+
+func isRxd(expr ast.Expr, name, ident string) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	x, ok := sel.X.(*ast.Ident)
+	return ok && x.Name == name && sel.Sel.Name == ident
+}
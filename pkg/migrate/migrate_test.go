@@ -0,0 +1,106 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const legacySource = `package main
+
+import r "github.com/ambitiousfew/rxd"
+
+type poller struct{}
+
+func (p *poller) Run(ctx *r.ServiceContext) r.ServiceResponse {
+	for {
+		select {
+		case <-ctx.ShutdownSignal():
+			return r.NewResponse(nil, r.ExitState)
+		case <-ctx.ChangeState():
+		}
+	}
+}
+`
+
+func TestSource(t *testing.T) {
+	findings, err := Source("poller.go", legacySource)
+	if err != nil {
+		t.Fatalf("error analyzing source: %s", err)
+	}
+
+	want := []string{"*r.ServiceContext", "r.ServiceResponse", "ShutdownSignal()", "r.NewResponse", "r.ExitState", "ChangeState()"}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %v", len(want), findings)
+	}
+
+	for i, finding := range findings {
+		if finding.Old != want[i] {
+			t.Errorf("expected finding %d to be %s, got %s", i, want[i], finding.Old)
+		}
+		if finding.Hint == "" {
+			t.Errorf("expected a hint for %s", finding.Old)
+		}
+	}
+
+	if findings[0].Pos.Filename != "poller.go" || findings[0].Pos.Line != 7 {
+		t.Errorf("expected the first finding at poller.go:7, got %s", findings[0].Pos)
+	}
+}
+
+func TestSource_Ported(t *testing.T) {
+	src := `package main
+
+import "github.com/ambitiousfew/rxd"
+
+type poller struct{}
+
+func (p *poller) Run(ctx rxd.ServiceContext) error {
+	states, cancel := ctx.WatchAllServices(rxd.Entered, rxd.StateRun, "api")
+	defer cancel()
+	<-states
+	return nil
+}
+`
+	findings, err := Source("poller.go", src)
+	if err != nil {
+		t.Fatalf("error analyzing source: %s", err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("expected no findings in ported code, got %v", findings)
+	}
+}
+
+func TestDir(t *testing.T) {
+	root := t.TempDir()
+	for path, src := range map[string]string{
+		"poller.go":               legacySource,
+		"vendor/dep/dep.go":       legacySource,
+		"other/other.go":          "package other\n\nfunc changeState() {}\n",
+		"other/notgo.txt":         "rxd.NewResponse",
+		"service/service_test.go": legacySource,
+	} {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	findings, err := Dir(root)
+	if err != nil {
+		t.Fatalf("error analyzing dir: %s", err)
+	}
+
+	files := map[string]int{}
+	for _, finding := range findings {
+		rel, _ := filepath.Rel(root, finding.Pos.Filename)
+		files[rel]++
+	}
+
+	if len(files) != 2 || files["poller.go"] != 6 || files[filepath.Join("service", "service_test.go")] != 6 {
+		t.Errorf("expected findings in poller.go and service/service_test.go only, got %v", files)
+	}
+}
@@ -57,6 +57,20 @@ func (c *Client) ChangeLogLevel(ctx context.Context, level log.Level) error {
 	return resp
 }
 
+// Batch sends a batch of operations to the daemon, see BatchRequest.
+func (c *Client) Batch(ctx context.Context, req BatchRequest) (BatchResponse, error) {
+	var resp BatchResponse
+
+	call := c.client.Go("CommandHandler.Batch", req, &resp, make(chan *rpc.Call, 1))
+
+	select {
+	case <-ctx.Done():
+		return resp, ctx.Err()
+	case result := <-call.Done:
+		return resp, result.Error
+	}
+}
+
 func (c *Client) Close() error {
 	return c.client.Close()
 }
@@ -1,8 +1,14 @@
 package rpc
 
+import "github.com/ambitiousfew/rxd/log"
+
 const (
 	Unknown Command = iota
 	SetLevel
+	RemoveService
+	RecycleService
+	Reload
+	SetTraceSampling
 )
 
 type Command uint8
@@ -11,7 +17,48 @@ func (c Command) String() string {
 	switch c {
 	case SetLevel:
 		return "SetLevel"
+	case RemoveService:
+		return "RemoveService"
+	case RecycleService:
+		return "RecycleService"
+	case Reload:
+		return "Reload"
+	case SetTraceSampling:
+		return "SetTraceSampling"
 	default:
 		return "Unknown"
 	}
 }
+
+// Operation is a single admin command of a batch.
+// Service is only used by service commands, Level only by SetLevel,
+// Rate and States (lifecycle state names, empty for all) only by SetTraceSampling.
+type Operation struct {
+	Command Command
+	Service string
+	Level   log.Level
+	Rate    float64
+	States  []string
+}
+
+// BatchRequest asks the daemon to apply its operations in order.
+// Every operation is validated before any is applied, with DryRun only the plan is reported.
+type BatchRequest struct {
+	Operations []Operation
+	DryRun     bool
+}
+
+// OperationResult reports the planned effect of an operation and whether it was applied.
+type OperationResult struct {
+	Operation Operation
+	Plan      string
+	Applied   bool
+	Err       string
+}
+
+// BatchResponse reports every operation of a batch in order.
+// Applied is true only when every operation was applied.
+type BatchResponse struct {
+	Results []OperationResult
+	Applied bool
+}
@@ -0,0 +1,113 @@
+// Package scaffold generates a runnable rxd project, the code behind `rxd new <project>`.
+//
+// The project holds a daemon wired from a config file with an HTTP API service and a poller checking
+// its health, tests running the daemon with the e2e harness, a systemd unit and a Dockerfile:
+//
+//	files, err := scaffold.Files(scaffold.Project{Name: "myd", Module: "example.com/myd"})
+//	err = scaffold.Generate("./myd", scaffold.Project{Name: "myd", Module: "example.com/myd"})
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/ambitiousfew/rxd/install"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.tmpl"))
+
+var (
+	ErrInvalidName = errors.New("project name must only hold letters, digits, '-', '_' and '.'")
+	ErrNotEmpty    = errors.New("project directory is not empty")
+)
+
+// Project describes the project to generate.
+type Project struct {
+	Name   string // name of the daemon, binary and systemd unit e.g. "myd".
+	Module string // module path of the project, defaults to Name.
+	// Version of rxd required by go.mod e.g. "v1.2.0", without one the requirement is left to `go mod tidy`.
+	Version string
+}
+
+func (p Project) withDefaults() (Project, error) {
+	if p.Name == "" || strings.Trim(p.Name, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_.") != "" {
+		return p, ErrInvalidName
+	}
+	if p.Module == "" {
+		p.Module = p.Name
+	}
+	return p, nil
+}
+
+// Files returns the content of every file of the project keyed by their path relative to the project directory.
+func Files(p Project) (map[string][]byte, error) {
+	p, err := p.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	for _, tmpl := range templates.Templates() {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, p); err != nil {
+			return nil, err
+		}
+
+		name := strings.TrimSuffix(tmpl.Name(), ".tmpl")
+		content := buf.Bytes()
+		if filepath.Ext(name) == ".go" {
+			if content, err = format.Source(content); err != nil {
+				return nil, errors.New(name + ": " + err.Error())
+			}
+		}
+		files[name] = content
+	}
+
+	unit, err := install.Generate(install.TargetSystemd, install.Config{
+		Name:        p.Name,
+		Description: p.Name + " daemon",
+		ExecPath:    "/usr/local/bin/" + p.Name,
+		Args:        []string{"-config", "/etc/" + p.Name + "/config.json"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	files[p.Name+".service"] = unit
+	return files, nil
+}
+
+// Generate writes the project into dir, which is created if needed and must otherwise be empty
+// so no existing work is overwritten.
+func Generate(dir string, p Project) error {
+	files, err := Files(p)
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if len(entries) > 0 {
+		return ErrNotEmpty
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
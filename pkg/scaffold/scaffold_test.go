@@ -0,0 +1,64 @@
+package scaffold
+
+import (
+	"errors"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ambitiousfew/rxd/config"
+)
+
+func TestGenerate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "myd")
+	if err := Generate(dir, Project{Name: "myd", Module: "example.com/myd", Version: "v1.2.0"}); err != nil {
+		t.Fatalf("error generating project: %s", err)
+	}
+
+	for _, name := range []string{"main.go", "api.go", "poller.go", "main_test.go", "config.json", "go.mod", "Dockerfile", "myd.service"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s to be generated: %s", name, err)
+		}
+	}
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, nil, parser.AllErrors)
+	if err != nil {
+		t.Fatalf("expected the generated code to parse: %s", err)
+	}
+	if _, ok := pkgs["main"]; !ok || len(pkgs) != 1 {
+		t.Errorf("expected a single main package, got %v", pkgs)
+	}
+
+	conf, err := config.Load(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatalf("expected the generated config to load: %s", err)
+	}
+	if _, err := conf.DaemonOptions(); err != nil {
+		t.Errorf("expected the daemon options to apply: %s", err)
+	}
+	for _, service := range []string{"api", "poller"} {
+		if _, err := conf.ServiceOptions(service); err != nil {
+			t.Errorf("expected the options of %s to apply: %s", service, err)
+		}
+	}
+
+	mod, _ := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if !strings.Contains(string(mod), "module example.com/myd") || !strings.Contains(string(mod), "github.com/ambitiousfew/rxd v1.2.0") {
+		t.Errorf("expected go.mod to declare the module and require rxd, got %q", mod)
+	}
+	unit, _ := os.ReadFile(filepath.Join(dir, "myd.service"))
+	if !strings.Contains(string(unit), "ExecStart=/usr/local/bin/myd -config /etc/myd/config.json") {
+		t.Errorf("expected the unit to start the binary with its config, got %q", unit)
+	}
+
+	if err := Generate(dir, Project{Name: "myd"}); !errors.Is(err, ErrNotEmpty) {
+		t.Errorf("expected %s generating over the project, got %v", ErrNotEmpty, err)
+	}
+	if _, err := Files(Project{Name: "my daemon"}); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("expected %s, got %v", ErrInvalidName, err)
+	}
+}
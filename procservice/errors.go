@@ -0,0 +1,47 @@
+package procservice
+
+import "strconv"
+
+// Error is a custom error type for the procservice package.
+type Error string
+
+const (
+	ErrNoCommand      = Error("no command provided")
+	ErrUnhealthy      = Error("process failed its health probe")
+	ErrProbeNoMatches = Error("no healthy output observed within the probe window")
+	ErrProbeMatched   = Error("unhealthy output observed")
+)
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// ErrProbe is returned by a probe when the process did not pass the check.
+type ErrProbe struct {
+	Probe string
+	Err   error
+}
+
+func (e ErrProbe) Error() string {
+	return e.Probe + " probe failed: " + e.Err.Error()
+}
+
+func (e ErrProbe) Unwrap() error {
+	return e.Err
+}
+
+// ErrExit is returned by Run when the process exits with a code other than 0 that is not mapped with WithExitCode.
+// The code is -1 for a process killed by a signal.
+type ErrExit struct {
+	Path string
+	Code int
+	Err  error
+}
+
+func (e ErrExit) Error() string {
+	return e.Path + " exited with code " + strconv.Itoa(e.Code) + ": " + e.Err.Error()
+}
+
+func (e ErrExit) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,57 @@
+package procservice
+
+import "github.com/ambitiousfew/rxd/log"
+
+type Option func(*ProcessRunner)
+
+// WithEnv sets the environment of the process, if not set the process inherits the daemon environment.
+func WithEnv(env ...string) Option {
+	return func(r *ProcessRunner) {
+		r.env = env
+	}
+}
+
+// WithDir sets the working directory of the process.
+func WithDir(dir string) Option {
+	return func(r *ProcessRunner) {
+		r.dir = dir
+	}
+}
+
+// WithHealthProbe enables health probing of the running process.
+// A process that is alive but fails the probe more than the policy allows is killed,
+// Run returns ErrUnhealthy and the service manager decides when to start it again.
+func WithHealthProbe(probe Probe, policy HealthPolicy) Option {
+	return func(r *ProcessRunner) {
+		r.probe = probe
+		r.health = policy
+	}
+}
+
+// WithExitCode maps an exit code of the process to the error Run returns, so the service manager can tell failures
+// apart, e.g. a code for a bad configuration from one for a lost connection. A nil error makes the code a clean exit.
+func WithExitCode(code int, err error) Option {
+	return func(r *ProcessRunner) {
+		if r.exitCodes == nil {
+			r.exitCodes = make(map[int]error)
+		}
+		r.exitCodes[code] = err
+	}
+}
+
+// WithOutputLevels sets the levels the stdout and stderr lines of the process are logged at, by default
+// stdout is logged at info and stderr at warning.
+func WithOutputLevels(stdout, stderr log.Level) Option {
+	return func(r *ProcessRunner) {
+		r.stdoutLevel = stdout
+		r.stderrLevel = stderr
+	}
+}
+
+// WithOutputLogged sets whether the output of the process is logged, it is by default.
+// Without it the output is discarded, stdout is still handed to a probe implementing LineObserver.
+func WithOutputLogged(logged bool) Option {
+	return func(r *ProcessRunner) {
+		r.quiet = !logged
+	}
+}
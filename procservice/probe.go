@@ -0,0 +1,184 @@
+package procservice
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Probe checks the health of a running child process.
+// A nil error means the process is healthy.
+type Probe interface {
+	Probe(ctx context.Context) error
+}
+
+// LineObserver is implemented by probes that inspect the stdout of the child process.
+// The runner hands every stdout line of the process to the observer.
+type LineObserver interface {
+	Observe(line string)
+}
+
+// HealthPolicy decides how often a process is probed and when it is recycled.
+// It mirrors the semantics of a docker HEALTHCHECK.
+type HealthPolicy struct {
+	Interval    time.Duration // time between probes (default: 30s)
+	Timeout     time.Duration // max time a single probe may take (default: 30s)
+	StartPeriod time.Duration // grace period after start where failures are not counted
+	Retries     int           // consecutive failures before the process is recycled (default: 3)
+}
+
+func (p HealthPolicy) withDefaults() HealthPolicy {
+	if p.Interval <= 0 {
+		p.Interval = 30 * time.Second
+	}
+	if p.Timeout <= 0 {
+		p.Timeout = 30 * time.Second
+	}
+	if p.Retries <= 0 {
+		p.Retries = 3
+	}
+	return p
+}
+
+// HTTPProbe is healthy when a GET request to URL returns the expected Status.
+// If Status is 0 any 2xx or 3xx status is considered healthy.
+type HTTPProbe struct {
+	URL    string
+	Status int
+	Client *http.Client
+}
+
+func (p HTTPProbe) Probe(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return ErrProbe{Probe: "http", Err: err}
+	}
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return ErrProbe{Probe: "http", Err: err}
+	}
+	resp.Body.Close()
+
+	if p.Status == 0 && resp.StatusCode >= 200 && resp.StatusCode < 400 {
+		return nil
+	}
+
+	if resp.StatusCode != p.Status {
+		return ErrProbe{Probe: "http", Err: errors.New("unexpected status " + strconv.Itoa(resp.StatusCode))}
+	}
+	return nil
+}
+
+// TCPProbe is healthy when a tcp connection to Addr can be established.
+type TCPProbe struct {
+	Addr string
+}
+
+func (p TCPProbe) Probe(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", p.Addr)
+	if err != nil {
+		return ErrProbe{Probe: "tcp", Err: err}
+	}
+	return conn.Close()
+}
+
+// ExecProbe is healthy when the command exits with a zero exit code.
+type ExecProbe struct {
+	Path string
+	Args []string
+}
+
+func (p ExecProbe) Probe(ctx context.Context) error {
+	err := exec.CommandContext(ctx, p.Path, p.Args...).Run()
+	if err != nil {
+		return ErrProbe{Probe: "exec", Err: err}
+	}
+	return nil
+}
+
+// OutputProbe inspects the stdout of the child process.
+// The process is unhealthy if a line matching Unhealthy was seen after the last line matching Healthy,
+// or when Healthy is set and no matching line was seen within Window (default: 1m).
+type OutputProbe struct {
+	Healthy   *regexp.Regexp
+	Unhealthy *regexp.Regexp
+	Window    time.Duration
+
+	lastHealthy   time.Time
+	lastUnhealthy time.Time
+	started       time.Time
+	mu            sync.RWMutex
+}
+
+// reset forgets the output observed, the runner calls it before every process it starts.
+func (p *OutputProbe) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastHealthy = time.Time{}
+	p.lastUnhealthy = time.Time{}
+	p.started = time.Now()
+}
+
+func (p *OutputProbe) Observe(line string) {
+	now := time.Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.started.IsZero() {
+		p.started = now
+	}
+
+	if p.Healthy != nil && p.Healthy.MatchString(line) {
+		p.lastHealthy = now
+	}
+
+	if p.Unhealthy != nil && p.Unhealthy.MatchString(line) {
+		p.lastUnhealthy = now
+	}
+}
+
+func (p *OutputProbe) Probe(ctx context.Context) error {
+	now := time.Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.started.IsZero() {
+		p.started = now
+	}
+
+	if !p.lastUnhealthy.IsZero() && !p.lastUnhealthy.Before(p.lastHealthy) {
+		return ErrProbe{Probe: "output", Err: ErrProbeMatched}
+	}
+
+	if p.Healthy == nil {
+		return nil
+	}
+
+	window := p.Window
+	if window <= 0 {
+		window = time.Minute
+	}
+
+	last := p.lastHealthy
+	if last.IsZero() {
+		last = p.started
+	}
+
+	if now.Sub(last) > window {
+		return ErrProbe{Probe: "output", Err: ErrProbeNoMatches}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package procservice
+
+import (
+	"context"
+	"errors"
+	"net"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestOutputProbe_UnhealthyMatch(t *testing.T) {
+	p := &OutputProbe{
+		Healthy:   regexp.MustCompile(`ready`),
+		Unhealthy: regexp.MustCompile(`FATAL`),
+	}
+
+	p.Observe("server ready")
+	if err := p.Probe(context.Background()); err != nil {
+		t.Fatalf("expected healthy probe, got %s", err)
+	}
+
+	p.Observe("FATAL: out of connections")
+	err := p.Probe(context.Background())
+	if !errors.Is(err, ErrProbeMatched) {
+		t.Fatalf("expected %s, got %v", ErrProbeMatched, err)
+	}
+}
+
+func TestOutputProbe_Window(t *testing.T) {
+	p := &OutputProbe{
+		Healthy: regexp.MustCompile(`heartbeat`),
+		Window:  10 * time.Millisecond,
+	}
+
+	p.Observe("heartbeat")
+	time.Sleep(20 * time.Millisecond)
+
+	err := p.Probe(context.Background())
+	if !errors.Is(err, ErrProbeNoMatches) {
+		t.Fatalf("expected %s, got %v", ErrProbeNoMatches, err)
+	}
+}
+
+func TestTCPProbe(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %s", err)
+	}
+
+	p := TCPProbe{Addr: ln.Addr().String()}
+	if err := p.Probe(context.Background()); err != nil {
+		t.Fatalf("expected healthy probe, got %s", err)
+	}
+
+	ln.Close()
+	if err := p.Probe(context.Background()); err == nil {
+		t.Fatalf("expected probe to fail once the listener is closed")
+	}
+}
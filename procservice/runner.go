@@ -0,0 +1,239 @@
+package procservice
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/ambitiousfew/rxd"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+var _ rxd.ServiceRunner = (*ProcessRunner)(nil)
+
+// maxLineSize is the longest line of output of a process that is logged.
+const maxLineSize = 1024 * 1024
+
+// ProcessRunner is a ServiceRunner that runs an external OS process during Run.
+// Run returns once the process exits, or once the process is recycled for failing its health probe,
+// leaving the decision to restart it to the service manager. A process exiting with a code other than 0
+// fails with an ErrExit, unless the code is mapped with WithExitCode. Every line the process writes to
+// stdout and stderr is logged through the service logger.
+type ProcessRunner struct {
+	path   string
+	args   []string
+	env    []string
+	dir    string
+	probe  Probe
+	health HealthPolicy
+
+	exitCodes   map[int]error
+	stdoutLevel log.Level
+	stderrLevel log.Level
+	quiet       bool
+
+	cmd *exec.Cmd
+	mu  sync.Mutex
+}
+
+// New creates a ProcessRunner that will run the command at path with the given args.
+func New(path string, args []string, opts ...Option) *ProcessRunner {
+	r := &ProcessRunner{
+		path:        path,
+		args:        args,
+		stdoutLevel: log.LevelInfo,
+		stderrLevel: log.LevelWarning,
+		mu:          sync.Mutex{},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	r.health = r.health.withDefaults()
+	return r
+}
+
+func (r *ProcessRunner) Init(sctx rxd.ServiceContext) error {
+	if r.path == "" {
+		return ErrNoCommand
+	}
+	return nil
+}
+
+func (r *ProcessRunner) Idle(sctx rxd.ServiceContext) error {
+	return nil
+}
+
+func (r *ProcessRunner) Run(sctx rxd.ServiceContext) error {
+	cmd := exec.CommandContext(sctx, r.path, r.args...)
+	cmd.Env = r.env
+	cmd.Dir = r.dir
+
+	// a probe keeping state, such as OutputProbe, starts over with every process.
+	if p, ok := r.probe.(interface{ reset() }); ok {
+		p.reset()
+	}
+
+	var stdout, stderr io.ReadCloser
+	observer, observes := r.probe.(LineObserver)
+	if observes || !r.quiet {
+		pipe, err := cmd.StdoutPipe()
+		if err != nil {
+			return err
+		}
+		stdout = pipe
+	}
+	if !r.quiet {
+		pipe, err := cmd.StderrPipe()
+		if err != nil {
+			return err
+		}
+		stderr = pipe
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.cmd = cmd
+	r.mu.Unlock()
+
+	sctx.Log(log.LevelInfo, "process started", log.String("path", r.path), log.Int("pid", cmd.Process.Pid))
+
+	pidField := log.Int("pid", cmd.Process.Pid)
+	var readers sync.WaitGroup
+	if stdout != nil {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			r.stream(stdout, func(line string) {
+				if observes {
+					observer.Observe(line)
+				}
+				if !r.quiet {
+					sctx.Log(r.stdoutLevel, line, log.String("stream", "stdout"), pidField)
+				}
+			})
+		}()
+	}
+	if stderr != nil {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			r.stream(stderr, func(line string) {
+				sctx.Log(r.stderrLevel, line, log.String("stream", "stderr"), pidField)
+			})
+		}()
+	}
+
+	waitC := make(chan error, 1)
+	go func() {
+		// pipes must be fully read before calling wait.
+		readers.Wait()
+		waitC <- r.exitError(sctx, cmd.Wait())
+	}()
+
+	if r.probe == nil {
+		return <-waitC
+	}
+
+	return r.supervise(sctx, cmd, waitC)
+}
+
+// supervise probes the running process until it exits or fails the health policy.
+func (r *ProcessRunner) supervise(sctx rxd.ServiceContext, cmd *exec.Cmd, waitC <-chan error) error {
+	ticker := time.NewTicker(r.health.Interval)
+	defer ticker.Stop()
+
+	started := time.Now()
+	var failures int
+
+	for {
+		select {
+		case err := <-waitC:
+			return err
+		case <-ticker.C:
+			pctx, cancel := context.WithTimeout(sctx, r.health.Timeout)
+			err := r.probe.Probe(pctx)
+			cancel()
+
+			if err == nil {
+				failures = 0
+				continue
+			}
+
+			if time.Since(started) < r.health.StartPeriod {
+				// failures during the start period do not count towards the retries.
+				sctx.Log(log.LevelDebug, "health probe failed during start period", log.Error("error", err))
+				continue
+			}
+
+			failures++
+			sctx.Log(log.LevelWarning, "health probe failed", log.Error("error", err), log.Int("failures", failures), log.Int("retries", r.health.Retries))
+			if failures < r.health.Retries {
+				continue
+			}
+
+			sctx.Log(log.LevelError, "process is unhealthy, recycling", log.Int("pid", cmd.Process.Pid))
+			if err := cmd.Process.Kill(); err != nil {
+				sctx.Log(log.LevelError, "error killing unhealthy process", log.Error("error", err))
+			}
+			<-waitC
+			return ErrUnhealthy
+		}
+	}
+}
+
+// stream hands every line read from pipe to fn, lines too long to scan are skipped.
+func (r *ProcessRunner) stream(pipe io.Reader, fn func(line string)) {
+	scanner := bufio.NewScanner(pipe)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineSize)
+	for scanner.Scan() {
+		fn(scanner.Text())
+	}
+	// the pipe must be drained even past a line that is too long, the process would block writing to it.
+	io.Copy(io.Discard, pipe)
+}
+
+// exitError maps the error of a process that exited to the error Run returns.
+func (r *ProcessRunner) exitError(sctx rxd.ServiceContext, err error) error {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return err
+	}
+
+	code := exitErr.ExitCode()
+	if sctx.Err() != nil {
+		// the process was killed because the service is stopping.
+		sctx.Log(log.LevelDebug, "process stopped with the service", log.String("path", r.path), log.Int("code", code))
+		return nil
+	}
+
+	sctx.Log(log.LevelInfo, "process exited", log.String("path", r.path), log.Int("code", code))
+	if mapped, ok := r.exitCodes[code]; ok {
+		return mapped
+	}
+	return ErrExit{Path: r.path, Code: code, Err: exitErr}
+}
+
+func (r *ProcessRunner) Stop(sctx rxd.ServiceContext) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cmd == nil {
+		return nil
+	}
+
+	// the process may still be running if Run was interrupted, ensure it does not outlive the service.
+	if r.cmd.ProcessState == nil && r.cmd.Process != nil {
+		r.cmd.Process.Kill()
+	}
+	r.cmd = nil
+	return nil
+}
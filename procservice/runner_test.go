@@ -0,0 +1,83 @@
+package procservice
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// runOnce runs the runner as a run once service and returns the error of the daemon and what it logged.
+func runOnce(t *testing.T, runner *ProcessRunner) (error, string) {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var out bytes.Buffer
+	d := rxd.NewDaemon("test-daemon", rxd.WithServiceLogger(log.NewLogger(log.LevelDebug, log.NewHandler(log.WithWriter(&out)))))
+	if err := d.AddService(rxd.NewService("proc", runner, rxd.WithManager(rxd.NewRunOnceManager(0)))); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+	err := d.Start(ctx)
+	return err, out.String()
+}
+
+func TestProcessRunner_ExitCode(t *testing.T) {
+	err, output := runOnce(t, New("sh", []string{"-c", "echo listening; echo bad flag >&2; exit 3"}))
+
+	var exitErr ErrExit
+	if !errors.As(err, &exitErr) || exitErr.Code != 3 {
+		t.Fatalf("expected the process to fail with exit code 3, got %v", err)
+	}
+	if !strings.Contains(output, "[INFO] listening") || !strings.Contains(output, "stream=stdout") {
+		t.Errorf("expected stdout to be logged at info, got %q", output)
+	}
+	if !strings.Contains(output, "[WARNING] bad flag") || !strings.Contains(output, "stream=stderr") {
+		t.Errorf("expected stderr to be logged at warning, got %q", output)
+	}
+}
+
+func TestProcessRunner_MappedExitCode(t *testing.T) {
+	errConfig := errors.New("invalid configuration")
+	err, _ := runOnce(t, New("sh", []string{"-c", "exit 78"}, WithExitCode(78, errConfig)))
+	if !errors.Is(err, errConfig) {
+		t.Fatalf("expected exit code 78 to map to %s, got %v", errConfig, err)
+	}
+
+	err, output := runOnce(t, New("sh", []string{"-c", "echo hidden; exit 1"}, WithExitCode(1, nil), WithOutputLogged(false)))
+	if err != nil {
+		t.Fatalf("expected exit code 1 to be a clean exit, got %s", err)
+	}
+	if strings.Contains(output, "hidden") {
+		t.Errorf("expected the output to be discarded, got %q", output)
+	}
+}
+
+func TestProcessRunner_OutputProbeRestart(t *testing.T) {
+	// the first process logs an unhealthy line and exits, the next one runs quietly.
+	marker := filepath.Join(t.TempDir(), "started")
+	probe := &OutputProbe{Unhealthy: regexp.MustCompile(`FATAL`)}
+	runner := New("sh", []string{"-c", "if [ -f " + marker + " ]; then sleep 0.2; else touch " + marker + "; echo FATAL; fi"},
+		WithHealthProbe(probe, HealthPolicy{Interval: 20 * time.Millisecond, Retries: 1}))
+
+	runOnce(t, runner)
+	if err := probe.Probe(context.Background()); !errors.Is(err, ErrProbeMatched) {
+		t.Fatalf("expected the first process to be unhealthy, got %v", err)
+	}
+
+	if err, output := runOnce(t, runner); err != nil {
+		t.Fatalf("expected the restarted process to be healthy, got %s: %q", err, output)
+	}
+}
@@ -1,6 +1,9 @@
 package rxd
 
-import "time"
+import (
+	"sync/atomic"
+	"time"
+)
 
 type ServiceRunner interface {
 	Init(ServiceContext) error
@@ -9,6 +12,13 @@ type ServiceRunner interface {
 	Stop(ServiceContext) error
 }
 
+// Reloader can optionally be implemented by a ServiceRunner to reload its configuration in place.
+// On SIGHUP the daemon interrupts Run, calls Reload in StateReload and moves the service back to Run,
+// if Reload fails the service goes through Stop and Init instead.
+type Reloader interface {
+	Reload(ServiceContext) error
+}
+
 // Service is a struct that contains the Name of the service, the ServiceRunner and the ServiceHandler.
 // This struct is what the caller uses to add a new service to the daemon.
 // The daemon performs checks and translates this struct into a Service struct before starting it.
@@ -16,6 +26,34 @@ type Service struct {
 	Name    string
 	Runner  ServiceRunner
 	Manager ServiceManager
+	config  serviceConfig
+}
+
+// serviceConfig carries the per-service options the daemon applies when running a service.
+type serviceConfig struct {
+	lockOSThread      bool               // run the service on a goroutine wired to its own OS thread.
+	cpuShare          float64            // soft fraction of wall time the service may spend working, 0 disables throttling.
+	cpuBurst          time.Duration      // amount of work time a service may accumulate before being throttled.
+	isolated          bool               // run the service in a child process of the daemon.
+	preconditions     []Precondition     // gates evaluated by the manager before every Init.
+	dependsOn         []string           // services that must reach StateRun before this service starts.
+	stopTimeout       time.Duration      // time the service is given to exit once stopped before it is abandoned, 0 waits forever.
+	traceSampling     *TraceSampling     // spans recorded for the service when the daemon has a tracer, nil records all.
+	restartPolicy     RestartPolicy      // what happens once Run returns on its own, restarts by default.
+	transitionHooks   []TransitionFunc   // called with every state transition of the service.
+	startPaused       bool               // the service waits in StatePaused until it is resumed.
+	resumeWhenRunning []string           // services that resume the paused service once they all reached StateRun.
+	settings          any                // settings of the service read by its runner, see WithSettings.
+	critical          bool               // the watchdog keep-alive is withheld while the service is wedged or unhealthy.
+	ttl               time.Duration      // time after which the started service is removed, 0 keeps it.
+	maxRuns           int                // completed Run lifecycles after which the started service is removed, 0 keeps it.
+	quarantine        quarantinePolicy   // when the service is quarantined for flapping, the policy of the daemon when unset.
+	dependencies      []dependencyConfig // services watched by circuit breakers, see WatchDependency.
+	labels            map[string]string  // select the service in service filters, see WithLabels.
+	// state timeouts of the continuous manager replaced by config reloads, nil until the first reload.
+	stateTimeouts *atomic.Pointer[ManagerStateTimeouts]
+	resources     *resourceAccount // resources accounted by the service, see AccountResources.
+	usage         *usageMeter      // usage sampled around the lifecycles of the service, see Usage.
 }
 
 // DaemonService is a struct that contains the Name of the service, the ServiceRunner
@@ -0,0 +1,218 @@
+package rxd
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// breakerState is the state of the circuit breaker of a service on one of its dependencies.
+type breakerState uint8
+
+const (
+	breakerClosed   breakerState = iota // the dependency is running, the service runs.
+	breakerOpen                         // the dependency was down for the threshold, the service waits in Idle.
+	breakerHalfOpen                     // the dependency is running again, the service probes it for the threshold.
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// dependencyConfig is a dependency watched by a circuit breaker, see WatchDependency.
+type dependencyConfig struct {
+	service   string
+	threshold time.Duration
+}
+
+// dependencyBreaker trips open once the dependency has been out of StateRun for the threshold, the service is
+// then driven back to Idle and waits there. Once the dependency runs again the breaker half-opens and lets the
+// service run as a probe, it closes if the dependency keeps running for the threshold and opens again otherwise.
+type dependencyBreaker struct {
+	dependencyConfig
+	mu     sync.Mutex
+	state  breakerState
+	closeC chan struct{} // closed once the breaker is no longer open, replaced every time it opens.
+}
+
+func newDependencyBreaker(conf dependencyConfig) *dependencyBreaker {
+	closeC := make(chan struct{})
+	close(closeC)
+	return &dependencyBreaker{dependencyConfig: conf, closeC: closeC}
+}
+
+func (b *dependencyBreaker) current() breakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// set changes the state of the breaker, releasing the services waiting on it once it is no longer open.
+func (b *dependencyBreaker) set(state breakerState) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case state == breakerOpen && b.state != breakerOpen:
+		b.closeC = make(chan struct{})
+	case state != breakerOpen && b.state == breakerOpen:
+		close(b.closeC)
+	}
+	b.state = state
+}
+
+// released returns a channel closed once the breaker is no longer open.
+func (b *dependencyBreaker) released() <-chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closeC
+}
+
+// watch moves the breaker along the states of the dependency until the service context is done,
+// trip is called every time the breaker opens.
+func (b *dependencyBreaker) watch(sctx ServiceContext, trip func()) {
+	statesC, cancel := sctx.WatchAllStates(NewServiceFilter(Include, b.service))
+	defer cancel()
+
+	timer := time.NewTimer(b.threshold)
+	timer.Stop()
+	defer timer.Stop()
+	var timerC <-chan time.Time
+
+	transition := func(state breakerState) {
+		from := b.current()
+		b.set(state)
+		if sctx.Err() != nil {
+			return
+		}
+		var level log.Level = log.LevelInfo
+		if state == breakerOpen {
+			level = log.LevelWarning
+		}
+		sctx.Log(level, "circuit breaker "+state.String(), log.String("dependency", b.service), log.String("from", from.String()))
+		if state == breakerOpen {
+			trip()
+		}
+	}
+
+	for {
+		select {
+		case <-sctx.Done():
+			return
+		case states, open := <-statesC:
+			if !open {
+				return
+			}
+			running := states[b.service] == StateRun
+			switch b.current() {
+			case breakerClosed:
+				if !running && timerC == nil {
+					// the dependency left run, the breaker trips unless it is back within the threshold.
+					timer.Reset(b.threshold)
+					timerC = timer.C
+				} else if running && timerC != nil {
+					timer.Stop()
+					timerC = nil
+				}
+			case breakerOpen:
+				if running {
+					timer.Reset(b.threshold)
+					timerC = timer.C
+					transition(breakerHalfOpen)
+				}
+			case breakerHalfOpen:
+				if !running {
+					// the probe failed, the dependency went down again.
+					timer.Stop()
+					timerC = nil
+					transition(breakerOpen)
+				}
+			}
+		case <-timerC:
+			timerC = nil
+			switch b.current() {
+			case breakerClosed:
+				transition(breakerOpen)
+			case breakerHalfOpen:
+				transition(breakerClosed)
+			}
+		}
+	}
+}
+
+// breakerRunner holds the service in Idle while any of its circuit breakers is open.
+type breakerRunner struct {
+	runner      ServiceRunner
+	breakers    []*dependencyBreaker
+	interrupter *interrupter
+}
+
+func (r breakerRunner) Init(sctx ServiceContext) error {
+	return r.runner.Init(sctx)
+}
+
+// Idle waits for every breaker to be closed or half-open before the service goes on to Run.
+func (r breakerRunner) Idle(sctx ServiceContext) error {
+	for _, b := range r.breakers {
+		select {
+		case <-b.released():
+			continue
+		default:
+		}
+
+		sctx.Log(log.LevelInfo, "waiting for dependency", log.String("dependency", b.service))
+		select {
+		case <-b.released():
+		case <-sctx.Done():
+			return nil
+		}
+	}
+	return r.runner.Idle(sctx)
+}
+
+func (r breakerRunner) Run(sctx ServiceContext) error {
+	for _, b := range r.breakers {
+		if b.current() == breakerOpen {
+			// a breaker opened since Idle returned, go back to wait for the dependency.
+			r.interrupter.requestRunning(InterruptIdle)
+			return nil
+		}
+	}
+	return r.runner.Run(sctx)
+}
+
+func (r breakerRunner) Stop(sctx ServiceContext) error {
+	return r.runner.Stop(sctx)
+}
+
+func (r breakerRunner) Reload(sctx ServiceContext) error {
+	return reloadRunner(sctx, r.runner)
+}
+
+// watchDependencies guards the runner with a circuit breaker per dependency of the service, their watchers
+// stop along with the service context. The returned func waits for them once the context is done, they log
+// through the service context until then.
+func watchDependencies(sctx ServiceContext, runner ServiceRunner, run *serviceRun, dependencies []dependencyConfig) (ServiceRunner, func()) {
+	var watchers sync.WaitGroup
+	breakers := make([]*dependencyBreaker, 0, len(dependencies))
+	for _, dep := range dependencies {
+		b := newDependencyBreaker(dep)
+		breakers = append(breakers, b)
+		watchers.Add(1)
+		go func() {
+			defer watchers.Done()
+			b.watch(sctx, func() {
+				run.interrupter.requestRunning(InterruptIdle)
+			})
+		}()
+	}
+	return breakerRunner{runner: runner, breakers: breakers, interrupter: run.interrupter}, watchers.Wait
+}
@@ -0,0 +1,95 @@
+package rxd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// crashingService leaves run once crashC is sent to and only initializes again once upC is closed.
+type crashingService struct {
+	recordingService
+	crashC chan struct{}
+	upC    chan struct{}
+}
+
+func (c *crashingService) Init(sctx ServiceContext) error {
+	if c.journal.count(c.name+":init") > 0 {
+		select {
+		case <-c.upC:
+		case <-sctx.Done():
+		}
+	}
+	return c.recordingService.Init(sctx)
+}
+
+func (c *crashingService) Run(sctx ServiceContext) error {
+	c.journal.record(c.name + ":run")
+	select {
+	case <-c.crashC:
+	case <-sctx.Done():
+	}
+	return nil
+}
+
+func TestDaemon_WatchDependency(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	journal := &recordingJournal{}
+	db := &crashingService{recordingService: recordingService{name: "db", journal: journal}, crashC: make(chan struct{}), upC: make(chan struct{})}
+	manager := RunContinuousManager{StartupDelay: time.Millisecond, DefaultDelay: time.Millisecond, StateTimeouts: ManagerStateTimeouts{}}
+
+	d := NewDaemon("test-daemon", WithServiceLogger(log.NewLogger(log.LevelError, newTestLogger()))).(*daemon)
+	err := d.AddServices(
+		NewService("db", db, WithManager(manager)),
+		NewService("api", &recordingService{name: "api", journal: journal}, WithManager(manager), WatchDependency("db", 50*time.Millisecond)),
+	)
+	if err != nil {
+		t.Fatalf("error adding services: %s", err)
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	await := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %s, api history: %v", what, d.History("api"))
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	last := func(service string) State {
+		changes := d.History(service)
+		if len(changes) == 0 {
+			return StateExit
+		}
+		return changes[len(changes)-1].State
+	}
+
+	await("db and api to run", func() bool { return journal.count("db:run") == 1 && last("api") == StateRun })
+	// let the breaker settle in case db was not running yet when api started.
+	time.Sleep(100 * time.Millisecond)
+	runs := journal.count("api:run")
+
+	db.crashC <- struct{}{}
+	await("api to be driven back to idle", func() bool { return last("api") == StateIdle })
+	if journal.index("api:stop") >= 0 {
+		t.Errorf("expected api to wait in idle without being stopped")
+	}
+
+	close(db.upC)
+	await("api to run again once db recovered", func() bool { return journal.count("api:run") > runs && last("api") == StateRun })
+
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+}
@@ -2,6 +2,9 @@ package rxd
 
 import (
 	"context"
+	"maps"
+	"net"
+	"sync/atomic"
 	"time"
 
 	"github.com/ambitiousfew/rxd/intracom"
@@ -26,6 +29,20 @@ type ServiceContext interface {
 	WithFields(fields ...log.Field) ServiceContext
 	WithParent(ctx context.Context) (ServiceContext, context.CancelFunc)
 	WithName(name string) (ServiceContext, context.CancelFunc)
+	ShutdownDeadline() (time.Time, bool)
+	Settings() any
+	Listener(name string) (net.Listener, error)
+	Checkpoint()
+	Yield()
+	Throttle(rate float64)
+	PollUntil(interval, timeout time.Duration, fn func(ctx context.Context) (done bool, err error)) error
+	WaitBarrier(name string) error
+	Tracer() Tracer
+	Publish(topic string, payload []byte) error
+	Subscribe(topic string, conf SubscribeConfig) (<-chan []byte, error)
+	Set(key string, value any)
+	Get(key string) (value any, ok bool)
+	Delete(key string)
 }
 
 type serviceContext struct {
@@ -35,25 +52,103 @@ type serviceContext struct {
 	fields []log.Field
 	logC   chan<- DaemonLog
 	ic     *intracom.Intracom
+	// throttle is shared by all child contexts of a service, nil when no cpu share is set.
+	throttle   *cpuThrottle
+	pacer      *pacer
+	extractors []FieldExtractor
+	niceness   Niceness
+	// preconditions are evaluated by the manager before the service enters Init.
+	preconditions []Precondition
+	interrupter   *interrupter
+	sampler       *traceSampler
+	results       *serviceResults
+	restartPolicy RestartPolicy
+	generation    *serviceGeneration
+	emergency     func(service string, entry DaemonLog)
+	settings      any
+	stateTimeouts *atomic.Pointer[ManagerStateTimeouts]
+	yieldTurn     func() // hands the turn of the deterministic scheduler back and waits for the next, nil without one.
+	resources     *resourceAccount
+	sockets       *activatedSockets
+	barriers      map[string]*barrier
+	quarantine    *quarantine
+	values        map[any]any // injected with UsingContextValues, read-only once the daemon runs.
+	store         *serviceStore
+	watchBuffer   int            // changes kept for each WatchAllStates receiver, see WithWatchBuffer.
+	labels        *serviceLabels // labels of the services, selected by the filters of WatchAllStates.
+}
+
+// contextConfig carries the daemon owned helpers that are shared by a service context and all of its children.
+type contextConfig struct {
+	throttle      *cpuThrottle
+	pacer         *pacer
+	extractors    []FieldExtractor
+	niceness      Niceness
+	preconditions []Precondition
+	interrupter   *interrupter
+	sampler       *traceSampler
+	results       *serviceResults
+	restartPolicy RestartPolicy
+	generation    *serviceGeneration
+	settings      any // given to the service with WithSettings.
+	stateTimeouts *atomic.Pointer[ManagerStateTimeouts]
+	yieldTurn     func()
+	resources     *resourceAccount  // shared by every lifecycle of the service, see AccountResources.
+	sockets       *activatedSockets // passed by systemd socket activation, nil without.
+	barriers      map[string]*barrier
+	quarantine    *quarantine // holds the service in StateQuarantined once it is flapping, nil without a policy.
+	values        map[any]any
+	store         *serviceStore // values kept by the service with Set, a new store is created when nil.
+	watchBuffer   int
+	labels        *serviceLabels
+	// emergency writes messages at LevelCritical or above synchronously, nil sends them to the log channel.
+	emergency func(service string, entry DaemonLog)
 }
 
 // newServiceWithCancel produces a new cancellable ServiceContext with the given name and fields.
 // func newServiceContextWithCancel(parent context.Context, name string, logC chan<- DaemonLog, icStates intracom.Topic[ServiceStates]) (ServiceContext, context.CancelFunc) {
-func newServiceContextWithCancel(parent context.Context, name string, logC chan<- DaemonLog, ic *intracom.Intracom) (ServiceContext, context.CancelFunc) {
+func newServiceContextWithCancel(parent context.Context, name string, logC chan<- DaemonLog, ic *intracom.Intracom, conf contextConfig) (ServiceContext, context.CancelFunc) {
 	ctx, cancel := context.WithCancel(parent)
 
+	store := conf.store
+	if store == nil {
+		store = &serviceStore{}
+	}
+
 	fields := []log.Field{}
 	if name != "" {
 		fields = append(fields, log.String("service", name))
 	}
 
 	return &serviceContext{
-		Context: ctx,
-		name:    name,
-		fqcn:    name,
-		fields:  fields,
-		logC:    logC,
-		ic:      ic,
+		Context:       ctx,
+		name:          name,
+		fqcn:          name,
+		fields:        fields,
+		logC:          logC,
+		ic:            ic,
+		throttle:      conf.throttle,
+		pacer:         conf.pacer,
+		extractors:    conf.extractors,
+		niceness:      conf.niceness,
+		preconditions: conf.preconditions,
+		interrupter:   conf.interrupter,
+		sampler:       conf.sampler,
+		results:       conf.results,
+		restartPolicy: conf.restartPolicy,
+		generation:    conf.generation,
+		emergency:     conf.emergency,
+		settings:      conf.settings,
+		stateTimeouts: conf.stateTimeouts,
+		yieldTurn:     conf.yieldTurn,
+		resources:     conf.resources,
+		sockets:       conf.sockets,
+		barriers:      conf.barriers,
+		quarantine:    conf.quarantine,
+		values:        conf.values,
+		store:         store,
+		watchBuffer:   conf.watchBuffer,
+		labels:        conf.labels,
 	}, cancel
 }
 
@@ -90,12 +185,114 @@ func (sc *serviceContext) Name() string {
 	return sc.name
 }
 
+// ShutdownDeadline returns the time by which the service should finish its cleanup once the daemon
+// has begun shutting down, so runners can budget their work (e.g. flush only what fits before the deadline).
+// ok is false while the daemon is running or when no shutdown grace period was configured.
+func (sc *serviceContext) ShutdownDeadline() (time.Time, bool) {
+	return ShutdownDeadline(sc.Context)
+}
+
+// Settings returns the settings the service was given with WithSettings, nil without any.
+// SettingsOf returns them as the type the runner expects.
+func (sc *serviceContext) Settings() any {
+	return sc.settings
+}
+
+// Listener returns a listener on the socket named name passed to the daemon by systemd socket activation,
+// see UsingSocketActivation, or ErrListenerNotFound. The socket stays open once the listener is closed, so
+// every lifecycle of the service can ask for it again.
+func (sc *serviceContext) Listener(name string) (net.Listener, error) {
+	return sc.sockets.listener(name)
+}
+
+// SettingsOf returns the settings of the service as a T, ok is false when the service was given none
+// or settings of another type.
+func SettingsOf[T any](sctx ServiceContext) (settings T, ok bool) {
+	settings, ok = sctx.Settings().(T)
+	return settings, ok
+}
+
+// Checkpoint marks a cooperative point in a processing loop of the service.
+// If the service was given a cpu share and has exceeded it, Checkpoint blocks
+// until the service is allowed to continue or the context is done.
+// Without a cpu share Checkpoint returns immediately.
+func (sc *serviceContext) Checkpoint() {
+	sc.throttle.checkpoint(sc.Context)
+	sc.yieldScheduler()
+}
+
+// Yield is meant to be called by tight processing loops on every iteration.
+// It honors the cpu share of the service and the pacing policy of the daemon,
+// which can be tightened at runtime when the host is under load.
+func (sc *serviceContext) Yield() {
+	sc.throttle.checkpoint(sc.Context)
+	sc.pacer.yield(sc.Context)
+	sc.yieldScheduler()
+}
+
+// Throttle blocks long enough to limit the calling loop to rate iterations per second.
+// The rate is scaled down by the rate factor of the daemon pacing policy, if one is set.
+// A rate of 0 or less behaves like Yield.
+func (sc *serviceContext) Throttle(rate float64) {
+	sc.throttle.checkpoint(sc.Context)
+	sc.pacer.throttle(sc.Context, rate)
+	sc.yieldScheduler()
+}
+
+// WaitBarrier waits at the barrier until every participant is waiting at it, see WithBarrier. The service joins
+// under the name of the context, so a child context created with WithName joins as a participant of its own.
+// It returns ErrBarrierNotFound for a barrier that was not added, which includes services running in isolation,
+// or the error of the context once the service is stopped while waiting.
+func (sc *serviceContext) WaitBarrier(name string) error {
+	b, ok := sc.barriers[name]
+	if !ok {
+		return ErrBarrierNotFound
+	}
+
+	sc.Log(log.LevelDebug, "waiting at barrier", log.String("barrier", name))
+	if err := b.wait(sc.Context, sc.name); err != nil {
+		return err
+	}
+	sc.Log(log.LevelDebug, "released from barrier", log.String("barrier", name))
+	return nil
+}
+
+// Tracer returns the tracer of the daemon, see WithTracer, so services record spans of their own under the span
+// of the current lifecycle, which the context carries. Unlike StartSpan, spans are not sampled.
+// Without a tracer the spans started record nothing.
+func (sc *serviceContext) Tracer() Tracer {
+	if sc.sampler == nil || sc.sampler.tracer == nil {
+		return noopTracer{}
+	}
+	return sc.sampler.tracer
+}
+
+// yieldScheduler lets the other services take a turn when the daemon runs with a DeterministicScheduler.
+func (sc *serviceContext) yieldScheduler() {
+	if sc.yieldTurn != nil {
+		sc.yieldTurn()
+	}
+}
+
 func (sc *serviceContext) Log(level log.Level, message string, fields ...log.Field) {
-	sc.logC <- DaemonLog{
+	fields = append(fields, sc.fields...)
+	// extract any fields from values stored in the context chain.
+	for _, extract := range sc.extractors {
+		fields = append(fields, extract(sc.Context)...)
+	}
+
+	entry := DaemonLog{
 		Level:   level,
 		Message: message,
-		Fields:  append(fields, sc.fields...),
+		Fields:  fields,
+	}
+
+	// the most severe messages must not be lost in the log channel if the process dies.
+	if level <= emergencyLevel && sc.emergency != nil {
+		sc.emergency(sc.name, entry)
+		return
 	}
+	sc.logC <- entry
 }
 
 func (sc *serviceContext) Deadline() (deadline time.Time, ok bool) {
@@ -110,8 +307,12 @@ func (sc *serviceContext) Err() error {
 	return sc.Context.Err()
 }
 
+// Value returns the value of key from the context chain or else from the values injected with UsingContextValues.
 func (sc *serviceContext) Value(key interface{}) interface{} {
-	return sc.Context.Value(key)
+	if value := sc.Context.Value(key); value != nil || len(sc.values) == 0 {
+		return value
+	}
+	return sc.values[key]
 }
 
 func (sc *serviceContext) WatchAllServices(action ServiceAction, target State, services ...string) (<-chan ServiceStates, context.CancelFunc) {
@@ -121,17 +322,17 @@ func (sc *serviceContext) WatchAllServices(action ServiceAction, target State, s
 	go func(ctx context.Context) {
 		defer close(ch)
 		// subscribe to the internal states on behalf of the service context given using its "full qualified consumer name" (fqcn).
-		consumer := internalStatesConsumer(action, target, sc.fqcn)
+		consumer := internalStatesConsumer(sc.ic, action, target, sc.fqcn)
 
 		sub, err := intracom.CreateSubscription[ServiceStates](ctx, sc.ic, internalServiceStates, -1, intracom.SubscriberConfig[ServiceStates]{
 			ConsumerGroup: consumer,
-			ErrIfExists:   false,
+			ErrIfExists:   true,
 			BufferSize:    1,
 			BufferPolicy:  intracom.BufferPolicyDropOldest[ServiceStates]{},
 		})
 
 		if err != nil {
-			sc.Log(log.LevelError, "failed to subscribe to internal states: "+err.Error())
+			sc.logWatchError(ctx, err)
 			return
 		}
 		defer intracom.RemoveSubscription[ServiceStates](sc.ic, internalServiceStates, consumer, sub)
@@ -189,16 +390,16 @@ func (sc *serviceContext) WatchAnyServices(action ServiceAction, target State, s
 		defer close(ch)
 
 		// subscribe to the internal states on behalf of the service context given using its "full qualified consumer name" (fqcn).
-		consumer := internalStatesConsumer(action, target, sc.fqcn)
+		consumer := internalStatesConsumer(sc.ic, action, target, sc.fqcn)
 		sub, err := intracom.CreateSubscription[ServiceStates](ctx, sc.ic, internalServiceStates, -1, intracom.SubscriberConfig[ServiceStates]{
 			ConsumerGroup: consumer,
-			ErrIfExists:   false,
+			ErrIfExists:   true,
 			BufferSize:    1,
 			BufferPolicy:  intracom.BufferPolicyDropOldest[ServiceStates]{},
 		})
 
 		if err != nil {
-			sc.Log(log.LevelError, "failed to subscribe to internal states: "+err.Error())
+			sc.logWatchError(ctx, err)
 			return
 		}
 		defer intracom.RemoveSubscription[ServiceStates](sc.ic, internalServiceStates, consumer, sub)
@@ -245,6 +446,11 @@ func (sc *serviceContext) WatchAnyServices(action ServiceAction, target State, s
 	return ch, cancel
 }
 
+// WatchAllStates returns a channel receiving the states of the services selected by filter, first a snapshot
+// of their current states then every change to them, in the order they happened. Each value holds the states of
+// every selected service, values repeating the previous one are not sent. Up to the watch buffer of the daemon,
+// see WithWatchBuffer, changes are kept while the receiver falls behind, past it the oldest are dropped.
+// The channel is closed once cancel is called or the service context is done.
 func (sc *serviceContext) WatchAllStates(filter ServiceFilter) (<-chan ServiceStates, context.CancelFunc) {
 	ch := make(chan ServiceStates, 1)
 	watchCtx, cancel := context.WithCancel(sc)
@@ -252,20 +458,32 @@ func (sc *serviceContext) WatchAllStates(filter ServiceFilter) (<-chan ServiceSt
 	go func(ctx context.Context) {
 		defer close(ch)
 		// subscribe to the internal states on behalf of the service context given using its "full qualified consumer name" (fqcn).
-		consumer := internalAllStatesConsumer(sc.fqcn)
+		// a new subscription first receives the last states published, or else the first ones, the snapshot.
+		consumer := internalAllStatesConsumer(sc.ic, sc.fqcn)
+		// the topic only delivers the states changing the view of the selected services, compared with the view
+		// of the states it saw last, so the watcher is not woken by the changes of the other services.
+		var seen ServiceStates
+		var seenAny bool
 		sub, err := intracom.CreateSubscription[ServiceStates](ctx, sc.ic, internalServiceStates, -1, intracom.SubscriberConfig[ServiceStates]{
 			ConsumerGroup: consumer,
-			ErrIfExists:   false,
-			BufferSize:    1,
+			ErrIfExists:   true,
+			BufferSize:    max(sc.watchBuffer, 1),
 			BufferPolicy:  intracom.BufferPolicyDropOldest[ServiceStates]{},
+			Filter: func(states ServiceStates) bool {
+				selected := filter.apply(states, sc.labels)
+				changed := !seenAny || !maps.Equal(selected, seen)
+				seen, seenAny = selected, true
+				return changed
+			},
 		})
 
 		if err != nil {
-			sc.Log(log.LevelError, "failed to subscribe to internal states: "+err.Error())
+			sc.logWatchError(ctx, err)
 			return
 		}
 		defer intracom.RemoveSubscription[ServiceStates](sc.ic, internalServiceStates, consumer, sub)
 
+		var last ServiceStates
 		for {
 			select {
 			case <-ctx.Done():
@@ -276,39 +494,17 @@ func (sc *serviceContext) WatchAllStates(filter ServiceFilter) (<-chan ServiceSt
 					return
 				}
 
-				// if no filters are given or mode is set to none, then we just send out all the states we have.
-				if len(filter.Names) == 0 || filter.Mode == None {
-					select {
-					case <-ctx.Done():
-						return
-					case ch <- states:
-						// no filtering applied, send out all the states we have.
-					}
+				// changes the topic let through may still repeat the view last sent once the oldest were dropped.
+				selected := filter.apply(states, sc.labels)
+				if last != nil && maps.Equal(selected, last) {
 					continue
 				}
-
-				// if we have filters, then we need to filter the states we have.
-				filteredInterests := make(ServiceStates, len(filter.Names))
-				for name, state := range states {
-					switch filter.Mode {
-					case Include:
-						// if the FilterSet given contains the service name, then we include it.
-						if _, ok := filter.Names[name]; ok {
-							filteredInterests[name] = state
-						}
-
-					case Exclude:
-						// if the FilterSet given does not contain the service name, then we include it.
-						if _, ok := filter.Names[name]; !ok {
-							filteredInterests[name] = state
-						}
-					}
-				}
+				last = selected
 
 				select {
 				case <-ctx.Done():
 					return
-				case ch <- filteredInterests: // send out the states
+				case ch <- selected: // send out the states
 				}
 			}
 		}
@@ -316,3 +512,12 @@ func (sc *serviceContext) WatchAllStates(filter ServiceFilter) (<-chan ServiceSt
 
 	return ch, cancel
 }
+
+// logWatchError reports a watch that could not subscribe to the states, unless the watch was cancelled
+// first: a watch cancelled once the service returned may run after the daemon stopped reading its logs.
+func (sc *serviceContext) logWatchError(ctx context.Context, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	sc.Log(log.LevelError, "failed to subscribe to internal states: "+err.Error())
+}
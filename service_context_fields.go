@@ -0,0 +1,32 @@
+package rxd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// FieldExtractor pulls log fields out of a context, it is called for every Log call of a ServiceContext
+// so values such as request IDs or tenants stored in the context chain are logged without manual field passing.
+// Extractors should be cheap and return nil when the value is not present.
+type FieldExtractor func(ctx context.Context) []log.Field
+
+// ValueExtractor returns a FieldExtractor that logs the context value stored under key as the given field name.
+func ValueExtractor(key any, field string) FieldExtractor {
+	return func(ctx context.Context) []log.Field {
+		val := ctx.Value(key)
+		if val == nil {
+			return nil
+		}
+
+		switch v := val.(type) {
+		case string:
+			return []log.Field{log.String(field, v)}
+		case fmt.Stringer:
+			return []log.Field{log.String(field, v.String())}
+		default:
+			return []log.Field{log.Any(field, v)}
+		}
+	}
+}
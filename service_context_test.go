@@ -1 +1,371 @@
 package rxd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/intracom"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+type testRequestIDKey struct{}
+
+func TestServiceContext_LogFieldExtractors(t *testing.T) {
+	logC := make(chan DaemonLog, 1)
+	sctx, cancel := newServiceContextWithCancel(context.Background(), "test-service", logC, nil, contextConfig{
+		extractors: []FieldExtractor{ValueExtractor(testRequestIDKey{}, "request_id")},
+	})
+	defer cancel()
+
+	child, childCancel := sctx.WithParent(context.WithValue(sctx, testRequestIDKey{}, "abc-123"))
+	defer childCancel()
+
+	child.Log(log.LevelInfo, "handled request")
+	entry := <-logC
+
+	var found bool
+	for _, field := range entry.Fields {
+		if field.Key == "request_id" && field.Value == "abc-123" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatalf("expected request_id field to be extracted, got %v", entry.Fields)
+	}
+
+	// the parent context does not carry the value, so no field should be added.
+	sctx.Log(log.LevelInfo, "no request")
+	entry = <-logC
+	for _, field := range entry.Fields {
+		if field.Key == "request_id" {
+			t.Fatalf("expected no request_id field, got %v", entry.Fields)
+		}
+	}
+}
+
+func TestServiceContext_ShutdownDeadline(t *testing.T) {
+	parent, parentCancel := context.WithCancel(context.Background())
+	clock := &shutdownClock{grace: time.Second, doneC: parent.Done()}
+	parent = context.WithValue(parent, shutdownDeadlineKey{}, clock)
+
+	sctx, cancel := newServiceContextWithCancel(parent, "test-service", make(chan DaemonLog, 1), nil, contextConfig{})
+	defer cancel()
+
+	if _, ok := sctx.ShutdownDeadline(); ok {
+		t.Fatalf("expected no shutdown deadline while the daemon is running")
+	}
+
+	parentCancel()
+	deadline, ok := sctx.ShutdownDeadline()
+	if !ok {
+		t.Fatalf("expected a shutdown deadline once the daemon is shutting down")
+	}
+
+	if remaining := time.Until(deadline); remaining <= 0 || remaining > time.Second {
+		t.Fatalf("expected deadline within the grace period, got %s remaining", remaining)
+	}
+
+	// the deadline is pinned once set.
+	if again, _ := ShutdownDeadline(sctx); !again.Equal(deadline) {
+		t.Fatalf("expected the same deadline, got %s and %s", deadline, again)
+	}
+}
+
+func TestCaptureStdio(t *testing.T) {
+	logC := make(chan DaemonLog, 2)
+	sctx, cancel := newServiceContextWithCancel(context.Background(), "test-service", logC, nil, contextConfig{})
+	defer cancel()
+
+	release, err := CaptureStdio(sctx)
+	if err != nil {
+		t.Fatalf("error capturing stdio: %s", err)
+	}
+
+	if _, err := CaptureStdio(sctx); err != ErrStdioCaptured {
+		t.Fatalf("expected %s, got %v", ErrStdioCaptured, err)
+	}
+
+	fmt.Fprintln(os.Stdout, "library print")
+	release()
+
+	entry := <-logC
+	if entry.Message != "library print" || entry.Level != log.LevelInfo {
+		t.Fatalf("expected captured stdout line at info level, got %+v", entry)
+	}
+}
+
+func TestServiceContext_Settings(t *testing.T) {
+	type apiSettings struct {
+		Addr string
+	}
+
+	sctx, cancel := newServiceContextWithCancel(context.Background(), "test-service", make(chan DaemonLog, 1), nil, contextConfig{
+		settings: apiSettings{Addr: ":8080"},
+	})
+	defer cancel()
+
+	child, childCancel := sctx.WithName("child")
+	defer childCancel()
+
+	settings, ok := SettingsOf[apiSettings](child)
+	if !ok || settings.Addr != ":8080" {
+		t.Errorf("expected the settings of the service, got %+v", settings)
+	}
+	if _, ok := SettingsOf[*apiSettings](child); ok {
+		t.Errorf("expected settings of another type not to be returned")
+	}
+
+	bare, bareCancel := newServiceContextWithCancel(context.Background(), "test-service", make(chan DaemonLog, 1), nil, contextConfig{})
+	defer bareCancel()
+	if _, ok := SettingsOf[apiSettings](bare); ok {
+		t.Errorf("expected a service without settings to have none")
+	}
+}
+
+func TestServiceContext_Values(t *testing.T) {
+	type poolKey struct{}
+	type flagsKey struct{}
+
+	d := NewDaemon("test-daemon",
+		UsingContextValues(map[any]any{poolKey{}: "pool", flagsKey{}: "old flags"}),
+		UsingContextValues(map[any]any{flagsKey{}: "flags"}),
+	).(*daemon)
+
+	sctx, cancel := newServiceContextWithCancel(context.Background(), "test-service", make(chan DaemonLog, 1), nil, contextConfig{values: d.values})
+	defer cancel()
+
+	named, namedCancel := sctx.WithName("child")
+	defer namedCancel()
+	// WithParent detaches the child from the context chain of the service, the injected values remain.
+	detached, detachedCancel := named.WithFields(log.String("k", "v")).WithParent(context.WithValue(context.Background(), flagsKey{}, "request flags"))
+	defer detachedCancel()
+
+	for _, tc := range []struct {
+		sctx  ServiceContext
+		key   any
+		value any
+	}{
+		{sctx, poolKey{}, "pool"},
+		{sctx, flagsKey{}, "flags"},
+		{named, poolKey{}, "pool"},
+		{detached, poolKey{}, "pool"},
+		{detached, flagsKey{}, "request flags"},
+		{detached, testRequestIDKey{}, nil},
+	} {
+		if value := tc.sctx.Value(tc.key); value != tc.value {
+			t.Errorf("expected %T to hold %v, got %v", tc.key, tc.value, value)
+		}
+	}
+}
+
+func TestServiceContext_PollUntil(t *testing.T) {
+	logC := make(chan DaemonLog, 100)
+	sctx, cancel := newServiceContextWithCancel(context.Background(), "test-service", logC, nil, contextConfig{})
+	defer cancel()
+
+	var calls int
+	err := sctx.PollUntil(time.Millisecond, time.Second, func(ctx context.Context) (bool, error) {
+		calls++
+		return calls == 3, nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("expected the condition to be met on the third call, got %d calls and %v", calls, err)
+	}
+
+	errDown := errors.New("dependency is gone")
+	err = sctx.PollUntil(time.Millisecond, time.Second, func(ctx context.Context) (bool, error) {
+		return false, errDown
+	})
+	if !errors.Is(err, errDown) {
+		t.Errorf("expected the error of the condition to stop polling, got %v", err)
+	}
+
+	started := time.Now()
+	err = sctx.PollUntil(10*time.Millisecond, 50*time.Millisecond, func(ctx context.Context) (bool, error) {
+		return false, nil
+	})
+	if !errors.Is(err, ErrPollTimeout) {
+		t.Errorf("expected %s, got %v", ErrPollTimeout, err)
+	}
+	if elapsed := time.Since(started); elapsed > 500*time.Millisecond {
+		t.Errorf("expected polling to stop at the timeout, took %s", elapsed)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	err = sctx.PollUntil(5*time.Millisecond, 0, func(ctx context.Context) (bool, error) {
+		return false, nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected polling to stop with the service, got %v", err)
+	}
+}
+
+func TestServiceContext_WatchersOfTheSameCondition(t *testing.T) {
+	ic := intracom.New("test-watchers")
+	defer intracom.Close(ic)
+	topic, err := intracom.CreateTopic[ServiceStates](ic, intracom.TopicConfig{Name: internalServiceStates})
+	if err != nil {
+		t.Fatalf("error creating states topic: %s", err)
+	}
+
+	sctx, cancel := newServiceContextWithCancel(context.Background(), "test-service", make(chan DaemonLog, 10), ic, contextConfig{})
+	defer cancel()
+
+	first, cancelFirst := sctx.WatchAllServices(Entered, StateRun, "db")
+	second, cancelSecond := sctx.WatchAllServices(Entered, StateRun, "db")
+	defer cancelSecond()
+
+	deadline := time.Now().Add(time.Second)
+	for len(intracom.Consumers(ic, internalServiceStates)) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected both watchers to subscribe on their own, got %v", intracom.Consumers(ic, internalServiceStates))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// cancelling a watcher must not unsubscribe the other one.
+	cancelFirst()
+	for range first {
+	}
+	topic.PublishChannel() <- ServiceStates{"db": StateRun}
+
+	select {
+	case states, open := <-second:
+		if !open || states["db"] != StateRun {
+			t.Fatalf("expected the second watcher to see db running, got %v (open: %t)", states, open)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected the second watcher to keep watching once the first was cancelled")
+	}
+}
+
+func TestServiceContext_WatchAllStates(t *testing.T) {
+	ic := intracom.New("test-watch-all")
+	defer intracom.Close(ic)
+	topic, err := intracom.CreateTopic[ServiceStates](ic, intracom.TopicConfig{Name: internalServiceStates})
+	if err != nil {
+		t.Fatalf("error creating states topic: %s", err)
+	}
+
+	sctx, cancel := newServiceContextWithCancel(context.Background(), "test-service", make(chan DaemonLog, 10), ic, contextConfig{watchBuffer: 4})
+	defer cancel()
+
+	topic.PublishChannel() <- ServiceStates{"db": StateRun, "api": StateInit}
+
+	all, cancelAll := sctx.WatchAllStates(NoFilter)
+	defer cancelAll()
+	db, cancelDB := sctx.WatchAllStates(NewServiceFilter(Include, "db"))
+	defer cancelDB()
+
+	receive := func(ch <-chan ServiceStates) ServiceStates {
+		t.Helper()
+		select {
+		case states := <-ch:
+			return states
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for states")
+			return nil
+		}
+	}
+
+	// both watchers start from the current states.
+	if states := receive(all); states["db"] != StateRun || states["api"] != StateInit {
+		t.Fatalf("expected the snapshot of every service, got %v", states)
+	}
+	if states := receive(db); len(states) != 1 || states["db"] != StateRun {
+		t.Fatalf("expected the snapshot of db, got %v", states)
+	}
+
+	// changes published while the watchers do not receive are kept within the buffer, in order.
+	changes := []ServiceStates{
+		{"db": StateRun, "api": StateIdle},
+		{"db": StateRun, "api": StateRun},
+		{"db": StateStop, "api": StateRun},
+	}
+	for _, states := range changes {
+		topic.PublishChannel() <- states
+	}
+
+	for i, want := range changes {
+		if got := receive(all); !maps.Equal(got, want) {
+			t.Fatalf("expected change %d to be %v, got %v", i, want, got)
+		}
+	}
+	// db did not change with the first two.
+	if states := receive(db); states["db"] != StateStop {
+		t.Fatalf("expected only the change of db, got %v", states)
+	}
+}
+
+func TestServiceContext_WatchAllStatesSelectors(t *testing.T) {
+	ic := intracom.New("test-watch-selectors")
+	defer intracom.Close(ic)
+	topic, err := intracom.CreateTopic[ServiceStates](ic, intracom.TopicConfig{Name: internalServiceStates})
+	if err != nil {
+		t.Fatalf("error creating states topic: %s", err)
+	}
+
+	labels := newServiceLabels()
+	labels.set("db", map[string]string{"tier": "storage", "zone": "a"})
+	labels.set("cache", map[string]string{"tier": "storage", "zone": "b"})
+	labels.set("api", map[string]string{"tier": "edge"})
+
+	sctx, cancel := newServiceContextWithCancel(context.Background(), "test-service", make(chan DaemonLog, 10), ic, contextConfig{watchBuffer: 4, labels: labels})
+	defer cancel()
+
+	topic.PublishChannel() <- ServiceStates{"db": StateRun, "cache": StateInit, "api": StateRun}
+
+	storage, cancelStorage := sctx.WatchAllStates(NewLabelFilter(Include, map[string]string{"tier": "storage"}))
+	defer cancelStorage()
+	notEdge, cancelNotEdge := sctx.WatchAllStates(NewLabelFilter(Exclude, map[string]string{"tier": "edge"}))
+	defer cancelNotEdge()
+	down, cancelDown := sctx.WatchAllStates(NewPredicateFilter(func(name string, state State) bool {
+		return state != StateRun
+	}))
+	defer cancelDown()
+
+	receive := func(ch <-chan ServiceStates) ServiceStates {
+		t.Helper()
+		select {
+		case states := <-ch:
+			return states
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for states")
+			return nil
+		}
+	}
+
+	want := ServiceStates{"db": StateRun, "cache": StateInit}
+	if states := receive(storage); !maps.Equal(states, want) {
+		t.Fatalf("expected the snapshot of the storage services %v, got %v", want, states)
+	}
+	if states := receive(notEdge); !maps.Equal(states, want) {
+		t.Fatalf("expected the snapshot of the services not on the edge %v, got %v", want, states)
+	}
+	if states := receive(down); !maps.Equal(states, ServiceStates{"cache": StateInit}) {
+		t.Fatalf("expected the snapshot of the services not running, got %v", states)
+	}
+
+	// the changes of api are not delivered to the storage watcher, the next value it receives is that of cache.
+	topic.PublishChannel() <- ServiceStates{"db": StateRun, "cache": StateInit, "api": StateStop}
+	topic.PublishChannel() <- ServiceStates{"db": StateRun, "cache": StateRun, "api": StateStop}
+
+	if states := receive(storage); !maps.Equal(states, ServiceStates{"db": StateRun, "cache": StateRun}) {
+		t.Fatalf("expected only the change of cache, got %v", states)
+	}
+	if states := receive(down); !maps.Equal(states, ServiceStates{"cache": StateInit, "api": StateStop}) {
+		t.Fatalf("expected api to be down, got %v", states)
+	}
+	if states := receive(down); !maps.Equal(states, ServiceStates{"api": StateStop}) {
+		t.Fatalf("expected only api to be down, got %v", states)
+	}
+}
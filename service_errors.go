@@ -0,0 +1,136 @@
+package rxd
+
+import (
+	"sync"
+	"time"
+)
+
+// LifecycleError is the most recent error a lifecycle of a service returned, see Daemon.ServiceErrors.
+type LifecycleError struct {
+	Service string
+	State   State     // the lifecycle that returned the error.
+	Time    time.Time // when the lifecycle returned.
+	Err     error
+}
+
+func (e LifecycleError) Error() string {
+	return e.Service + ": " + e.State.String() + ": " + e.Err.Error()
+}
+
+func (e LifecycleError) Unwrap() error {
+	return e.Err
+}
+
+// lastErrors holds the most recent lifecycle error of every service.
+type lastErrors struct {
+	mu   sync.RWMutex
+	errs map[string]LifecycleError
+	// services whose most recent error was not added to their state history yet.
+	unseen map[string]bool
+}
+
+func newLastErrors() *lastErrors {
+	return &lastErrors{errs: make(map[string]LifecycleError), unseen: make(map[string]bool)}
+}
+
+func (e *lastErrors) record(service string, state State, err error) {
+	e.mu.Lock()
+	e.errs[service] = LifecycleError{Service: service, State: state, Time: time.Now(), Err: err}
+	e.unseen[service] = true
+	e.mu.Unlock()
+}
+
+// take returns the most recent error of the service if the lifecycle of state returned it
+// and it was not taken yet, the lifecycle returns before the service leaves its state.
+func (e *lastErrors) take(service string, state State) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	err, ok := e.errs[service]
+	if !ok || !e.unseen[service] || err.State != state {
+		return nil
+	}
+	delete(e.unseen, service)
+	return err.Err
+}
+
+func (e *lastErrors) remove(service string) {
+	e.mu.Lock()
+	delete(e.errs, service)
+	delete(e.unseen, service)
+	e.mu.Unlock()
+}
+
+func (e *lastErrors) snapshot() map[string]LifecycleError {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	errs := make(map[string]LifecycleError, len(e.errs))
+	for name, err := range e.errs {
+		errs[name] = err
+	}
+	return errs
+}
+
+// messages returns the most recent error of every service as text, for the admin endpoints and the status file.
+func (e *lastErrors) messages() map[string]string {
+	if e == nil {
+		return nil
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	msgs := make(map[string]string, len(e.errs))
+	for name, err := range e.errs {
+		msgs[name] = err.State.String() + ": " + err.Err.Error()
+	}
+	return msgs
+}
+
+// ServiceErrors returns the most recent error returned by a lifecycle of every service that failed one,
+// as a LifecycleError, so the reason a service keeps failing Init can be found without the logs.
+// The error is kept once the service recovers, its Time tells when it happened.
+// Errors of isolated services are only reported through their logs.
+func (d *daemon) ServiceErrors() map[string]error {
+	snapshot := d.lastErrors.snapshot()
+	errs := make(map[string]error, len(snapshot))
+	for name, err := range snapshot {
+		errs[name] = err
+	}
+	return errs
+}
+
+// errorsRunner records the errors returned by the lifecycles of the runner, whichever manager runs the service.
+type errorsRunner struct {
+	runner  ServiceRunner
+	service string
+	errs    *lastErrors
+}
+
+func (r errorsRunner) Init(sctx ServiceContext) error {
+	return r.record(StateInit, r.runner.Init(sctx))
+}
+
+func (r errorsRunner) Idle(sctx ServiceContext) error {
+	return r.record(StateIdle, r.runner.Idle(sctx))
+}
+
+func (r errorsRunner) Run(sctx ServiceContext) error {
+	return r.record(StateRun, r.runner.Run(sctx))
+}
+
+func (r errorsRunner) Stop(sctx ServiceContext) error {
+	return r.record(StateStop, r.runner.Stop(sctx))
+}
+
+func (r errorsRunner) Reload(sctx ServiceContext) error {
+	return r.record(StateReload, reloadRunner(sctx, r.runner))
+}
+
+func (r errorsRunner) record(state State, err error) error {
+	if err != nil {
+		r.errs.record(r.service, state, err)
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+package rxd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+func TestDaemon_ServiceErrors(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	statusFile := filepath.Join(t.TempDir(), "status.json")
+	d := NewDaemon("test-daemon",
+		WithStatusFile(statusFile),
+		WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+		WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+	)
+
+	journal := &recordingJournal{}
+	errRun := errors.New("connection refused")
+	err := d.AddServices(
+		NewService("failing", &failingService{recordingService{name: "failing", journal: journal}, errRun}, WithManager(NewRunOnceManager(0))),
+		NewService("passing", &failingService{recordingService{name: "passing", journal: journal}, nil}, WithManager(NewRunOnceManager(0))),
+	)
+	if err != nil {
+		t.Fatalf("error adding services: %s", err)
+	}
+
+	before := time.Now()
+	if err := d.Start(ctx); !errors.Is(err, errRun) {
+		t.Fatalf("expected the run error of the failing service, got %v", err)
+	}
+
+	errs := d.ServiceErrors()
+	if len(errs) != 1 {
+		t.Fatalf("expected only the failing service to have an error, got %v", errs)
+	}
+
+	var lifecycleErr LifecycleError
+	if !errors.As(errs["failing"], &lifecycleErr) || !errors.Is(errs["failing"], errRun) {
+		t.Fatalf("expected a lifecycle error wrapping the run error, got %v", errs["failing"])
+	}
+	if lifecycleErr.State != StateRun || lifecycleErr.Time.Before(before) {
+		t.Errorf("expected the error to be from the run lifecycle of this start, got %+v", lifecycleErr)
+	}
+
+	data, err := os.ReadFile(statusFile)
+	if err != nil {
+		t.Fatalf("error reading status file: %s", err)
+	}
+
+	var report StatusReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("error decoding status file: %s", err)
+	}
+	if report.Errors["failing"] != "run: connection refused" {
+		t.Errorf("expected the status file to report the run error, got %s", data)
+	}
+}
@@ -0,0 +1,67 @@
+package rxd
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// expiringRunner counts the Run lifecycles of the runner and expires the service once it ran maxRuns times.
+type expiringRunner struct {
+	runner  ServiceRunner
+	maxRuns int
+	runs    *atomic.Int64
+	expire  func(reason string)
+}
+
+func (r expiringRunner) Init(sctx ServiceContext) error {
+	return r.runner.Init(sctx)
+}
+
+func (r expiringRunner) Idle(sctx ServiceContext) error {
+	return r.runner.Idle(sctx)
+}
+
+func (r expiringRunner) Run(sctx ServiceContext) error {
+	err := r.runner.Run(sctx)
+	if r.runs.Add(1) == int64(r.maxRuns) {
+		r.expire("max runs")
+	}
+	return err
+}
+
+func (r expiringRunner) Stop(sctx ServiceContext) error {
+	return r.runner.Stop(sctx)
+}
+
+func (r expiringRunner) Reload(sctx ServiceContext) error {
+	return reloadRunner(sctx, r.runner)
+}
+
+// expireAfter removes the service once ttl elapsed, the returned func stops the timer.
+func (d *daemon) expireAfter(name string, run *serviceRun, ttl time.Duration) func() bool {
+	timer := time.AfterFunc(ttl, func() {
+		d.expireService(name, run, "ttl")
+	})
+	return timer.Stop
+}
+
+// expireService removes the service whose ttl or max runs were reached, unless it was started again since.
+// It is removed from a routine of its own, removing a service waits for its routine to exit.
+func (d *daemon) expireService(name string, run *serviceRun, reason string) {
+	d.mu.RLock()
+	current := d.runs[name] == run
+	d.mu.RUnlock()
+	if !current {
+		return
+	}
+
+	nameField := log.String("rxd", d.name)
+	d.internalLogger.Log(log.LevelInfo, "service expired, removing it", log.String("service_name", name), log.String("reason", reason), nameField)
+	go func() {
+		if err := d.RemoveService(name); err != nil {
+			d.internalLogger.Log(log.LevelError, "error removing expired service", log.String("service_name", name), log.Error("error", err), nameField)
+		}
+	}()
+}
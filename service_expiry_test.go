@@ -0,0 +1,70 @@
+package rxd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// briefService returns from Run right away.
+type briefService struct {
+	recordingService
+}
+
+func (b *briefService) Run(sctx ServiceContext) error {
+	b.journal.record(b.name + ":run")
+	return nil
+}
+
+func TestDaemon_ServiceExpiry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	journal := &recordingJournal{}
+	d := NewDaemon("test-daemon", WithServiceLogger(log.NewLogger(log.LevelError, newTestLogger()))).(*daemon)
+	services := []Service{
+		NewService("resident", &recordingService{name: "resident", journal: journal}),
+		NewService("task", &briefService{recordingService{name: "task", journal: journal}}, WithMaxRuns(3), WithManager(RunContinuousManager{StartupDelay: time.Millisecond, DefaultDelay: time.Millisecond, StateTimeouts: ManagerStateTimeouts{}})),
+		NewService("lease", &recordingService{name: "lease", journal: journal}, WithTTL(50*time.Millisecond)),
+	}
+	if err := d.AddServices(services...); err != nil {
+		t.Fatalf("error adding services: %s", err)
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	registered := func(name string) bool {
+		d.mu.RLock()
+		defer d.mu.RUnlock()
+		_, ok := d.services[name]
+		return ok
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for registered("task") || registered("lease") {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the expired services to be removed, task: %t lease: %t", registered("task"), registered("lease"))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if runs := journal.count("task:run"); runs != 3 {
+		t.Errorf("expected task to be removed after 3 runs, got %d", runs)
+	}
+	if journal.index("lease:stop") < 0 {
+		t.Errorf("expected lease to be stopped before it was removed")
+	}
+	if !registered("resident") {
+		t.Errorf("expected resident to keep running")
+	}
+
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+}
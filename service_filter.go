@@ -10,9 +10,17 @@ const (
 	Exclude
 )
 
+// ServiceFilter selects the services whose states are watched. With Include only the services matching the
+// filter are kept, with Exclude only the others. A service matches when it is named in Names or carries every
+// label of Labels, see WithLabels. Predicate, if set, further keeps only the services it returns true for.
+//
+// Filters are applied by the states topic before changes are delivered, a watcher is not woken for changes
+// of the services it filters out. Predicate is called from the routine of the topic, it must not block.
 type ServiceFilter struct {
-	Mode  FilterMode
-	Names map[string]struct{}
+	Mode      FilterMode
+	Names     map[string]struct{}
+	Labels    map[string]string
+	Predicate func(name string, state State) bool
 }
 
 func NewServiceFilter(mode FilterMode, names ...string) ServiceFilter {
@@ -23,3 +31,41 @@ func NewServiceFilter(mode FilterMode, names ...string) ServiceFilter {
 
 	return ServiceFilter{Mode: mode, Names: set}
 }
+
+// NewLabelFilter returns a filter matching the services carrying every label given, see WithLabels.
+func NewLabelFilter(mode FilterMode, labels map[string]string) ServiceFilter {
+	return ServiceFilter{Mode: mode, Labels: labels}
+}
+
+// NewPredicateFilter returns a filter keeping the services predicate returns true for, e.g. those not running.
+func NewPredicateFilter(predicate func(name string, state State) bool) ServiceFilter {
+	return ServiceFilter{Mode: None, Predicate: predicate}
+}
+
+// apply returns the states of the services selected by the filter, states itself without a filter.
+func (f ServiceFilter) apply(states ServiceStates, labels *serviceLabels) ServiceStates {
+	selecting := f.Mode != None && (len(f.Names) > 0 || len(f.Labels) > 0)
+	if !selecting && f.Predicate == nil {
+		return states
+	}
+
+	filtered := make(ServiceStates)
+	for name, state := range states {
+		// Include keeps the matching services, Exclude keeps the others.
+		if selecting && (f.Mode == Include) != f.matches(name, labels) {
+			continue
+		}
+		if f.Predicate != nil && !f.Predicate(name, state) {
+			continue
+		}
+		filtered[name] = state
+	}
+	return filtered
+}
+
+func (f ServiceFilter) matches(name string, labels *serviceLabels) bool {
+	if _, named := f.Names[name]; named {
+		return true
+	}
+	return len(f.Labels) > 0 && labels.match(name, f.Labels)
+}
@@ -0,0 +1,273 @@
+package rxd
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/ambitiousfew/rxd/intracom"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// ServiceGroup is a set of related services that can be started, stopped and watched as a unit.
+type ServiceGroup struct {
+	Name     string
+	Services []Service
+}
+
+// NewServiceGroup creates a group of services, add it to the daemon with AddServiceGroup.
+func NewServiceGroup(name string, services ...Service) ServiceGroup {
+	return ServiceGroup{Name: name, Services: services}
+}
+
+// members returns the names of the services of the group.
+func (g ServiceGroup) members() []string {
+	names := make([]string, 0, len(g.Services))
+	for _, service := range g.Services {
+		names = append(names, service.Name)
+	}
+	return names
+}
+
+// GroupState is derived from the states of the members of a group.
+type GroupState uint8
+
+const (
+	GroupStopped  GroupState = iota // every member has exited.
+	GroupStarting                   // members are initializing, none has exited or is stopping.
+	GroupRunning                    // every member is running.
+	GroupStopping                   // a member is stopping.
+	GroupDegraded                   // some members are running while others have exited.
+)
+
+func (s GroupState) String() string {
+	switch s {
+	case GroupStopped:
+		return "stopped"
+	case GroupStarting:
+		return "starting"
+	case GroupRunning:
+		return "running"
+	case GroupStopping:
+		return "stopping"
+	case GroupDegraded:
+		return "degraded"
+	default:
+		return "unknown"
+	}
+}
+
+// groupState derives the state of a group from the states of its members, members without a state are ignored.
+func groupState(states ServiceStates, members []string) GroupState {
+	var total, running, exited, starting int
+	for _, name := range members {
+		state, ok := states[name]
+		if !ok {
+			continue
+		}
+		total++
+		switch state {
+		case StateStop:
+			return GroupStopping
+		case StateRun, StateReload:
+			running++
+		case StateExit, StatePaused:
+			exited++
+		default:
+			starting++
+		}
+	}
+
+	switch {
+	case running == total && total > 0:
+		return GroupRunning
+	case exited == total:
+		return GroupStopped
+	case starting > 0 && exited == 0:
+		return GroupStarting
+	case running > 0:
+		return GroupDegraded
+	default:
+		// some members exited while the others are still initializing.
+		return GroupStarting
+	}
+}
+
+// AddServiceGroup adds the services of the group to the daemon and registers the group.
+// If the daemon is already started, the services are launched right away.
+func (d *daemon) AddServiceGroup(group ServiceGroup) error {
+	if group.Name == "" {
+		return ErrNoGroupName
+	}
+
+	d.mu.Lock()
+	if _, exists := d.groups[group.Name]; exists {
+		d.mu.Unlock()
+		return ErrDuplicateGroupName
+	}
+	for _, name := range group.members() {
+		if _, exists := d.services[name]; exists {
+			d.mu.Unlock()
+			return ErrDuplicateServiceName
+		}
+	}
+	// reserved before the services are added so a concurrent call cannot take the same name.
+	d.groups[group.Name] = group.members()
+	d.mu.Unlock()
+
+	if err := d.AddServices(group.Services...); err != nil {
+		d.mu.Lock()
+		delete(d.groups, group.Name)
+		d.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// StartGroup starts every member of the group that is not running, members wait for their dependencies as usual.
+func (d *daemon) StartGroup(name string) error {
+	d.mu.RLock()
+	members, ok := d.groups[name]
+	d.mu.RUnlock()
+	if !ok {
+		return ErrGroupNotFound
+	}
+
+	d.internalLogger.Log(log.LevelInfo, "starting service group", log.String("group", name), log.String("rxd", d.name))
+	var errs []error
+	for _, member := range members {
+		if err := d.startService(member); err != nil && !errors.Is(err, ErrServiceRunning) && !errors.Is(err, ErrServiceNotFound) {
+			errs = append(errs, ServiceError{Service: member, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// StopGroup stops every running member of the group and waits until they have exited their lifecycles,
+// members stop after the members depending on them. The services stay registered and can be started
+// again with StartGroup. Running services outside of the group must not depend on its members.
+// NOTE: once the last running service exits the daemon shuts down, just as when all services exit on their own.
+func (d *daemon) StopGroup(name string) error {
+	d.mu.RLock()
+	members, ok := d.groups[name]
+	if !ok {
+		d.mu.RUnlock()
+		return ErrGroupNotFound
+	}
+	if d.rt == nil {
+		d.mu.RUnlock()
+		return ErrDaemonNotStarted
+	}
+
+	runs := make(map[string]*serviceRun, len(members))
+	for _, member := range members {
+		if run, ok := d.runs[member]; ok && !run.exited() {
+			runs[member] = run
+		}
+	}
+
+	// members wait for the running members depending on them to exit first.
+	waitFor := make(map[string][]chan struct{}, len(runs))
+	for other, conf := range d.configs {
+		run, running := d.runs[other]
+		if !running || run.exited() {
+			continue
+		}
+		for _, dep := range conf.dependsOn {
+			if _, stopping := runs[dep]; !stopping {
+				continue
+			}
+			if !slices.Contains(members, other) {
+				d.mu.RUnlock()
+				return ErrServiceHasDependents
+			}
+			waitFor[dep] = append(waitFor[dep], run.doneC)
+		}
+	}
+	d.mu.RUnlock()
+
+	d.internalLogger.Log(log.LevelInfo, "stopping service group", log.String("group", name), log.String("rxd", d.name))
+	var wg sync.WaitGroup
+	for member, run := range runs {
+		wg.Add(1)
+		go func(run *serviceRun, dependents []chan struct{}) {
+			defer wg.Done()
+			for _, doneC := range dependents {
+				<-doneC
+			}
+			run.cancel()
+			<-run.doneC
+		}(run, waitFor[member])
+	}
+	wg.Wait()
+	return nil
+}
+
+// GroupStates returns the current state of every service group.
+func (d *daemon) GroupStates() map[string]GroupState {
+	states := d.readiness.current()
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	groups := make(map[string]GroupState, len(d.groups))
+	for name, members := range d.groups {
+		groups[name] = groupState(states, members)
+	}
+	return groups
+}
+
+// WatchGroup returns a channel receiving the state of the group each time it changes, starting with its
+// current state. The channel is closed once the context is done.
+func WatchGroup(sctx ServiceContext, group ServiceGroup) (<-chan GroupState, context.CancelFunc) {
+	ch := make(chan GroupState, 1)
+	sc, ok := sctx.(*serviceContext)
+	if !ok {
+		close(ch)
+		return ch, func() {}
+	}
+
+	members := group.members()
+	watchCtx, cancel := context.WithCancel(sc)
+	go func(ctx context.Context) {
+		defer close(ch)
+
+		consumer := intracom.ConsumerID(sc.ic, prefix+".group."+group.Name+"."+sc.fqcn)
+		sub, err := intracom.CreateSubscription[ServiceStates](ctx, sc.ic, internalServiceStates, -1, intracom.SubscriberConfig[ServiceStates]{
+			ConsumerGroup: consumer,
+			ErrIfExists:   true,
+			BufferSize:    1,
+			BufferPolicy:  intracom.BufferPolicyDropOldest[ServiceStates]{},
+		})
+		if err != nil {
+			sc.Log(log.LevelError, "failed to subscribe to internal states: "+err.Error())
+			return
+		}
+		defer intracom.RemoveSubscription[ServiceStates](sc.ic, internalServiceStates, consumer, sub)
+
+		var last GroupState
+		var sent bool
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case states, open := <-sub:
+				if !open {
+					return
+				}
+				state := groupState(states, members)
+				if sent && state == last {
+					continue
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case ch <- state:
+					last, sent = state, true
+				}
+			}
+		}
+	}(watchCtx)
+
+	return ch, cancel
+}
@@ -0,0 +1,107 @@
+package rxd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+func TestGroupState(t *testing.T) {
+	members := []string{"a", "b"}
+	tests := []struct {
+		name   string
+		states ServiceStates
+		want   GroupState
+	}{
+		{"all running", ServiceStates{"a": StateRun, "b": StateReload}, GroupRunning},
+		{"all exited", ServiceStates{"a": StateExit, "b": StateExit}, GroupStopped},
+		{"initializing", ServiceStates{"a": StateInit, "b": StateRun}, GroupStarting},
+		{"stopping", ServiceStates{"a": StateStop, "b": StateRun}, GroupStopping},
+		{"degraded", ServiceStates{"a": StateExit, "b": StateRun}, GroupDegraded},
+		{"removed member", ServiceStates{"a": StateRun, "c": StateExit}, GroupRunning},
+		{"paused", ServiceStates{"a": StatePaused, "b": StateExit}, GroupStopped},
+		{"no members", ServiceStates{}, GroupStopped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := groupState(tt.states, members); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDaemon_ServiceGroups(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	journal := &recordingJournal{}
+	service := func(name string, opts ...ServiceOption) Service {
+		return NewService(name, &recordingService{name: name, journal: journal}, opts...)
+	}
+
+	d := NewDaemon("test-daemon", WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())), WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())))
+	if err := d.AddServiceGroup(NewServiceGroup("ingest", service("reader"), service("parser", WithDependsOn("reader")))); err != nil {
+		t.Fatalf("error adding group: %s", err)
+	}
+	if err := d.AddServiceGroup(NewServiceGroup("api", service("http"))); err != nil {
+		t.Fatalf("error adding group: %s", err)
+	}
+	if err := d.AddServiceGroup(NewServiceGroup("api")); err != ErrDuplicateGroupName {
+		t.Errorf("expected %s, got %v", ErrDuplicateGroupName, err)
+	}
+	if err := d.AddServiceGroup(NewServiceGroup("other", service("http"))); err != ErrDuplicateServiceName {
+		t.Errorf("expected %s, got %v", ErrDuplicateServiceName, err)
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	awaitGroup := func(name string, want GroupState) {
+		t.Helper()
+		for d.GroupStates()[name] != want {
+			select {
+			case <-ctx.Done():
+				t.Fatalf("timed out waiting for group %s to be %s, got %v", name, want, d.GroupStates())
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}
+
+	awaitGroup("ingest", GroupRunning)
+	awaitGroup("api", GroupRunning)
+
+	if err := d.StopGroup("ingest"); err != nil {
+		t.Fatalf("error stopping group: %s", err)
+	}
+	awaitGroup("ingest", GroupStopped)
+	if got := d.GroupStates()["api"]; got != GroupRunning {
+		t.Errorf("expected api to keep running, got %s", got)
+	}
+	// the dependent stops before its dependency.
+	if journal.index("parser:stop") > journal.index("reader:stop") {
+		t.Errorf("expected parser to stop before reader, got %v", journal.entries)
+	}
+
+	if err := d.StartGroup("ingest"); err != nil {
+		t.Fatalf("error starting group: %s", err)
+	}
+	awaitGroup("ingest", GroupRunning)
+	if journal.count("parser:init") != 2 || journal.count("reader:init") != 2 {
+		t.Errorf("expected the ingest services to start again, got %v", journal.entries)
+	}
+
+	if err := d.StopGroup("unknown"); err != ErrGroupNotFound {
+		t.Errorf("expected %s, got %v", ErrGroupNotFound, err)
+	}
+
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+}
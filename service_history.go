@@ -0,0 +1,114 @@
+package rxd
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultHistorySize is the number of state changes kept per service unless set with WithStateHistory.
+const defaultHistorySize = 32
+
+// StateChange is a state a service entered, see Daemon.History.
+type StateChange struct {
+	State State     // the state entered.
+	Time  time.Time // when the states watcher received the change.
+	Err   error     // error returned by the lifecycle of the state left, if any.
+}
+
+// stateHistory holds the most recent state changes of every service in a ring buffer per service.
+type stateHistory struct {
+	mu    sync.RWMutex
+	size  int
+	rings map[string]*historyRing
+}
+
+// historyRing keeps the last changes of a service, next is where the following change is written.
+type historyRing struct {
+	changes []StateChange
+	next    int
+	full    bool
+}
+
+func newStateHistory(size int) *stateHistory {
+	return &stateHistory{size: size, rings: make(map[string]*historyRing)}
+}
+
+// record adds a state change of the service, overwriting its oldest change once its ring is full.
+func (h *stateHistory) record(service string, change StateChange) {
+	if h == nil || h.size <= 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ring, ok := h.rings[service]
+	if !ok {
+		ring = &historyRing{changes: make([]StateChange, h.size)}
+		h.rings[service] = ring
+	}
+	ring.changes[ring.next] = change
+	ring.next = (ring.next + 1) % h.size
+	ring.full = ring.full || ring.next == 0
+}
+
+func (h *stateHistory) remove(service string) {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	delete(h.rings, service)
+	h.mu.Unlock()
+}
+
+// changes returns the changes of the service from the oldest to the most recent.
+func (h *stateHistory) changes(service string) []StateChange {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	ring, ok := h.rings[service]
+	if !ok {
+		return nil
+	}
+	if !ring.full {
+		return append([]StateChange(nil), ring.changes[:ring.next]...)
+	}
+	changes := make([]StateChange, 0, h.size)
+	changes = append(changes, ring.changes[ring.next:]...)
+	return append(changes, ring.changes[:ring.next]...)
+}
+
+func (h *stateHistory) services() []string {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	names := make([]string, 0, len(h.rings))
+	for name := range h.rings {
+		names = append(names, name)
+	}
+	return names
+}
+
+// recordTransition adds the state the service entered to its history, along with the error returned
+// by the lifecycle of the state it left, which is returned. Only called by the states watcher routine.
+func (d *daemon) recordTransition(service string, from, to State) error {
+	err := d.lastErrors.take(service, from)
+	d.history.record(service, StateChange{State: to, Time: time.Now(), Err: err})
+	return err
+}
+
+// History returns the last state changes of the service from the oldest to the most recent, see WithStateHistory,
+// so a service flapping between states can be seen after the fact. It returns nil for unknown services.
+// Errors of isolated services are only reported through their logs.
+func (d *daemon) History(service string) []StateChange {
+	return d.history.changes(service)
+}
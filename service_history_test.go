@@ -0,0 +1,80 @@
+package rxd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+func TestStateHistory_Ring(t *testing.T) {
+	h := newStateHistory(3)
+	for _, state := range []State{StateInit, StateIdle, StateRun, StateStop, StateExit} {
+		h.record("flapping", StateChange{State: state})
+	}
+
+	changes := h.changes("flapping")
+	if len(changes) != 3 || changes[0].State != StateRun || changes[1].State != StateStop || changes[2].State != StateExit {
+		t.Fatalf("expected the last 3 changes from the oldest, got %v", changes)
+	}
+
+	h.remove("flapping")
+	if changes := h.changes("flapping"); changes != nil {
+		t.Errorf("expected the history of a removed service to be dropped, got %v", changes)
+	}
+
+	disabled := newStateHistory(0)
+	disabled.record("none", StateChange{State: StateInit})
+	if changes := disabled.changes("none"); changes != nil {
+		t.Errorf("expected no history with a size of 0, got %v", changes)
+	}
+}
+
+func TestDaemon_History(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	d := NewDaemon("test-daemon", WithServiceLogger(log.NewLogger(log.LevelError, newTestLogger()))).(*daemon)
+	journal := &recordingJournal{}
+	errRun := errors.New("connection refused")
+	if err := d.AddService(NewService("failing", &failingService{recordingService{name: "failing", journal: journal}, errRun}, WithManager(NewRunOnceManager(0)))); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	before := time.Now()
+	if err := d.Start(ctx); !errors.Is(err, errRun) {
+		t.Fatalf("expected the run error of the failing service, got %v", err)
+	}
+
+	changes := d.History("failing")
+	expected := []State{StateInit, StateIdle, StateRun, StateStop, StateExit}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected the changes %v, got %v", expected, changes)
+	}
+	for i, change := range changes {
+		if change.State != expected[i] || change.Time.Before(before) {
+			t.Errorf("expected change %d to be %s of this start, got %+v", i, expected[i], change)
+		}
+		if wantErr := change.State == StateStop; wantErr != errors.Is(change.Err, errRun) {
+			t.Errorf("expected only leaving run to carry the run error, got %+v", change)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	d.serveHistory(rec, httptest.NewRequest(http.MethodGet, "/history?service=failing", nil))
+	var served []map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &served); err != nil || len(served) != len(expected) || served[3]["error"] != errRun.Error() {
+		t.Errorf("expected the history to be served, got %s (%v)", rec.Body.String(), err)
+	}
+
+	rec = httptest.NewRecorder()
+	d.serveHistory(rec, httptest.NewRequest(http.MethodGet, "/history?service=unknown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected unknown services to be not found, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,49 @@
+package rxd
+
+import "sync"
+
+// serviceLabels holds the labels of the registered services, see WithLabels. It has a lock of its own since
+// filters read it from the routine of the states topic, which the daemon may wait on while holding its lock.
+type serviceLabels struct {
+	mu     sync.RWMutex
+	labels map[string]map[string]string
+}
+
+func newServiceLabels() *serviceLabels {
+	return &serviceLabels{labels: make(map[string]map[string]string)}
+}
+
+func (l *serviceLabels) set(service string, labels map[string]string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(labels) == 0 {
+		delete(l.labels, service)
+		return
+	}
+	l.labels[service] = labels
+}
+
+func (l *serviceLabels) remove(service string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.labels, service)
+}
+
+// match reports whether the service carries every label of the selector with the same value.
+func (l *serviceLabels) match(service string, selector map[string]string) bool {
+	if l == nil {
+		return false
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	labels := l.labels[service]
+	for key, value := range selector {
+		if v, ok := labels[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
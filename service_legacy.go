@@ -0,0 +1,211 @@
+package rxd
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// The states of the ServiceResponse API, each lifecycle returned the state the service moves to next.
+const (
+	// Deprecated: use StateInit.
+	InitState = StateInit
+	// Deprecated: use StateIdle.
+	IdleState = StateIdle
+	// Deprecated: use StateRun.
+	RunState = StateRun
+	// Deprecated: use StateStop.
+	StopState = StateStop
+	// Deprecated: use StateExit.
+	ExitState = StateExit
+	// NoopState moves the service to the state it would move to by default:
+	// Init to Idle, Idle to Run, Run to Stop and Stop to Exit.
+	//
+	// Deprecated: ServiceRunner lifecycles return an error only, the manager picks the next state.
+	NoopState State = math.MaxUint8
+)
+
+// ServiceResponse is returned by every lifecycle of a LegacyServiceRunner.
+//
+// Deprecated: implement ServiceRunner, whose lifecycles return an error only.
+type ServiceResponse struct {
+	Error     error
+	NextState State
+}
+
+// NewResponse returns a response moving the service to next, err is logged by the daemon.
+//
+// Deprecated: implement ServiceRunner, whose lifecycles return an error only.
+func NewResponse(err error, next State) ServiceResponse {
+	return ServiceResponse{Error: err, NextState: next}
+}
+
+// LegacyServiceRunner is a service written against the ServiceResponse API, it is run by NewLegacyService.
+//
+// Deprecated: implement ServiceRunner, see cmd/rxdmigrate to find the code that needs porting.
+type LegacyServiceRunner interface {
+	Init(*LegacyContext) ServiceResponse
+	Idle(*LegacyContext) ServiceResponse
+	Run(*LegacyContext) ServiceResponse
+	Stop(*LegacyContext) ServiceResponse
+}
+
+// LegacyContext is the service context handed to a LegacyServiceRunner, it is valid for a single lifecycle.
+//
+// Deprecated: ServiceRunner lifecycles are given a ServiceContext.
+type LegacyContext struct {
+	ServiceContext
+
+	mu      sync.Mutex
+	changeC <-chan ServiceStates
+	cancel  context.CancelFunc
+}
+
+// ChangeState returns a channel receiving the states of every service of the daemon whenever they change,
+// the channel is closed once the lifecycle returns.
+//
+// Deprecated: use WatchAllStates, WatchAnyServices or WatchAllServices of the ServiceContext.
+func (c *LegacyContext) ChangeState() <-chan ServiceStates {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.changeC == nil {
+		c.changeC, c.cancel = c.WatchAllStates(NoFilter)
+	}
+	return c.changeC
+}
+
+// ShutdownSignal returns a channel closed once the service is stopped.
+//
+// Deprecated: use Done of the ServiceContext.
+func (c *LegacyContext) ShutdownSignal() <-chan struct{} {
+	return c.Done()
+}
+
+// close stops watching the states for ChangeState.
+func (c *LegacyContext) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+// NewLegacyService creates a service running a LegacyServiceRunner, the state returned by each lifecycle
+// is honored by the manager of the service. Once the daemon stops the service it runs Stop and exits
+// whichever state is returned. Giving the service another manager with WithManager ignores the returned states.
+//
+// Deprecated: implement ServiceRunner and use NewService, see cmd/rxdmigrate to find the code that needs porting.
+func NewLegacyService(name string, runner LegacyServiceRunner, opts ...ServiceOption) Service {
+	adapter := &legacyRunner{runner: runner}
+	return NewService(name, adapter, append([]ServiceOption{WithManager(legacyManager{
+		runner:       adapter,
+		RestartDelay: 5 * time.Second,
+	})}, opts...)...)
+}
+
+// legacyRunner adapts a LegacyServiceRunner to a ServiceRunner, remembering the state the last lifecycle returned.
+type legacyRunner struct {
+	runner LegacyServiceRunner
+	next   State
+}
+
+func (r *legacyRunner) Init(sctx ServiceContext) error {
+	return r.lifecycle(sctx, r.runner.Init)
+}
+
+func (r *legacyRunner) Idle(sctx ServiceContext) error {
+	return r.lifecycle(sctx, r.runner.Idle)
+}
+
+func (r *legacyRunner) Run(sctx ServiceContext) error {
+	return r.lifecycle(sctx, r.runner.Run)
+}
+
+func (r *legacyRunner) Stop(sctx ServiceContext) error {
+	return r.lifecycle(sctx, r.runner.Stop)
+}
+
+func (r *legacyRunner) lifecycle(sctx ServiceContext, fn func(*LegacyContext) ServiceResponse) error {
+	lctx := &LegacyContext{ServiceContext: sctx}
+	defer lctx.close()
+
+	resp := fn(lctx)
+	r.next = resp.NextState
+	return resp.Error
+}
+
+// nextState returns the state following current, as returned by the last lifecycle of the runner.
+func (r *legacyRunner) nextState(current State) State {
+	switch r.next {
+	case StateInit, StateIdle, StateRun, StateStop, StateExit:
+		return r.next
+	}
+
+	switch current {
+	case StateInit:
+		return StateIdle
+	case StateIdle:
+		return StateRun
+	case StateRun:
+		return StateStop
+	default:
+		return StateExit
+	}
+}
+
+// legacyManager moves a service through the states returned by its LegacyServiceRunner.
+type legacyManager struct {
+	runner *legacyRunner
+	// RestartDelay is waited before the service re-enters Init after Stop.
+	RestartDelay time.Duration
+}
+
+func (m legacyManager) Manage(sctx ServiceContext, ds DaemonService, updateC chan<- StateUpdate) {
+	state := StateInit
+	for {
+		updateC <- StateUpdate{Name: ds.Name, State: state}
+		if state == StateExit {
+			return
+		}
+
+		var err error
+		switch state {
+		case StateInit:
+			err = ds.Runner.Init(sctx)
+		case StateIdle:
+			err = ds.Runner.Idle(sctx)
+		case StateRun:
+			err = ds.Runner.Run(sctx)
+		case StateStop:
+			err = ds.Runner.Stop(sctx)
+		}
+		if err != nil {
+			sctx.Log(log.LevelError, err.Error())
+		}
+
+		next := m.runner.nextState(state)
+		if sctx.Err() != nil {
+			// a stopped service runs Stop once and exits, whichever state was returned.
+			next = StateStop
+			if state == StateStop {
+				next = StateExit
+			}
+		}
+
+		if state == StateStop && next == StateInit {
+			select {
+			case <-sctx.Done():
+				next = StateExit
+			case <-time.After(m.RestartDelay):
+			}
+		}
+
+		yieldTransition(sctx)
+		state = next
+	}
+}
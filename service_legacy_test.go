@@ -0,0 +1,101 @@
+package rxd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// legacyService is written against the ServiceResponse API, it idles once from Run before stopping.
+type legacyService struct {
+	runs int
+}
+
+func (s *legacyService) Init(ctx *LegacyContext) ServiceResponse {
+	return NewResponse(nil, NoopState)
+}
+
+func (s *legacyService) Idle(ctx *LegacyContext) ServiceResponse {
+	select {
+	case <-ctx.ShutdownSignal():
+		return NewResponse(nil, StopState)
+	case <-ctx.ChangeState():
+		return NewResponse(nil, RunState)
+	}
+}
+
+func (s *legacyService) Run(ctx *LegacyContext) ServiceResponse {
+	s.runs++
+	if s.runs == 1 {
+		return NewResponse(errors.New("lost connection"), IdleState)
+	}
+	return NewResponse(nil, StopState)
+}
+
+func (s *legacyService) Stop(ctx *LegacyContext) ServiceResponse {
+	return NewResponse(nil, NoopState)
+}
+
+func TestNewLegacyService(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var transitions []string
+	d := NewDaemon("test-daemon",
+		WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+		WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+	)
+
+	runner := &legacyService{}
+	err := d.AddService(NewLegacyService("legacy", runner, WithTransitionHook(func(service string, from, to State) {
+		transitions = append(transitions, to.String())
+	})))
+	if err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	if err := d.Start(ctx); err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+
+	want := "init,idle,run,idle,run,stop,exit"
+	if got := strings.Join(transitions, ","); got != want {
+		t.Errorf("expected the legacy service to go through %s, got %s", want, got)
+	}
+}
+
+func TestNewLegacyService_Stopped(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	d := NewDaemon("test-daemon",
+		WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+		WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+		UsingTransitionHook(func(service string, from, to State) {
+			if to == StateIdle {
+				cancel()
+			}
+		}),
+	)
+
+	if err := d.AddService(NewLegacyService("legacy", &legacyService{})); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	doneC := make(chan error, 1)
+	go func() {
+		doneC <- d.Start(ctx)
+	}()
+
+	select {
+	case err := <-doneC:
+		if err != nil {
+			t.Fatalf("error starting daemon: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the legacy service to exit once the daemon is stopped")
+	}
+}
@@ -50,6 +50,10 @@ func (m RunContinuousManager) Manage(sctx ServiceContext, ds DaemonService, upda
 	var state State = StateInit
 
 	var hasStopped bool
+	// recycling is set when a recycle interrupted run, so the re-init skips its transition timeout.
+	var recycling bool
+	// exiting is set when the restart policy does not restart the service, it exits once stopped.
+	var exiting bool
 
 	for state != StateExit {
 		// signal the current state we are about to enter. to the daemon states watcher.
@@ -69,7 +73,10 @@ func (m RunContinuousManager) Manage(sctx ServiceContext, ds DaemonService, upda
 
 			switch state {
 			case StateInit:
-				if err := ds.Runner.Init(sctx); err != nil {
+				if err := awaitPreconditions(sctx); err != nil {
+					// preconditions only fail once the context is done, the service will exit.
+					state = StateStop
+				} else if err := ds.Runner.Init(sctx); err != nil {
 					sctx.Log(log.LevelError, err.Error())
 					// if an error occurs in init state, transition to stop skipping idle and run.
 					state = StateStop
@@ -87,23 +94,58 @@ func (m RunContinuousManager) Manage(sctx ServiceContext, ds DaemonService, upda
 					state = StateRun
 				}
 			case StateRun:
-				if err := ds.Runner.Run(sctx); err != nil {
+				runCtx, interrupted := runScope(sctx)
+				err := ds.Runner.Run(runCtx)
+				if err != nil {
 					sctx.Log(log.LevelError, err.Error())
 				}
-				// run continous manager will always go back to stop after run to perform any cleanup.
-				state = StateStop
+				switch interrupted() {
+				case InterruptReload:
+					// reload in place, skipping stop and init.
+					state = StateReload
+				case InterruptIdle:
+					// a dependency is down, wait for it in idle unless the service is stopping, see WatchDependency.
+					state = StateIdle
+					if sctx.Err() != nil {
+						state = StateStop
+					}
+				case InterruptRecycle:
+					recycling = true
+					state = StateStop
+				default:
+					// run continous manager will always go back to stop after run to perform any cleanup.
+					exiting = sctx.Err() == nil && !restartAfterRun(sctx, err)
+					state = StateStop
+				}
+			case StateReload:
+				if err := reloadRunner(sctx, ds.Runner); err != nil {
+					sctx.Log(log.LevelError, err.Error())
+					// if an error occurs in reload state, transition to stop for a full restart.
+					state = StateStop
+				} else {
+					state = StateRun
+				}
 			case StateStop:
 				if err := ds.Runner.Stop(sctx); err != nil {
 					sctx.Log(log.LevelError, err.Error())
 				}
-				// run continous manager will always go back to init after stop unless context is cancelled.
+				// run continous manager will always go back to init after stop unless context is cancelled
+				// or the restart policy does not restart the service.
 				state = StateInit
+				if exiting {
+					state = StateExit
+				}
 				// flip hasStopped to true to ensure we don't run stop again if Exit is next.
 				hasStopped = true
 			}
 
+			yieldTransition(sctx)
+
 			// reset the timeout to the next desired state, if transition timeout not set use default.
-			if transitionTimeout, ok := m.StateTimeouts[state]; ok {
+			if recycling && state == StateInit {
+				recycling = false
+				timeout.Reset(m.DefaultDelay)
+			} else if transitionTimeout, ok := m.stateTimeout(sctx, state); ok {
 				timeout.Reset(transitionTimeout)
 			} else {
 				timeout.Reset(m.DefaultDelay)
@@ -145,7 +187,8 @@ func (m RunUntilSuccessManager) Manage(sctx ServiceContext, ds DaemonService, up
 	defer func() {
 		// if any panics occur with the users defined service runner, recover and push error out to daemon logger.
 		if r := recover(); r != nil {
-			sctx.Log(log.LevelError, fmt.Sprintf("recovered from a panic: %v", r))
+			perr := newPanicError("service "+ds.Name, r)
+			sctx.Log(log.LevelError, fmt.Sprintf("recovered from a panic: %v", r), log.String("stack", string(perr.Stack)))
 		}
 	}()
 
@@ -160,6 +203,11 @@ func (m RunUntilSuccessManager) Manage(sctx ServiceContext, ds DaemonService, up
 		state = StateExit
 	case <-ticker.C:
 		// startup delay has passed, we can start the service runner loop.
+		if err := awaitPreconditions(sctx); err != nil {
+			// preconditions only fail once the context is done, the service will exit.
+			state = StateExit
+			break
+		}
 		if err := ds.Runner.Init(sctx); err != nil {
 			sctx.Log(log.LevelError, err.Error())
 			state = StateStop
@@ -185,6 +233,11 @@ func (m RunUntilSuccessManager) Manage(sctx ServiceContext, ds DaemonService, up
 
 			switch state {
 			case StateInit:
+				if err := awaitPreconditions(sctx); err != nil {
+					// preconditions only fail once the context is done, the service will exit.
+					state = StateStop
+					continue
+				}
 				if err := ds.Runner.Init(sctx); err != nil {
 					sctx.Log(log.LevelError, err.Error())
 					state = StateStop
@@ -201,13 +254,39 @@ func (m RunUntilSuccessManager) Manage(sctx ServiceContext, ds DaemonService, up
 				state = StateRun
 
 			case StateRun:
-				if err := ds.Runner.Run(sctx); err != nil {
+				runCtx, interrupted := runScope(sctx)
+				err := ds.Runner.Run(runCtx)
+				switch interrupted() {
+				case InterruptReload:
+					// run was interrupted by a reload, reload in place and run again.
+					state = StateReload
+					continue
+				case InterruptIdle:
+					// run was interrupted by a circuit breaker, wait for the dependency in idle unless stopping.
+					state = StateIdle
+					if sctx.Err() != nil {
+						state = StateStop
+					}
+					continue
+				case InterruptRecycle:
+					// run was interrupted by a recycle, cycle back through stop and init.
+					state = StateStop
+					continue
+				}
+				if err != nil {
 					sctx.Log(log.LevelError, err.Error())
 					state = StateStop
 					continue
 				}
 				// run exited successfully, we can exit the loop.
 				state = StateExit
+			case StateReload:
+				if err := reloadRunner(sctx, ds.Runner); err != nil {
+					sctx.Log(log.LevelError, err.Error())
+					state = StateStop
+					continue
+				}
+				state = StateRun
 			case StateStop:
 				if err := ds.Runner.Stop(sctx); err != nil {
 					sctx.Log(log.LevelError, err.Error())
@@ -215,6 +294,7 @@ func (m RunUntilSuccessManager) Manage(sctx ServiceContext, ds DaemonService, up
 				state = StateInit
 				hasStopped = true
 			}
+			yieldTransition(sctx)
 		}
 
 	}
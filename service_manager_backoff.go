@@ -0,0 +1,202 @@
+package rxd
+
+import (
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// Backoff computes exponentially growing delays between the restarts of a failing service.
+type Backoff struct {
+	Initial    time.Duration // delay before the first restart.
+	Max        time.Duration // cap of the delay, 0 does not cap it.
+	Multiplier float64       // growth of the delay after every consecutive failure, 2 when unset.
+	Jitter     float64       // fraction (0-1) of the delay that is randomized to spread restarts.
+}
+
+// Delay returns the delay before the restart following the given number of consecutive failures,
+// 0 before the first failure.
+func (b Backoff) Delay(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+
+	multiplier := b.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	d := float64(b.Initial)
+	for i := 1; i < failures; i++ {
+		d *= multiplier
+		if b.Max > 0 && d >= float64(b.Max) {
+			break
+		}
+	}
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+
+	if b.Jitter > 0 {
+		// spread the delay evenly within +/- jitter of itself.
+		d += d * b.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
+
+// ExponentialBackoffManager restarts a failing service with an exponentially growing delay plus jitter,
+// instead of the fixed delay of RunContinuousManager which hot loops while e.g. a dependency is down.
+// Any lifecycle error, or Run returning, counts as a failure. Once Run has stayed up for HealthyPeriod
+// the backoff is reset, and after MaxRetries consecutive failures the service exits.
+type ExponentialBackoffManager struct {
+	StartupDelay    time.Duration
+	InitialInterval time.Duration // delay before the first restart.
+	MaxInterval     time.Duration // cap of the delay between restarts.
+	Multiplier      float64       // growth of the delay after every consecutive failure.
+	Jitter          float64       // fraction (0-1) of the delay that is randomized to spread restarts.
+	MaxRetries      int           // consecutive failures before the service exits, 0 retries forever.
+	HealthyPeriod   time.Duration // time Run must stay up for the backoff to reset.
+}
+
+// NewExponentialBackoffManager creates a manager backing off from initial up to max between restarts,
+// doubling with 20% jitter, retrying forever and resetting after a minute of healthy running.
+func NewExponentialBackoffManager(initial, max time.Duration) ExponentialBackoffManager {
+	return ExponentialBackoffManager{
+		StartupDelay:    10 * time.Nanosecond,
+		InitialInterval: initial,
+		MaxInterval:     max,
+		Multiplier:      2,
+		Jitter:          0.2,
+		HealthyPeriod:   time.Minute,
+	}
+}
+
+func (m ExponentialBackoffManager) Manage(sctx ServiceContext, ds DaemonService, updateC chan<- StateUpdate) {
+	timeout := time.NewTimer(m.StartupDelay)
+	defer timeout.Stop()
+
+	var state State = StateInit
+	var hasStopped bool
+	var failures int
+	// failed is set when the lifecycle that just ran failed, the service is stopped and re-initialized after a backoff.
+	var failed bool
+	// backoff is the delay to wait before the next init, once the failed service has stopped.
+	var backoff time.Duration
+	var givingUp bool
+	delays := Backoff{Initial: m.InitialInterval, Max: m.MaxInterval, Multiplier: m.Multiplier, Jitter: m.Jitter}
+
+	for state != StateExit {
+		// signal the current state we are about to enter to the daemon states watcher.
+		updateC <- StateUpdate{Name: ds.Name, State: state}
+
+		select {
+		case <-sctx.Done():
+			state = StateExit
+			continue
+		case <-timeout.C:
+			hasStopped = false
+			failed = false
+
+			switch state {
+			case StateInit:
+				if err := awaitPreconditions(sctx); err != nil {
+					// preconditions only fail once the context is done, the service will exit.
+					state = StateStop
+				} else if err := ds.Runner.Init(sctx); err != nil {
+					sctx.Log(log.LevelError, err.Error())
+					failed = true
+					state = StateStop
+				} else {
+					state = StateIdle
+				}
+			case StateIdle:
+				if err := ds.Runner.Idle(sctx); err != nil {
+					sctx.Log(log.LevelError, err.Error())
+					failed = true
+					state = StateStop
+				} else {
+					state = StateRun
+				}
+			case StateRun:
+				started := time.Now()
+				runCtx, interrupted := runScope(sctx)
+				err := ds.Runner.Run(runCtx)
+				reason := interrupted()
+				if err != nil {
+					sctx.Log(log.LevelError, err.Error())
+				}
+
+				if time.Since(started) >= m.HealthyPeriod {
+					// the service ran long enough to be considered healthy again.
+					failures = 0
+				}
+
+				switch {
+				case reason == InterruptReload:
+					state = StateReload
+				case reason == InterruptIdle && sctx.Err() == nil:
+					state = StateIdle
+				case reason == InterruptRecycle || sctx.Err() != nil:
+					state = StateStop
+				case !restartAfterRun(sctx, err):
+					// the restart policy exits the service, it is stopped without a backoff.
+					givingUp = true
+					state = StateStop
+				default:
+					// run is expected to last, returning at all is a failure.
+					failed = true
+					state = StateStop
+				}
+			case StateReload:
+				if err := reloadRunner(sctx, ds.Runner); err != nil {
+					sctx.Log(log.LevelError, err.Error())
+					failed = true
+					state = StateStop
+				} else {
+					state = StateRun
+				}
+			case StateStop:
+				if err := ds.Runner.Stop(sctx); err != nil {
+					sctx.Log(log.LevelError, err.Error())
+				}
+				state = StateInit
+				hasStopped = true
+				if givingUp {
+					state = StateExit
+				}
+			}
+
+			yieldTransition(sctx)
+
+			if failed {
+				failures++
+				if m.MaxRetries > 0 && failures > m.MaxRetries {
+					sctx.Log(log.LevelError, "service failed "+strconv.Itoa(failures)+" times in a row, giving up")
+					givingUp = true
+				} else {
+					backoff = delays.Delay(failures)
+					sctx.Log(log.LevelWarning, "service failed, restarting with backoff", log.Int("failures", failures), log.String("delay", backoff.String()))
+				}
+			}
+
+			if state == StateInit && backoff > 0 {
+				// the failed service has stopped, wait out the backoff before initializing it again.
+				timeout.Reset(backoff)
+				backoff = 0
+			} else {
+				timeout.Reset(0)
+			}
+		}
+	}
+
+	if !hasStopped {
+		if err := ds.Runner.Stop(sctx); err != nil {
+			sctx.Log(log.LevelError, err.Error())
+		}
+	}
+
+	// push final state to the daemon states watcher.
+	updateC <- StateUpdate{Name: ds.Name, State: StateExit}
+}
@@ -0,0 +1,66 @@
+package rxd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackoff_Delay(t *testing.T) {
+	b := Backoff{Initial: 100 * time.Millisecond, Max: time.Second}
+	expected := []time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for failures, want := range expected {
+		if got := b.Delay(failures); got != want {
+			t.Errorf("expected a delay of %s after %d failures, got %s", want, failures, got)
+		}
+	}
+
+	b.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := b.Delay(1); got < 50*time.Millisecond || got > 150*time.Millisecond {
+			t.Fatalf("expected the delay within the jitter, got %s", got)
+		}
+	}
+}
+
+// failingRunService records its lifecycles and fails every Run.
+type failingRunService struct {
+	recordingService
+}
+
+func (f *failingRunService) Run(sctx ServiceContext) error {
+	f.journal.record(f.name + ":run")
+	return errors.New("dependency is down")
+}
+
+func TestExponentialBackoffManager_MaxRetries(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	journal := &recordingJournal{}
+	runner := &failingRunService{recordingService{name: "flaky", journal: journal}}
+
+	m := NewExponentialBackoffManager(10*time.Millisecond, 40*time.Millisecond)
+	m.MaxRetries = 2
+
+	logC := make(chan DaemonLog, 100)
+	sctx, scancel := newServiceContextWithCancel(ctx, "flaky", logC, nil, contextConfig{})
+	defer scancel()
+
+	updateC := make(chan StateUpdate, 100)
+	started := time.Now()
+	m.Manage(sctx, DaemonService{Name: "flaky", Runner: runner}, updateC)
+
+	if runs := journal.count("flaky:run"); runs != 3 {
+		t.Errorf("expected 3 runs before giving up, got %d: %v", runs, journal.entries)
+	}
+
+	if stops := journal.count("flaky:stop"); stops != 3 {
+		t.Errorf("expected every run to be stopped once, got %d: %v", stops, journal.entries)
+	}
+
+	if elapsed := time.Since(started); elapsed < 20*time.Millisecond {
+		t.Errorf("expected the restarts to back off, finished in %s", elapsed)
+	}
+}
@@ -0,0 +1,37 @@
+package rxd
+
+// The helpers below let custom managers honor the options the daemon applies to the services they run,
+// the managerkit package builds a complete toolkit for writing managers on top of them.
+
+// AwaitPreconditions blocks until the preconditions of the service have passed, see WithPreconditions, and holds
+// the service in quarantine while it is flapping, see WithQuarantine. Managers call it before every Init.
+// An error is only returned when the service context is done first.
+func AwaitPreconditions(sctx ServiceContext) error {
+	return awaitPreconditions(sctx)
+}
+
+// RunInterruptible calls Run of the runner with a context the daemon cancels to reload, recycle or idle the
+// service, and reports why it was interrupted. InterruptNone is reported when Run returned on its own.
+// Managers move the service to StateReload, StateStop or StateIdle accordingly.
+func RunInterruptible(sctx ServiceContext, runner ServiceRunner) (Interrupt, error) {
+	runCtx, interrupted := runScope(sctx)
+	err := runner.Run(runCtx)
+	return interrupted(), err
+}
+
+// RestartAfterRun reports whether the restart policy of the service restarts it once Run returned err on its own,
+// see WithRestartPolicy.
+func RestartAfterRun(sctx ServiceContext, err error) bool {
+	return restartAfterRun(sctx, err)
+}
+
+// ReloadRunner calls Reload of runners implementing Reloader, other runners are left as they are.
+func ReloadRunner(sctx ServiceContext, runner ServiceRunner) error {
+	return reloadRunner(sctx, runner)
+}
+
+// YieldTransition yields the processor between two lifecycles when the niceness of the daemon asks for it,
+// see WithNiceness.
+func YieldTransition(sctx ServiceContext) {
+	yieldTransition(sctx)
+}
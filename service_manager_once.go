@@ -0,0 +1,162 @@
+package rxd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// ServiceError is the terminal error of a service that ran to completion, e.g. under a RunOnceManager.
+type ServiceError struct {
+	Service string
+	Err     error
+}
+
+func (e ServiceError) Error() string {
+	return e.Service + ": " + e.Err.Error()
+}
+
+func (e ServiceError) Unwrap() error {
+	return e.Err
+}
+
+// RunOnceManager runs the service lifecycles Init, Idle, Run and Stop exactly once and then exits.
+// The first error returned by Init, Idle or Run, joined with the error of Stop, is recorded as the result of
+// the service, it can be read with Daemon.Results and failed results are returned by Daemon.Start.
+// A service stopped by the daemon before it reached Run does not record a result.
+type RunOnceManager struct {
+	StartupDelay time.Duration
+}
+
+// NewRunOnceManager creates a manager running the service once after the given startup delay.
+func NewRunOnceManager(startupDelay time.Duration) RunOnceManager {
+	return RunOnceManager{StartupDelay: startupDelay}
+}
+
+func (m RunOnceManager) Manage(sctx ServiceContext, ds DaemonService, updateC chan<- StateUpdate) {
+	var ran bool
+	var err error
+
+	defer func() {
+		// a panic is the terminal error of the service, it is recovered here so it can be recorded.
+		if r := recover(); r != nil {
+			perr := newPanicError("service "+ds.Name, r)
+			sctx.Log(log.LevelError, fmt.Sprintf("recovered from a panic: %v", r), log.String("stack", string(perr.Stack)))
+			err, ran = perr, true
+		}
+
+		if ran {
+			recordResult(sctx, err)
+		}
+		updateC <- StateUpdate{Name: ds.Name, State: StateExit}
+	}()
+
+	updateC <- StateUpdate{Name: ds.Name, State: StateInit}
+	select {
+	case <-sctx.Done():
+		return
+	case <-time.After(m.StartupDelay):
+	}
+
+	if awaitPreconditions(sctx) != nil {
+		return
+	}
+
+	err = ds.Runner.Init(sctx)
+	if err == nil && sctx.Err() == nil {
+		updateC <- StateUpdate{Name: ds.Name, State: StateIdle}
+		err = ds.Runner.Idle(sctx)
+	}
+
+	if err == nil && sctx.Err() == nil {
+		updateC <- StateUpdate{Name: ds.Name, State: StateRun}
+		ran = true
+		err = ds.Runner.Run(sctx)
+	}
+
+	// a failed Init or Idle is the result of the service, it never got to run.
+	ran = ran || err != nil
+
+	updateC <- StateUpdate{Name: ds.Name, State: StateStop}
+	if stopErr := ds.Runner.Stop(sctx); stopErr != nil {
+		sctx.Log(log.LevelError, stopErr.Error())
+		err = errors.Join(err, stopErr)
+	}
+
+	if err != nil {
+		sctx.Log(log.LevelError, err.Error())
+	}
+}
+
+// serviceResults holds the terminal errors recorded by services that ran to completion.
+type serviceResults struct {
+	mu   sync.Mutex
+	errs map[string]error
+}
+
+func newServiceResults() *serviceResults {
+	return &serviceResults{errs: make(map[string]error)}
+}
+
+func (r *serviceResults) record(name string, err error) {
+	r.mu.Lock()
+	r.errs[name] = err
+	r.mu.Unlock()
+}
+
+// add joins err with the error already recorded for the service.
+func (r *serviceResults) add(name string, err error) {
+	r.mu.Lock()
+	r.errs[name] = errors.Join(r.errs[name], err)
+	r.mu.Unlock()
+}
+
+func (r *serviceResults) snapshot() map[string]error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := make(map[string]error, len(r.errs))
+	for name, err := range r.errs {
+		results[name] = err
+	}
+	return results
+}
+
+// failed joins the recorded errors of every failed service, wrapped in a ServiceError, nil if none failed.
+func (r *serviceResults) failed() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.errs))
+	for name, err := range r.errs {
+		if err != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	errs := make([]error, 0, len(names))
+	for _, name := range names {
+		errs = append(errs, ServiceError{Service: name, Err: r.errs[name]})
+	}
+	return errors.Join(errs...)
+}
+
+// recordResult stores the terminal error of the service owning the context.
+func recordResult(sctx ServiceContext, err error) {
+	sc, ok := sctx.(*serviceContext)
+	if !ok || sc.results == nil {
+		return
+	}
+	sc.results.record(sc.name, err)
+}
+
+// Results returns the terminal result of every service that recorded one, a nil error means it succeeded.
+// Only managers running a service to completion, such as RunOnceManager, record a result.
+func (d *daemon) Results() map[string]error {
+	return d.results.snapshot()
+}
@@ -0,0 +1,158 @@
+package rxd
+
+import (
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// OverlapPolicy decides what a ScheduleManager does when an activation is due while the service is still running.
+type OverlapPolicy uint8
+
+const (
+	// OverlapSkip drops the activation.
+	OverlapSkip OverlapPolicy = iota
+	// OverlapQueue runs the service again as soon as the current run returns, activations queue up.
+	OverlapQueue
+	// OverlapConcurrent runs the service again right away, alongside the current run.
+	OverlapConcurrent
+)
+
+func (p OverlapPolicy) String() string {
+	switch p {
+	case OverlapSkip:
+		return "skip"
+	case OverlapQueue:
+		return "queue"
+	case OverlapConcurrent:
+		return "concurrent"
+	default:
+		return "unknown"
+	}
+}
+
+// ScheduleManager runs batch style services on a schedule. The service is initialized once and held
+// in Idle, every activation of the schedule moves it to Run until Run returns and back to Idle.
+// A failed Init is retried at the next activation, a failed Run is logged and waits for the next activation.
+type ScheduleManager struct {
+	Schedule     Schedule
+	Overlap      OverlapPolicy
+	StartupDelay time.Duration
+}
+
+// NewScheduleManager creates a manager running the service on the schedule, e.g. Every(time.Hour)
+// or MustParseCron("*/15 * * * *"), handling overlapping activations with the given policy.
+func NewScheduleManager(schedule Schedule, overlap OverlapPolicy) ScheduleManager {
+	return ScheduleManager{
+		Schedule:     schedule,
+		Overlap:      overlap,
+		StartupDelay: 10 * time.Nanosecond,
+	}
+}
+
+func (m ScheduleManager) Manage(sctx ServiceContext, ds DaemonService, updateC chan<- StateUpdate) {
+	timer := time.NewTimer(m.StartupDelay)
+	defer timer.Stop()
+
+	var initialized bool
+	var running, queued int
+	doneC := make(chan error)
+
+	start := func() {
+		running++
+		if running == 1 {
+			updateC <- StateUpdate{Name: ds.Name, State: StateRun}
+		}
+		go func() {
+			doneC <- ds.Runner.Run(sctx)
+		}()
+	}
+
+	// initialize brings the service from init to idle, returning false if it has to wait for the next activation.
+	initialize := func() bool {
+		updateC <- StateUpdate{Name: ds.Name, State: StateInit}
+		if err := awaitPreconditions(sctx); err != nil {
+			return false
+		}
+
+		if err := ds.Runner.Init(sctx); err != nil {
+			sctx.Log(log.LevelError, err.Error())
+			updateC <- StateUpdate{Name: ds.Name, State: StateStop}
+			if err := ds.Runner.Stop(sctx); err != nil {
+				sctx.Log(log.LevelError, err.Error())
+			}
+			return false
+		}
+
+		updateC <- StateUpdate{Name: ds.Name, State: StateIdle}
+		if err := ds.Runner.Idle(sctx); err != nil {
+			sctx.Log(log.LevelError, err.Error())
+		}
+		return true
+	}
+
+	// the first activation initializes the service and is scheduled from then on.
+	next := time.Now()
+	for {
+		select {
+		case <-sctx.Done():
+			// wait for the runs in flight, they observe the same context.
+			for ; running > 0; running-- {
+				<-doneC
+			}
+			if initialized {
+				updateC <- StateUpdate{Name: ds.Name, State: StateStop}
+				if err := ds.Runner.Stop(sctx); err != nil {
+					sctx.Log(log.LevelError, err.Error())
+				}
+			}
+			updateC <- StateUpdate{Name: ds.Name, State: StateExit}
+			return
+
+		case <-timer.C:
+			if !initialized {
+				initialized = initialize()
+			} else if running == 0 {
+				start()
+			} else {
+				switch m.Overlap {
+				case OverlapQueue:
+					queued++
+				case OverlapConcurrent:
+					start()
+				default:
+					sctx.Log(log.LevelWarning, "skipping scheduled run, the previous run is still in progress")
+				}
+			}
+
+			next = m.Schedule.Next(next)
+			if next.IsZero() {
+				sctx.Log(log.LevelError, "schedule has no further activations")
+				timer.Stop()
+				continue
+			}
+			if now := time.Now(); next.Before(now) {
+				// activations missed while the service was initializing are skipped.
+				next = m.Schedule.Next(now)
+			}
+			timer.Reset(time.Until(next))
+
+		case err := <-doneC:
+			running--
+			if err != nil {
+				sctx.Log(log.LevelError, err.Error())
+			}
+
+			if running > 0 {
+				continue
+			}
+			if queued > 0 {
+				queued--
+				start()
+				continue
+			}
+			updateC <- StateUpdate{Name: ds.Name, State: StateIdle}
+			yieldTransition(sctx)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package rxd
+
+import (
+	"context"
+	"time"
+
+	"github.com/ambitiousfew/rxd/intracom"
+)
+
+// SubscribeConfig configures the subscription of a service to a topic, see ServiceContext.Subscribe.
+type SubscribeConfig struct {
+	BufferSize int // messages buffered for the subscriber.
+	// BufferPolicy applies once the buffer is full, e.g. intracom.BufferPolicyDropOldest[[]byte]{}.
+	// Without one the publishers wait for the subscriber to catch up.
+	BufferPolicy intracom.BufferPolicyHandler[[]byte]
+	DropTimeout  time.Duration // used by the policies dropping messages after a timeout.
+}
+
+// serviceTopic returns the topic services exchange messages on under name, creating it on first use
+// so subscribers and publishers can come up in any order.
+func (sc *serviceContext) serviceTopic(name string) (intracom.Topic[[]byte], error) {
+	if name == "" {
+		return nil, ErrNoTopicName
+	}
+	return intracom.CreateTopic[[]byte](sc.ic, intracom.TopicConfig{Name: internalServiceTopics + name})
+}
+
+// Publish sends payload to every service subscribed to topic, see Subscribe. It waits for the subscribers
+// that apply back pressure and returns the error of the context once it is done before the message was sent.
+// Subscribers share the payload, it must not be modified once published.
+// Topics are local to the daemon, services running in isolation only reach the services of their own process.
+func (sc *serviceContext) Publish(topic string, payload []byte) error {
+	t, err := sc.serviceTopic(topic)
+	if err != nil {
+		return err
+	}
+	// a stopped service does not publish anymore, even to a subscriber ready to receive.
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return t.Publish(sc, payload)
+}
+
+// Subscribe returns the messages published to topic from then on, preceded by the last message published before
+// when the buffer has room for it, as with every intracom topic. The subscription ends and the channel
+// is closed once the context is done, for the service context once the service exits, so a subscription
+// made from a child context created with WithName ends when the child is cancelled.
+func (sc *serviceContext) Subscribe(topic string, conf SubscribeConfig) (<-chan []byte, error) {
+	if _, err := sc.serviceTopic(topic); err != nil {
+		return nil, err
+	}
+
+	name := internalServiceTopics + topic
+	consumer := intracom.ConsumerID(sc.ic, name+"."+sc.fqcn)
+	ch, err := intracom.CreateSubscription[[]byte](sc, sc.ic, name, 0, intracom.SubscriberConfig[[]byte]{
+		ConsumerGroup: consumer,
+		ErrIfExists:   true,
+		BufferSize:    conf.BufferSize,
+		BufferPolicy:  conf.BufferPolicy,
+		DropTimeout:   conf.DropTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go func(ctx context.Context) {
+		<-ctx.Done()
+		// the subscription is already gone when intracom was closed first.
+		_ = intracom.RemoveSubscription(sc.ic, name, consumer, ch)
+	}(sc)
+	return ch, nil
+}
@@ -0,0 +1,81 @@
+package rxd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/intracom"
+)
+
+func TestServiceContext_PublishSubscribe(t *testing.T) {
+	ic := intracom.New("test")
+	defer intracom.Close(ic)
+
+	consumer, consumerCancel := newServiceContextWithCancel(context.Background(), "consumer", make(chan DaemonLog, 10), ic, contextConfig{})
+	defer consumerCancel()
+	producer, producerCancel := newServiceContextWithCancel(context.Background(), "producer", make(chan DaemonLog, 10), ic, contextConfig{})
+	defer producerCancel()
+
+	orders, err := consumer.Subscribe("orders", SubscribeConfig{BufferSize: 1})
+	if err != nil {
+		t.Fatalf("error subscribing: %s", err)
+	}
+	worker, workerCancel := consumer.WithName("worker")
+	workerOrders, err := worker.Subscribe("orders", SubscribeConfig{BufferSize: 1})
+	if err != nil {
+		t.Fatalf("error subscribing from a child context: %s", err)
+	}
+
+	if err := producer.Publish("orders", []byte("order-1")); err != nil {
+		t.Fatalf("error publishing: %s", err)
+	}
+	for _, ch := range []<-chan []byte{orders, workerOrders} {
+		select {
+		case msg := <-ch:
+			if string(msg) != "order-1" {
+				t.Errorf("expected order-1, got %q", msg)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected every subscriber to get the message")
+		}
+	}
+
+	// cancelling the child ends its subscription only, cancelling the service ends the other.
+	workerCancel()
+	expectClosed(t, workerOrders)
+	if err := producer.Publish("orders", []byte("order-2")); err != nil {
+		t.Fatalf("error publishing: %s", err)
+	}
+	if msg := <-orders; string(msg) != "order-2" {
+		t.Errorf("expected order-2, got %q", msg)
+	}
+	consumerCancel()
+	expectClosed(t, orders)
+
+	if err := producer.Publish("", nil); !errors.Is(err, ErrNoTopicName) {
+		t.Errorf("expected %s, got %v", ErrNoTopicName, err)
+	}
+	producerCancel()
+	if err := producer.Publish("orders", []byte("late")); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a stopped service not to publish, got %v", err)
+	}
+}
+
+// expectClosed fails the test unless ch is closed within a second, draining what is left in it.
+func expectClosed(t *testing.T, ch <-chan []byte) {
+	t.Helper()
+	timer := time.NewTimer(time.Second)
+	defer timer.Stop()
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-timer.C:
+			t.Fatalf("expected the subscription to end")
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package rxd
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// serviceGeneration counts how many times a service has entered Init, it is shared by the
+// service context and all of its children so guards created by OnceValue can tell stale values apart.
+type serviceGeneration struct {
+	n atomic.Uint64
+}
+
+func (g *serviceGeneration) current() uint64 {
+	if g == nil {
+		return 0
+	}
+	return g.n.Load()
+}
+
+// Generation returns how many times the service has entered Init, it is 0 before the first Init
+// and for contexts that were not created by the daemon.
+func Generation(sctx ServiceContext) uint64 {
+	sc, ok := sctx.(*serviceContext)
+	if !ok {
+		return 0
+	}
+	return sc.generation.current()
+}
+
+// generationRunner advances the generation of the service every time the runner enters Init,
+// so guards are reset whichever manager runs the service.
+type generationRunner struct {
+	runner     ServiceRunner
+	generation *serviceGeneration
+}
+
+func (r generationRunner) Init(sctx ServiceContext) error {
+	r.generation.n.Add(1)
+	return r.runner.Init(sctx)
+}
+
+func (r generationRunner) Idle(sctx ServiceContext) error {
+	return r.runner.Idle(sctx)
+}
+
+func (r generationRunner) Run(sctx ServiceContext) error {
+	return r.runner.Run(sctx)
+}
+
+func (r generationRunner) Stop(sctx ServiceContext) error {
+	return r.runner.Stop(sctx)
+}
+
+func (r generationRunner) Reload(sctx ServiceContext) error {
+	return reloadRunner(sctx, r.runner)
+}
+
+// OnceValue returns a function that calls f only once per generation of the service, the value and error
+// of the first call are returned to every call until the service enters Init again, e.g. after a restart
+// by its manager, which makes the next call run f again. Use it to guard expensive setup of a runner
+// without keeping a connection or client around that belongs to a previous run.
+//
+// Like sync.OnceValues concurrent callers wait for the first call to return. With a context that was not
+// created by the daemon f is only ever called once.
+func OnceValue[T any](f func(sctx ServiceContext) (T, error)) func(sctx ServiceContext) (T, error) {
+	var (
+		mu         sync.Mutex
+		done       bool
+		generation *serviceGeneration
+		n          uint64
+		value      T
+		err        error
+	)
+
+	return func(sctx ServiceContext) (T, error) {
+		var current *serviceGeneration
+		if sc, ok := sctx.(*serviceContext); ok {
+			current = sc.generation
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if done && generation == current && n == current.current() {
+			return value, err
+		}
+
+		generation, n = current, current.current()
+		value, err = f(sctx)
+		done = true
+		return value, err
+	}
+}
+
+// OnceFunc returns a function that calls f only once per generation of the service, see OnceValue.
+func OnceFunc(f func(sctx ServiceContext) error) func(sctx ServiceContext) error {
+	once := OnceValue(func(sctx ServiceContext) (struct{}, error) {
+		return struct{}{}, f(sctx)
+	})
+	return func(sctx ServiceContext) error {
+		_, err := once(sctx)
+		return err
+	}
+}
@@ -0,0 +1,93 @@
+package rxd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestOnceValue_ResetOnInit(t *testing.T) {
+	generation := &serviceGeneration{}
+	sctx, cancel := newServiceContextWithCancel(context.Background(), "once", make(chan DaemonLog, 100), nil, contextConfig{generation: generation})
+	defer cancel()
+
+	runner := generationRunner{runner: &recordingService{name: "once", journal: &recordingJournal{}}, generation: generation}
+
+	var calls int
+	connect := OnceValue(func(sctx ServiceContext) (int, error) {
+		calls++
+		return calls, nil
+	})
+
+	if got := Generation(sctx); got != 0 {
+		t.Fatalf("expected generation 0 before Init, got %d", got)
+	}
+
+	for run := 1; run <= 3; run++ {
+		if err := runner.Init(sctx); err != nil {
+			t.Fatalf("error in init: %s", err)
+		}
+		if got := Generation(sctx); got != uint64(run) {
+			t.Fatalf("expected generation %d, got %d", run, got)
+		}
+
+		// child contexts share the generation of the service.
+		child, childCancel := sctx.WithName("child")
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(sctx ServiceContext) {
+				defer wg.Done()
+				if v, _ := connect(sctx); v != run {
+					t.Errorf("expected value %d of generation %d, got %d", run, run, v)
+				}
+			}(child)
+		}
+		wg.Wait()
+		childCancel()
+
+		if calls != run {
+			t.Fatalf("expected %d calls after generation %d, got %d", run, run, calls)
+		}
+	}
+}
+
+func TestOnceFunc_CachesErrorWithinGeneration(t *testing.T) {
+	generation := &serviceGeneration{}
+	sctx, cancel := newServiceContextWithCancel(context.Background(), "once", make(chan DaemonLog, 100), nil, contextConfig{generation: generation})
+	defer cancel()
+
+	errSetup := errors.New("setup failed")
+	var calls int
+	setup := OnceFunc(func(sctx ServiceContext) error {
+		calls++
+		return errSetup
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := setup(sctx); !errors.Is(err, errSetup) {
+			t.Fatalf("expected %s, got %v", errSetup, err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected 1 call, got %d", calls)
+	}
+
+	generation.n.Add(1)
+	setup(sctx)
+	if calls != 2 {
+		t.Fatalf("expected setup to run again in a new generation, got %d calls", calls)
+	}
+
+	// a context not created by the daemon has no generation, f only runs once.
+	plain := OnceValue(func(sctx ServiceContext) (int, error) {
+		calls++
+		return calls, nil
+	})
+	plain(nil)
+	plain(nil)
+	if calls != 3 {
+		t.Fatalf("expected 3 calls, got %d", calls)
+	}
+}
@@ -1,5 +1,7 @@
 package rxd
 
+import "time"
+
 type ServiceOption func(*Service)
 
 func WithManager(manager ServiceManager) ServiceOption {
@@ -7,3 +9,170 @@ func WithManager(manager ServiceManager) ServiceOption {
 		s.Manager = manager
 	}
 }
+
+// WithLockOSThread wires the goroutine running the service to its own OS thread
+// for the lifetime of the service. This is useful for cgo or CPU-bound services
+// that rely on thread-local state.
+func WithLockOSThread() ServiceOption {
+	return func(s *Service) {
+		s.config.lockOSThread = true
+	}
+}
+
+// WithCPUShare sets a soft share (0 < share <= 1) of wall time the service may spend working.
+// The share is only enforced when the service cooperates by calling sctx.Checkpoint() in its
+// processing loops, the checkpoint will pause the service when it has consumed more than its share.
+// Burst is the amount of work time the service may accumulate before being throttled.
+// Work is the cpu time the process spends while the service is between checkpoints, at most the wall time
+// between them, so a service waiting between checkpoints is not charged while the rest of the daemon idles.
+// Where the cpu time of the process cannot be read, e.g. on js/wasm, the wall time is counted as work.
+func WithCPUShare(share float64, burst time.Duration) ServiceOption {
+	return func(s *Service) {
+		s.config.cpuShare = share
+		s.config.cpuBurst = burst
+	}
+}
+
+// WithPreconditions gates the service on the given preconditions, which the manager evaluates
+// in order before every Init. Each precondition is retried with a backoff until it passes,
+// so the service only starts once e.g. its network dependencies are reachable.
+func WithPreconditions(preconditions ...Precondition) ServiceOption {
+	return func(s *Service) {
+		s.config.preconditions = append(s.config.preconditions, preconditions...)
+	}
+}
+
+// WithDependsOn starts the service only once all of the named services have reached StateRun.
+// When the daemon stops, the service is stopped before the services it depends on.
+// The dependencies must be registered with the daemon and must not form a cycle.
+func WithDependsOn(services ...string) ServiceOption {
+	return func(s *Service) {
+		s.config.dependsOn = append(s.config.dependsOn, services...)
+	}
+}
+
+// WatchDependency guards the service with a circuit breaker on the named service. The breaker trips open once
+// the dependency has been out of StateRun for the threshold, the Run lifecycle of the service is then interrupted
+// and the service waits in Idle, without going through Stop and Init. Once the dependency runs again the breaker
+// half-opens and the service runs as a probe, the breaker closes if the dependency keeps running for the threshold
+// and opens again as soon as it does not. Only the built-in continuous managers move the service back to Idle,
+// isolated services are not guarded.
+func WatchDependency(service string, threshold time.Duration) ServiceOption {
+	return func(s *Service) {
+		s.config.dependencies = append(s.config.dependencies, dependencyConfig{service: service, threshold: threshold})
+	}
+}
+
+// WithStartPaused registers the service without starting it, it is reported in StatePaused until it is
+// resumed with Daemon.ResumeService, e.g. through the http admin server or the control socket, for components
+// an operator starts such as migration tools. Given services the service also resumes on its own once all of
+// them have reached StateRun. A paused service keeps the daemon running and does not hold back its readiness.
+func WithStartPaused(resumeWhenRunning ...string) ServiceOption {
+	return func(s *Service) {
+		s.config.startPaused = true
+		s.config.resumeWhenRunning = append(s.config.resumeWhenRunning, resumeWhenRunning...)
+	}
+}
+
+// WithStopTimeout bounds the time the service is given to exit once it has been told to stop.
+// If its manager has not returned within the timeout, e.g. because Stop is wedged, the daemon logs it,
+// abandons the routine and marks the service as exited so the daemon can still finish, Start then returns
+// ErrShutdownTimeout wrapped in a ServiceError.
+// The abandoned routine keeps running until it returns on its own or the process exits.
+func WithStopTimeout(timeout time.Duration) ServiceOption {
+	return func(s *Service) {
+		s.config.stopTimeout = timeout
+	}
+}
+
+// WithQuarantine quarantines the service once it cycled from Init to Stop more than cycles times within window,
+// e.g. failing Init over and over. It is held in StateQuarantined for the cooldown before entering Init again,
+// and an alert is logged, so a broken service does not flood the logs or burn cpu. See UsingQuarantine.
+func WithQuarantine(cycles int, window, cooldown time.Duration) ServiceOption {
+	return func(s *Service) {
+		s.config.quarantine = quarantinePolicy{cycles: cycles, window: window, cooldown: cooldown}
+	}
+}
+
+// WithTTL removes the service from the daemon once ttl elapsed since it was started, stopping it first, e.g. for
+// ephemeral services added per task so they do not accumulate. See RemoveService.
+func WithTTL(ttl time.Duration) ServiceOption {
+	return func(s *Service) {
+		s.config.ttl = ttl
+	}
+}
+
+// WithMaxRuns removes the service from the daemon once its Run lifecycle returned n times since it was started,
+// stopping it first. Runs of services running in isolation are not counted. See RemoveService.
+func WithMaxRuns(n int) ServiceOption {
+	return func(s *Service) {
+		s.config.maxRuns = n
+	}
+}
+
+// WithRestartPolicy sets whether the service is restarted, exited or exits the whole daemon
+// once its Run lifecycle returns on its own, see RestartPolicy. Services restart by default.
+func WithRestartPolicy(policy RestartPolicy) ServiceOption {
+	return func(s *Service) {
+		s.config.restartPolicy = policy
+	}
+}
+
+// WithTraceSampling sets which spans of the service are recorded when the daemon has a tracer,
+// e.g. every Init and Stop but only 1% of the spans started with StartSpan in the Run loop.
+// The sampling can be changed at runtime with SetTraceSampling or the admin api.
+func WithTraceSampling(sampling TraceSampling) ServiceOption {
+	return func(s *Service) {
+		s.config.traceSampling = &sampling
+	}
+}
+
+// WithIsolation runs the service in its own child process of the daemon, so a crash
+// (e.g. in cgo code) cannot take down the whole daemon. The child reports its states and
+// logs back to the daemon, which restarts the child if it crashes as the restart policy of the service allows.
+// Isolation needs a unix platform, elsewhere the service exits with ErrIsolationUnsupported.
+// The daemon binary must register the same services in the child for this to work,
+// which is the case when the daemon is built the same way on every run.
+func WithIsolation() ServiceOption {
+	return func(s *Service) {
+		s.config.isolated = true
+	}
+}
+
+// WithSettings hands settings to the runner of the service, e.g. a struct decoded from a config file,
+// which reads them with ServiceContext.Settings or SettingsOf.
+func WithSettings(settings any) ServiceOption {
+	return func(s *Service) {
+		s.config.settings = settings
+	}
+}
+
+// WithLabels attaches labels to the service, e.g. {"tier": "storage"}, selecting it in the service filters
+// of the watchers of states, see NewLabelFilter. Labels set by repeated options are merged.
+func WithLabels(labels map[string]string) ServiceOption {
+	return func(s *Service) {
+		if s.config.labels == nil {
+			s.config.labels = make(map[string]string, len(labels))
+		}
+		for key, value := range labels {
+			s.config.labels[key] = value
+		}
+	}
+}
+
+// WithCritical marks the service as critical to the daemon, the watchdog keep-alive of the service manager
+// (see WithReportAlive) is only sent while every critical service is in StateRun or StateIdle and its latest
+// health check passed, so a wedged critical service gets the daemon restarted.
+func WithCritical() ServiceOption {
+	return func(s *Service) {
+		s.config.critical = true
+	}
+}
+
+// WithTransitionHook calls hook with every state transition of the service, synchronously before the
+// new state is published. See UsingTransitionHook for hooks called for every service.
+func WithTransitionHook(hook TransitionFunc) ServiceOption {
+	return func(s *Service) {
+		s.config.transitionHooks = append(s.config.transitionHooks, hook)
+	}
+}
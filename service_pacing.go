@@ -0,0 +1,95 @@
+package rxd
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Pacing is the daemon wide policy applied to services that cooperate by calling
+// sctx.Yield() or sctx.Throttle(rate) in their processing loops.
+// The daemon can tighten the pacing at runtime via SetPacing when the host is under load.
+type Pacing struct {
+	// YieldDelay is how long every call to Yield pauses for, 0 only yields the processor.
+	YieldDelay time.Duration
+	// RateFactor scales the rate given to Throttle (0 < factor <= 1), 0 leaves rates untouched.
+	RateFactor float64
+}
+
+// pacer is shared by all the contexts of a service and holds the state of its Throttle calls.
+type pacer struct {
+	policy *atomic.Pointer[Pacing] // owned by the daemon, shared by all pacers.
+	next   time.Time               // earliest time the next throttled iteration may run.
+	mu     sync.Mutex
+}
+
+func newPacer(policy *atomic.Pointer[Pacing]) *pacer {
+	return &pacer{
+		policy: policy,
+		mu:     sync.Mutex{},
+	}
+}
+
+func (p *pacer) current() Pacing {
+	if p == nil || p.policy == nil {
+		return Pacing{}
+	}
+
+	if policy := p.policy.Load(); policy != nil {
+		return *policy
+	}
+	return Pacing{}
+}
+
+// yield pauses for the configured yield delay or yields the processor when there is none.
+func (p *pacer) yield(ctx context.Context) {
+	delay := p.current().YieldDelay
+	if delay <= 0 {
+		runtime.Gosched()
+		return
+	}
+
+	sleep(ctx, delay)
+}
+
+// throttle blocks until the next iteration of a loop limited to rate iterations per second may run.
+func (p *pacer) throttle(ctx context.Context, rate float64) {
+	if rate <= 0 {
+		p.yield(ctx)
+		return
+	}
+
+	if factor := p.current().RateFactor; factor > 0 && factor < 1 {
+		rate *= factor
+	}
+	interval := time.Duration(float64(time.Second) / rate)
+
+	p.mu.Lock()
+	now := time.Now()
+	if p.next.Before(now) {
+		p.next = now
+	}
+	wait := p.next.Sub(now)
+	p.next = p.next.Add(interval)
+	p.mu.Unlock()
+
+	if wait <= 0 {
+		runtime.Gosched()
+		return
+	}
+
+	sleep(ctx, wait)
+}
+
+// sleep pauses for the given duration or until the context is done.
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
@@ -0,0 +1,62 @@
+package rxd
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPacer_Yield(t *testing.T) {
+	policy := &atomic.Pointer[Pacing]{}
+	p := newPacer(policy)
+	ctx := context.Background()
+
+	start := time.Now()
+	p.yield(ctx)
+	if elapsed := time.Since(start); elapsed > 5*time.Millisecond {
+		t.Errorf("expected yield without a delay to return at once, took %s", elapsed)
+	}
+
+	policy.Store(&Pacing{YieldDelay: 20 * time.Millisecond})
+	start = time.Now()
+	p.yield(ctx)
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("expected yield to pause for the yield delay, took %s", elapsed)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	policy.Store(&Pacing{YieldDelay: time.Minute})
+	start = time.Now()
+	p.yield(cancelled)
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("expected yield to return once the context is done, took %s", elapsed)
+	}
+}
+
+func TestPacer_Throttle(t *testing.T) {
+	policy := &atomic.Pointer[Pacing]{}
+	p := newPacer(policy)
+	ctx := context.Background()
+
+	// 5 iterations at 100 per second are spread over at least 40ms, the first one runs at once.
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		p.throttle(ctx, 100)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("expected 5 iterations at 100/s to take at least 40ms, took %s", elapsed)
+	}
+
+	// halving the rate doubles the interval between iterations.
+	policy.Store(&Pacing{RateFactor: 0.5})
+	p = newPacer(policy)
+	start = time.Now()
+	for i := 0; i < 5; i++ {
+		p.throttle(ctx, 100)
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("expected 5 iterations at 100/s with a rate factor of 0.5 to take at least 80ms, took %s", elapsed)
+	}
+}
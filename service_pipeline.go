@@ -0,0 +1,103 @@
+package rxd
+
+import (
+	"reflect"
+
+	"github.com/ambitiousfew/rxd/intracom"
+)
+
+// StageSpec describes a service of a pipeline, it is built with PipelineSource, PipelineStage or PipelineSink.
+type StageSpec struct {
+	Name    string          // name of the service running the stage.
+	Options []ServiceOption // options of the service running the stage.
+	Buffer  int             // messages the pipe to the next stage buffers, 0 hands them over directly.
+
+	in, out reflect.Type // types received from the previous stage and sent to the next, nil for none.
+	// build creates the runner of the stage from the pipe of the previous stage along with the pipe to the next.
+	build func(in any, buffer int) (ServiceRunner, any)
+}
+
+// PipelineSource is the first stage of a pipeline, the runner built by newRunner sends to the next stage on out.
+func PipelineSource[Out any](name string, newRunner func(out *intracom.Pipe[Out]) ServiceRunner, opts ...ServiceOption) StageSpec {
+	return StageSpec{
+		Name:    name,
+		Options: opts,
+		out:     reflect.TypeOf((*Out)(nil)).Elem(),
+		build: func(_ any, buffer int) (ServiceRunner, any) {
+			out := intracom.NewPipe[Out](buffer)
+			return newRunner(out), out
+		},
+	}
+}
+
+// PipelineStage is a stage in the middle of a pipeline, the runner built by newRunner receives from the previous
+// stage on in and sends to the next stage on out.
+func PipelineStage[In, Out any](name string, newRunner func(in *intracom.Pipe[In], out *intracom.Pipe[Out]) ServiceRunner, opts ...ServiceOption) StageSpec {
+	return StageSpec{
+		Name:    name,
+		Options: opts,
+		in:      reflect.TypeOf((*In)(nil)).Elem(),
+		out:     reflect.TypeOf((*Out)(nil)).Elem(),
+		build: func(in any, buffer int) (ServiceRunner, any) {
+			out := intracom.NewPipe[Out](buffer)
+			return newRunner(in.(*intracom.Pipe[In]), out), out
+		},
+	}
+}
+
+// PipelineSink is the last stage of a pipeline, the runner built by newRunner receives from the previous stage on in.
+func PipelineSink[In any](name string, newRunner func(in *intracom.Pipe[In]) ServiceRunner, opts ...ServiceOption) StageSpec {
+	return StageSpec{
+		Name:    name,
+		Options: opts,
+		in:      reflect.TypeOf((*In)(nil)).Elem(),
+		build: func(in any, _ int) (ServiceRunner, any) {
+			return newRunner(in.(*intracom.Pipe[In])), nil
+		},
+	}
+}
+
+// NewPipeline returns the services of a linear chain of stages, each stage depends on the previous one so it
+// only starts once its predecessor reached StateRun, and receives what its predecessor sends on a typed pipe:
+//
+//	services, err := rxd.NewPipeline(
+//		rxd.PipelineSource("reader", func(out *intracom.Pipe[Line]) rxd.ServiceRunner { return &Reader{out: out} }),
+//		rxd.PipelineStage("parser", func(in *intracom.Pipe[Line], out *intracom.Pipe[Record]) rxd.ServiceRunner { return &Parser{in: in, out: out} }),
+//		rxd.PipelineSink("writer", func(in *intracom.Pipe[Record]) rxd.ServiceRunner { return &Writer{in: in} }),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	err = d.AddServices(services...)
+//
+// The pipes outlive the stages, a stage that restarts keeps receiving where it left off.
+func NewPipeline(stages ...StageSpec) ([]Service, error) {
+	if len(stages) == 0 {
+		return nil, ErrEmptyPipeline
+	}
+
+	services := make([]Service, 0, len(stages))
+	var pipe any
+	for i, stage := range stages {
+		switch {
+		case stage.build == nil:
+			return nil, ErrPipelineStage{Stage: stage.Name, Err: ErrNilService}
+		case i == 0 && stage.in != nil:
+			return nil, ErrPipelineStage{Stage: stage.Name, Err: ErrPipelineSource}
+		case i == len(stages)-1 && stage.out != nil:
+			return nil, ErrPipelineStage{Stage: stage.Name, Err: ErrPipelineSink}
+		case i > 0 && (stage.in == nil || stage.in != stages[i-1].out):
+			return nil, ErrPipelineStage{Stage: stage.Name, Err: ErrPipelineLink}
+		}
+
+		var runner ServiceRunner
+		runner, pipe = stage.build(pipe, stage.Buffer)
+
+		opts := stage.Options
+		if i > 0 {
+			opts = append([]ServiceOption{WithDependsOn(stages[i-1].Name)}, opts...)
+		}
+		services = append(services, NewService(stage.Name, runner, opts...))
+	}
+	return services, nil
+}
@@ -0,0 +1,161 @@
+package rxd
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/intracom"
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// countingSource sends the numbers up to n once it runs.
+type countingSource struct {
+	recordingService
+	n   int
+	out *intracom.Pipe[int]
+}
+
+func (s *countingSource) Run(sctx ServiceContext) error {
+	s.journal.record(s.name + ":run")
+	for i := 1; i <= s.n; i++ {
+		if err := s.out.Send(sctx, i); err != nil {
+			return nil
+		}
+	}
+	<-sctx.Done()
+	return nil
+}
+
+// formattingStage sends the numbers it receives as strings.
+type formattingStage struct {
+	recordingService
+	in  *intracom.Pipe[int]
+	out *intracom.Pipe[string]
+}
+
+func (s *formattingStage) Run(sctx ServiceContext) error {
+	s.journal.record(s.name + ":run")
+	for {
+		n, err := s.in.Receive(sctx)
+		if err != nil {
+			return nil
+		}
+		if err := s.out.Send(sctx, "#"+strconv.Itoa(n)); err != nil {
+			return nil
+		}
+	}
+}
+
+// collectingSink hands the strings it receives to receivedC.
+type collectingSink struct {
+	recordingService
+	in        *intracom.Pipe[string]
+	receivedC chan<- string
+}
+
+func (s *collectingSink) Run(sctx ServiceContext) error {
+	s.journal.record(s.name + ":run")
+	for {
+		select {
+		case <-sctx.Done():
+			return nil
+		case v := <-s.in.C():
+			s.receivedC <- v
+		}
+	}
+}
+
+func TestNewPipeline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	journal := &recordingJournal{}
+	receivedC := make(chan string, 3)
+	source := PipelineSource("source", func(out *intracom.Pipe[int]) ServiceRunner {
+		return &countingSource{recordingService{name: "source", journal: journal}, 3, out}
+	})
+	source.Buffer = 3
+
+	services, err := NewPipeline(
+		source,
+		PipelineStage("format", func(in *intracom.Pipe[int], out *intracom.Pipe[string]) ServiceRunner {
+			return &formattingStage{recordingService{name: "format", journal: journal}, in, out}
+		}),
+		PipelineSink("sink", func(in *intracom.Pipe[string]) ServiceRunner {
+			return &collectingSink{recordingService{name: "sink", journal: journal}, in, receivedC}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("error building pipeline: %s", err)
+	}
+
+	d := NewDaemon("test-daemon", WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())), WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())))
+	if err := d.AddServices(services...); err != nil {
+		t.Fatalf("error adding services: %s", err)
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	for _, want := range []string{"#1", "#2", "#3"} {
+		select {
+		case got := <-receivedC:
+			if got != want {
+				t.Errorf("expected %s, got %s", want, got)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for %s", want)
+		}
+	}
+
+	if !(journal.index("source:run") < journal.index("format:init") && journal.index("format:run") < journal.index("sink:init")) {
+		t.Errorf("expected every stage to start once the previous one runs, got %v", journal.entries)
+	}
+
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+}
+
+func TestNewPipeline_Invalid(t *testing.T) {
+	newSource := func(name string) StageSpec {
+		return PipelineSource(name, func(out *intracom.Pipe[int]) ServiceRunner { return &recordingService{} })
+	}
+	newSink := func(name string) StageSpec {
+		return PipelineSink(name, func(in *intracom.Pipe[int]) ServiceRunner { return &recordingService{} })
+	}
+	stringSink := PipelineSink("strings", func(in *intracom.Pipe[string]) ServiceRunner { return &recordingService{} })
+
+	tests := []struct {
+		name   string
+		stages []StageSpec
+		stage  string
+		err    error
+	}{
+		{"empty", nil, "", ErrEmptyPipeline},
+		{"no source", []StageSpec{newSink("first"), newSink("second")}, "first", ErrPipelineSource},
+		{"no sink", []StageSpec{newSource("first"), newSource("second")}, "second", ErrPipelineSink},
+		{"mismatch", []StageSpec{newSource("first"), stringSink}, "strings", ErrPipelineLink},
+		{"disconnected", []StageSpec{newSource("a"), newSink("b"), newSource("c"), newSink("d")}, "c", ErrPipelineLink},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPipeline(tt.stages...)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %s, got %v", tt.err, err)
+			}
+
+			var stageErr ErrPipelineStage
+			if tt.stage != "" && (!errors.As(err, &stageErr) || stageErr.Stage != tt.stage) {
+				t.Errorf("expected the error to name stage %s, got %v", tt.stage, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package rxd
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+const (
+	// pollMaxBackoff caps the growth of the poll interval, as a multiple of the initial interval.
+	pollMaxBackoff = 32
+	// pollJitter is the fraction of the poll interval that is randomized.
+	pollJitter = 0.2
+)
+
+// PollUntil calls fn until it reports done, returns an error or the timeout elapses, e.g. in Init to wait for
+// a dependency to come up. The interval between calls starts at interval and doubles after every call, up to
+// 32 times interval, with jitter so the services of a fleet do not poll in lockstep. fn is given a context
+// that is done once the timeout elapses or the service is stopped, a timeout of 0 or less polls until then.
+// PollUntil returns ErrPollTimeout once the timeout elapsed, or the error of the context of the service.
+func (sc *serviceContext) PollUntil(interval, timeout time.Duration, fn func(ctx context.Context) (done bool, err error)) error {
+	var ctx context.Context = sc
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(sc, timeout)
+		defer cancel()
+	}
+
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	delay := interval
+	for attempt := 1; ; attempt++ {
+		done, err := fn(ctx)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		wait := time.Duration(float64(delay) * (1 + pollJitter*(2*rand.Float64()-1)))
+		sc.Log(log.LevelDebug, "condition not met yet, polling again", log.Int("attempt", attempt), log.String("delay", wait.String()))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if err := sc.Err(); err != nil {
+				return err
+			}
+			return ErrPollTimeout
+		case <-timer.C:
+		}
+
+		delay = min(2*delay, pollMaxBackoff*interval)
+	}
+}
@@ -0,0 +1,134 @@
+package rxd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+const (
+	preconditionInitialBackoff = 250 * time.Millisecond
+	preconditionMaxBackoff     = 10 * time.Second
+	preconditionAttemptTimeout = 5 * time.Second
+)
+
+// Precondition is a gate that must pass before the manager moves a service into Init,
+// e.g. a dependency being reachable over the network.
+// Check is retried with an exponential backoff until it passes or the service context is done.
+type Precondition struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// WaitForDNS waits until host resolves to at least one address.
+func WaitForDNS(host string) Precondition {
+	return Precondition{
+		Name: "dns " + host,
+		Check: func(ctx context.Context) error {
+			addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+			if err != nil {
+				return err
+			}
+			if len(addrs) == 0 {
+				return errors.New("no addresses found for " + host)
+			}
+			return nil
+		},
+	}
+}
+
+// WaitForTCP waits until a tcp connection can be established to addr (host:port).
+func WaitForTCP(addr string) Precondition {
+	return Precondition{
+		Name: "tcp " + addr,
+		Check: func(ctx context.Context) error {
+			var dialer net.Dialer
+			conn, err := dialer.DialContext(ctx, "tcp", addr)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		},
+	}
+}
+
+// WaitForFile waits until a file or directory exists at path.
+func WaitForFile(path string) Precondition {
+	return Precondition{
+		Name: "file " + path,
+		Check: func(ctx context.Context) error {
+			_, err := os.Stat(path)
+			return err
+		},
+	}
+}
+
+// WaitForHTTP waits until a GET request to url responds with the given status code.
+func WaitForHTTP(url string, status int) Precondition {
+	return Precondition{
+		Name: "http " + url,
+		Check: func(ctx context.Context) error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return err
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != status {
+				return errors.New("unexpected status " + strconv.Itoa(resp.StatusCode) + ", want " + strconv.Itoa(status))
+			}
+			return nil
+		},
+	}
+}
+
+// awaitPreconditions blocks until every precondition of the service has passed, in order.
+// Each failed attempt is logged and retried with an exponential backoff.
+// A flapping service is quarantined first, see WithQuarantine.
+// An error is only returned when the service context is done before the preconditions passed.
+func awaitPreconditions(sctx ServiceContext) error {
+	sc, ok := sctx.(*serviceContext)
+	if !ok {
+		return nil
+	}
+	if err := sc.quarantine.await(sctx); err != nil {
+		return err
+	}
+
+	for _, pre := range sc.preconditions {
+		backoff := preconditionInitialBackoff
+		for attempt := 1; ; attempt++ {
+			ctx, cancel := context.WithTimeout(sctx, preconditionAttemptTimeout)
+			err := pre.Check(ctx)
+			cancel()
+			if err == nil {
+				sctx.Log(log.LevelDebug, "precondition passed", log.String("precondition", pre.Name), log.Int("attempt", attempt))
+				break
+			}
+
+			sctx.Log(log.LevelWarning, "precondition not met", log.String("precondition", pre.Name), log.Int("attempt", attempt), log.Error("error", err), log.String("retry_in", backoff.String()))
+
+			sleep(sctx, backoff)
+			if sctx.Err() != nil {
+				return ErrPreconditionNotMet
+			}
+
+			backoff *= 2
+			if backoff > preconditionMaxBackoff {
+				backoff = preconditionMaxBackoff
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package rxd
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPrecondition_Checks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %s", err)
+	}
+	defer listener.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	passing := []Precondition{
+		WaitForTCP(listener.Addr().String()),
+		WaitForHTTP(server.URL, http.StatusNoContent),
+		WaitForFile(t.TempDir()),
+		WaitForDNS("localhost"),
+	}
+	for _, pre := range passing {
+		if err := pre.Check(ctx); err != nil {
+			t.Errorf("expected %s to pass, got: %s", pre.Name, err)
+		}
+	}
+
+	failing := []Precondition{
+		WaitForHTTP(server.URL, http.StatusOK),
+		WaitForFile(filepath.Join(t.TempDir(), "missing")),
+	}
+	for _, pre := range failing {
+		if err := pre.Check(ctx); err == nil {
+			t.Errorf("expected %s to fail", pre.Name)
+		}
+	}
+}
+
+func TestPrecondition_AwaitRetries(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "ready")
+	logC := make(chan DaemonLog, 10)
+	sctx, scancel := newServiceContextWithCancel(ctx, "test-service", logC, nil, contextConfig{
+		preconditions: []Precondition{WaitForFile(path)},
+	})
+	defer scancel()
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		os.WriteFile(path, nil, 0o600)
+	}()
+
+	if err := awaitPreconditions(sctx); err != nil {
+		t.Fatalf("expected preconditions to pass, got: %s", err)
+	}
+
+	scancel()
+	os.Remove(path)
+	if err := awaitPreconditions(sctx); err != ErrPreconditionNotMet {
+		t.Fatalf("expected %s, got: %v", ErrPreconditionNotMet, err)
+	}
+}
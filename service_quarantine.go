@@ -0,0 +1,86 @@
+package rxd
+
+import (
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// quarantinePolicy is when a flapping service is quarantined and for how long, see WithQuarantine.
+type quarantinePolicy struct {
+	cycles   int           // restarts allowed within the window, 0 disables the quarantine.
+	window   time.Duration // time the restarts are counted over.
+	cooldown time.Duration // time the service is held in StateQuarantined.
+}
+
+// quarantine counts the restarts of a service, once it cycled from Init to Stop more times than the policy
+// allows within its window the service is held in StateQuarantined for the cool-down before entering Init again.
+// It is only used by the manager routine of the service, through awaitPreconditions.
+type quarantine struct {
+	policy  quarantinePolicy
+	service string
+	stateC  chan<- StateUpdate
+	started bool
+	inits   []time.Time // times the service entered Init again within the window.
+}
+
+func newQuarantine(service string, policy quarantinePolicy, stateC chan<- StateUpdate) *quarantine {
+	if policy.cycles <= 0 {
+		return nil
+	}
+	return &quarantine{policy: policy, service: service, stateC: stateC}
+}
+
+// newQuarantine returns the quarantine of the service, following the policy of the daemon unless it has its own.
+// Isolated services apply their manager policy in the child process, they are not quarantined.
+func (d *daemon) newQuarantine(service string, conf serviceConfig, stateC chan<- StateUpdate) *quarantine {
+	if conf.isolated {
+		return nil
+	}
+	policy := conf.quarantine
+	if policy.cycles <= 0 {
+		policy = d.quarantine
+	}
+	return newQuarantine(service, policy, stateC)
+}
+
+// await is called every time the service enters Init. It blocks for the cool-down once the service is flapping,
+// an error is only returned when the service context is done before the cool-down elapsed.
+func (q *quarantine) await(sctx ServiceContext) error {
+	if q == nil {
+		return nil
+	}
+	if !q.started {
+		// entering Init for the first time is not a restart.
+		q.started = true
+		return nil
+	}
+
+	now := time.Now()
+	recent := q.inits[:0]
+	for _, at := range q.inits {
+		if now.Sub(at) < q.policy.window {
+			recent = append(recent, at)
+		}
+	}
+	q.inits = append(recent, now)
+	if len(q.inits) <= q.policy.cycles {
+		return nil
+	}
+
+	sctx.Log(log.LevelAlert, "service is flapping, quarantining it",
+		log.Int("cycles", len(q.inits)),
+		log.String("window", q.policy.window.String()),
+		log.String("cooldown", q.policy.cooldown.String()),
+	)
+	q.stateC <- StateUpdate{Name: q.service, State: StateQuarantined}
+	sleep(sctx, q.policy.cooldown)
+	if sctx.Err() != nil {
+		return ErrPreconditionNotMet
+	}
+
+	q.inits = q.inits[:0]
+	sctx.Log(log.LevelNotice, "service cooled down, leaving quarantine")
+	q.stateC <- StateUpdate{Name: q.service, State: StateInit}
+	return nil
+}
@@ -0,0 +1,93 @@
+package rxd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// brokenService fails every Init.
+type brokenService struct {
+	recordingService
+}
+
+func (b *brokenService) Init(sctx ServiceContext) error {
+	b.journal.record(b.name + ":init")
+	return errors.New("missing configuration")
+}
+
+func TestDaemon_Quarantine(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var quarantinedAt, leftAt time.Time
+	var alerts int
+	journal := &recordingJournal{}
+	d := NewDaemon("test-daemon",
+		WithServiceLogger(log.NewLogger(log.LevelError, newTestLogger())),
+		UsingQuarantine(3, time.Second, 100*time.Millisecond),
+		UsingTransitionHook(func(service string, from, to State) {
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case to == StateQuarantined && quarantinedAt.IsZero():
+				quarantinedAt = time.Now()
+			case from == StateQuarantined && leftAt.IsZero():
+				leftAt = time.Now()
+			}
+		}),
+		UsingEmergencyHook(func(e Emergency) {
+			mu.Lock()
+			defer mu.Unlock()
+			alerts++
+		}),
+	).(*daemon)
+	manager := RunContinuousManager{StartupDelay: time.Millisecond, DefaultDelay: time.Millisecond, StateTimeouts: ManagerStateTimeouts{}}
+	if err := d.AddService(NewService("broken", &brokenService{recordingService{name: "broken", journal: journal}}, WithManager(manager))); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		left := !leftAt.IsZero()
+		mu.Unlock()
+		if left {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected broken to be quarantined and to leave the quarantine, history: %v", d.History("broken"))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	cooldown := leftAt.Sub(quarantinedAt)
+	if cooldown < 100*time.Millisecond {
+		t.Errorf("expected broken to be held for the cooldown, left after %s", cooldown)
+	}
+	if alerts == 0 {
+		t.Errorf("expected the quarantine to be alerted")
+	}
+	mu.Unlock()
+
+	// the first Init and the 3 restarts allowed within the window, the next one is quarantined.
+	if inits := journal.count("broken:init"); inits < 4 {
+		t.Errorf("expected broken to be restarted 3 times before its quarantine, got %d inits", inits)
+	}
+
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+}
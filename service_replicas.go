@@ -0,0 +1,130 @@
+package rxd
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// rolloutPollInterval is how often a rolling restart checks whether the recycled replicas recovered.
+const rolloutPollInterval = 50 * time.Millisecond
+
+// NewReplicas creates a group of replicas of a service, named name-0 up to name-N, each with a runner of its own
+// built by newRunner and the same options. Add it with AddServiceGroup and restart it with RollingRestart.
+func NewReplicas(name string, replicas int, newRunner func(replica int) ServiceRunner, options ...ServiceOption) ServiceGroup {
+	services := make([]Service, 0, replicas)
+	for i := 0; i < replicas; i++ {
+		services = append(services, NewService(name+"-"+strconv.Itoa(i), newRunner(i), options...))
+	}
+	return NewServiceGroup(name, services...)
+}
+
+// RollingRestart recycles the members of the group, such as the replicas created with NewReplicas, maxUnavailable
+// at a time so the others keep serving. A batch must be back in StateRun, and pass a health check for replicas
+// implementing HealthChecker, before the next batch is recycled. The restart is aborted with an ErrRollingRestart
+// once a batch does not recover within the rollout timeout (see WithRolloutTimeout), the members that were not
+// recycled yet keep running.
+func (d *daemon) RollingRestart(name string, maxUnavailable int) error {
+	d.mu.RLock()
+	members, ok := d.groups[name]
+	rt := d.rt
+	timeout := d.rolloutTimeout
+	d.mu.RUnlock()
+	if !ok {
+		return ErrGroupNotFound
+	}
+	if rt == nil {
+		return ErrDaemonNotStarted
+	}
+	if maxUnavailable < 1 {
+		maxUnavailable = 1
+	}
+
+	groupField, nameField := log.String("group", name), log.String("rxd", d.name)
+	d.internalLogger.Log(log.LevelInfo, "starting rolling restart of service group", groupField, log.Int("replicas", len(members)), log.Int("max_unavailable", maxUnavailable), nameField)
+	for start := 0; start < len(members); start += maxUnavailable {
+		batch := members[start:min(start+maxUnavailable, len(members))]
+
+		generations := make(map[string]uint64, len(batch))
+		for _, member := range batch {
+			d.mu.RLock()
+			run, running := d.runs[member]
+			d.mu.RUnlock()
+			if !running || run.exited() {
+				return d.abortRollout(name, []string{member}, ErrServiceNotRunning)
+			}
+
+			generations[member] = run.generation.current()
+			if err := d.Recycle(member); err != nil {
+				return d.abortRollout(name, []string{member}, err)
+			}
+		}
+
+		if pending, err := d.awaitRecovered(rt.ctx, batch, generations, timeout); err != nil {
+			return d.abortRollout(name, pending, err)
+		}
+		d.internalLogger.Log(log.LevelInfo, "service group batch recovered", groupField, log.String("services", strings.Join(batch, ",")), nameField)
+	}
+
+	d.internalLogger.Log(log.LevelInfo, "completed rolling restart of service group", groupField, nameField)
+	return nil
+}
+
+// awaitRecovered waits until every member of the batch entered Init again, is back in StateRun and, when it
+// implements HealthChecker, passed a health check since. It returns the members that did not recover in time.
+func (d *daemon) awaitRecovered(ctx context.Context, batch []string, generations map[string]uint64, timeout time.Duration) ([]string, error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(rolloutPollInterval)
+	defer ticker.Stop()
+
+	// when each member was first seen running its new generation, health checks must have completed after it.
+	runningSince := make(map[string]time.Time, len(batch))
+	for {
+		select {
+		case <-ctx.Done():
+			return batch, ErrDaemonStopping
+		case <-deadline.C:
+			return batch, ErrRolloutTimeout
+		case <-ticker.C:
+		}
+
+		states, health := d.readiness.current(), d.health.get()
+		var pending []string
+		for _, member := range batch {
+			d.mu.RLock()
+			run, running := d.runs[member]
+			_, checked := d.services[member].Runner.(HealthChecker)
+			d.mu.RUnlock()
+			if !running || run.exited() {
+				return []string{member}, ErrServiceNotRunning
+			}
+
+			if run.generation.current() <= generations[member] || states[member] != StateRun {
+				delete(runningSince, member)
+				pending = append(pending, member)
+				continue
+			}
+			if _, ok := runningSince[member]; !ok {
+				runningSince[member] = time.Now()
+			}
+			if checked && (health[member].Status != HealthHealthy || !health[member].CheckedAt.After(runningSince[member])) {
+				pending = append(pending, member)
+			}
+		}
+
+		if len(pending) == 0 {
+			return nil, nil
+		}
+		batch = pending
+	}
+}
+
+// abortRollout logs and returns the error of a rolling restart that could not go on.
+func (d *daemon) abortRollout(group string, services []string, err error) error {
+	d.internalLogger.Log(log.LevelError, "aborted rolling restart of service group", log.String("group", group), log.String("services", strings.Join(services, ",")), log.Error("error", err), log.String("rxd", d.name))
+	return ErrRollingRestart{Group: group, Services: services, Err: err}
+}
@@ -0,0 +1,171 @@
+package rxd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// replicaService is a replica counting its inits, unhealthy once recycled when failAfterRecycle is set.
+type replicaService struct {
+	inits            atomic.Int32
+	failAfterRecycle bool
+}
+
+func (s *replicaService) Init(sctx ServiceContext) error {
+	s.inits.Add(1)
+	return nil
+}
+
+func (s *replicaService) Idle(sctx ServiceContext) error { return nil }
+
+func (s *replicaService) Run(sctx ServiceContext) error {
+	<-sctx.Done()
+	return nil
+}
+
+func (s *replicaService) Stop(sctx ServiceContext) error { return nil }
+
+func (s *replicaService) HealthCheck(ctx context.Context) error {
+	if s.failAfterRecycle && s.inits.Load() > 1 {
+		return errors.New("warming up")
+	}
+	return nil
+}
+
+// startReplicas runs a daemon with the replicas of group "api" until every replica is running.
+func startReplicas(t *testing.T, ctx context.Context, replicas []*replicaService, options ...DaemonOption) (*daemon, <-chan error) {
+	t.Helper()
+
+	options = append(options,
+		WithHealthChecks(20*time.Millisecond, time.Second),
+		WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+	)
+	return runReplicas(t, ctx, NewDaemon("test-daemon", options...).(*daemon), replicas)
+}
+
+// runReplicas runs the daemon with the replicas of group "api" until every replica is running.
+func runReplicas(t *testing.T, ctx context.Context, d *daemon, replicas []*replicaService) (*daemon, <-chan error) {
+	t.Helper()
+
+	group := NewReplicas("api", len(replicas), func(replica int) ServiceRunner { return replicas[replica] })
+	if err := d.AddServiceGroup(group); err != nil {
+		t.Fatalf("error adding replicas: %s", err)
+	}
+
+	errC := make(chan error, 1)
+	go func() { errC <- d.Start(ctx) }()
+
+	for {
+		d.mu.RLock()
+		readiness := d.readiness
+		d.mu.RUnlock()
+		if readiness != nil && groupState(readiness.current(), group.members()) == GroupRunning {
+			return d, errC
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("expected the replicas to run")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestDaemon_RollingRestart(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// the replicas out of StateRun at once, recorded by the transition hook.
+	var mu sync.Mutex
+	down, maxDown := map[string]bool{}, 0
+	hook := func(service string, from, to State) {
+		mu.Lock()
+		defer mu.Unlock()
+		if from == StateRun && to != StateRun {
+			down[service] = true
+		} else if to == StateRun {
+			delete(down, service)
+		}
+		maxDown = max(maxDown, len(down))
+	}
+
+	replicas := []*replicaService{{}, {}, {}, {}}
+	d, errC := startReplicas(t, ctx, replicas, UsingTransitionHook(hook))
+
+	if err := d.RollingRestart("api", 2); err != nil {
+		t.Fatalf("error restarting replicas: %s", err)
+	}
+	for i, replica := range replicas {
+		if got := replica.inits.Load(); got != 2 {
+			t.Errorf("expected replica %d to be recycled once, got %d inits", i, got)
+		}
+	}
+	mu.Lock()
+	if maxDown > 2 {
+		t.Errorf("expected at most 2 replicas out of StateRun at once, got %d", maxDown)
+	}
+	mu.Unlock()
+
+	if err := d.RollingRestart("unknown", 1); err != ErrGroupNotFound {
+		t.Errorf("expected %s, got %v", ErrGroupNotFound, err)
+	}
+
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error running daemon: %s", err)
+	}
+}
+
+func TestDaemon_RollingRestartAborts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	replicas := []*replicaService{{failAfterRecycle: true}, {}, {}}
+	d, errC := startReplicas(t, ctx, replicas, WithRolloutTimeout(300*time.Millisecond))
+
+	var rollErr ErrRollingRestart
+	err := d.RollingRestart("api", 1)
+	if !errors.As(err, &rollErr) || !errors.Is(err, ErrRolloutTimeout) {
+		t.Fatalf("expected the rolling restart to time out, got %v", err)
+	}
+	if len(rollErr.Services) != 1 || rollErr.Services[0] != "api-0" {
+		t.Errorf("expected api-0 not to recover, got %v", rollErr.Services)
+	}
+	if replicas[1].inits.Load() != 1 || replicas[2].inits.Load() != 1 {
+		t.Errorf("expected the other replicas not to be recycled once aborted")
+	}
+
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error running daemon: %s", err)
+	}
+}
+
+func TestDaemon_RollingRestartWithLogger(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// the daemon built with NewDaemonWithLogger gets the default rollout timeout of NewDaemon.
+	logger := log.NewLogger(log.LevelDebug, newTestLogger())
+	replicas := []*replicaService{{}, {}}
+	d, errC := runReplicas(t, ctx, NewDaemonWithLogger("test-daemon", logger, WithHealthChecks(20*time.Millisecond, time.Second)).(*daemon), replicas)
+
+	if err := d.RollingRestart("api", 1); err != nil {
+		t.Fatalf("error restarting replicas: %s", err)
+	}
+	for i, replica := range replicas {
+		if got := replica.inits.Load(); got != 2 {
+			t.Errorf("expected replica %d to be recycled once, got %d inits", i, got)
+		}
+	}
+
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error running daemon: %s", err)
+	}
+}
@@ -0,0 +1,20 @@
+package rxd
+
+// ResolveFunc builds the runner of the named service, see UsingResolver.
+type ResolveFunc func(service string) (ServiceRunner, error)
+
+// resolve builds the runner of a service added without one, ErrNilService without a resolver.
+func (d *daemon) resolve(name string) (ServiceRunner, error) {
+	if d.resolver == nil {
+		return nil, ErrNilService
+	}
+
+	runner, err := d.resolver(name)
+	if err != nil {
+		return nil, ErrResolve{Service: name, Err: err}
+	}
+	if runner == nil {
+		return nil, ErrResolve{Service: name, Err: ErrNilService}
+	}
+	return runner, nil
+}
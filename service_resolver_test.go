@@ -0,0 +1,58 @@
+package rxd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+func TestDaemon_Resolver(t *testing.T) {
+	journal := &recordingJournal{}
+	errMissing := errors.New("no factory registered")
+
+	var resolved []string
+	d := NewDaemon("test-daemon",
+		UsingResolver(func(service string) (ServiceRunner, error) {
+			resolved = append(resolved, service)
+			if service == "missing" {
+				return nil, errMissing
+			}
+			return &failingService{recordingService{name: service, journal: journal}, nil}, nil
+		}),
+		WithInternalLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+		WithServiceLogger(log.NewLogger(log.LevelDebug, newTestLogger())),
+	)
+
+	err := d.AddServices(
+		NewService("resolved", nil, WithManager(NewRunOnceManager(0))),
+		NewService("static", &failingService{recordingService{name: "static", journal: journal}, nil}, WithManager(NewRunOnceManager(0))),
+	)
+	if err != nil {
+		t.Fatalf("error adding services: %s", err)
+	}
+
+	var resolveErr ErrResolve
+	if err := d.AddService(NewService("missing", nil)); !errors.As(err, &resolveErr) || resolveErr.Service != "missing" || !errors.Is(err, errMissing) {
+		t.Fatalf("expected the resolver error of the missing service, got %v", err)
+	}
+
+	if len(resolved) != 2 || resolved[0] != "resolved" {
+		t.Errorf("expected only the services without a runner to be resolved, got %v", resolved)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.Start(ctx); err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+	if journal.count("resolved:run") != 1 {
+		t.Errorf("expected the resolved runner to run")
+	}
+
+	if err := NewDaemon("test-daemon").AddService(NewService("nil", nil)); err != ErrNilService {
+		t.Errorf("expected %s without a resolver, got %v", ErrNilService, err)
+	}
+}
@@ -0,0 +1,59 @@
+package rxd
+
+// RestartPolicy declares what happens once the Run lifecycle of a service returns on its own,
+// mirroring the Restart= setting of systemd units. It is honored by RunContinuousManager and
+// ExponentialBackoffManager, returning from Run because the service was stopped never restarts it.
+type RestartPolicy uint8
+
+const (
+	// RestartAlways stops and re-initializes the service whether Run failed or not, this is the default.
+	RestartAlways RestartPolicy = iota
+	// RestartOnFailure restarts the service only when Run returned an error, otherwise the service exits.
+	RestartOnFailure
+	// RestartNever stops the service once Run returns and exits it, the rest of the daemon keeps running.
+	RestartNever
+	// RestartExitDaemon stops the service once Run returns and shuts down the whole daemon.
+	RestartExitDaemon
+)
+
+func (p RestartPolicy) String() string {
+	switch p {
+	case RestartAlways:
+		return "always"
+	case RestartOnFailure:
+		return "on-failure"
+	case RestartNever:
+		return "never"
+	case RestartExitDaemon:
+		return "exit-daemon"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseRestartPolicy returns the restart policy named name, the inverse of RestartPolicy.String.
+func ParseRestartPolicy(name string) (RestartPolicy, bool) {
+	for _, policy := range []RestartPolicy{RestartAlways, RestartOnFailure, RestartNever, RestartExitDaemon} {
+		if policy.String() == name {
+			return policy, true
+		}
+	}
+	return RestartAlways, false
+}
+
+// restartAfterRun reports whether the restart policy of the service restarts it after Run returned err.
+func restartAfterRun(sctx ServiceContext, err error) bool {
+	sc, ok := sctx.(*serviceContext)
+	if !ok {
+		return true
+	}
+
+	switch sc.restartPolicy {
+	case RestartOnFailure:
+		return err != nil
+	case RestartNever, RestartExitDaemon:
+		return false
+	default:
+		return true
+	}
+}
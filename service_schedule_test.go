@@ -0,0 +1,93 @@
+package rxd
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseCron_Next(t *testing.T) {
+	base := time.Date(2024, time.January, 31, 10, 7, 30, 0, time.Local) // a wednesday
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, time.January, 31, 10, 8, 0, 0, time.Local)},
+		{"*/15 * * * *", time.Date(2024, time.January, 31, 10, 15, 0, 0, time.Local)},
+		{"0 9-17 * * 1-5", time.Date(2024, time.January, 31, 11, 0, 0, 0, time.Local)},
+		{"30 2 * * *", time.Date(2024, time.February, 1, 2, 30, 0, 0, time.Local)},
+		{"0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.Local)},
+		{"0 12 * * 7", time.Date(2024, time.February, 4, 12, 0, 0, 0, time.Local)},
+		{"0 0 15 * 1", time.Date(2024, time.February, 5, 0, 0, 0, 0, time.Local)},
+		{"@monthly", time.Date(2024, time.February, 1, 0, 0, 0, 0, time.Local)},
+	}
+
+	for _, tt := range tests {
+		schedule, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("error parsing %q: %s", tt.expr, err)
+		}
+		if got := schedule.Next(base); !got.Equal(tt.want) {
+			t.Errorf("%q: expected %s, got %s", tt.expr, tt.want, got)
+		}
+	}
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := ParseCron(expr); err != ErrInvalidCronExpression {
+			t.Errorf("%q: expected %s, got %v", expr, ErrInvalidCronExpression, err)
+		}
+	}
+}
+
+// slowRunService counts its runs, each run lasts for the given duration.
+type slowRunService struct {
+	recordingService
+	runFor time.Duration
+	runs   atomic.Int32
+}
+
+func (s *slowRunService) Run(sctx ServiceContext) error {
+	s.runs.Add(1)
+	select {
+	case <-sctx.Done():
+	case <-time.After(s.runFor):
+	}
+	return nil
+}
+
+func TestScheduleManager_Overlap(t *testing.T) {
+	tests := []struct {
+		overlap  OverlapPolicy
+		min, max int32
+	}{
+		// activations every 20ms with runs of 50ms over 210ms.
+		{OverlapSkip, 2, 4},
+		{OverlapConcurrent, 8, 11},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.overlap.String(), func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 210*time.Millisecond)
+			defer cancel()
+
+			runner := &slowRunService{recordingService: recordingService{name: "job", journal: &recordingJournal{}}, runFor: 50 * time.Millisecond}
+			m := NewScheduleManager(Every(20*time.Millisecond), tt.overlap)
+
+			sctx, scancel := newServiceContextWithCancel(ctx, "job", make(chan DaemonLog, 100), nil, contextConfig{})
+			defer scancel()
+
+			updateC := make(chan StateUpdate, 100)
+			m.Manage(sctx, DaemonService{Name: "job", Runner: runner}, updateC)
+
+			if runs := runner.runs.Load(); runs < tt.min || runs > tt.max {
+				t.Errorf("expected between %d and %d runs, got %d", tt.min, tt.max, runs)
+			}
+
+			if init, stop := runner.journal.count("job:init"), runner.journal.count("job:stop"); init != 1 || stop != 1 {
+				t.Errorf("expected a single init and stop, got %v", runner.journal.entries)
+			}
+		})
+	}
+}
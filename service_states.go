@@ -1,7 +1,10 @@
 package rxd
 
 import (
+	"maps"
 	"strings"
+
+	"github.com/ambitiousfew/rxd/intracom"
 )
 
 const (
@@ -10,6 +13,9 @@ const (
 	StateIdle
 	StateRun
 	StateStop
+	StateReload      // the service is reloading its configuration in place, see Reloader.
+	StatePaused      // the service is registered but waits to be resumed before it starts, see WithStartPaused.
+	StateQuarantined // the service was flapping and cools down before it enters Init again, see WithQuarantine.
 )
 
 type State uint8
@@ -24,6 +30,12 @@ func (s State) String() string {
 		return "run"
 	case StateStop:
 		return "stop"
+	case StateReload:
+		return "reload"
+	case StatePaused:
+		return "paused"
+	case StateQuarantined:
+		return "quarantined"
 	case StateExit:
 		return "exit"
 	default:
@@ -31,12 +43,26 @@ func (s State) String() string {
 	}
 }
 
+// states lists every state in lifecycle order.
+var states = []State{StateExit, StateInit, StateIdle, StateRun, StateStop, StateReload, StatePaused, StateQuarantined}
+
+// ParseState returns the state named name, the inverse of State.String.
+func ParseState(name string) (State, bool) {
+	for _, state := range states {
+		if state.String() == name {
+			return state, true
+		}
+	}
+	return StateExit, false
+}
+
 type ServiceStates map[string]State
 
 func (s ServiceStates) copy() ServiceStates {
-	c := make(ServiceStates, len(s))
-	for k, v := range s {
-		c[k] = v
+	// maps.Clone copies the buckets at once, which is much cheaper than re-inserting every entry for large daemons.
+	c := maps.Clone(s)
+	if c == nil {
+		c = ServiceStates{}
 	}
 	return c
 }
@@ -50,6 +76,8 @@ type StatesResponse struct {
 type StateUpdate struct {
 	Name  string
 	State State
+
+	removed bool // set by the daemon when the service has been removed.
 }
 
 // States is a map of service name to service state which
@@ -57,17 +85,17 @@ type StateUpdate struct {
 type States map[string]State
 
 // internalAllStatesConsumer returns a string that represents the internal consumer name
-// this is an internal helper to help build a more unique consumer name for the internal states
-// to prevent overlapping consumer group names within the same service
-// format: _rxd.states.all.<consumer>
-func internalAllStatesConsumer(consumer string) string {
-	return strings.Join([]string{internalServiceStates, "all", consumer}, ".")
+// this is an internal helper to build a unique consumer name for the internal states per watcher,
+// so watchers of the same service never share or unsubscribe each other's subscription
+// format: _rxd.states.all.<consumer>#<id>
+func internalAllStatesConsumer(ic *intracom.Intracom, consumer string) string {
+	return intracom.ConsumerID(ic, strings.Join([]string{internalServiceStates, "all", consumer}, "."))
 }
 
 // internalStatesConsumer returns a string that represents the internal consumer name
-// this is an internal helper to help build a more unique consumer name for the internal states
-// to prevent overlapping consumer group names within the same service
-// format: _rxd.states.<action>.<target>.<consumer>
-func internalStatesConsumer(action ServiceAction, target State, consumer string) string {
-	return strings.Join([]string{internalServiceStates, action.String(), target.String(), consumer}, ".")
+// this is an internal helper to build a unique consumer name for the internal states per watcher,
+// so watchers of the same condition within the same service never share or unsubscribe each other's subscription
+// format: _rxd.states.<action>.<target>.<consumer>#<id>
+func internalStatesConsumer(ic *intracom.Intracom, action ServiceAction, target State, consumer string) string {
+	return intracom.ConsumerID(ic, strings.Join([]string{internalServiceStates, action.String(), target.String(), consumer}, "."))
 }
@@ -0,0 +1,84 @@
+package rxd
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// stdioCapture guards the process wide os.Stdout and os.Stderr replacement.
+var stdioCapture = struct {
+	active bool
+	mu     sync.Mutex
+}{}
+
+// CaptureStdio redirects direct writes to os.Stdout and os.Stderr (e.g. fmt.Println calls in third-party libraries)
+// into the logger of the given service context, stdout lines are logged at info level and stderr lines at error level.
+// The returned release function restores the original os.Stdout and os.Stderr and must be called when done,
+// typically in the Stop lifecycle of the service that captured.
+//
+// Limitations:
+//   - os.Stdout and os.Stderr are process wide, so only one capture may be active at a time and every write
+//     made while it is active is attributed to the capturing service, regardless of which routine wrote it.
+//   - only writes through the os.Stdout and os.Stderr variables are captured, writes straight to file
+//     descriptors 1 and 2 (e.g. from cgo code) and writers that kept a reference to the original files are not.
+//   - log handlers must not be created while a capture is active, or they would write back into the capture.
+func CaptureStdio(sctx ServiceContext) (release func(), err error) {
+	stdioCapture.mu.Lock()
+	defer stdioCapture.mu.Unlock()
+
+	if stdioCapture.active {
+		return nil, ErrStdioCaptured
+	}
+
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	errR, errW, err := os.Pipe()
+	if err != nil {
+		outR.Close()
+		outW.Close()
+		return nil, err
+	}
+
+	origOut, origErr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = outW, errW
+	stdioCapture.active = true
+
+	var readers sync.WaitGroup
+	readers.Add(2)
+	go relayStdio(&readers, sctx, outR, log.LevelInfo, "stdout")
+	go relayStdio(&readers, sctx, errR, log.LevelError, "stderr")
+
+	var once sync.Once
+	release = func() {
+		once.Do(func() {
+			stdioCapture.mu.Lock()
+			os.Stdout, os.Stderr = origOut, origErr
+			stdioCapture.active = false
+			stdioCapture.mu.Unlock()
+
+			// closing the write ends lets the relays drain what is left and exit.
+			outW.Close()
+			errW.Close()
+			readers.Wait()
+		})
+	}
+
+	return release, nil
+}
+
+func relayStdio(wg *sync.WaitGroup, sctx ServiceContext, r io.ReadCloser, level log.Level, stream string) {
+	defer wg.Done()
+	defer r.Close()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		sctx.Log(level, scanner.Text(), log.String("stream", stream))
+	}
+}
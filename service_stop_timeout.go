@@ -0,0 +1,83 @@
+package rxd
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// stopGuard relays the states and logs of a service to the daemon so the service can be abandoned
+// when it does not exit within its stop timeout, without the abandoned routine ever writing to
+// daemon channels that are closed on shutdown. Writes of an abandoned routine simply block.
+type stopGuard struct {
+	timeout time.Duration
+	capped  *atomic.Int64 // caps the timeout once set by a signal shutdown, see WithSignalShutdown.
+	stateC  chan StateUpdate
+	logC    chan DaemonLog
+}
+
+func newStopGuard(timeout time.Duration, capped *atomic.Int64) *stopGuard {
+	return &stopGuard{
+		timeout: timeout,
+		capped:  capped,
+		stateC:  make(chan StateUpdate),
+		logC:    make(chan DaemonLog),
+	}
+}
+
+// stopTimeout returns the time the service has to exit once stopped, 0 waits forever.
+func (g *stopGuard) stopTimeout() time.Duration {
+	if g.capped != nil {
+		if capped := time.Duration(g.capped.Load()); capped > 0 && (g.timeout <= 0 || capped < g.timeout) {
+			return capped
+		}
+	}
+	return g.timeout
+}
+
+// run calls fn in its own routine and relays its states and logs until fn returns.
+// Once stopC is closed fn has the stop timeout to return, true is returned when it was abandoned.
+// A panic in fn is raised again in the calling routine.
+func (g *stopGuard) run(stopC <-chan struct{}, stateC chan<- StateUpdate, logC chan<- DaemonLog, fn func()) bool {
+	doneC := make(chan struct{})
+	panicC := make(chan any, 1)
+
+	go func() {
+		defer close(doneC)
+		defer func() {
+			if r := recover(); r != nil {
+				// the stack is captured here, it is lost once the panic is raised again.
+				panicC <- newPanicError("", r)
+			}
+		}()
+		fn()
+	}()
+
+	var timeoutC <-chan time.Time
+	for {
+		select {
+		case update := <-g.stateC:
+			stateC <- update
+		case entry := <-g.logC:
+			logC <- entry
+		case <-doneC:
+			select {
+			case r := <-panicC:
+				panic(r)
+			default:
+				return false
+			}
+		case <-stopC:
+			// the service was told to stop, start counting down its stop timeout.
+			stopC = nil
+			timeout := g.stopTimeout()
+			if timeout <= 0 {
+				continue
+			}
+			timer := time.NewTimer(timeout)
+			defer timer.Stop()
+			timeoutC = timer.C
+		case <-timeoutC:
+			return true
+		}
+	}
+}
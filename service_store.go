@@ -0,0 +1,57 @@
+package rxd
+
+import "sync"
+
+// serviceStore holds the values a service keeps between its lifecycles, see ServiceContext.Set.
+// It is shared by the service context and all of its children, and cleared once the service exits.
+type serviceStore struct {
+	mu     sync.RWMutex
+	values map[string]any
+}
+
+func (s *serviceStore) set(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.values == nil {
+		s.values = make(map[string]any)
+	}
+	s.values[key] = value
+}
+
+func (s *serviceStore) get(key string) (any, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.values[key]
+	return value, ok
+}
+
+func (s *serviceStore) delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, key)
+}
+
+// clear drops every value, called once the manager of the service has returned.
+func (s *serviceStore) clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values = nil
+}
+
+// Set stores value under key for the service, so its lifecycles can pass data along, e.g. a connection opened
+// in Init and used in Run, without keeping it in the runner. Values survive the transitions of the service and
+// are visible to every child of its context, they are dropped once the service exits, without being closed.
+// Set is safe for concurrent use.
+func (sc *serviceContext) Set(key string, value any) {
+	sc.store.set(key, value)
+}
+
+// Get returns the value stored under key with Set, ok is false when there is none.
+func (sc *serviceContext) Get(key string) (value any, ok bool) {
+	return sc.store.get(key)
+}
+
+// Delete removes the value stored under key, if any.
+func (sc *serviceContext) Delete(key string) {
+	sc.store.delete(key)
+}
@@ -0,0 +1,73 @@
+package rxd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// storingService counts its inits in the store of its context and reads the count back in Run.
+type storingService struct {
+	recordingService
+	sctx  ServiceContext
+	runsC chan int
+}
+
+func (s *storingService) Init(sctx ServiceContext) error {
+	inits, _ := sctx.Get("inits")
+	count, _ := inits.(int)
+	sctx.Set("inits", count+1)
+	sctx.Set("scratch", "init only")
+	s.sctx = sctx
+	return nil
+}
+
+func (s *storingService) Run(sctx ServiceContext) error {
+	child, cancel := sctx.WithName("worker")
+	defer cancel()
+	child.Delete("scratch")
+	if _, ok := sctx.Get("scratch"); ok {
+		s.runsC <- -1
+		return nil
+	}
+
+	inits, _ := child.Get("inits")
+	s.runsC <- inits.(int)
+	return nil
+}
+
+func TestServiceContext_Store(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	runner := &storingService{recordingService: recordingService{name: "storing", journal: &recordingJournal{}}, runsC: make(chan int, 10)}
+	d := NewDaemon("test-daemon", WithServiceLogger(log.NewLogger(log.LevelError, newTestLogger())))
+	manager := RunContinuousManager{StartupDelay: time.Millisecond, DefaultDelay: time.Millisecond, StateTimeouts: ManagerStateTimeouts{}}
+	if err := d.AddService(NewService("storing", runner, WithManager(manager))); err != nil {
+		t.Fatalf("error adding service: %s", err)
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+
+	// every lifecycle runs again from Init, the count kept in the store grows across them.
+	for want := 1; want <= 3; want++ {
+		if got := <-runner.runsC; got != want {
+			t.Fatalf("expected run %d to see %d inits, got %d", want, want, got)
+		}
+	}
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+
+	for _, key := range []string{"inits", "scratch"} {
+		if value, ok := runner.sctx.Get(key); ok {
+			t.Errorf("expected the store to be cleared once the service exited, got %s=%v", key, value)
+		}
+	}
+}
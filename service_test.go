@@ -202,3 +202,73 @@ func (m *mockPanicService) Stop(sctx ServiceContext) error {
 		return nil
 	}
 }
+
+// recordingService records its lifecycle calls in a shared journal so tests can assert ordering.
+type recordingService struct {
+	name    string
+	journal *recordingJournal
+}
+
+type recordingJournal struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (j *recordingJournal) record(entry string) {
+	j.mu.Lock()
+	j.entries = append(j.entries, entry)
+	j.mu.Unlock()
+}
+
+func (j *recordingJournal) index(entry string) int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for i, e := range j.entries {
+		if e == entry {
+			return i
+		}
+	}
+	return -1
+}
+
+func (j *recordingJournal) count(entry string) int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var n int
+	for _, e := range j.entries {
+		if e == entry {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *recordingService) Init(sctx ServiceContext) error {
+	r.journal.record(r.name + ":init")
+	return nil
+}
+
+func (r *recordingService) Idle(sctx ServiceContext) error {
+	return nil
+}
+
+func (r *recordingService) Run(sctx ServiceContext) error {
+	r.journal.record(r.name + ":run")
+	<-sctx.Done()
+	return nil
+}
+
+func (r *recordingService) Stop(sctx ServiceContext) error {
+	r.journal.record(r.name + ":stop")
+	return nil
+}
+
+// wedgedService never returns from Stop.
+type wedgedService struct {
+	recordingService
+}
+
+func (w *wedgedService) Stop(sctx ServiceContext) error {
+	w.journal.record(w.name + ":stop")
+	select {}
+}
@@ -0,0 +1,124 @@
+package rxd
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ThrottleStats reflects how often a service has been throttled by its cpu share.
+type ThrottleStats struct {
+	Share     float64       // fraction of wall time the service is allowed to spend working.
+	Throttled uint64        // number of checkpoints that resulted in the service being paused.
+	Delayed   time.Duration // total amount of time the service has spent paused.
+}
+
+// cpuThrottle is a token bucket measured in units of work time.
+// Work time is the cpu time the process spends while the service is between two checkpoints, at most the
+// wall time between them, so time the service spends waiting is not counted while the process is idle.
+// Where the cpu time of the process cannot be read the wall time between checkpoints is counted.
+// Tokens refill at the rate of the share over wall time and the service is paused when the bucket runs dry.
+type cpuThrottle struct {
+	share     float64
+	burst     time.Duration
+	tokens    time.Duration
+	last      time.Time
+	work      time.Duration // work clock at the last checkpoint.
+	throttled atomic.Uint64
+	delayed   atomic.Int64
+	mu        sync.Mutex
+
+	now       func() time.Time
+	workClock func() (work time.Duration, measured bool)
+}
+
+func newCPUThrottle(share float64, burst time.Duration) *cpuThrottle {
+	if share <= 0 || share > 1 {
+		share = 1
+	}
+
+	if burst <= 0 {
+		burst = 100 * time.Millisecond
+	}
+
+	return &cpuThrottle{
+		share:     share,
+		burst:     burst,
+		tokens:    burst,
+		mu:        sync.Mutex{},
+		now:       time.Now,
+		workClock: processWorkClock,
+	}
+}
+
+// checkpoint consumes the work time since the last checkpoint and blocks
+// until enough tokens have been refilled or the context is done.
+func (t *cpuThrottle) checkpoint(ctx context.Context) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	now := t.now()
+	work, measured := t.workClock()
+	if t.last.IsZero() {
+		// first checkpoint only marks the beginning of the work period.
+		t.last, t.work = now, work
+		t.mu.Unlock()
+		return
+	}
+
+	elapsed := now.Sub(t.last)
+	// without a work clock everything between checkpoints is work.
+	busy := elapsed
+	if measured {
+		busy = min(max(work-t.work, 0), elapsed)
+	}
+	t.tokens += time.Duration(float64(elapsed)*t.share) - busy
+	if t.tokens > t.burst {
+		t.tokens = t.burst
+	}
+	t.last, t.work = now, work
+
+	var wait time.Duration
+	if t.tokens < 0 {
+		// while paused no work is done, so the debt is repaid at the rate of the share.
+		wait = time.Duration(float64(-t.tokens) / t.share)
+	}
+	t.mu.Unlock()
+
+	if wait == 0 {
+		return
+	}
+
+	t.throttled.Add(1)
+	timer := time.NewTimer(wait)
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+	timer.Stop()
+
+	t.mu.Lock()
+	resumed := t.now()
+	paused := resumed.Sub(now)
+	t.tokens += time.Duration(float64(paused) * t.share)
+	if t.tokens > 0 {
+		t.tokens = 0
+	}
+	// the work period starts over once the caller resumes.
+	t.last = resumed
+	t.work, _ = t.workClock()
+	t.mu.Unlock()
+
+	t.delayed.Add(int64(paused))
+}
+
+func (t *cpuThrottle) stats() ThrottleStats {
+	return ThrottleStats{
+		Share:     t.share,
+		Throttled: t.throttled.Load(),
+		Delayed:   time.Duration(t.delayed.Load()),
+	}
+}
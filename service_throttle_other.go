@@ -0,0 +1,10 @@
+//go:build !unix && !windows
+
+package rxd
+
+import "time"
+
+// processWorkClock is not supported on this platform, the wall time between checkpoints is counted as work.
+func processWorkClock() (work time.Duration, measured bool) {
+	return 0, false
+}
@@ -0,0 +1,119 @@
+package rxd
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeWork drives a throttle from a work clock set by the test and a wall clock the test moves ahead,
+// so the tests do not depend on how much cpu time the test routine is given.
+type fakeWork struct {
+	offset time.Duration // added to the wall clock.
+	work   time.Duration
+}
+
+func newFakeWork(throttle *cpuThrottle) *fakeWork {
+	f := &fakeWork{}
+	throttle.now = func() time.Time { return time.Now().Add(f.offset) }
+	throttle.workClock = func() (time.Duration, bool) { return f.work, true }
+	return f
+}
+
+// advance moves the clocks ahead by elapsed, of which busy was spent working.
+func (f *fakeWork) advance(elapsed, busy time.Duration) {
+	f.offset += elapsed
+	f.work += busy
+}
+
+func TestCPUThrottle_Checkpoint(t *testing.T) {
+	throttle := newCPUThrottle(0.5, time.Millisecond)
+	clock := newFakeWork(throttle)
+	ctx := context.Background()
+
+	throttle.checkpoint(ctx)
+	// 40ms of work, a 50% share should pause the service for roughly that long.
+	clock.advance(40*time.Millisecond, 40*time.Millisecond)
+
+	start := time.Now()
+	throttle.checkpoint(ctx)
+	if paused := time.Since(start); paused < 30*time.Millisecond {
+		t.Fatalf("expected checkpoint to pause the service, paused for %s", paused)
+	}
+
+	stats := throttle.stats()
+	if stats.Throttled != 1 {
+		t.Fatalf("expected 1 throttled checkpoint, got %d", stats.Throttled)
+	}
+
+	if stats.Delayed <= 0 {
+		t.Fatalf("expected delayed duration to be recorded, got %s", stats.Delayed)
+	}
+}
+
+func TestCPUThrottle_CheckpointIdle(t *testing.T) {
+	throttle := newCPUThrottle(0.5, time.Millisecond)
+	clock := newFakeWork(throttle)
+	ctx := context.Background()
+
+	throttle.checkpoint(ctx)
+	// waiting between checkpoints is not work, the service should not be paused.
+	clock.advance(40*time.Millisecond, 0)
+
+	start := time.Now()
+	throttle.checkpoint(ctx)
+	if paused := time.Since(start); paused > 5*time.Millisecond {
+		t.Fatalf("expected an idle service not to be paused, paused for %s", paused)
+	}
+	if stats := throttle.stats(); stats.Throttled != 0 {
+		t.Fatalf("expected no throttled checkpoint, got %d", stats.Throttled)
+	}
+}
+
+func TestCPUThrottle_CheckpointWorkCapped(t *testing.T) {
+	throttle := newCPUThrottle(0.5, time.Millisecond)
+	clock := newFakeWork(throttle)
+	ctx := context.Background()
+
+	throttle.checkpoint(ctx)
+	// the process worked on several cores meanwhile, the service is charged at most the wall time.
+	clock.advance(10*time.Millisecond, 100*time.Millisecond)
+
+	start := time.Now()
+	throttle.checkpoint(ctx)
+	if paused := time.Since(start); paused > 50*time.Millisecond {
+		t.Fatalf("expected the service to be charged at most the wall time, paused for %s", paused)
+	}
+}
+
+func TestCPUThrottle_CheckpointCancelled(t *testing.T) {
+	throttle := newCPUThrottle(0.01, time.Millisecond)
+	clock := newFakeWork(throttle)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	throttle.checkpoint(ctx)
+	clock.advance(10*time.Millisecond, 10*time.Millisecond)
+	cancel()
+
+	start := time.Now()
+	throttle.checkpoint(ctx)
+	if paused := time.Since(start); paused > 100*time.Millisecond {
+		t.Fatalf("expected checkpoint to return once the context is done, paused for %s", paused)
+	}
+}
+
+func TestProcessWorkClock(t *testing.T) {
+	before, measured := processWorkClock()
+	if !measured {
+		t.Skip("the cpu time of the process is not available on this platform")
+	}
+
+	// spin until the process used 5ms of cpu time, however long that takes under load.
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if work, _ := processWorkClock(); work-before >= 5*time.Millisecond {
+			return
+		}
+	}
+	t.Fatalf("expected the work clock to advance while the process works")
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package rxd
+
+import (
+	"syscall"
+	"time"
+)
+
+// processWorkClock returns the cpu time consumed by the process.
+func processWorkClock() (work time.Duration, measured bool) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, false
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano()), true
+}
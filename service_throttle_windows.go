@@ -0,0 +1,23 @@
+//go:build windows
+
+package rxd
+
+import (
+	"syscall"
+	"time"
+)
+
+// processWorkClock returns the cpu time consumed by the process.
+func processWorkClock() (work time.Duration, measured bool) {
+	handle, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return 0, false
+	}
+	var creation, exit, kernel, user syscall.Filetime
+	if err := syscall.GetProcessTimes(handle, &creation, &exit, &kernel, &user); err != nil {
+		return 0, false
+	}
+	// the times are counted in units of 100ns.
+	ticks := func(ft syscall.Filetime) int64 { return int64(ft.HighDateTime)<<32 | int64(ft.LowDateTime) }
+	return time.Duration((ticks(kernel) + ticks(user)) * 100), true
+}
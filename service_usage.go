@@ -0,0 +1,191 @@
+package rxd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"runtime/metrics"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ServiceUsage is the approximate resource usage of a service, see Usage.
+// Goroutines are counted exactly, the CPU time and allocations are the process wide usage sampled around the
+// lifecycles of the service, including the work of other services running at the same time. Compared between
+// services and over time they still point at the service behind a leak.
+type ServiceUsage struct {
+	Goroutines  int           `json:"goroutines"`   // goroutines started by the service routine, including its own.
+	Lifecycles  uint64        `json:"lifecycles"`   // lifecycles the service entered.
+	BusyTime    time.Duration `json:"busy_time"`    // wall time spent in lifecycles.
+	CPUTime     time.Duration `json:"cpu_time"`     // process cpu time spent while the lifecycles ran.
+	AllocBytes  uint64        `json:"alloc_bytes"`  // bytes allocated on the heap by the process while the lifecycles ran.
+	FDs         int64         `json:"fds"`          // file descriptors accounted with AccountResources.
+	MemoryBytes int64         `json:"memory_bytes"` // bytes accounted with AccountResources.
+}
+
+// usageSample is a reading of the process wide usage.
+type usageSample struct {
+	at     time.Time
+	cpu    float64 // user cpu seconds.
+	allocs uint64  // cumulative heap allocated bytes.
+}
+
+func sampleUsage() usageSample {
+	samples := []metrics.Sample{
+		{Name: "/cpu/classes/user:cpu-seconds"},
+		{Name: "/gc/heap/allocs:bytes"},
+	}
+	metrics.Read(samples)
+
+	s := usageSample{at: time.Now()}
+	if samples[0].Value.Kind() == metrics.KindFloat64 {
+		s.cpu = samples[0].Value.Float64()
+	}
+	if samples[1].Value.Kind() == metrics.KindUint64 {
+		s.allocs = samples[1].Value.Uint64()
+	}
+	return s
+}
+
+// usageMeter accumulates the usage sampled around the lifecycles of a service.
+type usageMeter struct {
+	mu         sync.Mutex
+	lifecycles uint64
+	busy       time.Duration
+	cpu        float64
+	allocs     uint64
+	current    *usageSample // taken when the lifecycle in progress started, nil between lifecycles.
+}
+
+// measure samples the usage around lifecycle.
+func (m *usageMeter) measure(lifecycle func() error) error {
+	start := sampleUsage()
+	m.mu.Lock()
+	m.lifecycles++
+	m.current = &start
+	m.mu.Unlock()
+
+	defer func() {
+		end := sampleUsage()
+		m.mu.Lock()
+		m.addLocked(start, end)
+		m.current = nil
+		m.mu.Unlock()
+	}()
+	return lifecycle()
+}
+
+func (m *usageMeter) addLocked(start, end usageSample) {
+	m.busy += end.at.Sub(start.at)
+	m.cpu += max(end.cpu-start.cpu, 0)
+	if end.allocs > start.allocs {
+		m.allocs += end.allocs - start.allocs
+	}
+}
+
+// usage returns the usage so far, including the lifecycle in progress such as a Run that never returns.
+func (m *usageMeter) usage(now usageSample) ServiceUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	busy, cpu, allocs := m.busy, m.cpu, m.allocs
+	if m.current != nil {
+		busy += now.at.Sub(m.current.at)
+		cpu += max(now.cpu-m.current.cpu, 0)
+		if now.allocs > m.current.allocs {
+			allocs += now.allocs - m.current.allocs
+		}
+	}
+	return ServiceUsage{
+		Lifecycles: m.lifecycles,
+		BusyTime:   busy,
+		CPUTime:    time.Duration(cpu * float64(time.Second)),
+		AllocBytes: allocs,
+	}
+}
+
+// meteredRunner samples the usage around every lifecycle of the runner.
+type meteredRunner struct {
+	runner ServiceRunner
+	meter  *usageMeter
+}
+
+func (r meteredRunner) Init(sctx ServiceContext) error {
+	return r.meter.measure(func() error { return r.runner.Init(sctx) })
+}
+
+func (r meteredRunner) Idle(sctx ServiceContext) error {
+	return r.meter.measure(func() error { return r.runner.Idle(sctx) })
+}
+
+func (r meteredRunner) Run(sctx ServiceContext) error {
+	return r.meter.measure(func() error { return r.runner.Run(sctx) })
+}
+
+func (r meteredRunner) Stop(sctx ServiceContext) error {
+	return r.meter.measure(func() error { return r.runner.Stop(sctx) })
+}
+
+func (r meteredRunner) Reload(sctx ServiceContext) error {
+	return r.meter.measure(func() error { return reloadRunner(sctx, r.runner) })
+}
+
+// serviceGoroutines counts the goroutines per service from the goroutine profile, goroutines inherit the labels
+// of the goroutine starting them so every goroutine started by a service routine carries the service label.
+func serviceGoroutines() map[string]int {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	var n int
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if count, _, ok := strings.Cut(line, " @ "); ok {
+			// a stack shared by count goroutines, followed by their labels if they have any.
+			n, _ = strconv.Atoi(count)
+			continue
+		}
+
+		encoded, ok := strings.CutPrefix(line, "# labels: ")
+		if !ok {
+			continue
+		}
+		var labels map[string]string
+		if err := json.Unmarshal([]byte(encoded), &labels); err != nil {
+			continue
+		}
+		if name, ok := labels[labelService]; ok {
+			counts[name] += n
+		}
+	}
+	return counts
+}
+
+// Usage returns the approximate resource usage of every service, see ServiceUsage.
+func (d *daemon) Usage() map[string]ServiceUsage {
+	goroutines := serviceGoroutines()
+	now := sampleUsage()
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	usage := make(map[string]ServiceUsage, len(d.configs))
+	for name, conf := range d.configs {
+		var u ServiceUsage
+		if conf.usage != nil {
+			u = conf.usage.usage(now)
+		}
+		u.Goroutines = goroutines[name]
+		if conf.resources != nil {
+			u.FDs, u.MemoryBytes = conf.resources.fds.Load(), conf.resources.bytes.Load()
+		}
+		usage[name] = u
+	}
+	return usage
+}
@@ -0,0 +1,103 @@
+package rxd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime/pprof"
+	"testing"
+	"time"
+
+	"github.com/ambitiousfew/rxd/log"
+)
+
+// spawningService starts workers of its own from Run, which stop along with the service.
+type spawningService struct {
+	recordingService
+	workers int
+	buf     []byte
+}
+
+func (s *spawningService) Init(sctx ServiceContext) error {
+	s.buf = make([]byte, 1<<20)
+	return nil
+}
+
+func (s *spawningService) Run(sctx ServiceContext) error {
+	for i := 0; i < s.workers; i++ {
+		go func() {
+			<-sctx.Done()
+		}()
+	}
+	return s.recordingService.Run(sctx)
+}
+
+func TestDaemon_Usage(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	journal := &recordingJournal{}
+	d := NewDaemon("test-daemon", WithServiceLogger(log.NewLogger(log.LevelError, newTestLogger()))).(*daemon)
+	services := []Service{
+		NewService("spawning", &spawningService{recordingService: recordingService{name: "spawning", journal: journal}, workers: 5}),
+		NewService("tidy", &recordingService{name: "tidy", journal: journal}),
+	}
+	if err := d.AddServices(services...); err != nil {
+		t.Fatalf("error adding services: %s", err)
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- d.Start(ctx)
+	}()
+	for journal.index("spawning:run") < 0 || journal.index("tidy:run") < 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	usage := d.Usage()
+	for usage["spawning"].Goroutines < usage["tidy"].Goroutines+5 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the workers of spawning to be attributed to it, got %+v", usage)
+		}
+		time.Sleep(10 * time.Millisecond)
+		usage = d.Usage()
+	}
+	if spawning := usage["spawning"]; spawning.Lifecycles < 3 || spawning.AllocBytes < 1<<20 || spawning.BusyTime <= 0 {
+		t.Errorf("expected the lifecycles of spawning to be sampled, including its Run in progress, got %+v", spawning)
+	}
+
+	rec := httptest.NewRecorder()
+	d.serveUsage(rec, httptest.NewRequest(http.MethodGet, "/usage", nil))
+	var served map[string]ServiceUsage
+	if err := json.Unmarshal(rec.Body.Bytes(), &served); err != nil || served["spawning"].Goroutines == 0 {
+		t.Errorf("expected the usage to be served, got %s (%v)", rec.Body.String(), err)
+	}
+
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("error starting daemon: %s", err)
+	}
+}
+
+func TestServiceGoroutines(t *testing.T) {
+	doneC := make(chan struct{})
+	defer close(doneC)
+
+	pprof.Do(context.Background(), pprof.Labels(labelService, "metered"), func(ctx context.Context) {
+		for i := 0; i < 3; i++ {
+			go func() {
+				<-doneC
+			}()
+		}
+	})
+
+	deadline := time.Now().Add(time.Second)
+	for serviceGoroutines()["metered"] != 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 3 goroutines labeled metered, got %d", serviceGoroutines()["metered"])
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}